@@ -14,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/kms"
 	kmsTypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -156,7 +157,9 @@ func loadAWSConfig(cfg AWSConfig) (aws.Config, error) {
 
 // UploadToS3FromReader uploads data from an io.Reader to an S3 bucket.
 // This method supports streaming uploads for large files and multipart data.
-// The contentLength parameter is optional; pass -1 if unknown (AWS SDK will buffer).
+// The contentLength parameter is optional; pass -1 if unknown. S3 requires the length of
+// every request body, so readers of unknown length that cannot seek are streamed in parts
+// through the multipart upload manager.
 func (a *AWSManager) UploadToS3FromReader(ctx context.Context, bucket, key string, reader io.Reader, contentLength int64, contentType string, metadata map[string]string) (*s3.PutObjectOutput, error) {
 	input := &s3.PutObjectInput{
 		Bucket:      aws.String(bucket),
@@ -178,6 +181,26 @@ func (a *AWSManager) UploadToS3FromReader(ctx context.Context, bucket, key strin
 		input.Metadata = awsMetadata
 	}
 
+	if _, seekable := reader.(io.Seeker); contentLength < 0 && !seekable {
+		uploaded, err := manager.NewUploader(a.s3Client).Upload(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload to S3: %w", err)
+		}
+		return &s3.PutObjectOutput{
+			ETag:                 uploaded.ETag,
+			VersionId:            uploaded.VersionID,
+			ChecksumCRC32:        uploaded.ChecksumCRC32,
+			ChecksumCRC32C:       uploaded.ChecksumCRC32C,
+			ChecksumSHA1:         uploaded.ChecksumSHA1,
+			ChecksumSHA256:       uploaded.ChecksumSHA256,
+			Expiration:           uploaded.Expiration,
+			ServerSideEncryption: uploaded.ServerSideEncryption,
+			SSEKMSKeyId:          uploaded.SSEKMSKeyId,
+			BucketKeyEnabled:     aws.Bool(uploaded.BucketKeyEnabled),
+			RequestCharged:       uploaded.RequestCharged,
+		}, nil
+	}
+
 	result, err := a.s3Client.PutObject(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload to S3: %w", err)
@@ -285,6 +308,36 @@ func (a *AWSManager) CreateS3PresignedPutURL(ctx context.Context, bucket, key, c
 	return request.URL, nil
 }
 
+// CreateS3PresignedPostURL creates a presigned POST policy for uploading to S3.
+// The returned fields must be sent as form data along with the file. A positive maxSize
+// limits the upload size through a content-length-range condition.
+func (a *AWSManager) CreateS3PresignedPostURL(ctx context.Context, bucket, key, contentType string, maxSize int64, expiration time.Duration) (string, map[string]string, error) {
+	presignClient := s3.NewPresignClient(a.s3Client)
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	var conditions []any
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+		conditions = append(conditions, map[string]string{"Content-Type": contentType})
+	}
+	if maxSize > 0 {
+		conditions = append(conditions, []any{"content-length-range", 0, maxSize})
+	}
+
+	request, err := presignClient.PresignPostObject(ctx, input, func(opts *s3.PresignPostOptions) {
+		opts.Expires = expiration
+		opts.Conditions = conditions
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create presigned post URL: %w", err)
+	}
+
+	return request.URL, request.Values, nil
+}
+
 // KMS Operations
 
 // EncryptWithKMS encrypts data using KMS
@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 stores the objects PUT to it and, like S3, rejects bodies of unknown length.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+	if r.ContentLength < 0 {
+		w.WriteHeader(http.StatusLengthRequired)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.objects[r.URL.Path] = string(body)
+	f.mu.Unlock()
+	w.Header().Set("ETag", `"etag"`)
+}
+
+func TestUploadToS3FromReaderUnknownLength(t *testing.T) {
+	s3 := &fakeS3{objects: make(map[string]string)}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	m, err := NewAWSManager(AWSConfig{
+		Region:           "ap-south-1",
+		AccessKeyID:      "key",
+		SecretAccessKey:  "secret",
+		Endpoint:         server.URL,
+		S3ForcePathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// io.MultiReader hides the Seeker of the strings.Reader, like a multipart file part.
+	body := io.MultiReader(strings.NewReader("hello, "), strings.NewReader("world"))
+	if _, err := m.UploadToS3FromReader(context.Background(), "bucket", "greeting.txt", body, -1, "text/plain", nil); err != nil {
+		t.Fatalf("UploadToS3FromReader: %v", err)
+	}
+	if got := s3.objects["/bucket/greeting.txt"]; got != "hello, world" {
+		t.Fatalf("stored %q, want %q", got, "hello, world")
+	}
+}
@@ -0,0 +1,281 @@
+// Package batch runs long jobs such as backfills and reconciliations over chunked sources,
+// persisting a checkpoint after every chunk so a crashed or redeployed job resumes where it
+// stopped instead of starting over.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultChunkSize is the number of items fetched and processed per chunk.
+	DefaultChunkSize = 500
+	// DefaultChunkRetries is how many times a failing chunk is retried before the job stops.
+	DefaultChunkRetries = 3
+)
+
+// ProcessFunc handles one chunk. It must be idempotent: a chunk interrupted by a crash is
+// processed again on resume.
+type ProcessFunc[T any] func(ctx context.Context, chunk []T) error
+
+// Progress is reported after each chunk.
+type Progress struct {
+	JobID     string
+	Processed int64
+	Chunks    int64
+	Cursor    string
+	Elapsed   time.Duration
+	Completed bool
+}
+
+// config holds the settings shared by all job types.
+type config struct {
+	chunkSize   int
+	retries     int
+	retryDelay  time.Duration
+	checkpoints CheckpointStore
+	limiter     *rate.Limiter
+	metrics     *Metrics
+	logger      *log.Log
+	onProgress  func(Progress)
+	restart     bool
+}
+
+// Option configures a Job.
+type Option func(*config)
+
+// WithChunkSize sets how many items are fetched and processed at a time.
+func WithChunkSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.chunkSize = size
+		}
+	}
+}
+
+// WithChunkRetries sets how many times a failing chunk is retried, waiting delay between attempts.
+func WithChunkRetries(retries int, delay time.Duration) Option {
+	return func(c *config) {
+		if retries >= 0 {
+			c.retries = retries
+		}
+		c.retryDelay = delay
+	}
+}
+
+// WithCheckpointStore sets where progress is persisted. Without it progress is kept in memory.
+func WithCheckpointStore(store CheckpointStore) Option {
+	return func(c *config) {
+		c.checkpoints = store
+	}
+}
+
+// WithRateLimit caps throughput at itemsPerSecond, protecting the source and downstream systems.
+func WithRateLimit(itemsPerSecond float64) Option {
+	return func(c *config) {
+		if itemsPerSecond > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(itemsPerSecond), 1)
+		}
+	}
+}
+
+// WithMetrics records progress in the given Prometheus collectors.
+func WithMetrics(metrics *Metrics) Option {
+	return func(c *config) {
+		c.metrics = metrics
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithProgress registers a callback invoked after every checkpoint.
+func WithProgress(fn func(Progress)) Option {
+	return func(c *config) {
+		c.onProgress = fn
+	}
+}
+
+// WithRestart ignores any existing checkpoint and starts from the beginning.
+func WithRestart() Option {
+	return func(c *config) {
+		c.restart = true
+	}
+}
+
+// Job iterates a Source in chunks and hands each chunk to a ProcessFunc.
+type Job[T any] struct {
+	id      string
+	source  Source[T]
+	process ProcessFunc[T]
+	cfg     config
+}
+
+// NewJob creates a job. id identifies the checkpoint, so it must be stable across runs.
+func NewJob[T any](id string, source Source[T], process ProcessFunc[T], options ...Option) (*Job[T], error) {
+	if helpers.IsEmpty(id) || source == nil || process == nil {
+		return nil, errors.New("batch: id, source and process are required")
+	}
+	cfg := config{
+		chunkSize:  DefaultChunkSize,
+		retries:    DefaultChunkRetries,
+		retryDelay: time.Second,
+	}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	if cfg.checkpoints == nil {
+		cfg.checkpoints = NewMemoryCheckpointStore()
+	}
+	if cfg.logger == nil {
+		cfg.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	if cfg.limiter != nil {
+		// Allow a whole chunk to be admitted at once.
+		cfg.limiter.SetBurst(cfg.chunkSize)
+	}
+	return &Job[T]{id: id, source: source, process: process, cfg: cfg}, nil
+}
+
+// Run processes the source from the last checkpoint until it is exhausted, ctx is cancelled
+// or a chunk keeps failing. A job whose checkpoint is already complete returns immediately
+// unless WithRestart is set.
+func (j *Job[T]) Run(ctx context.Context) (Progress, error) {
+	cp, err := j.loadCheckpoint(ctx)
+	if err != nil {
+		return Progress{JobID: j.id}, err
+	}
+	if cp.Completed {
+		j.cfg.logger.Info("Batch job already completed", log.String("job", j.id), log.Any("processed", cp.Processed))
+		return j.progress(cp), nil
+	}
+	if cp.Cursor != "" {
+		j.cfg.logger.Info("Resuming batch job", log.String("job", j.id), log.String("cursor", cp.Cursor), log.Any("processed", cp.Processed))
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return j.progress(cp), err
+		}
+
+		items, next, err := j.source.Next(ctx, cp.Cursor, j.cfg.chunkSize)
+		if err != nil {
+			return j.progress(cp), fmt.Errorf("batch: failed to fetch chunk after cursor %q: %w", cp.Cursor, err)
+		}
+
+		if len(items) > 0 {
+			if j.cfg.limiter != nil {
+				if err := j.cfg.limiter.WaitN(ctx, min(len(items), j.cfg.chunkSize)); err != nil {
+					return j.progress(cp), err
+				}
+			}
+			if err := j.processChunk(ctx, items); err != nil {
+				return j.progress(cp), err
+			}
+		}
+
+		cp.Cursor = next
+		cp.Processed += int64(len(items))
+		cp.Chunks++
+		cp.Completed = next == ""
+		if err := j.saveCheckpoint(ctx, cp); err != nil {
+			return j.progress(cp), err
+		}
+		if j.cfg.metrics != nil {
+			j.cfg.metrics.itemsProcessed.WithLabelValues(j.id).Add(float64(len(items)))
+		}
+		if j.cfg.onProgress != nil {
+			j.cfg.onProgress(j.progress(cp))
+		}
+
+		if cp.Completed {
+			j.cfg.logger.Info("Batch job completed", log.String("job", j.id), log.Any("processed", cp.Processed), log.Any("chunks", cp.Chunks))
+			return j.progress(cp), nil
+		}
+	}
+}
+
+// processChunk runs the process function with retries.
+func (j *Job[T]) processChunk(ctx context.Context, items []T) error {
+	var err error
+	for attempt := 0; attempt <= j.cfg.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(j.cfg.retryDelay):
+			}
+		}
+
+		start := time.Now()
+		err = j.process(ctx, items)
+		if j.cfg.metrics != nil {
+			j.cfg.metrics.chunkDuration.WithLabelValues(j.id).Observe(time.Since(start).Seconds())
+		}
+		if err == nil {
+			return nil
+		}
+		if j.cfg.metrics != nil {
+			j.cfg.metrics.chunksFailed.WithLabelValues(j.id).Inc()
+		}
+		j.cfg.logger.Warn("Batch chunk failed", log.String("job", j.id), log.Any("attempt", attempt+1), log.Err(err))
+	}
+	return fmt.Errorf("batch: chunk failed after %d attempts: %w", j.cfg.retries+1, err)
+}
+
+// loadCheckpoint returns the stored checkpoint or a fresh one.
+func (j *Job[T]) loadCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	if !j.cfg.restart {
+		cp, err := j.cfg.checkpoints.Load(ctx, j.id)
+		if err != nil {
+			return nil, fmt.Errorf("batch: failed to load checkpoint: %w", err)
+		}
+		if cp != nil {
+			return cp, nil
+		}
+	}
+	now := time.Now()
+	return &Checkpoint{JobID: j.id, StartedAt: now, UpdatedAt: now}, nil
+}
+
+// saveCheckpoint persists cp, using a detached context so progress is kept on cancellation.
+func (j *Job[T]) saveCheckpoint(ctx context.Context, cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now()
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := j.cfg.checkpoints.Save(saveCtx, cp); err != nil {
+		return fmt.Errorf("batch: failed to save checkpoint: %w", err)
+	}
+	if j.cfg.metrics != nil {
+		j.cfg.metrics.lastCheckpoint.WithLabelValues(j.id).Set(float64(cp.UpdatedAt.Unix()))
+	}
+	return nil
+}
+
+// progress converts a checkpoint to a Progress report.
+func (j *Job[T]) progress(cp *Checkpoint) Progress {
+	return Progress{
+		JobID:     cp.JobID,
+		Processed: cp.Processed,
+		Chunks:    cp.Chunks,
+		Cursor:    cp.Cursor,
+		Elapsed:   time.Since(cp.StartedAt),
+		Completed: cp.Completed,
+	}
+}
+
+// Reset deletes the job checkpoint so the next Run starts from the beginning.
+func (j *Job[T]) Reset(ctx context.Context) error {
+	return j.cfg.checkpoints.Delete(ctx, j.id)
+}
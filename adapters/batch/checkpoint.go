@@ -0,0 +1,168 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Checkpoint records how far a job has progressed.
+type Checkpoint struct {
+	JobID     string    `json:"job_id"`
+	Cursor    string    `json:"cursor"`
+	Processed int64     `json:"processed"`
+	Chunks    int64     `json:"chunks"`
+	Completed bool      `json:"completed"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckpointStore persists checkpoints between runs.
+type CheckpointStore interface {
+	// Load returns the checkpoint for jobID, or nil when the job has not run before.
+	Load(ctx context.Context, jobID string) (*Checkpoint, error)
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+	Delete(ctx context.Context, jobID string) error
+}
+
+// MemoryCheckpointStore keeps checkpoints in memory; progress does not survive restarts.
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+// NewMemoryCheckpointStore creates an empty MemoryCheckpointStore.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: make(map[string]Checkpoint)}
+}
+
+func (m *MemoryCheckpointStore) Load(_ context.Context, jobID string) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp, ok := m.checkpoints[jobID]
+	if !ok {
+		return nil, nil
+	}
+	return &cp, nil
+}
+
+func (m *MemoryCheckpointStore) Save(_ context.Context, checkpoint *Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[checkpoint.JobID] = *checkpoint
+	return nil
+}
+
+func (m *MemoryCheckpointStore) Delete(_ context.Context, jobID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.checkpoints, jobID)
+	return nil
+}
+
+// FileCheckpointStore writes one JSON file per job into a directory. Writes go through a
+// temporary file and rename so a crash never leaves a torn checkpoint.
+type FileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore creates the directory if needed and returns a FileCheckpointStore.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	dir = filepath.Clean(dir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("batch: failed to create checkpoint directory: %w", err)
+	}
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+func (f *FileCheckpointStore) path(jobID string) string {
+	return filepath.Join(f.dir, filepath.Base(jobID)+".json")
+}
+
+func (f *FileCheckpointStore) Load(_ context.Context, jobID string) (*Checkpoint, error) {
+	data, err := os.ReadFile(f.path(jobID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("batch: corrupt checkpoint for %s: %w", jobID, err)
+	}
+	return &cp, nil
+}
+
+func (f *FileCheckpointStore) Save(_ context.Context, checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	target := f.path(checkpoint.JobID)
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+func (f *FileCheckpointStore) Delete(_ context.Context, jobID string) error {
+	err := os.Remove(f.path(jobID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RedisCheckpointStore keeps checkpoints in Redis so any replica can resume a job.
+type RedisCheckpointStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisCheckpointStore creates a RedisCheckpointStore. prefix defaults to "neuron:batch".
+func NewRedisCheckpointStore(client redis.UniversalClient, prefix string) *RedisCheckpointStore {
+	if prefix == "" {
+		prefix = "neuron:batch"
+	}
+	return &RedisCheckpointStore{client: client, prefix: prefix}
+}
+
+func (r *RedisCheckpointStore) key(jobID string) string {
+	return r.prefix + ":checkpoint:" + jobID
+}
+
+func (r *RedisCheckpointStore) Load(ctx context.Context, jobID string) (*Checkpoint, error) {
+	data, err := r.client.Get(ctx, r.key(jobID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("batch: corrupt checkpoint for %s: %w", jobID, err)
+	}
+	return &cp, nil
+}
+
+func (r *RedisCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, r.key(checkpoint.JobID), data, 0).Err()
+}
+
+func (r *RedisCheckpointStore) Delete(ctx context.Context, jobID string) error {
+	return r.client.Del(ctx, r.key(jobID)).Err()
+}
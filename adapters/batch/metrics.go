@@ -0,0 +1,68 @@
+package batch
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus collectors shared by all jobs, labelled by job ID.
+type Metrics struct {
+	itemsProcessed *prometheus.CounterVec
+	chunksFailed   *prometheus.CounterVec
+	lastCheckpoint *prometheus.GaugeVec
+	chunkDuration  *prometheus.HistogramVec
+}
+
+// NewMetrics registers the batch collectors with registerer. Collectors that are already
+// registered are reused, so it is safe to call once per job.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		itemsProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "batch_items_processed_total",
+			Help: "Number of items processed by batch jobs.",
+		}, []string{"job"}),
+		chunksFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "batch_chunks_failed_total",
+			Help: "Number of chunk processing attempts that failed.",
+		}, []string{"job"}),
+		lastCheckpoint: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "batch_last_checkpoint_timestamp_seconds",
+			Help: "Unix time of the last saved checkpoint.",
+		}, []string{"job"}),
+		chunkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "batch_chunk_duration_seconds",
+			Help:    "Time taken to process a chunk.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"job"}),
+	}
+
+	var err error
+	if m.itemsProcessed, err = register(registerer, m.itemsProcessed); err != nil {
+		return nil, err
+	}
+	if m.chunksFailed, err = register(registerer, m.chunksFailed); err != nil {
+		return nil, err
+	}
+	if m.lastCheckpoint, err = register(registerer, m.lastCheckpoint); err != nil {
+		return nil, err
+	}
+	if m.chunkDuration, err = register(registerer, m.chunkDuration); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers c, returning the existing collector when one is already registered.
+func register[C prometheus.Collector](registerer prometheus.Registerer, c C) (C, error) {
+	if err := registerer.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
@@ -0,0 +1,120 @@
+package batch
+
+import (
+	"context"
+	"sort"
+
+	"github.com/abhissng/neuron/adapters/cloud"
+	"github.com/abhissng/neuron/database"
+)
+
+// Source yields items in chunks. cursor is the opaque position returned with the previous
+// chunk ("" for the first call). An empty next cursor marks the last chunk.
+// Cursors are persisted in checkpoints, so they must be stable across restarts.
+type Source[T any] interface {
+	Next(ctx context.Context, cursor string, limit int) (items []T, next string, err error)
+}
+
+// SourceFunc adapts a function to the Source interface.
+type SourceFunc[T any] func(ctx context.Context, cursor string, limit int) ([]T, string, error)
+
+// Next calls f.
+func (f SourceFunc[T]) Next(ctx context.Context, cursor string, limit int) ([]T, string, error) {
+	return f(ctx, cursor, limit)
+}
+
+// Querier is the subset of database.Database used by SQLSource.
+type Querier interface {
+	Query(ctx context.Context, query string, args ...any) (database.Rows, error)
+}
+
+// ScanFunc scans the current row into an item and returns the row's key, which becomes
+// the cursor once the chunk is processed.
+type ScanFunc[T any] func(rows database.Rows) (item T, key string, err error)
+
+// SQLSource iterates a table with keyset pagination. The query receives the last key and
+// the chunk size as its two arguments and must order by the key, e.g.
+//
+//	SELECT id, email FROM users WHERE id > $1 ORDER BY id LIMIT $2
+//
+// Keyset pagination keeps every chunk an index range scan, unlike OFFSET.
+type SQLSource[T any] struct {
+	db    Querier
+	query string
+	start string
+	scan  ScanFunc[T]
+}
+
+// NewSQLSource creates a SQLSource. start is the key passed on the first call,
+// e.g. "0" for numeric keys or "" for text keys.
+func NewSQLSource[T any](db Querier, query, start string, scan ScanFunc[T]) *SQLSource[T] {
+	return &SQLSource[T]{db: db, query: query, start: start, scan: scan}
+}
+
+// Next fetches the rows following cursor.
+func (s *SQLSource[T]) Next(ctx context.Context, cursor string, limit int) ([]T, string, error) {
+	if cursor == "" {
+		cursor = s.start
+	}
+	rows, err := s.db.Query(ctx, s.query, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	items := make([]T, 0, limit)
+	var last string
+	for rows.Next() {
+		item, key, err := s.scan(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+		last = key
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	// A short page is the last one.
+	if len(items) < limit {
+		return items, "", nil
+	}
+	return items, last, nil
+}
+
+// ObjectSource iterates the objects under a prefix in object storage in key order.
+// The listing is fetched once per run and cursors are object keys, so objects added
+// behind the cursor after a crash are skipped on resume.
+type ObjectSource struct {
+	cm      cloud.CloudManager
+	bucket  string
+	prefix  string
+	objects []cloud.ObjectInfo
+}
+
+// NewObjectSource creates an ObjectSource over bucket/prefix.
+func NewObjectSource(cm cloud.CloudManager, bucket, prefix string) *ObjectSource {
+	return &ObjectSource{cm: cm, bucket: bucket, prefix: prefix}
+}
+
+// Next returns the objects whose keys follow cursor.
+func (s *ObjectSource) Next(ctx context.Context, cursor string, limit int) ([]cloud.ObjectInfo, string, error) {
+	if s.objects == nil {
+		objects, err := s.cm.ListObjects(ctx, s.bucket, s.prefix)
+		if err != nil {
+			return nil, "", err
+		}
+		sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+		s.objects = objects
+	}
+
+	start := sort.Search(len(s.objects), func(i int) bool { return s.objects[i].Key > cursor })
+	end := min(start+limit, len(s.objects))
+	chunk := s.objects[start:end]
+	if end == len(s.objects) {
+		return chunk, "", nil
+	}
+	return chunk, chunk[len(chunk)-1].Key, nil
+}
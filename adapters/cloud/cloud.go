@@ -17,8 +17,9 @@ import (
 type Provider string
 
 const (
-	ProviderAWS Provider = "AWS"
-	ProviderOCI Provider = "OCI"
+	ProviderAWS    Provider = "AWS"
+	ProviderOCI    Provider = "OCI"
+	ProviderMemory Provider = "MEMORY"
 )
 
 // ErrUnsupportedProvider is returned when an invalid cloud provider is specified.
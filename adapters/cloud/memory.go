@@ -0,0 +1,178 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrObjectNotFound is returned by the memory manager for missing objects and secrets.
+var ErrObjectNotFound = errors.New("cloud: object not found")
+
+// memoryObject is an object stored by the memory manager.
+type memoryObject struct {
+	data        []byte
+	contentType string
+	metadata    map[string]string
+	info        ObjectInfo
+}
+
+// memoryManager is a CloudManager keeping objects and secrets in memory.
+type memoryManager struct {
+	mu      sync.RWMutex
+	objects map[string]map[string]*memoryObject // By bucket, then key
+	secrets map[string]string
+}
+
+// NewMemoryCloudManager creates a CloudManager keeping objects and secrets in memory, for
+// local development and tests. Presigned URLs use the memory:// scheme and only identify the
+// object.
+func NewMemoryCloudManager() CloudManager {
+	return &memoryManager{
+		objects: make(map[string]map[string]*memoryObject),
+		secrets: make(map[string]string),
+	}
+}
+
+// Provider returns ProviderMemory.
+func (m *memoryManager) Provider() Provider {
+	return ProviderMemory
+}
+
+// GetMetadata returns the metadata of the memory manager.
+func (m *memoryManager) GetMetadata() Metadata {
+	return Metadata{Provider: ProviderMemory, Region: "local"}
+}
+
+// UploadFile stores a copy of data.
+func (m *memoryManager) UploadFile(_ context.Context, bucket, key string, data []byte, contentType string, metadata map[string]string) error {
+	sum := md5.Sum(data) // #nosec G401 -- ETag, as S3
+	object := &memoryObject{
+		data:        bytes.Clone(data),
+		contentType: contentType,
+		metadata:    metadata,
+		info: ObjectInfo{
+			Key:          key,
+			Size:         int64(len(data)),
+			LastModified: time.Now(),
+			ETag:         hex.EncodeToString(sum[:]),
+		},
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.objects[bucket] == nil {
+		m.objects[bucket] = make(map[string]*memoryObject)
+	}
+	m.objects[bucket][key] = object
+	return nil
+}
+
+// UploadFileFromReader stores the content of reader.
+func (m *memoryManager) UploadFileFromReader(ctx context.Context, bucket, key string, reader io.Reader, _ int64, contentType string, metadata map[string]string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("cloud: read upload: %w", err)
+	}
+	return m.UploadFile(ctx, bucket, key, data, contentType, metadata)
+}
+
+// DownloadFile returns a copy of the object.
+func (m *memoryManager) DownloadFile(_ context.Context, bucket, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	object, ok := m.objects[bucket][key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s", ErrObjectNotFound, bucket, key)
+	}
+	return bytes.Clone(object.data), nil
+}
+
+// ListObjects lists the objects of bucket starting with prefix, sorted by key.
+func (m *memoryManager) ListObjects(_ context.Context, bucket, prefix string) ([]ObjectInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var objects []ObjectInfo
+	for key, object := range m.objects[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, object.info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// DeleteObject deletes the object; deleting a missing object is not an error, as with S3.
+func (m *memoryManager) DeleteObject(_ context.Context, bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects[bucket], key)
+	return nil
+}
+
+// GetPresignedURL returns the memory:// URL of the object.
+func (m *memoryManager) GetPresignedURL(_ context.Context, bucket, key string, expiration time.Duration) (string, error) {
+	return memoryURL(bucket, key, expiration), nil
+}
+
+// GetPresignedUploadURL returns the memory:// URL of the object.
+func (m *memoryManager) GetPresignedUploadURL(_ context.Context, bucket, key, _ string, expiration time.Duration) (string, error) {
+	return memoryURL(bucket, key, expiration), nil
+}
+
+// memoryURL returns the memory:// URL of an object expiring after expiration.
+func memoryURL(bucket, key string, expiration time.Duration) string {
+	u := url.URL{Scheme: "memory", Host: bucket, Path: "/" + key}
+	u.RawQuery = url.Values{"expires": {time.Now().Add(expiration).UTC().Format(time.RFC3339)}}.Encode()
+	return u.String()
+}
+
+// GetSecret returns the secret stored under secretID.
+func (m *memoryManager) GetSecret(_ context.Context, secretID string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.secrets[secretID]
+	if !ok {
+		return "", fmt.Errorf("%w: secret %s", ErrObjectNotFound, secretID)
+	}
+	return value, nil
+}
+
+// CreateSecret stores the secret and returns name as its identifier.
+func (m *memoryManager) CreateSecret(_ context.Context, name, value string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.secrets[name]; exists {
+		return "", fmt.Errorf("cloud: secret %s already exists", name)
+	}
+	m.secrets[name] = value
+	return name, nil
+}
+
+// UpdateSecret replaces the value of an existing secret.
+func (m *memoryManager) UpdateSecret(_ context.Context, secretID, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[secretID]; !ok {
+		return fmt.Errorf("%w: secret %s", ErrObjectNotFound, secretID)
+	}
+	m.secrets[secretID] = value
+	return nil
+}
+
+// DeleteSecret deletes the secret.
+func (m *memoryManager) DeleteSecret(_ context.Context, secretID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.secrets, secretID)
+	return nil
+}
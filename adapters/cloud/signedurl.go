@@ -0,0 +1,143 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/abhissng/neuron/utils/helpers"
+)
+
+// SignedURLOperation is the object operation a signed URL grants.
+type SignedURLOperation string
+
+const (
+	// SignedURLRead grants downloading an object.
+	SignedURLRead SignedURLOperation = "read"
+	// SignedURLWrite grants uploading an object.
+	SignedURLWrite SignedURLOperation = "write"
+)
+
+// ErrConstraintNotSupported is returned when a provider cannot enforce a requested constraint.
+// Constraints are never silently dropped.
+var ErrConstraintNotSupported = errors.New("cloud: signed url constraint not supported by provider")
+
+// SignedURLConstraints restricts what a signed URL may be used for. Zero values impose no limit.
+type SignedURLConstraints struct {
+	// ContentType the upload must be sent with.
+	ContentType string
+	// MaxSizeBytes caps the upload size. On S3 this switches to a POST policy upload.
+	MaxSizeBytes int64
+	// ClientIP binds the URL to a caller address where the provider supports it.
+	ClientIP string
+}
+
+// SignedURL is a provider-agnostic description of how a client uses a signed URL.
+// For POST policy uploads FormFields must be sent as multipart form fields before the file;
+// otherwise Headers must be set on the request.
+type SignedURL struct {
+	URL        string            `json:"url"`
+	Method     string            `json:"method"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	FormFields map[string]string `json:"form_fields,omitempty"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+}
+
+// URLSigner issues signed URLs for object operations.
+type URLSigner interface {
+	SignedURL(ctx context.Context, bucket string, op SignedURLOperation, key string, expiry time.Duration, constraints SignedURLConstraints) (*SignedURL, error)
+}
+
+// SignURL issues a signed URL through cm. It is a convenience for callers holding a CloudManager.
+func SignURL(ctx context.Context, cm CloudManager, bucket string, op SignedURLOperation, key string, expiry time.Duration, constraints SignedURLConstraints) (*SignedURL, error) {
+	signer, ok := cm.(URLSigner)
+	if !ok {
+		return nil, fmt.Errorf("%w: signed urls", ErrUnsupportedProvider)
+	}
+	return signer.SignedURL(ctx, bucket, op, key, expiry, constraints)
+}
+
+// SignedURL issues a signed URL over S3 presigning or OCI pre-authenticated requests.
+func (cm *cloudManager) SignedURL(ctx context.Context, bucket string, op SignedURLOperation, key string, expiry time.Duration, constraints SignedURLConstraints) (*SignedURL, error) {
+	if err := validateSignedURLRequest(op, key, expiry); err != nil {
+		return nil, err
+	}
+	if !helpers.IsEmpty(constraints.ClientIP) {
+		return nil, fmt.Errorf("%w: ip binding on %s", ErrConstraintNotSupported, cm.provider)
+	}
+	expiresAt := time.Now().Add(expiry)
+
+	switch cm.provider {
+	case ProviderAWS:
+		if cm.awsManager == nil {
+			return nil, ErrNotInitialized
+		}
+		if op == SignedURLRead {
+			url, err := cm.awsManager.CreateS3PresignedURL(ctx, bucket, key, expiry)
+			if err != nil {
+				return nil, err
+			}
+			return &SignedURL{URL: url, Method: http.MethodGet, ExpiresAt: expiresAt}, nil
+		}
+		// A size cap can only be enforced through a POST policy.
+		if constraints.MaxSizeBytes > 0 {
+			url, fields, err := cm.awsManager.CreateS3PresignedPostURL(ctx, bucket, key, constraints.ContentType, constraints.MaxSizeBytes, expiry)
+			if err != nil {
+				return nil, err
+			}
+			return &SignedURL{URL: url, Method: http.MethodPost, FormFields: fields, ExpiresAt: expiresAt}, nil
+		}
+		url, err := cm.awsManager.CreateS3PresignedPutURL(ctx, bucket, key, constraints.ContentType, expiry)
+		if err != nil {
+			return nil, err
+		}
+		return &SignedURL{URL: url, Method: http.MethodPut, Headers: contentTypeHeader(constraints.ContentType), ExpiresAt: expiresAt}, nil
+
+	case ProviderOCI:
+		if cm.ociManager == nil {
+			return nil, ErrNotInitialized
+		}
+		if cm.ociNamespace == "" {
+			return nil, errors.New("cloud: OCI namespace is required for object storage operations")
+		}
+		if constraints.MaxSizeBytes > 0 || !helpers.IsEmpty(constraints.ContentType) {
+			return nil, fmt.Errorf("%w: upload constraints on %s", ErrConstraintNotSupported, cm.provider)
+		}
+		accessType, method := "ObjectRead", http.MethodGet
+		if op == SignedURLWrite {
+			accessType, method = "ObjectWrite", http.MethodPut
+		}
+		url, err := cm.ociManager.CreatePreauthenticatedRequest(ctx, cm.ociNamespace, bucket, key, accessType, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		return &SignedURL{URL: url, Method: method, ExpiresAt: expiresAt}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, cm.provider)
+	}
+}
+
+// validateSignedURLRequest checks the provider-independent arguments of a signed URL request.
+func validateSignedURLRequest(op SignedURLOperation, key string, expiry time.Duration) error {
+	if op != SignedURLRead && op != SignedURLWrite {
+		return fmt.Errorf("cloud: unknown signed url operation %q", op)
+	}
+	if helpers.IsEmpty(key) {
+		return errors.New("cloud: object key is required")
+	}
+	if expiry <= 0 {
+		return errors.New("cloud: signed url expiry must be positive")
+	}
+	return nil
+}
+
+// contentTypeHeader returns the Content-Type header a client must send, if any.
+func contentTypeHeader(contentType string) map[string]string {
+	if helpers.IsEmpty(contentType) {
+		return nil
+	}
+	return map[string]string{"Content-Type": contentType}
+}
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"time"
 
+	"github.com/abhissng/neuron/adapters/file/uploadFile"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 )
@@ -227,3 +229,18 @@ func safeInt64(i *int64) int64 {
 	}
 	return *i
 }
+
+// UploadMultipartFile validates file with the given upload validator (content sniffing, size and
+// image limits, virus scan) and streams it to cloud storage only if every check passes.
+// The sniffed content type is used rather than the one supplied by the client.
+func UploadMultipartFile(ctx context.Context, cm CloudManager, validator *uploadFile.Config, bucket, key string, file *multipart.FileHeader, metadata map[string]string) error {
+	if cm == nil {
+		return ErrNotInitialized
+	}
+	if validator == nil {
+		return errors.New("cloud: upload validator is required")
+	}
+	return validator.ValidateAndUpload(file, func(r io.Reader, size int64, contentType string) error {
+		return cm.UploadFileFromReader(ctx, bucket, key, r, size, contentType, metadata)
+	})
+}
@@ -0,0 +1,271 @@
+// Package compliance helps services meet data protection laws such as the DPDP Act and the
+// GDPR. It records who accessed personal data and for which purpose, keeps an append-only
+// ledger of consents, assembles subject access exports from the providers holding personal
+// data, and orchestrates and verifies erasure across them.
+package compliance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/database"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// Legal bases for processing personal data
+const (
+	LegalBasisConsent    = "consent"
+	LegalBasisContract   = "contract"
+	LegalBasisLegal      = "legal_obligation"
+	LegalBasisVital      = "vital_interest"
+	LegalBasisPublicTask = "public_task"
+	LegalBasisLegitimate = "legitimate_interest"
+)
+
+// Data access actions
+const (
+	ActionRead   = "read"
+	ActionUpdate = "update"
+	ActionExport = "export"
+	ActionDelete = "delete"
+)
+
+var (
+	// ErrConsentRequired is returned when data is accessed on the basis of consent that the
+	// subject has not given or has withdrawn.
+	ErrConsentRequired = errors.New("compliance: consent required for purpose")
+	// ErrNoConsentLedger is returned by consent operations when no ConsentLedger is set.
+	ErrNoConsentLedger = errors.New("compliance: no consent ledger configured")
+)
+
+// AccessEvent records an access to the personal data of a subject.
+type AccessEvent struct {
+	ID            string            `json:"id"`
+	SubjectID     string            `json:"subject_id"`
+	ActorID       string            `json:"actor_id,omitempty"`
+	Action        string            `json:"action"`
+	Resource      string            `json:"resource"`
+	Fields        []string          `json:"fields,omitempty"`
+	Purpose       string            `json:"purpose"`
+	LegalBasis    string            `json:"legal_basis"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Time          time.Time         `json:"time"`
+}
+
+// ConsentRecord is an entry of the consent ledger. The latest record of a subject and
+// purpose decides whether consent is given.
+type ConsentRecord struct {
+	ID            string    `json:"id"`
+	SubjectID     string    `json:"subject_id"`
+	Purpose       string    `json:"purpose"`
+	Granted       bool      `json:"granted"`
+	PolicyVersion string    `json:"policy_version,omitempty"`
+	Source        string    `json:"source,omitempty"`
+	Time          time.Time `json:"time"`
+	ExpiresAt     time.Time `json:"expires_at,omitzero"`
+}
+
+// Active reports whether the record grants consent at t.
+func (r ConsentRecord) Active(t time.Time) bool {
+	return r.Granted && (r.ExpiresAt.IsZero() || t.Before(r.ExpiresAt))
+}
+
+// AuditSink stores access events. Implementations should write to append-only storage.
+type AuditSink interface {
+	RecordAccess(ctx context.Context, event AccessEvent) error
+}
+
+// ConsentLedger stores consent records. Records are never updated or deleted; withdrawing
+// consent appends a record with Granted unset.
+type ConsentLedger interface {
+	Append(ctx context.Context, record ConsentRecord) error
+	// History returns the records of subject, oldest first.
+	History(ctx context.Context, subjectID string) ([]ConsentRecord, error)
+}
+
+// Manager records data access and consents and runs subject requests.
+type Manager struct {
+	audit     AuditSink
+	ledger    ConsentLedger
+	providers []DataProvider
+	hook      EventHook
+	logger    *log.Log
+	loggerSet bool
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithAuditSink sets where access events are stored. Defaults to the logger.
+func WithAuditSink(sink AuditSink) Option {
+	return func(m *Manager) {
+		m.audit = sink
+	}
+}
+
+// WithConsentLedger sets the consent ledger.
+func WithConsentLedger(ledger ConsentLedger) Option {
+	return func(m *Manager) {
+		m.ledger = ledger
+	}
+}
+
+// WithProviders registers the providers holding personal data.
+func WithProviders(providers ...DataProvider) Option {
+	return func(m *Manager) {
+		m.providers = append(m.providers, providers...)
+	}
+}
+
+// WithEventHook sets a hook receiving the events of subject requests.
+func WithEventHook(hook EventHook) Option {
+	return func(m *Manager) {
+		m.hook = hook
+	}
+}
+
+// WithLogger sets the logger of the manager.
+func WithLogger(logger *log.Log) Option {
+	return func(m *Manager) {
+		m.logger = logger
+		m.loggerSet = true
+	}
+}
+
+// NewManager creates a Manager.
+func NewManager(opts ...Option) *Manager {
+	defaultLog := log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	m := &Manager{logger: defaultLog}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.loggerSet {
+		_ = defaultLog.Sync()
+	}
+	if m.audit == nil {
+		m.audit = NewLogAuditSink(m.logger)
+	}
+	return m
+}
+
+// Register adds a provider holding personal data.
+func (m *Manager) Register(provider DataProvider) {
+	m.providers = append(m.providers, provider)
+}
+
+// RecordAccess records an access to personal data. An empty LegalBasis means consent, and
+// accesses on the basis of consent are rejected with ErrConsentRequired, and not recorded,
+// unless the subject consented to the purpose. The actor defaults to the actor of ctx.
+func (m *Manager) RecordAccess(ctx context.Context, event AccessEvent) error {
+	if event.LegalBasis == "" {
+		event.LegalBasis = LegalBasisConsent
+	}
+	if event.LegalBasis == LegalBasisConsent {
+		granted, err := m.HasConsent(ctx, event.SubjectID, event.Purpose)
+		if err != nil {
+			return err
+		}
+		if !granted {
+			return ErrConsentRequired
+		}
+	}
+	if event.ID == "" {
+		event.ID = random.GenerateUUIDString()
+	}
+	if event.ActorID == "" {
+		event.ActorID = database.ActorFromContext(ctx)
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	return m.audit.RecordAccess(ctx, event)
+}
+
+// GrantConsent records that subject consented to purpose under policyVersion, collected from
+// source, e.g. "signup_form". A zero ttl does not expire.
+func (m *Manager) GrantConsent(ctx context.Context, subjectID, purpose, policyVersion, source string, ttl time.Duration) (ConsentRecord, error) {
+	record := ConsentRecord{
+		SubjectID:     subjectID,
+		Purpose:       purpose,
+		Granted:       true,
+		PolicyVersion: policyVersion,
+		Source:        source,
+	}
+	if ttl > 0 {
+		record.ExpiresAt = time.Now().UTC().Add(ttl)
+	}
+	return record, m.appendConsent(ctx, &record)
+}
+
+// WithdrawConsent records that subject withdrew consent to purpose.
+func (m *Manager) WithdrawConsent(ctx context.Context, subjectID, purpose, source string) (ConsentRecord, error) {
+	record := ConsentRecord{SubjectID: subjectID, Purpose: purpose, Source: source}
+	return record, m.appendConsent(ctx, &record)
+}
+
+// HasConsent reports whether subject currently consents to purpose.
+func (m *Manager) HasConsent(ctx context.Context, subjectID, purpose string) (bool, error) {
+	consents, err := m.Consents(ctx, subjectID)
+	if err != nil {
+		return false, err
+	}
+	record, ok := consents[purpose]
+	return ok && record.Active(time.Now()), nil
+}
+
+// Consents returns the latest consent record of subject for each purpose.
+func (m *Manager) Consents(ctx context.Context, subjectID string) (map[string]ConsentRecord, error) {
+	if m.ledger == nil {
+		return nil, ErrNoConsentLedger
+	}
+	history, err := m.ledger.History(ctx, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]ConsentRecord)
+	for _, record := range history {
+		if current, ok := latest[record.Purpose]; !ok || !record.Time.Before(current.Time) {
+			latest[record.Purpose] = record
+		}
+	}
+	return latest, nil
+}
+
+func (m *Manager) appendConsent(ctx context.Context, record *ConsentRecord) error {
+	if m.ledger == nil {
+		return ErrNoConsentLedger
+	}
+	record.ID = random.GenerateUUIDString()
+	record.Time = time.Now().UTC()
+	return m.ledger.Append(ctx, *record)
+}
+
+// LogAuditSink writes access events to a logger. It is the default AuditSink; production
+// services should ship these logs to tamper evident storage or use a dedicated sink.
+type LogAuditSink struct {
+	logger *log.Log
+}
+
+// NewLogAuditSink creates a LogAuditSink writing to logger.
+func NewLogAuditSink(logger *log.Log) *LogAuditSink {
+	return &LogAuditSink{logger: logger}
+}
+
+func (s *LogAuditSink) RecordAccess(_ context.Context, event AccessEvent) error {
+	s.logger.Info("personal data accessed",
+		log.String("event_id", event.ID),
+		log.String("subject_id", event.SubjectID),
+		log.String("actor_id", event.ActorID),
+		log.String("action", event.Action),
+		log.String("resource", event.Resource),
+		log.Any("fields", event.Fields),
+		log.String("purpose", event.Purpose),
+		log.String("legal_basis", event.LegalBasis),
+		log.String("correlation_id", event.CorrelationID),
+	)
+	return nil
+}
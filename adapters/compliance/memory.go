@@ -0,0 +1,64 @@
+package compliance
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryLedger keeps consent records in process memory. It is intended for tests and local
+// development; records are lost on restart.
+type MemoryLedger struct {
+	mu      sync.RWMutex
+	records map[string][]ConsentRecord
+}
+
+// NewMemoryLedger creates an empty MemoryLedger.
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{records: make(map[string][]ConsentRecord)}
+}
+
+func (l *MemoryLedger) Append(_ context.Context, record ConsentRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records[record.SubjectID] = append(l.records[record.SubjectID], record)
+	return nil
+}
+
+func (l *MemoryLedger) History(_ context.Context, subjectID string) ([]ConsentRecord, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]ConsentRecord(nil), l.records[subjectID]...), nil
+}
+
+// MemoryAuditSink keeps access events in process memory. It is intended for tests and local
+// development.
+type MemoryAuditSink struct {
+	mu     sync.RWMutex
+	events []AccessEvent
+}
+
+// NewMemoryAuditSink creates an empty MemoryAuditSink.
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{}
+}
+
+func (s *MemoryAuditSink) RecordAccess(_ context.Context, event AccessEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns the recorded events of subject, oldest first, or all events when subjectID
+// is empty.
+func (s *MemoryAuditSink) Events(subjectID string) []AccessEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	events := make([]AccessEvent, 0, len(s.events))
+	for _, event := range s.events {
+		if subjectID == "" || event.SubjectID == subjectID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,215 @@
+package compliance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// Subject request event types
+const (
+	EventExportCompleted    = "export_completed"
+	EventErasureStarted     = "erasure_started"
+	EventProviderErased     = "provider_erased"
+	EventErasureFailed      = "erasure_failed"
+	EventErasureVerified    = "erasure_verified"
+	EventVerificationFailed = "erasure_verification_failed"
+	EventErasureCompleted   = "erasure_completed"
+	EventErasureIncomplete  = "erasure_incomplete"
+)
+
+// subjectRequestPurpose is the purpose recorded for exports and erasures, which are handled
+// under legal obligation.
+const subjectRequestPurpose = "subject_request"
+
+// DataProvider gives access to the personal data a component holds, e.g. a table, a bucket
+// or a downstream service.
+type DataProvider interface {
+	// Name identifies the provider in exports and reports.
+	Name() string
+	// Export returns the personal data of subject, encodable as JSON, or nil when there is
+	// none.
+	Export(ctx context.Context, subjectID string) (any, error)
+	// Erase deletes or anonymises the personal data of subject. Data the provider must keep
+	// for legal reasons should be left in place and reported by Verify as retained.
+	Erase(ctx context.Context, subjectID string) error
+	// Verify reports whether no personal data of subject remains after Erase.
+	Verify(ctx context.Context, subjectID string) (bool, error)
+}
+
+// Event reports the progress of a subject request, for audit and monitoring.
+type Event struct {
+	Type      string    `json:"type"`
+	RequestID string    `json:"request_id"`
+	SubjectID string    `json:"subject_id"`
+	Provider  string    `json:"provider,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// EventHook receives the events of subject requests. It is called synchronously.
+type EventHook func(ctx context.Context, event Event)
+
+// SubjectExport is the personal data of a subject assembled from all providers.
+type SubjectExport struct {
+	RequestID   string                   `json:"request_id"`
+	SubjectID   string                   `json:"subject_id"`
+	GeneratedAt time.Time                `json:"generated_at"`
+	Data        map[string]any           `json:"data"`
+	Consents    map[string]ConsentRecord `json:"consents,omitempty"`
+	Errors      map[string]string        `json:"errors,omitempty"`
+}
+
+// ProviderErasure is the result of erasing the data of a provider.
+type ProviderErasure struct {
+	Provider string `json:"provider"`
+	Erased   bool   `json:"erased"`
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ErasureReport is the result of an erasure request, to be kept as proof of erasure.
+type ErasureReport struct {
+	RequestID   string            `json:"request_id"`
+	SubjectID   string            `json:"subject_id"`
+	Reason      string            `json:"reason,omitempty"`
+	StartedAt   time.Time         `json:"started_at"`
+	CompletedAt time.Time         `json:"completed_at"`
+	Providers   []ProviderErasure `json:"providers"`
+}
+
+// Complete reports whether every provider erased and verified the data.
+func (r *ErasureReport) Complete() bool {
+	for _, provider := range r.Providers {
+		if !provider.Verified {
+			return false
+		}
+	}
+	return true
+}
+
+// Export assembles the personal data of subject from every provider, with the consents of
+// subject when a ConsentLedger is set. A failing provider does not fail the export; its error
+// is reported in Errors so the export can be completed manually.
+func (m *Manager) Export(ctx context.Context, subjectID string) (*SubjectExport, error) {
+	export := &SubjectExport{
+		RequestID:   random.GenerateUUIDString(),
+		SubjectID:   subjectID,
+		GeneratedAt: time.Now().UTC(),
+		Data:        make(map[string]any, len(m.providers)),
+		Errors:      make(map[string]string),
+	}
+	for _, provider := range m.providers {
+		data, err := provider.Export(ctx, subjectID)
+		if err != nil {
+			export.Errors[provider.Name()] = err.Error()
+			m.logger.Error("subject export failed", log.String("provider", provider.Name()), log.String("request_id", export.RequestID), log.Err(err))
+			continue
+		}
+		if data != nil {
+			export.Data[provider.Name()] = data
+		}
+	}
+	if m.ledger != nil {
+		consents, err := m.Consents(ctx, subjectID)
+		if err != nil {
+			export.Errors["consents"] = err.Error()
+		}
+		export.Consents = consents
+	}
+
+	if err := m.recordSubjectRequest(ctx, subjectID, ActionExport, export.RequestID); err != nil {
+		return nil, err
+	}
+	m.emit(ctx, Event{Type: EventExportCompleted, RequestID: export.RequestID, SubjectID: subjectID})
+	return export, nil
+}
+
+// Erase erases the personal data of subject from every provider and verifies each erasure.
+// All providers are attempted even when one fails; the returned error joins their errors and
+// the report records the outcome of each provider.
+func (m *Manager) Erase(ctx context.Context, subjectID, reason string) (*ErasureReport, error) {
+	report := &ErasureReport{
+		RequestID: random.GenerateUUIDString(),
+		SubjectID: subjectID,
+		Reason:    reason,
+		StartedAt: time.Now().UTC(),
+		Providers: make([]ProviderErasure, 0, len(m.providers)),
+	}
+	if err := m.recordSubjectRequest(ctx, subjectID, ActionDelete, report.RequestID); err != nil {
+		return nil, err
+	}
+	m.emit(ctx, Event{Type: EventErasureStarted, RequestID: report.RequestID, SubjectID: subjectID})
+
+	var errs []error
+	for _, provider := range m.providers {
+		result := ProviderErasure{Provider: provider.Name()}
+		event := Event{RequestID: report.RequestID, SubjectID: subjectID, Provider: provider.Name()}
+
+		if err := provider.Erase(ctx, subjectID); err != nil {
+			result.Error = err.Error()
+			errs = append(errs, err)
+			event.Type, event.Error = EventErasureFailed, err.Error()
+			m.emit(ctx, event)
+			report.Providers = append(report.Providers, result)
+			continue
+		}
+		result.Erased = true
+		event.Type = EventProviderErased
+		m.emit(ctx, event)
+
+		verified, err := provider.Verify(ctx, subjectID)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			errs = append(errs, err)
+			event.Type, event.Error = EventVerificationFailed, err.Error()
+		case !verified:
+			result.Error = "personal data remains after erasure"
+			event.Type, event.Error = EventVerificationFailed, result.Error
+		default:
+			result.Verified = true
+			event.Type = EventErasureVerified
+		}
+		m.emit(ctx, event)
+		report.Providers = append(report.Providers, result)
+	}
+
+	report.CompletedAt = time.Now().UTC()
+	if report.Complete() {
+		m.emit(ctx, Event{Type: EventErasureCompleted, RequestID: report.RequestID, SubjectID: subjectID})
+	} else {
+		m.emit(ctx, Event{Type: EventErasureIncomplete, RequestID: report.RequestID, SubjectID: subjectID})
+	}
+	return report, errors.Join(errs...)
+}
+
+// recordSubjectRequest audits a subject request.
+func (m *Manager) recordSubjectRequest(ctx context.Context, subjectID, action, requestID string) error {
+	return m.RecordAccess(ctx, AccessEvent{
+		SubjectID:     subjectID,
+		Action:        action,
+		Resource:      "subject",
+		Purpose:       subjectRequestPurpose,
+		LegalBasis:    LegalBasisLegal,
+		CorrelationID: requestID,
+	})
+}
+
+// emit logs event and passes it to the hook.
+func (m *Manager) emit(ctx context.Context, event Event) {
+	event.Time = time.Now().UTC()
+	m.logger.Info("subject request event",
+		log.String("type", event.Type),
+		log.String("request_id", event.RequestID),
+		log.String("subject_id", event.SubjectID),
+		log.String("provider", event.Provider),
+		log.String("error", event.Error),
+	)
+	if m.hook != nil {
+		m.hook(ctx, event)
+	}
+}
@@ -0,0 +1,81 @@
+// Package broker builds the events.Broker selected by configuration, so the same handlers run
+// on NATS in one environment and on Kafka or RabbitMQ in another.
+package broker
+
+import (
+	"fmt"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/events/kafka"
+	"github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/adapters/events/rabbitmq"
+	"github.com/abhissng/neuron/adapters/log"
+)
+
+// Config selects and configures the broker.
+type Config struct {
+	// Driver is events.DriverNATS, events.DriverKafka or events.DriverRabbitMQ.
+	Driver string
+	// URL is the NATS server URL or the RabbitMQ AMQP URI.
+	URL string
+	// Brokers are the Kafka seed brokers.
+	Brokers []string
+	// Group is the Kafka consumer group, the NATS queue group or the RabbitMQ shared queue.
+	Group string
+	// ReplyTopic is the Kafka topic PublishAndWait replies are read from.
+	ReplyTopic string
+	// Logger is passed to the adapter; nil uses its default logger.
+	Logger *log.Log
+	// NATSOptions, KafkaOptions and RabbitMQOptions are appended to the options derived from
+	// the fields above.
+	NATSOptions     []nats.Option
+	KafkaOptions    []kafka.Option
+	RabbitMQOptions []rabbitmq.Option
+}
+
+// New connects the broker of cfg.Driver with a circuit breaker around publishing.
+func New(cfg Config) (events.Broker, error) {
+	switch cfg.Driver {
+	case events.DriverNATS:
+		options := []nats.Option{nats.WithCircuitBreaker()}
+		if cfg.Logger != nil {
+			options = append(options, nats.WithLogger(cfg.Logger))
+		}
+		manager, err := nats.NewNATSManager(cfg.URL, append(options, cfg.NATSOptions...)...)
+		if err != nil {
+			return nil, err
+		}
+		return manager.Broker(cfg.Group), nil
+
+	case events.DriverKafka:
+		options := []kafka.Option{kafka.WithCircuitBreaker()}
+		if cfg.Logger != nil {
+			options = append(options, kafka.WithLogger(cfg.Logger))
+		}
+		if cfg.Group != "" {
+			options = append(options, kafka.WithConsumerGroup(cfg.Group))
+		}
+		if cfg.ReplyTopic != "" {
+			options = append(options, kafka.WithReplyTopic(cfg.ReplyTopic))
+		}
+		manager, err := kafka.NewKafkaManager(cfg.Brokers, append(options, cfg.KafkaOptions...)...)
+		if err != nil {
+			return nil, err
+		}
+		return manager.Broker(), nil
+
+	case events.DriverRabbitMQ:
+		options := []rabbitmq.Option{rabbitmq.WithCircuitBreaker()}
+		if cfg.Logger != nil {
+			options = append(options, rabbitmq.WithLogger(cfg.Logger))
+		}
+		manager, err := rabbitmq.NewRabbitMQManager(cfg.URL, append(options, cfg.RabbitMQOptions...)...)
+		if err != nil {
+			return nil, err
+		}
+		return manager.Broker(cfg.Group), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported event broker driver %q", cfg.Driver)
+	}
+}
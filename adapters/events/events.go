@@ -1 +1,101 @@
+// Package events defines a broker independent publish/subscribe surface implemented by the
+// NATS, Kafka and RabbitMQ adapters, so services can swap brokers through configuration without
+// changing their handlers. Use broker.New to build the Broker selected by configuration.
 package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/types"
+)
+
+// Supported broker drivers
+const (
+	DriverNATS  = "nats"
+	DriverKafka = "kafka"
+	// DriverRabbitMQ selects the RabbitMQ adapter.
+	DriverRabbitMQ = "rabbitmq"
+)
+
+// Message is an event received from or replied to by a Broker. Raw holds the broker
+// specific message, *nats.Msg, *kgo.Record or *amqp.Delivery, for handlers needing broker features.
+type Message struct {
+	Subject string
+	Data    []byte
+	Headers map[string]string
+	Raw     any
+}
+
+// Header returns the value of the header key.
+func (m *Message) Header(key string) string {
+	return m.Headers[key]
+}
+
+// MessageID returns the Message-ID header used for idempotency.
+func (m *Message) MessageID() string {
+	return m.Headers[constant.MessageIdHeader]
+}
+
+// CorrelationID returns the correlation id header.
+func (m *Message) CorrelationID() string {
+	return m.Headers[constant.CorrelationIDHeader]
+}
+
+// Decode decodes the JSON payload of msg into a T.
+func Decode[T any](msg *Message) (T, blame.Blame) {
+	value, err := codec.Decode[T](msg.Data, codec.JSON)
+	if err != nil {
+		return value, blame.UnMarshalError(codec.JSON, err)
+	}
+	return value, nil
+}
+
+// Handler processes a message. The context carries the correlation id of the message.
+type Handler func(ctx context.Context, msg *Message) blame.Blame
+
+// Middleware wraps a Handler.
+type Middleware func(Handler) Handler
+
+// Chain applies middlewares to handler, the first middleware running first.
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Broker publishes and consumes JSON events with Message-ID idempotency, correlation id
+// propagation and a circuit breaker around publishing.
+type Broker interface {
+	// Publish publishes payload to subject.
+	Publish(ctx context.Context, subject string, payload any) blame.Blame
+	// PublishAndWait publishes payload and waits up to timeout for the reply.
+	PublishAndWait(ctx context.Context, subject string, payload any, timeout time.Duration) (*Message, blame.Blame)
+	// Subscribe processes the messages of subject with handler.
+	Subscribe(subject string, handler Handler, middlewares ...Middleware) blame.Blame
+	// Reply answers a message received from PublishAndWait.
+	Reply(ctx context.Context, request *Message, payload any) blame.Blame
+	// Ping checks the connection to the broker.
+	Ping() error
+	// Close drains subscriptions and closes the connection.
+	Close()
+}
+
+// CorrelationIDFromContext returns the correlation id carried by ctx, as set by the broker
+// adapters or by gin.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if correlationID, ok := ctx.Value(types.StringConstant(constant.CorrelationIDHeader)).(string); ok {
+		return correlationID
+	}
+	correlationID, _ := ctx.Value(constant.CorrelationID).(string)
+	return correlationID
+}
+
+// WithCorrelationID returns ctx carrying correlationID for the broker adapters.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, types.StringConstant(constant.CorrelationIDHeader), correlationID)
+}
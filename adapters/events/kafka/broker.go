@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/blame"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// broker adapts KafkaManager to events.Broker.
+type broker struct {
+	manager *KafkaManager
+}
+
+// Broker returns the manager as a broker independent events.Broker. Subjects map to topics.
+func (k *KafkaManager) Broker() events.Broker {
+	return &broker{manager: k}
+}
+
+func (b *broker) Publish(ctx context.Context, subject string, payload any) blame.Blame {
+	return b.manager.Publish(ctx, subject, payload)
+}
+
+func (b *broker) PublishAndWait(ctx context.Context, subject string, payload any, timeout time.Duration) (*events.Message, blame.Blame) {
+	record, err := b.manager.PublishAndWait(ctx, subject, payload, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return recordMessage(record), nil
+}
+
+func (b *broker) Subscribe(subject string, handler events.Handler, middlewares ...events.Middleware) blame.Blame {
+	handler = events.Chain(handler, middlewares...)
+	return b.manager.Subscribe(subject, func(ctx context.Context, record *kgo.Record) blame.Blame {
+		return handler(ctx, recordMessage(record))
+	})
+}
+
+func (b *broker) Reply(ctx context.Context, request *events.Message, payload any) blame.Blame {
+	record, ok := request.Raw.(*kgo.Record)
+	if !ok {
+		return blame.PublishMessageError(request.Subject, "", errors.New("message was not received from Kafka"))
+	}
+	return b.manager.Reply(ctx, record, payload)
+}
+
+func (b *broker) Ping() error {
+	return b.manager.Ping()
+}
+
+func (b *broker) Close() {
+	b.manager.Close()
+}
+
+// recordMessage converts record to an events.Message.
+func recordMessage(record *kgo.Record) *events.Message {
+	headers := make(map[string]string, len(record.Headers))
+	for _, header := range record.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	return &events.Message{Subject: record.Topic, Data: record.Value, Headers: headers, Raw: record}
+}
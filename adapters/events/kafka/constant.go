@@ -0,0 +1,28 @@
+package kafka
+
+import "time"
+
+const (
+	BreakerName               = "KafkaProduce"
+	DefaultRetryBackoff       = time.Second
+	DefaultCloseTimeout       = 10 * time.Second
+	ConnectionFailedMessage   = "connection to Kafka is not yet established or failed"
+	ConsumerGroupMissingError = "consumer group is not configured"
+	ReplyTopicMissingError    = "reply topic is not configured"
+	ReplyToMissingError       = "record has no Reply-To header"
+	ReplyToHeader             = "Reply-To"
+	InReplyToHeader           = "In-Reply-To"
+	DefaultDeadLetterSuffix   = ".dlq"
+)
+
+// Headers set on dead letters, mirroring those of the NATS adapter.
+const (
+	DeadLetterTopicHeader     = "X-DLQ-Original-Topic"
+	DeadLetterMessageIDHeader = "X-DLQ-Original-Message-ID"
+	DeadLetterPartitionHeader = "X-DLQ-Original-Partition"
+	DeadLetterOffsetHeader    = "X-DLQ-Original-Offset"
+	DeadLetterErrorHeader     = "X-DLQ-Error"
+	DeadLetterErrorCodeHeader = "X-DLQ-Error-Code"
+	DeadLetterAttemptsHeader  = "X-DLQ-Attempts"
+	DeadLetterFailedAtHeader  = "X-DLQ-Failed-At"
+)
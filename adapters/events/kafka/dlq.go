@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// DeadLetterTopic returns the dead letter topic of topic.
+func DeadLetterTopic(topic string) string {
+	return topic + DefaultDeadLetterSuffix
+}
+
+// publishDeadLetter produces record to its dead letter topic with the failure in headers. A
+// new Message-ID is assigned so the dead letter is not skipped as already processed.
+func (k *KafkaManager) publishDeadLetter(ctx context.Context, record *kgo.Record, cause blame.Blame, attempts int) error {
+	dead := &kgo.Record{
+		Topic:   DeadLetterTopic(record.Topic),
+		Key:     record.Key,
+		Value:   record.Value,
+		Headers: append([]kgo.RecordHeader(nil), record.Headers...),
+	}
+	SetHeader(dead, constant.MessageIdHeader, random.GenerateUUIDString())
+	SetHeader(dead, DeadLetterTopicHeader, record.Topic)
+	SetHeader(dead, DeadLetterMessageIDHeader, Header(record, constant.MessageIdHeader))
+	SetHeader(dead, DeadLetterPartitionHeader, strconv.Itoa(int(record.Partition)))
+	SetHeader(dead, DeadLetterOffsetHeader, strconv.FormatInt(record.Offset, 10))
+	SetHeader(dead, DeadLetterAttemptsHeader, strconv.Itoa(attempts))
+	SetHeader(dead, DeadLetterFailedAtHeader, time.Now().UTC().Format(time.RFC3339Nano))
+	if cause != nil {
+		SetHeader(dead, DeadLetterErrorCodeHeader, string(cause.FetchErrCode()))
+		message, _ := cause.Translate()
+		if message == "" {
+			message = cause.ErrorFromBlame().Error()
+		}
+		SetHeader(dead, DeadLetterErrorHeader, strings.Join(strings.Fields(message), " "))
+	}
+
+	if err := k.client.ProduceSync(ctx, dead).FirstErr(); err != nil {
+		return err
+	}
+	k.logger.Warn("Message dead lettered", Slog(dead, log.Any("attempts", attempts))...)
+	return nil
+}
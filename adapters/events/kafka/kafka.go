@@ -0,0 +1,170 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/idempotency"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/sony/gobreaker"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+//----------------------------------------------------------
+// GENERIC Kafka Manager WITH CIRCUIT BREAKER
+//----------------------------------------------------------
+
+// KafkaManager wraps a franz-go client with the same publish/subscribe conventions as
+// NATSManager: JSON payloads, Message-ID based idempotency, correlation and trace header
+// propagation, middleware chains and blame errors.
+type KafkaManager struct {
+	client             *kgo.Client
+	clientOpts         []kgo.Opt
+	connOpts           []kgo.Opt
+	group              string
+	mu                 sync.Mutex
+	logger             *log.Log
+	loggerSet          bool
+	idempotencyManager *idempotency.IdempotencyManager[string]
+	breaker            *gobreaker.CircuitBreaker
+	handlers           map[string]KafkaMsgProcessor
+	retries            int
+	retryBackoff       time.Duration
+	deadLetter         bool
+	cancel             context.CancelFunc
+	replyTopic         string
+	replyClient        *kgo.Client
+	replyCancel        context.CancelFunc
+	pending            map[string]chan *kgo.Record
+	wg                 sync.WaitGroup
+	closeOnce          sync.Once
+}
+
+// NewKafkaManager creates a Kafka manager connected to brokers. Records are produced
+// idempotently and consumed with offsets committed only after they are processed.
+func NewKafkaManager(brokers []string, options ...Option) (*KafkaManager, error) {
+	defaultLog := log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+
+	manager := &KafkaManager{
+		logger:             defaultLog,
+		idempotencyManager: idempotency.NewIdempotencyManager[string](idempotency.DefaultCleanupInterval),
+		handlers:           make(map[string]KafkaMsgProcessor),
+		pending:            make(map[string]chan *kgo.Record),
+		retryBackoff:       DefaultRetryBackoff,
+	}
+	for _, opt := range options {
+		opt(manager)
+	}
+
+	clientOpts := append([]kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.AutoCommitMarks(),
+		kgo.BlockRebalanceOnPoll(),
+	}, manager.connOpts...)
+	client, err := kgo.NewClient(append(clientOpts, manager.clientOpts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Kafka: %v", err)
+	}
+	manager.client = client
+
+	if manager.replyTopic != "" {
+		if err := manager.startReplyConsumer(brokers); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to consume reply topic: %v", err)
+		}
+	}
+
+	if manager.loggerSet {
+		_ = defaultLog.Sync()
+	}
+	return manager, nil
+}
+
+// Ping checks that at least one broker is reachable.
+func (k *KafkaManager) Ping() error {
+	if k.client == nil {
+		return errors.New(ConnectionFailedMessage)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := k.client.Ping(ctx); err != nil {
+		return fmt.Errorf("%s: %w", ConnectionFailedMessage, err)
+	}
+	return nil
+}
+
+// Client returns the underlying franz-go client.
+func (k *KafkaManager) Client() *kgo.Client {
+	return k.client
+}
+
+// Close stops consuming, commits the offsets of processed records and closes the client.
+func (k *KafkaManager) Close() {
+	k.closeOnce.Do(func() {
+		k.mu.Lock()
+		cancel, replyCancel := k.cancel, k.replyCancel
+		k.mu.Unlock()
+		if cancel != nil {
+			cancel()
+		}
+		if replyCancel != nil {
+			replyCancel()
+		}
+		k.wg.Wait()
+
+		if k.group != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultCloseTimeout)
+			if err := k.client.CommitMarkedOffsets(ctx); err != nil {
+				k.logger.Error("Failed to commit offsets", log.Err(err))
+			}
+			cancel()
+		}
+		k.logger.Info(constant.ConnectionClosing, log.Any("message", "Kafka connection closing"))
+		k.client.Close()
+		if k.replyClient != nil {
+			k.replyClient.Close()
+		}
+		k.idempotencyManager.Close()
+		k.logger.Info(constant.ConnectionClosed, log.Any("message", "Kafka connection closed"))
+	})
+}
+
+// Slog returns the standard log fields for a record.
+func Slog(record *kgo.Record, withFields ...types.Field) []types.Field {
+	fields := make([]types.Field, 0, 5+len(withFields))
+	fields = append(fields,
+		log.String("kafka.topic", record.Topic),
+		log.Any("kafka.partition", record.Partition),
+		log.Any("kafka.offset", record.Offset),
+		log.String(constant.MessageIdHeader, Header(record, constant.MessageIdHeader)),
+		log.String(constant.CorrelationIDHeader, Header(record, constant.CorrelationIDHeader)),
+	)
+	return append(fields, withFields...)
+}
+
+// Header returns the first value of the header key on record.
+func Header(record *kgo.Record, key string) string {
+	for _, header := range record.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// SetHeader replaces the header key on record.
+func SetHeader(record *kgo.Record, key, value string) {
+	for i, header := range record.Headers {
+		if header.Key == key {
+			record.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	record.Headers = append(record.Headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+}
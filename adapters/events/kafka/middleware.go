@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/adapters/paseto"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// ----------------------
+// Middleware support
+// ----------------------
+
+// KafkaMsgProcessor defines the signature for a record processor.
+type KafkaMsgProcessor func(ctx context.Context, record *kgo.Record) blame.Blame
+
+// MiddlewareFunc defines the signature for a middleware function.
+type MiddlewareFunc func(KafkaMsgProcessor) KafkaMsgProcessor
+
+// applyMiddleware applies the middleware chain to a processor.
+func applyMiddleware(processor KafkaMsgProcessor, middlewares ...MiddlewareFunc) KafkaMsgProcessor {
+	// Apply in reverse order so that the first middleware in the list is executed first.
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		processor = middlewares[i](processor)
+	}
+	return processor
+}
+
+// AddHeaderMiddleware returns a middleware that sets a header key/value on the record.
+func AddHeaderMiddleware(key, value string) MiddlewareFunc {
+	return func(next KafkaMsgProcessor) KafkaMsgProcessor {
+		return func(ctx context.Context, record *kgo.Record) blame.Blame {
+			SetHeader(record, key, value)
+			return next(ctx, record)
+		}
+	}
+}
+
+// LogMiddleware returns a middleware that logs the record and any processing failure.
+func LogMiddleware(eventType string, logger *log.Log) MiddlewareFunc {
+	return func(next KafkaMsgProcessor) KafkaMsgProcessor {
+		return func(ctx context.Context, record *kgo.Record) blame.Blame {
+			if logger == nil {
+				return next(ctx, record)
+			}
+			logger.Info(constant.EventProcessed+" : "+eventType, Slog(record, logger.SanitizeAny("kafka.data", string(record.Value)))...)
+			err := next(ctx, record)
+			if err != nil {
+				logger.Error(eventType+" failed", Slog(record, log.Err(err))...)
+			}
+			return err
+		}
+	}
+}
+
+// RecoveryMiddleware converts a panic in the processor into a blame error.
+func RecoveryMiddleware() MiddlewareFunc {
+	return func(next KafkaMsgProcessor) KafkaMsgProcessor {
+		return func(ctx context.Context, record *kgo.Record) (err blame.Blame) {
+			defer func() {
+				if r := recover(); r != nil {
+					helpers.Println(constant.ERROR, "Recovered from panic in Kafka record handler")
+					helpers.Println(constant.ERROR, string(debug.Stack()))
+					err = blame.GeneralKnownError(fmt.Errorf("panic recovered: %v", r))
+				}
+			}()
+			return next(ctx, record)
+		}
+	}
+}
+
+// ValidateHeadersMiddleware validates the Authorization header of consumed records with
+// paseto and the optional custom validators.
+func ValidateHeadersMiddleware(pasetoManager *paseto.PasetoManager, validators ...paseto.TokenValidator) MiddlewareFunc {
+	return func(next KafkaMsgProcessor) KafkaMsgProcessor {
+		return func(ctx context.Context, record *kgo.Record) blame.Blame {
+			token := helpers.ExtractBearerToken(Header(record, constant.AuthorizationHeader))
+			if helpers.IsEmpty(token) {
+				return blame.MalformedAuthToken(errors.New("token is empty"))
+			}
+			if pasetoManager == nil {
+				return blame.MalformedAuthToken(errors.New("paseto manager is not configured"))
+			}
+			extra := make(map[string]any)
+			if subject := Header(record, constant.XSubject); subject != "" {
+				extra["subject"] = subject
+			}
+			if ip := Header(record, constant.IPHeader); ip != "" {
+				extra["ip"] = ip
+			}
+			res := pasetoManager.ValidateToken(token, extra, validators...)
+			if !res.IsSuccess() {
+				return res.Blame()
+			}
+			return next(ctx, record)
+		}
+	}
+}
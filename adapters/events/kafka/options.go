@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/circuitBreaker"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// Option defines a functional option for configuring KafkaManager.
+type Option func(*KafkaManager)
+
+// WithLogger sets the logger for the manager.
+func WithLogger(log *log.Log) Option {
+	return func(k *KafkaManager) {
+		k.logger = log
+		k.loggerSet = true
+	}
+}
+
+// WithConsumerGroup joins the consumer group used by Subscribe.
+func WithConsumerGroup(group string) Option {
+	return func(k *KafkaManager) {
+		k.group = group
+		k.clientOpts = append(k.clientOpts, kgo.ConsumerGroup(group))
+	}
+}
+
+// WithClientID sets the client id reported to the brokers.
+func WithClientID(id string) Option {
+	return func(k *KafkaManager) {
+		k.connOpts = append(k.connOpts, kgo.ClientID(id))
+	}
+}
+
+// WithTLS enables TLS with the given configuration.
+func WithTLS(cfg *tls.Config) Option {
+	return func(k *KafkaManager) {
+		k.connOpts = append(k.connOpts, kgo.DialTLSConfig(cfg))
+	}
+}
+
+// WithSASLPlain authenticates with SASL/PLAIN.
+func WithSASLPlain(user, password string) Option {
+	return func(k *KafkaManager) {
+		k.connOpts = append(k.connOpts, kgo.SASL(plain.Auth{User: user, Pass: password}.AsMechanism()))
+	}
+}
+
+// WithSASLScram authenticates with SASL/SCRAM-SHA-512.
+func WithSASLScram(user, password string) Option {
+	return func(k *KafkaManager) {
+		k.connOpts = append(k.connOpts, kgo.SASL(scram.Auth{User: user, Pass: password}.AsSha512Mechanism()))
+	}
+}
+
+// WithReplyTopic sets the topic this instance reads PublishAndWait replies from. Replies are
+// matched by Message-ID, so instances may share a reply topic at the cost of reading each
+// other's replies.
+func WithReplyTopic(topic string) Option {
+	return func(k *KafkaManager) {
+		k.replyTopic = topic
+	}
+}
+
+// WithRetries retries a failed handler up to attempts more times with backoff between them
+// before the record is given up on.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(k *KafkaManager) {
+		k.retries = attempts
+		k.retryBackoff = backoff
+	}
+}
+
+// WithDeadLetter produces records that still fail after their retries to DeadLetterTopic of
+// their topic, with the failure in headers, and commits them. Without it such records are
+// consumed again from their offset, holding back the rest of their partition.
+func WithDeadLetter() Option {
+	return func(k *KafkaManager) {
+		k.deadLetter = true
+	}
+}
+
+// WithCircuitBreaker enables a circuit breaker around produce calls.
+func WithCircuitBreaker(options ...circuitBreaker.CircuitBreakerOption) Option {
+	if len(options) <= 0 {
+		options = append(options, circuitBreaker.WithName(BreakerName))
+	}
+	return func(k *KafkaManager) {
+		k.breaker = circuitBreaker.NewCircuitBreaker(options...)
+	}
+}
+
+// WithClientOptions passes raw franz-go options to the client.
+func WithClientOptions(opts ...kgo.Opt) Option {
+	return func(k *KafkaManager) {
+		k.clientOpts = append(k.clientOpts, opts...)
+	}
+}
@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.opentelemetry.io/otel"
+)
+
+// Publish publishes payload as JSON to topic and waits for the brokers to acknowledge it.
+func (k *KafkaManager) Publish(ctx context.Context, topic string, payload any) blame.Blame {
+	return k.publishInternal(ctx, topic, "", payload)
+}
+
+// PublishWithKey publishes payload with a partition key, so records sharing key keep their
+// order, and applies middlewares before producing.
+func (k *KafkaManager) PublishWithKey(ctx context.Context, topic, key string, payload any, middlewares ...MiddlewareFunc) blame.Blame {
+	return k.publishInternal(ctx, topic, key, payload, middlewares...)
+}
+
+// PublishWithMiddleware publishes payload to topic with middleware attached.
+func (k *KafkaManager) PublishWithMiddleware(ctx context.Context, topic string, payload any, middlewares ...MiddlewareFunc) blame.Blame {
+	return k.publishInternal(ctx, topic, "", payload, middlewares...)
+}
+
+// publishInternal encodes payload, sets the standard headers and produces the record.
+func (k *KafkaManager) publishInternal(ctx context.Context, topic, key string, payload any, middlewares ...MiddlewareFunc) blame.Blame {
+	data, err := codec.Encode(payload, codec.JSON)
+	if err != nil {
+		k.logger.Error(constant.EventPublishedFailed, log.Any("codec.Encode", err))
+		return blame.MarshalError(codec.JSON, err)
+	}
+	record := &kgo.Record{Topic: topic, Value: data}
+	if key != "" {
+		record.Key = []byte(key)
+	}
+	SetHeader(record, constant.MessageIdHeader, random.GenerateUUIDString())
+	injectContextHeaders(ctx, record)
+
+	finalHandler := func(ctx context.Context, record *kgo.Record) blame.Blame {
+		produce := func() (any, error) {
+			return nil, k.client.ProduceSync(ctx, record).FirstErr()
+		}
+		var produceErr error
+		if k.breaker != nil {
+			_, produceErr = k.breaker.Execute(produce)
+		} else {
+			_, produceErr = produce()
+		}
+		if produceErr != nil {
+			k.logger.Error(constant.EventPublishedFailed, log.Any("kgo.ProduceSync", produceErr))
+			return blame.PublishMessageError(topic, string(data), produceErr)
+		}
+		return nil
+	}
+
+	if err := applyMiddleware(finalHandler, middlewares...)(ctx, record); err != nil {
+		return err
+	}
+	k.logger.Info(constant.EventPublished, Slog(record)...)
+	return nil
+}
+
+// injectContextHeaders copies the correlation id and the trace context of ctx onto record.
+func injectContextHeaders(ctx context.Context, record *kgo.Record) {
+	correlationID, _ := ctx.Value(types.StringConstant(constant.CorrelationIDHeader)).(string)
+	if correlationID == "" {
+		// gin.Context stores request values under plain string keys.
+		correlationID, _ = ctx.Value(constant.CorrelationID).(string)
+	}
+	if correlationID != "" {
+		SetHeader(record, constant.CorrelationIDHeader, correlationID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{record: record})
+}
+
+// extractContextHeaders returns ctx carrying the correlation id and trace context of record.
+func extractContextHeaders(ctx context.Context, record *kgo.Record) context.Context {
+	if correlationID := Header(record, constant.CorrelationIDHeader); correlationID != "" {
+		ctx = context.WithValue(ctx, types.StringConstant(constant.CorrelationIDHeader), correlationID)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier{record: record})
+}
+
+// headerCarrier adapts record headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	record *kgo.Record
+}
+
+func (c headerCarrier) Get(key string) string {
+	return Header(c.record, key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	SetHeader(c.record, key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(c.record.Headers))
+	for i, header := range c.record.Headers {
+		keys[i] = header.Key
+	}
+	return keys
+}
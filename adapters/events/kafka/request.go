@@ -0,0 +1,112 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// PublishAndWait publishes payload to topic with a Reply-To header naming the reply topic and
+// waits up to timeout for the record replying to it, the Kafka equivalent of
+// NATSManager.PublishAndWait. It requires WithReplyTopic.
+func (k *KafkaManager) PublishAndWait(ctx context.Context, topic string, payload any, timeout time.Duration, middlewares ...MiddlewareFunc) (*kgo.Record, blame.Blame) {
+	if k.replyClient == nil {
+		return nil, blame.PublishMessageError(topic, "", errors.New(ReplyTopicMissingError))
+	}
+
+	var messageID string
+	reply := make(chan *kgo.Record, 1)
+	register := func(next KafkaMsgProcessor) KafkaMsgProcessor {
+		return func(ctx context.Context, record *kgo.Record) blame.Blame {
+			messageID = Header(record, constant.MessageIdHeader)
+			SetHeader(record, ReplyToHeader, k.replyTopic)
+			k.mu.Lock()
+			k.pending[messageID] = reply
+			k.mu.Unlock()
+			return next(ctx, record)
+		}
+	}
+	defer func() {
+		k.mu.Lock()
+		delete(k.pending, messageID)
+		k.mu.Unlock()
+	}()
+
+	if err := k.publishInternal(ctx, topic, "", payload, append([]MiddlewareFunc{register}, middlewares...)...); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case record := <-reply:
+		return record, nil
+	case <-timer.C:
+		k.logger.Error(constant.EventPublishedFailed, log.String(constant.MessageIdHeader, messageID), log.String("topic", topic), log.Any("timeout", timeout.String()))
+		return nil, blame.PublishMessageError(topic, "", context.DeadlineExceeded)
+	case <-ctx.Done():
+		return nil, blame.PublishMessageError(topic, "", ctx.Err())
+	}
+}
+
+// Reply publishes payload to the Reply-To topic of request, referencing its Message-ID, so
+// that the PublishAndWait call that sent request receives it.
+func (k *KafkaManager) Reply(ctx context.Context, request *kgo.Record, payload any, middlewares ...MiddlewareFunc) blame.Blame {
+	replyTo := Header(request, ReplyToHeader)
+	if replyTo == "" {
+		return blame.PublishMessageError(request.Topic, "", errors.New(ReplyToMissingError))
+	}
+	inReplyTo := AddHeaderMiddleware(InReplyToHeader, Header(request, constant.MessageIdHeader))
+	if correlationID := Header(request, constant.CorrelationIDHeader); correlationID != "" {
+		middlewares = append([]MiddlewareFunc{AddHeaderMiddleware(constant.CorrelationIDHeader, correlationID)}, middlewares...)
+	}
+	return k.publishInternal(ctx, replyTo, "", payload, append([]MiddlewareFunc{inReplyTo}, middlewares...)...)
+}
+
+// startReplyConsumer reads the reply topic from its end, outside the consumer group, and
+// hands replies to the waiting PublishAndWait calls.
+func (k *KafkaManager) startReplyConsumer(brokers []string) error {
+	opts := append([]kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumeTopics(k.replyTopic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtEnd()),
+	}, k.connOpts...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	k.replyClient = client
+	k.replyCancel = cancel
+
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		for {
+			fetches := client.PollFetches(ctx)
+			if ctx.Err() != nil || fetches.IsClientClosed() {
+				return
+			}
+			fetches.EachError(func(topic string, partition int32, err error) {
+				k.logger.Error(constant.SubjectSubscribeFailed, log.String("topic", topic), log.Any("partition", partition), log.Err(err))
+			})
+			fetches.EachRecord(func(record *kgo.Record) {
+				k.mu.Lock()
+				waiter, ok := k.pending[Header(record, InReplyToHeader)]
+				k.mu.Unlock()
+				if ok {
+					select {
+					case waiter <- record:
+					default:
+					}
+				}
+			})
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,167 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Subscribe registers processor for topic within the consumer group and starts consuming if
+// not already running. Records are processed in order per partition; a record's offset is
+// committed once its processor succeeds or, with WithDeadLetter, once it is dead lettered
+// after its retries. A record that still fails is consumed again from its offset on the next
+// poll, so a crash or shutdown redelivers unprocessed records. Records carrying an already
+// processed Message-ID are skipped.
+func (k *KafkaManager) Subscribe(topic string, processor KafkaMsgProcessor, middlewares ...MiddlewareFunc) blame.Blame {
+	if k.group == "" {
+		return blame.SubscribeToSubjectError(topic, errors.New(ConsumerGroupMissingError))
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, exists := k.handlers[topic]; exists {
+		return blame.AlreadySubscribedToSubjectError(topic)
+	}
+	k.handlers[topic] = applyMiddleware(processor, append([]MiddlewareFunc{RecoveryMiddleware()}, middlewares...)...)
+	k.client.AddConsumeTopics(topic)
+
+	if k.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		k.cancel = cancel
+		k.wg.Add(1)
+		go k.consume(ctx)
+	}
+	k.logger.Info(constant.SubjectSubscribed, log.String("topic", topic), log.String("group", k.group))
+	return nil
+}
+
+// Unsubscribe stops consuming topic.
+func (k *KafkaManager) Unsubscribe(topic string) {
+	k.mu.Lock()
+	delete(k.handlers, topic)
+	k.mu.Unlock()
+	k.client.PurgeTopicsFromConsuming(topic)
+}
+
+// consume polls records until ctx is cancelled.
+func (k *KafkaManager) consume(ctx context.Context) {
+	defer k.wg.Done()
+	for {
+		fetches := k.client.PollFetches(ctx)
+		if ctx.Err() != nil || fetches.IsClientClosed() {
+			k.client.AllowRebalance()
+			return
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			k.logger.Error(constant.SubjectSubscribeFailed, log.String("topic", topic), log.Any("partition", partition), log.Err(err))
+		})
+
+		var (
+			wg       sync.WaitGroup
+			rewindMu sync.Mutex
+			rewind   = make(map[string]map[int32]kgo.EpochOffset)
+		)
+		fetches.EachPartition(func(partition kgo.FetchTopicPartition) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, record := range partition.Records {
+					switch k.handleRecord(ctx, record) {
+					case recordDone:
+						k.client.MarkCommitRecords(record)
+						continue
+					case recordFailed:
+						// Later records must not be committed past this one; consume
+						// the partition again from it.
+						rewindMu.Lock()
+						if rewind[record.Topic] == nil {
+							rewind[record.Topic] = make(map[int32]kgo.EpochOffset)
+						}
+						rewind[record.Topic][record.Partition] = kgo.EpochOffset{Epoch: record.LeaderEpoch, Offset: record.Offset}
+						rewindMu.Unlock()
+					}
+					return
+				}
+			}()
+		})
+		wg.Wait()
+		if ctx.Err() == nil {
+			k.client.SetOffsets(rewind)
+		}
+		k.client.AllowRebalance()
+	}
+}
+
+// recordOutcome is what became of a record handed to handleRecord.
+type recordOutcome int
+
+const (
+	// recordDone records were processed, skipped or dead lettered; they may be committed.
+	recordDone recordOutcome = iota
+	// recordFailed records exhausted their retries without being dead lettered.
+	recordFailed
+	// recordAborted records were interrupted by the consumer stopping.
+	recordAborted
+)
+
+// handleRecord runs the processor of the record's topic with retries, dead lettering the
+// record when they are exhausted and dead lettering is enabled.
+func (k *KafkaManager) handleRecord(ctx context.Context, record *kgo.Record) recordOutcome {
+	k.mu.Lock()
+	processor := k.handlers[record.Topic]
+	k.mu.Unlock()
+	if processor == nil {
+		return recordDone
+	}
+
+	messageID := Header(record, constant.MessageIdHeader)
+	if messageID != "" && k.idempotencyManager.IsProcessed(messageID) {
+		k.logger.Info("Message already processed", Slog(record)...)
+		return recordDone
+	}
+
+	ctx = extractContextHeaders(ctx, record)
+	var err blame.Blame
+	attempts := 0
+	for attempt := 0; attempt <= k.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return recordAborted
+			case <-time.After(k.retryBackoff):
+			}
+		}
+		attempts++
+		if err = processor(ctx, record); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return recordAborted
+		}
+	}
+	if err != nil {
+		k.logger.Error(constant.HandlerFailed, Slog(record, log.Any("error", err.FetchErrCode()))...)
+		if !k.deadLetter {
+			return recordFailed
+		}
+		if dlqErr := k.publishDeadLetter(ctx, record, err, attempts); dlqErr != nil {
+			k.logger.Error(constant.EventPublishedFailed, Slog(record, log.String("topic", DeadLetterTopic(record.Topic)), log.Err(dlqErr))...)
+			if ctx.Err() != nil {
+				return recordAborted
+			}
+			return recordFailed
+		}
+		return recordDone
+	}
+	if messageID != "" {
+		k.idempotencyManager.MarkAsProcessed(messageID)
+	}
+	k.logger.Info("Message processed", Slog(record)...)
+	return recordDone
+}
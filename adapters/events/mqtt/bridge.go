@@ -0,0 +1,66 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/blame"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// BridgedMessage is the NATS payload of an MQTT message forwarded by BridgeToNATS. JSON
+// payloads are embedded as is, anything else is carried base64 encoded in Data.
+type BridgedMessage struct {
+	Topic      string          `json:"topic"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Data       []byte          `json:"data,omitempty"`
+	QoS        QoS             `json:"qos"`
+	Retained   bool            `json:"retained"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+// SubjectMapper maps an MQTT topic to the NATS subject it is forwarded to.
+type SubjectMapper func(topic string) string
+
+// TopicToSubject maps topics to subjects under prefix by turning "/" into ".", e.g.
+// "devices/42/telemetry" becomes "iot.devices.42.telemetry" with prefix "iot". Characters
+// that are special in NATS subjects are replaced with "_".
+func TopicToSubject(prefix string) SubjectMapper {
+	replacer := strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_", "/", ".")
+	return func(topic string) string {
+		subject := replacer.Replace(strings.Trim(topic, "/"))
+		if prefix == "" {
+			return subject
+		}
+		return prefix + "." + subject
+	}
+}
+
+// BridgeToNATS subscribes to filter and republishes every message on the NATS subject
+// chosen by mapper, so device telemetry can be consumed with the regular NATS tooling.
+// A NATS manager must be configured with WithNATSBridge.
+func (m *MQTTManager) BridgeToNATS(filter string, qos QoS, mapper SubjectMapper) blame.Blame {
+	if m.nats == nil {
+		return blame.SubscribeToSubjectError(filter, errors.New("nats bridge is not configured"))
+	}
+	if mapper == nil {
+		mapper = TopicToSubject("")
+	}
+	return m.Subscribe(filter, qos, func(msg paho.Message) blame.Blame {
+		bridged := BridgedMessage{
+			Topic:      msg.Topic(),
+			QoS:        QoS(msg.Qos()),
+			Retained:   msg.Retained(),
+			ReceivedAt: time.Now().UTC(),
+		}
+		if json.Valid(msg.Payload()) {
+			bridged.Payload = msg.Payload()
+		} else {
+			bridged.Data = msg.Payload()
+		}
+		_, err := m.nats.Publish(mapper(msg.Topic()), bridged)
+		return err
+	})
+}
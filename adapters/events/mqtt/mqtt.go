@@ -0,0 +1,245 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// QoS is an MQTT delivery guarantee
+type QoS byte
+
+// MQTT quality of service levels
+const (
+	AtMostOnce  QoS = 0
+	AtLeastOnce QoS = 1
+	ExactlyOnce QoS = 2
+)
+
+const (
+	DefaultTimeout          = 10 * time.Second
+	ConnectionFailedMessage = "connection to MQTT broker is not yet established or failed"
+)
+
+// MQTTMsgProcessor processes a received MQTT message.
+type MQTTMsgProcessor func(msg paho.Message) blame.Blame
+
+// MQTTManager manages an MQTT connection for device and telemetry ingestion. Subscriptions
+// are restored after reconnecting and selected topics can be bridged into NATS subjects.
+type MQTTManager struct {
+	client        paho.Client
+	opts          *paho.ClientOptions
+	mu            sync.Mutex
+	logger        *log.Log
+	loggerSet     bool
+	timeout       time.Duration
+	subscriptions map[string]subscription
+	nats          *nats.NATSManager
+}
+
+type subscription struct {
+	qos     QoS
+	handler paho.MessageHandler
+}
+
+// NewMQTTManager connects to brokerURL, e.g. "ssl://broker:8883" or "tcp://broker:1883".
+func NewMQTTManager(brokerURL string, options ...Option) (*MQTTManager, error) {
+	defaultLog := log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+
+	manager := &MQTTManager{
+		opts: paho.NewClientOptions().
+			AddBroker(brokerURL).
+			SetClientID("neuron-" + random.GenerateUUIDString()).
+			SetAutoReconnect(true).
+			SetConnectRetry(true).
+			SetOrderMatters(false).
+			SetConnectTimeout(DefaultTimeout),
+		logger:        defaultLog,
+		timeout:       DefaultTimeout,
+		subscriptions: make(map[string]subscription),
+	}
+	for _, opt := range options {
+		opt(manager)
+	}
+
+	manager.opts.SetOnConnectHandler(func(paho.Client) {
+		manager.logger.Info("MQTT connected", log.String("broker", brokerURL))
+		manager.resubscribe()
+	})
+	manager.opts.SetConnectionLostHandler(func(_ paho.Client, err error) {
+		manager.logger.Error("MQTT disconnected", log.Err(err))
+	})
+
+	manager.client = paho.NewClient(manager.opts)
+	token := manager.client.Connect()
+	if !token.WaitTimeout(manager.timeout) {
+		manager.client.Disconnect(0)
+		return nil, errors.New("failed to connect to MQTT: timed out")
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT: %v", err)
+	}
+
+	if manager.loggerSet {
+		_ = defaultLog.Sync()
+	}
+	return manager, nil
+}
+
+// Ping checks the health of the MQTT connection.
+func (m *MQTTManager) Ping() error {
+	if m.client == nil || !m.client.IsConnectionOpen() {
+		return errors.New(ConnectionFailedMessage)
+	}
+	return nil
+}
+
+// Client returns the underlying paho client.
+func (m *MQTTManager) Client() paho.Client {
+	return m.client
+}
+
+// Publish publishes payload to topic. Byte slices and strings are sent as is, anything else
+// is encoded as JSON.
+func (m *MQTTManager) Publish(topic string, payload any, qos QoS, retained bool) blame.Blame {
+	var data []byte
+	switch v := payload.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		encoded, err := codec.Encode(payload, codec.JSON)
+		if err != nil {
+			m.logger.Error(constant.EventPublishedFailed, log.Any("codec.Encode", err))
+			return blame.MarshalError(codec.JSON, err)
+		}
+		data = encoded
+	}
+
+	token := m.client.Publish(topic, byte(qos), retained, data)
+	if err := m.wait(token); err != nil {
+		m.logger.Error(constant.EventPublishedFailed, log.String("topic", topic), log.Err(err))
+		return blame.PublishMessageError(topic, string(data), err)
+	}
+	m.logger.Debug(constant.EventPublished, log.String("topic", topic), log.Any("qos", qos))
+	return nil
+}
+
+// Subscribe subscribes to a topic filter, which may use the + and # wildcards. The
+// subscription is restored whenever the client reconnects.
+func (m *MQTTManager) Subscribe(filter string, qos QoS, processor MQTTMsgProcessor) blame.Blame {
+	m.mu.Lock()
+	if _, exists := m.subscriptions[filter]; exists {
+		m.mu.Unlock()
+		return blame.AlreadySubscribedToSubjectError(filter)
+	}
+	sub := subscription{qos: qos, handler: m.wrap(processor)}
+	m.subscriptions[filter] = sub
+	m.mu.Unlock()
+
+	if err := m.wait(m.client.Subscribe(filter, byte(qos), sub.handler)); err != nil {
+		m.mu.Lock()
+		delete(m.subscriptions, filter)
+		m.mu.Unlock()
+		m.logger.Error(constant.SubjectSubscribeFailed, log.String("topic", filter), log.Err(err))
+		return blame.SubscribeToSubjectError(filter, err)
+	}
+	m.logger.Info(constant.SubjectSubscribed, log.String("topic", filter), log.Any("qos", qos))
+	return nil
+}
+
+// Unsubscribe removes the subscription for filter.
+func (m *MQTTManager) Unsubscribe(filter string) blame.Blame {
+	m.mu.Lock()
+	delete(m.subscriptions, filter)
+	m.mu.Unlock()
+	if err := m.wait(m.client.Unsubscribe(filter)); err != nil {
+		return blame.UnsubscribeFailedError(filter, err)
+	}
+	return nil
+}
+
+// Close unsubscribes and disconnects, giving in-flight work a moment to complete.
+func (m *MQTTManager) Close() {
+	m.mu.Lock()
+	m.subscriptions = make(map[string]subscription)
+	m.mu.Unlock()
+	m.logger.Info(constant.ConnectionClosing, log.Any("message", "MQTT connection closing"))
+	m.client.Disconnect(250)
+	m.logger.Info(constant.ConnectionClosed, log.Any("message", "MQTT connection closed"))
+}
+
+// wrap adapts a processor to a paho handler with panic recovery and error logging.
+func (m *MQTTManager) wrap(processor MQTTMsgProcessor) paho.MessageHandler {
+	return func(_ paho.Client, msg paho.Message) {
+		defer func() { helpers.RecoverException(recover()) }()
+		if err := processor(msg); err != nil {
+			m.logger.Error(constant.HandlerFailed, log.String("topic", msg.Topic()), log.Any("error", err.FetchErrCode()))
+		}
+	}
+}
+
+// resubscribe restores the subscriptions after a reconnect.
+func (m *MQTTManager) resubscribe() {
+	m.mu.Lock()
+	filters := make(map[string]byte, len(m.subscriptions))
+	handlers := make(map[string]paho.MessageHandler, len(m.subscriptions))
+	for filter, sub := range m.subscriptions {
+		filters[filter] = byte(sub.qos)
+		handlers[filter] = sub.handler
+	}
+	m.mu.Unlock()
+
+	for filter, qos := range filters {
+		// Do not wait here: this runs on the client's connection goroutine.
+		m.client.Subscribe(filter, qos, handlers[filter])
+	}
+}
+
+func (m *MQTTManager) wait(token paho.Token) error {
+	if !token.WaitTimeout(m.timeout) {
+		return errors.New("mqtt operation timed out")
+	}
+	return token.Error()
+}
+
+// MatchTopic reports whether topic matches filter, which may use the single level + and
+// multi level # wildcards.
+func MatchTopic(filter, topic string) bool {
+	_, ok := TopicParams(filter, topic)
+	return ok
+}
+
+// TopicParams returns the topic levels matched by the wildcards of filter, e.g. "42" for
+// filter "devices/+/telemetry" and topic "devices/42/telemetry". A trailing # contributes the
+// remaining levels joined by "/".
+func TopicParams(filter, topic string) ([]string, bool) {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	var params []string
+	for i, level := range filterLevels {
+		switch {
+		case level == "#":
+			return append(params, strings.Join(topicLevels[i:], "/")), true
+		case i >= len(topicLevels):
+			return nil, false
+		case level == "+":
+			params = append(params, topicLevels[i])
+		case level != topicLevels[i]:
+			return nil, false
+		}
+	}
+	return params, len(filterLevels) == len(topicLevels)
+}
@@ -0,0 +1,80 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/adapters/log"
+)
+
+// Option defines a functional option for configuring MQTTManager.
+type Option func(*MQTTManager)
+
+// WithLogger sets the logger for the manager.
+func WithLogger(log *log.Log) Option {
+	return func(m *MQTTManager) {
+		m.logger = log
+		m.loggerSet = true
+	}
+}
+
+// WithClientID sets the MQTT client id. Defaults to a random id.
+func WithClientID(id string) Option {
+	return func(m *MQTTManager) {
+		m.opts.SetClientID(id)
+	}
+}
+
+// WithCredentials authenticates with a username and password.
+func WithCredentials(username, password string) Option {
+	return func(m *MQTTManager) {
+		m.opts.SetUsername(username)
+		m.opts.SetPassword(password)
+	}
+}
+
+// WithTLS connects over TLS; set Certificates on cfg for client certificate authentication.
+func WithTLS(cfg *tls.Config) Option {
+	return func(m *MQTTManager) {
+		m.opts.SetTLSConfig(cfg)
+	}
+}
+
+// WithCleanSession controls whether the broker discards the session on disconnect. Disable
+// it together with a fixed client id to receive QoS 1 and 2 messages sent while offline.
+func WithCleanSession(clean bool) Option {
+	return func(m *MQTTManager) {
+		m.opts.SetCleanSession(clean)
+	}
+}
+
+// WithKeepAlive sets the keep alive interval.
+func WithKeepAlive(d time.Duration) Option {
+	return func(m *MQTTManager) {
+		m.opts.SetKeepAlive(d)
+	}
+}
+
+// WithConnectTimeout sets how long connecting and each operation may take.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(m *MQTTManager) {
+		m.opts.SetConnectTimeout(d)
+		m.timeout = d
+	}
+}
+
+// WithWill sets the last will message the broker publishes if the client disconnects
+// unexpectedly.
+func WithWill(topic string, payload []byte, qos QoS, retained bool) Option {
+	return func(m *MQTTManager) {
+		m.opts.SetBinaryWill(topic, payload, byte(qos), retained)
+	}
+}
+
+// WithNATSBridge sets the NATS manager used by BridgeToNATS.
+func WithNATSBridge(manager *nats.NATSManager) Option {
+	return func(m *MQTTManager) {
+		m.nats = manager
+	}
+}
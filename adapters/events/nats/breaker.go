@@ -0,0 +1,82 @@
+package nats
+
+import (
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/circuitBreaker"
+	"github.com/sony/gobreaker"
+)
+
+// BreakerState is a snapshot of a circuit breaker of the manager.
+type BreakerState struct {
+	// Name of the breaker, BreakerName or "NATSRequest:<pattern>" unless set in its options.
+	Name string
+	// Pattern of the subjects of the breaker, empty for the default breaker.
+	Pattern string
+	// State is "closed", "half-open" or "open".
+	State string
+	// Counts of the requests of the current interval.
+	Counts gobreaker.Counts
+}
+
+// subjectBreaker is the circuit breaker of the subjects matching pattern.
+type subjectBreaker struct {
+	pattern string
+	options []circuitBreaker.CircuitBreakerOption
+	breaker *gobreaker.CircuitBreaker
+}
+
+// buildBreakers creates the breakers of WithCircuitBreaker and WithSubjectCircuitBreaker once
+// every option is applied, so the state hook and metrics observe them.
+func (w *NATSManager) buildBreakers() {
+	if w.breakerOptions != nil {
+		w.breaker = circuitBreaker.NewCircuitBreaker(append(w.breakerOptions, w.observeBreaker(""))...)
+	}
+	for _, sb := range w.subjectBreakers {
+		sb.breaker = circuitBreaker.NewCircuitBreaker(append(sb.options, w.observeBreaker(sb.pattern))...)
+	}
+}
+
+// observeBreaker chains the logging, metrics and WithBreakerStateHook of state changes after
+// the OnStateChange set in the options of a breaker.
+func (w *NATSManager) observeBreaker(pattern string) circuitBreaker.CircuitBreakerOption {
+	return func(s *gobreaker.Settings) {
+		onStateChange := s.OnStateChange
+		s.OnStateChange = func(name string, from, to gobreaker.State) {
+			if onStateChange != nil {
+				onStateChange(name, from, to)
+			}
+			w.logger.Warn("Circuit breaker state changed", log.String("breaker", name), log.String("pattern", pattern), log.String("from", from.String()), log.String("to", to.String()))
+			if w.metrics != nil {
+				w.metrics.breakerState.WithLabelValues(name).Set(float64(to))
+			}
+			if w.breakerHook != nil {
+				w.breakerHook(BreakerState{Name: name, Pattern: pattern, State: to.String()})
+			}
+		}
+	}
+}
+
+// breakerFor returns the breaker of the first subject pattern matching subject, else the
+// default breaker, which is nil without WithCircuitBreaker.
+func (w *NATSManager) breakerFor(subject string) *gobreaker.CircuitBreaker {
+	for _, sb := range w.subjectBreakers {
+		if sb.breaker != nil && subjectMatches(sb.pattern, subject) {
+			return sb.breaker
+		}
+	}
+	return w.breaker
+}
+
+// BreakerStates returns the state of every circuit breaker of the manager, for health checks.
+func (w *NATSManager) BreakerStates() []BreakerState {
+	states := make([]BreakerState, 0, len(w.subjectBreakers)+1)
+	if w.breaker != nil {
+		states = append(states, BreakerState{Name: w.breaker.Name(), State: w.breaker.State().String(), Counts: w.breaker.Counts()})
+	}
+	for _, sb := range w.subjectBreakers {
+		if sb.breaker != nil {
+			states = append(states, BreakerState{Name: sb.breaker.Name(), Pattern: sb.pattern, State: sb.breaker.State().String(), Counts: sb.breaker.Counts()})
+		}
+	}
+	return states
+}
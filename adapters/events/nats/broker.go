@@ -0,0 +1,102 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/nats-io/nats.go"
+)
+
+// broker adapts NATSManager to events.Broker.
+type broker struct {
+	manager    *NATSManager
+	queueGroup string
+}
+
+// Broker returns the manager as a broker independent events.Broker. Subscriptions join
+// queueGroup when it is not empty, so instances share the messages like a Kafka group.
+func (w *NATSManager) Broker(queueGroup string) events.Broker {
+	return &broker{manager: w, queueGroup: queueGroup}
+}
+
+func (b *broker) Publish(ctx context.Context, subject string, payload any) blame.Blame {
+	_, err := b.manager.PublishWithContext(ctx, subject, payload, correlationMiddleware(ctx)...)
+	return err
+}
+
+func (b *broker) PublishAndWait(ctx context.Context, subject string, payload any, timeout time.Duration) (*events.Message, blame.Blame) {
+	msg, err := b.manager.PublishAndWaitWithContext(ctx, subject, "", payload, timeout, correlationMiddleware(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+	return natsMessage(msg), nil
+}
+
+func (b *broker) Subscribe(subject string, handler events.Handler, middlewares ...events.Middleware) blame.Blame {
+	handler = events.Chain(handler, middlewares...)
+	processor := func(msg *nats.Msg) blame.Blame {
+		return handler(ContextFromMsg(msg), natsMessage(msg))
+	}
+	var err blame.Blame
+	if b.queueGroup != "" {
+		_, err = b.manager.SubscribeQueueWithMiddleware(subject, b.queueGroup, processor, nil)
+	} else {
+		_, err = b.manager.SubscribeWithMiddleware(subject, processor, nil)
+	}
+	return err
+}
+
+func (b *broker) Reply(ctx context.Context, request *events.Message, payload any) blame.Blame {
+	msg, ok := request.Raw.(*nats.Msg)
+	if !ok || msg.Reply == "" {
+		return blame.PublishMessageError(request.Subject, "", errors.New("message has no reply subject"))
+	}
+	data, err := codec.Encode(payload, codec.JSON)
+	if err != nil {
+		return blame.MarshalError(codec.JSON, err)
+	}
+	reply := &nats.Msg{Subject: msg.Reply, Data: data, Header: nats.Header{}}
+	reply.Header.Set(constant.MessageIdHeader, random.GenerateUUIDString())
+	correlationID := request.CorrelationID()
+	if correlationID == "" {
+		correlationID = events.CorrelationIDFromContext(ctx)
+	}
+	if correlationID != "" {
+		reply.Header.Set(constant.CorrelationIDHeader, correlationID)
+	}
+	if err := b.manager.Conn().PublishMsg(reply); err != nil {
+		return blame.PublishMessageError(msg.Reply, string(data), err)
+	}
+	return nil
+}
+
+func (b *broker) Ping() error {
+	return b.manager.Ping()
+}
+
+func (b *broker) Close() {
+	b.manager.Close()
+}
+
+// correlationMiddleware sets the correlation id carried by ctx on published messages.
+func correlationMiddleware(ctx context.Context) []MiddlewareFunc {
+	if correlationID := events.CorrelationIDFromContext(ctx); correlationID != "" {
+		return []MiddlewareFunc{AddHeaderMiddleware(constant.CorrelationIDHeader, correlationID)}
+	}
+	return nil
+}
+
+// natsMessage converts msg to an events.Message.
+func natsMessage(msg *nats.Msg) *events.Message {
+	headers := make(map[string]string, len(msg.Header))
+	for key := range msg.Header {
+		headers[key] = msg.Header.Get(key)
+	}
+	return &events.Message{Subject: msg.Subject, Data: msg.Data, Headers: headers, Raw: msg}
+}
@@ -7,4 +7,38 @@ const (
 	DefaultReconnectWait    = 5 * time.Second
 	DefaultMaxReconnects    = -1 // Infinite reconnection attempts
 	ConnectionFailedMessage = "connection to NATS is not yet established or failed"
+	DefaultDeadLetterSuffix = ".dlq"
+	JetStreamDisabledError  = "JetStream is not enabled for this NATS manager"
+
+	DefaultPendingLimit      = 1024
+	DefaultPendingRetryDelay = time.Second
+
+	DefaultScheduleStream        = "SCHEDULED_MESSAGES"
+	DefaultScheduleSubjectPrefix = "_scheduled"
+	DefaultScheduleQueue         = "scheduled-relay"
+	DefaultScheduleRetryDelay    = 5 * time.Second
+)
+
+// Priority classes of DefaultPriorityClasses
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+	PriorityHeader = "X-Priority"
+)
+
+// Headers of the messages stored in the schedule stream
+const (
+	ScheduledSubjectHeader = "X-Scheduled-Subject"
+	ScheduledAtHeader      = "X-Scheduled-At"
+)
+
+// Dead letter headers set on messages republished to the dead letter subject
+const (
+	DeadLetterSubjectHeader   = "X-DLQ-Original-Subject"
+	DeadLetterMessageIDHeader = "X-DLQ-Original-Message-ID"
+	DeadLetterErrorHeader     = "X-DLQ-Error"
+	DeadLetterErrorCodeHeader = "X-DLQ-Error-Code"
+	DeadLetterAttemptsHeader  = "X-DLQ-Attempts"
+	DeadLetterFailedAtHeader  = "X-DLQ-Failed-At"
 )
@@ -0,0 +1,181 @@
+package nats
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/nats-io/nats.go"
+)
+
+// DeadLetter is a message that exhausted its handler attempts, as received by SubscribeDLQ.
+type DeadLetter struct {
+	Subject   string    // Original subject of the message
+	MessageID string    // Original Message-ID of the message
+	Error     string    // Message of the last blame returned by the handler
+	ErrorCode string    // Error code of the last blame returned by the handler
+	Attempts  int       // Number of handler attempts made
+	FailedAt  time.Time // When the message was dead lettered
+	Data      []byte    // Original payload
+	Msg       *nats.Msg // The dead letter message, with the original headers
+}
+
+// DeadLetterSubject returns the dead letter subject of subject.
+func DeadLetterSubject(subject string) string {
+	return subject + DefaultDeadLetterSuffix
+}
+
+// processWithDeadLetter runs processor, retrying and dead lettering the message when dead
+// lettering is enabled. Retries never block the subscription: JetStream messages are NAKed
+// with the backoff as delay and counted by their deliveries, core NATS messages are retried
+// from a timer. The returned blame is nil once the message is dead lettered or its retry
+// scheduled.
+func (w *NATSManager) processWithDeadLetter(msg *nats.Msg, processor NATSMsgProcessor) blame.Blame {
+	if w.deadLetterAttempts <= 0 {
+		return processor(msg)
+	}
+	if meta, err := msg.Metadata(); err == nil {
+		return w.attemptJetStream(msg, processor, int(meta.NumDelivered))
+	}
+	return w.attemptCore(msg, processor, 1)
+}
+
+// attemptJetStream runs the delivery attempt of a JetStream message. A failed attempt NAKs the
+// message for redelivery after the backoff, and forgets its Message-ID so that the redelivery
+// is not dropped as a duplicate; the last one dead letters it.
+func (w *NATSManager) attemptJetStream(msg *nats.Msg, processor NATSMsgProcessor, attempt int) blame.Blame {
+	err := processor(msg)
+	if err == nil {
+		return nil
+	}
+	w.logger.Warn(constant.HandlerFailed, Slog(msg, log.String("subject", msg.Subject), log.Any("attempt", attempt), log.Any("error", err.FetchErrCode()))...)
+	if attempt < w.deadLetterAttempts {
+		messageID := msg.Header.Get(constant.MessageIdHeader)
+		if messageID != "" {
+			w.idempotencyManager.UnmarkProcessed(messageID)
+		}
+		if nakErr := msg.NakWithDelay(w.deadLetterBackoff); nakErr != nil {
+			w.logger.Error("Failed to NAK message", Slog(msg, log.Err(nakErr))...)
+			return err
+		}
+		return nil
+	}
+	return w.deadLetter(msg, err, attempt)
+}
+
+// attemptCore runs attempt of a core NATS message, scheduling the next one after the backoff
+// when it fails and dead lettering the message after the last one. Pending retries are
+// dropped when the manager is closed.
+func (w *NATSManager) attemptCore(msg *nats.Msg, processor NATSMsgProcessor, attempt int) blame.Blame {
+	err := processor(msg)
+	if err == nil {
+		return nil
+	}
+	w.logger.Warn(constant.HandlerFailed, Slog(msg, log.String("subject", msg.Subject), log.Any("attempt", attempt), log.Any("error", err.FetchErrCode()))...)
+	if attempt >= w.deadLetterAttempts {
+		return w.deadLetter(msg, err, attempt)
+	}
+
+	timer := w.clock.NewTimer(w.deadLetterBackoff)
+	go func() {
+		defer helpers.RecoverException(recover())
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+		if err := w.attemptCore(msg, processor, attempt+1); err != nil {
+			w.logger.Error(constant.HandlerFailed, Slog(msg, log.String("subject", msg.Subject), log.Any("error", err.FetchErrCode()))...)
+		}
+	}()
+	return nil
+}
+
+// deadLetter publishes msg to its dead letter subject after attempts failed with cause. It
+// returns cause when the dead letter cannot be published.
+func (w *NATSManager) deadLetter(msg *nats.Msg, cause blame.Blame, attempts int) blame.Blame {
+	if dlqErr := w.publishDeadLetter(msg, cause, attempts); dlqErr != nil {
+		w.logger.Error(constant.EventPublishedFailed, Slog(msg, log.String("subject", DeadLetterSubject(msg.Subject)), log.Err(dlqErr))...)
+		return cause
+	}
+	return nil
+}
+
+// publishDeadLetter republishes msg to its dead letter subject with the failure in headers.
+// A new Message-ID is assigned, and the JetStream Nats-Msg-Id dropped, so the dead letter is
+// not discarded as a duplicate by a stream also capturing the original subject.
+func (w *NATSManager) publishDeadLetter(msg *nats.Msg, cause blame.Blame, attempts int) error {
+	dead := &nats.Msg{
+		Subject: DeadLetterSubject(msg.Subject),
+		Data:    msg.Data,
+		Header:  nats.Header{},
+	}
+	for key, values := range msg.Header {
+		dead.Header[key] = append([]string(nil), values...)
+	}
+	dead.Header.Del(nats.MsgIdHdr)
+	dead.Header.Set(constant.MessageIdHeader, random.GenerateUUIDString())
+	dead.Header.Set(DeadLetterSubjectHeader, msg.Subject)
+	dead.Header.Set(DeadLetterMessageIDHeader, msg.Header.Get(constant.MessageIdHeader))
+	dead.Header.Set(DeadLetterAttemptsHeader, strconv.Itoa(attempts))
+	dead.Header.Set(DeadLetterFailedAtHeader, w.clock.Now().UTC().Format(time.RFC3339Nano))
+	if cause != nil {
+		dead.Header.Set(DeadLetterErrorCodeHeader, string(cause.FetchErrCode()))
+		message, _ := cause.Translate()
+		if message == "" {
+			message = cause.ErrorFromBlame().Error()
+		}
+		// Header values cannot span lines.
+		dead.Header.Set(DeadLetterErrorHeader, strings.Join(strings.Fields(message), " "))
+	}
+
+	if w.js != nil {
+		_, err := w.js.PublishMsg(dead)
+		if err == nil || !errors.Is(err, nats.ErrNoStreamResponse) {
+			return err
+		}
+		// No stream captures the dead letter subject; fall back to core NATS.
+	}
+	if err := w.nc.PublishMsg(dead); err != nil {
+		return err
+	}
+	w.logger.Warn("Message dead lettered", Slog(dead, log.String("subject", dead.Subject), log.Any("attempts", attempts))...)
+	return nil
+}
+
+// SubscribeDLQ consumes the dead letters of subject. Returning a blame from handler only logs
+// it; dead letters are never dead lettered again.
+func (w *NATSManager) SubscribeDLQ(subject string, handler func(letter *DeadLetter) blame.Blame, opts ...nats.SubOpt) (*nats.Subscription, blame.Blame) {
+	return w.Subscribe(DeadLetterSubject(subject), func(msg *nats.Msg) {
+		if err := handler(ParseDeadLetter(msg)); err != nil {
+			w.logger.Error(constant.HandlerFailed, Slog(msg, log.String("subject", msg.Subject), log.Any("error", err.FetchErrCode()))...)
+		}
+	}, opts...)
+}
+
+// ParseDeadLetter reads the dead letter headers of msg.
+func ParseDeadLetter(msg *nats.Msg) *DeadLetter {
+	attempts, _ := strconv.Atoi(msg.Header.Get(DeadLetterAttemptsHeader))
+	failedAt, _ := time.Parse(time.RFC3339Nano, msg.Header.Get(DeadLetterFailedAtHeader))
+	subject := msg.Header.Get(DeadLetterSubjectHeader)
+	if helpers.IsEmpty(subject) {
+		subject = msg.Subject
+	}
+	return &DeadLetter{
+		Subject:   subject,
+		MessageID: msg.Header.Get(DeadLetterMessageIDHeader),
+		Error:     msg.Header.Get(DeadLetterErrorHeader),
+		ErrorCode: msg.Header.Get(DeadLetterErrorCodeHeader),
+		Attempts:  attempts,
+		FailedAt:  failedAt,
+		Data:      msg.Data,
+		Msg:       msg,
+	}
+}
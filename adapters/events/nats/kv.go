@@ -0,0 +1,253 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/nats-io/nats.go"
+)
+
+// KVStore is a JetStream Key-Value bucket whose values are encoded with a codec, JSON by
+// default. Use KVGet, KVPut, KVCreate, KVUpdate and KVWatch for typed access.
+type KVStore struct {
+	bucket string
+	kv     nats.KeyValue
+	codec  types.CodecType
+	logger *log.Log
+}
+
+// KVEntry is a decoded entry of a KVStore. Value is the zero value for deleted entries.
+type KVEntry[T any] struct {
+	Key      string
+	Value    T
+	Revision uint64
+	Created  time.Time
+	Deleted  bool
+}
+
+// kvConfig holds the KVOption settings.
+type kvConfig struct {
+	bucket nats.KeyValueConfig
+	codec  types.CodecType
+}
+
+// KVOption configures a KVStore and the bucket created for it.
+type KVOption func(*kvConfig)
+
+// WithKVTTL expires values ttl after they were last written.
+func WithKVTTL(ttl time.Duration) KVOption {
+	return func(c *kvConfig) {
+		c.bucket.TTL = ttl
+	}
+}
+
+// WithKVHistory keeps the last n values of each key, up to nats.KeyValueMaxHistory.
+func WithKVHistory(n uint8) KVOption {
+	return func(c *kvConfig) {
+		c.bucket.History = n
+	}
+}
+
+// WithKVReplicas sets the number of replicas of the bucket.
+func WithKVReplicas(n int) KVOption {
+	return func(c *kvConfig) {
+		c.bucket.Replicas = n
+	}
+}
+
+// WithKVStorage sets the storage of the bucket. Defaults to file storage.
+func WithKVStorage(storage nats.StorageType) KVOption {
+	return func(c *kvConfig) {
+		c.bucket.Storage = storage
+	}
+}
+
+// WithKVDescription sets the description of the bucket.
+func WithKVDescription(description string) KVOption {
+	return func(c *kvConfig) {
+		c.bucket.Description = description
+	}
+}
+
+// WithKVCodec sets the codec values are encoded with. Defaults to codec.JSON.
+func WithKVCodec(codecType types.CodecType) KVOption {
+	return func(c *kvConfig) {
+		c.codec = codecType
+	}
+}
+
+// KV binds to the Key-Value bucket, creating it with the given options when it does not
+// exist. Options other than WithKVCodec do not change an existing bucket. JetStream must be
+// enabled with WithJetStream.
+func (w *NATSManager) KV(bucket string, opts ...KVOption) (*KVStore, blame.Blame) {
+	if w.js == nil {
+		return nil, blame.KeyValueOperationError(bucket, "", "bind", errors.New(JetStreamDisabledError))
+	}
+	cfg := &kvConfig{
+		bucket: nats.KeyValueConfig{Bucket: bucket},
+		codec:  codec.JSON,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	kv, err := w.js.KeyValue(bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = w.js.CreateKeyValue(&cfg.bucket)
+		if err == nil {
+			w.logger.Info("Key value bucket created", log.String("bucket", bucket), log.Any("ttl", cfg.bucket.TTL.String()))
+		}
+	}
+	if err != nil {
+		return nil, blame.KeyValueOperationError(bucket, "", "bind", err)
+	}
+	return &KVStore{bucket: bucket, kv: kv, codec: cfg.codec, logger: w.logger}, nil
+}
+
+// Bucket returns the name of the bucket.
+func (s *KVStore) Bucket() string {
+	return s.bucket
+}
+
+// KeyValue returns the underlying JetStream Key-Value bucket.
+func (s *KVStore) KeyValue() nats.KeyValue {
+	return s.kv
+}
+
+// Delete deletes key, keeping its history.
+func (s *KVStore) Delete(key string) blame.Blame {
+	if err := s.kv.Delete(key); err != nil {
+		return blame.KeyValueOperationError(s.bucket, key, "delete", err)
+	}
+	return nil
+}
+
+// Purge deletes key and its history.
+func (s *KVStore) Purge(key string) blame.Blame {
+	if err := s.kv.Purge(key); err != nil {
+		return blame.KeyValueOperationError(s.bucket, key, "purge", err)
+	}
+	return nil
+}
+
+// Keys returns the keys of the bucket that are not deleted.
+func (s *KVStore) Keys() ([]string, blame.Blame) {
+	keys, err := s.kv.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, blame.KeyValueOperationError(s.bucket, "", "keys", err)
+	}
+	return keys, nil
+}
+
+// KVGet returns the value of key and its revision. A missing or deleted key returns a
+// KeyValueKeyNotFound blame.
+func KVGet[T any](s *KVStore, key string) (T, uint64, blame.Blame) {
+	var value T
+	entry, err := s.kv.Get(key)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return value, 0, blame.KeyValueKeyNotFoundError(s.bucket, key)
+	}
+	if err != nil {
+		return value, 0, blame.KeyValueOperationError(s.bucket, key, "get", err)
+	}
+	value, err = codec.Decode[T](entry.Value(), s.codec)
+	if err != nil {
+		return value, 0, blame.UnMarshalError(s.codec, err)
+	}
+	return value, entry.Revision(), nil
+}
+
+// KVPut stores value under key and returns the new revision.
+func KVPut[T any](s *KVStore, key string, value T) (uint64, blame.Blame) {
+	data, err := codec.Encode(value, s.codec)
+	if err != nil {
+		return 0, blame.MarshalError(s.codec, err)
+	}
+	revision, err := s.kv.Put(key, data)
+	if err != nil {
+		return 0, blame.KeyValueOperationError(s.bucket, key, "put", err)
+	}
+	return revision, nil
+}
+
+// KVCreate stores value under key only when key does not exist, e.g. to take a lock or
+// elect a leader.
+func KVCreate[T any](s *KVStore, key string, value T) (uint64, blame.Blame) {
+	data, err := codec.Encode(value, s.codec)
+	if err != nil {
+		return 0, blame.MarshalError(s.codec, err)
+	}
+	revision, err := s.kv.Create(key, data)
+	if err != nil {
+		return 0, blame.KeyValueOperationError(s.bucket, key, "create", err)
+	}
+	return revision, nil
+}
+
+// KVUpdate stores value under key only when the latest revision of key is revision, for
+// optimistic concurrency.
+func KVUpdate[T any](s *KVStore, key string, value T, revision uint64) (uint64, blame.Blame) {
+	data, err := codec.Encode(value, s.codec)
+	if err != nil {
+		return 0, blame.MarshalError(s.codec, err)
+	}
+	revision, err = s.kv.Update(key, data, revision)
+	if err != nil {
+		return 0, blame.KeyValueOperationError(s.bucket, key, "update", err)
+	}
+	return revision, nil
+}
+
+// KVWatch calls handler with the current value of every key matching keys, a subject
+// pattern such as nats.AllKeys, and then with every change until ctx is done. Values that
+// cannot be decoded are logged and skipped. Handler calls are sequential.
+func KVWatch[T any](ctx context.Context, s *KVStore, keys string, handler func(entry KVEntry[T]), opts ...nats.WatchOpt) blame.Blame {
+	watcher, err := s.kv.Watch(keys, opts...)
+	if err != nil {
+		return blame.KeyValueOperationError(s.bucket, keys, "watch", err)
+	}
+
+	go func() {
+		defer func() {
+			_ = watcher.Stop()
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-watcher.Updates():
+				if !ok {
+					return
+				}
+				// A nil entry marks the end of the initial values.
+				if update == nil {
+					continue
+				}
+				entry := KVEntry[T]{
+					Key:      update.Key(),
+					Revision: update.Revision(),
+					Created:  update.Created(),
+					Deleted:  update.Operation() != nats.KeyValuePut,
+				}
+				if !entry.Deleted {
+					value, err := codec.Decode[T](update.Value(), s.codec)
+					if err != nil {
+						s.logger.Warn(blame.ErrorUnmarshalFailed.String(), log.String("bucket", s.bucket), log.String("key", entry.Key), log.Err(err))
+						continue
+					}
+					entry.Value = value
+				}
+				handler(entry)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,69 @@
+package nats
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus collectors of NATS subscriptions, labelled by subject, and the
+// state of the circuit breakers, labelled by breaker name.
+type Metrics struct {
+	queueDepth         *prometheus.GaugeVec
+	rejected           *prometheus.CounterVec
+	processingDuration *prometheus.HistogramVec
+	breakerState       *prometheus.GaugeVec
+}
+
+// NewMetrics registers the NATS subscription collectors with registerer. Collectors that are
+// already registered are reused, so managers may share them.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nats_subscription_pending_messages",
+			Help: "Number of messages waiting for a worker.",
+		}, []string{"subject"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nats_subscription_rejected_total",
+			Help: "Number of JetStream messages NAKed because the subscription queue was full.",
+		}, []string{"subject"}),
+		processingDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nats_message_processing_duration_seconds",
+			Help:    "Time taken by subscription handlers to process a message.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"subject"}),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "nats_circuit_breaker_state",
+			Help: "State of the circuit breakers: 0 closed, 1 half-open, 2 open.",
+		}, []string{"breaker"}),
+	}
+
+	var err error
+	if m.queueDepth, err = register(registerer, m.queueDepth); err != nil {
+		return nil, err
+	}
+	if m.rejected, err = register(registerer, m.rejected); err != nil {
+		return nil, err
+	}
+	if m.processingDuration, err = register(registerer, m.processingDuration); err != nil {
+		return nil, err
+	}
+	if m.breakerState, err = register(registerer, m.breakerState); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register registers c, returning the existing collector when one is already registered.
+func register[C prometheus.Collector](registerer prometheus.Registerer, c C) (C, error) {
+	if err := registerer.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
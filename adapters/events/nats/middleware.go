@@ -51,7 +51,7 @@ func LogMiddleware(eventType string, logger *log.Log) MiddlewareFunc {
 	return func(next NATSMsgProcessor) NATSMsgProcessor {
 		return func(msg *nats.Msg) blame.Blame {
 			defer func() { helpers.RecoverException(recover()) }()
-			logger.Info(constant.EventProcessed+" : "+eventType, log.Any("nats.subject", msg.Subject), log.Any("nats.reply", msg.Reply), logger.SanitizeAny("nats.header", msg.Header), logger.SanitizeAny("nats.data", json.RawMessage(msg.Data)))
+			logger.Info(constant.EventProcessed+" : "+eventType, log.String("nats.subject", msg.Subject), log.String("nats.reply", msg.Reply), logger.LazySanitizeAny("nats.header", msg.Header), logger.LazySanitizeAny("nats.data", json.RawMessage(msg.Data)))
 			err := next(msg)
 			if err != nil {
 				if logger == nil {
@@ -10,11 +10,14 @@ import (
 
 	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/circuitBreaker"
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/idempotency"
 	"github.com/abhissng/neuron/utils/types"
 	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/nats-io/nats.go"
 )
@@ -33,10 +36,30 @@ type NATSManager struct {
 	loggerSet          bool
 	idempotencyManager *idempotency.IdempotencyManager[string]
 	breaker            *gobreaker.CircuitBreaker
+	breakerOptions     []circuitBreaker.CircuitBreakerOption
+	subjectBreakers    []*subjectBreaker // Breakers by subject pattern, in priority order
+	breakerHook        func(BreakerState)
 	subjects           map[string]*nats.Subscription
 	subParams          map[string]*subscriptionParams // Track subscription parameters
 	done               chan struct{}                  // Channel to signal shutdown
 	reconnect          bool                           // Flag to enable auto-reconnection
+	deadLetterAttempts int                            // Handler attempts before dead lettering; 0 disables it
+	deadLetterBackoff  time.Duration                  // Wait between handler attempts
+	concurrency        int                            // Workers per subscription; 0 runs handlers inline
+	pendingLimit       int                            // Messages queued per subscription before backpressure
+	pendingRetryDelay  time.Duration                  // Redelivery delay of JetStream messages rejected by a full queue
+	pools              map[string]*subscriptionPool   // Worker pools by subject
+	workers            sync.WaitGroup                 // Pool and priority class workers, waited for by Close
+	metrics            *Metrics
+	scheduler          *scheduler // Delayed and recurring publishes
+	priorityClasses    []PriorityClass
+	priorityDefault    string
+	subjectPriorities  []subjectPriority
+	priorities         *priorityScheduler // Class queues when priority classes are set
+	streamSpecs        []StreamSpec       // Streams provisioned on creation
+	consumerSpecs      []ConsumerSpec     // Consumers provisioned on creation
+	tracer             trace.Tracer       // Span per published and processed message when set
+	clock              clock.Clock        // Time source of scheduled publishes
 }
 
 // subscriptionParams stores the parameters needed to recreate a subscription.
@@ -46,6 +69,9 @@ type subscriptionKind uint8
 const (
 	subscriptionKindPush subscriptionKind = iota
 	subscriptionKindPull
+	// subscriptionKindCore subscriptions bypass JetStream even when it is configured, e.g.
+	// request/reply responders.
+	subscriptionKindCore
 )
 
 type subscriptionParams struct {
@@ -231,11 +257,26 @@ func NewNATSManager(url string, options ...Option) (*NATSManager, error) {
 		done:               make(chan struct{}),
 		reconnect:          true,
 		breaker:            nil,
+		pendingLimit:       DefaultPendingLimit,
+		pendingRetryDelay:  DefaultPendingRetryDelay,
+		pools:              make(map[string]*subscriptionPool),
+		scheduler:          newScheduler(),
+		clock:              clock.System,
 	}
 
 	for _, opt := range options {
 		opt(manager)
 	}
+	manager.buildBreakers()
+	if len(manager.streamSpecs) > 0 || len(manager.consumerSpecs) > 0 {
+		if err := manager.Provision(manager.streamSpecs, manager.consumerSpecs); err != nil {
+			nc.Close()
+			return nil, err.ErrorFromBlame()
+		}
+	}
+	if len(manager.priorityClasses) > 0 {
+		manager.startPriorities()
+	}
 
 	if manager.loggerSet {
 		_ = defaultLog.Sync()
@@ -269,12 +310,10 @@ func (w *NATSManager) IsClosed() bool {
 }
 
 // Close gracefully shuts down the NATS manager.
-// It unsubscribes from all subjects, closes connections, and cleans up resources.
+// It unsubscribes from all subjects, waits for the workers to process the queued messages,
+// closes connections, and cleans up resources.
 func (w *NATSManager) Close() {
 	w.mu.Lock()
-	defer w.mu.Unlock()
-	defer close(w.done)
-
 	for subject, sub := range w.subjects {
 		if err := sub.Unsubscribe(); err != nil {
 			b := blame.UnsubscribeFailedError(subject, err)
@@ -286,6 +325,25 @@ func (w *NATSManager) Close() {
 	// Clear the map to prevent double Unsubscribe
 	w.subjects = make(map[string]*nats.Subscription)
 
+	for _, pool := range w.pools {
+		pool.close()
+	}
+	w.pools = make(map[string]*subscriptionPool)
+	if w.priorities != nil {
+		w.priorities.close()
+	}
+	w.mu.Unlock()
+
+	// Handlers may still ack, publish or subscribe, so the connection stays up and the lock
+	// free until they return.
+	w.workers.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer close(w.done)
+
+	w.stopScheduler()
+
 	if w.nc != nil && !w.nc.IsClosed() {
 		w.logger.Info(constant.ConnectionClosing, log.Any("message", "NATS connection closing"))
 		_ = w.nc.Drain()
@@ -303,10 +361,21 @@ func (w *NATSManager) IsJetStreamEnabled() bool {
 	return w.js != nil
 }
 
+// JetStream returns the JetStream context, or nil when JetStream is not enabled.
+func (w *NATSManager) JetStream() nats.JetStreamContext {
+	return w.js
+}
+
+// Conn returns the underlying NATS connection.
+func (w *NATSManager) Conn() *nats.Conn {
+	return w.nc
+}
+
 // ackIfJetStream sends an ACK if using JetStream
 func (w *NATSManager) ackIfJetStream(msg *nats.Msg) {
 	if w.js != nil {
-		if err := msg.Ack(); err != nil {
+		// Messages NAKed for a dead letter retry are already acknowledged.
+		if err := msg.Ack(); err != nil && !errors.Is(err, nats.ErrMsgAlreadyAckd) {
 			w.logger.Error("Failed to ACK message", log.Any("error", err))
 		}
 	}
@@ -427,7 +496,7 @@ func (w *NATSManager) resubscribe(subject string) {
 		var sub *nats.Subscription
 		var err error
 
-		if w.js != nil {
+		if w.js != nil && params.kind != subscriptionKindCore {
 			if params.kind == subscriptionKindPull {
 				sub, err = w.js.PullSubscribe(subject, params.pullConsumer, params.subOpts...)
 			} else if params.queue != "" {
@@ -476,10 +545,12 @@ func FetchMessageAndCorrelationField(msg *nats.Msg) []types.Field {
 // Slog creates a structured log entry with message metadata and optional additional fields.
 // It combines message ID, correlation ID, IP header, and any provided fields.
 func Slog(msg *nats.Msg, withFields ...types.Field) []types.Field {
-	// Start with the message and correlation fields
+	// Start with the message and correlation fields, sized so the entry takes one allocation
 	fields := make([]types.Field, 0, 3+len(withFields))
-	fields = append(fields, FetchMessageAndCorrelationField(msg)...)
-	fields = append(fields, log.String(constant.IPHeader, helpers.IPHeaderFromNatsMsg(msg)))
+	fields = append(fields,
+		log.String(constant.MessageIdHeader, helpers.MessageIDFromNatsMsg(msg)),
+		log.String(constant.CorrelationIDHeader, helpers.CorrelationIDFromNatsMsg(msg)),
+		log.String(constant.IPHeader, helpers.IPHeaderFromNatsMsg(msg)))
 
 	// Append additional fields provided as variadic arguments
 	fields = append(fields, withFields...)
@@ -504,3 +575,13 @@ func (w *NATSManager) RunSafely(fn func()) {
 	}()
 	fn()
 }
+
+// executeWithBreaker runs fn, publishing to subject, through the circuit breaker of subject, or
+// directly when it has none.
+func (w *NATSManager) executeWithBreaker(subject string, fn func() (interface{}, error)) (interface{}, error) {
+	breaker := w.breakerFor(subject)
+	if breaker == nil {
+		return fn()
+	}
+	return breaker.Execute(fn)
+}
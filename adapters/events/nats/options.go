@@ -6,9 +6,10 @@ import (
 
 	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/utils/circuitBreaker"
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/idempotency"
 	"github.com/nats-io/nats.go"
-	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Option defines a functional option for configuring NATSManager.
@@ -59,6 +60,22 @@ func WithJetStream(cfgs JetStreamOptions, opts ...nats.JSOpt) Option {
 	}
 }
 
+// WithStreams ensures the streams with EnsureStream when the manager is created, which fails
+// when one cannot be provisioned. It requires WithJetStream.
+func WithStreams(specs ...StreamSpec) Option {
+	return func(w *NATSManager) {
+		w.streamSpecs = append(w.streamSpecs, specs...)
+	}
+}
+
+// WithConsumers ensures the consumers with EnsureConsumer, after the streams of WithStreams,
+// when the manager is created. It requires WithJetStream.
+func WithConsumers(specs ...ConsumerSpec) Option {
+	return func(w *NATSManager) {
+		w.consumerSpecs = append(w.consumerSpecs, specs...)
+	}
+}
+
 // WithLogger sets the logger  for the manager.
 func WithLogger(log *log.Log) Option {
 	return func(w *NATSManager) {
@@ -74,9 +91,27 @@ func WithCircuitBreaker(options ...circuitBreaker.CircuitBreakerOption) Option {
 	}
 
 	return func(w *NATSManager) {
-		w.breaker = &gobreaker.CircuitBreaker{}
-		w.breaker = circuitBreaker.NewCircuitBreaker(
-			options...)
+		w.breakerOptions = options
+	}
+}
+
+// WithSubjectCircuitBreaker gives the subjects matching pattern a circuit breaker of their own,
+// so failures on one subject do not open the circuit of the others. The options set its trip
+// threshold (circuitBreaker.WithFailureThreshold or WithReadyToTrip), open timeout and number
+// of half-open probes (WithMaxRequests). The first matching pattern applies; other subjects use
+// the breaker of WithCircuitBreaker, if any.
+func WithSubjectCircuitBreaker(pattern string, options ...circuitBreaker.CircuitBreakerOption) Option {
+	options = append([]circuitBreaker.CircuitBreakerOption{circuitBreaker.WithName(BreakerName + ":" + pattern)}, options...)
+	return func(w *NATSManager) {
+		w.subjectBreakers = append(w.subjectBreakers, &subjectBreaker{pattern: pattern, options: options})
+	}
+}
+
+// WithBreakerStateHook calls hook whenever a circuit breaker of the manager changes state,
+// e.g. to feed a health check or alerting.
+func WithBreakerStateHook(hook func(state BreakerState)) Option {
+	return func(w *NATSManager) {
+		w.breakerHook = hook
 	}
 }
 
@@ -86,3 +121,92 @@ func WithIdempotencyManager(cleanUpInterval time.Duration) Option {
 		w.idempotencyManager = idempotency.NewIdempotencyManager[string](cleanUpInterval)
 	}
 }
+
+// WithDeadLetter retries a handler returning a blame up to maxAttempts times in total, waiting
+// backoff between attempts, then republishes the message to "<subject>.dlq" with the error in
+// X-DLQ-* headers. It applies to SubscribeWithMiddleware and SubscribeQueueWithMiddleware.
+// JetStream messages are retried by redelivery, so the MaxDeliver of their consumer must
+// allow maxAttempts deliveries.
+func WithDeadLetter(maxAttempts int, backoff time.Duration) Option {
+	return func(w *NATSManager) {
+		w.deadLetterAttempts = maxAttempts
+		w.deadLetterBackoff = backoff
+	}
+}
+
+// WithConcurrency runs the handlers of each push subscription on n workers instead of inline
+// in the subscription callback, so a slow handler does not stall delivery. Messages of a
+// subject may then be processed out of order. JetStream AckWait must cover the time messages
+// wait in the queue.
+func WithConcurrency(n int) Option {
+	return func(w *NATSManager) {
+		w.concurrency = n
+	}
+}
+
+// WithPendingLimit sets how many messages of a subscription may wait for a worker when
+// WithConcurrency is used. Once the limit is reached, JetStream messages are NAKed to be
+// redelivered after retryDelay and core NATS delivery blocks until a worker is free.
+// Defaults to DefaultPendingLimit and DefaultPendingRetryDelay.
+func WithPendingLimit(limit int, retryDelay time.Duration) Option {
+	return func(w *NATSManager) {
+		w.pendingLimit = limit
+		w.pendingRetryDelay = retryDelay
+	}
+}
+
+// WithPriorityClasses processes the messages of every push subscription in the queue of their
+// class, chosen by the PriorityHeader of the message, else by WithSubjectPriority, else
+// defaultClass. Each class has its own workers, and the WithConcurrency workers are shared
+// between the classes by weight, so bulk traffic cannot starve latency sensitive traffic of
+// the same service. Like WithConcurrency, messages may be processed out of order.
+func WithPriorityClasses(defaultClass string, classes ...PriorityClass) Option {
+	return func(w *NATSManager) {
+		w.priorityDefault = defaultClass
+		w.priorityClasses = classes
+	}
+}
+
+// WithSubjectPriority processes the messages of the subjects matching pattern, which may use
+// the * and > wildcards, in class. Patterns are tried in the order they are added.
+func WithSubjectPriority(pattern, class string) Option {
+	return func(w *NATSManager) {
+		w.subjectPriorities = append(w.subjectPriorities, subjectPriority{pattern: pattern, class: class})
+	}
+}
+
+// WithMetrics records the queue depth, rejections and processing latency of every
+// subscription, labelled by subject, in the given Prometheus collectors.
+func WithMetrics(metrics *Metrics) Option {
+	return func(w *NATSManager) {
+		w.metrics = metrics
+	}
+}
+
+// WithTracer creates a span per published and processed message with tracer. The trace
+// context travels in the traceparent header, written and read with the propagator set by
+// otel.SetTextMapPropagator; the spans of a conversation share its correlation id.
+func WithTracer(tracer trace.Tracer) Option {
+	return func(w *NATSManager) {
+		w.tracer = tracer
+	}
+}
+
+// WithScheduling sets the JetStream stream and subject prefix delayed messages are stored
+// under until they are due, and the durable queue consumer relaying them. Defaults to
+// DefaultScheduleStream, DefaultScheduleSubjectPrefix and DefaultScheduleQueue.
+func WithScheduling(stream, subjectPrefix, queue string) Option {
+	return func(w *NATSManager) {
+		w.scheduler.stream = stream
+		w.scheduler.prefix = subjectPrefix
+		w.scheduler.queue = queue
+	}
+}
+
+// WithClock sets the clock timing delayed and recurring publishes, e.g. a clock.Fake in tests.
+// Defaults to clock.System.
+func WithClock(c clock.Clock) Option {
+	return func(w *NATSManager) {
+		w.clock = clock.OrSystem(c)
+	}
+}
@@ -0,0 +1,374 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/database"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	DefaultOutboxTable        = "nats_outbox"
+	DefaultOutboxBatchSize    = 100
+	DefaultOutboxPollInterval = time.Second
+	// DefaultOutboxMaxAttempts is how many times a message is published before it is parked.
+	DefaultOutboxMaxAttempts = 10
+	// DefaultOutboxRetryBackoff is the wait after the first failed publish; it doubles with
+	// every further failure up to DefaultOutboxMaxRetryBackoff.
+	DefaultOutboxRetryBackoff    = time.Second
+	DefaultOutboxMaxRetryBackoff = 5 * time.Minute
+)
+
+// OutboxSchema returns the Postgres DDL of an outbox table. It also upgrades tables created
+// before messages were retried with backoff and parked.
+func OutboxSchema(table string) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id              UUID PRIMARY KEY,
+	subject         TEXT NOT NULL,
+	data            BYTEA NOT NULL,
+	headers         JSONB NOT NULL DEFAULT '{}',
+	created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	published_at    TIMESTAMPTZ,
+	attempts        INT NOT NULL DEFAULT 0,
+	last_error      TEXT,
+	next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	parked_at       TIMESTAMPTZ
+);
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS parked_at TIMESTAMPTZ;
+DROP INDEX IF EXISTS %[1]s_pending_idx;
+CREATE INDEX IF NOT EXISTS %[1]s_due_idx ON %[1]s (next_attempt_at) WHERE published_at IS NULL AND parked_at IS NULL;`, table)
+}
+
+// Outbox implements the transactional outbox pattern. Enqueue writes messages to a Postgres
+// table in the transaction of the caller, so they are stored if and only if the business
+// change commits, and the relay started by Start publishes them to JetStream.
+//
+// Each message is published with its outbox id as Message-ID and as the JetStream Nats-Msg-Id,
+// so a message published again after a relay crash, before it was marked published, is
+// dropped by the stream's duplicate window and by the Message-ID based idempotency of
+// subscribers.
+type Outbox struct {
+	w            *NATSManager
+	db           database.Database
+	table        string
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	logger       *log.Log
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OutboxOption configures an Outbox.
+type OutboxOption func(*Outbox)
+
+// WithOutboxTable sets the outbox table. Defaults to DefaultOutboxTable.
+func WithOutboxTable(table string) OutboxOption {
+	return func(o *Outbox) {
+		o.table = table
+	}
+}
+
+// WithOutboxBatchSize sets the number of messages the relay publishes per transaction.
+func WithOutboxBatchSize(size int) OutboxOption {
+	return func(o *Outbox) {
+		o.batchSize = size
+	}
+}
+
+// WithOutboxPollInterval sets how often the relay looks for unpublished messages.
+func WithOutboxPollInterval(interval time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.pollInterval = interval
+	}
+}
+
+// WithOutboxRetry sets how many times a message is published before it is parked, and the
+// wait after its first failure, which doubles with every further failure up to maxBackoff.
+// Defaults to DefaultOutboxMaxAttempts, DefaultOutboxRetryBackoff and
+// DefaultOutboxMaxRetryBackoff.
+func WithOutboxRetry(maxAttempts int, backoff, maxBackoff time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.maxAttempts = maxAttempts
+		o.backoff = backoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithOutboxLogger sets the logger of the outbox. Defaults to the logger of the manager.
+func WithOutboxLogger(logger *log.Log) OutboxOption {
+	return func(o *Outbox) {
+		o.logger = logger
+	}
+}
+
+// NewOutbox creates an Outbox storing messages in db and relaying them through w, which must
+// have JetStream enabled. Create the table with OutboxSchema.
+func NewOutbox(w *NATSManager, db database.Database, opts ...OutboxOption) (*Outbox, error) {
+	if w.js == nil {
+		return nil, errors.New(JetStreamDisabledError)
+	}
+	o := &Outbox{
+		w:            w,
+		db:           db,
+		table:        DefaultOutboxTable,
+		batchSize:    DefaultOutboxBatchSize,
+		pollInterval: DefaultOutboxPollInterval,
+		maxAttempts:  DefaultOutboxMaxAttempts,
+		backoff:      DefaultOutboxRetryBackoff,
+		maxBackoff:   DefaultOutboxMaxRetryBackoff,
+		logger:       w.logger,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o, nil
+}
+
+// Enqueue encodes payload as JSON and writes it to the outbox with exec, which should be the
+// transaction of the business change. The correlation id of ctx is added to headers. It
+// returns the Message-ID the message will be published with.
+func (o *Outbox) Enqueue(ctx context.Context, exec database.Executor, subject string, payload any, headers map[string]string) (string, error) {
+	data, err := codec.Encode(payload, codec.JSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode outbox message: %w", err)
+	}
+	return o.EnqueueRaw(ctx, exec, subject, data, headers)
+}
+
+// EnqueueRaw is Enqueue for an already encoded payload.
+func (o *Outbox) EnqueueRaw(ctx context.Context, exec database.Executor, subject string, data []byte, headers map[string]string) (string, error) {
+	all := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		all[k] = v
+	}
+	if correlationID := events.CorrelationIDFromContext(ctx); correlationID != "" {
+		if _, ok := all[constant.CorrelationIDHeader]; !ok {
+			all[constant.CorrelationIDHeader] = correlationID
+		}
+	}
+	encodedHeaders, err := json.Marshal(all)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode outbox headers: %w", err)
+	}
+
+	id := random.GenerateUUIDString()
+	query := fmt.Sprintf("INSERT INTO %s (id, subject, data, headers) VALUES ($1, $2, $3, $4)", o.table)
+	if _, err := exec.Exec(ctx, query, id, subject, data, encodedHeaders); err != nil {
+		return "", fmt.Errorf("failed to write outbox message: %w", err)
+	}
+	return id, nil
+}
+
+// Start starts the relay. It stops when ctx is done or Stop is called.
+func (o *Outbox) Start(ctx context.Context) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.stop != nil {
+		return
+	}
+	o.stop = make(chan struct{})
+	o.done = make(chan struct{})
+	go o.run(ctx, o.stop, o.done)
+}
+
+// Stop stops the relay and waits for the current batch to finish.
+func (o *Outbox) Stop() {
+	o.mu.Lock()
+	stop, done := o.stop, o.done
+	o.stop, o.done = nil, nil
+	o.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (o *Outbox) run(ctx context.Context, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	for {
+		// Keep relaying while full batches are found, so a backlog drains without waiting
+		for {
+			n, err := o.Relay(ctx)
+			if err != nil {
+				o.logger.Error("Outbox relay failed", log.String("table", o.table), log.Err(err))
+			}
+			if err != nil || n < o.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// outboxRow is a message read from the outbox table.
+type outboxRow struct {
+	id       string
+	subject  string
+	data     []byte
+	headers  []byte
+	attempts int
+}
+
+// Relay publishes one batch of due messages, oldest first, and returns how many were
+// published. Rows are locked with SKIP LOCKED, so several relays can run concurrently. Messages
+// that fail to publish stay in the outbox with their attempts and last error recorded and are
+// retried after a backoff, so they do not hold up the messages behind them; once they reach
+// the maximum attempts they are parked until RequeueParked.
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	tx, err := o.db.BeginTransaction(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	pending, err := o.pending(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, row := range pending {
+		if pubErr := o.publish(row); pubErr != nil {
+			if err := o.recordFailure(ctx, tx, row, pubErr); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		query := fmt.Sprintf("UPDATE %s SET published_at = now(), attempts = attempts + 1, last_error = NULL WHERE id = $1", o.table)
+		if _, err := tx.Exec(ctx, query, row.id); err != nil {
+			return 0, fmt.Errorf("failed to mark outbox message published: %w", err)
+		}
+		published++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox transaction: %w", err)
+	}
+	return published, nil
+}
+
+// recordFailure records a failed publish of row, scheduling its next attempt or parking it.
+func (o *Outbox) recordFailure(ctx context.Context, tx database.Transaction, row outboxRow, pubErr error) error {
+	attempts := row.attempts + 1
+	if o.maxAttempts > 0 && attempts >= o.maxAttempts {
+		o.logger.Error("Outbox message parked", log.String("id", row.id), log.String("subject", row.subject), log.Any("attempts", attempts), log.Err(pubErr))
+		query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = $2, parked_at = now() WHERE id = $1", o.table)
+		if _, err := tx.Exec(ctx, query, row.id, pubErr.Error()); err != nil {
+			return fmt.Errorf("failed to park outbox message: %w", err)
+		}
+		return nil
+	}
+
+	delay := o.retryDelay(attempts)
+	o.logger.Warn("Outbox message publish failed", log.String("id", row.id), log.String("subject", row.subject), log.Any("attempts", attempts), log.Any("retry_in", delay.String()), log.Err(pubErr))
+	query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = $2, next_attempt_at = now() + $3 * interval '1 millisecond' WHERE id = $1", o.table)
+	if _, err := tx.Exec(ctx, query, row.id, pubErr.Error(), delay.Milliseconds()); err != nil {
+		return fmt.Errorf("failed to record outbox failure: %w", err)
+	}
+	return nil
+}
+
+// retryDelay returns the wait before the next publish of a message that failed attempts times.
+func (o *Outbox) retryDelay(attempts int) time.Duration {
+	delay := o.backoff
+	for i := 1; i < attempts && (o.maxBackoff <= 0 || delay < o.maxBackoff); i++ {
+		delay *= 2
+	}
+	if o.maxBackoff > 0 {
+		delay = min(delay, o.maxBackoff)
+	}
+	return delay
+}
+
+// RequeueParked makes the parked messages due again with their attempts reset, e.g. once the
+// cause of their failures is fixed, and returns how many were requeued.
+func (o *Outbox) RequeueParked(ctx context.Context) (int64, error) {
+	query := fmt.Sprintf("UPDATE %s SET parked_at = NULL, attempts = 0, next_attempt_at = now() WHERE parked_at IS NOT NULL AND published_at IS NULL", o.table)
+	res, err := o.db.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue parked outbox messages: %w", err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// pending locks and reads a batch of due messages.
+func (o *Outbox) pending(ctx context.Context, tx database.Transaction) ([]outboxRow, error) {
+	query := fmt.Sprintf(`SELECT id::text, subject, data, headers, attempts FROM %s
+WHERE published_at IS NULL AND parked_at IS NULL AND next_attempt_at <= now()
+ORDER BY created_at LIMIT $1 FOR UPDATE SKIP LOCKED`, o.table)
+	rows, err := tx.Query(ctx, query, o.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var pending []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		if err := rows.Scan(&row.id, &row.subject, &row.data, &row.headers, &row.attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		pending = append(pending, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	return pending, nil
+}
+
+// publish publishes row to JetStream with its id as Message-ID and Nats-Msg-Id.
+func (o *Outbox) publish(row outboxRow) error {
+	msg := &nats.Msg{Subject: row.subject, Data: row.data, Header: nats.Header{}}
+	if len(row.headers) > 0 {
+		var headers map[string]string
+		if err := json.Unmarshal(row.headers, &headers); err != nil {
+			return fmt.Errorf("invalid outbox headers: %w", err)
+		}
+		for k, v := range headers {
+			msg.Header.Set(k, v)
+		}
+	}
+	msg.Header.Set(constant.MessageIdHeader, row.id)
+	msg.Header.Set(nats.MsgIdHdr, row.id)
+
+	_, err := o.w.executeWithBreaker(row.subject, func() (interface{}, error) {
+		return o.w.js.PublishMsg(msg)
+	})
+	return err
+}
+
+// Cleanup deletes messages published more than olderThan ago and returns how many were
+// deleted.
+func (o *Outbox) Cleanup(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE published_at IS NOT NULL AND published_at < $1", o.table)
+	res, err := o.db.Exec(ctx, query, time.Now().UTC().Add(-olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("failed to clean up outbox: %w", err)
+	}
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,121 @@
+package nats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/nats-io/nats.go"
+)
+
+// subscriptionPool runs the handler of a subscription on a bounded pool of workers.
+type subscriptionPool struct {
+	subject string
+	queue   chan *nats.Msg
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// close stops the workers once the queued messages are processed.
+func (p *subscriptionPool) close() {
+	p.once.Do(func() { close(p.stop) })
+}
+
+// dispatch wraps the handler of a subscription to subject with the processing latency metric
+// and either the class queues of WithPriorityClasses or, with WithConcurrency, a worker pool.
+// The returned function stops the pool and must be called when the subscription could not be
+// created.
+func (w *NATSManager) dispatch(subject string, handler nats.MsgHandler) (nats.MsgHandler, func()) {
+	handler = w.traced(subject, handler)
+	measured := handler
+	if w.metrics != nil {
+		measured = func(msg *nats.Msg) {
+			start := time.Now()
+			defer func() {
+				w.metrics.processingDuration.WithLabelValues(subject).Observe(time.Since(start).Seconds())
+			}()
+			handler(msg)
+		}
+	}
+	if w.priorities != nil {
+		return func(msg *nats.Msg) { w.enqueuePriority(msg, measured) }, func() {}
+	}
+	if w.concurrency <= 0 {
+		return measured, func() {}
+	}
+
+	pool := &subscriptionPool{
+		subject: subject,
+		queue:   make(chan *nats.Msg, max(w.pendingLimit, 1)),
+		stop:    make(chan struct{}),
+	}
+	for range w.concurrency {
+		w.startWorker(func() { w.runWorker(pool, measured) })
+	}
+	w.pools[subject] = pool
+	return func(msg *nats.Msg) { w.enqueue(pool, msg) }, func() {
+		pool.close()
+		delete(w.pools, subject)
+	}
+}
+
+// enqueue queues msg for a worker. When the queue is full, JetStream messages are NAKed for
+// later redelivery and core NATS messages wait for room, holding up the subscription.
+func (w *NATSManager) enqueue(pool *subscriptionPool, msg *nats.Msg) {
+	select {
+	case pool.queue <- msg:
+		w.observeQueue(pool)
+		return
+	default:
+	}
+
+	if _, err := msg.Metadata(); err == nil {
+		if w.metrics != nil {
+			w.metrics.rejected.WithLabelValues(pool.subject).Inc()
+		}
+		w.logger.Warn("Subscription queue full, message NAKed for redelivery", Slog(msg, log.String("subject", pool.subject), log.Any("pending", len(pool.queue)))...)
+		_ = msg.NakWithDelay(w.pendingRetryDelay)
+		return
+	}
+
+	select {
+	case pool.queue <- msg:
+		w.observeQueue(pool)
+	case <-pool.stop:
+	}
+}
+
+// startWorker runs worker in a goroutine tracked by Close.
+func (w *NATSManager) startWorker(worker func()) {
+	w.workers.Add(1)
+	go func() {
+		defer w.workers.Done()
+		worker()
+	}()
+}
+
+// runWorker processes queued messages until the pool is closed and drained.
+func (w *NATSManager) runWorker(pool *subscriptionPool, handler nats.MsgHandler) {
+	for {
+		select {
+		case msg := <-pool.queue:
+			w.observeQueue(pool)
+			handler(msg)
+		case <-pool.stop:
+			for {
+				select {
+				case msg := <-pool.queue:
+					handler(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *NATSManager) observeQueue(pool *subscriptionPool) {
+	if w.metrics != nil {
+		w.metrics.queueDepth.WithLabelValues(pool.subject).Set(float64(len(pool.queue)))
+	}
+}
@@ -0,0 +1,248 @@
+package nats
+
+import (
+	"sync"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/nats-io/nats.go"
+)
+
+// PriorityClass is a tier of message processing with a queue and workers of its own, so a
+// backlog in one class cannot hold up the messages of another.
+type PriorityClass struct {
+	// Name identifies the class in WithSubjectPriority and the PriorityHeader.
+	Name string
+	// Workers are dedicated to the class and only process its messages.
+	Workers int
+	// Weight is the share of the shared WithConcurrency workers the class gets while classes
+	// compete for them. Defaults to 1.
+	Weight int
+	// QueueLimit is the number of messages queued before backpressure applies, as described
+	// in WithPendingLimit. Defaults to the pending limit.
+	QueueLimit int
+}
+
+// DefaultPriorityClasses returns a latency sensitive "high" class, a "normal" class and a
+// "low" class for bulk work, weighted 8:4:1, with one dedicated worker each.
+func DefaultPriorityClasses() []PriorityClass {
+	return []PriorityClass{
+		{Name: PriorityHigh, Workers: 1, Weight: 8},
+		{Name: PriorityNormal, Workers: 1, Weight: 4},
+		{Name: PriorityLow, Workers: 1, Weight: 1},
+	}
+}
+
+// PriorityMiddleware returns a publish middleware setting the class messages are processed
+// in, overriding the class of their subject.
+func PriorityMiddleware(class string) MiddlewareFunc {
+	return AddHeaderMiddleware(PriorityHeader, class)
+}
+
+// priorityItem is a message waiting in a class queue with the handler of its subscription.
+type priorityItem struct {
+	msg     *nats.Msg
+	handler nats.MsgHandler
+}
+
+// priorityQueue is the queue of a class and its remaining budget in the current round.
+type priorityQueue struct {
+	class  PriorityClass
+	queue  chan priorityItem
+	credit int
+}
+
+// subjectPriority assigns the subjects matching pattern to a class.
+type subjectPriority struct {
+	pattern string
+	class   string
+}
+
+// priorityScheduler queues the messages of every subscription by class. Each class is served
+// by its dedicated workers, and the shared workers serve the classes by weighted round robin:
+// in each round a class may take as many messages as its weight before the budgets are
+// refilled, so low classes progress without starving high ones.
+type priorityScheduler struct {
+	mu           sync.Mutex
+	queues       []*priorityQueue
+	byName       map[string]*priorityQueue
+	defaultClass string
+	subjects     []subjectPriority
+	cursor       int
+	ready        chan struct{}
+	stop         chan struct{}
+	once         sync.Once
+}
+
+// startPriorities creates the queues of the classes set with WithPriorityClasses and starts
+// their dedicated workers and the shared workers.
+func (w *NATSManager) startPriorities() {
+	s := &priorityScheduler{
+		byName:       make(map[string]*priorityQueue, len(w.priorityClasses)),
+		defaultClass: w.priorityDefault,
+		subjects:     w.subjectPriorities,
+		stop:         make(chan struct{}),
+	}
+	capacity := 0
+	for _, class := range w.priorityClasses {
+		if class.Weight <= 0 {
+			class.Weight = 1
+		}
+		if class.QueueLimit <= 0 {
+			class.QueueLimit = max(w.pendingLimit, 1)
+		}
+		if class.Workers <= 0 && w.concurrency <= 0 {
+			// Without shared workers the class would never be served.
+			class.Workers = 1
+		}
+		q := &priorityQueue{class: class, queue: make(chan priorityItem, class.QueueLimit), credit: class.Weight}
+		s.queues = append(s.queues, q)
+		s.byName[class.Name] = q
+		capacity += class.QueueLimit
+	}
+	if _, ok := s.byName[s.defaultClass]; !ok {
+		s.defaultClass = s.queues[0].class.Name
+	}
+	s.ready = make(chan struct{}, capacity)
+	w.priorities = s
+
+	for _, q := range s.queues {
+		for range q.class.Workers {
+			w.startWorker(func() { w.runClassWorker(q) })
+		}
+	}
+	for range w.concurrency {
+		w.startWorker(w.runSharedWorker)
+	}
+}
+
+// classify returns the queue of msg: the class of its PriorityHeader, else of the first
+// matching subject pattern, else the default class.
+func (s *priorityScheduler) classify(msg *nats.Msg) *priorityQueue {
+	if name := msg.Header.Get(PriorityHeader); name != "" {
+		if q, ok := s.byName[name]; ok {
+			return q
+		}
+	}
+	for _, sp := range s.subjects {
+		if subjectMatches(sp.pattern, msg.Subject) {
+			if q, ok := s.byName[sp.class]; ok {
+				return q
+			}
+		}
+	}
+	return s.byName[s.defaultClass]
+}
+
+// enqueuePriority queues msg in its class. A full class queue applies the backpressure of
+// enqueue: JetStream messages are NAKed for redelivery, core NATS delivery waits for room.
+func (w *NATSManager) enqueuePriority(msg *nats.Msg, handler nats.MsgHandler) {
+	s := w.priorities
+	q := s.classify(msg)
+	item := priorityItem{msg: msg, handler: handler}
+
+	select {
+	case q.queue <- item:
+		s.signal()
+		return
+	default:
+	}
+
+	if _, err := msg.Metadata(); err == nil {
+		if w.metrics != nil {
+			w.metrics.rejected.WithLabelValues(msg.Subject).Inc()
+		}
+		w.logger.Warn("Priority queue full, message NAKed for redelivery", Slog(msg, log.String("subject", msg.Subject), log.String("priority", q.class.Name))...)
+		_ = msg.NakWithDelay(w.pendingRetryDelay)
+		return
+	}
+
+	select {
+	case q.queue <- item:
+		s.signal()
+	case <-s.stop:
+	}
+}
+
+// signal wakes a shared worker. A full ready channel already guarantees a wake up.
+func (s *priorityScheduler) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the next message for a shared worker by weighted round robin.
+func (s *priorityScheduler) next() (priorityItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for refill := 0; refill < 2; refill++ {
+		for i := range s.queues {
+			q := s.queues[(s.cursor+i)%len(s.queues)]
+			if q.credit <= 0 {
+				continue
+			}
+			select {
+			case item := <-q.queue:
+				q.credit--
+				if q.credit == 0 {
+					s.cursor = (s.cursor + i + 1) % len(s.queues)
+				}
+				return item, true
+			default:
+			}
+		}
+		// Every class with messages spent its budget: start a new round.
+		for _, q := range s.queues {
+			q.credit = q.class.Weight
+		}
+	}
+	return priorityItem{}, false
+}
+
+// runClassWorker processes the messages of q until the scheduler stops and q is drained.
+func (w *NATSManager) runClassWorker(q *priorityQueue) {
+	s := w.priorities
+	for {
+		select {
+		case item := <-q.queue:
+			item.handler(item.msg)
+		case <-s.stop:
+			for {
+				select {
+				case item := <-q.queue:
+					item.handler(item.msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// runSharedWorker processes the messages of every class until the scheduler stops and the
+// queues are drained.
+func (w *NATSManager) runSharedWorker() {
+	s := w.priorities
+	for {
+		if item, ok := s.next(); ok {
+			item.handler(item.msg)
+			continue
+		}
+		select {
+		case <-s.ready:
+		case <-s.stop:
+			for {
+				item, ok := s.next()
+				if !ok {
+					return
+				}
+				item.handler(item.msg)
+			}
+		}
+	}
+}
+
+// close stops the workers once the queued messages are processed.
+func (s *priorityScheduler) close() {
+	s.once.Do(func() { close(s.stop) })
+}
@@ -0,0 +1,185 @@
+package nats
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/nats-io/nats.go"
+)
+
+// StreamSpec declares a JetStream stream for EnsureStream. Zero values mean no limit, one
+// replica, limits retention and file storage, as with the nats CLI.
+type StreamSpec struct {
+	Name        string
+	Description string
+	Subjects    []string
+	Retention   nats.RetentionPolicy
+	Storage     nats.StorageType
+	MaxAge      time.Duration
+	MaxMsgs     int64
+	MaxBytes    int64
+	Replicas    int
+	// DuplicateWindow is the window of Nats-Msg-Id deduplication. Zero keeps the server
+	// default of two minutes.
+	DuplicateWindow time.Duration
+}
+
+// ConsumerSpec declares a durable JetStream consumer for EnsureConsumer. Zero values keep the
+// server defaults.
+type ConsumerSpec struct {
+	Stream         string
+	Durable        string
+	Description    string
+	FilterSubjects []string
+	DeliverPolicy  nats.DeliverPolicy
+	// AckNone disables acknowledgements; consumers otherwise acknowledge explicitly.
+	AckNone       bool
+	AckWait       time.Duration
+	MaxDeliver    int
+	MaxAckPending int
+	BackOff       []time.Duration
+	// DeliverSubject and DeliverGroup make a push consumer; it is a pull consumer otherwise.
+	DeliverSubject string
+	DeliverGroup   string
+}
+
+// streamConfig returns the stream configuration of spec, starting from existing so the
+// fields the spec does not manage keep their values.
+func (spec StreamSpec) streamConfig(existing nats.StreamConfig) nats.StreamConfig {
+	cfg := existing
+	cfg.Name = spec.Name
+	cfg.Description = spec.Description
+	cfg.Subjects = spec.Subjects
+	cfg.Retention = spec.Retention
+	cfg.Storage = spec.Storage
+	cfg.MaxAge = spec.MaxAge
+	cfg.MaxMsgs = limit(spec.MaxMsgs)
+	cfg.MaxBytes = limit(spec.MaxBytes)
+	cfg.Replicas = max(spec.Replicas, 1)
+	if spec.DuplicateWindow > 0 {
+		cfg.Duplicates = spec.DuplicateWindow
+	}
+	return cfg
+}
+
+// consumerConfig returns the consumer configuration of spec, starting from existing so the
+// fields the spec does not manage keep their values.
+func (spec ConsumerSpec) consumerConfig(existing nats.ConsumerConfig) nats.ConsumerConfig {
+	cfg := existing
+	cfg.Durable = spec.Durable
+	cfg.Description = spec.Description
+	cfg.DeliverPolicy = spec.DeliverPolicy
+	cfg.AckPolicy = nats.AckExplicitPolicy
+	if spec.AckNone {
+		cfg.AckPolicy = nats.AckNonePolicy
+	}
+	cfg.DeliverSubject = spec.DeliverSubject
+	cfg.DeliverGroup = spec.DeliverGroup
+	cfg.BackOff = spec.BackOff
+	if len(spec.FilterSubjects) == 1 {
+		cfg.FilterSubject, cfg.FilterSubjects = spec.FilterSubjects[0], nil
+	} else {
+		cfg.FilterSubject, cfg.FilterSubjects = "", spec.FilterSubjects
+	}
+	if spec.AckWait > 0 {
+		cfg.AckWait = spec.AckWait
+	}
+	if spec.MaxDeliver != 0 {
+		cfg.MaxDeliver = spec.MaxDeliver
+	}
+	if spec.MaxAckPending != 0 {
+		cfg.MaxAckPending = spec.MaxAckPending
+	}
+	return cfg
+}
+
+// limit returns n, or -1 (unlimited) when n is not positive.
+func limit(n int64) int64 {
+	if n <= 0 {
+		return -1
+	}
+	return n
+}
+
+// EnsureStream creates the stream of spec, or updates it when its configuration differs from
+// spec. The retention and storage of an existing stream cannot be changed. JetStream must be
+// enabled with WithJetStream.
+func (w *NATSManager) EnsureStream(spec StreamSpec) (*nats.StreamInfo, blame.Blame) {
+	if w.js == nil {
+		return nil, blame.StreamProvisionError("stream", spec.Name, "create", errors.New(JetStreamDisabledError))
+	}
+
+	info, err := w.js.StreamInfo(spec.Name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		cfg := spec.streamConfig(nats.StreamConfig{})
+		if info, err = w.js.AddStream(&cfg); err != nil {
+			return nil, blame.StreamProvisionError("stream", spec.Name, "create", err)
+		}
+		w.logger.Info("Stream created", log.String("stream", spec.Name), log.Any("subjects", spec.Subjects))
+		return info, nil
+	}
+	if err != nil {
+		return nil, blame.StreamProvisionError("stream", spec.Name, "inspect", err)
+	}
+
+	cfg := spec.streamConfig(info.Config)
+	if reflect.DeepEqual(cfg, info.Config) {
+		return info, nil
+	}
+	if info, err = w.js.UpdateStream(&cfg); err != nil {
+		return nil, blame.StreamProvisionError("stream", spec.Name, "update", err)
+	}
+	w.logger.Info("Stream updated", log.String("stream", spec.Name), log.Any("subjects", spec.Subjects))
+	return info, nil
+}
+
+// EnsureConsumer creates the durable consumer of spec on its stream, or updates it when its
+// configuration differs from spec. The deliver and ack policies of an existing consumer
+// cannot be changed. JetStream must be enabled with WithJetStream.
+func (w *NATSManager) EnsureConsumer(spec ConsumerSpec) (*nats.ConsumerInfo, blame.Blame) {
+	name := spec.Stream + "." + spec.Durable
+	if w.js == nil {
+		return nil, blame.StreamProvisionError("consumer", name, "create", errors.New(JetStreamDisabledError))
+	}
+
+	info, err := w.js.ConsumerInfo(spec.Stream, spec.Durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		cfg := spec.consumerConfig(nats.ConsumerConfig{})
+		if info, err = w.js.AddConsumer(spec.Stream, &cfg); err != nil {
+			return nil, blame.StreamProvisionError("consumer", name, "create", err)
+		}
+		w.logger.Info("Consumer created", log.String("stream", spec.Stream), log.String("consumer", spec.Durable))
+		return info, nil
+	}
+	if err != nil {
+		return nil, blame.StreamProvisionError("consumer", name, "inspect", err)
+	}
+
+	cfg := spec.consumerConfig(info.Config)
+	if reflect.DeepEqual(cfg, info.Config) {
+		return info, nil
+	}
+	if info, err = w.js.UpdateConsumer(spec.Stream, &cfg); err != nil {
+		return nil, blame.StreamProvisionError("consumer", name, "update", err)
+	}
+	w.logger.Info("Consumer updated", log.String("stream", spec.Stream), log.String("consumer", spec.Durable))
+	return info, nil
+}
+
+// Provision ensures the streams, then the consumers, stopping at the first failure.
+func (w *NATSManager) Provision(streams []StreamSpec, consumers []ConsumerSpec) blame.Blame {
+	for _, spec := range streams {
+		if _, err := w.EnsureStream(spec); err != nil {
+			return err
+		}
+	}
+	for _, spec := range consumers {
+		if _, err := w.EnsureConsumer(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
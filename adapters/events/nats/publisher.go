@@ -1,6 +1,7 @@
 package nats
 
 import (
+	"context"
 	"strings"
 	"time"
 
@@ -15,22 +16,31 @@ import (
 
 // Publish publishes a message to a subject.
 func (w *NATSManager) Publish(subject string, payload any) (*nats.PubAck, blame.Blame) {
-	return w.publishInternal(subject, payload)
+	return w.publishInternal(context.Background(), subject, payload)
 }
 
 // PublishWithMiddleware publishes a message to a subject with middleware attached.
 func (w *NATSManager) PublishWithMiddleware(subject string, payload any, middlewares ...MiddlewareFunc) (*nats.PubAck, blame.Blame) {
-	return w.publishInternal(subject, payload, middlewares...)
+	return w.publishInternal(context.Background(), subject, payload, middlewares...)
+}
+
+// PublishWithContext publishes a message to a subject with middleware attached, carrying the
+// correlation id and trace context of ctx.
+func (w *NATSManager) PublishWithContext(ctx context.Context, subject string, payload any, middlewares ...MiddlewareFunc) (*nats.PubAck, blame.Blame) {
+	return w.publishInternal(ctx, subject, payload, middlewares...)
 }
 
 // publishInternal is a helper function that handles common publishing logic.
-func (w *NATSManager) publishInternal(subject string, payload any, middlewares ...MiddlewareFunc) (*nats.PubAck, blame.Blame) {
+func (w *NATSManager) publishInternal(ctx context.Context, subject string, payload any, middlewares ...MiddlewareFunc) (*nats.PubAck, blame.Blame) {
 	defer helpers.RecoverException(recover())
-	data, err := codec.Encode(payload, codec.JSON)
+	buf, err := codec.EncodeJSONPooled(payload)
 	if err != nil {
 		w.logger.Error(constant.EventPublishedFailed, log.Any("codec.Encode", err))
 		return nil, blame.MarshalError(codec.JSON, err)
 	}
+	// The client copies the data when publishing, so the buffer is reused once published.
+	defer codec.PutBuffer(buf)
+	data := buf.Bytes()
 	messageId := random.GenerateUUIDString()
 	// Create the message with headers
 	msg := &nats.Msg{
@@ -60,8 +70,8 @@ func (w *NATSManager) publishInternal(subject string, payload any, middlewares .
 		return nil
 	}
 
-	// Apply middleware if provided
-	wrappedHandler := applyMiddleware(finalHandler, middlewares...)
+	// Apply middleware if provided, inside the span of the message
+	wrappedHandler := applyMiddleware(finalHandler, append([]MiddlewareFunc{w.TraceMiddleware(ctx)}, middlewares...)...)
 
 	// Execute the wrapped publish handler
 	if err := wrappedHandler(msg); err != nil {
@@ -79,16 +89,24 @@ func (w *NATSManager) publishInternal(subject string, payload any, middlewares .
 
 // PublishAndWait handles message preparation and publishing using JetStream
 func (w *NATSManager) PublishAndWait(subject, queueGroup string, payload any, timeout time.Duration, middlewares ...MiddlewareFunc) (*nats.Msg, blame.Blame) {
+	return w.PublishAndWaitWithContext(context.Background(), subject, queueGroup, payload, timeout, middlewares...)
+}
+
+// PublishAndWaitWithContext is PublishAndWait carrying the correlation id and trace context
+// of ctx.
+func (w *NATSManager) PublishAndWaitWithContext(ctx context.Context, subject, queueGroup string, payload any, timeout time.Duration, middlewares ...MiddlewareFunc) (*nats.Msg, blame.Blame) {
 	defer helpers.RecoverException(recover())
 
-	data, err := codec.Encode(payload, codec.JSON)
+	buf, err := codec.EncodeJSONPooled(payload)
 	if err != nil {
 		w.logger.Error(constant.EventPublishedFailed, log.Any("codec.Encode", err))
 		return nil, blame.MarshalError(codec.JSON, err)
 	}
+	defer codec.PutBuffer(buf)
+	data := buf.Bytes()
 	messageId := random.GenerateUUIDString()
 
-	result, err := w.breaker.Execute(func() (interface{}, error) {
+	result, err := w.executeWithBreaker(subject, func() (interface{}, error) {
 		replySubj := w.createReplySubject(subject)
 		sub, blameErr := w.createSubscription(replySubj, queueGroup, messageId)
 		if blameErr != nil {
@@ -97,7 +115,7 @@ func (w *NATSManager) PublishAndWait(subject, queueGroup string, payload any, ti
 		}
 		defer func() { _ = sub.Unsubscribe() }()
 
-		if blameErr := w.publishMessage(subject, replySubj, data, messageId, middlewares...); blameErr != nil {
+		if blameErr := w.publishMessage(ctx, subject, replySubj, data, messageId, middlewares...); blameErr != nil {
 			w.logger.Error(constant.EventPublishedFailed, log.Any("publishMessage", blameErr))
 			return nil, blameErr.ErrorFromBlame()
 		}
@@ -154,7 +172,7 @@ func (w *NATSManager) createSubscription(replySubj, queueGroup string, messageId
 }
 
 // publishMessage handles message preparation and publishing
-func (w *NATSManager) publishMessage(subject, replySubj string, data []byte, messageId string, middlewares ...MiddlewareFunc) blame.Blame {
+func (w *NATSManager) publishMessage(ctx context.Context, subject, replySubj string, data []byte, messageId string, middlewares ...MiddlewareFunc) blame.Blame {
 	msg := &nats.Msg{
 		Subject: subject,
 		Reply:   replySubj,
@@ -172,7 +190,7 @@ func (w *NATSManager) publishMessage(subject, replySubj string, data []byte, mes
 		return nil
 	}
 
-	wrappedHandler := applyMiddleware(finalHandler, middlewares...)
+	wrappedHandler := applyMiddleware(finalHandler, append([]MiddlewareFunc{w.TraceMiddleware(ctx)}, middlewares...)...)
 	return wrappedHandler(msg)
 }
 
@@ -203,7 +221,7 @@ func (w *NATSManager) createStreamSubscription(replySubj, queueGroup string, mes
 }
 
 // publishStreamMessage handles message preparation and publishing using JetStream
-func (w *NATSManager) publishStreamMessage(subject, replySubj string, data []byte, messageId string, middlewares ...MiddlewareFunc) blame.Blame {
+func (w *NATSManager) publishStreamMessage(ctx context.Context, subject, replySubj string, data []byte, messageId string, middlewares ...MiddlewareFunc) blame.Blame {
 	msg := &nats.Msg{
 		Subject: subject,
 		Reply:   replySubj,
@@ -221,7 +239,7 @@ func (w *NATSManager) publishStreamMessage(subject, replySubj string, data []byt
 		return nil
 	}
 
-	wrappedHandler := applyMiddleware(finalHandler, middlewares...)
+	wrappedHandler := applyMiddleware(finalHandler, append([]MiddlewareFunc{w.TraceMiddleware(ctx)}, middlewares...)...)
 	return wrappedHandler(msg)
 }
 
@@ -229,13 +247,15 @@ func (w *NATSManager) publishStreamMessage(subject, replySubj string, data []byt
 func (w *NATSManager) PublishAndWaitUsingStream(subject, queueGroup string, payload any, timeout time.Duration, middlewares ...MiddlewareFunc) (*nats.Msg, blame.Blame) {
 	defer helpers.RecoverException(recover())
 
-	data, err := codec.Encode(payload, codec.JSON)
+	buf, err := codec.EncodeJSONPooled(payload)
 	if err != nil {
 		return nil, blame.MarshalError(codec.JSON, err)
 	}
+	defer codec.PutBuffer(buf)
+	data := buf.Bytes()
 	messageId := random.GenerateUUIDString()
 
-	result, err := w.breaker.Execute(func() (interface{}, error) {
+	result, err := w.executeWithBreaker(subject, func() (interface{}, error) {
 		replySubj := w.createReplySubject(subject)
 		w.logger.Info("ReplySubject", log.Any("ReplySubject", replySubj))
 
@@ -245,7 +265,7 @@ func (w *NATSManager) PublishAndWaitUsingStream(subject, queueGroup string, payl
 		}
 		defer func() { _ = sub.Unsubscribe() }()
 
-		if blameErr := w.publishStreamMessage(subject, replySubj, data, messageId, middlewares...); blameErr != nil {
+		if blameErr := w.publishStreamMessage(context.Background(), subject, replySubj, data, messageId, middlewares...); blameErr != nil {
 			return nil, blameErr.ErrorFromBlame()
 		}
 
@@ -0,0 +1,279 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/clock"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/nats-io/nats.go"
+	"github.com/robfig/cron/v3"
+)
+
+// scheduler holds the state of delayed and recurring publishes.
+type scheduler struct {
+	mu         sync.Mutex
+	stream     string
+	prefix     string
+	queue      string
+	retryDelay time.Duration
+	sub        *nats.Subscription
+	timers     map[clock.Timer]chan struct{} // Pending delayed publishes on core NATS, closed to drop them
+	recurring  map[string]context.CancelFunc // Recurring publishes by name
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		stream:     DefaultScheduleStream,
+		prefix:     DefaultScheduleSubjectPrefix,
+		queue:      DefaultScheduleQueue,
+		retryDelay: DefaultScheduleRetryDelay,
+		timers:     make(map[clock.Timer]chan struct{}),
+		recurring:  make(map[string]context.CancelFunc),
+	}
+}
+
+// PublishAfter publishes payload to subject once delay has elapsed.
+//
+// With JetStream the message is stored in the schedule stream until it is due and relayed by
+// whichever replica running the scheduler receives it, so it survives restarts. Without
+// JetStream it is held in memory by this process and lost if the process exits first.
+func (w *NATSManager) PublishAfter(subject string, payload any, delay time.Duration) blame.Blame {
+	return w.PublishAt(subject, payload, w.clock.Now().Add(delay))
+}
+
+// PublishAt publishes payload to subject at the given time. See PublishAfter.
+func (w *NATSManager) PublishAt(subject string, payload any, at time.Time) blame.Blame {
+	msg, b := newScheduledMsg(subject, payload, random.GenerateUUIDString())
+	if b != nil {
+		return b
+	}
+	if w.js == nil {
+		w.publishLocallyAt(msg, at)
+		return nil
+	}
+	if b := w.StartScheduler(); b != nil {
+		return b
+	}
+
+	msg.Subject = w.scheduler.prefix + "." + subject
+	msg.Header.Set(ScheduledSubjectHeader, subject)
+	msg.Header.Set(ScheduledAtHeader, at.UTC().Format(time.RFC3339Nano))
+	if _, err := w.executeWithBreaker(subject, func() (interface{}, error) {
+		return w.js.PublishMsg(msg)
+	}); err != nil {
+		w.logger.Error(constant.EventPublishedFailed, log.String("subject", subject), log.Err(err))
+		return blame.PublishMessageError(subject, string(msg.Data), err)
+	}
+	return nil
+}
+
+// PublishCron publishes payload to subject on the cron schedule spec, in standard five field
+// syntax or a descriptor such as "@hourly" or "@every 30m", until CancelCron is called with
+// name or the manager is closed. Registering a name again replaces its schedule.
+//
+// Each occurrence carries the Message-ID "<name>:<unix time>", also used as the JetStream
+// Nats-Msg-Id, so replicas registering the same schedule publish each occurrence once to
+// subjects stored in a stream, and subscribers discard the duplicates of the others.
+func (w *NATSManager) PublishCron(name, spec, subject string, payload any) blame.Blame {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return blame.InvalidScheduleError(name, spec, err)
+	}
+	data, err := codec.Encode(payload, codec.JSON)
+	if err != nil {
+		return blame.MarshalError(codec.JSON, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := w.scheduler
+	s.mu.Lock()
+	if previous, ok := s.recurring[name]; ok {
+		previous()
+	}
+	s.recurring[name] = cancel
+	s.mu.Unlock()
+
+	go w.runCron(ctx, name, schedule, subject, data)
+	w.logger.Info("Recurring publish scheduled", log.String("name", name), log.String("schedule", spec), log.String("subject", subject))
+	return nil
+}
+
+// CancelCron stops the recurring publish registered as name and reports whether it existed.
+func (w *NATSManager) CancelCron(name string) bool {
+	s := w.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cancel, ok := s.recurring[name]
+	if ok {
+		cancel()
+		delete(s.recurring, name)
+	}
+	return ok
+}
+
+// StartScheduler creates the schedule stream and starts relaying the delayed messages that
+// fall due. PublishAfter and PublishAt start it on first use; replicas that should relay
+// without publishing call it at startup. Replicas share the work through a durable queue
+// consumer. It does nothing without JetStream.
+func (w *NATSManager) StartScheduler() blame.Blame {
+	s := w.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sub != nil || w.js == nil {
+		return nil
+	}
+
+	subject := s.prefix + ".>"
+	_, err := w.js.AddStream(&nats.StreamConfig{
+		Name:      s.stream,
+		Subjects:  []string{subject},
+		Retention: nats.WorkQueuePolicy,
+	})
+	if err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		return blame.SubscribeToSubjectError(subject, err)
+	}
+
+	sub, err := w.js.QueueSubscribe(subject, s.queue, w.relayScheduled,
+		nats.Durable(s.queue), nats.ManualAck(), nats.AckExplicit(), nats.DeliverAll())
+	if err != nil {
+		return blame.SubscribeToSubjectError(subject, err)
+	}
+	s.sub = sub
+	w.logger.Info("Scheduler started", log.String("stream", s.stream), log.String("subject", subject))
+	return nil
+}
+
+// relayScheduled publishes a message of the schedule stream to its subject once it is due,
+// and otherwise NAKs it for redelivery when it falls due.
+func (w *NATSManager) relayScheduled(msg *nats.Msg) {
+	subject := msg.Header.Get(ScheduledSubjectHeader)
+	at, err := time.Parse(time.RFC3339Nano, msg.Header.Get(ScheduledAtHeader))
+	if subject == "" || err != nil {
+		w.logger.Error("Invalid scheduled message discarded", Slog(msg, log.String("subject", msg.Subject))...)
+		_ = msg.Term()
+		return
+	}
+	if wait := w.clock.Until(at); wait > 0 {
+		_ = msg.NakWithDelay(wait)
+		return
+	}
+
+	// The Nats-Msg-Id of the scheduled message is dropped: a stream capturing both the
+	// schedule and the target subject would discard the relayed message as its duplicate
+	out := &nats.Msg{Subject: subject, Data: msg.Data, Header: nats.Header{}}
+	for key, values := range msg.Header {
+		if key != ScheduledSubjectHeader && key != ScheduledAtHeader && key != nats.MsgIdHdr {
+			out.Header[key] = values
+		}
+	}
+	if err := w.republish(out); err != nil {
+		w.logger.Error(constant.EventPublishedFailed, Slog(msg, log.String("subject", subject), log.Err(err))...)
+		_ = msg.NakWithDelay(w.scheduler.retryDelay)
+		return
+	}
+	_ = msg.Ack()
+}
+
+// republish publishes a prepared msg to JetStream when a stream stores its subject, and to
+// core NATS otherwise.
+func (w *NATSManager) republish(msg *nats.Msg) error {
+	if w.js != nil {
+		_, err := w.js.StreamNameBySubject(msg.Subject)
+		switch {
+		case err == nil:
+			_, err = w.js.PublishMsg(msg)
+			return err
+		case !errors.Is(err, nats.ErrNoMatchingStream):
+			return err
+		}
+	}
+	return w.nc.PublishMsg(msg)
+}
+
+// publishLocallyAt publishes msg at the given time from an in-process timer.
+func (w *NATSManager) publishLocallyAt(msg *nats.Msg, at time.Time) {
+	s := w.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	timer := w.clock.NewTimer(w.clock.Until(at))
+	dropped := make(chan struct{})
+	s.timers[timer] = dropped
+	go func() {
+		select {
+		case <-dropped:
+			return
+		case <-timer.C():
+		}
+		s.mu.Lock()
+		delete(s.timers, timer)
+		s.mu.Unlock()
+		if err := w.republish(msg); err != nil {
+			w.logger.Error(constant.EventPublishedFailed, Slog(msg, log.String("subject", msg.Subject), log.Err(err))...)
+		}
+	}()
+}
+
+// runCron publishes data to subject on every occurrence of schedule until ctx is cancelled.
+func (w *NATSManager) runCron(ctx context.Context, name string, schedule cron.Schedule, subject string, data []byte) {
+	for {
+		next := schedule.Next(w.clock.Now())
+		if next.IsZero() {
+			return
+		}
+		timer := w.clock.NewTimer(w.clock.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		msg := &nats.Msg{Subject: subject, Data: data, Header: nats.Header{}}
+		id := fmt.Sprintf("%s:%d", name, next.Unix())
+		msg.Header.Set(constant.MessageIdHeader, id)
+		msg.Header.Set(nats.MsgIdHdr, id)
+		if err := w.republish(msg); err != nil {
+			w.logger.Error(constant.EventPublishedFailed, log.String("name", name), log.String("subject", subject), log.Err(err))
+		}
+	}
+}
+
+// stopScheduler cancels the recurring publishes and the pending delayed publishes on core
+// NATS.
+func (w *NATSManager) stopScheduler() {
+	s := w.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, cancel := range s.recurring {
+		cancel()
+		delete(s.recurring, name)
+	}
+	if len(s.timers) > 0 {
+		w.logger.Warn("Pending delayed messages dropped", log.Any("count", len(s.timers)))
+	}
+	for timer, dropped := range s.timers {
+		timer.Stop()
+		close(dropped)
+		delete(s.timers, timer)
+	}
+}
+
+// newScheduledMsg encodes payload as JSON into a message to subject with the given Message-ID.
+func newScheduledMsg(subject string, payload any, messageID string) (*nats.Msg, blame.Blame) {
+	data, err := codec.Encode(payload, codec.JSON)
+	if err != nil {
+		return nil, blame.MarshalError(codec.JSON, err)
+	}
+	msg := &nats.Msg{Subject: subject, Data: data, Header: nats.Header{}}
+	msg.Header.Set(constant.MessageIdHeader, messageID)
+	msg.Header.Set(nats.MsgIdHdr, messageID)
+	return msg, nil
+}
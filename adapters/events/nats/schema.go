@@ -0,0 +1,206 @@
+package nats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/nats-io/nats.go"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// SchemaRegistry holds the JSON Schemas messages are validated against, by subject. Subjects
+// may use the * and > wildcards; an exact subject takes precedence over wildcard ones.
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]*jsonschema.Schema
+}
+
+// NewSchemaRegistry creates an empty SchemaRegistry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[string]*jsonschema.Schema)}
+}
+
+// Register compiles schema, a JSON Schema document, and uses it for subject, replacing the
+// schema registered before. Schemas may only reference themselves.
+func (r *SchemaRegistry) Register(subject string, schema []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return fmt.Errorf("invalid schema for %s: %w", subject, err)
+	}
+	location := "schema:///" + subject + ".json"
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(location, doc); err != nil {
+		return fmt.Errorf("invalid schema for %s: %w", subject, err)
+	}
+	compiled, err := compiler.Compile(location)
+	if err != nil {
+		return fmt.Errorf("invalid schema for %s: %w", subject, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[subject] = compiled
+	return nil
+}
+
+// Validate validates data against the schema of subject. It returns a blame listing the
+// violations when data does not match, and nil when it matches or no schema is registered
+// unless required is set.
+func (r *SchemaRegistry) Validate(subject string, data []byte, required bool) blame.Blame {
+	schema, ok := r.lookup(subject)
+	if !ok {
+		if required {
+			return blame.SchemaValidationError(subject, "no schema registered for subject")
+		}
+		return nil
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return blame.SchemaValidationError(subject, "payload is not valid JSON", err)
+	}
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return blame.SchemaValidationError(subject, err.Error(), err)
+	}
+	return blame.SchemaValidationError(subject, strings.Join(violations(validationErr.BasicOutput()), "; "), err)
+}
+
+// lookup returns the schema of subject, preferring an exact match.
+func (r *SchemaRegistry) lookup(subject string) (*jsonschema.Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if schema, ok := r.schemas[subject]; ok {
+		return schema, true
+	}
+	for pattern, schema := range r.schemas {
+		if subjectMatches(pattern, subject) {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+// violations flattens the basic output of a validation error into "<location>: <error>".
+func violations(output *jsonschema.OutputUnit) []string {
+	var list []string
+	for _, unit := range output.Errors {
+		if unit.Error == nil {
+			continue
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		list = append(list, location+": "+unit.Error.String())
+	}
+	if len(list) == 0 && output.Error != nil {
+		list = append(list, output.Error.String())
+	}
+	return list
+}
+
+// subjectMatches reports whether subject matches pattern, which may use the * and >
+// wildcards.
+func subjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// schemaConfig holds the SchemaOption settings.
+type schemaConfig struct {
+	quarantine string
+	required   bool
+}
+
+// SchemaOption configures SchemaValidationMiddleware.
+type SchemaOption func(*schemaConfig)
+
+// WithQuarantine republishes invalid messages to subject, with the original headers, a new
+// Message-ID and the DeadLetter headers describing the violation, for later inspection.
+func WithQuarantine(subject string) SchemaOption {
+	return func(c *schemaConfig) {
+		c.quarantine = subject
+	}
+}
+
+// WithSchemaRequired rejects messages on subjects that have no registered schema.
+func WithSchemaRequired() SchemaOption {
+	return func(c *schemaConfig) {
+		c.required = true
+	}
+}
+
+// SchemaValidationMiddleware returns a middleware validating message payloads against the
+// schemas of registry. It can be attached to publishers, where an invalid payload fails the
+// publish, and to subscribers, where an invalid message is acknowledged so it is not
+// redelivered, answered with the blame when it is a request, and optionally quarantined.
+func (w *NATSManager) SchemaValidationMiddleware(registry *SchemaRegistry, opts ...SchemaOption) MiddlewareFunc {
+	cfg := &schemaConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(next NATSMsgProcessor) NATSMsgProcessor {
+		return func(msg *nats.Msg) blame.Blame {
+			err := registry.Validate(msg.Subject, msg.Data, cfg.required)
+			if err == nil {
+				return next(msg)
+			}
+			w.logger.Warn("Message rejected by schema validation", Slog(msg, log.String("subject", msg.Subject), log.Any("error", err.FetchErrCode()))...)
+
+			if cfg.quarantine != "" {
+				if quarantineErr := w.quarantine(cfg.quarantine, msg, err); quarantineErr != nil {
+					w.logger.Error(constant.EventPublishedFailed, Slog(msg, log.String("subject", cfg.quarantine), log.Err(quarantineErr))...)
+				}
+			}
+			if _, metaErr := msg.Metadata(); metaErr == nil {
+				_ = msg.Ack()
+			} else if msg.Reply != "" && msg.Sub != nil {
+				_ = respond(msg, msg.Header.Get(constant.CorrelationIDHeader), failureReply(msg.Header.Get(constant.CorrelationIDHeader), err))
+			}
+			return err
+		}
+	}
+}
+
+// quarantine republishes msg to subject with the violation of cause in headers.
+func (w *NATSManager) quarantine(subject string, msg *nats.Msg, cause blame.Blame) error {
+	out := &nats.Msg{Subject: subject, Data: msg.Data, Header: nats.Header{}}
+	for key, values := range msg.Header {
+		out.Header[key] = append([]string(nil), values...)
+	}
+	out.Header.Del(nats.MsgIdHdr)
+	out.Header.Set(constant.MessageIdHeader, random.GenerateUUIDString())
+	out.Header.Set(DeadLetterSubjectHeader, msg.Subject)
+	out.Header.Set(DeadLetterMessageIDHeader, msg.Header.Get(constant.MessageIdHeader))
+	out.Header.Set(DeadLetterErrorCodeHeader, string(cause.FetchErrCode()))
+	_, description := cause.Translate()
+	if description == "" {
+		description = cause.ErrorFromBlame().Error()
+	}
+	// Header values cannot span lines.
+	out.Header.Set(DeadLetterErrorHeader, strings.Join(strings.Fields(description), " "))
+	out.Header.Set(DeadLetterFailedAtHeader, time.Now().UTC().Format(time.RFC3339Nano))
+	return w.republish(out)
+}
@@ -107,14 +107,14 @@ func (w *NATSManager) PullSubscribeBindConsumer(subject, stream, consumer string
 // Subscribe subscribes to a subject and processes messages using the provided handler.
 func (w *NATSManager) Subscribe(subject string, handler nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, blame.Blame) {
 	defer helpers.RecoverException(recover())
-	return w.subscribeInternal(subject, handler, opts, nil)
+	return w.subscribeInternal(subject, handler, opts)
 }
 
 // SubscribeWithMiddleware subscribes to a subject and applies middleware functions.
 func (w *NATSManager) SubscribeWithMiddleware(subject string, processor NATSMsgProcessor, opts []nats.SubOpt, middlewares ...MiddlewareFunc) (*nats.Subscription, blame.Blame) {
 	wrappedHandler := func(msg *nats.Msg) {
 		defer helpers.RecoverException(recover())
-		err := processor(msg) // Ignoring blame for now, adjust if needed
+		err := w.processWithDeadLetter(msg, processor)
 		if err != nil {
 			w.logger.Error(constant.HandlerFailed, log.Any("SubscribeWithMiddleware", err))
 		}
@@ -155,7 +155,7 @@ func (w *NATSManager) subscribeInternal(subject string, handler nats.MsgHandler,
 			}
 			// ACK successful processing
 			w.ackIfJetStream(msg)
-			w.logger.Info(constant.MessageProcessed, log.Any(constant.MessageIdHeader, messageID))
+			w.logger.Info(constant.MessageProcessed, log.String(constant.MessageIdHeader, messageID))
 		}
 	} else {
 		finalHandler = func(msg *nats.Msg) {
@@ -163,6 +163,8 @@ func (w *NATSManager) subscribeInternal(subject string, handler nats.MsgHandler,
 		}
 	}
 
+	finalHandler, stopPool := w.dispatch(subject, finalHandler)
+
 	var sub *nats.Subscription
 	var err error
 
@@ -184,6 +186,7 @@ func (w *NATSManager) subscribeInternal(subject string, handler nats.MsgHandler,
 
 	if err != nil {
 		w.logger.Error(constant.SubjectSubscribeFailed, log.Any("nats.Subscribe", err))
+		stopPool()
 		return nil, blame.SubscribeToSubjectError(subject, err)
 	}
 
@@ -191,6 +194,7 @@ func (w *NATSManager) subscribeInternal(subject string, handler nats.MsgHandler,
 		// Ensure subscription is active before continuing
 		if err := w.nc.Flush(); err != nil {
 			w.logger.Error(constant.ConnectionClosed, log.Err(err))
+			stopPool()
 			return nil, blame.SubscribeToSubjectError(subject, err)
 		}
 	}
@@ -205,6 +209,45 @@ func (w *NATSManager) subscribeInternal(subject string, handler nats.MsgHandler,
 	return sub, nil
 }
 
+// subscribeCore subscribes handler to subject on the core connection, load balanced within
+// queue when it is not empty. Replies to JetStream deliveries never reach the inbox of a
+// requester, so responders subscribe here even when JetStream is configured.
+func (w *NATSManager) subscribeCore(subject, queue string, handler nats.MsgHandler) (*nats.Subscription, blame.Blame) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, exists := w.subjects[subject]; exists {
+		return nil, blame.AlreadySubscribedToSubjectError(subject)
+	}
+	finalHandler, stopPool := w.dispatch(subject, handler)
+
+	var sub *nats.Subscription
+	var err error
+	if queue != "" {
+		sub, err = w.nc.QueueSubscribe(subject, queue, finalHandler)
+	} else {
+		sub, err = w.nc.Subscribe(subject, finalHandler)
+	}
+	if err == nil {
+		err = w.nc.Flush()
+	}
+	if err != nil {
+		w.logger.Error(constant.SubjectSubscribeFailed, log.Any("nats.Subscribe", err))
+		if sub != nil {
+			_ = sub.Unsubscribe()
+		}
+		stopPool()
+		return nil, blame.SubscribeToSubjectError(subject, err)
+	}
+
+	w.subjects[subject] = sub
+	w.subParams[subject] = &subscriptionParams{kind: subscriptionKindCore, queue: queue, handler: finalHandler}
+	w.logger.Info(constant.SubjectSubscribed, log.Any("message", fmt.Sprintf("Subscribed to subject %s", subject)))
+
+	go w.monitorSubscription(subject, sub)
+	return sub, nil
+}
+
 // SubscribeQueue subscribes to a subject using a queue and processes messages using the provided handler.
 func (w *NATSManager) SubscribeQueue(subject, queue string, handler nats.MsgHandler, opts ...nats.SubOpt) (*nats.Subscription, blame.Blame) {
 	return w.subscribeQueueInternal(subject, queue, handler, opts)
@@ -215,7 +258,7 @@ func (w *NATSManager) SubscribeQueueWithMiddleware(subject, queue string, proces
 	defer helpers.RecoverException(recover())
 	// Wrap the NATSMsgProcessor into a nats.MsgHandler
 	wrappedHandler := func(msg *nats.Msg) {
-		err := processor(msg) // Ignoring blame for now, adjust if needed
+		err := w.processWithDeadLetter(msg, processor)
 		if err != nil {
 			w.logger.Error(constant.HandlerFailed, log.Any("SubscribeQueueWithMiddleware", err))
 		}
@@ -256,7 +299,7 @@ func (w *NATSManager) subscribeQueueInternal(subject, queue string, handler nats
 			}
 			// ACK successful processing
 			w.ackIfJetStream(msg)
-			w.logger.Info(constant.MessageProcessed, log.Any(constant.MessageIdHeader, messageID))
+			w.logger.Info(constant.MessageProcessed, log.String(constant.MessageIdHeader, messageID))
 		}
 	} else {
 		finalHandler = func(msg *nats.Msg) {
@@ -264,6 +307,8 @@ func (w *NATSManager) subscribeQueueInternal(subject, queue string, handler nats
 		}
 	}
 
+	finalHandler, stopPool := w.dispatch(subject, finalHandler)
+
 	var sub *nats.Subscription
 	var err error
 
@@ -285,6 +330,7 @@ func (w *NATSManager) subscribeQueueInternal(subject, queue string, handler nats
 
 	if err != nil {
 		w.logger.Error(constant.SubjectWithQueueSubscribedFailed, log.Any("nats.QueueSubscribe", err))
+		stopPool()
 		return nil, blame.SubscribeToSubjectError(subject, err)
 	}
 
@@ -292,6 +338,7 @@ func (w *NATSManager) subscribeQueueInternal(subject, queue string, handler nats
 		// Ensure subscription is active before continuing
 		if err := w.nc.Flush(); err != nil {
 			w.logger.Error(constant.ConnectionClosed, log.Err(err))
+			stopPool()
 			return nil, blame.SubscribeToSubjectError(subject, err)
 		}
 	}
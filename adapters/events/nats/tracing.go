@@ -0,0 +1,119 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// messagingSystem is the messaging.system attribute of the NATS spans.
+var messagingSystem = semconv.MessagingSystemKey.String("nats")
+
+// ContextFromMsg returns a context carrying the correlation id and trace context of msg, for
+// handlers starting spans of their own or calling other services. When tracing is enabled the
+// trace context is the span processing msg.
+func ContextFromMsg(msg *nats.Msg) context.Context {
+	ctx := context.Background()
+	if correlationID := msg.Header.Get(constant.CorrelationIDHeader); correlationID != "" {
+		ctx = events.WithCorrelationID(ctx, correlationID)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier(msg.Header))
+}
+
+// TraceMiddleware returns a publish middleware creating a span per message, as a child of the
+// span of ctx, and injecting its traceparent and the correlation id of ctx in the headers. It
+// does nothing without WithTracer.
+func (w *NATSManager) TraceMiddleware(ctx context.Context) MiddlewareFunc {
+	return func(next NATSMsgProcessor) NATSMsgProcessor {
+		if w.tracer == nil {
+			return next
+		}
+		return func(msg *nats.Msg) blame.Blame {
+			if msg.Header == nil {
+				msg.Header = nats.Header{}
+			}
+			if correlationID := events.CorrelationIDFromContext(ctx); correlationID != "" && msg.Header.Get(constant.CorrelationIDHeader) == "" {
+				msg.Header.Set(constant.CorrelationIDHeader, correlationID)
+			}
+			spanCtx, span := w.tracer.Start(ctx, msg.Subject+" publish",
+				trace.WithSpanKind(trace.SpanKindProducer),
+				trace.WithAttributes(messageAttributes(msg, semconv.MessagingOperationTypePublish)...),
+			)
+			defer span.End()
+			otel.GetTextMapPropagator().Inject(spanCtx, headerCarrier(msg.Header))
+
+			err := next(msg)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(otelcodes.Error, string(err.FetchErrCode()))
+			}
+			return err
+		}
+	}
+}
+
+// traced wraps handler in a span per message of subject, continuing the trace of its
+// traceparent. The traceparent of msg is replaced by that of the span, so ContextFromMsg
+// returns it to the handler.
+func (w *NATSManager) traced(subject string, handler nats.MsgHandler) nats.MsgHandler {
+	if w.tracer == nil {
+		return handler
+	}
+	return func(msg *nats.Msg) {
+		if msg.Header == nil {
+			msg.Header = nats.Header{}
+		}
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), headerCarrier(msg.Header))
+		ctx, span := w.tracer.Start(ctx, subject+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(messageAttributes(msg, semconv.MessagingOperationTypeDeliver)...),
+		)
+		defer span.End()
+		otel.GetTextMapPropagator().Inject(ctx, headerCarrier(msg.Header))
+		handler(msg)
+	}
+}
+
+// messageAttributes returns the span attributes of msg, linking the spans of a conversation
+// through its correlation id.
+func messageAttributes(msg *nats.Msg, operation attribute.KeyValue) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		messagingSystem,
+		operation,
+		semconv.MessagingDestinationName(msg.Subject),
+	}
+	if messageID := msg.Header.Get(constant.MessageIdHeader); messageID != "" {
+		attrs = append(attrs, semconv.MessagingMessageID(messageID))
+	}
+	if correlationID := msg.Header.Get(constant.CorrelationIDHeader); correlationID != "" {
+		attrs = append(attrs, semconv.MessagingMessageConversationID(correlationID))
+	}
+	return attrs
+}
+
+// headerCarrier adapts NATS headers to propagation.TextMapCarrier.
+type headerCarrier nats.Header
+
+func (c headerCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,117 @@
+package nats
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/structures/message"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/nats-io/nats.go"
+)
+
+// replyFailure is the part of a failed message.Message reply needed to detect it.
+type replyFailure struct {
+	Status types.Status        `json:"status"`
+	Error  blame.ErrorResponse `json:"error"`
+}
+
+// Request publishes req to subject with PublishAndWait and decodes the reply into a TResp.
+// The correlation id carried by ctx, or a new one, is sent with the request. A reply sent by
+// HandleRequest for a failed handler, or any message.Message with a failed status and an
+// error, is returned as the blame it carries.
+func Request[TReq, TResp any](ctx context.Context, w *NATSManager, subject string, req TReq, timeout time.Duration, middlewares ...MiddlewareFunc) result.Result[TResp] {
+	correlationID := events.CorrelationIDFromContext(ctx)
+	if correlationID == "" {
+		correlationID = random.GenerateUUIDString()
+	}
+	middlewares = append([]MiddlewareFunc{AddHeaderMiddleware(constant.CorrelationIDHeader, correlationID)}, middlewares...)
+
+	reply, err := w.PublishAndWait(subject, "", req, timeout, middlewares...)
+	if err != nil {
+		return result.NewFailure[TResp](err)
+	}
+	return decodeReply[TResp](w, reply)
+}
+
+// decodeReply decodes reply into a TResp, or into the blame of a failure reply.
+func decodeReply[TResp any](w *NATSManager, reply *nats.Msg) result.Result[TResp] {
+	if failure, err := codec.Decode[replyFailure](reply.Data, codec.JSON); err == nil &&
+		failure.Status == constant.Failed && failure.Error.ErrorCode != "" {
+		return result.NewFailure[TResp](failure.Error.NewErrorResponseBlame(nil))
+	}
+	value, err := codec.Decode[TResp](reply.Data, codec.JSON)
+	if err != nil {
+		w.logger.Error(constant.ProcessingFailed, Slog(reply, log.String("subject", reply.Subject), log.Err(err))...)
+		return result.NewFailure[TResp](blame.UnMarshalError(codec.JSON, err))
+	}
+	return result.NewSuccess(&value)
+}
+
+// HandleRequest answers the requests of subject, sent by Request, with handler. The request
+// is decoded into a TReq and handler runs with the correlation id of the request in its
+// context. The returned TResp is sent as the reply; a returned blame is sent as a failed
+// message.Message, which Request turns back into the blame. A non empty queueGroup load
+// balances the requests between the members of the group. The responder subscribes on the core
+// connection, even when JetStream is configured, so its replies reach the requester.
+func HandleRequest[TReq, TResp any](w *NATSManager, subject, queueGroup string, handler func(ctx context.Context, req TReq) (TResp, blame.Blame), middlewares ...MiddlewareFunc) (*nats.Subscription, blame.Blame) {
+	processor := func(msg *nats.Msg) blame.Blame {
+		ctx := context.Background()
+		correlationID := msg.Header.Get(constant.CorrelationIDHeader)
+		if correlationID != "" {
+			ctx = events.WithCorrelationID(ctx, correlationID)
+		}
+
+		var data []byte
+		req, err := codec.Decode[TReq](msg.Data, codec.JSON)
+		if err != nil {
+			data = failureReply(correlationID, blame.UnMarshalError(codec.JSON, err))
+		} else if resp, handlerErr := handler(ctx, req); handlerErr != nil {
+			data = failureReply(correlationID, handlerErr)
+		} else if data, err = codec.Encode(resp, codec.JSON); err != nil {
+			data = failureReply(correlationID, blame.MarshalError(codec.JSON, err))
+		}
+		return respond(msg, correlationID, data)
+	}
+	processor = applyMiddleware(processor, middlewares...)
+
+	return w.subscribeCore(subject, queueGroup, func(msg *nats.Msg) {
+		defer helpers.RecoverException(recover())
+		if err := processor(msg); err != nil {
+			w.logger.Error(constant.HandlerFailed, Slog(msg, log.String("subject", msg.Subject), log.Any("error", err.FetchErrCode()))...)
+		}
+	})
+}
+
+// failureReply encodes err as a failed message.Message.
+func failureReply(correlationID string, err blame.Blame) []byte {
+	var zero any
+	reply := message.NewMessage(constant.Execute, constant.Failed, types.CorrelationID(correlationID), zero)
+	reply.AddError(err.FetchErrorResponse(blame.WithTranslation()))
+	data, _ := codec.Encode(reply, codec.JSON)
+	return data
+}
+
+// respond sends data to the reply subject of msg with a new Message-ID and the correlation id
+// of the request.
+func respond(msg *nats.Msg, correlationID string, data []byte) blame.Blame {
+	if msg.Reply == "" {
+		return blame.PublishMessageError(msg.Subject, "", nats.ErrMsgNoReply)
+	}
+	reply := &nats.Msg{Subject: msg.Reply, Data: data, Header: nats.Header{}}
+	reply.Header.Set(constant.MessageIdHeader, random.GenerateUUIDString())
+	if correlationID != "" {
+		reply.Header.Set(constant.CorrelationIDHeader, correlationID)
+	}
+	if err := msg.RespondMsg(reply); err != nil {
+		return blame.PublishMessageError(msg.Reply, "", err)
+	}
+	return nil
+}
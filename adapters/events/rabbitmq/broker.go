@@ -0,0 +1,69 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/blame"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// broker adapts RabbitMQManager to events.Broker.
+type broker struct {
+	manager *RabbitMQManager
+	queue   string
+}
+
+// Broker returns the manager as a broker independent events.Broker. Subjects map to routing
+// keys; a non empty queue makes Subscribe share that durable queue between instances.
+func (r *RabbitMQManager) Broker(queue string) events.Broker {
+	return &broker{manager: r, queue: queue}
+}
+
+func (b *broker) Publish(ctx context.Context, subject string, payload any) blame.Blame {
+	return b.manager.Publish(ctx, subject, payload)
+}
+
+func (b *broker) PublishAndWait(ctx context.Context, subject string, payload any, timeout time.Duration) (*events.Message, blame.Blame) {
+	delivery, err := b.manager.PublishAndWait(ctx, subject, payload, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return deliveryMessage(delivery), nil
+}
+
+func (b *broker) Subscribe(subject string, handler events.Handler, middlewares ...events.Middleware) blame.Blame {
+	handler = events.Chain(handler, middlewares...)
+	return b.manager.QueueSubscribe(subject, b.queue, func(ctx context.Context, delivery *amqp.Delivery) blame.Blame {
+		return handler(ctx, deliveryMessage(delivery))
+	})
+}
+
+func (b *broker) Reply(ctx context.Context, request *events.Message, payload any) blame.Blame {
+	delivery, ok := request.Raw.(*amqp.Delivery)
+	if !ok {
+		return blame.PublishMessageError(request.Subject, "", errors.New("message was not received from RabbitMQ"))
+	}
+	return b.manager.Reply(ctx, delivery, payload)
+}
+
+func (b *broker) Ping() error {
+	return b.manager.Ping()
+}
+
+func (b *broker) Close() {
+	b.manager.Close()
+}
+
+// deliveryMessage converts delivery to an events.Message.
+func deliveryMessage(delivery *amqp.Delivery) *events.Message {
+	headers := make(map[string]string, len(delivery.Headers))
+	for key := range delivery.Headers {
+		if value := Header(delivery, key); value != "" {
+			headers[key] = value
+		}
+	}
+	return &events.Message{Subject: delivery.RoutingKey, Data: delivery.Body, Headers: headers, Raw: delivery}
+}
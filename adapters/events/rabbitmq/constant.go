@@ -0,0 +1,17 @@
+package rabbitmq
+
+import "time"
+
+const (
+	BreakerName             = "RabbitMQPublish"
+	DefaultExchange         = "neuron.events"
+	DefaultPrefetch         = 10
+	DefaultReconnectWait    = 2 * time.Second
+	MaxResubscribeWait      = 30 * time.Second
+	DefaultConfirmTimeout   = 5 * time.Second
+	ConnectionFailedMessage = "connection to RabbitMQ is not yet established or failed"
+	PublishNackedError      = "message was not confirmed by the broker"
+	ReplyToMissingError     = "delivery has no reply-to address"
+	DirectReplyTo           = "amq.rabbitmq.reply-to"
+	InReplyToHeader         = "In-Reply-To"
+)
@@ -0,0 +1,106 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/adapters/paseto"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ----------------------
+// Middleware support
+// ----------------------
+
+// RabbitMsgProcessor defines the signature for a message processor. Outgoing messages are
+// built as a Delivery too, so the same middlewares apply when publishing and consuming.
+type RabbitMsgProcessor func(ctx context.Context, delivery *amqp.Delivery) blame.Blame
+
+// MiddlewareFunc defines the signature for a middleware function.
+type MiddlewareFunc func(RabbitMsgProcessor) RabbitMsgProcessor
+
+// applyMiddleware applies the middleware chain to a processor.
+func applyMiddleware(processor RabbitMsgProcessor, middlewares ...MiddlewareFunc) RabbitMsgProcessor {
+	// Apply in reverse order so that the first middleware in the list is executed first.
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		processor = middlewares[i](processor)
+	}
+	return processor
+}
+
+// AddHeaderMiddleware returns a middleware that sets a header key/value on the message.
+func AddHeaderMiddleware(key, value string) MiddlewareFunc {
+	return func(next RabbitMsgProcessor) RabbitMsgProcessor {
+		return func(ctx context.Context, delivery *amqp.Delivery) blame.Blame {
+			SetHeader(delivery, key, value)
+			return next(ctx, delivery)
+		}
+	}
+}
+
+// LogMiddleware returns a middleware that logs the message and any processing failure.
+func LogMiddleware(eventType string, logger *log.Log) MiddlewareFunc {
+	return func(next RabbitMsgProcessor) RabbitMsgProcessor {
+		return func(ctx context.Context, delivery *amqp.Delivery) blame.Blame {
+			if logger == nil {
+				return next(ctx, delivery)
+			}
+			logger.Info(constant.EventProcessed+" : "+eventType, Slog(delivery, logger.SanitizeAny("rabbitmq.data", string(delivery.Body)))...)
+			err := next(ctx, delivery)
+			if err != nil {
+				logger.Error(eventType+" failed", Slog(delivery, log.Err(err))...)
+			}
+			return err
+		}
+	}
+}
+
+// RecoveryMiddleware converts a panic in the processor into a blame error.
+func RecoveryMiddleware() MiddlewareFunc {
+	return func(next RabbitMsgProcessor) RabbitMsgProcessor {
+		return func(ctx context.Context, delivery *amqp.Delivery) (err blame.Blame) {
+			defer func() {
+				if r := recover(); r != nil {
+					helpers.Println(constant.ERROR, "Recovered from panic in RabbitMQ message handler")
+					helpers.Println(constant.ERROR, string(debug.Stack()))
+					err = blame.GeneralKnownError(fmt.Errorf("panic recovered: %v", r))
+				}
+			}()
+			return next(ctx, delivery)
+		}
+	}
+}
+
+// ValidateHeadersMiddleware validates the Authorization header of consumed messages with
+// paseto and the optional custom validators.
+func ValidateHeadersMiddleware(pasetoManager *paseto.PasetoManager, validators ...paseto.TokenValidator) MiddlewareFunc {
+	return func(next RabbitMsgProcessor) RabbitMsgProcessor {
+		return func(ctx context.Context, delivery *amqp.Delivery) blame.Blame {
+			token := helpers.ExtractBearerToken(Header(delivery, constant.AuthorizationHeader))
+			if helpers.IsEmpty(token) {
+				return blame.MalformedAuthToken(errors.New("token is empty"))
+			}
+			if pasetoManager == nil {
+				return blame.MalformedAuthToken(errors.New("paseto manager is not configured"))
+			}
+			extra := make(map[string]any)
+			if subject := Header(delivery, constant.XSubject); subject != "" {
+				extra["subject"] = subject
+			}
+			if ip := Header(delivery, constant.IPHeader); ip != "" {
+				extra["ip"] = ip
+			}
+			res := pasetoManager.ValidateToken(token, extra, validators...)
+			if !res.IsSuccess() {
+				return res.Blame()
+			}
+			return next(ctx, delivery)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package rabbitmq
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/circuitBreaker"
+)
+
+// Option defines a functional option for configuring RabbitMQManager.
+type Option func(*RabbitMQManager)
+
+// WithLogger sets the logger for the manager.
+func WithLogger(log *log.Log) Option {
+	return func(r *RabbitMQManager) {
+		r.logger = log
+		r.loggerSet = true
+	}
+}
+
+// WithExchange sets the topic exchange messages are published to and queues are bound to.
+// Defaults to DefaultExchange.
+func WithExchange(name string) Option {
+	return func(r *RabbitMQManager) {
+		r.exchange = name
+	}
+}
+
+// WithPrefetch sets how many unacknowledged messages each subscription receives at once.
+// Defaults to DefaultPrefetch.
+func WithPrefetch(count int) Option {
+	return func(r *RabbitMQManager) {
+		r.prefetch = count
+	}
+}
+
+// WithReconnectWait sets the delay between reconnection attempts. Defaults to
+// DefaultReconnectWait.
+func WithReconnectWait(wait time.Duration) Option {
+	return func(r *RabbitMQManager) {
+		r.reconnectWait = wait
+	}
+}
+
+// WithConfirmTimeout sets how long a publish waits for the broker's confirmation. Defaults to
+// DefaultConfirmTimeout.
+func WithConfirmTimeout(timeout time.Duration) Option {
+	return func(r *RabbitMQManager) {
+		r.confirmTimeout = timeout
+	}
+}
+
+// WithConnectionName sets the connection name shown in the RabbitMQ management UI.
+func WithConnectionName(name string) Option {
+	return func(r *RabbitMQManager) {
+		r.config.Properties.SetClientConnectionName(name)
+	}
+}
+
+// WithTLS enables TLS with the given configuration, for amqps:// URLs.
+func WithTLS(cfg *tls.Config) Option {
+	return func(r *RabbitMQManager) {
+		r.config.TLSClientConfig = cfg
+	}
+}
+
+// WithHeartbeat sets the heartbeat interval used to detect dead connections.
+func WithHeartbeat(interval time.Duration) Option {
+	return func(r *RabbitMQManager) {
+		r.config.Heartbeat = interval
+	}
+}
+
+// WithCircuitBreaker enables a circuit breaker around publish calls.
+func WithCircuitBreaker(options ...circuitBreaker.CircuitBreakerOption) Option {
+	if len(options) <= 0 {
+		options = append(options, circuitBreaker.WithName(BreakerName))
+	}
+	return func(r *RabbitMQManager) {
+		r.breaker = circuitBreaker.NewCircuitBreaker(options...)
+	}
+}
@@ -0,0 +1,147 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/types"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+)
+
+// Publish publishes payload as JSON to the exchange with routingKey and waits for the broker
+// to confirm it.
+func (r *RabbitMQManager) Publish(ctx context.Context, routingKey string, payload any) blame.Blame {
+	return r.publishInternal(ctx, r.exchange, routingKey, payload)
+}
+
+// PublishWithMiddleware publishes payload to routingKey with middleware attached.
+func (r *RabbitMQManager) PublishWithMiddleware(ctx context.Context, routingKey string, payload any, middlewares ...MiddlewareFunc) blame.Blame {
+	return r.publishInternal(ctx, r.exchange, routingKey, payload, middlewares...)
+}
+
+// publishInternal encodes payload, sets the standard headers and publishes the message.
+func (r *RabbitMQManager) publishInternal(ctx context.Context, exchange, routingKey string, payload any, middlewares ...MiddlewareFunc) blame.Blame {
+	data, err := codec.Encode(payload, codec.JSON)
+	if err != nil {
+		r.logger.Error(constant.EventPublishedFailed, log.Any("codec.Encode", err))
+		return blame.MarshalError(codec.JSON, err)
+	}
+	delivery := &amqp.Delivery{
+		Exchange:     exchange,
+		RoutingKey:   routingKey,
+		Body:         data,
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+	}
+	SetHeader(delivery, constant.MessageIdHeader, random.GenerateUUIDString())
+	injectContextHeaders(ctx, delivery)
+
+	finalHandler := func(ctx context.Context, delivery *amqp.Delivery) blame.Blame {
+		delivery.MessageId = Header(delivery, constant.MessageIdHeader)
+		delivery.CorrelationId = Header(delivery, constant.CorrelationIDHeader)
+		publish := func() (any, error) {
+			return nil, r.publish(ctx, delivery)
+		}
+		var publishErr error
+		if r.breaker != nil {
+			_, publishErr = r.breaker.Execute(publish)
+		} else {
+			_, publishErr = publish()
+		}
+		if publishErr != nil {
+			r.logger.Error(constant.EventPublishedFailed, log.Any("amqp.Publish", publishErr))
+			return blame.PublishMessageError(routingKey, string(data), publishErr)
+		}
+		return nil
+	}
+
+	if err := applyMiddleware(finalHandler, middlewares...)(ctx, delivery); err != nil {
+		return err
+	}
+	r.logger.Info(constant.EventPublished, Slog(delivery)...)
+	return nil
+}
+
+// publish publishes delivery on the publishing channel and waits for its confirmation.
+func (r *RabbitMQManager) publish(ctx context.Context, delivery *amqp.Delivery) error {
+	r.mu.Lock()
+	ch := r.channel
+	r.mu.Unlock()
+	if ch == nil || ch.IsClosed() {
+		return errors.New(ConnectionFailedMessage)
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, delivery.Exchange, delivery.RoutingKey, false, false, amqp.Publishing{
+		Headers:       delivery.Headers,
+		ContentType:   delivery.ContentType,
+		DeliveryMode:  delivery.DeliveryMode,
+		CorrelationId: delivery.CorrelationId,
+		ReplyTo:       delivery.ReplyTo,
+		MessageId:     delivery.MessageId,
+		Timestamp:     delivery.Timestamp,
+		Body:          delivery.Body,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.confirmTimeout)
+	defer cancel()
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New(PublishNackedError)
+	}
+	return nil
+}
+
+// injectContextHeaders copies the correlation id and the trace context of ctx onto delivery.
+func injectContextHeaders(ctx context.Context, delivery *amqp.Delivery) {
+	correlationID, _ := ctx.Value(types.StringConstant(constant.CorrelationIDHeader)).(string)
+	if correlationID == "" {
+		// gin.Context stores request values under plain string keys.
+		correlationID, _ = ctx.Value(constant.CorrelationID).(string)
+	}
+	if correlationID != "" {
+		SetHeader(delivery, constant.CorrelationIDHeader, correlationID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{delivery: delivery})
+}
+
+// extractContextHeaders returns ctx carrying the correlation id and trace context of delivery.
+func extractContextHeaders(ctx context.Context, delivery *amqp.Delivery) context.Context {
+	if correlationID := Header(delivery, constant.CorrelationIDHeader); correlationID != "" {
+		ctx = context.WithValue(ctx, types.StringConstant(constant.CorrelationIDHeader), correlationID)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier{delivery: delivery})
+}
+
+// headerCarrier adapts delivery headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	delivery *amqp.Delivery
+}
+
+func (c headerCarrier) Get(key string) string {
+	return Header(c.delivery, key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	SetHeader(c.delivery, key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.delivery.Headers))
+	for key := range c.delivery.Headers {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -1 +1,248 @@
 package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/idempotency"
+	"github.com/abhissng/neuron/utils/types"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sony/gobreaker"
+)
+
+//----------------------------------------------------------
+// GENERIC RabbitMQ Manager WITH CIRCUIT BREAKER
+//----------------------------------------------------------
+
+// RabbitMQManager wraps an AMQP 0-9-1 connection with the same publish/subscribe conventions
+// as NATSManager: JSON payloads, Message-ID based idempotency, correlation and trace header
+// propagation, middleware chains and blame errors. Messages are published to a durable topic
+// exchange with the subject as routing key.
+type RabbitMQManager struct {
+	url                string
+	config             amqp.Config
+	exchange           string
+	prefetch           int
+	reconnectWait      time.Duration
+	confirmTimeout     time.Duration
+	mu                 sync.Mutex
+	conn               *amqp.Connection
+	channel            *amqp.Channel // Publishes and consumes direct reply-to replies
+	subscriptions      map[string]*subscription
+	pending            map[string]chan *amqp.Delivery
+	logger             *log.Log
+	loggerSet          bool
+	idempotencyManager *idempotency.IdempotencyManager[string]
+	breaker            *gobreaker.CircuitBreaker
+	done               chan struct{}
+	wg                 sync.WaitGroup
+	closeOnce          sync.Once
+}
+
+// subscription holds what is needed to consume a routing key again after a reconnection.
+type subscription struct {
+	routingKey string
+	queue      string
+	processor  RabbitMsgProcessor
+	channel    *amqp.Channel
+}
+
+// NewRabbitMQManager creates a RabbitMQ manager connected to url, an amqp:// or amqps:// URI,
+// and declares its exchange. Publishes wait for the broker's confirmation, and the connection
+// is re-established and the subscriptions restored when it is lost.
+func NewRabbitMQManager(url string, options ...Option) (*RabbitMQManager, error) {
+	defaultLog := log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+
+	manager := &RabbitMQManager{
+		url: url,
+		config: amqp.Config{
+			Heartbeat:  10 * time.Second,
+			Locale:     "en_US",
+			Properties: amqp.NewConnectionProperties(),
+		},
+		exchange:           DefaultExchange,
+		prefetch:           DefaultPrefetch,
+		reconnectWait:      DefaultReconnectWait,
+		confirmTimeout:     DefaultConfirmTimeout,
+		subscriptions:      make(map[string]*subscription),
+		pending:            make(map[string]chan *amqp.Delivery),
+		logger:             defaultLog,
+		idempotencyManager: idempotency.NewIdempotencyManager[string](idempotency.DefaultCleanupInterval),
+		done:               make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(manager)
+	}
+
+	manager.mu.Lock()
+	err := manager.connect()
+	manager.mu.Unlock()
+	if err != nil {
+		manager.idempotencyManager.Close()
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+
+	if manager.loggerSet {
+		_ = defaultLog.Sync()
+	}
+	return manager, nil
+}
+
+// connect dials the broker, declares the exchange, opens the publishing channel and starts
+// monitoring the connection. The caller holds r.mu.
+func (r *RabbitMQManager) connect() error {
+	conn, err := amqp.DialConfig(r.url, r.config)
+	if err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
+	if err == nil {
+		err = ch.Confirm(false)
+	}
+	if err == nil {
+		err = ch.ExchangeDeclare(r.exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	}
+	var replies <-chan amqp.Delivery
+	if err == nil {
+		replies, err = ch.Consume(DirectReplyTo, "", true, false, false, false, nil)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	r.conn, r.channel = conn, ch
+
+	r.wg.Add(2)
+	go r.dispatchReplies(replies)
+	go r.monitor(conn.NotifyClose(make(chan *amqp.Error, 1)), ch.NotifyClose(make(chan *amqp.Error, 1)))
+	return nil
+}
+
+// monitor waits for the connection or the publishing channel to close and reconnects, then
+// restores the subscriptions, unless the manager is closed.
+func (r *RabbitMQManager) monitor(connClosed, channelClosed <-chan *amqp.Error) {
+	defer r.wg.Done()
+	var reason *amqp.Error
+	select {
+	case <-r.done:
+		return
+	case reason = <-connClosed:
+	case reason = <-channelClosed:
+	}
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	r.logger.Error("RabbitMQ disconnected", log.Any("error", reason))
+
+	r.mu.Lock()
+	if r.conn != nil && !r.conn.IsClosed() {
+		_ = r.conn.Close()
+	}
+	r.mu.Unlock()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-time.After(r.reconnectWait):
+		}
+		r.mu.Lock()
+		err := r.connect()
+		if err == nil {
+			r.resubscribe()
+		}
+		r.mu.Unlock()
+		if err != nil {
+			r.logger.Warn("RabbitMQ reconnection failed", log.Err(err))
+			continue
+		}
+		r.logger.Info("RabbitMQ reconnected", log.String("exchange", r.exchange))
+		return
+	}
+}
+
+// Ping checks that the connection and the publishing channel are open.
+func (r *RabbitMQManager) Ping() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil || r.conn.IsClosed() || r.channel == nil || r.channel.IsClosed() {
+		return errors.New(ConnectionFailedMessage)
+	}
+	return nil
+}
+
+// Connection returns the underlying AMQP connection. It changes after a reconnection.
+func (r *RabbitMQManager) Connection() *amqp.Connection {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.conn
+}
+
+// Exchange returns the name of the exchange messages are published to.
+func (r *RabbitMQManager) Exchange() string {
+	return r.exchange
+}
+
+// Close stops consuming, waits for the messages being processed and closes the connection.
+func (r *RabbitMQManager) Close() {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		r.logger.Info(constant.ConnectionClosing, log.Any("message", "RabbitMQ connection closing"))
+
+		r.mu.Lock()
+		for _, sub := range r.subscriptions {
+			if sub.channel != nil {
+				_ = sub.channel.Close()
+			}
+		}
+		conn := r.conn
+		r.mu.Unlock()
+		if conn != nil && !conn.IsClosed() {
+			_ = conn.Close()
+		}
+
+		r.wg.Wait()
+		r.idempotencyManager.Close()
+		r.logger.Info(constant.ConnectionClosed, log.Any("message", "RabbitMQ connection closed"))
+	})
+}
+
+// Slog returns the standard log fields for a delivery.
+func Slog(delivery *amqp.Delivery, withFields ...types.Field) []types.Field {
+	fields := make([]types.Field, 0, 5+len(withFields))
+	fields = append(fields,
+		log.String("rabbitmq.exchange", delivery.Exchange),
+		log.String("rabbitmq.routing_key", delivery.RoutingKey),
+		log.Any("rabbitmq.delivery_tag", delivery.DeliveryTag),
+		log.String(constant.MessageIdHeader, Header(delivery, constant.MessageIdHeader)),
+		log.String(constant.CorrelationIDHeader, Header(delivery, constant.CorrelationIDHeader)),
+	)
+	return append(fields, withFields...)
+}
+
+// Header returns the header key of delivery as a string.
+func Header(delivery *amqp.Delivery, key string) string {
+	switch value := delivery.Headers[key].(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	default:
+		return ""
+	}
+}
+
+// SetHeader replaces the header key on delivery.
+func SetHeader(delivery *amqp.Delivery, key, value string) {
+	if delivery.Headers == nil {
+		delivery.Headers = amqp.Table{}
+	}
+	delivery.Headers[key] = value
+}
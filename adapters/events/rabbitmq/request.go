@@ -0,0 +1,82 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// PublishAndWait publishes payload to routingKey with RabbitMQ's direct reply-to address and
+// waits up to timeout for the message replying to it, the RabbitMQ equivalent of
+// NATSManager.PublishAndWait.
+func (r *RabbitMQManager) PublishAndWait(ctx context.Context, routingKey string, payload any, timeout time.Duration, middlewares ...MiddlewareFunc) (*amqp.Delivery, blame.Blame) {
+	var messageID string
+	reply := make(chan *amqp.Delivery, 1)
+	register := func(next RabbitMsgProcessor) RabbitMsgProcessor {
+		return func(ctx context.Context, delivery *amqp.Delivery) blame.Blame {
+			messageID = Header(delivery, constant.MessageIdHeader)
+			delivery.ReplyTo = DirectReplyTo
+			r.mu.Lock()
+			r.pending[messageID] = reply
+			r.mu.Unlock()
+			return next(ctx, delivery)
+		}
+	}
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, messageID)
+		r.mu.Unlock()
+	}()
+
+	if err := r.publishInternal(ctx, r.exchange, routingKey, payload, append([]MiddlewareFunc{register}, middlewares...)...); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case delivery := <-reply:
+		return delivery, nil
+	case <-timer.C:
+		r.logger.Error(constant.EventPublishedFailed, log.String(constant.MessageIdHeader, messageID), log.String("routing_key", routingKey), log.Any("timeout", timeout.String()))
+		return nil, blame.PublishMessageError(routingKey, "", context.DeadlineExceeded)
+	case <-ctx.Done():
+		return nil, blame.PublishMessageError(routingKey, "", ctx.Err())
+	}
+}
+
+// Reply publishes payload to the reply-to address of request, referencing its Message-ID, so
+// that the PublishAndWait call that sent request receives it.
+func (r *RabbitMQManager) Reply(ctx context.Context, request *amqp.Delivery, payload any, middlewares ...MiddlewareFunc) blame.Blame {
+	if request.ReplyTo == "" {
+		return blame.PublishMessageError(request.RoutingKey, "", errors.New(ReplyToMissingError))
+	}
+	inReplyTo := AddHeaderMiddleware(InReplyToHeader, Header(request, constant.MessageIdHeader))
+	if correlationID := Header(request, constant.CorrelationIDHeader); correlationID != "" {
+		middlewares = append([]MiddlewareFunc{AddHeaderMiddleware(constant.CorrelationIDHeader, correlationID)}, middlewares...)
+	}
+	// Replies go through the default exchange, which routes to the queue named by routing key.
+	return r.publishInternal(ctx, "", request.ReplyTo, payload, append([]MiddlewareFunc{inReplyTo}, middlewares...)...)
+}
+
+// dispatchReplies hands the direct reply-to replies to the waiting PublishAndWait calls until
+// the publishing channel closes.
+func (r *RabbitMQManager) dispatchReplies(replies <-chan amqp.Delivery) {
+	defer r.wg.Done()
+	for delivery := range replies {
+		r.mu.Lock()
+		waiter, ok := r.pending[Header(&delivery, InReplyToHeader)]
+		r.mu.Unlock()
+		if ok {
+			select {
+			case waiter <- &delivery:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package rabbitmq
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Subscribe processes the messages published with routingKey, which may use the * and #
+// wildcards of topic exchanges, through an exclusive queue of this instance, so every
+// instance receives every message. See QueueSubscribe for delivery guarantees.
+func (r *RabbitMQManager) Subscribe(routingKey string, processor RabbitMsgProcessor, middlewares ...MiddlewareFunc) blame.Blame {
+	return r.QueueSubscribe(routingKey, "", processor, middlewares...)
+}
+
+// QueueSubscribe processes the messages published with routingKey through the durable queue
+// named queue, shared by the instances subscribing with it so each message is processed by
+// one of them. A message is acknowledged once processor returns; a failed message is requeued
+// once and then rejected, which dead-letters it when the queue has a dead letter exchange.
+// Messages carrying an already processed Message-ID are skipped. When the channel of the
+// subscription closes or the broker cancels its consumer, it is consumed again with a backoff
+// from the reconnect wait up to MaxResubscribeWait.
+func (r *RabbitMQManager) QueueSubscribe(routingKey, queue string, processor RabbitMsgProcessor, middlewares ...MiddlewareFunc) blame.Blame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.subscriptions[routingKey]; exists {
+		return blame.AlreadySubscribedToSubjectError(routingKey)
+	}
+
+	sub := &subscription{
+		routingKey: routingKey,
+		queue:      queue,
+		processor:  applyMiddleware(processor, append([]MiddlewareFunc{RecoveryMiddleware()}, middlewares...)...),
+	}
+	if err := r.consume(sub); err != nil {
+		r.logger.Error(constant.SubjectWithQueueSubscribedFailed, log.String("routing_key", routingKey), log.String("queue", queue), log.Err(err))
+		return blame.SubscribeToSubjectError(routingKey, err)
+	}
+	r.subscriptions[routingKey] = sub
+	r.logger.Info(constant.SubjectSubscribed, log.String("routing_key", routingKey), log.String("queue", queue))
+	return nil
+}
+
+// Unsubscribe stops consuming routingKey. An exclusive queue is deleted with its channel; a
+// shared queue keeps its messages for the other instances.
+func (r *RabbitMQManager) Unsubscribe(routingKey string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if sub, ok := r.subscriptions[routingKey]; ok {
+		if sub.channel != nil {
+			_ = sub.channel.Close()
+		}
+		delete(r.subscriptions, routingKey)
+	}
+}
+
+// consume declares and binds the queue of sub and starts delivering its messages on a
+// channel of its own. The caller holds r.mu.
+func (r *RabbitMQManager) consume(sub *subscription) error {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return err
+	}
+	exclusive := sub.queue == ""
+	deliveries, err := func() (<-chan amqp.Delivery, error) {
+		if err := ch.Qos(r.prefetch, 0, false); err != nil {
+			return nil, err
+		}
+		queue, err := ch.QueueDeclare(sub.queue, !exclusive, exclusive, exclusive, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := ch.QueueBind(queue.Name, sub.routingKey, r.exchange, false, nil); err != nil {
+			return nil, err
+		}
+		return ch.Consume(queue.Name, "", false, exclusive, false, false, nil)
+	}()
+	if err != nil {
+		_ = ch.Close()
+		return err
+	}
+	sub.channel = ch
+
+	r.wg.Add(2)
+	go func() {
+		defer r.wg.Done()
+		for delivery := range deliveries {
+			r.handleDelivery(sub.processor, &delivery)
+		}
+	}()
+	go r.watchSubscription(sub, ch, ch.NotifyClose(make(chan *amqp.Error, 1)), ch.NotifyCancel(make(chan string, 1)))
+	return nil
+}
+
+// watchSubscription consumes sub again when its channel ch closes on its own, e.g. after a
+// channel exception, or the broker cancels its consumer, e.g. because the queue was deleted.
+// Losing the connection is left to monitor, which restores every subscription.
+func (r *RabbitMQManager) watchSubscription(sub *subscription, ch *amqp.Channel, closed <-chan *amqp.Error, cancelled <-chan string) {
+	defer r.wg.Done()
+	var reason any
+	select {
+	case <-r.done:
+		return
+	case err := <-closed:
+		if err == nil {
+			// Closed by Unsubscribe, Close or with the connection.
+			return
+		}
+		reason = err
+	case tag := <-cancelled:
+		reason = "consumer " + tag + " cancelled by the broker"
+		_ = ch.Close()
+	}
+	r.logger.Warn("RabbitMQ subscription lost", log.String("routing_key", sub.routingKey), log.Any("error", reason))
+
+	wait := r.reconnectWait
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-time.After(wait):
+		}
+		r.mu.Lock()
+		if r.subscriptions[sub.routingKey] != sub || sub.channel != ch || r.conn == nil || r.conn.IsClosed() {
+			// Unsubscribed, or the connection was lost and monitor resubscribes.
+			r.mu.Unlock()
+			return
+		}
+		err := r.consume(sub)
+		r.mu.Unlock()
+		if err == nil {
+			r.logger.Info(constant.SubjectSubscribed, log.String("routing_key", sub.routingKey), log.String("queue", sub.queue))
+			return
+		}
+		r.logger.Warn("Failed to resubscribe:", log.String("routing_key", sub.routingKey), log.Err(err))
+		wait = min(2*wait, MaxResubscribeWait)
+	}
+}
+
+// resubscribe restores the subscriptions on a new connection. The caller holds r.mu.
+func (r *RabbitMQManager) resubscribe() {
+	for routingKey, sub := range r.subscriptions {
+		if err := r.consume(sub); err != nil {
+			r.logger.Error("Failed to resubscribe:", log.String("routing_key", routingKey), log.Err(err))
+		}
+	}
+}
+
+// handleDelivery runs processor on delivery and acknowledges it.
+func (r *RabbitMQManager) handleDelivery(processor RabbitMsgProcessor, delivery *amqp.Delivery) {
+	messageID := Header(delivery, constant.MessageIdHeader)
+	if messageID != "" && r.idempotencyManager.IsProcessed(messageID) {
+		r.logger.Info("Message already processed", Slog(delivery)...)
+		_ = delivery.Ack(false)
+		return
+	}
+
+	ctx := extractContextHeaders(context.Background(), delivery)
+	if err := processor(ctx, delivery); err != nil {
+		r.logger.Error(constant.HandlerFailed, Slog(delivery, log.Any("error", err.FetchErrCode()))...)
+		_ = delivery.Nack(false, !delivery.Redelivered)
+		return
+	}
+	_ = delivery.Ack(false)
+	if messageID != "" {
+		r.idempotencyManager.MarkAsProcessed(messageID)
+	}
+	r.logger.Info("Message processed", Slog(delivery)...)
+}
@@ -0,0 +1,374 @@
+// Package export streams records to partitioned CSV, Parquet or Avro files in object
+// storage, writes a manifest describing the files and announces completion on NATS. It lets
+// analytics consumers pick up data without direct database access.
+package export
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/batch"
+	"github.com/abhissng/neuron/adapters/cloud"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// DefaultRowsPerFile caps the number of records in a single file.
+	DefaultRowsPerFile = 1_000_000
+	// DefaultChunkSize is the number of records read from the source at a time.
+	DefaultChunkSize = 5000
+	// DefaultCompletionSubject is the NATS subject for completion events.
+	DefaultCompletionSubject = "export.completed"
+	// ManifestFileName is written next to the exported files.
+	ManifestFileName = "_manifest.json"
+)
+
+// Publisher publishes completion events. NATSManager satisfies it.
+type Publisher interface {
+	Publish(subject string, payload any) (*nats.PubAck, blame.Blame)
+}
+
+// PartitionFunc returns the partition path of a record, e.g. "dt=2026-10-15".
+// An empty string places the record at the export root.
+type PartitionFunc[T any] func(record T) string
+
+// ManifestFile describes one exported file.
+type ManifestFile struct {
+	Key       string `json:"key"`
+	Partition string `json:"partition,omitempty"`
+	Rows      int64  `json:"rows"`
+	Bytes     int64  `json:"bytes"`
+	SHA256    string `json:"sha256"`
+}
+
+// Manifest lists the files of a completed export. Consumers should only read files listed
+// here; a missing manifest means the export is incomplete.
+type Manifest struct {
+	ExportID    string         `json:"export_id"`
+	Format      Format         `json:"format"`
+	Bucket      string         `json:"bucket"`
+	Prefix      string         `json:"prefix"`
+	TotalRows   int64          `json:"total_rows"`
+	Files       []ManifestFile `json:"files"`
+	StartedAt   time.Time      `json:"started_at"`
+	CompletedAt time.Time      `json:"completed_at"`
+}
+
+// CompletedEvent is published once the manifest is written.
+type CompletedEvent struct {
+	ExportID    string    `json:"export_id"`
+	Bucket      string    `json:"bucket"`
+	ManifestKey string    `json:"manifest_key"`
+	Format      Format    `json:"format"`
+	TotalRows   int64     `json:"total_rows"`
+	FileCount   int       `json:"file_count"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Exporter writes records of type T to object storage.
+type Exporter[T any] struct {
+	cm          cloud.CloudManager
+	bucket      string
+	prefix      string
+	format      Format
+	rowsPerFile int64
+	chunkSize   int
+	partition   PartitionFunc[T]
+	publisher   Publisher
+	subject     string
+	tempDir     string
+	avroSchema  string
+	logger      *log.Log
+}
+
+// Option configures an Exporter.
+type Option[T any] func(*Exporter[T])
+
+// WithFormat sets the output format. CSV is the default.
+func WithFormat[T any](format Format) Option[T] {
+	return func(e *Exporter[T]) {
+		e.format = format
+	}
+}
+
+// WithRowsPerFile caps the number of records per file; larger partitions are split.
+func WithRowsPerFile[T any](rows int64) Option[T] {
+	return func(e *Exporter[T]) {
+		if rows > 0 {
+			e.rowsPerFile = rows
+		}
+	}
+}
+
+// WithChunkSize sets how many records are read from the source at a time.
+func WithChunkSize[T any](size int) Option[T] {
+	return func(e *Exporter[T]) {
+		if size > 0 {
+			e.chunkSize = size
+		}
+	}
+}
+
+// WithPartitionFunc splits the export into partition directories.
+func WithPartitionFunc[T any](fn PartitionFunc[T]) Option[T] {
+	return func(e *Exporter[T]) {
+		e.partition = fn
+	}
+}
+
+// WithCompletionEvent publishes a CompletedEvent on subject after the manifest is written.
+func WithCompletionEvent[T any](publisher Publisher, subject string) Option[T] {
+	return func(e *Exporter[T]) {
+		e.publisher = publisher
+		if subject != "" {
+			e.subject = subject
+		}
+	}
+}
+
+// WithTempDir sets where files are staged before upload. Defaults to os.TempDir().
+func WithTempDir[T any](dir string) Option[T] {
+	return func(e *Exporter[T]) {
+		e.tempDir = dir
+	}
+}
+
+// WithAvroSchema sets the schema for Avro files instead of deriving it from T.
+func WithAvroSchema[T any](schema string) Option[T] {
+	return func(e *Exporter[T]) {
+		e.avroSchema = schema
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger[T any](logger *log.Log) Option[T] {
+	return func(e *Exporter[T]) {
+		e.logger = logger
+	}
+}
+
+// NewExporter creates an Exporter writing under bucket/prefix.
+func NewExporter[T any](cm cloud.CloudManager, bucket, prefix string, options ...Option[T]) (*Exporter[T], error) {
+	if cm == nil {
+		return nil, cloud.ErrNotInitialized
+	}
+	if helpers.IsEmpty(bucket) {
+		return nil, errors.New("export: bucket is required")
+	}
+	e := &Exporter[T]{
+		cm:          cm,
+		bucket:      bucket,
+		prefix:      prefix,
+		format:      FormatCSV,
+		rowsPerFile: DefaultRowsPerFile,
+		chunkSize:   DefaultChunkSize,
+		subject:     DefaultCompletionSubject,
+	}
+	for _, opt := range options {
+		opt(e)
+	}
+	if e.logger == nil {
+		e.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	// Fail fast on unsupported formats or record types.
+	probe, err := newRecordWriter[T](e.format, io.Discard, e.avroSchema)
+	if err != nil {
+		return nil, err
+	}
+	_ = probe.Close()
+	return e, nil
+}
+
+// partFile is a file being staged on local disk.
+type partFile[T any] struct {
+	partition string
+	seq       int
+	file      *os.File
+	writer    recordWriter[T]
+	rows      int64
+}
+
+// Export reads source to exhaustion, uploads the files, writes the manifest and publishes the
+// completion event. exportID names the export directory and must be unique per export.
+func (e *Exporter[T]) Export(ctx context.Context, exportID string, source batch.Source[T]) (*Manifest, error) {
+	if helpers.IsEmpty(exportID) {
+		return nil, errors.New("export: export id is required")
+	}
+
+	manifest := &Manifest{
+		ExportID:  exportID,
+		Format:    e.format,
+		Bucket:    e.bucket,
+		Prefix:    path.Join(e.prefix, exportID),
+		StartedAt: time.Now(),
+	}
+	open := map[string]*partFile[T]{}
+	sequences := map[string]int{}
+	defer func() {
+		for _, part := range open {
+			e.discard(part)
+		}
+	}()
+
+	cursor := ""
+	for {
+		records, next, err := source.Next(ctx, cursor, e.chunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("export: failed to read source: %w", err)
+		}
+
+		for _, record := range records {
+			partition := ""
+			if e.partition != nil {
+				partition = e.partition(record)
+			}
+			part, ok := open[partition]
+			if !ok {
+				part, err = e.openPart(partition, sequences[partition])
+				if err != nil {
+					return nil, err
+				}
+				sequences[partition]++
+				open[partition] = part
+			}
+			if err := part.writer.Write([]T{record}); err != nil {
+				return nil, fmt.Errorf("export: failed to write record: %w", err)
+			}
+			part.rows++
+
+			if part.rows >= e.rowsPerFile {
+				delete(open, partition)
+				file, err := e.upload(ctx, manifest.Prefix, part)
+				if err != nil {
+					return nil, err
+				}
+				manifest.Files = append(manifest.Files, *file)
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	// Flush the remaining partitions in a stable order.
+	partitions := make([]string, 0, len(open))
+	for partition := range open {
+		partitions = append(partitions, partition)
+	}
+	sort.Strings(partitions)
+	for _, partition := range partitions {
+		part := open[partition]
+		delete(open, partition)
+		file, err := e.upload(ctx, manifest.Prefix, part)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Files = append(manifest.Files, *file)
+	}
+
+	for _, f := range manifest.Files {
+		manifest.TotalRows += f.Rows
+	}
+	manifest.CompletedAt = time.Now()
+
+	manifestKey := path.Join(manifest.Prefix, ManifestFileName)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := e.cm.UploadFile(ctx, e.bucket, manifestKey, data, "application/json", nil); err != nil {
+		return nil, fmt.Errorf("export: failed to upload manifest: %w", err)
+	}
+
+	e.logger.Info("Export completed", log.String("export_id", exportID), log.Any("rows", manifest.TotalRows), log.Any("files", len(manifest.Files)))
+	e.notify(manifest, manifestKey)
+	return manifest, nil
+}
+
+// openPart creates a staging file for a partition.
+func (e *Exporter[T]) openPart(partition string, seq int) (*partFile[T], error) {
+	file, err := os.CreateTemp(e.tempDir, "export-*"+e.format.Extension())
+	if err != nil {
+		return nil, fmt.Errorf("export: failed to create staging file: %w", err)
+	}
+	writer, err := newRecordWriter[T](e.format, file, e.avroSchema)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+	return &partFile[T]{partition: partition, seq: seq, file: file, writer: writer}, nil
+}
+
+// upload finalises a staging file, uploads it and removes it from disk.
+func (e *Exporter[T]) upload(ctx context.Context, prefix string, part *partFile[T]) (*ManifestFile, error) {
+	defer e.discard(part)
+
+	if err := part.writer.Close(); err != nil {
+		return nil, fmt.Errorf("export: failed to finalise file: %w", err)
+	}
+	if _, err := part.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, part.file)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	key := path.Join(prefix, part.partition, fmt.Sprintf("part-%05d%s", part.seq, e.format.Extension()))
+	metadata := map[string]string{"rows": fmt.Sprintf("%d", part.rows)}
+	if err := e.cm.UploadFileFromReader(ctx, e.bucket, key, part.file, size, e.format.ContentType(), metadata); err != nil {
+		return nil, fmt.Errorf("export: failed to upload %s: %w", key, err)
+	}
+
+	return &ManifestFile{
+		Key:       key,
+		Partition: part.partition,
+		Rows:      part.rows,
+		Bytes:     size,
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// discard closes and deletes a staging file.
+func (e *Exporter[T]) discard(part *partFile[T]) {
+	_ = part.file.Close()
+	_ = os.Remove(part.file.Name())
+}
+
+// notify publishes the completion event. Failures are logged; the manifest remains the source of truth.
+func (e *Exporter[T]) notify(manifest *Manifest, manifestKey string) {
+	if e.publisher == nil {
+		return
+	}
+	event := CompletedEvent{
+		ExportID:    manifest.ExportID,
+		Bucket:      manifest.Bucket,
+		ManifestKey: manifestKey,
+		Format:      manifest.Format,
+		TotalRows:   manifest.TotalRows,
+		FileCount:   len(manifest.Files),
+		CompletedAt: manifest.CompletedAt,
+	}
+	if _, err := e.publisher.Publish(e.subject, event); err != nil {
+		e.logger.Error("Failed to publish export completion event", log.String("export_id", manifest.ExportID), log.Any("error", err))
+	}
+}
@@ -0,0 +1,249 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/ocf"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Format identifies the file format written by the exporter.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+	FormatAvro    Format = "avro"
+)
+
+// Extension returns the file extension for the format.
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ContentType returns the MIME type for the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatAvro:
+		return "application/avro"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// recordWriter writes typed records into a single file.
+type recordWriter[T any] interface {
+	Write(records []T) error
+	Close() error
+}
+
+// newRecordWriter returns a writer for format on top of w. avroSchema overrides the schema
+// derived from T for Avro files.
+func newRecordWriter[T any](format Format, w io.Writer, avroSchema string) (recordWriter[T], error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter[T](w)
+	case FormatParquet:
+		return &parquetWriter[T]{w: parquet.NewGenericWriter[T](w, parquet.Compression(&parquet.Snappy))}, nil
+	case FormatAvro:
+		return newAvroWriter[T](w, avroSchema)
+	default:
+		return nil, fmt.Errorf("export: unsupported format %q", format)
+	}
+}
+
+// parquetWriter writes records with a schema derived from `parquet` struct tags.
+type parquetWriter[T any] struct {
+	w *parquet.GenericWriter[T]
+}
+
+func (p *parquetWriter[T]) Write(records []T) error {
+	_, err := p.w.Write(records)
+	return err
+}
+
+func (p *parquetWriter[T]) Close() error {
+	return p.w.Close()
+}
+
+// avroWriter writes records to an Avro object container file.
+type avroWriter[T any] struct {
+	enc *ocf.Encoder
+}
+
+func newAvroWriter[T any](w io.Writer, schema string) (*avroWriter[T], error) {
+	var parsed avro.Schema
+	var err error
+	if schema != "" {
+		parsed, err = avro.Parse(schema)
+	} else {
+		parsed, err = codec.AvroSchemaOf[T]()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("export: invalid avro schema: %w", err)
+	}
+	enc, err := ocf.NewEncoderWithSchema(parsed, w, ocf.WithCodec(ocf.Snappy))
+	if err != nil {
+		return nil, err
+	}
+	return &avroWriter[T]{enc: enc}, nil
+}
+
+func (a *avroWriter[T]) Write(records []T) error {
+	for _, record := range records {
+		if err := a.enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *avroWriter[T]) Close() error {
+	return a.enc.Close()
+}
+
+// csvColumn maps a struct field to a CSV column.
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvWriter writes records with a header row. Columns come from `csv` struct tags, falling
+// back to `json` tags and then field names; "-" skips a field.
+type csvWriter[T any] struct {
+	w       *csv.Writer
+	columns []csvColumn
+	header  bool
+}
+
+func newCSVWriter[T any](w io.Writer) (*csvWriter[T], error) {
+	columns, err := csvColumns(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	return &csvWriter[T]{w: csv.NewWriter(w), columns: columns}, nil
+}
+
+// csvColumns lists the exported fields of a struct type in declaration order.
+func csvColumns(t reflect.Type) ([]csvColumn, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("export: csv records must be structs, got %s", t)
+	}
+
+	columns := make([]csvColumn, 0, t.NumField())
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := tagName(field, "csv")
+		if name == "" {
+			name = tagName(field, "json")
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+	return columns, nil
+}
+
+// tagName returns the name part of a struct tag.
+func tagName(field reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+	return name
+}
+
+func (c *csvWriter[T]) Write(records []T) error {
+	if !c.header {
+		header := make([]string, len(c.columns))
+		for i, col := range c.columns {
+			header[i] = col.name
+		}
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+		c.header = true
+	}
+
+	row := make([]string, len(c.columns))
+	for _, record := range records {
+		v := reflect.Indirect(reflect.ValueOf(record))
+		for i, col := range c.columns {
+			field, err := v.FieldByIndexErr(col.index)
+			if err != nil {
+				row[i] = ""
+				continue
+			}
+			row[i] = formatValue(field)
+		}
+		if err := c.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *csvWriter[T]) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// formatValue renders a field as a CSV cell.
+func formatValue(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return value.String()
+	case []byte:
+		return string(value)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
@@ -0,0 +1,165 @@
+package uploadFile
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http/httputil"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ICAPScanner scans content through an ICAP (RFC 3507) server using RESPMOD,
+// e.g. c-icap with the virus_scan service or a commercial AV gateway.
+type ICAPScanner struct {
+	Address string
+	Service string
+}
+
+func NewICAPScanner(addr, service string) *ICAPScanner {
+	if service == "" {
+		service = "avscan"
+	}
+	return &ICAPScanner{Address: addr, Service: strings.TrimPrefix(service, "/")}
+}
+
+func (s *ICAPScanner) Scan(r io.Reader) (bool, error) {
+	conn, err := net.DialTimeout("tcp", s.Address, 10*time.Second)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// Set overall deadline for the scan operation
+	if err := conn.SetDeadline(time.Now().Add(5 * time.Minute)); err != nil {
+		return false, err
+	}
+
+	host, _, err := net.SplitHostPort(s.Address)
+	if err != nil {
+		host = s.Address
+	}
+
+	// Encapsulated HTTP response headers precede the chunked body.
+	httpHeader := "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\n\r\n"
+	request := fmt.Sprintf(
+		"RESPMOD icap://%s/%s ICAP/1.0\r\nHost: %s\r\nAllow: 204\r\nEncapsulated: res-hdr=0, res-body=%d\r\n\r\n%s",
+		s.Address, s.Service, host, len(httpHeader), httpHeader,
+	)
+
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString(request); err != nil {
+		return false, err
+	}
+
+	// The sent content is hashed to check a 200 response returned it unmodified
+	sent := sha256.New()
+	buf := make([]byte, 8192)
+	for {
+		n, err := r.Read(buf)
+		sent.Write(buf[:n])
+		if n > 0 {
+			if _, err := w.WriteString(strconv.FormatInt(int64(n), 16) + "\r\n"); err != nil {
+				return false, err
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return false, err
+			}
+			if _, err := w.WriteString("\r\n"); err != nil {
+				return false, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// End of stream
+	if _, err := w.WriteString("0\r\n\r\n"); err != nil {
+		return false, err
+	}
+	if err := w.Flush(); err != nil {
+		return false, err
+	}
+
+	br := bufio.NewReader(conn)
+	tp := textproto.NewReader(br)
+	statusLine, err := tp.ReadLine()
+	if err != nil {
+		return false, err
+	}
+	headers, err := tp.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	parts := strings.SplitN(statusLine, " ", 3)
+	if len(parts) < 2 {
+		return false, fmt.Errorf("unexpected icap response: %s", statusLine)
+	}
+
+	switch parts[1] {
+	case "204":
+		return true, nil
+	case "200":
+		if headers.Get("X-Infection-Found") != "" ||
+			headers.Get("X-Violations-Found") != "" ||
+			headers.Get("X-Virus-ID") != "" {
+			return false, nil
+		}
+		// Servers may answer 200 with a block page or a cleaned copy instead of an infection
+		// header, so the content only passes when it comes back unmodified.
+		if err := verifyUnmodified(br, headers.Get("Encapsulated"), sent); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("unexpected icap response: %s", statusLine)
+	}
+}
+
+// errICAPModified fails scans whose content the ICAP server modified or withheld.
+var errICAPModified = errors.New("icap server modified the content")
+
+// verifyUnmodified reads the encapsulated response of a 200 answer from r and checks its body
+// hashes to sent, the hash of the content scanned.
+func verifyUnmodified(r *bufio.Reader, encapsulated string, sent hash.Hash) error {
+	bodyAt := -1
+	for _, field := range strings.Split(encapsulated, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		if name == "res-body" {
+			offset, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid icap encapsulated header %q", encapsulated)
+			}
+			bodyAt = offset
+		}
+	}
+	if bodyAt < 0 {
+		return errICAPModified
+	}
+	// The encapsulated HTTP headers precede the body
+	if _, err := io.CopyN(io.Discard, r, int64(bodyAt)); err != nil {
+		return err
+	}
+	received := sha256.New()
+	if _, err := io.Copy(received, httputil.NewChunkedReader(r)); err != nil {
+		return err
+	}
+	if !bytes.Equal(received.Sum(nil), sent.Sum(nil)) {
+		return errICAPModified
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 type Config struct {
 	rule         *FileRule
 	virusScanner VirusScanner
+	imageLimits  *ImageLimits
 }
 
 type Option func(*Config)
@@ -49,6 +50,20 @@ func WithClamAV(address string) Option {
 	}
 }
 
+func WithICAP(address, service string) Option {
+	return func(c *Config) {
+		c.virusScanner = NewICAPScanner(address, service)
+	}
+}
+
+// WithImageLimits bounds the dimensions and size of image uploads. It applies only to
+// files whose sniffed content type is an image.
+func WithImageLimits(limits ImageLimits) Option {
+	return func(c *Config) {
+		c.imageLimits = &limits
+	}
+}
+
 func WithCustomRule(maxSize int64, mimes []string, exts []string) Option {
 	return func(c *Config) {
 		c.rule = NewCustomRule(maxSize, mimes, exts)
@@ -0,0 +1,99 @@
+package uploadFile
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoder for DecodeConfig
+	_ "image/jpeg" // register JPEG decoder for DecodeConfig
+	_ "image/png"  // register PNG decoder for DecodeConfig
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	_ "golang.org/x/image/webp" // register WebP decoder for DecodeConfig
+)
+
+/*
+========================================
+ Content Sniffing
+========================================
+*/
+
+var (
+	ErrImageTooLarge      = errors.New("image dimensions exceed allowed limits")
+	ErrImageUnreadable    = errors.New("image header could not be decoded")
+	ErrUnseekableUpload   = errors.New("file must be seekable for validation")
+	ErrUploadFuncRequired = errors.New("upload function is required")
+)
+
+// ImageLimits bounds the dimensions and size of uploaded images. Zero values disable a check.
+type ImageLimits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+	MaxBytes  int64
+}
+
+// DetectContentType sniffs the MIME type of r from its magic bytes rather than its name.
+// The reader is consumed up to the detection limit; rewind it before reusing.
+func DetectContentType(r io.Reader) (*mimetype.MIME, error) {
+	mtype, err := mimetype.DetectReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect content type: %w", err)
+	}
+	return mtype, nil
+}
+
+// activeMIMEs are the types browsers execute scripts in. They are rejected unless a rule lists
+// them itself, so a profile allowing text can never serve an uploaded page.
+var activeMIMEs = []string{"text/html", "image/svg+xml", "application/xhtml+xml"}
+
+// isAllowedMIME reports whether the detected type itself is in allowed. Parents are not
+// consulted: HTML descends from text/plain, and would otherwise pass a rule allowing text.
+func isAllowedMIME(mtype *mimetype.MIME, allowed map[string]struct{}) bool {
+	for _, active := range activeMIMEs {
+		if _, listed := allowed[active]; mtype.Is(active) && !listed {
+			return false
+		}
+	}
+	for a := range allowed {
+		if mtype.Is(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImageLimits decodes the image header from r and enforces limits.
+func checkImageLimits(r io.Reader, size int64, limits *ImageLimits) error {
+	if limits.MaxBytes > 0 && size > limits.MaxBytes {
+		return ErrFileTooLarge
+	}
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrImageUnreadable, err)
+	}
+	if limits.MaxWidth > 0 && cfg.Width > limits.MaxWidth {
+		return ErrImageTooLarge
+	}
+	if limits.MaxHeight > 0 && cfg.Height > limits.MaxHeight {
+		return ErrImageTooLarge
+	}
+	if limits.MaxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > limits.MaxPixels {
+		return ErrImageTooLarge
+	}
+	return nil
+}
+
+// AllowsExtension reports whether the extension of name is allowed by the rule.
+func (r *FileRule) AllowsExtension(name string) bool {
+	_, ok := r.AllowedExts[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// AllowsMIME reports whether mtype is allowed by the rule.
+func (r *FileRule) AllowsMIME(mtype *mimetype.MIME) bool {
+	return isAllowedMIME(mtype, r.AllowedMIMEs)
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
-	"net/http"
-	"path/filepath"
 	"strings"
 
 	"github.com/abhissng/neuron/utils/helpers"
@@ -18,6 +16,10 @@ import (
 ========================================
 */
 
+// UploadFunc persists a validated file. r is positioned at the start of the content and
+// contentType is the type sniffed from its magic bytes.
+type UploadFunc func(r io.Reader, size int64, contentType string) error
+
 func (cfg *Config) ValidateFile(file *multipart.FileHeader) error {
 
 	if cfg.rule == nil {
@@ -28,14 +30,6 @@ func (cfg *Config) ValidateFile(file *multipart.FileHeader) error {
 
 func (cfg *Config) validateSingleFile(file *multipart.FileHeader) error {
 	defer func() { helpers.RecoverException(recover()) }()
-	if file.Size > cfg.rule.MaxSizeBytes {
-		return ErrFileTooLarge
-	}
-
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if _, ok := cfg.rule.AllowedExts[ext]; !ok {
-		return ErrInvalidExtension
-	}
 
 	f, err := file.Open()
 	if err != nil {
@@ -45,36 +39,83 @@ func (cfg *Config) validateSingleFile(file *multipart.FileHeader) error {
 		_ = f.Close()
 	}()
 
-	header := make([]byte, 512)
-	n, err := f.Read(header)
-	if err != nil && err != io.EOF {
-		return err
+	_, err = cfg.ValidateReader(file.Filename, file.Size, f)
+	return err
+}
+
+// ValidateReader validates content of the given name and size against the configured rule.
+// The content type is sniffed from magic bytes, image limits are checked for images and the
+// virus scanner, if any, is run over the whole content. On success r is rewound to the start
+// and the sniffed content type is returned.
+func (cfg *Config) ValidateReader(name string, size int64, r io.ReadSeeker) (string, error) {
+	if cfg.rule == nil {
+		return "", errors.New("validation rule is required")
+	}
+	if size > cfg.rule.MaxSizeBytes {
+		return "", ErrFileTooLarge
 	}
 
-	mime := http.DetectContentType(header[:n])
-	if _, ok := cfg.rule.AllowedMIMEs[mime]; !ok {
-		return ErrInvalidMimeType
+	if !cfg.rule.AllowsExtension(name) {
+		return "", ErrInvalidExtension
 	}
 
-	if cfg.virusScanner != nil {
-		seeker, ok := f.(io.Seeker)
-		if !ok {
-			return errors.New("virus scanning requires seekable file")
+	mtype, err := DetectContentType(r)
+	if err != nil {
+		return "", err
+	}
+	if !cfg.rule.AllowsMIME(mtype) {
+		return "", ErrInvalidMimeType
+	}
+
+	if cfg.imageLimits != nil && strings.HasPrefix(mtype.String(), "image/") {
+		if err := rewind(r); err != nil {
+			return "", err
 		}
-		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
-			return fmt.Errorf("failed to rewind file for virus scanning: %w", err)
+		if err := checkImageLimits(r, size, cfg.imageLimits); err != nil {
+			return "", err
 		}
+	}
 
-		clean, err := cfg.virusScanner.Scan(f)
+	if cfg.virusScanner != nil {
+		if err := rewind(r); err != nil {
+			return "", fmt.Errorf("failed to rewind file for virus scanning: %w", err)
+		}
+
+		clean, err := cfg.virusScanner.Scan(r)
 		if err != nil {
-			return err
+			return "", err
 		}
 		if !clean {
-			return ErrVirusDetected
+			return "", ErrVirusDetected
 		}
 	}
 
-	return nil
+	if err := rewind(r); err != nil {
+		return "", err
+	}
+	return mtype.String(), nil
+}
+
+// ValidateAndUpload validates the file and hands it to upload only when every check passes,
+// so rejected or infected files are never persisted.
+func (cfg *Config) ValidateAndUpload(file *multipart.FileHeader, upload UploadFunc) error {
+	if upload == nil {
+		return ErrUploadFuncRequired
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	contentType, err := cfg.ValidateReader(file.Filename, file.Size, f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Filename, err)
+	}
+	return upload(f, file.Size, contentType)
 }
 
 func (cfg *Config) ValidateFiles(files []*multipart.FileHeader) error {
@@ -85,3 +126,12 @@ func (cfg *Config) ValidateFiles(files []*multipart.FileHeader) error {
 	}
 	return nil
 }
+
+// rewind seeks r back to the start of the content.
+func rewind(r io.Seeker) error {
+	if r == nil {
+		return ErrUnseekableUpload
+	}
+	_, err := r.Seek(0, io.SeekStart)
+	return err
+}
@@ -0,0 +1,275 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/cache"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/oschwald/geoip2-golang"
+)
+
+// GeoInfo holds the geographic and network details resolved for an IP address.
+type GeoInfo struct {
+	IP                string `json:"ip"`
+	CountryCode       string `json:"country_code,omitempty"`
+	CountryName       string `json:"country_name,omitempty"`
+	ContinentCode     string `json:"continent_code,omitempty"`
+	IsInEuropeanUnion bool   `json:"is_in_european_union,omitempty"`
+	ASN               uint   `json:"asn,omitempty"`
+	ASNOrganization   string `json:"asn_organization,omitempty"`
+}
+
+// IsResolved reports whether a country was found for the IP address.
+func (g *GeoInfo) IsResolved() bool {
+	return g != nil && g.CountryCode != ""
+}
+
+// dbReader keeps an open MaxMind reader together with the file state it was loaded from.
+type dbReader struct {
+	path    string
+	reader  *geoip2.Reader
+	modTime time.Time
+}
+
+// GeoIPManager resolves IP addresses to country and ASN information using MaxMind databases.
+// Database files are watched for changes and reloaded without interrupting lookups.
+type GeoIPManager struct {
+	countryPath    string
+	asnPath        string
+	country        *dbReader
+	asn            *dbReader
+	mu             sync.RWMutex
+	cache          cache.Cache[string, *GeoInfo]
+	cacheSize      int
+	cacheTTL       time.Duration
+	reloadInterval time.Duration
+	logger         *log.Log
+	done           chan struct{}
+	closeOnce      sync.Once
+}
+
+// NewGeoIPManager creates a new GeoIPManager and opens the configured databases.
+// At least one of the country or ASN databases must be configured.
+func NewGeoIPManager(options ...Option) (*GeoIPManager, error) {
+	manager := &GeoIPManager{
+		cacheSize:      DefaultCacheSize,
+		cacheTTL:       DefaultCacheTTL,
+		reloadInterval: DefaultReloadInterval,
+		done:           make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(manager)
+	}
+
+	if manager.logger == nil {
+		manager.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+
+	if helpers.IsEmpty(manager.countryPath) && helpers.IsEmpty(manager.asnPath) {
+		return nil, errors.New("geoip: no database path configured")
+	}
+
+	if !helpers.IsEmpty(manager.countryPath) {
+		db, err := openDatabase(manager.countryPath)
+		if err != nil {
+			return nil, err
+		}
+		manager.country = db
+	}
+
+	if !helpers.IsEmpty(manager.asnPath) {
+		db, err := openDatabase(manager.asnPath)
+		if err != nil {
+			manager.closeReaders()
+			return nil, err
+		}
+		manager.asn = db
+	}
+
+	manager.cache = cache.NewLRUCache[string, *GeoInfo](manager.cacheSize)
+
+	if manager.reloadInterval > 0 {
+		go manager.watch()
+	}
+
+	return manager, nil
+}
+
+// openDatabase opens a MaxMind database file and records its modification time.
+func openDatabase(path string) (*dbReader, error) {
+	cleanPath := filepath.Clean(path)
+	stat, err := os.Stat(cleanPath)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: failed to stat database %s: %w", cleanPath, err)
+	}
+
+	reader, err := geoip2.Open(cleanPath)
+	if err != nil {
+		return nil, fmt.Errorf("geoip: failed to open database %s: %w", cleanPath, err)
+	}
+
+	return &dbReader{path: cleanPath, reader: reader, modTime: stat.ModTime()}, nil
+}
+
+// Lookup resolves the given IP address (with or without port) to its GeoInfo.
+// Results are served from the cache when available.
+func (g *GeoIPManager) Lookup(remoteAddress string) (*GeoInfo, error) {
+	addr, err := helpers.ToNetIPAddr(remoteAddress)
+	if err != nil {
+		return nil, err
+	}
+	key := addr.String()
+
+	if info, ok := g.cache.Get(key); ok {
+		return info, nil
+	}
+
+	ip := net.IP(addr.AsSlice())
+	info := &GeoInfo{IP: key}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.country != nil {
+		record, err := g.country.reader.Country(ip)
+		if err != nil {
+			return nil, fmt.Errorf("geoip: country lookup failed for %s: %w", key, err)
+		}
+		info.CountryCode = record.Country.IsoCode
+		info.CountryName = record.Country.Names["en"]
+		info.ContinentCode = record.Continent.Code
+		info.IsInEuropeanUnion = record.Country.IsInEuropeanUnion
+	}
+
+	if g.asn != nil {
+		record, err := g.asn.reader.ASN(ip)
+		if err != nil {
+			return nil, fmt.Errorf("geoip: asn lookup failed for %s: %w", key, err)
+		}
+		info.ASN = record.AutonomousSystemNumber
+		info.ASNOrganization = record.AutonomousSystemOrganization
+	}
+
+	g.cache.SetWithExpiry(key, info, g.cacheTTL)
+	return info, nil
+}
+
+// LookupCountryCode returns the ISO country code for the IP address, or an empty string if unknown.
+func (g *GeoIPManager) LookupCountryCode(remoteAddress string) string {
+	info, err := g.Lookup(remoteAddress)
+	if err != nil {
+		return ""
+	}
+	return info.CountryCode
+}
+
+// Reload reopens any database file whose modification time has changed.
+// The lookup cache is cleared when at least one database is swapped.
+func (g *GeoIPManager) Reload() error {
+	countryReloaded, err := g.reloadDatabase(&g.country)
+	if err != nil {
+		return err
+	}
+	asnReloaded, err := g.reloadDatabase(&g.asn)
+	if err != nil {
+		return err
+	}
+
+	if countryReloaded || asnReloaded {
+		g.cache.Clear()
+	}
+	return nil
+}
+
+// reloadDatabase swaps the reader held in target when its file has changed on disk.
+func (g *GeoIPManager) reloadDatabase(target **dbReader) (bool, error) {
+	g.mu.RLock()
+	current := *target
+	g.mu.RUnlock()
+
+	if current == nil {
+		return false, nil
+	}
+
+	stat, err := os.Stat(current.path)
+	if err != nil {
+		return false, fmt.Errorf("geoip: failed to stat database %s: %w", current.path, err)
+	}
+	if !stat.ModTime().After(current.modTime) {
+		return false, nil
+	}
+
+	next, err := openDatabase(current.path)
+	if err != nil {
+		return false, err
+	}
+
+	g.mu.Lock()
+	*target = next
+	g.mu.Unlock()
+
+	if err := current.reader.Close(); err != nil {
+		g.logger.Warn("Failed to close previous geoip database", log.String("path", current.path), log.Err(err))
+	}
+	g.logger.Info("GeoIP database reloaded", log.String("path", current.path), log.Time("modified_at", next.modTime))
+	return true, nil
+}
+
+// watch periodically checks the database files and reloads them on change.
+func (g *GeoIPManager) watch() {
+	ticker := time.NewTicker(g.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						helpers.Println(constant.ERROR, "exception: occurred in geoip watch", "stack:", string(debug.Stack()))
+					}
+				}()
+				if err := g.Reload(); err != nil {
+					g.logger.Error("Failed to reload geoip database", log.Err(err))
+				}
+			}()
+		}
+	}
+}
+
+// closeReaders closes all open database readers.
+func (g *GeoIPManager) closeReaders() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, db := range []*dbReader{g.country, g.asn} {
+		if db != nil && db.reader != nil {
+			_ = db.reader.Close()
+		}
+	}
+	g.country = nil
+	g.asn = nil
+}
+
+// Close stops the reload watcher, closes the databases and releases the cache.
+func (g *GeoIPManager) Close() {
+	g.closeOnce.Do(func() {
+		close(g.done)
+		g.closeReaders()
+		if g.cache != nil {
+			g.cache.StopCleanup()
+		}
+	})
+}
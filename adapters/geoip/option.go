@@ -0,0 +1,66 @@
+package geoip
+
+import (
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+)
+
+const (
+	// DefaultReloadInterval is how often the database files are checked for changes.
+	DefaultReloadInterval = 1 * time.Minute
+	// DefaultCacheSize is the maximum number of IP lookups kept in the LRU cache.
+	DefaultCacheSize = 10000
+	// DefaultCacheTTL is how long a cached lookup stays valid.
+	DefaultCacheTTL = 1 * time.Hour
+)
+
+// Option defines a functional option for configuring GeoIPManager.
+type Option func(*GeoIPManager)
+
+// WithCountryDatabase sets the path of the MaxMind country (or city) database.
+func WithCountryDatabase(path string) Option {
+	return func(g *GeoIPManager) {
+		g.countryPath = path
+	}
+}
+
+// WithASNDatabase sets the path of the MaxMind ASN database.
+func WithASNDatabase(path string) Option {
+	return func(g *GeoIPManager) {
+		g.asnPath = path
+	}
+}
+
+// WithReloadInterval sets how often database files are checked for changes.
+// A non-positive interval disables hot reload.
+func WithReloadInterval(interval time.Duration) Option {
+	return func(g *GeoIPManager) {
+		g.reloadInterval = interval
+	}
+}
+
+// WithCacheSize sets the maximum number of cached lookups.
+func WithCacheSize(size int) Option {
+	return func(g *GeoIPManager) {
+		if size > 0 {
+			g.cacheSize = size
+		}
+	}
+}
+
+// WithCacheTTL sets how long a cached lookup stays valid.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(g *GeoIPManager) {
+		if ttl > 0 {
+			g.cacheTTL = ttl
+		}
+	}
+}
+
+// WithLogger sets the logger for the manager.
+func WithLogger(logger *log.Log) Option {
+	return func(g *GeoIPManager) {
+		g.logger = logger
+	}
+}
@@ -0,0 +1,306 @@
+// Package canary splits the traffic of Gin routes between releases, for in-process canary
+// and blue/green deployments. A request is routed to a variant by an override header, by the
+// organisation it belongs to, or by a sticky hash of the caller weighted by the variant
+// percentages, which can be shifted at runtime. Variants are either handlers of the same
+// binary or upstream services reached through a reverse proxy.
+package canary
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is the gin context key holding the name of the variant serving the request.
+const ContextKey = "canary_variant"
+
+// Variant is a release serving a share of the traffic, either in process with Handler or on
+// the service at Upstream, e.g. "http://orders-v2:8080".
+type Variant struct {
+	Name string
+	// Weight is the percentage of the traffic routed to the variant.
+	Weight   int
+	Handler  gin.HandlerFunc
+	Upstream string
+
+	proxy *httputil.ReverseProxy
+}
+
+// Router routes the requests of a route to its variants.
+type Router struct {
+	mu           sync.RWMutex
+	variants     []*Variant
+	byName       map[string]*Variant
+	header       string
+	orgs         map[string]string
+	stickyKey    func(*gin.Context) string
+	salt         string
+	cookie       string
+	cookieMaxAge int
+	metrics      *Metrics
+	logger       *log.Log
+}
+
+// Option configures a Router.
+type Option func(*Router)
+
+// WithHeader sets the header naming the variant a request must be routed to, e.g. for testers
+// reaching the canary before it gets traffic. Defaults to constant.XCanaryVariant; an empty
+// header disables the override.
+func WithHeader(header string) Option {
+	return func(r *Router) {
+		r.header = header
+	}
+}
+
+// WithOrgs pins the organisations orgIDs to variant, whatever the weights.
+func WithOrgs(variant string, orgIDs ...string) Option {
+	return func(r *Router) {
+		for _, orgID := range orgIDs {
+			r.orgs[orgID] = variant
+		}
+	}
+}
+
+// WithStickyKey sets the key a caller is consistently routed by. Defaults to the X-User-Id
+// header, then the X-Org-Id header, then the client IP.
+func WithStickyKey(key func(*gin.Context) string) Option {
+	return func(r *Router) {
+		r.stickyKey = key
+	}
+}
+
+// WithSalt sets the salt of the sticky hash. Changing it reshuffles the callers between the
+// variants.
+func WithSalt(salt string) Option {
+	return func(r *Router) {
+		r.salt = salt
+	}
+}
+
+// WithCookie remembers the variant of a caller in the cookie name for maxAge seconds, so it
+// stays on its variant when its sticky key changes, e.g. after signing in.
+func WithCookie(name string, maxAge int) Option {
+	return func(r *Router) {
+		r.cookie = name
+		r.cookieMaxAge = maxAge
+	}
+}
+
+// WithMetrics counts requests and their latency per variant.
+func WithMetrics(metrics *Metrics) Option {
+	return func(r *Router) {
+		r.metrics = metrics
+	}
+}
+
+// WithLogger sets the logger used to report upstream failures.
+func WithLogger(logger *log.Log) Option {
+	return func(r *Router) {
+		r.logger = logger
+	}
+}
+
+// NewRouter creates a Router for variants, whose weights must add up to 100. The first
+// variant is the stable release.
+func NewRouter(variants []Variant, options ...Option) (*Router, error) {
+	if len(variants) == 0 {
+		return nil, errors.New("canary: at least one variant is required")
+	}
+	r := &Router{
+		byName:    make(map[string]*Variant, len(variants)),
+		header:    constant.XCanaryVariant,
+		orgs:      make(map[string]string),
+		stickyKey: defaultStickyKey,
+		logger:    log.NewBasicLogger(helpers.IsProdEnvironment(), true),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	weights := make(map[string]int, len(variants))
+	for i := range variants {
+		v := variants[i]
+		if v.Name == "" {
+			return nil, errors.New("canary: variant name is required")
+		}
+		if _, ok := r.byName[v.Name]; ok {
+			return nil, fmt.Errorf("canary: duplicate variant %q", v.Name)
+		}
+		if (v.Handler == nil) == (v.Upstream == "") {
+			return nil, fmt.Errorf("canary: variant %q needs either a handler or an upstream", v.Name)
+		}
+		if v.Upstream != "" {
+			target, err := url.Parse(v.Upstream)
+			if err != nil || target.Scheme == "" || target.Host == "" {
+				return nil, fmt.Errorf("canary: invalid upstream %q of variant %q", v.Upstream, v.Name)
+			}
+			v.proxy = r.newProxy(v.Name, target)
+		}
+		r.variants = append(r.variants, &v)
+		r.byName[v.Name] = &v
+		weights[v.Name] = v.Weight
+	}
+	for orgID, name := range r.orgs {
+		if _, ok := r.byName[name]; !ok {
+			return nil, fmt.Errorf("canary: organisation %s is pinned to unknown variant %q", orgID, name)
+		}
+	}
+	if err := r.SetWeights(weights); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetWeights shifts the traffic between the variants, e.g. from 5% to 25% on the canary, or
+// all of it at once for a blue/green switch. Variants missing from weights get no traffic;
+// the weights must add up to 100. Sticky callers stay on their variant as long as it keeps
+// its share of the traffic.
+func (r *Router) SetWeights(weights map[string]int) error {
+	total := 0
+	for name, weight := range weights {
+		if _, ok := r.byName[name]; !ok {
+			return fmt.Errorf("canary: unknown variant %q", name)
+		}
+		if weight < 0 {
+			return fmt.Errorf("canary: negative weight of variant %q", name)
+		}
+		total += weight
+	}
+	if total != 100 {
+		return fmt.Errorf("canary: weights add up to %d, not 100", total)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.variants {
+		v.Weight = weights[v.Name]
+	}
+	return nil
+}
+
+// Weights returns the current percentage of the traffic of each variant.
+func (r *Router) Weights() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	weights := make(map[string]int, len(r.variants))
+	for _, v := range r.variants {
+		weights[v.Name] = v.Weight
+	}
+	return weights
+}
+
+// Handler returns the handler of the route, serving each request with its variant.
+func (r *Router) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		v := r.Assign(c)
+		c.Set(ContextKey, v.Name)
+		c.Header(constant.XCanaryVariant, v.Name)
+		if r.cookie != "" {
+			c.SetSameSite(http.SameSiteLaxMode)
+			c.SetCookie(r.cookie, v.Name, r.cookieMaxAge, "/", "", c.Request.TLS != nil, true)
+		}
+
+		if v.proxy != nil {
+			v.proxy.ServeHTTP(c.Writer, c.Request)
+		} else {
+			v.Handler(c)
+		}
+
+		if r.metrics != nil {
+			r.metrics.observe(c.FullPath(), v.Name, c.Writer.Status(), time.Since(start))
+		}
+	}
+}
+
+// Assign returns the variant serving the request of c: the one named by the override header,
+// then the one its organisation is pinned to, then the one remembered in its cookie, and
+// otherwise the one its sticky key hashes to.
+func (r *Router) Assign(c *gin.Context) *Variant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.header != "" {
+		if v, ok := r.byName[c.GetHeader(r.header)]; ok {
+			return v
+		}
+	}
+	if name, ok := r.orgs[c.GetHeader(constant.XOrgId)]; ok {
+		return r.byName[name]
+	}
+	if r.cookie != "" {
+		if name, err := c.Cookie(r.cookie); err == nil {
+			if v, ok := r.byName[name]; ok && v.Weight > 0 {
+				return v
+			}
+		}
+	}
+	return r.pick(r.bucket(r.stickyKey(c)))
+}
+
+// bucket returns the bucket, from 0 to 99, of key; callers without a key get a random one.
+func (r *Router) bucket(key string) int {
+	if key == "" {
+		return rand.IntN(100) // #nosec G404 -- traffic split only
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(r.salt))
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
+
+// pick returns the variant whose share of the traffic covers bucket.
+func (r *Router) pick(bucket int) *Variant {
+	cumulative := 0
+	for _, v := range r.variants {
+		cumulative += v.Weight
+		if bucket < cumulative {
+			return v
+		}
+	}
+	return r.variants[0]
+}
+
+// newProxy creates the reverse proxy to the upstream of the variant name.
+func (r *Router) newProxy(name string, target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			r.logger.Error("Canary upstream failed", log.String("variant", name), log.String("path", req.URL.Path), log.Err(err))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"error":"Upstream is unavailable"}`))
+		},
+	}
+}
+
+// GetVariant returns the name of the variant serving the request of c.
+func GetVariant(c *gin.Context) string {
+	return c.GetString(ContextKey)
+}
+
+// defaultStickyKey routes callers by user, then organisation, then client IP.
+func defaultStickyKey(c *gin.Context) string {
+	if user := c.GetHeader(constant.XUserId); user != "" {
+		return user
+	}
+	if org := c.GetHeader(constant.XOrgId); org != "" {
+		return org
+	}
+	return c.ClientIP()
+}
@@ -0,0 +1,59 @@
+package canary
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics counts requests and their latency per variant, to compare a canary with the stable
+// release before shifting more traffic to it.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewMetrics registers the canary collectors with registerer. An already registered collector
+// is reused.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	requests, err := register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_canary_requests_total",
+		Help: "Number of HTTP requests per routing variant.",
+	}, []string{"route", "variant", "status"}))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_canary_request_duration_seconds",
+		Help:    "Duration of HTTP requests per routing variant.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "variant"}))
+	if err != nil {
+		return nil, err
+	}
+	return &Metrics{requests: requests, duration: duration}, nil
+}
+
+func (m *Metrics) observe(route, variant string, status int, elapsed time.Duration) {
+	m.requests.WithLabelValues(route, variant, strconv.Itoa(status)).Inc()
+	m.duration.WithLabelValues(route, variant).Observe(elapsed.Seconds())
+}
+
+// register registers c with registerer, returning the existing collector when it is already
+// registered.
+func register[C prometheus.Collector](registerer prometheus.Registerer, c C) (C, error) {
+	if err := registerer.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			return c, err
+		}
+		existing, ok := already.ExistingCollector.(C)
+		if !ok {
+			return c, err
+		}
+		return existing, nil
+	}
+	return c, nil
+}
@@ -0,0 +1,229 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/gin/proxy"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultAggregateTimeout bounds the fan-out of an Aggregator without WithAggregateTimeout.
+const DefaultAggregateTimeout = 10 * time.Second
+
+// maxBackendResponse is the largest backend response an Aggregator reads.
+const maxBackendResponse = 10 << 20
+
+// Backend is a service queried by an Aggregator. URL may hold route parameters in braces,
+// e.g. "http://users.internal/users/{id}", filled from the parameters of the gin route.
+type Backend struct {
+	// Name is the key of the backend response in the merged response.
+	Name string
+	URL  string
+	// Required fails the whole request when the backend fails. Otherwise its response is
+	// left out and the failure reported under "errors".
+	Required bool
+}
+
+// MergeFunc merges the JSON responses of the backends, by backend name, into the response
+// body. failed holds the errors of the optional backends that failed.
+type MergeFunc func(responses map[string]json.RawMessage, failed map[string]string) (any, error)
+
+// Aggregator fans a request out to several backends concurrently and merges their responses,
+// e.g. a user profile with its orders and its wallet balance.
+type Aggregator struct {
+	backends    []Backend
+	client      *http.Client
+	headers     []string
+	tokenSource proxy.TokenSource
+	timeout     time.Duration
+	merge       MergeFunc
+	logger      *log.Log
+}
+
+// AggregateOption configures an Aggregator.
+type AggregateOption func(*Aggregator)
+
+// WithClient sets the client the backends are queried with. Defaults to http.DefaultClient.
+func WithClient(client *http.Client) AggregateOption {
+	return func(a *Aggregator) {
+		a.client = client
+	}
+}
+
+// WithForwardedHeaders replaces proxy.DefaultHeaders with the request headers forwarded to
+// the backends.
+func WithForwardedHeaders(headers ...string) AggregateOption {
+	return func(a *Aggregator) {
+		a.headers = headers
+	}
+}
+
+// WithServiceToken authorises the backend requests with the bearer token of source.
+func WithServiceToken(source proxy.TokenSource) AggregateOption {
+	return func(a *Aggregator) {
+		a.tokenSource = source
+	}
+}
+
+// WithAggregateTimeout bounds the fan-out. Defaults to DefaultAggregateTimeout.
+func WithAggregateTimeout(timeout time.Duration) AggregateOption {
+	return func(a *Aggregator) {
+		a.timeout = timeout
+	}
+}
+
+// WithMerge sets how the responses are merged. By default the response is an object holding
+// each backend response under its name, and "errors" when optional backends failed.
+func WithMerge(merge MergeFunc) AggregateOption {
+	return func(a *Aggregator) {
+		a.merge = merge
+	}
+}
+
+// WithAggregateLogger sets the logger used to report backend failures.
+func WithAggregateLogger(logger *log.Log) AggregateOption {
+	return func(a *Aggregator) {
+		a.logger = logger
+	}
+}
+
+// NewAggregator creates an Aggregator of backends.
+func NewAggregator(backends []Backend, opts ...AggregateOption) *Aggregator {
+	a := &Aggregator{
+		backends: backends,
+		client:   http.DefaultClient,
+		headers:  proxy.DefaultHeaders,
+		timeout:  DefaultAggregateTimeout,
+		merge:    mergeByName,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.logger == nil {
+		a.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return a
+}
+
+// Handler returns the handler of the aggregated route.
+func (a *Aggregator) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), a.timeout)
+		defer cancel()
+
+		token := ""
+		if a.tokenSource != nil {
+			var err error
+			if token, err = a.tokenSource(ctx); err != nil {
+				a.logger.Error("Failed to fetch the service token", log.Err(err))
+				c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "Upstream is unavailable"})
+				return
+			}
+		}
+
+		var (
+			mu        sync.Mutex
+			wg        sync.WaitGroup
+			responses = make(map[string]json.RawMessage, len(a.backends))
+			failed    = make(map[string]string)
+		)
+		for _, backend := range a.backends {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				body, err := a.fetch(ctx, c, backend, token)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					a.logger.Warn("Aggregated backend failed", log.String("backend", backend.Name), log.Err(err))
+					failed[backend.Name] = err.Error()
+					return
+				}
+				responses[backend.Name] = body
+			}()
+		}
+		wg.Wait()
+
+		for _, backend := range a.backends {
+			if _, ok := failed[backend.Name]; ok && backend.Required {
+				status := http.StatusBadGateway
+				if ctx.Err() != nil {
+					status = http.StatusGatewayTimeout
+				}
+				c.AbortWithStatusJSON(status, gin.H{"error": fmt.Sprintf("Upstream %s is unavailable", backend.Name)})
+				return
+			}
+		}
+		merged, err := a.merge(responses, failed)
+		if err != nil {
+			a.logger.Error("Failed to merge aggregated responses", log.Err(err))
+			c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "Failed to merge upstream responses"})
+			return
+		}
+		if len(failed) > 0 {
+			// Partial responses must not be cached.
+			c.Header("Cache-Control", "no-store")
+		}
+		c.JSON(http.StatusOK, merged)
+	}
+}
+
+// fetch queries backend and returns its JSON response.
+func (a *Aggregator) fetch(ctx context.Context, c *gin.Context, backend Backend, token string) (json.RawMessage, error) {
+	target := backend.URL
+	for _, param := range c.Params {
+		target = strings.ReplaceAll(target, "{"+param.Key+"}", url.PathEscape(param.Value))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range a.headers {
+		if value := c.GetHeader(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	if token != "" {
+		req.Header.Set(constant.AuthorizationHeader, "Bearer "+token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBackendResponse))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("upstream responded with status %d", resp.StatusCode)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("upstream response is not JSON")
+	}
+	return body, nil
+}
+
+// mergeByName nests each response under its backend name.
+func mergeByName(responses map[string]json.RawMessage, failed map[string]string) (any, error) {
+	merged := make(map[string]any, len(responses)+1)
+	for name, body := range responses {
+		merged[name] = body
+	}
+	if len(failed) > 0 {
+		merged["errors"] = failed
+	}
+	return merged, nil
+}
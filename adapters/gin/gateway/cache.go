@@ -0,0 +1,211 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/cache"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// CacheHeader reports whether a response was served from the cache, "HIT", or not, "MISS".
+const CacheHeader = "X-Cache"
+
+// CacheStore stores the responses cached by Cache.
+type CacheStore interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// RedisCacheStore is a CacheStore backed by Redis, shared by the replicas of the gateway.
+type RedisCacheStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCacheStore creates a RedisCacheStore storing its keys under prefix. Defaults to
+// "gateway_cache:".
+func NewRedisCacheStore(client *redis.Client, prefix string) *RedisCacheStore {
+	if prefix == "" {
+		prefix = "gateway_cache:"
+	}
+	return &RedisCacheStore{client: client, prefix: prefix}
+}
+
+// Get returns the value of key.
+func (s *RedisCacheStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value for ttl.
+func (s *RedisCacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, value, ttl).Err()
+}
+
+// MemoryCacheStore is a CacheStore local to a replica, backed by a cache.Cache.
+type MemoryCacheStore struct {
+	cache cache.Cache[string, []byte]
+}
+
+// NewMemoryCacheStore creates a MemoryCacheStore.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{cache: cache.NewBasicCache[string, []byte]()}
+}
+
+// Get returns the value of key.
+func (s *MemoryCacheStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := s.cache.Get(key)
+	return value, ok, nil
+}
+
+// Set stores value for ttl.
+func (s *MemoryCacheStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.cache.SetWithExpiry(key, value, ttl)
+	return nil
+}
+
+// Close stops the cleanup of the expired entries.
+func (s *MemoryCacheStore) Close() {
+	s.cache.StopCleanup()
+}
+
+// cachedResponse is a response stored by Cache.
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// cacheOptions holds the options of Cache.
+type cacheOptions struct {
+	vary   []string
+	logger *log.Log
+}
+
+// CacheOption configures Cache.
+type CacheOption func(*cacheOptions)
+
+// WithVary caches a response per value of headers, e.g. constant.XOrgId for responses
+// specific to an organisation. Authorization is always part of the key.
+func WithVary(headers ...string) CacheOption {
+	return func(o *cacheOptions) {
+		o.vary = append(o.vary, headers...)
+	}
+}
+
+// WithCacheLogger sets the logger used to report store failures.
+func WithCacheLogger(logger *log.Log) CacheOption {
+	return func(o *cacheOptions) {
+		o.logger = logger
+	}
+}
+
+// Cache returns a middleware caching the successful GET and HEAD responses of a route in
+// store for ttl. Requests sending Cache-Control: no-cache skip the cache, and responses
+// sending Cache-Control: no-store or private, or setting cookies, are not cached. Store
+// failures let requests through to the upstream.
+func Cache(store CacheStore, ttl time.Duration, opts ...CacheOption) gin.HandlerFunc {
+	options := cacheOptions{vary: []string{constant.AuthorizationHeader}}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.logger == nil {
+		options.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		key := cacheKey(c.Request, options.vary)
+		if !strings.Contains(c.GetHeader("Cache-Control"), "no-cache") {
+			data, ok, err := store.Get(c.Request.Context(), key)
+			if err != nil {
+				options.logger.Warn("Gateway cache lookup failed", log.String("path", c.Request.URL.Path), log.Err(err))
+			}
+			var cached cachedResponse
+			if ok && json.Unmarshal(data, &cached) == nil {
+				for name, values := range cached.Header {
+					c.Writer.Header()[name] = values
+				}
+				c.Header(CacheHeader, "HIT")
+				c.Status(cached.Status)
+				_, _ = c.Writer.Write(cached.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Header(CacheHeader, "MISS")
+		c.Next()
+
+		if !cacheable(writer) {
+			return
+		}
+		header := writer.Header().Clone()
+		header.Del(CacheHeader)
+		data, err := json.Marshal(cachedResponse{Status: writer.Status(), Header: header, Body: writer.body.Bytes()})
+		if err != nil {
+			return
+		}
+		if err := store.Set(context.WithoutCancel(c.Request.Context()), key, data, ttl); err != nil {
+			options.logger.Warn("Failed to cache gateway response", log.String("path", c.Request.URL.Path), log.Err(err))
+		}
+	}
+}
+
+// cacheable reports whether the response of w can be cached.
+func cacheable(w *captureWriter) bool {
+	if w.Status() != http.StatusOK || w.Header().Get("Set-Cookie") != "" {
+		return false
+	}
+	control := w.Header().Get("Cache-Control")
+	return !strings.Contains(control, "no-store") && !strings.Contains(control, "private")
+}
+
+// cacheKey identifies the response of r by its method, URI and the values of vary.
+func cacheKey(r *http.Request, vary []string) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	for _, header := range vary {
+		h.Write([]byte(header + ": " + r.Header.Get(header) + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureWriter records the body written to a response.
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,19 @@
+// Package gateway provides the primitives to assemble a lightweight API gateway from neuron,
+// on top of the proxy package: per-route authentication policies, response caching and the
+// aggregation of several backend responses into one.
+//
+//	policies := gateway.Policies{
+//		"/health":         gateway.Public(),
+//		"GET /orders/:id": gateway.RequireToken(pasetoManager, "orders:read"),
+//	}
+//	router.Use(policies.Middleware(gateway.Deny()))
+//
+//	orders, _ := proxy.New("http://orders.internal:8080", proxy.WithStripPrefix("/orders"))
+//	router.GET("/orders/:id", gateway.Cache(store, time.Minute), orders.Handler())
+//
+//	profile := gateway.NewAggregator([]gateway.Backend{
+//		{Name: "user", URL: "http://users.internal/users/{id}", Required: true},
+//		{Name: "wallet", URL: "http://wallet.internal/wallets/{id}"},
+//	})
+//	router.GET("/profiles/:id", profile.Handler())
+package gateway
@@ -0,0 +1,133 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/gin/request"
+	"github.com/abhissng/neuron/adapters/paseto"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/gin-gonic/gin"
+)
+
+// These are the errors of the policies, answered with 401 and 403 respectively. Policies
+// returning other errors are answered with 403.
+var (
+	ErrUnauthenticated = errors.New("gateway: authentication required")
+	ErrForbidden       = errors.New("gateway: access denied")
+)
+
+// Policy decides whether the gateway lets a request through.
+type Policy func(c *gin.Context) error
+
+// Policies maps routes to their policy. A route is either "METHOD /full/path", e.g.
+// "POST /orders/:id", or "/full/path" for every method, with the path as registered in gin.
+type Policies map[string]Policy
+
+// policy returns the policy of method on route, the method-specific entry first.
+func (p Policies) policy(method, route string) (Policy, bool) {
+	if policy, ok := p[method+" "+route]; ok {
+		return policy, true
+	}
+	policy, ok := p[route]
+	return policy, ok
+}
+
+// Middleware enforces the policies of the routes, and fallback on the routes without one.
+// Pass Deny as the fallback so routes added later are closed until given a policy.
+func (p Policies) Middleware(fallback Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy, ok := p.policy(c.Request.Method, c.FullPath())
+		if !ok {
+			policy = fallback
+		}
+		if policy == nil {
+			c.Next()
+			return
+		}
+		if err := policy(c); err != nil {
+			status, message := http.StatusForbidden, "Access denied"
+			if errors.Is(err, ErrUnauthenticated) {
+				status, message = http.StatusUnauthorized, "Authentication required"
+			}
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(status, gin.H{"error": message})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Public lets every request through.
+func Public() Policy {
+	return func(*gin.Context) error {
+		return nil
+	}
+}
+
+// Deny rejects every request.
+func Deny() Policy {
+	return func(*gin.Context) error {
+		return ErrForbidden
+	}
+}
+
+// RequireHeaders rejects the requests missing one of headers, e.g. an API key checked by the
+// upstream.
+func RequireHeaders(headers ...string) Policy {
+	return func(c *gin.Context) error {
+		for _, header := range headers {
+			if c.GetHeader(header) == "" {
+				return fmt.Errorf("%w: missing %s header", ErrUnauthenticated, header)
+			}
+		}
+		return nil
+	}
+}
+
+// RequireToken requires a valid paseto bearer token granting scopes. The claims of the token
+// are stored in the gin context under constant.Claims.
+func RequireToken(manager *paseto.PasetoManager, scopes ...string) Policy {
+	return func(c *gin.Context) error {
+		token, err := request.FetchPasetoBearerToken(c).Value()
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrUnauthenticated, err.FetchErrCode())
+		}
+		claim, err := manager.ValidateToken(*token, nil, paseto.WithValidateEssentialTags).Value()
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrUnauthenticated, err.FetchErrCode())
+		}
+		if missing := claim.MissingScopes(scopes...); len(missing) > 0 {
+			return fmt.Errorf("%w: missing scopes %s", ErrForbidden, strings.Join(missing, ", "))
+		}
+		c.Set(constant.Claims, claim)
+		return nil
+	}
+}
+
+// Any lets through the requests allowed by at least one of policies.
+func Any(policies ...Policy) Policy {
+	return func(c *gin.Context) error {
+		err := ErrForbidden
+		for _, policy := range policies {
+			if err = policy(c); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
+}
+
+// All lets through the requests allowed by every one of policies.
+func All(policies ...Policy) Policy {
+	return func(c *gin.Context) error {
+		for _, policy := range policies {
+			if err := policy(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/abhissng/neuron/blame"
 	"github.com/abhissng/neuron/context"
 	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/codec"
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/structures/acknowledgment"
@@ -83,7 +84,7 @@ func ExecuteControllerHandler[T any](handler RequestHandler[T]) gin.HandlerFunc
 				handleException("Controller", ctx, err)
 				return
 			}
-			processResult(handlerResult, ctx)
+			processResult(handlerResult, ctx, codec.JSON)
 			_ = c.Request.Body.Close() // #nosec G104
 		}()
 
@@ -103,8 +104,8 @@ func handleException(handlerType string, ctx *context.ServiceContext, err any) {
 	})
 }
 
-// processResult processes the result and returns the response
-func processResult[T any](res result.Result[T], ctx *context.ServiceContext) {
+// processResult processes the result and returns the response in format
+func processResult[T any](res result.Result[T], ctx *context.ServiceContext, format types.CodecType) {
 	if !res.IsSuccess() {
 		redirectURL, Redirect := res.Redirect()
 		if Redirect {
@@ -117,7 +118,10 @@ func processResult[T any](res result.Result[T], ctx *context.ServiceContext) {
 		status := helpers.FetchHTTPStatusCode(cause.FetchResponseType())
 		errorResponse := cause.FetchErrorResponse(blame.WithTranslation())
 		ctx.SlogError(constant.HandlerFailed, log.Blame(cause))
-		ctx.JSON(status, acknowledgment.NewAPIResponse[any](false, types.CorrelationID(ctx.GetGinContextCorrelationID()), errorResponse))
+		if format == codec.CSV {
+			format = codec.JSON
+		}
+		render(ctx, status, format, acknowledgment.NewAPIResponse[any](false, types.CorrelationID(ctx.GetGinContextCorrelationID()), errorResponse), nil)
 		return
 	}
 
@@ -129,5 +133,5 @@ func processResult[T any](res result.Result[T], ctx *context.ServiceContext) {
 	}
 
 	data, _ := res.Value()
-	ctx.JSON(http.StatusOK, acknowledgment.NewAPIResponse[*T](true, ctx.GetGinContextCorrelationID(), data))
+	render(ctx, http.StatusOK, format, acknowledgment.NewAPIResponse[*T](true, ctx.GetGinContextCorrelationID(), data), data)
 }
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/gin/middleware"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/context"
+	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// DefaultFormats are the formats offered by ExecuteNegotiatedHandler when a route names none.
+var DefaultFormats = []types.CodecType{codec.JSON, codec.XML, codec.MessagePack, codec.CSV}
+
+// mediaTypes lists the media types of each negotiable format; the first one is sent as the
+// Content-Type.
+var mediaTypes = map[types.CodecType][]string{
+	codec.JSON:        {"application/json"},
+	codec.XML:         {"application/xml", "text/xml"},
+	codec.MessagePack: {"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+	codec.CSV:         {"text/csv"},
+}
+
+// ExecuteNegotiatedHandler is ExecuteControllerHandler rendering the response in the format
+// the Accept header prefers among formats, DefaultFormats when empty. XML, JSON and
+// MessagePack responses carry the usual envelope; CSV responses carry the result alone,
+// which must be a struct or a slice of structs, and errors are then sent as JSON. Requests
+// accepting none of formats get 406 Not Acceptable.
+func ExecuteNegotiatedHandler[T any](handler RequestHandler[T], formats ...types.CodecType) gin.HandlerFunc {
+	if len(formats) == 0 {
+		formats = DefaultFormats
+	}
+	return func(c *gin.Context) {
+		ctx, err := middleware.GetServiceContext(c)
+		if err != nil {
+			err := blame.ServiceContextFetchError(viper.GetString(constant.SupportEmail), err)
+			res := err.FetchErrorResponse(blame.WithTranslation())
+			c.AbortWithStatusJSON(500, acknowledgment.NewAPIResponse[any](false, "", res))
+			_ = c.Request.Body.Close() // #nosec G104
+			return
+		}
+
+		c.Header("Vary", "Accept")
+		format, ok := Negotiate(c, formats...)
+		if !ok {
+			response := acknowledgment.NewAPIResponse[any](false, ctx.GetGinContextCorrelationID(), nil)
+			c.AbortWithStatusJSON(http.StatusNotAcceptable, response.WithError(errors.New("none of the accepted media types is available")))
+			_ = c.Request.Body.Close() // #nosec G104
+			return
+		}
+
+		var handlerResult result.Result[T]
+		defer func() {
+			if err := recover(); err != nil {
+				handleException("Controller", ctx, err)
+				return
+			}
+			processResult(handlerResult, ctx, format)
+			_ = c.Request.Body.Close() // #nosec G104
+		}()
+
+		handlerResult = handler(ctx)
+	}
+}
+
+// Negotiate returns the format of offered preferred by the Accept header of c, honouring
+// quality values, wildcards and exclusions by "q=0". A request without an Accept header gets
+// the first of offered.
+func Negotiate(c *gin.Context, offered ...types.CodecType) (types.CodecType, bool) {
+	if len(offered) == 0 {
+		return "", false
+	}
+	accept := strings.TrimSpace(c.GetHeader("Accept"))
+	if accept == "" {
+		return offered[0], true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+	var ranges []mediaRange
+	var excluded []string
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		for _, param := range params[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					r.quality = q
+				}
+			}
+		}
+		switch {
+		case r.mediaType == "":
+		case r.quality <= 0:
+			excluded = append(excluded, r.mediaType)
+		default:
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, r := range ranges {
+		for _, format := range offered {
+			if slices.Contains(excluded, mediaTypes[format][0]) {
+				continue
+			}
+			if matchesMediaRange(r.mediaType, mediaTypes[format]) {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// matchesMediaRange reports whether mediaRange, e.g. "text/*", covers one of mediaTypes.
+func matchesMediaRange(mediaRange string, mediaTypes []string) bool {
+	if mediaRange == "*/*" {
+		return len(mediaTypes) > 0
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "/*"); ok {
+		return slices.ContainsFunc(mediaTypes, func(mediaType string) bool {
+			return strings.HasPrefix(mediaType, prefix+"/")
+		})
+	}
+	return slices.Contains(mediaTypes, mediaRange)
+}
+
+// render writes body, the response envelope, in format. CSV carries payload instead, as a
+// table has no room for the envelope.
+func render(ctx *context.ServiceContext, status int, format types.CodecType, body, payload any) {
+	switch format {
+	case codec.JSON, "":
+		ctx.Render(status, pooledJSON{Data: body})
+		return
+	case codec.CSV:
+		body = payload
+	}
+
+	data, err := encodeResponse(body, format)
+	if err != nil {
+		ctx.SlogError("Failed to encode response", log.WithField("format", format.String()), log.WithField("error", err.Error()))
+		response := acknowledgment.NewAPIResponse[any](false, ctx.GetGinContextCorrelationID(), nil)
+		ctx.Render(http.StatusInternalServerError, pooledJSON{Data: response.WithError(errors.New("failed to encode the response as " + format.String()))})
+		return
+	}
+	ctx.Data(status, mediaTypes[format][0], data)
+}
+
+// encodeResponse encodes body with codec, wrapping XML in a <response> root element as the
+// generic envelope has no usable element name.
+func encodeResponse(body any, format types.CodecType) ([]byte, error) {
+	if format == codec.XML {
+		return codec.EncodeXMLElement(body, "response")
+	}
+	return codec.Encode(body, format)
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/abhissng/neuron/utils/codec"
+)
+
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+// pooledJSON renders Data as JSON through the pooled buffers of codec.EncodeJSONTo, sparing
+// the per response allocation of render.JSON.
+type pooledJSON struct {
+	Data any
+}
+
+// Render writes the JSON encoding of Data.
+func (r pooledJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return codec.EncodeJSONTo(w, r.Data)
+}
+
+// WriteContentType sets the JSON content type.
+func (r pooledJSON) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = jsonContentType
+	}
+}
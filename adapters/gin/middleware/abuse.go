@@ -0,0 +1,451 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// Security event types emitted by AbuseGuard
+const (
+	SecurityEventSlowBody     = "slow_body"
+	SecurityEventAuthBurst    = "auth_failure_burst"
+	SecurityEventNotFoundScan = "not_found_burst"
+	SecurityEventMissingAgent = "missing_user_agent"
+	SecurityEventBadAgent     = "blocked_user_agent"
+	SecurityEventThrottled    = "throttled"
+	SecurityEventBanned       = "banned"
+)
+
+// errSlowBody is returned by the request body reader when a client sends its body too slowly.
+var errSlowBody = errors.New("request body is being sent too slowly")
+
+// SecurityEvent describes suspicious client behaviour detected by AbuseGuard.
+type SecurityEvent struct {
+	Type      string        `json:"type"`
+	IP        string        `json:"ip"`
+	UserID    string        `json:"user_id,omitempty"`
+	UserAgent string        `json:"user_agent,omitempty"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Detail    string        `json:"detail,omitempty"`
+	Strikes   int           `json:"strikes"`
+	Penalty   time.Duration `json:"penalty"`
+	Time      time.Time     `json:"time"`
+}
+
+// SecurityEventSink receives the security events of an AbuseGuard, e.g. to write them to an
+// audit index. It is called synchronously and must not block.
+type SecurityEventSink func(ctx context.Context, event SecurityEvent)
+
+// abuseClient is the detection state of one client IP.
+type abuseClient struct {
+	strikes      int
+	lastStrike   time.Time
+	blockedUntil time.Time
+	authFailures []time.Time
+	notFound     []time.Time
+	lastSeen     time.Time
+}
+
+// AbuseGuard detects abusive clients and throttles them with an escalating penalty. It
+// watches for request bodies sent abnormally slowly, bursts of 401/403 and 404 responses
+// from one IP and requests with missing or blocked User-Agents. Every detection adds a
+// strike; the client is then rejected for the base penalty doubled per strike, up to the
+// maximum penalty, and may be banned on an IPRateLimiter after enough strikes.
+type AbuseGuard struct {
+	minBodyRate     int64
+	bodyGrace       time.Duration
+	failureLimit    int
+	notFoundLimit   int
+	window          time.Duration
+	requireAgent    bool
+	blockedAgents   []string
+	basePenalty     time.Duration
+	maxPenalty      time.Duration
+	strikeDecay     time.Duration
+	banAfter        int
+	banDuration     time.Duration
+	limiter         *IPRateLimiter
+	sink            SecurityEventSink
+	logger          *log.Log
+	clients         map[string]*abuseClient
+	mu              sync.Mutex
+	stop            chan struct{}
+	stopOnce        sync.Once
+	cleanupInterval time.Duration
+}
+
+// AbuseOption configures an AbuseGuard.
+type AbuseOption func(*AbuseGuard)
+
+// WithMinBodyRate rejects request bodies arriving slower than bytesPerSecond once grace has
+// elapsed, which defeats slowloris style uploads. Defaults to 1KiB/s after 5 seconds; zero
+// disables the check.
+func WithMinBodyRate(bytesPerSecond int64, grace time.Duration) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.minBodyRate = bytesPerSecond
+		g.bodyGrace = grace
+	}
+}
+
+// WithFailureBursts sets how many 401/403 and 404 responses one IP may receive within window
+// before a strike. Defaults to 10 authentication failures and 30 not found responses per
+// minute; zero disables a check.
+func WithFailureBursts(authFailures, notFound int, window time.Duration) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.failureLimit = authFailures
+		g.notFoundLimit = notFound
+		g.window = window
+	}
+}
+
+// WithRequireUserAgent adds a strike to requests without a User-Agent. Enabled by default.
+func WithRequireUserAgent(require bool) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.requireAgent = require
+	}
+}
+
+// WithBlockedUserAgents replaces the User-Agent substrings, matched case-insensitively, that
+// are rejected outright. Defaults to common vulnerability scanners.
+func WithBlockedUserAgents(agents ...string) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.blockedAgents = make([]string, 0, len(agents))
+		for _, agent := range agents {
+			g.blockedAgents = append(g.blockedAgents, strings.ToLower(agent))
+		}
+	}
+}
+
+// WithPenalty sets the throttling applied after the first strike and its upper bound.
+// Strikes are forgotten after decay without new detections. Defaults to 30 seconds, 1 hour
+// and 24 hours.
+func WithPenalty(base, max, decay time.Duration) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.basePenalty = base
+		g.maxPenalty = max
+		g.strikeDecay = decay
+	}
+}
+
+// WithAbuseBan bans the client IP on limiter for d once it reaches strikes, so that the ban
+// is shared through the limiter's store.
+func WithAbuseBan(limiter *IPRateLimiter, strikes int, d time.Duration) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.limiter = limiter
+		g.banAfter = strikes
+		g.banDuration = d
+	}
+}
+
+// WithSecurityEventSink sets where security events are sent in addition to the log.
+func WithSecurityEventSink(sink SecurityEventSink) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.sink = sink
+	}
+}
+
+// WithAbuseLogger sets the logger security events are written to.
+func WithAbuseLogger(logger *log.Log) AbuseOption {
+	return func(g *AbuseGuard) {
+		g.logger = logger
+	}
+}
+
+// NewAbuseGuard creates an AbuseGuard. Call Stop on shutdown to end its cleanup goroutine.
+func NewAbuseGuard(opts ...AbuseOption) *AbuseGuard {
+	g := &AbuseGuard{
+		minBodyRate:     1024,
+		bodyGrace:       5 * time.Second,
+		failureLimit:    10,
+		notFoundLimit:   30,
+		window:          time.Minute,
+		requireAgent:    true,
+		blockedAgents:   []string{"sqlmap", "nikto", "nmap", "masscan", "zgrab", "dirbuster", "gobuster", "wpscan", "acunetix", "nuclei"},
+		basePenalty:     30 * time.Second,
+		maxPenalty:      time.Hour,
+		strikeDecay:     24 * time.Hour,
+		clients:         make(map[string]*abuseClient),
+		stop:            make(chan struct{}),
+		cleanupInterval: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.logger == nil {
+		g.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+
+	go g.cleanupClients()
+
+	return g
+}
+
+// Stop stops the cleanup goroutine.
+func (g *AbuseGuard) Stop() {
+	g.stopOnce.Do(func() {
+		close(g.stop)
+	})
+}
+
+// Middleware returns the Gin middleware handler. Throttled clients receive 429 Too Many
+// Requests with a Retry-After header, blocked User-Agents 403 Forbidden and slow bodies 408
+// Request Timeout once the handler reads them.
+func (g *AbuseGuard) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.Request.RemoteAddr
+		if idx := strings.LastIndex(ip, ":"); idx != -1 {
+			ip = ip[:idx]
+		}
+		now := time.Now()
+
+		if until, blocked := g.blocked(ip, now); blocked {
+			c.Header("Retry-After", fmt.Sprintf("%d", int(until.Sub(now).Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+
+		agent := c.Request.UserAgent()
+		if agent == "" && g.requireAgent {
+			g.strike(c, ip, SecurityEventMissingAgent, "")
+		} else if blockedAgent := g.matchAgent(agent); blockedAgent != "" {
+			g.strike(c, ip, SecurityEventBadAgent, blockedAgent)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+
+		var body *slowBodyReader
+		if g.minBodyRate > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body = &slowBodyReader{ReadCloser: c.Request.Body, start: now, grace: g.bodyGrace, minRate: g.minBodyRate}
+			c.Request.Body = body
+			// A client that stalls entirely never returns from Read, so bound the whole body.
+			if length := c.Request.ContentLength; length > 0 {
+				deadline := now.Add(g.bodyGrace + time.Duration(length/g.minBodyRate+1)*time.Second)
+				_ = http.NewResponseController(c.Writer).SetReadDeadline(deadline)
+			}
+		}
+
+		c.Next()
+
+		if body != nil && body.slow {
+			g.strike(c, ip, SecurityEventSlowBody, fmt.Sprintf("%d bytes in %s", body.read, time.Since(now).Round(time.Millisecond)))
+			if !c.Writer.Written() {
+				c.AbortWithStatusJSON(http.StatusRequestTimeout, gin.H{"error": "Request body timeout"})
+			}
+			return
+		}
+
+		switch status := c.Writer.Status(); {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			if g.recordFailure(ip, true) {
+				g.strike(c, ip, SecurityEventAuthBurst, fmt.Sprintf("more than %d in %s", g.failureLimit, g.window))
+			}
+		case status == http.StatusNotFound:
+			if g.recordFailure(ip, false) {
+				g.strike(c, ip, SecurityEventNotFoundScan, fmt.Sprintf("more than %d in %s", g.notFoundLimit, g.window))
+			}
+		}
+	}
+}
+
+// blocked reports whether ip is serving a penalty.
+func (g *AbuseGuard) blocked(ip string, now time.Time) (time.Time, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	client, ok := g.clients[ip]
+	if !ok {
+		return time.Time{}, false
+	}
+	client.lastSeen = now
+	return client.blockedUntil, now.Before(client.blockedUntil)
+}
+
+// matchAgent returns the blocked User-Agent pattern agent contains.
+func (g *AbuseGuard) matchAgent(agent string) string {
+	agent = strings.ToLower(agent)
+	for _, blocked := range g.blockedAgents {
+		if strings.Contains(agent, blocked) {
+			return blocked
+		}
+	}
+	return ""
+}
+
+// recordFailure records an authentication failure or not found response of ip and reports
+// whether it exceeds the burst limit, resetting the window when it does.
+func (g *AbuseGuard) recordFailure(ip string, auth bool) bool {
+	limit := g.notFoundLimit
+	if auth {
+		limit = g.failureLimit
+	}
+	if limit <= 0 {
+		return false
+	}
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	client := g.client(ip, now)
+	failures := &client.notFound
+	if auth {
+		failures = &client.authFailures
+	}
+	kept := (*failures)[:0]
+	for _, at := range *failures {
+		if now.Sub(at) <= g.window {
+			kept = append(kept, at)
+		}
+	}
+	*failures = append(kept, now)
+	if len(*failures) > limit {
+		*failures = nil
+		return true
+	}
+	return false
+}
+
+// strike adds a strike to ip, extends its penalty and emits the security event.
+func (g *AbuseGuard) strike(c *gin.Context, ip, eventType, detail string) {
+	now := time.Now()
+	g.mu.Lock()
+	client := g.client(ip, now)
+	if g.strikeDecay > 0 && !client.lastStrike.IsZero() && now.Sub(client.lastStrike) > g.strikeDecay {
+		client.strikes = 0
+	}
+	client.strikes++
+	client.lastStrike = now
+	penalty := g.basePenalty
+	for i := 1; i < client.strikes && penalty < g.maxPenalty; i++ {
+		penalty *= 2
+	}
+	if g.maxPenalty > 0 && penalty > g.maxPenalty {
+		penalty = g.maxPenalty
+	}
+	// Missing User-Agents alone only throttle from the second strike onward.
+	if eventType == SecurityEventMissingAgent && client.strikes == 1 {
+		penalty = 0
+	}
+	if until := now.Add(penalty); until.After(client.blockedUntil) {
+		client.blockedUntil = until
+	}
+	strikes := client.strikes
+	g.mu.Unlock()
+
+	event := SecurityEvent{
+		Type:      eventType,
+		IP:        ip,
+		UserID:    authenticatedUserID(c),
+		UserAgent: c.Request.UserAgent(),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Detail:    detail,
+		Strikes:   strikes,
+		Penalty:   penalty,
+		Time:      now,
+	}
+	g.emit(c.Request.Context(), event)
+
+	if g.limiter != nil && g.banAfter > 0 && strikes >= g.banAfter {
+		reason := fmt.Sprintf("abuse guard: %d strikes, last %s", strikes, eventType)
+		if err := g.limiter.Ban(c.Request.Context(), IPKey(ip), g.banDuration, reason); err != nil {
+			g.logger.Error(constant.SystemWarning, log.String("ip", ip), log.Err(err))
+			return
+		}
+		event.Type = SecurityEventBanned
+		event.Penalty = g.banDuration
+		g.emit(c.Request.Context(), event)
+	}
+}
+
+// emit writes event to the log and the sink.
+func (g *AbuseGuard) emit(ctx context.Context, event SecurityEvent) {
+	g.logger.Warn("security event",
+		log.String("type", event.Type),
+		log.String("ip", event.IP),
+		log.String("path", event.Path),
+		log.String("detail", event.Detail),
+		log.Any("strikes", event.Strikes),
+		log.Any("penalty", event.Penalty.String()),
+	)
+	if g.sink != nil {
+		g.sink(ctx, event)
+	}
+}
+
+// client returns the state of ip, creating it. The caller must hold g.mu.
+func (g *AbuseGuard) client(ip string, now time.Time) *abuseClient {
+	client, ok := g.clients[ip]
+	if !ok {
+		client = &abuseClient{}
+		g.clients[ip] = client
+	}
+	client.lastSeen = now
+	return client
+}
+
+// cleanupClients periodically forgets clients without an active penalty or recent strike.
+func (g *AbuseGuard) cleanupClients() {
+	ticker := time.NewTicker(g.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						helpers.Println(constant.ERROR, "exception: occurred in abuse guard cleanup", "stack:", string(debug.Stack()))
+					}
+				}()
+				now := time.Now()
+				g.mu.Lock()
+				defer g.mu.Unlock()
+				for ip, client := range g.clients {
+					idle := now.Sub(client.lastSeen) > g.window
+					decayed := client.strikes == 0 || now.Sub(client.lastStrike) > g.strikeDecay
+					if idle && decayed && now.After(client.blockedUntil) {
+						delete(g.clients, ip)
+					}
+				}
+			}()
+		}
+	}
+}
+
+// slowBodyReader fails reads once the body rate drops below minRate after the grace period.
+type slowBodyReader struct {
+	io.ReadCloser
+	start   time.Time
+	grace   time.Duration
+	minRate int64
+	read    int64
+	slow    bool
+}
+
+func (r *slowBodyReader) Read(p []byte) (int, error) {
+	if r.slow {
+		return 0, errSlowBody
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if elapsed := time.Since(r.start); elapsed > r.grace && err == nil {
+		if float64(r.read)/elapsed.Seconds() < float64(r.minRate) {
+			r.slow = true
+			return n, errSlowBody
+		}
+	}
+	return n, err
+}
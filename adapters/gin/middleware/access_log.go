@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/gin/request"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/structures/claims"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultAccessLogSlowThreshold is the latency from which requests are always logged.
+const DefaultAccessLogSlowThreshold = 2 * time.Second
+
+// AccessLogEntry is the structured record of a served request.
+type AccessLogEntry struct {
+	Time   time.Time `json:"@timestamp"`
+	Method string    `json:"method"`
+	// Route is the route template, e.g. /users/:id, or empty when no route matched.
+	Route         string   `json:"route"`
+	Path          string   `json:"path"`
+	Status        int      `json:"status"`
+	LatencyMS     float64  `json:"latency_ms"`
+	BytesIn       int64    `json:"bytes_in"`
+	BytesOut      int      `json:"bytes_out"`
+	ClientIP      string   `json:"client_ip"`
+	UserAgent     string   `json:"user_agent,omitempty"`
+	RequestID     string   `json:"request_id,omitempty"`
+	CorrelationID string   `json:"correlation_id,omitempty"`
+	UserID        string   `json:"user_id,omitempty"`
+	OrgID         string   `json:"org_id,omitempty"`
+	ActorID       string   `json:"actor_id,omitempty"`
+	Errors        []string `json:"errors,omitempty"`
+	// Sampled is false for entries logged regardless of the sample rate, i.e. failed and slow
+	// requests.
+	Sampled bool `json:"sampled"`
+}
+
+// AccessLogSink receives the access log entries.
+type AccessLogSink interface {
+	WriteAccessLog(entry *AccessLogEntry)
+}
+
+// AccessLogSinkFunc is a function implementing AccessLogSink.
+type AccessLogSinkFunc func(entry *AccessLogEntry)
+
+// WriteAccessLog implements AccessLogSink.
+func (f AccessLogSinkFunc) WriteAccessLog(entry *AccessLogEntry) {
+	f(entry)
+}
+
+// LoggerAccessLogSink writes the entries through logger, and so to the OpenSearch log index when
+// the logger ships there.
+func LoggerAccessLogSink(logger *log.Log) AccessLogSink {
+	return AccessLogSinkFunc(func(e *AccessLogEntry) {
+		fields := []types.Field{
+			log.String("method", e.Method),
+			log.String("route", e.Route),
+			log.String("path", e.Path),
+			log.Int("status_code", e.Status),
+			log.Float64("latency_ms", e.LatencyMS),
+			log.Int64("bytes_in", e.BytesIn),
+			log.Int("bytes_out", e.BytesOut),
+			log.String("client_ip", e.ClientIP),
+			log.String("user_agent", e.UserAgent),
+			log.String("request_id", e.RequestID),
+			log.String(constant.CorrelationIDHeader, e.CorrelationID),
+			log.String("user_id", e.UserID),
+			log.String("org_id", e.OrgID),
+			log.String("actor_id", e.ActorID),
+			log.Bool("sampled", e.Sampled),
+		}
+		if len(e.Errors) > 0 {
+			fields = append(fields, log.Any("errors", e.Errors))
+		}
+		switch {
+		case e.Status >= 500:
+			logger.Error("Access", fields...)
+		case e.Status >= 400:
+			logger.Warn("Access", fields...)
+		default:
+			logger.Info("Access", fields...)
+		}
+	})
+}
+
+// JSONAccessLogSink writes each entry as a JSON document to w, e.g. an
+// opensearch.OpenSearchWriter on a dedicated audit index.
+func JSONAccessLogSink(w io.Writer) AccessLogSink {
+	var mu sync.Mutex
+	return AccessLogSinkFunc(func(e *AccessLogEntry) {
+		doc, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(doc)
+	})
+}
+
+// accessLogConfig holds the options of AccessLog.
+type accessLogConfig struct {
+	sinks         []AccessLogSink
+	sampleRate    float64
+	slowThreshold time.Duration
+	skipPaths     []string
+	logger        *log.Log
+}
+
+// AccessLogOption configures AccessLog.
+type AccessLogOption func(*accessLogConfig)
+
+// WithAccessLogSink adds a sink. Without sinks the entries go to the logger.
+func WithAccessLogSink(sink AccessLogSink) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.sinks = append(c.sinks, sink)
+	}
+}
+
+// WithAccessLogSampleRate logs the given fraction, from 0 to 1, of the successful requests that
+// are not slow. Failed and slow requests are always logged. Defaults to 1.
+func WithAccessLogSampleRate(rate float64) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.sampleRate = min(max(rate, 0), 1)
+	}
+}
+
+// WithAccessLogSlowThreshold sets the latency from which requests are always logged. Defaults to
+// DefaultAccessLogSlowThreshold.
+func WithAccessLogSlowThreshold(threshold time.Duration) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.slowThreshold = threshold
+	}
+}
+
+// WithAccessLogSkipPaths never logs the successful requests to paths, e.g. health checks. A path
+// ending with "*" matches its prefix.
+func WithAccessLogSkipPaths(paths ...string) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.skipPaths = append(c.skipPaths, paths...)
+	}
+}
+
+// WithAccessLogLogger sets the logger of the default sink.
+func WithAccessLogLogger(logger *log.Log) AccessLogOption {
+	return func(c *accessLogConfig) {
+		c.logger = logger
+	}
+}
+
+// AccessLog returns a middleware recording an AccessLogEntry per request: latency, status, sizes,
+// the route template and the user and organisation of the caller, read from the verified token
+// stored by the auth middlewares. Client supplied identity headers are ignored.
+func AccessLog(options ...AccessLogOption) gin.HandlerFunc {
+	cfg := &accessLogConfig{
+		sampleRate:    1,
+		slowThreshold: DefaultAccessLogSlowThreshold,
+	}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	if len(cfg.sinks) == 0 {
+		if cfg.logger == nil {
+			cfg.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+		}
+		cfg.sinks = []AccessLogSink{LoggerAccessLogSink(cfg.logger)}
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		status := c.Writer.Status()
+		always := status >= 400 || latency >= cfg.slowThreshold
+		if !always {
+			if cfg.skipped(c.Request.URL.Path) {
+				return
+			}
+			if cfg.sampleRate < 1 && rand.Float64() >= cfg.sampleRate { // #nosec G404 -- sampling
+				return
+			}
+		}
+
+		entry := &AccessLogEntry{
+			Time:          start.UTC(),
+			Method:        c.Request.Method,
+			Route:         c.FullPath(),
+			Path:          c.Request.URL.Path,
+			Status:        status,
+			LatencyMS:     float64(latency.Microseconds()) / 1000,
+			BytesIn:       max(c.Request.ContentLength, 0),
+			BytesOut:      max(c.Writer.Size(), 0),
+			ClientIP:      c.ClientIP(),
+			UserAgent:     c.Request.UserAgent(),
+			RequestID:     c.GetString(constant.RequestID),
+			CorrelationID: c.GetString(constant.CorrelationID),
+			UserID:        authenticatedUserID(c),
+			OrgID:         accessLogOrgID(c),
+			ActorID:       c.GetString(constant.ActorID),
+			Sampled:       !always,
+		}
+		for _, err := range c.Errors {
+			entry.Errors = append(entry.Errors, err.Error())
+		}
+		for _, sink := range cfg.sinks {
+			sink.WriteAccessLog(entry)
+		}
+	}
+}
+
+// skipped reports whether the successful requests to path are not logged.
+func (c *accessLogConfig) skipped(path string) bool {
+	return slices.ContainsFunc(c.skipPaths, func(skip string) bool {
+		if prefix, ok := strings.CutSuffix(skip, "*"); ok {
+			return strings.HasPrefix(path, prefix)
+		}
+		return path == skip
+	})
+}
+
+// authenticatedUserID returns the authenticated user of the request, or "".
+func authenticatedUserID(c *gin.Context) string {
+	if userID, err := request.RetrieveUserIdFromContext(c).Value(); err == nil {
+		return userID.String()
+	}
+	return ""
+}
+
+// accessLogOrgID returns the org_id claim of the verified token of the request, or "".
+func accessLogOrgID(c *gin.Context) string {
+	if claim, ok := c.Value(constant.Claims).(*claims.StandardClaims); ok {
+		return claim.OrgID()
+	}
+	return ""
+}
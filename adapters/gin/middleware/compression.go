@@ -0,0 +1,361 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported response encodings
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+// DefaultCompressionMinSize is the smallest response body worth compressing
+const DefaultCompressionMinSize = 1024
+
+// defaultIncompressibleTypes are media types that are already compressed
+var defaultIncompressibleTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"font/woff2",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"application/x-brotli",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/pdf",
+	"application/octet-stream",
+	"text/event-stream",
+}
+
+// CompressionOption configures CompressionMiddleware
+type CompressionOption func(*compressionConfig)
+
+type compressionConfig struct {
+	encodings     []string
+	minSize       int
+	types         []string
+	excludedTypes []string
+	excludedPaths []string
+}
+
+// WithCompressionEncodings sets the supported encodings in order of server preference.
+// Defaults to br, zstd and gzip
+func WithCompressionEncodings(encodings ...string) CompressionOption {
+	return func(c *compressionConfig) {
+		c.encodings = encodings
+	}
+}
+
+// WithCompressionMinSize sets the smallest body that is compressed
+func WithCompressionMinSize(size int) CompressionOption {
+	return func(c *compressionConfig) {
+		c.minSize = size
+	}
+}
+
+// WithCompressionTypes restricts compression to the given media types. Entries ending in "/"
+// match a whole family, e.g. "text/"
+func WithCompressionTypes(types ...string) CompressionOption {
+	return func(c *compressionConfig) {
+		c.types = types
+	}
+}
+
+// WithCompressionExcludedTypes adds media types that are never compressed
+func WithCompressionExcludedTypes(types ...string) CompressionOption {
+	return func(c *compressionConfig) {
+		c.excludedTypes = append(c.excludedTypes, types...)
+	}
+}
+
+// WithCompressionExcludedPaths disables compression for request paths with these prefixes
+func WithCompressionExcludedPaths(paths ...string) CompressionOption {
+	return func(c *compressionConfig) {
+		c.excludedPaths = append(c.excludedPaths, paths...)
+	}
+}
+
+// CompressionMiddleware compresses responses with the best encoding both sides support,
+// negotiated from Accept-Encoding. Bodies smaller than the minimum size, responses that are
+// already encoded and already compressed media types such as images and archives are sent as is
+func CompressionMiddleware(options ...CompressionOption) gin.HandlerFunc {
+	config := &compressionConfig{
+		encodings:     []string{EncodingBrotli, EncodingZstd, EncodingGzip},
+		minSize:       DefaultCompressionMinSize,
+		excludedTypes: slices.Clone(defaultIncompressibleTypes),
+	}
+	for _, opt := range options {
+		opt(config)
+	}
+	pools := map[string]*sync.Pool{
+		EncodingBrotli: {New: func() any { return brotli.NewWriterLevel(io.Discard, 4) }},
+		EncodingZstd: {New: func() any {
+			encoder, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+			return encoder
+		}},
+		EncodingGzip: {New: func() any {
+			writer, _ := gzip.NewWriterLevel(io.Discard, gzip.BestSpeed)
+			return writer
+		}},
+	}
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if c.Request.Method == http.MethodHead || strings.Contains(c.GetHeader("Connection"), "Upgrade") {
+			c.Next()
+			return
+		}
+		for _, prefix := range config.excludedPaths {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), config.encodings)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{
+			ResponseWriter: c.Writer,
+			config:         config,
+			encoding:       encoding,
+			pool:           pools[encoding],
+			status:         c.Writer.Status(),
+		}
+		c.Writer = writer
+		defer func() {
+			writer.close()
+			// Writes made by gin after the chain, e.g. default 404 bodies, bypass compression.
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks the supported encoding with the highest q-value, using the server
+// preference order to break ties
+func negotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	weights := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+		weights[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supported {
+		q, ok := weights[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// encoder is implemented by the gzip, brotli and zstd writers
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressWriter buffers the start of the body until it can decide whether to compress
+type compressWriter struct {
+	gin.ResponseWriter
+	config   *compressionConfig
+	encoding string
+	pool     *sync.Pool
+
+	status        int
+	headerWritten bool
+	decided       bool
+	buffer        []byte
+	encoder       encoder
+	pooled        any
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.headerWritten {
+		return
+	}
+	w.status = code
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide()
+	}
+}
+
+func (w *compressWriter) Status() int {
+	if !w.headerWritten {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.headerWritten || len(w.buffer) > 0
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		return w.write(data)
+	}
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) >= w.config.minSize {
+		w.decide()
+		if err := w.flushBuffer(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+		_ = w.flushBuffer()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	w.headerWritten = true
+	return w.ResponseWriter.Hijack()
+}
+
+// decide chooses whether to compress from the status, headers and buffered body, then writes
+// the header
+func (w *compressWriter) decide() {
+	w.decided = true
+	if w.shouldCompress() {
+		w.pooled = w.pool.Get()
+		w.encoder = w.pooled.(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+	w.headerWritten = true
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) shouldCompress() bool {
+	header := w.Header()
+	if len(w.buffer) < w.config.minSize || header.Get("Content-Encoding") != "" ||
+		w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buffer)
+		header.Set("Content-Type", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if mediaType == "image/svg+xml" {
+		return len(w.config.types) == 0 || matchesMediaType(mediaType, w.config.types)
+	}
+	if matchesMediaType(mediaType, w.config.excludedTypes) {
+		return false
+	}
+	return len(w.config.types) == 0 || matchesMediaType(mediaType, w.config.types)
+}
+
+func (w *compressWriter) flushBuffer() error {
+	if len(w.buffer) == 0 {
+		return nil
+	}
+	_, err := w.write(w.buffer)
+	w.buffer = nil
+	return err
+}
+
+func (w *compressWriter) write(data []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// close writes any buffered body and finishes the compressed stream
+func (w *compressWriter) close() {
+	if !w.decided {
+		if len(w.buffer) == 0 {
+			// Nothing was written; pass the status on and leave the response to gin.
+			w.ResponseWriter.WriteHeader(w.status)
+			return
+		}
+		w.decide()
+		_ = w.flushBuffer()
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		w.encoder.Reset(io.Discard)
+		w.pool.Put(w.pooled)
+		w.encoder = nil
+	}
+}
+
+func matchesMediaType(mediaType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if mediaType == pattern || (strings.HasSuffix(pattern, "/") && strings.HasPrefix(mediaType, pattern)) {
+			return true
+		}
+	}
+	return false
+}
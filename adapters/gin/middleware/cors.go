@@ -1,56 +1,172 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 )
 
-// CORSMiddleware returns a gin.HandlerFunc that handles CORS requests
+// CORSConfig configures NewCORSMiddleware.
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the service: exact origins such as
+	// "https://app.example.com", subdomain wildcards such as "https://*.example.com", or "*".
+	// An empty list allows any origin unless Strict is set.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the preflight responses.
+	MaxAge time.Duration
+	// Strict refuses "*" and an empty AllowedOrigins, so only listed origins are allowed.
+	Strict bool
+}
+
+// DefaultCORSConfig returns the configuration of CORSMiddleware without any setting: every
+// standard method, the neuron headers, credentials allowed, and strict in production.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods:   getAllowedMethodsList(),
+		AllowedHeaders:   getAllowedHeadersList(),
+		ExposedHeaders:   []string{"Set-Cookie"},
+		AllowCredentials: true,
+		Strict:           helpers.IsProdEnvironment(),
+	}
+}
+
+// CORSConfigFromViper returns DefaultCORSConfig overridden by the Cors* settings, e.g. the
+// CorsAllowedOrigins setting or environment variable.
+func CORSConfigFromViper() CORSConfig {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = viper.GetStringSlice(constant.CorsAllowedOriginsKey)
+	if viper.IsSet(constant.CorsAllowedMethodsKey) {
+		cfg.AllowedMethods = viper.GetStringSlice(constant.CorsAllowedMethodsKey)
+	}
+	if viper.IsSet(constant.CorsAllowedHeadersKey) {
+		cfg.AllowedHeaders = viper.GetStringSlice(constant.CorsAllowedHeadersKey)
+	}
+	if viper.IsSet(constant.CorsExposedHeadersKey) {
+		cfg.ExposedHeaders = viper.GetStringSlice(constant.CorsExposedHeadersKey)
+	}
+	if viper.IsSet(constant.CorsCredentialsKey) {
+		cfg.AllowCredentials = viper.GetBool(constant.CorsCredentialsKey)
+	}
+	if viper.IsSet(constant.CorsMaxAgeKey) {
+		cfg.MaxAge = viper.GetDuration(constant.CorsMaxAgeKey)
+	}
+	if viper.IsSet(constant.CorsStrictKey) {
+		cfg.Strict = viper.GetBool(constant.CorsStrictKey)
+	}
+	return cfg
+}
+
+// Validate checks the origins of the configuration, refusing "*" and an empty allowlist in
+// strict mode.
+func (cfg CORSConfig) Validate() error {
+	if cfg.Strict && len(cfg.AllowedOrigins) == 0 {
+		return errors.New("cors: strict mode requires an explicit list of allowed origins")
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		switch {
+		case origin == "*" && cfg.Strict:
+			return errors.New(`cors: strict mode refuses the "*" origin`)
+		case origin == "*":
+		case strings.Count(origin, "*") > 1 || (strings.Contains(origin, "*") && !strings.Contains(origin, "://*.")):
+			return fmt.Errorf("cors: invalid origin pattern %q, wildcards must be a leading subdomain such as https://*.example.com", origin)
+		}
+	}
+	return nil
+}
+
+// NewCORSMiddleware returns a middleware answering the CORS preflight requests of the allowed
+// origins and adding the CORS headers to their responses. Preflight requests of other origins
+// are rejected with 403.
+func NewCORSMiddleware(cfg CORSConfig) (gin.HandlerFunc, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return corsHandler(cfg), nil
+}
+
+// CORSMiddleware returns a gin.HandlerFunc that handles CORS requests with the configuration
+// of CORSConfigFromViper, allowing additionalHeaders as well. An invalid configuration is
+// logged; in strict mode "*" then allows no origin.
 func CORSMiddleware(additionalHeaders ...string) gin.HandlerFunc {
+	cfg := CORSConfigFromViper()
+	cfg.AllowedHeaders = append(cfg.AllowedHeaders, additionalHeaders...)
+	if err := cfg.Validate(); err != nil {
+		log.NewBasicLogger(helpers.IsProdEnvironment(), true).Error("Invalid CORS configuration", log.Err(err))
+	}
+	return corsHandler(cfg)
+}
+
+// corsHandler returns the CORS middleware of cfg.
+func corsHandler(cfg CORSConfig) gin.HandlerFunc {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
 	return func(c *gin.Context) {
 		r := c.Request
-		w := c.Writer
+		h := c.Writer.Header()
 
 		// Set security headers
-		setSecurityHeaders(w)
+		setSecurityHeaders(c.Writer)
+
+		origin := getAllowedOrigin(r, cfg)
+		if origin != "" {
+			h.Set("Access-Control-Allow-Origin", origin)
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+			h.Add("Vary", "Origin")
+		}
 
-		// Check for preflight request
 		if isCORSPreflightRequest(r) {
-			if handlePreflightRequest(r, w, additionalHeaders...) {
-				c.JSON(http.StatusOK, gin.H{"message": "OK"})
+			if origin == "" || !isMethodAllowed(r.Header.Get("Access-Control-Request-Method"), cfg.AllowedMethods) {
+				c.AbortWithStatus(http.StatusForbidden)
 				return
 			}
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			if maxAge != "" {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatusJSON(http.StatusOK, gin.H{"message": "OK"})
+			return
 		}
 
-		// Handle regular CORS request (pass request so we can echo origin when appropriate)
-		handleRegularCORSRequest(r, w, additionalHeaders...)
+		if origin != "" && exposed != "" {
+			h.Set("Access-Control-Expose-Headers", exposed)
+		}
 		c.Next()
 		// === Re-assert essential CORS headers on the final response ===
 		// This prevents a downstream handler or proxy from accidentally removing them.
-		origin := getAllowedOrigin(r)
 		if origin != "" {
-			h := w.Header()
 			if h.Get("Access-Control-Allow-Origin") == "" {
 				h.Set("Access-Control-Allow-Origin", origin)
 			}
-			if h.Get("Access-Control-Allow-Credentials") == "" {
+			if cfg.AllowCredentials && h.Get("Access-Control-Allow-Credentials") == "" {
 				h.Set("Access-Control-Allow-Credentials", "true")
 			}
-			if h.Get("Access-Control-Expose-Headers") == "" {
-				h.Set("Access-Control-Expose-Headers", "Set-Cookie")
-			}
-			// also ensure Allow-Headers/Methods present on final response if absent
-			if h.Get("Access-Control-Allow-Headers") == "" {
-				h.Set("Access-Control-Allow-Headers", getAllowedHeaders(additionalHeaders...))
+			if exposed != "" && h.Get("Access-Control-Expose-Headers") == "" {
+				h.Set("Access-Control-Expose-Headers", exposed)
 			}
-			if h.Get("Access-Control-Allow-Methods") == "" {
-				h.Set("Access-Control-Allow-Methods", getAllowedMethods())
-			}
-
 			if !strings.Contains(h.Get("Vary"), "Origin") {
 				h.Add("Vary", "Origin")
 			}
@@ -76,56 +192,11 @@ func isCORSPreflightRequest(r *http.Request) bool {
 		r.Header.Get("Access-Control-Request-Method") != ""
 }
 
-// handlePreflightRequest handles preflight requests
-func handlePreflightRequest(r *http.Request, w http.ResponseWriter, additionalHeaders ...string) bool {
-	// Handle preflight request and allow methods
-	method := r.Header.Get("Access-Control-Request-Method")
-	if ok := isMethodAllowed(method); ok {
-		origin := getAllowedOrigin(r)
-		// If origin is not allowed, return false so caller can respond with default (403/empty)
-		if origin == "" {
-			return false
-		}
-
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", getAllowedMethods())
-		w.Header().Set("Access-Control-Allow-Headers", getAllowedHeaders(additionalHeaders...))
-		// Always allow credentials for allowed origins (caller intends to set credentials).
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Add("Vary", "Origin")
-		return true
-	}
-	return false
-}
-
-// handleRegularCORSRequest handles regular CORS requests
-func handleRegularCORSRequest(r *http.Request, w http.ResponseWriter, additionalHeaders ...string) {
-	origin := getAllowedOrigin(r)
-	if origin == "" {
-		return
-	}
-
-	// Required on actual request
-	w.Header().Set("Access-Control-Allow-Origin", origin)
-	w.Header().Set("Access-Control-Allow-Credentials", "true")
-	w.Header().Add("Vary", "Origin")
-
-	// Additional recommended headers
-	w.Header().Set("Access-Control-Allow-Headers", getAllowedHeaders(additionalHeaders...))
-	w.Header().Set("Access-Control-Allow-Methods", getAllowedMethods())
-	w.Header().Set("Access-Control-Expose-Headers", "Set-Cookie")
-}
-
 // isMethodAllowed returns true if the method is allowed
-func isMethodAllowed(method string) bool {
-	// Check if the requested method is allowed
-	allowedMethods := getAllowedMethodsList()
-	for _, m := range allowedMethods {
-		if strings.EqualFold(m, method) {
-			return true
-		}
-	}
-	return false
+func isMethodAllowed(method string, allowed []string) bool {
+	return slices.ContainsFunc(allowed, func(m string) bool {
+		return strings.EqualFold(m, method)
+	})
 }
 
 // getAllowedMethodsList returns a list of allowed HTTP methods for CORS
@@ -144,17 +215,9 @@ func getAllowedMethodsList() []string {
 	}
 }
 
-// getAllowedMethods returns a comma-separated string of allowed HTTP methods for CORS
-func getAllowedMethods() string {
-	// Return allowed methods as a string
-	return strings.Join(getAllowedMethodsList(), ", ")
-}
-
-// getAllowedHeaders returns a comma-separated string of allowed headers for CORS,
-// appending any optional additional headers provided.
-func getAllowedHeaders(additionalHeaders ...string) string {
-	// Define the base list of allowed headers
-	headers := []string{
+// getAllowedHeadersList returns the list of headers allowed by default for CORS.
+func getAllowedHeadersList() []string {
+	return []string{
 		"Content-Type",
 		"Content-Length",
 		"Accept-Encoding",
@@ -177,30 +240,15 @@ func getAllowedHeaders(additionalHeaders ...string) string {
 		"X-RateLimit-Limit",
 		"Retry-After",
 	}
-
-	// If additional headers are provided, append them to the list
-	if len(additionalHeaders) > 0 {
-		headers = append(headers, additionalHeaders...)
-	}
-
-	// Return allowed headers as a string (joined by comma)
-	return strings.Join(headers, ", ")
 }
 
 // getAllowedOrigin returns the allowed origin for CORS with wildcard support.
 // IMPORTANT:
 //   - If allowedOrigins contains "*" and a request Origin header is present, this function will echo the request Origin.
 //     This avoids returning Access-Control-Allow-Origin: * which is incompatible with Allow-Credentials: true.
+//   - In strict mode "*" and an empty allowlist allow no origin.
 //   - If the request Origin is not present or not allowed, returns an empty string.
-func getAllowedOrigin(r *http.Request) string {
-	allowedOrigins := viper.GetStringSlice(constant.CorsAllowedOriginsKey)
-
-	// If no request available, return a safe default:
-	if r == nil {
-		// Without a request, we cannot safely echo an origin.
-		return ""
-	}
-
+func getAllowedOrigin(r *http.Request, cfg CORSConfig) string {
 	origin := r.Header.Get("Origin")
 	if origin == "" {
 		// No Origin header on request — nothing to do
@@ -208,39 +256,45 @@ func getAllowedOrigin(r *http.Request) string {
 	}
 
 	// If allowedOrigins empty -> act permissive but echo origin (to support credentials)
-	if len(allowedOrigins) == 0 {
-		return origin
-	}
-
-	// If wildcard present in allowedOrigins, echo the origin (don't return "*")
-	for _, ao := range allowedOrigins {
-		if ao == "*" {
-			return origin
-		}
-	}
-
-	// Try exact matches
-	for _, allowedOrigin := range allowedOrigins {
-		if allowedOrigin == origin {
-			return origin
+	if len(cfg.AllowedOrigins) == 0 {
+		if cfg.Strict {
+			return ""
 		}
+		return origin
 	}
 
-	// Try wildcard patterns like https://*.example.com
-	for _, allowedOrigin := range allowedOrigins {
-		if strings.Contains(allowedOrigin, "*") {
-			parts := strings.SplitN(allowedOrigin, "*", 2)
-			prefix := parts[0]
-			suffix := ""
-			if len(parts) > 1 {
-				suffix = parts[1]
-			}
-			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+	for _, allowedOrigin := range cfg.AllowedOrigins {
+		switch {
+		case allowedOrigin == "*":
+			if !cfg.Strict {
 				return origin
 			}
+		case allowedOrigin == origin:
+			return origin
+		case matchWildcardOrigin(allowedOrigin, origin):
+			return origin
 		}
 	}
 
 	// Not allowed
 	return ""
 }
+
+// matchWildcardOrigin reports whether origin is a subdomain of the wildcard pattern, e.g.
+// "https://*.example.com" matches "https://app.example.com" and "https://a.b.example.com"
+// but neither "https://example.com" nor "http://app.example.com".
+func matchWildcardOrigin(pattern, origin string) bool {
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != scheme || u.Path != "" {
+		return false
+	}
+	domain, port, _ := strings.Cut(host, ":")
+	if u.Port() != port {
+		return false
+	}
+	return strings.HasSuffix(u.Hostname(), "."+domain)
+}
@@ -1,24 +1,31 @@
 package middleware
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
 	"slices"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/cookie"
+	"github.com/abhissng/neuron/utils/random"
 	"github.com/gin-gonic/gin"
 )
 
 // CSRFToken represents a cross-site request forgery token
 type CSRFToken struct {
-	Value     string
-	CreatedAt time.Time
-	ExpiresAt time.Time
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // CSRFManager centrally manages CSRF tokens across requests
@@ -31,15 +38,43 @@ type CSRFManager struct {
 	sameSite       http.SameSite
 	tokenLifetime  time.Duration
 	excludedRoutes []string
+	cookies        *cookie.Manager
 
-	// Map of session ID to token
-	tokens     map[string]*CSRFToken
-	tokenMutex sync.RWMutex
+	// store keeps the token of each session, unless doubleSubmit is set
+	store        CSRFTokenStore
+	doubleSubmit bool
+}
+
+// CSRFOption configures a CSRFManager
+type CSRFOption func(*CSRFManager)
+
+// WithCSRFCookieManager encrypts the session id and CSRF token cookies with manager
+func WithCSRFCookieManager(manager *cookie.Manager) CSRFOption {
+	return func(m *CSRFManager) {
+		m.cookies = manager
+	}
+}
+
+// WithCSRFTokenStore stores the tokens in store, e.g. a RedisCSRFTokenStore shared by the
+// replicas. Defaults to a MemoryCSRFTokenStore.
+func WithCSRFTokenStore(store CSRFTokenStore) CSRFOption {
+	return func(m *CSRFManager) {
+		m.store = store
+	}
+}
+
+// WithCSRFDoubleSubmit makes the manager stateless: tokens are signed with the secret key and
+// bound to the session, and a request is valid when its header repeats the token of its cookie.
+// No token is stored, so GetToken always returns nil.
+func WithCSRFDoubleSubmit() CSRFOption {
+	return func(m *CSRFManager) {
+		m.doubleSubmit = true
+	}
 }
 
 // NewCSRFManager creates a new CSRF manager
-func NewCSRFManager(secretKey string, excludedRoutes []string) *CSRFManager {
-	return &CSRFManager{
+func NewCSRFManager(secretKey string, excludedRoutes []string, opts ...CSRFOption) *CSRFManager {
+	m := &CSRFManager{
 		secretKey:      []byte(secretKey),
 		cookieName:     constant.CSRFTokenCookie,
 		headerName:     constant.CSRFTokenHeader,
@@ -48,8 +83,14 @@ func NewCSRFManager(secretKey string, excludedRoutes []string) *CSRFManager {
 		sameSite:       http.SameSiteStrictMode,
 		tokenLifetime:  24 * time.Hour,
 		excludedRoutes: excludedRoutes,
-		tokens:         make(map[string]*CSRFToken),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.store == nil && !m.doubleSubmit {
+		m.store = NewMemoryCSRFTokenStore(DefaultCSRFSweepInterval)
+	}
+	return m
 }
 
 // CreateCSRFConfig initializes the CSRF configuration settings.
@@ -59,46 +100,50 @@ func CreateCSRFConfig(secretKey string, excludedRoutes []string) *CSRFManager {
 
 // CreateToken generates and stores a new token for the given session
 func (m *CSRFManager) CreateToken(sessionID string) (*CSRFToken, error) {
-	// Generate a unique token
-	tokenData := fmt.Sprintf("%s:%d:%s", sessionID, time.Now().UnixNano(), m.secretKey)
-	hasher := sha256.New()
-	hasher.Write([]byte(tokenData))
-	tokenValue := base64.URLEncoding.EncodeToString(hasher.Sum(nil))
+	return m.createToken(context.Background(), sessionID)
+}
 
-	// Create token with expiration
+func (m *CSRFManager) createToken(ctx context.Context, sessionID string) (*CSRFToken, error) {
 	now := time.Now()
 	token := &CSRFToken{
-		Value:     tokenValue,
 		CreatedAt: now,
 		ExpiresAt: now.Add(m.tokenLifetime),
 	}
+	if m.doubleSubmit {
+		nonce := make([]byte, 32)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		payload := base64.RawURLEncoding.EncodeToString(nonce) + "." + strconv.FormatInt(token.ExpiresAt.Unix(), 10)
+		token.Value = payload + "." + m.sign(sessionID, payload)
+		return token, nil
+	}
 
-	// Store token
-	m.tokenMutex.Lock()
-	m.tokens[sessionID] = token
-	m.tokenMutex.Unlock()
+	// Generate a unique token
+	tokenData := fmt.Sprintf("%s:%d:%s", sessionID, now.UnixNano(), m.secretKey)
+	hasher := sha256.New()
+	hasher.Write([]byte(tokenData))
+	token.Value = base64.URLEncoding.EncodeToString(hasher.Sum(nil))
 
+	if err := m.store.Save(ctx, sessionID, token); err != nil {
+		return nil, err
+	}
 	return token, nil
 }
 
 // GetToken retrieves a token for the given session
 func (m *CSRFManager) GetToken(sessionID string) *CSRFToken {
-	m.tokenMutex.RLock()
-	token, exists := m.tokens[sessionID]
-	m.tokenMutex.RUnlock()
+	return m.getToken(context.Background(), sessionID)
+}
 
-	if !exists {
+func (m *CSRFManager) getToken(ctx context.Context, sessionID string) *CSRFToken {
+	if m.doubleSubmit {
 		return nil
 	}
-
-	// Check if token is expired
-	if time.Now().After(token.ExpiresAt) {
-		m.tokenMutex.Lock()
-		delete(m.tokens, sessionID)
-		m.tokenMutex.Unlock()
+	token, err := m.store.Load(ctx, sessionID)
+	if err != nil {
 		return nil
 	}
-
 	return token
 }
 
@@ -109,11 +154,47 @@ func (m *CSRFManager) ValidateToken(sessionID, tokenValue string) bool {
 		return false
 	}
 
-	return token.Value == tokenValue
+	return subtle.ConstantTimeCompare([]byte(token.Value), []byte(tokenValue)) == 1
+}
+
+// sign returns the signature binding the payload of a double-submit token to the session.
+func (m *CSRFManager) sign(sessionID, payload string) string {
+	mac := hmac.New(sha256.New, m.secretKey)
+	mac.Write([]byte(sessionID + "|" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cookieToken returns the double-submit token of the cookie of r when it is signed for the
+// session and not expired.
+func (m *CSRFManager) cookieToken(r *http.Request, sessionID string) *CSRFToken {
+	var value string
+	if m.cookies != nil {
+		value, _ = m.cookies.GetString(r, m.cookieName)
+	} else if c, err := r.Cookie(m.cookieName); err == nil {
+		value = c.Value
+	}
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(m.sign(sessionID, payload))) {
+		return nil
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return nil
+	}
+	expiresAt := time.Unix(expiry, 0)
+	return &CSRFToken{Value: value, CreatedAt: expiresAt.Add(-m.tokenLifetime), ExpiresAt: expiresAt}
 }
 
 // SetCSRFCookie sets the CSRF token cookie
 func (m *CSRFManager) SetCSRFCookie(w http.ResponseWriter, token *CSRFToken) {
+	if m.cookies != nil {
+		_ = m.cookies.SetStringWithMaxAge(w, m.cookieName, token.Value, time.Until(token.ExpiresAt))
+		return
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:     m.cookieName,
 		Value:    token.Value,
@@ -128,15 +209,24 @@ func (m *CSRFManager) SetCSRFCookie(w http.ResponseWriter, token *CSRFToken) {
 // GetOrCreateSessionID gets the existing session ID or creates a new one
 func (m *CSRFManager) GetOrCreateSessionID(r *http.Request, w http.ResponseWriter) (string, error) {
 	// Try to get existing session ID
-	sessionCookie, err := r.Cookie(constant.SessionID)
-	if err == nil && sessionCookie.Value != "" {
+	if m.cookies != nil {
+		if sessionID, err := m.cookies.GetString(r, constant.SessionID); err == nil && sessionID != "" {
+			return sessionID, nil
+		}
+	} else if sessionCookie, err := r.Cookie(constant.SessionID); err == nil && sessionCookie.Value != "" {
 		return sessionCookie.Value, nil
 	}
 
 	// Generate a new session ID
-	sessionID := fmt.Sprintf("%d", time.Now().UnixNano())
+	sessionID := random.GenerateSecureUUIDString()
 
 	// Set the session cookie
+	if m.cookies != nil {
+		if err := m.cookies.SetStringWithMaxAge(w, constant.SessionID, sessionID, m.tokenLifetime); err != nil {
+			return "", err
+		}
+		return sessionID, nil
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:     constant.SessionID,
 		Value:    sessionID,
@@ -160,7 +250,7 @@ func (m *CSRFManager) HandleCSRF(w http.ResponseWriter, r *http.Request) (*CSRFT
 
 	// For root path requests, always generate a new token
 	if r.URL.Path == "/" {
-		token, err := m.CreateToken(sessionID)
+		token, err := m.createToken(r.Context(), sessionID)
 		if err != nil {
 			return nil, errors.New("failed to generate CSRF token")
 		}
@@ -178,7 +268,12 @@ func (m *CSRFManager) HandleCSRF(w http.ResponseWriter, r *http.Request) (*CSRFT
 	}
 
 	// For other paths, get the token but don't validate for GET requests
-	token := m.GetToken(sessionID)
+	var token *CSRFToken
+	if m.doubleSubmit {
+		token = m.cookieToken(r, sessionID)
+	} else {
+		token = m.getToken(r.Context(), sessionID)
+	}
 	if token == nil {
 		// No token exists yet - this means the client hasn't visited "/" first
 		return nil, errors.New("CSRF token not found - visit root path first")
@@ -191,7 +286,7 @@ func (m *CSRFManager) HandleCSRF(w http.ResponseWriter, r *http.Request) (*CSRFT
 			return nil, errors.New("CSRF token header missing")
 		}
 
-		if !m.ValidateToken(sessionID, headerToken) {
+		if subtle.ConstantTimeCompare([]byte(token.Value), []byte(headerToken)) != 1 {
 			return nil, errors.New("CSRF token invalid")
 		}
 	}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultCSRFSweepInterval is how often MemoryCSRFTokenStore drops its expired tokens.
+const DefaultCSRFSweepInterval = 10 * time.Minute
+
+// CSRFTokenStore stores the CSRF token of each session, shared by the replicas of a service.
+type CSRFTokenStore interface {
+	// Save stores token for sessionID until it expires.
+	Save(ctx context.Context, sessionID string, token *CSRFToken) error
+	// Load returns the token of sessionID, or nil when there is none or it expired.
+	Load(ctx context.Context, sessionID string) (*CSRFToken, error)
+	// Delete removes the token of sessionID, if any.
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// RedisCSRFTokenStore is a CSRFTokenStore backed by Redis, expiring the tokens with their keys.
+type RedisCSRFTokenStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCSRFTokenStore creates a RedisCSRFTokenStore storing its keys under prefix.
+// Defaults to "csrf:".
+func NewRedisCSRFTokenStore(client *redis.Client, prefix string) *RedisCSRFTokenStore {
+	if prefix == "" {
+		prefix = "csrf:"
+	}
+	return &RedisCSRFTokenStore{client: client, prefix: prefix}
+}
+
+// Save stores token with a TTL matching its expiry.
+func (s *RedisCSRFTokenStore) Save(ctx context.Context, sessionID string, token *CSRFToken) error {
+	ttl := time.Until(token.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+sessionID, data, ttl).Err()
+}
+
+// Load returns the token of sessionID.
+func (s *RedisCSRFTokenStore) Load(ctx context.Context, sessionID string) (*CSRFToken, error) {
+	data, err := s.client.Get(ctx, s.prefix+sessionID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var token CSRFToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// Delete deletes the token of sessionID.
+func (s *RedisCSRFTokenStore) Delete(ctx context.Context, sessionID string) error {
+	return s.client.Del(ctx, s.prefix+sessionID).Err()
+}
+
+// MemoryCSRFTokenStore is a CSRFTokenStore for a single replica. Expired tokens are dropped
+// when read and swept on writes, at most once per sweep interval.
+type MemoryCSRFTokenStore struct {
+	mu            sync.Mutex
+	tokens        map[string]*CSRFToken
+	sweepInterval time.Duration
+	lastSweep     time.Time
+}
+
+// NewMemoryCSRFTokenStore creates a MemoryCSRFTokenStore sweeping its expired tokens every
+// sweepInterval. Defaults to DefaultCSRFSweepInterval.
+func NewMemoryCSRFTokenStore(sweepInterval time.Duration) *MemoryCSRFTokenStore {
+	if sweepInterval <= 0 {
+		sweepInterval = DefaultCSRFSweepInterval
+	}
+	return &MemoryCSRFTokenStore{
+		tokens:        make(map[string]*CSRFToken),
+		sweepInterval: sweepInterval,
+		lastSweep:     time.Now(),
+	}
+}
+
+// Save stores token, sweeping the expired tokens when due.
+func (s *MemoryCSRFTokenStore) Save(_ context.Context, sessionID string, token *CSRFToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= s.sweepInterval {
+		for id, stored := range s.tokens {
+			if now.After(stored.ExpiresAt) {
+				delete(s.tokens, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	stored := *token
+	s.tokens[sessionID] = &stored
+	return nil
+}
+
+// Load returns the token of sessionID, dropping it when expired.
+func (s *MemoryCSRFTokenStore) Load(_ context.Context, sessionID string) (*CSRFToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.tokens[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		delete(s.tokens, sessionID)
+		return nil, nil
+	}
+	token := *stored
+	return &token, nil
+}
+
+// Delete removes the token of sessionID.
+func (s *MemoryCSRFTokenStore) Delete(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, sessionID)
+	return nil
+}
+
+// Len returns the number of tokens stored, expired ones included until swept.
+func (s *MemoryCSRFTokenStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tokens)
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/geoip"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/gin-gonic/gin"
+)
+
+// GeoIPMiddleware resolves the client IP with the given GeoIPManager and stores the
+// result in the gin context under constant.GeoInfo and constant.CountryCode.
+// Lookup failures are logged and never block the request.
+func GeoIPMiddleware(manager *geoip.GeoIPManager, logger *log.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if manager == nil {
+			c.Next()
+			return
+		}
+
+		info, err := manager.Lookup(c.ClientIP())
+		if err != nil {
+			if logger != nil {
+				logger.Debug("geoip lookup failed", log.String("client_ip", c.ClientIP()), log.Err(err))
+			}
+			c.Next()
+			return
+		}
+
+		c.Set(constant.GeoInfo, info)
+		c.Set(constant.CountryCode, info.CountryCode)
+		c.Next()
+	}
+}
+
+// GeoFenceMiddleware rejects requests by country code. When allowed is non-empty only those
+// countries pass; any country in denied is always rejected. Requests whose country could not
+// be resolved pass unless allowUnknown is false. It must run after GeoIPMiddleware.
+func GeoFenceMiddleware(allowed, denied []string, allowUnknown bool) gin.HandlerFunc {
+	allowSet := toCountrySet(allowed)
+	denySet := toCountrySet(denied)
+
+	return func(c *gin.Context) {
+		countryCode := GetCountryCode(c)
+		if countryCode == "" {
+			if !allowUnknown {
+				c.AbortWithStatusJSON(http.StatusUnavailableForLegalReasons, gin.H{"error": "Request origin could not be verified"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if _, blocked := denySet[countryCode]; blocked {
+			c.AbortWithStatusJSON(http.StatusUnavailableForLegalReasons, gin.H{"error": "Service is not available in your region"})
+			return
+		}
+
+		if len(allowSet) > 0 {
+			if _, ok := allowSet[countryCode]; !ok {
+				c.AbortWithStatusJSON(http.StatusUnavailableForLegalReasons, gin.H{"error": "Service is not available in your region"})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// GetCountryCode returns the country code stored by GeoIPMiddleware, or an empty string.
+// It can be used as a key for geo-based rate limits or compliance rules.
+func GetCountryCode(c *gin.Context) string {
+	return c.GetString(constant.CountryCode)
+}
+
+// GetGeoInfo returns the GeoInfo stored by GeoIPMiddleware.
+func GetGeoInfo(c *gin.Context) (*geoip.GeoInfo, bool) {
+	value, exists := c.Get(constant.GeoInfo)
+	if !exists {
+		return nil, false
+	}
+	info, ok := value.(*geoip.GeoInfo)
+	return info, ok
+}
+
+// toCountrySet normalises country codes into a lookup set.
+func toCountrySet(codes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code != "" {
+			set[code] = struct{}{}
+		}
+	}
+	return set
+}
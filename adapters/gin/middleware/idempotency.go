@@ -0,0 +1,328 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// Defaults of the Idempotency options.
+const (
+	DefaultIdempotencyTTL     = 24 * time.Hour
+	DefaultIdempotencyLockTTL = time.Minute
+	maxIdempotencyKeyLength   = 255
+)
+
+// StoredResponse is the response of a request, replayed for the retries carrying the same
+// Idempotency-Key. Pending marks a request still being processed.
+type StoredResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Pending     bool        `json:"pending,omitempty"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// IdempotencyStore stores the responses of the Idempotency middleware, shared by the replicas
+// of a service.
+type IdempotencyStore interface {
+	// Reserve marks key as pending for ttl when it is unknown and returns nil. Otherwise it
+	// returns the response stored for key, which may still be pending.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*StoredResponse, error)
+	// Save stores the response of key for ttl.
+	Save(ctx context.Context, key string, resp StoredResponse, ttl time.Duration) error
+	// Release forgets key, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// RedisIdempotencyStore is an IdempotencyStore backed by Redis.
+type RedisIdempotencyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisIdempotencyStore creates a RedisIdempotencyStore storing its keys under prefix.
+// Defaults to "idempotency:".
+func NewRedisIdempotencyStore(client *redis.Client, prefix string) *RedisIdempotencyStore {
+	if prefix == "" {
+		prefix = "idempotency:"
+	}
+	return &RedisIdempotencyStore{client: client, prefix: prefix}
+}
+
+// Reserve sets the pending marker of key unless it exists.
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*StoredResponse, error) {
+	pending, err := json.Marshal(StoredResponse{Fingerprint: fingerprint, Pending: true, CreatedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.client.SetNX(ctx, s.prefix+key, pending, ttl).Result()
+	if err != nil || ok {
+		return nil, err
+	}
+
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Expired in between, try again.
+		return s.Reserve(ctx, key, fingerprint, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored StoredResponse
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Save replaces the pending marker of key with resp.
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, resp StoredResponse, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, data, ttl).Err()
+}
+
+// Release deletes key.
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore for a single replica, e.g. in tests.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryIdempotencyEntry
+}
+
+type memoryIdempotencyEntry struct {
+	resp      StoredResponse
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore creates a MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]memoryIdempotencyEntry)}
+}
+
+// Reserve marks key as pending unless it is stored.
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key, fingerprint string, ttl time.Duration) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		resp := entry.resp
+		return &resp, nil
+	}
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memoryIdempotencyEntry{
+		resp:      StoredResponse{Fingerprint: fingerprint, Pending: true, CreatedAt: now},
+		expiresAt: now.Add(ttl),
+	}
+	return nil, nil
+}
+
+// Save stores resp for key.
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, resp StoredResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryIdempotencyEntry{resp: resp, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release forgets key.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// idempotencyOptions holds the options of Idempotency.
+type idempotencyOptions struct {
+	ttl      time.Duration
+	lockTTL  time.Duration
+	methods  []string
+	required bool
+	scope    func(*gin.Context) string
+	logger   *log.Log
+}
+
+// IdempotencyOption configures Idempotency.
+type IdempotencyOption func(*idempotencyOptions)
+
+// WithIdempotencyTTL sets how long responses are replayed. Defaults to DefaultIdempotencyTTL.
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithIdempotencyLockTTL bounds how long a request is considered in flight, so a replica dying
+// mid-request does not block its retries forever. Defaults to DefaultIdempotencyLockTTL.
+func WithIdempotencyLockTTL(ttl time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.lockTTL = ttl
+	}
+}
+
+// WithIdempotencyMethods sets the methods honouring the header. Defaults to POST and PATCH.
+func WithIdempotencyMethods(methods ...string) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.methods = methods
+	}
+}
+
+// WithIdempotencyRequired rejects the requests without an Idempotency-Key with 400.
+func WithIdempotencyRequired() IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.required = true
+	}
+}
+
+// WithIdempotencyScope sets the scope of the keys, so that callers cannot replay each other's
+// responses. Defaults to the X-Org-Id and X-User-Id headers.
+func WithIdempotencyScope(scope func(*gin.Context) string) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.scope = scope
+	}
+}
+
+// WithIdempotencyLogger sets the logger used to report store failures.
+func WithIdempotencyLogger(logger *log.Log) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.logger = logger
+	}
+}
+
+// Idempotency returns a middleware making retries of the requests carrying an Idempotency-Key
+// header safe. The first request is processed and its response stored in store; retries get
+// the stored response back with the Idempotent-Replayed header, retries arriving while it is
+// in flight get 409, and reusing a key for a different request gets 422. Server errors are
+// not stored, so they can be retried. Store failures let requests through.
+func Idempotency(store IdempotencyStore, opts ...IdempotencyOption) gin.HandlerFunc {
+	options := idempotencyOptions{
+		ttl:     DefaultIdempotencyTTL,
+		lockTTL: DefaultIdempotencyLockTTL,
+		methods: []string{http.MethodPost, http.MethodPatch},
+		scope:   defaultIdempotencyScope,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.logger == nil {
+		options.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+
+	return func(c *gin.Context) {
+		if !slices.Contains(options.methods, c.Request.Method) {
+			c.Next()
+			return
+		}
+		key := c.GetHeader(constant.IdempotencyKey)
+		switch {
+		case key == "" && options.required:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key header is required"})
+			return
+		case key == "":
+			c.Next()
+			return
+		case len(key) > maxIdempotencyKeyLength:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key header is too long"})
+			return
+		}
+
+		body, err := helpers.ReadBodySafe(c.Request)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.RequestURI(), body)
+		storeKey := options.scope(c) + ":" + key
+
+		stored, err := store.Reserve(c.Request.Context(), storeKey, fingerprint, options.lockTTL)
+		if err != nil {
+			options.logger.Error("Idempotency store failed, processing request", log.String("key", key), log.Err(err))
+			c.Next()
+			return
+		}
+		if stored != nil {
+			replayResponse(c, stored, fingerprint)
+			return
+		}
+
+		writer := &responseWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+
+		// Stores are updated even when the client went away, it will retry.
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(ctx, storeKey); err != nil {
+				options.logger.Error("Failed to release idempotency key", log.String("key", key), log.Err(err))
+			}
+			return
+		}
+		resp := StoredResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			Header:      writer.Header().Clone(),
+			Body:        writer.body.Bytes(),
+			CreatedAt:   time.Now(),
+		}
+		if err := store.Save(ctx, storeKey, resp, options.ttl); err != nil {
+			options.logger.Error("Failed to store idempotent response", log.String("key", key), log.Err(err))
+		}
+	}
+}
+
+// replayResponse answers c with the stored response of its key.
+func replayResponse(c *gin.Context, stored *StoredResponse, fingerprint string) {
+	switch {
+	case stored.Fingerprint != fingerprint:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case stored.Pending:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already in progress"})
+	default:
+		for name, values := range stored.Header {
+			c.Writer.Header()[name] = values
+		}
+		c.Header(constant.IdempotentReplayed, "true")
+		c.Status(stored.Status)
+		_, _ = c.Writer.Write(stored.Body)
+		c.Abort()
+	}
+}
+
+// requestFingerprint identifies a request by its method, URI and body.
+func requestFingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + uri + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// defaultIdempotencyScope scopes the keys by organisation and user.
+func defaultIdempotencyScope(c *gin.Context) string {
+	return c.GetHeader(constant.XOrgId) + ":" + c.GetHeader(constant.XUserId)
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationAuditMiddleware logs an audit entry for every request made with an
+// impersonation token, once it is handled: the user and actor, the actor chain of delegated
+// tokens, the route and the response status. The identities are set by
+// PasetoVerifyMiddleware, so the middleware may be registered before it.
+func ImpersonationAuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		ctx, err := GetServiceContext(c)
+		if err != nil || !ctx.IsImpersonated() {
+			return
+		}
+		fields := []types.Field{
+			log.String("method", c.Request.Method),
+			log.String("route", c.FullPath()),
+			log.String("path", c.Request.URL.Path),
+			log.Int("status", c.Writer.Status()),
+			log.Duration("latency", time.Since(start)),
+		}
+		if claim, ok := ctx.GetClaims(); ok {
+			fields = append(fields, log.Any("actor_chain", claim.ActorChain()))
+		}
+		ctx.SlogInfo(constant.ImpersonatedCall, fields...)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/gin-gonic/gin"
@@ -121,6 +123,23 @@ Always call StopCleanup() when shutting down to prevent goroutine leaks:
 When rate limit is exceeded, clients receive:
   - HTTP Status: 429 Too Many Requests
   - Response Body: {"error": "Too many requests"}
+
+# Bans and Warm Restart
+
+IPs and authenticated users can be banned for a duration. User bans only apply when the
+limiter runs after the authentication middleware; before it, requests are checked against the
+bans of their IP. Banned clients receive 403 Forbidden with a Retry-After header. With a LimiterStore the bans are shared by all
+replicas and token buckets can be saved on shutdown and restored on startup:
+
+	store := middleware.NewRedisLimiterStore(redisClient, "api:limiter:")
+	limiter := middleware.NewIPRateLimiter(rate.Limit(10), 100, 5*time.Minute,
+		middleware.WithLimiterStore(store),
+	)
+	_ = limiter.RestoreState(ctx)
+	defer limiter.SaveState(context.Background())
+
+	_ = limiter.Ban(ctx, middleware.IPKey("203.0.113.7"), time.Hour, "credential stuffing")
+	_ = limiter.Unban(ctx, middleware.UserKey(userID))
 */
 
 // clientLimiter holds the limiter and the last seen time for a client
@@ -138,20 +157,69 @@ type IPRateLimiter struct {
 	ttl      time.Duration // Time-to-live for inactive client entries
 	stop     chan struct{}
 	stopOnce sync.Once
+
+	store       LimiterStore // Optional store sharing bans and token buckets across replicas
+	logger      *log.Log     // Logger used to record bans and store failures
+	bans        map[string]*banEntry
+	banCacheTTL time.Duration // How long a ban lookup from the store is cached
+	clock       clock.Clock   // Clock token buckets, bans and inactivity are measured with
+}
+
+// IPRateLimiterOption configures an IPRateLimiter.
+type IPRateLimiterOption func(*IPRateLimiter)
+
+// WithLimiterStore persists bans and token buckets in store, e.g. a RedisLimiterStore, so that
+// they apply across replicas and survive restarts.
+func WithLimiterStore(store LimiterStore) IPRateLimiterOption {
+	return func(l *IPRateLimiter) {
+		l.store = store
+	}
+}
+
+// WithLimiterLogger sets the logger used to record bans.
+func WithLimiterLogger(logger *log.Log) IPRateLimiterOption {
+	return func(l *IPRateLimiter) {
+		l.logger = logger
+	}
+}
+
+// WithBanCacheTTL sets how long ban lookups from the store are cached locally. Defaults to
+// 5 seconds; a ban made on another replica takes at most this long to apply.
+func WithBanCacheTTL(ttl time.Duration) IPRateLimiterOption {
+	return func(l *IPRateLimiter) {
+		l.banCacheTTL = ttl
+	}
+}
+
+// WithLimiterClock sets the clock token buckets, bans and client inactivity are measured
+// with. Defaults to clock.System.
+func WithLimiterClock(c clock.Clock) IPRateLimiterOption {
+	return func(l *IPRateLimiter) {
+		l.clock = clock.OrSystem(c)
+	}
 }
 
 // NewIPRateLimiter creates a new rate limiter manager.
 // r: The number of events allowed per second.
 // b: The burst size (how many requests can be made in a short burst).
 // ttl: How long to keep an IP's limiter in memory after its last request.
-func NewIPRateLimiter(r rate.Limit, b int, ttl time.Duration) *IPRateLimiter {
+func NewIPRateLimiter(r rate.Limit, b int, ttl time.Duration, opts ...IPRateLimiterOption) *IPRateLimiter {
 	limiter := &IPRateLimiter{
-		clients: make(map[string]*clientLimiter),
-		mu:      &sync.Mutex{},
-		rate:    r,
-		burst:   b,
-		ttl:     ttl,
-		stop:    make(chan struct{}),
+		clients:     make(map[string]*clientLimiter),
+		mu:          &sync.Mutex{},
+		rate:        r,
+		burst:       b,
+		ttl:         ttl,
+		stop:        make(chan struct{}),
+		bans:        make(map[string]*banEntry),
+		banCacheTTL: 5 * time.Second,
+		clock:       clock.System,
+	}
+	for _, opt := range opts {
+		opt(limiter)
+	}
+	if limiter.logger == nil {
+		limiter.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
 	}
 
 	// Start a background goroutine to clean up old entries
@@ -175,7 +243,7 @@ func (l *IPRateLimiter) getLimiter(ip string) *rate.Limiter {
 	}
 
 	// Update the last seen time
-	client.lastSeen = time.Now()
+	client.lastSeen = l.clock.Now()
 	return client.limiter
 }
 
@@ -186,14 +254,14 @@ func (l *IPRateLimiter) cleanupClients() {
 	if interval < time.Minute {
 		interval = time.Minute
 	}
-	ticker := time.NewTicker(interval)
+	ticker := l.clock.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-l.stop:
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
@@ -203,7 +271,7 @@ func (l *IPRateLimiter) cleanupClients() {
 				}()
 				// Collect IPs to delete without holding lock for entire iteration
 				var toDelete []string
-				now := time.Now()
+				now := l.clock.Now()
 				l.mu.Lock()
 				for ip, client := range l.clients {
 					if now.Sub(client.lastSeen) > l.ttl {
@@ -213,6 +281,11 @@ func (l *IPRateLimiter) cleanupClients() {
 				for _, ip := range toDelete {
 					delete(l.clients, ip)
 				}
+				for key, entry := range l.bans {
+					if !entry.active(now) && now.Sub(entry.checkedAt) > l.banCacheTTL {
+						delete(l.bans, key)
+					}
+				}
 				l.mu.Unlock()
 			}()
 		}
@@ -236,10 +309,18 @@ func (l *IPRateLimiter) Middleware() gin.HandlerFunc {
 		if idx := strings.LastIndex(ip, ":"); idx != -1 {
 			ip = ip[:idx]
 		}
+
+		if ban, banned := l.bannedRequest(c, ip); banned {
+			retryAfter := int(l.clock.Until(ban.Until).Seconds()) + 1
+			c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access temporarily blocked"})
+			return
+		}
+
 		limiter := l.getLimiter(ip)
 
 		// Check if the request is allowed
-		if !limiter.Allow() {
+		if !limiter.AllowN(l.clock.Now(), 1) {
 			// Calculate retry-after based on rate limit
 			retryAfter := int(time.Second / time.Duration(l.rate))
 			c.Header("Retry-After", fmt.Sprintf("%d", retryAfter))
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// banEntry caches the ban state of a key; ban is nil when the key is not banned.
+type banEntry struct {
+	ban       *Ban
+	checkedAt time.Time
+}
+
+func (e *banEntry) active(now time.Time) bool {
+	return e.ban != nil && e.ban.Active(now)
+}
+
+// Ban blocks key, built with IPKey or UserKey, for d. The reason is logged and kept with the
+// ban. With a LimiterStore the ban applies to every replica sharing the store.
+func (l *IPRateLimiter) Ban(ctx context.Context, key string, d time.Duration, reason string) error {
+	if key == "" || d <= 0 {
+		return errors.New("ban requires a key and a positive duration")
+	}
+	now := l.clock.Now()
+	ban := Ban{Key: key, Reason: reason, CreatedAt: now, Until: now.Add(d)}
+	if l.store != nil {
+		if err := l.store.SaveBan(ctx, ban); err != nil {
+			return err
+		}
+	}
+	l.mu.Lock()
+	l.bans[key] = &banEntry{ban: &ban, checkedAt: now}
+	l.mu.Unlock()
+
+	l.logger.Warn("rate limiter ban added",
+		log.String("key", key),
+		log.String("reason", reason),
+		log.Any("until", ban.Until),
+	)
+	return nil
+}
+
+// Unban lifts the ban of key.
+func (l *IPRateLimiter) Unban(ctx context.Context, key string) error {
+	if l.store != nil {
+		if err := l.store.DeleteBan(ctx, key); err != nil {
+			return err
+		}
+	}
+	l.mu.Lock()
+	l.bans[key] = &banEntry{checkedAt: l.clock.Now()}
+	l.mu.Unlock()
+
+	l.logger.Info("rate limiter ban lifted", log.String("key", key))
+	return nil
+}
+
+// Banned returns the active ban of key. Lookups in the store are cached for the ban cache TTL,
+// and the last known state is used when the store cannot be reached.
+func (l *IPRateLimiter) Banned(ctx context.Context, key string) (Ban, bool) {
+	now := l.clock.Now()
+	l.mu.Lock()
+	entry, ok := l.bans[key]
+	if ok && (l.store == nil || now.Sub(entry.checkedAt) < l.banCacheTTL) {
+		l.mu.Unlock()
+		if entry.active(now) {
+			return *entry.ban, true
+		}
+		return Ban{}, false
+	}
+	l.mu.Unlock()
+	if l.store == nil {
+		return Ban{}, false
+	}
+
+	ban, err := l.store.LoadBan(ctx, key)
+	if err != nil {
+		l.logger.Error(constant.SystemWarning, log.String("key", key), log.Err(err))
+		if ok && entry.active(now) {
+			return *entry.ban, true
+		}
+		return Ban{}, false
+	}
+	l.mu.Lock()
+	l.bans[key] = &banEntry{ban: ban, checkedAt: now}
+	l.mu.Unlock()
+	if ban == nil {
+		return Ban{}, false
+	}
+	return *ban, true
+}
+
+// Bans returns the active bans, soonest expiry first.
+func (l *IPRateLimiter) Bans(ctx context.Context) ([]Ban, error) {
+	var bans []Ban
+	now := l.clock.Now()
+	if l.store != nil {
+		stored, err := l.store.ListBans(ctx)
+		if err != nil {
+			return nil, err
+		}
+		bans = stored
+	} else {
+		l.mu.Lock()
+		for _, entry := range l.bans {
+			if entry.active(now) {
+				bans = append(bans, *entry.ban)
+			}
+		}
+		l.mu.Unlock()
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Until.Before(bans[j].Until)
+	})
+	return bans, nil
+}
+
+// bannedRequest checks the bans of the client IP and, once authenticated, of the user sending
+// the request. The user comes from the verified token, never from request headers.
+func (l *IPRateLimiter) bannedRequest(c *gin.Context, ip string) (Ban, bool) {
+	ctx := c.Request.Context()
+	if ban, ok := l.Banned(ctx, IPKey(ip)); ok {
+		return ban, true
+	}
+	if userID := authenticatedUserID(c); userID != "" {
+		return l.Banned(ctx, UserKey(userID))
+	}
+	return Ban{}, false
+}
+
+// SaveState stores the remaining tokens of every active client so that a restarted instance
+// does not hand out fresh bursts. It requires a LimiterStore.
+func (l *IPRateLimiter) SaveState(ctx context.Context) error {
+	if l.store == nil {
+		return errors.New("ip rate limiter has no store")
+	}
+	now := l.clock.Now()
+	l.mu.Lock()
+	tokens := make(map[string]float64, len(l.clients))
+	for ip, client := range l.clients {
+		tokens[ip] = client.limiter.TokensAt(now)
+	}
+	l.mu.Unlock()
+	return l.store.SaveTokens(ctx, tokens, l.ttl)
+}
+
+// RestoreState loads the token buckets saved with SaveState.
+func (l *IPRateLimiter) RestoreState(ctx context.Context) error {
+	if l.store == nil {
+		return errors.New("ip rate limiter has no store")
+	}
+	tokens, err := l.store.LoadTokens(ctx)
+	if err != nil {
+		return err
+	}
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ip, remaining := range tokens {
+		limiter := rate.NewLimiter(l.rate, l.burst)
+		if used := l.burst - int(remaining); used > 0 {
+			limiter.AllowN(now, used)
+		}
+		l.clients[ip] = &clientLimiter{limiter: limiter, lastSeen: now}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Ban is a temporary block of an IP address or user on an IPRateLimiter.
+type Ban struct {
+	Key       string    `json:"key"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	Until     time.Time `json:"until"`
+}
+
+// Active reports whether the ban is still in force at now.
+func (b Ban) Active(now time.Time) bool {
+	return now.Before(b.Until)
+}
+
+// IPKey returns the ban key of an IP address.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// UserKey returns the ban key of an authenticated user id.
+func UserKey(userID string) string {
+	return "user:" + userID
+}
+
+// LimiterStore persists the state of an IPRateLimiter so that bans apply across replicas and
+// token buckets survive a restart.
+type LimiterStore interface {
+	// SaveBan stores ban until it expires.
+	SaveBan(ctx context.Context, ban Ban) error
+	// DeleteBan removes the ban of key, if any.
+	DeleteBan(ctx context.Context, key string) error
+	// LoadBan returns the active ban of key, or nil when key is not banned.
+	LoadBan(ctx context.Context, key string) (*Ban, error)
+	// ListBans returns every active ban.
+	ListBans(ctx context.Context) ([]Ban, error)
+	// SaveTokens stores the remaining tokens of each client for ttl.
+	SaveTokens(ctx context.Context, tokens map[string]float64, ttl time.Duration) error
+	// LoadTokens returns the tokens stored with SaveTokens.
+	LoadTokens(ctx context.Context) (map[string]float64, error)
+}
+
+// RedisLimiterStore is a LimiterStore backed by Redis. Bans are stored as keys expiring with
+// the ban, and token buckets as a single hash.
+type RedisLimiterStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLimiterStore creates a RedisLimiterStore storing its keys under prefix, e.g.
+// "ip_limiter:". Replicas sharing bans must use the same prefix.
+func NewRedisLimiterStore(client *redis.Client, prefix string) *RedisLimiterStore {
+	if prefix == "" {
+		prefix = "ip_limiter:"
+	}
+	return &RedisLimiterStore{client: client, prefix: prefix}
+}
+
+func (s *RedisLimiterStore) banKey(key string) string {
+	return s.prefix + "ban:" + key
+}
+
+// SaveBan stores ban with a TTL matching its expiry.
+func (s *RedisLimiterStore) SaveBan(ctx context.Context, ban Ban) error {
+	ttl := time.Until(ban.Until)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.banKey(ban.Key), data, ttl).Err()
+}
+
+// DeleteBan removes the ban of key.
+func (s *RedisLimiterStore) DeleteBan(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.banKey(key)).Err()
+}
+
+// LoadBan returns the ban of key, or nil when there is none.
+func (s *RedisLimiterStore) LoadBan(ctx context.Context, key string) (*Ban, error) {
+	data, err := s.client.Get(ctx, s.banKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ban Ban
+	if err := json.Unmarshal(data, &ban); err != nil {
+		return nil, err
+	}
+	if !ban.Active(time.Now()) {
+		return nil, nil
+	}
+	return &ban, nil
+}
+
+// ListBans scans the ban keys and returns the active bans.
+func (s *RedisLimiterStore) ListBans(ctx context.Context) ([]Ban, error) {
+	var bans []Ban
+	now := time.Now()
+	iter := s.client.Scan(ctx, 0, s.banKey("*"), 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := s.client.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var ban Ban
+		if err := json.Unmarshal(data, &ban); err != nil {
+			continue
+		}
+		if ban.Active(now) {
+			bans = append(bans, ban)
+		}
+	}
+	return bans, iter.Err()
+}
+
+// SaveTokens replaces the stored token buckets.
+func (s *RedisLimiterStore) SaveTokens(ctx context.Context, tokens map[string]float64, ttl time.Duration) error {
+	key := s.prefix + "tokens"
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, key)
+	if len(tokens) > 0 {
+		values := make(map[string]any, len(tokens))
+		for client, n := range tokens {
+			values[client] = strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		pipe.HSet(ctx, key, values)
+		if ttl > 0 {
+			pipe.Expire(ctx, key, ttl)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// LoadTokens returns the stored token buckets.
+func (s *RedisLimiterStore) LoadTokens(ctx context.Context) (map[string]float64, error) {
+	values, err := s.client.HGetAll(ctx, s.prefix+"tokens").Result()
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[string]float64, len(values))
+	for client, value := range values {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		tokens[client] = n
+	}
+	return tokens, nil
+}
@@ -0,0 +1,342 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/gin/response"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// ErrHandlerTimeout is returned by the writes of handlers still running after their timeout.
+var ErrHandlerTimeout = errors.New("middleware: handler timed out")
+
+// LimitsConfig configures Limits. Route keys are either a route template such as
+// "/users/:id" or a method and a route template such as "POST /uploads", the latter taking
+// precedence; they are matched case-insensitively since viper lower-cases map keys.
+type LimitsConfig struct {
+	// Timeout bounds every request; zero disables it.
+	Timeout       time.Duration
+	RouteTimeouts map[string]time.Duration
+	// MaxBodySize bounds every request body in bytes; zero disables it.
+	MaxBodySize       int64
+	RouteMaxBodySizes map[string]int64
+}
+
+// LimitsConfigFromViper reads the RequestTimeout, RouteTimeouts, MaxRequestBodySize and
+// RouteMaxRequestBodySizes settings. Sizes are byte counts or carry a KB, MB or GB suffix:
+//
+//	RequestTimeout: 10s
+//	RouteTimeouts:
+//	  "POST /reports": 2m
+//	MaxRequestBodySize: 1MB
+//	RouteMaxRequestBodySizes:
+//	  /uploads: 100MB
+func LimitsConfigFromViper() (LimitsConfig, error) {
+	cfg := LimitsConfig{
+		Timeout:           viper.GetDuration(constant.RequestTimeoutKey),
+		RouteTimeouts:     make(map[string]time.Duration),
+		RouteMaxBodySizes: make(map[string]int64),
+	}
+	if raw := viper.GetString(constant.MaxBodySizeKey); raw != "" {
+		size, err := parseByteSize(raw)
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", constant.MaxBodySizeKey, err)
+		}
+		cfg.MaxBodySize = size
+	}
+	for route, raw := range viper.GetStringMapString(constant.RouteTimeoutsKey) {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil {
+			return cfg, fmt.Errorf("%s %q: %w", constant.RouteTimeoutsKey, route, err)
+		}
+		cfg.RouteTimeouts[route] = timeout
+	}
+	for route, raw := range viper.GetStringMapString(constant.RouteMaxBodySizesKey) {
+		size, err := parseByteSize(raw)
+		if err != nil {
+			return cfg, fmt.Errorf("%s %q: %w", constant.RouteMaxBodySizesKey, route, err)
+		}
+		cfg.RouteMaxBodySizes[route] = size
+	}
+	return cfg, nil
+}
+
+// Limits returns a middleware applying the timeout and body size limit of the matched route,
+// as Timeout and MaxBodySize do.
+func Limits(cfg LimitsConfig) gin.HandlerFunc {
+	timeouts := lowerKeys(cfg.RouteTimeouts)
+	sizes := lowerKeys(cfg.RouteMaxBodySizes)
+	return func(c *gin.Context) {
+		if limit := routeValue(c, sizes, cfg.MaxBodySize); limit > 0 {
+			if !limitBody(c, limit) {
+				return
+			}
+		}
+		if timeout := routeValue(c, timeouts, cfg.Timeout); timeout > 0 {
+			serveWithTimeout(c, timeout)
+			return
+		}
+		c.Next()
+	}
+}
+
+// MaxBodySize returns a middleware rejecting with 413 the requests whose declared body exceeds
+// limit bytes and capping the reads of the others, so request.ExtractDataFromRequestBody fails
+// with blame.RequestBodyTooLargeError when the body turns out larger.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limitBody(c, limit) {
+			c.Next()
+		}
+	}
+}
+
+// Timeout returns a middleware bounding the request context by timeout. When it expires before
+// the handler writes its response, the client gets 408 with blame.RequestTimeoutError and the
+// later writes of the handler are dropped; handlers should stop on ctx.Done().
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serveWithTimeout(c, timeout)
+	}
+}
+
+// limitBody applies the body size limit to c, aborting it and returning false when the
+// declared body is already too large.
+func limitBody(c *gin.Context, limit int64) bool {
+	if c.Request.ContentLength > limit {
+		response.Error(c, blame.RequestBodyTooLargeError(limit))
+		return false
+	}
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	}
+	return true
+}
+
+// serveWithTimeout runs the rest of the chain with the request context bounded by timeout.
+func serveWithTimeout(c *gin.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	writer := &timeoutWriter{
+		ResponseWriter: c.Writer,
+		header:         c.Writer.Header().Clone(),
+		body:           timeoutBody(c, timeout),
+	}
+	c.Writer = writer
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			writer.timeout()
+		}
+	})
+	defer stop()
+
+	c.Next()
+	c.Writer = writer.ResponseWriter
+	if writer.finish(ctx) {
+		c.Abort()
+	}
+}
+
+// timeoutBody encodes the error envelope written on timeout. It is built beforehand since the
+// handler may still be using the gin context when the timeout fires.
+func timeoutBody(c *gin.Context, timeout time.Duration) []byte {
+	errorResponse := blame.RequestTimeoutError(timeout.String()).FetchErrorResponse()
+	body, _ := json.Marshal(response.Envelope[any]{
+		Success:       false,
+		Error:         &errorResponse,
+		RequestID:     types.RequestID(c.GetString(constant.RequestID)),
+		CorrelationID: types.CorrelationID(c.GetString(constant.CorrelationID)),
+	})
+	return body
+}
+
+// timeoutWriter lets a handler write its response until the timeout, then writes the timeout
+// response and drops the writes of the handler. The handler gets its own header map so setting
+// headers does not race with the timeout response.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	mu          sync.Mutex
+	header      http.Header
+	body        []byte
+	wroteHeader bool
+	expired     bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the status, like the gin writer, until the first write.
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired || w.wroteHeader {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired || w.wroteHeader {
+		return
+	}
+	w.writeHeaderLocked()
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired {
+		return 0, ErrHandlerTimeout
+	}
+	if !w.wroteHeader {
+		w.writeHeaderLocked()
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ResponseWriter.Status()
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ResponseWriter.Size()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.wroteHeader || w.expired
+}
+
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired {
+		return
+	}
+	if !w.wroteHeader {
+		w.writeHeaderLocked()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("middleware: cannot hijack a connection with a timeout")
+}
+
+// writeHeaderLocked copies the headers of the handler and writes the recorded status.
+func (w *timeoutWriter) writeHeaderLocked() {
+	dst := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		dst[key] = values
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// timeout writes the timeout response unless the handler has started its own.
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wroteHeader || w.expired {
+		return
+	}
+	w.timeoutLocked()
+}
+
+// timeoutLocked writes the timeout response.
+func (w *timeoutWriter) timeoutLocked() {
+	w.expired = true
+	w.ResponseWriter.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.ResponseWriter.WriteHeader(http.StatusRequestTimeout)
+	_, _ = w.ResponseWriter.Write(w.body)
+	w.ResponseWriter.Flush()
+}
+
+// finish is called when the handler returns. It writes the timeout response if the handler
+// returned on the deadline before the timeout callback ran, and otherwise copies the headers of
+// a handler that wrote no response so they are sent with the status written by gin. It reports
+// whether the timeout response was written.
+func (w *timeoutWriter) finish(ctx context.Context) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.expired && !w.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		w.timeoutLocked()
+	}
+	if !w.expired && !w.wroteHeader {
+		dst := w.ResponseWriter.Header()
+		for key, values := range w.header {
+			dst[key] = values
+		}
+		w.wroteHeader = true
+	}
+	return w.expired
+}
+
+// routeValue returns the value of the route of c, or fallback.
+func routeValue[T any](c *gin.Context, values map[string]T, fallback T) T {
+	route := strings.ToLower(c.FullPath())
+	if value, ok := values[strings.ToLower(c.Request.Method)+" "+route]; ok {
+		return value
+	}
+	if value, ok := values[route]; ok {
+		return value
+	}
+	return fallback
+}
+
+// lowerKeys returns values with lower-cased keys.
+func lowerKeys[T any](values map[string]T) map[string]T {
+	lowered := make(map[string]T, len(values))
+	for key, value := range values {
+		lowered[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return lowered
+}
+
+// parseByteSize parses a byte count with an optional KB, MB or GB suffix.
+func parseByteSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = strings.TrimSpace(number), unit.size
+			break
+		}
+	}
+	size, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q", raw)
+	}
+	return size * multiplier, nil
+}
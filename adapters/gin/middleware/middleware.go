@@ -18,9 +18,7 @@ import (
 	"github.com/abhissng/neuron/utils/random"
 	"github.com/abhissng/neuron/utils/structures"
 	"github.com/abhissng/neuron/utils/structures/claims"
-	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // RequestIDMiddleware creates a Gin middleware that generates a request ID and a correlation ID,
@@ -37,7 +35,7 @@ func RequestIDMiddleware(log1 *log.Log) gin.HandlerFunc {
 		// Check if correlationId is passed in the headers
 		correlationId := c.GetHeader(constant.CorrelationIDHeader)
 		if correlationId == "" {
-			correlationId = uuid.New().String() // Generate a new one if not provided
+			correlationId = random.GenerateUUIDString() // Generate a new one if not provided
 		}
 
 		// Attach IDs to the context
@@ -98,11 +96,6 @@ func ServiceNameMiddleware() gin.HandlerFunc {
 	}
 }
 
-// **Gin Middleware for Compression**
-func CompressionMiddleware() gin.HandlerFunc {
-	return gzip.Gzip(gzip.BestSpeed)
-}
-
 // TODO create correct logic for autorefresh
 // basically  token services needs to be called for auto- refresh
 // **Gin Middleware for Auto-Refresh**
@@ -195,6 +188,10 @@ func PasetoVerifyMiddleware(ctx *context.ServiceContext) result.Result[bool] {
 		return result.NewFailure[bool](res.Blame())
 	}
 
+	// Surface the user, and the actor of impersonation tokens, to the handlers
+	claim, _ := res.Value()
+	ctx.SetClaims(claim)
+
 	validToken := true
 	return result.NewSuccess(&validToken)
 }
@@ -238,7 +235,11 @@ func SessionVerifyMiddleware(ctx *context.ServiceContext) result.Result[bool] {
 	}()
 
 	// 🧩 Extract session ID cookie
-	sessionID, err = ctx.Cookie(constant.SessionID)
+	if ctx.SessionManager != nil {
+		sessionID, err = ctx.SessionIDFromRequest(ctx.Request)
+	} else {
+		sessionID, err = ctx.Cookie(constant.SessionID)
+	}
 	if err != nil || sessionID == "" {
 		ctx.SlogError("session cookie is missing", log.Err(err))
 		return result.NewFailure[bool](blame.SessionMalformed(errors.New("session cookie is missing")))
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// gcraScript implements the generic cell rate algorithm on one key holding the theoretical
+// arrival time (TAT) in microseconds of Redis time, so replicas share one clock.
+// ARGV[1] is the emission interval and ARGV[2] the burst tolerance, both in microseconds.
+// It returns {allowed, retry after in microseconds, remaining requests}.
+var gcraScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+  tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if allow_at > now then
+  return {0, allow_at - now, 0}
+end
+redis.call('SET', KEYS[1], string.format('%.0f', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, 0, math.floor((now - allow_at) / interval)}
+`)
+
+// RouteLimit is the rate of a route overriding the default rate of a RedisRateLimiter. A
+// zero Rate exempts the route.
+type RouteLimit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// RateLimitResult is the outcome of RedisRateLimiter.Allow.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// RedisRateLimiter is a distributed rate limiter keeping its state in Redis, so that the
+// limits hold across replicas. It applies the GCRA algorithm, equivalent to a token bucket of
+// rate and burst, with one Redis round trip per request, and offers the same Middleware() as
+// IPRateLimiter.
+//
+// Clients are limited on a default bucket shared by every route, and on a bucket of their own
+// for each route with a RouteLimit.
+type RedisRateLimiter struct {
+	client   *redis.Client
+	prefix   string
+	limit    RouteLimit
+	routes   map[string]RouteLimit
+	key      func(c *gin.Context) string
+	failOpen bool
+	logger   *log.Log
+}
+
+// RedisRateLimiterOption configures a RedisRateLimiter.
+type RedisRateLimiterOption func(*RedisRateLimiter)
+
+// WithRouteLimits overrides the rate of routes. A route is either "METHOD /full/path", e.g.
+// "POST /login", or "/full/path" for every method, with the path as registered in gin.
+func WithRouteLimits(routes map[string]RouteLimit) RedisRateLimiterOption {
+	return func(l *RedisRateLimiter) {
+		for route, limit := range routes {
+			l.routes[route] = limit
+		}
+	}
+}
+
+// WithRateLimitPrefix sets the prefix of the Redis keys. Defaults to "rate_limiter:";
+// replicas sharing limits must use the same prefix.
+func WithRateLimitPrefix(prefix string) RedisRateLimiterOption {
+	return func(l *RedisRateLimiter) {
+		l.prefix = prefix
+	}
+}
+
+// WithRateLimitKey sets the function identifying the client of a request, e.g. by user id.
+// Defaults to the remote IP address, as IPRateLimiter does.
+func WithRateLimitKey(key func(c *gin.Context) string) RedisRateLimiterOption {
+	return func(l *RedisRateLimiter) {
+		l.key = key
+	}
+}
+
+// WithRateLimitFailClosed rejects requests when Redis is unavailable. By default they are
+// allowed, so an outage of Redis does not take the service down.
+func WithRateLimitFailClosed() RedisRateLimiterOption {
+	return func(l *RedisRateLimiter) {
+		l.failOpen = false
+	}
+}
+
+// WithRateLimitLogger sets the logger used to record Redis failures.
+func WithRateLimitLogger(logger *log.Log) RedisRateLimiterOption {
+	return func(l *RedisRateLimiter) {
+		l.logger = logger
+	}
+}
+
+// NewRedisRateLimiter creates a RedisRateLimiter allowing r requests per second with bursts
+// of b to each client.
+func NewRedisRateLimiter(client *redis.Client, r rate.Limit, b int, opts ...RedisRateLimiterOption) *RedisRateLimiter {
+	limiter := &RedisRateLimiter{
+		client:   client,
+		prefix:   "rate_limiter:",
+		limit:    RouteLimit{Rate: r, Burst: b},
+		routes:   make(map[string]RouteLimit),
+		key:      remoteIP,
+		failOpen: true,
+	}
+	for _, opt := range opts {
+		opt(limiter)
+	}
+	if limiter.logger == nil {
+		limiter.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return limiter
+}
+
+// Allow consumes a request of the bucket key at limit.
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit RouteLimit) (RateLimitResult, error) {
+	if limit.Rate == rate.Inf || limit.Rate <= 0 {
+		return RateLimitResult{Allowed: true, Remaining: limit.Burst}, nil
+	}
+	burst := max(limit.Burst, 1)
+	interval := math.Ceil(1e6 / float64(limit.Rate))
+	values, err := gcraScript.Run(ctx, l.client, []string{l.prefix + key}, int64(interval), int64(interval)*int64(burst)).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		RetryAfter: time.Duration(values[1]) * time.Microsecond,
+		Remaining:  int(values[2]),
+	}, nil
+}
+
+// Middleware returns the Gin middleware handler.
+func (l *RedisRateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := l.key(c)
+		limit, key := l.limit, client
+		if routeLimit, route, ok := l.routeLimit(c); ok {
+			limit, key = routeLimit, route+":"+client
+		}
+		if limit.Rate <= 0 {
+			c.Next()
+			return
+		}
+
+		result, err := l.Allow(c.Request.Context(), key, limit)
+		if err != nil {
+			l.logger.Error("Rate limiter store unavailable", log.String("key", key), log.Bool("fail_open", l.failOpen), log.Err(err))
+			if l.failOpen {
+				c.Next()
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Rate limiter unavailable"})
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", fmt.Sprintf("%d", limit.Burst))
+		c.Header("X-RateLimit-Remaining", fmt.Sprintf("%d", result.Remaining))
+		if !result.Allowed {
+			c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(result.RetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// routeLimit returns the RouteLimit of the route of c and its bucket name, the
+// method-specific entry first.
+func (l *RedisRateLimiter) routeLimit(c *gin.Context) (RouteLimit, string, bool) {
+	if len(l.routes) == 0 {
+		return RouteLimit{}, "", false
+	}
+	route := c.FullPath()
+	if limit, ok := l.routes[c.Request.Method+" "+route]; ok {
+		return limit, c.Request.Method + " " + route, true
+	}
+	if limit, ok := l.routes[route]; ok {
+		return limit, route, true
+	}
+	return RouteLimit{}, "", false
+}
+
+// remoteIP returns the IP address of the remote peer of c, without its port.
+func remoteIP(c *gin.Context) string {
+	ip := c.Request.RemoteAddr
+	if idx := strings.LastIndex(ip, ":"); idx != -1 {
+		ip = ip[:idx]
+	}
+	return ip
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/context"
+	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/helpers"
+)
+
+// ScopeRoutes maps routes to the scopes their tokens must grant. A route is either
+// "METHOD /full/path", e.g. "POST /orders/:id", or "/full/path" for every method, with the
+// path as registered in gin. Routes not listed require no scope.
+type ScopeRoutes map[string][]string
+
+// scopes returns the scopes required by method on route, the method-specific entry first.
+func (r ScopeRoutes) scopes(method, route string) []string {
+	if scopes, ok := r[method+" "+route]; ok {
+		return scopes
+	}
+	return r[route]
+}
+
+// ScopeMiddleware returns a service middleware enforcing the scopes of routes, to be wrapped
+// with handler.WrapServiceMiddlewareHandler after PasetoVerifyMiddleware. Requests whose token
+// lacks a required scope fail with blame.InsufficientScopesError.
+func ScopeMiddleware(routes ScopeRoutes) func(*context.ServiceContext) result.Result[bool] {
+	return func(ctx *context.ServiceContext) result.Result[bool] {
+		return checkScopes(ctx, routes.scopes(ctx.Request.Method, ctx.FullPath()))
+	}
+}
+
+// RequireScopes returns a service middleware requiring scopes on every route it is applied
+// to, e.g. a route group.
+func RequireScopes(scopes ...string) func(*context.ServiceContext) result.Result[bool] {
+	return func(ctx *context.ServiceContext) result.Result[bool] {
+		return checkScopes(ctx, scopes)
+	}
+}
+
+// checkScopes fails when the verified token of ctx does not grant scopes.
+func checkScopes(ctx *context.ServiceContext, scopes []string) result.Result[bool] {
+	if len(scopes) == 0 {
+		return result.NewSuccess(helpers.Valid())
+	}
+	missing := scopes
+	if claim, ok := ctx.GetClaims(); ok {
+		missing = claim.MissingScopes(scopes...)
+	}
+	if len(missing) > 0 {
+		ctx.SlogWarn("insufficient scopes", log.Any("missing_scopes", missing), log.String("route", ctx.FullPath()))
+		return result.NewFailure[bool](blame.InsufficientScopesError(strings.Join(missing, ", ")))
+	}
+	return result.NewSuccess(helpers.Valid())
+}
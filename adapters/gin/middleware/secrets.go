@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultSecretGuardMaxBuffer is the largest response body SecretGuardMiddleware scans as a
+// whole; larger bodies are scanned chunk by chunk
+const DefaultSecretGuardMaxBuffer = 1 << 20
+
+// secretGuardSkipKey is the context key set by SkipSecretGuard
+const secretGuardSkipKey = "neuron.secret_guard.skip"
+
+// SecretGuardOption configures SecretGuardMiddleware
+type SecretGuardOption func(*secretGuardConfig)
+
+type secretGuardConfig struct {
+	excludedPaths []string
+}
+
+// WithSecretGuardExcludedPaths sends the responses of request paths with these prefixes
+// unscanned, e.g. the login, token refresh and API key endpoints whose responses carry
+// tokens by design
+func WithSecretGuardExcludedPaths(paths ...string) SecretGuardOption {
+	return func(c *secretGuardConfig) {
+		c.excludedPaths = append(c.excludedPaths, paths...)
+	}
+}
+
+// SkipSecretGuard lets the current response through SecretGuardMiddleware unscanned. Call it
+// from a handler issuing a secret on purpose, before writing the response
+func SkipSecretGuard(c *gin.Context) {
+	c.Set(secretGuardSkipKey, true)
+}
+
+// SecretGuardMiddleware scans rendered response bodies for secrets with scanner and masks
+// them before they are sent, logging an alert with the request path. Bodies up to maxBuffer
+// bytes are buffered so secrets split across writes are caught; streamed, flushed or hijacked
+// responses are scanned per write. Register it before handlers and after compression.
+// Responses meant to carry secrets opt out with WithSecretGuardExcludedPaths or
+// SkipSecretGuard.
+func SecretGuardMiddleware(scanner *helpers.SecretScanner, logger *log.Log, maxBuffer int, options ...SecretGuardOption) gin.HandlerFunc {
+	if maxBuffer <= 0 {
+		maxBuffer = DefaultSecretGuardMaxBuffer
+	}
+	if logger == nil {
+		logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	config := &secretGuardConfig{}
+	for _, opt := range options {
+		opt(config)
+	}
+
+	return func(c *gin.Context) {
+		for _, prefix := range config.excludedPaths {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		writer := &secretGuardWriter{ResponseWriter: c.Writer, ctx: c, scanner: scanner, maxBuffer: maxBuffer}
+		c.Writer = writer
+		defer func() {
+			writer.flushBuffer()
+			c.Writer = writer.ResponseWriter
+			if writer.detected {
+				logger.Warn("secret masked in response",
+					log.String("method", c.Request.Method),
+					log.String("path", c.Request.URL.Path),
+					log.Any("status", writer.Status()),
+				)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// secretGuardWriter buffers the response body to mask secrets before writing it.
+type secretGuardWriter struct {
+	gin.ResponseWriter
+	ctx       *gin.Context
+	scanner   *helpers.SecretScanner
+	maxBuffer int
+	buffer    []byte
+	streaming bool
+	detected  bool
+}
+
+func (w *secretGuardWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *secretGuardWriter) Write(data []byte) (int, error) {
+	if w.streaming {
+		if _, err := w.write(data); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) > w.maxBuffer {
+		w.streaming = true
+		w.flushBuffer()
+	}
+	return len(data), nil
+}
+
+func (w *secretGuardWriter) Written() bool {
+	return w.ResponseWriter.Written() || len(w.buffer) > 0
+}
+
+func (w *secretGuardWriter) Size() int {
+	return w.ResponseWriter.Size() + len(w.buffer)
+}
+
+func (w *secretGuardWriter) Flush() {
+	w.streaming = true
+	w.flushBuffer()
+	w.ResponseWriter.Flush()
+}
+
+func (w *secretGuardWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.streaming = true
+	w.flushBuffer()
+	return w.ResponseWriter.Hijack()
+}
+
+// flushBuffer writes the buffered body, fixing Content-Length when masking changed its size.
+func (w *secretGuardWriter) flushBuffer() {
+	if len(w.buffer) == 0 {
+		return
+	}
+	data := w.buffer
+	w.buffer = nil
+	if w.skipped() {
+		_, _ = w.ResponseWriter.Write(data)
+		return
+	}
+	masked, found := w.scanner.MaskBytes("response", data)
+	if found {
+		w.detected = true
+		if !w.ResponseWriter.Written() && !w.streaming {
+			w.Header().Set("Content-Length", strconv.Itoa(len(masked)))
+		} else {
+			w.Header().Del("Content-Length")
+		}
+	}
+	_, _ = w.ResponseWriter.Write(masked)
+}
+
+func (w *secretGuardWriter) write(data []byte) (int, error) {
+	if w.skipped() {
+		return w.ResponseWriter.Write(data)
+	}
+	masked, found := w.scanner.MaskBytes("response", data)
+	if found {
+		w.detected = true
+	}
+	return w.ResponseWriter.Write(masked)
+}
+
+// skipped reports whether the handler opted the response out with SkipSecretGuard.
+func (w *secretGuardWriter) skipped() bool {
+	return w.ctx.GetBool(secretGuardSkipKey)
+}
@@ -0,0 +1,355 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// Defaults of the Shadower options.
+const (
+	DefaultShadowMaxBody = 1 << 20
+	DefaultShadowQueue   = 1024
+	DefaultShadowWorkers = 4
+	DefaultShadowTimeout = 5 * time.Second
+)
+
+// defaultShadowScrubbedHeaders are the headers masked in mirrored requests.
+var defaultShadowScrubbedHeaders = []string{
+	constant.AuthorizationHeader, "Proxy-Authorization", "Cookie", "X-Api-Key",
+	constant.XPasetoToken, constant.XRefreshToken, constant.CSRFTokenHeader,
+}
+
+// ShadowRequest is a mirrored request, with its secrets scrubbed.
+type ShadowRequest struct {
+	Method        string              `json:"method"`
+	Path          string              `json:"path"`
+	RawQuery      string              `json:"raw_query,omitempty"`
+	Headers       map[string][]string `json:"headers"`
+	Body          []byte              `json:"body,omitempty"`
+	CorrelationID string              `json:"correlation_id,omitempty"`
+	ReceivedAt    time.Time           `json:"received_at"`
+}
+
+// ShadowTarget receives the mirrored requests, e.g. a new version of the service.
+type ShadowTarget interface {
+	Send(ctx context.Context, req *ShadowRequest) error
+}
+
+// ShadowTargetFunc is a function implementing ShadowTarget.
+type ShadowTargetFunc func(ctx context.Context, req *ShadowRequest) error
+
+// Send implements ShadowTarget.
+func (f ShadowTargetFunc) Send(ctx context.Context, req *ShadowRequest) error {
+	return f(ctx, req)
+}
+
+// HTTPShadowTarget replays the mirrored requests against baseURL, e.g.
+// "http://orders-canary:8080", with client, or http.DefaultClient when nil. The responses are
+// discarded.
+func HTTPShadowTarget(baseURL string, client *http.Client) ShadowTarget {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return ShadowTargetFunc(func(ctx context.Context, req *ShadowRequest) error {
+		url := baseURL + req.Path
+		if req.RawQuery != "" {
+			url += "?" + req.RawQuery
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.Method, url, bytes.NewReader(req.Body))
+		if err != nil {
+			return err
+		}
+		for name, values := range req.Headers {
+			httpReq.Header[name] = values
+		}
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.Body.Close()
+	})
+}
+
+// BrokerShadowTarget publishes the mirrored requests as JSON to subject, e.g. on the
+// events.Broker of a NATS manager, for consumers replaying them.
+func BrokerShadowTarget(broker events.Broker, subject string) ShadowTarget {
+	return ShadowTargetFunc(func(ctx context.Context, req *ShadowRequest) error {
+		if err := broker.Publish(ctx, subject, req); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// Shadower mirrors a sample of the requests to a ShadowTarget, asynchronously and without
+// affecting the responses, to test a new version of a service against real traffic. Secret
+// headers are masked, and JSON bodies are sanitized and scanned for secrets before leaving
+// the service. Requests are dropped when the queue is full.
+type Shadower struct {
+	target    ShadowTarget
+	percent   float64
+	maxBody   int64
+	timeout   time.Duration
+	scrubbed  map[string]bool
+	sanitizer *helpers.Sanitizer
+	scanner   *helpers.SecretScanner
+	logger    *log.Log
+	queue     chan *ShadowRequest
+	workers   int
+	dropped   atomic.Int64
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// ShadowOption configures a Shadower.
+type ShadowOption func(*Shadower)
+
+// WithShadowPercent sets the share of requests mirrored, from 0 to 100. Defaults to 100.
+func WithShadowPercent(percent float64) ShadowOption {
+	return func(s *Shadower) {
+		s.percent = min(max(percent, 0), 100)
+	}
+}
+
+// WithShadowMaxBody sets the largest body mirrored; requests with larger bodies are not
+// mirrored. Defaults to DefaultShadowMaxBody.
+func WithShadowMaxBody(bytes int64) ShadowOption {
+	return func(s *Shadower) {
+		s.maxBody = bytes
+	}
+}
+
+// WithShadowQueue sets the number of requests waiting to be sent and of workers sending them.
+// Defaults to DefaultShadowQueue and DefaultShadowWorkers.
+func WithShadowQueue(size, workers int) ShadowOption {
+	return func(s *Shadower) {
+		if size > 0 {
+			s.queue = make(chan *ShadowRequest, size)
+		}
+		if workers > 0 {
+			s.workers = workers
+		}
+	}
+}
+
+// WithShadowTimeout bounds the delivery of each mirrored request. Defaults to
+// DefaultShadowTimeout.
+func WithShadowTimeout(timeout time.Duration) ShadowOption {
+	return func(s *Shadower) {
+		s.timeout = timeout
+	}
+}
+
+// WithShadowScrubbedHeaders masks headers in addition to the credential and cookie headers.
+func WithShadowScrubbedHeaders(headers ...string) ShadowOption {
+	return func(s *Shadower) {
+		for _, header := range headers {
+			s.scrubbed[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+}
+
+// WithShadowSanitizer sets the sanitizer of JSON bodies. Defaults to helpers.DefaultSanitizer.
+func WithShadowSanitizer(sanitizer *helpers.Sanitizer) ShadowOption {
+	return func(s *Shadower) {
+		s.sanitizer = sanitizer
+	}
+}
+
+// WithShadowLogger sets the logger used to record failed deliveries.
+func WithShadowLogger(logger *log.Log) ShadowOption {
+	return func(s *Shadower) {
+		s.logger = logger
+	}
+}
+
+// NewShadower creates a Shadower mirroring requests to target and starts its workers. Call
+// Close on shutdown to deliver the queued requests.
+func NewShadower(target ShadowTarget, opts ...ShadowOption) *Shadower {
+	s := &Shadower{
+		target:    target,
+		percent:   100,
+		maxBody:   DefaultShadowMaxBody,
+		timeout:   DefaultShadowTimeout,
+		scrubbed:  make(map[string]bool),
+		sanitizer: helpers.DefaultSanitizer,
+		scanner:   helpers.NewSecretScanner(),
+		workers:   DefaultShadowWorkers,
+		closed:    make(chan struct{}),
+	}
+	for _, header := range defaultShadowScrubbedHeaders {
+		s.scrubbed[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.queue == nil {
+		s.queue = make(chan *ShadowRequest, DefaultShadowQueue)
+	}
+	if s.logger == nil {
+		s.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	for range s.workers {
+		s.wg.Add(1)
+		go s.work()
+	}
+	return s
+}
+
+// Middleware returns the Gin middleware handler. Mirrored requests carry the
+// X-Shadow-Request header, and requests carrying it are never mirrored again.
+func (s *Shadower) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.sampled(c.Request) {
+			c.Next()
+			return
+		}
+		if req, ok := s.capture(c.Request); ok {
+			select {
+			case s.queue <- req:
+			default:
+				s.dropped.Add(1)
+			}
+		}
+		c.Next()
+	}
+}
+
+// Dropped returns the number of requests dropped because the queue was full.
+func (s *Shadower) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops mirroring and waits for the queued requests to be delivered until ctx is done.
+func (s *Shadower) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sampled reports whether r is mirrored.
+func (s *Shadower) sampled(r *http.Request) bool {
+	if r.Header.Get(constant.XShadowRequest) != "" {
+		return false
+	}
+	select {
+	case <-s.closed:
+		return false
+	default:
+	}
+	return s.percent >= 100 || rand.Float64()*100 < s.percent // #nosec G404 -- sampling only
+}
+
+// capture copies r with its secrets scrubbed, restoring its body for the handlers. It
+// reports false when the body is too large or unreadable.
+func (s *Shadower) capture(r *http.Request) (*ShadowRequest, bool) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength > s.maxBody {
+			return nil, false
+		}
+		data, err := io.ReadAll(io.LimitReader(r.Body, s.maxBody+1))
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), r.Body), Closer: r.Body}
+		if err != nil || int64(len(data)) > s.maxBody {
+			return nil, false
+		}
+		body = s.scrubBody(r.Header.Get("Content-Type"), data)
+	}
+
+	headers := make(map[string][]string, len(r.Header)+1)
+	for name, values := range r.Header {
+		if s.scrubbed[name] {
+			headers[name] = []string{"****"}
+			continue
+		}
+		headers[name] = append([]string(nil), values...)
+	}
+	headers[constant.XShadowRequest] = []string{"true"}
+
+	return &ShadowRequest{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		RawQuery:      r.URL.RawQuery,
+		Headers:       headers,
+		Body:          body,
+		CorrelationID: r.Header.Get(constant.CorrelationIDHeader),
+		ReceivedAt:    time.Now(),
+	}, true
+}
+
+// scrubBody sanitizes the sensitive fields of JSON bodies and masks the secrets of any body.
+func (s *Shadower) scrubBody(contentType string, body []byte) []byte {
+	if strings.Contains(contentType, "json") {
+		var parsed any
+		if err := json.Unmarshal(body, &parsed); err == nil {
+			if sanitized, err := json.Marshal(s.sanitizer.Sanitize(parsed)); err == nil {
+				body = sanitized
+			}
+		}
+	}
+	masked, _ := s.scanner.MaskBytes("shadow", body)
+	return masked
+}
+
+// work delivers the queued requests until the Shadower is closed and its queue drained.
+func (s *Shadower) work() {
+	defer s.wg.Done()
+	for {
+		select {
+		case req := <-s.queue:
+			s.send(req)
+		case <-s.closed:
+			for {
+				select {
+				case req := <-s.queue:
+					s.send(req)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send delivers req to the target.
+func (s *Shadower) send(req *ShadowRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if err := s.target.Send(ctx, req); err != nil && !errors.Is(err, context.Canceled) {
+		s.logger.Warn("Failed to mirror request", log.String("method", req.Method), log.String("path", req.Path), log.Err(fmt.Errorf("shadow: %w", err)))
+	}
+}
+
+// readCloser restores a partially read request body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
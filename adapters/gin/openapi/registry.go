@@ -0,0 +1,482 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultSwaggerUIURL is the CDN the Swagger UI assets are loaded from.
+const DefaultSwaggerUIURL = "https://unpkg.com/swagger-ui-dist@5"
+
+// Registry records routes registered through it and builds the OpenAPI document describing
+// them. It is safe for concurrent use.
+type Registry struct {
+	mu            sync.RWMutex
+	info          Info
+	servers       []Server
+	schemes       map[string]SecurityScheme
+	security      []SecurityRequirement
+	swaggerUIURL  string
+	errorResponse reflect.Type
+	routes        []*route
+}
+
+// route is a registered endpoint and its documentation.
+type route struct {
+	method    string
+	path      string
+	operation *Operation
+	request   reflect.Type
+	query     reflect.Type
+	responses map[int]response
+	noAuth    bool
+}
+
+type response struct {
+	description string
+	body        reflect.Type
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithTitle sets the API title.
+func WithTitle(title string) Option {
+	return func(r *Registry) {
+		r.info.Title = title
+	}
+}
+
+// WithVersion sets the API version.
+func WithVersion(version string) Option {
+	return func(r *Registry) {
+		r.info.Version = version
+	}
+}
+
+// WithDescription sets the API description.
+func WithDescription(description string) Option {
+	return func(r *Registry) {
+		r.info.Description = description
+	}
+}
+
+// WithServer adds a base URL the API is served from.
+func WithServer(url, description string) Option {
+	return func(r *Registry) {
+		r.servers = append(r.servers, Server{URL: url, Description: description})
+	}
+}
+
+// WithSecurityScheme registers an authentication scheme under name.
+func WithSecurityScheme(name string, scheme SecurityScheme) Option {
+	return func(r *Registry) {
+		r.schemes[name] = scheme
+	}
+}
+
+// WithDefaultSecurity requires the named schemes on every operation that does not override
+// them with WithAuth or WithNoAuth.
+func WithDefaultSecurity(schemes ...string) Option {
+	return func(r *Registry) {
+		r.security = append(r.security, requirement(schemes))
+	}
+}
+
+// WithSwaggerUIURL sets where the Swagger UI assets are loaded from, e.g. a self-hosted copy.
+func WithSwaggerUIURL(url string) Option {
+	return func(r *Registry) {
+		r.swaggerUIURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithErrorResponse sets the body documented for the default error response. It defaults to
+// acknowledgment.APIResponse[blame.ErrorResponse], the envelope written by the middlewares.
+func WithErrorResponse[T any]() Option {
+	return func(r *Registry) {
+		r.errorResponse = reflect.TypeFor[T]()
+	}
+}
+
+// NewRegistry creates a Registry.
+func NewRegistry(options ...Option) *Registry {
+	r := &Registry{
+		info:          Info{Title: "API", Version: "1.0.0"},
+		schemes:       map[string]SecurityScheme{},
+		swaggerUIURL:  DefaultSwaggerUIURL,
+		errorResponse: reflect.TypeFor[acknowledgment.APIResponse[blame.ErrorResponse]](),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// RouteOption documents a registered route.
+type RouteOption func(*route)
+
+// WithSummary sets the operation summary.
+func WithSummary(summary string) RouteOption {
+	return func(rt *route) {
+		rt.operation.Summary = summary
+	}
+}
+
+// WithOperationDescription sets the operation description.
+func WithOperationDescription(description string) RouteOption {
+	return func(rt *route) {
+		rt.operation.Description = description
+	}
+}
+
+// WithTags groups the operation under tags.
+func WithTags(tags ...string) RouteOption {
+	return func(rt *route) {
+		rt.operation.Tags = append(rt.operation.Tags, tags...)
+	}
+}
+
+// WithOperationID overrides the generated operation ID.
+func WithOperationID(id string) RouteOption {
+	return func(rt *route) {
+		rt.operation.OperationID = id
+	}
+}
+
+// WithRequest documents the JSON request body as T.
+func WithRequest[T any]() RouteOption {
+	return func(rt *route) {
+		rt.request = reflect.TypeFor[T]()
+	}
+}
+
+// WithQuery documents the query parameters bound from the form tags of struct T.
+func WithQuery[T any]() RouteOption {
+	return func(rt *route) {
+		rt.query = reflect.TypeFor[T]()
+	}
+}
+
+// WithResponse documents a JSON response of type T for status.
+func WithResponse[T any](status int, description string) RouteOption {
+	return func(rt *route) {
+		rt.responses[status] = response{description: description, body: reflect.TypeFor[T]()}
+	}
+}
+
+// WithAPIResponse documents an acknowledgment.APIResponse[T] envelope for status.
+func WithAPIResponse[T any](status int) RouteOption {
+	return func(rt *route) {
+		rt.responses[status] = response{
+			description: http.StatusText(status),
+			body:        reflect.TypeFor[acknowledgment.APIResponse[T]](),
+		}
+	}
+}
+
+// WithEmptyResponse documents a response without a body, e.g. 204.
+func WithEmptyResponse(status int, description string) RouteOption {
+	return func(rt *route) {
+		rt.responses[status] = response{description: description}
+	}
+}
+
+// WithPathParam describes a path parameter. Path parameters are detected from the route and
+// documented as strings without this option.
+func WithPathParam(name, description string) RouteOption {
+	return func(rt *route) {
+		for i := range rt.operation.Parameters {
+			if p := &rt.operation.Parameters[i]; p.In == "path" && p.Name == name {
+				p.Description = description
+			}
+		}
+	}
+}
+
+// WithHeaderParam documents a request header.
+func WithHeaderParam(name, description string, required bool) RouteOption {
+	return func(rt *route) {
+		rt.operation.Parameters = append(rt.operation.Parameters, Parameter{
+			Name:        name,
+			In:          "header",
+			Description: description,
+			Required:    required,
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+}
+
+// WithAuth requires all of the named security schemes, replacing the registry default.
+func WithAuth(schemes ...string) RouteOption {
+	return func(rt *route) {
+		rt.operation.Security = append(rt.operation.Security, requirement(schemes))
+	}
+}
+
+// WithNoAuth marks the operation as public, overriding the registry default.
+func WithNoAuth() RouteOption {
+	return func(rt *route) {
+		rt.noAuth = true
+	}
+}
+
+// WithDeprecated marks the operation as deprecated.
+func WithDeprecated() RouteOption {
+	return func(rt *route) {
+		rt.operation.Deprecated = true
+	}
+}
+
+// Register adds handlers to router under method and relativePath, and records the route with
+// its documentation. Handlers are registered exactly as with router.Handle.
+func (r *Registry) Register(router gin.IRouter, method, relativePath string, handlers []gin.HandlerFunc, options ...RouteOption) gin.IRoutes {
+	fullPath := relativePath
+	if group, ok := router.(*gin.RouterGroup); ok {
+		fullPath = joinPaths(group.BasePath(), relativePath)
+	}
+	openAPIPath, params := convertPath(fullPath)
+
+	rt := &route{
+		method:    strings.ToUpper(method),
+		path:      openAPIPath,
+		operation: &Operation{OperationID: operationID(method, openAPIPath)},
+		responses: map[int]response{},
+	}
+	for _, name := range params {
+		rt.operation.Parameters = append(rt.operation.Parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	for _, opt := range options {
+		opt(rt)
+	}
+
+	r.mu.Lock()
+	r.routes = append(r.routes, rt)
+	r.mu.Unlock()
+	return router.Handle(rt.method, relativePath, handlers...)
+}
+
+// GET registers a GET route. See Register.
+func (r *Registry) GET(router gin.IRouter, relativePath string, handler gin.HandlerFunc, options ...RouteOption) gin.IRoutes {
+	return r.Register(router, http.MethodGet, relativePath, []gin.HandlerFunc{handler}, options...)
+}
+
+// POST registers a POST route. See Register.
+func (r *Registry) POST(router gin.IRouter, relativePath string, handler gin.HandlerFunc, options ...RouteOption) gin.IRoutes {
+	return r.Register(router, http.MethodPost, relativePath, []gin.HandlerFunc{handler}, options...)
+}
+
+// PUT registers a PUT route. See Register.
+func (r *Registry) PUT(router gin.IRouter, relativePath string, handler gin.HandlerFunc, options ...RouteOption) gin.IRoutes {
+	return r.Register(router, http.MethodPut, relativePath, []gin.HandlerFunc{handler}, options...)
+}
+
+// PATCH registers a PATCH route. See Register.
+func (r *Registry) PATCH(router gin.IRouter, relativePath string, handler gin.HandlerFunc, options ...RouteOption) gin.IRoutes {
+	return r.Register(router, http.MethodPatch, relativePath, []gin.HandlerFunc{handler}, options...)
+}
+
+// DELETE registers a DELETE route. See Register.
+func (r *Registry) DELETE(router gin.IRouter, relativePath string, handler gin.HandlerFunc, options ...RouteOption) gin.IRoutes {
+	return r.Register(router, http.MethodDelete, relativePath, []gin.HandlerFunc{handler}, options...)
+}
+
+// Document builds the OpenAPI document for the routes registered so far.
+func (r *Registry) Document() *Document {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	gen := newSchemaGenerator()
+	doc := &Document{
+		OpenAPI:  Version,
+		Info:     r.info,
+		Servers:  r.servers,
+		Paths:    map[string]PathItem{},
+		Security: r.security,
+	}
+
+	tags := map[string]bool{}
+	for _, rt := range r.routes {
+		op := *rt.operation
+		op.Parameters = append([]Parameter(nil), rt.operation.Parameters...)
+		if rt.query != nil {
+			op.Parameters = append(op.Parameters, queryParameters(gen, rt.query)...)
+		}
+		if rt.request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{gin.MIMEJSON: {Schema: gen.schemaFor(rt.request)}},
+			}
+		}
+		op.Responses = r.responses(gen, rt)
+		if rt.noAuth {
+			// An empty requirement allows anonymous access, overriding the document default.
+			op.Security = []SecurityRequirement{{}}
+		}
+		for _, tag := range op.Tags {
+			if !tags[tag] {
+				tags[tag] = true
+				doc.Tags = append(doc.Tags, Tag{Name: tag})
+			}
+		}
+
+		item := doc.Paths[rt.path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = &op
+	}
+
+	doc.Components = Components{Schemas: gen.components}
+	if len(r.schemes) > 0 {
+		doc.Components.SecuritySchemes = r.schemes
+	}
+	return doc
+}
+
+// JSON returns the OpenAPI document encoded as JSON.
+func (r *Registry) JSON() ([]byte, error) {
+	return json.MarshalIndent(r.Document(), "", "  ")
+}
+
+// Mount serves the document at <relativePath>/openapi.json and the Swagger UI at
+// <relativePath>. The document is rebuilt on each request so late registrations appear.
+func (r *Registry) Mount(router gin.IRouter, relativePath string) {
+	base := strings.TrimSuffix(relativePath, "/")
+	specPath := base + "/openapi.json"
+
+	router.GET(specPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, r.Document())
+	})
+	router.GET(base+"/", func(c *gin.Context) {
+		specURL := specPath
+		if group, ok := router.(*gin.RouterGroup); ok {
+			specURL = joinPaths(group.BasePath(), specPath)
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage(r.info.Title, r.swaggerUIURL, specURL)))
+	})
+}
+
+// responses documents the registered responses plus the shared error response.
+func (r *Registry) responses(gen *schemaGenerator, rt *route) map[string]Response {
+	out := make(map[string]Response, len(rt.responses)+1)
+	for status, res := range rt.responses {
+		doc := Response{Description: res.description}
+		if doc.Description == "" {
+			doc.Description = http.StatusText(status)
+		}
+		if res.body != nil {
+			doc.Content = map[string]MediaType{gin.MIMEJSON: {Schema: gen.schemaFor(res.body)}}
+		}
+		out[strconv.Itoa(status)] = doc
+	}
+	if len(out) == 0 {
+		out[strconv.Itoa(http.StatusOK)] = Response{Description: http.StatusText(http.StatusOK)}
+	}
+	if r.errorResponse != nil {
+		out["default"] = Response{
+			Description: "Error",
+			Content:     map[string]MediaType{gin.MIMEJSON: {Schema: gen.schemaFor(r.errorResponse)}},
+		}
+	}
+	return out
+}
+
+// queryParameters documents the fields of struct t as query parameters, named by their form
+// tags as gin's query binding does.
+func queryParameters(gen *schemaGenerator, t reflect.Type) []Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []Parameter
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := gen.schemaFor(field.Type)
+		applyTags(schema, field)
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: schema.Description,
+			Required:    isRequired(field),
+			Schema:      schema,
+		})
+	}
+	return params
+}
+
+// convertPath rewrites gin's :name and *name segments to OpenAPI {name} templates and
+// returns the parameter names.
+func convertPath(p string) (string, []string) {
+	segments := strings.Split(p, "/")
+	var params []string
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives an identifier such as "getUsersById" from the method and path.
+func operationID(method, p string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.FieldsFunc(p, func(r rune) bool {
+		return r == '/' || r == '-' || r == '_' || r == '.'
+	}) {
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		if segment != "" {
+			b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+		}
+	}
+	return b.String()
+}
+
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
+
+func requirement(schemes []string) SecurityRequirement {
+	req := SecurityRequirement{}
+	for _, scheme := range schemes {
+		req[scheme] = []string{}
+	}
+	return req
+}
@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	durationType      = reflect.TypeFor[time.Duration]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	schemaNameCleaner = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+)
+
+// schemaGenerator converts Go types to schemas, collecting named structs as components.
+type schemaGenerator struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{components: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schemaFor returns the schema of t; named structs are referenced through components.
+func (g *schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "duration in nanoseconds"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(textMarshalerType):
+		// Types such as uuid.UUID and net.IP are serialised as strings.
+		return &Schema{Type: "string", Format: textFormat(t)}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schemaFor(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		// Interfaces and other dynamic values accept anything.
+		return &Schema{}
+	}
+}
+
+// component registers a named struct and returns its component name.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := schemaName(t)
+	for i := 2; ; i++ {
+		if _, taken := g.components[name]; !taken {
+			break
+		}
+		name = schemaName(t) + strconv.Itoa(i)
+	}
+	g.names[t] = name
+	// Reserve the name before recursing so self-referencing types terminate.
+	g.components[name] = &Schema{}
+	*g.components[name] = *g.structSchema(t)
+	return name
+}
+
+// structSchema builds an object schema from the exported fields of t.
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+		name, inline := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		if inline {
+			// Embedded structs without a json name are flattened by encoding/json and are
+			// already listed by reflect.VisibleFields.
+			continue
+		}
+
+		prop := g.schemaFor(field.Type)
+		applyTags(prop, field)
+		schema.Properties[name] = prop
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// jsonName returns the JSON property name of a field and whether it is an embedded struct
+// flattened into its parent.
+func jsonName(field reflect.StructField) (string, bool) {
+	tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if field.Anonymous && tag == "" {
+		t := field.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+	if tag == "" {
+		tag = field.Name
+	}
+	return tag, false
+}
+
+// isRequired reports whether gin binding or validator tags require the field.
+func isRequired(field reflect.StructField) bool {
+	for _, key := range []string{"binding", "validate"} {
+		for _, rule := range strings.Split(field.Tag.Get(key), ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyTags copies documentation tags onto a property schema. Supported tags are
+// description, example, enum (comma separated), format, minimum and maximum.
+func applyTags(schema *Schema, field reflect.StructField) {
+	if d := field.Tag.Get("description"); d != "" {
+		schema.Description = d
+	}
+	if schema.Ref != "" {
+		// Only descriptions are meaningful next to a $ref.
+		return
+	}
+	target := schema
+	if f := field.Tag.Get("format"); f != "" {
+		target.Format = f
+	}
+	if e := field.Tag.Get("example"); e != "" {
+		target.Example = parseExample(e, target.Type)
+	}
+	if e := field.Tag.Get("enum"); e != "" {
+		for _, v := range strings.Split(e, ",") {
+			target.Enum = append(target.Enum, parseExample(strings.TrimSpace(v), target.Type))
+		}
+	}
+	if v, err := strconv.ParseFloat(field.Tag.Get("minimum"), 64); err == nil {
+		target.Minimum = &v
+	}
+	if v, err := strconv.ParseFloat(field.Tag.Get("maximum"), 64); err == nil {
+		target.Maximum = &v
+	}
+}
+
+// parseExample converts a tag value to the schema type.
+func parseExample(value string, schemaType any) any {
+	switch schemaType {
+	case "integer":
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case "boolean":
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// nullable marks a schema as accepting null.
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return schema
+	}
+	if t, ok := schema.Type.(string); ok {
+		schema.Type = []string{t, "null"}
+	}
+	return schema
+}
+
+// textFormat returns the string format of a type serialised as text.
+func textFormat(t reflect.Type) string {
+	if strings.EqualFold(t.Name(), "uuid") {
+		return "uuid"
+	}
+	return ""
+}
+
+// schemaName returns a component name for t, e.g. "acknowledgment.APIResponse_user.User".
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name := t.Name()
+	if pkg != "" {
+		name = pkg + "." + name
+	}
+	name = strings.NewReplacer("[", "-", "]", "", "*", "").Replace(name)
+	// Generic instantiations embed full import paths; keep only the last segments.
+	parts := strings.Split(name, "-")
+	for i, part := range parts {
+		if j := strings.LastIndex(part, "/"); j >= 0 {
+			parts[i] = part[j+1:]
+		}
+	}
+	return schemaNameCleaner.ReplaceAllString(strings.Join(parts, "-"), "_")
+}
@@ -0,0 +1,29 @@
+package openapi
+
+import (
+	"fmt"
+	"html"
+)
+
+// swaggerUIPage renders a Swagger UI page loading the document at specURL.
+func swaggerUIPage(title, assetsURL, specURL string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>%[1]s</title>
+  <link rel="stylesheet" href="%[2]s/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="%[2]s/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: %[3]q, dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`, html.EscapeString(title), html.EscapeString(assetsURL), specURL)
+}
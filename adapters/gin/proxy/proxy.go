@@ -0,0 +1,266 @@
+// Package proxy forwards Gin routes to internal upstream services, for thin API gateways
+// built on neuron. Requests are streamed to the upstream with their path rewritten, only the
+// allowed headers and a service token, within a timeout and behind a circuit breaker.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/circuitBreaker"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/sony/gobreaker"
+)
+
+// DefaultTimeout bounds the proxied requests without WithTimeout.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultHeaders are the request headers forwarded without WithHeaderAllowlist.
+var DefaultHeaders = []string{
+	"Accept", "Accept-Encoding", "Accept-Language", "Content-Type", "User-Agent",
+	"If-Match", "If-None-Match", "If-Modified-Since", "Range",
+	constant.CorrelationIDHeader, constant.XRequestID, constant.XOrgId, constant.XUserId,
+	constant.XUserRole, constant.XLocationId, constant.XFeatureFlags, constant.IdempotencyKey,
+}
+
+// errUpstreamStatus marks the server errors of the upstream as failures of the breaker.
+var errUpstreamStatus = errors.New("proxy: upstream server error")
+
+// TokenSource returns the token the proxied requests are authorised with.
+type TokenSource func(ctx context.Context) (string, error)
+
+// tokenKey is the request context key of the token to inject.
+type tokenKey struct{}
+
+// Proxy forwards requests to an upstream.
+type Proxy struct {
+	target       *url.URL
+	rewrite      func(path string) string
+	headers      map[string]bool
+	tokenSource  TokenSource
+	tokenHeader  string
+	timeout      time.Duration
+	transport    http.RoundTripper
+	breaker      *gobreaker.CircuitBreaker
+	breakerOpts  []circuitBreaker.CircuitBreakerOption
+	flush        time.Duration
+	logger       *log.Log
+	reverseProxy *httputil.ReverseProxy
+}
+
+// Option configures a Proxy.
+type Option func(*Proxy)
+
+// WithStripPrefix removes prefix from the request paths, e.g. "/api/orders" so that
+// "/api/orders/42" is forwarded as "/42" under the upstream path.
+func WithStripPrefix(prefix string) Option {
+	return WithRewrite(func(path string) string {
+		stripped := strings.TrimPrefix(path, prefix)
+		if !strings.HasPrefix(stripped, "/") {
+			stripped = "/" + stripped
+		}
+		return stripped
+	})
+}
+
+// WithRewrite sets the function rewriting the request paths. The result is appended to the
+// upstream path.
+func WithRewrite(rewrite func(path string) string) Option {
+	return func(p *Proxy) {
+		p.rewrite = rewrite
+	}
+}
+
+// WithHeaderAllowlist replaces DefaultHeaders with the request headers forwarded to the
+// upstream. The others, including cookies and client credentials, are dropped.
+func WithHeaderAllowlist(headers ...string) Option {
+	return func(p *Proxy) {
+		p.headers = make(map[string]bool, len(headers))
+		for _, header := range headers {
+			p.headers[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+}
+
+// WithTokenSource authorises the proxied requests with the token of source as a bearer
+// Authorization header, e.g. a paseto token issued to the gateway.
+func WithTokenSource(source TokenSource) Option {
+	return func(p *Proxy) {
+		p.tokenSource = source
+	}
+}
+
+// WithTokenHeader sends the token of WithTokenSource as is in header instead of the
+// Authorization header, e.g. constant.XPasetoToken.
+func WithTokenHeader(header string) Option {
+	return func(p *Proxy) {
+		p.tokenHeader = header
+	}
+}
+
+// WithTimeout bounds each proxied request, including the streaming of its response. Zero
+// disables the timeout, e.g. for event streams. Defaults to DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Proxy) {
+		p.timeout = timeout
+	}
+}
+
+// WithTransport sets the transport of the upstream requests. Defaults to
+// http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(p *Proxy) {
+		p.transport = transport
+	}
+}
+
+// WithCircuitBreaker fails fast with 503 while the upstream keeps failing. Connection errors
+// and 5xx responses count as failures.
+func WithCircuitBreaker(options ...circuitBreaker.CircuitBreakerOption) Option {
+	return func(p *Proxy) {
+		p.breakerOpts = append([]circuitBreaker.CircuitBreakerOption{}, options...)
+	}
+}
+
+// WithFlushInterval sets how often the response is flushed to the client while it streams;
+// negative flushes after each write. Event streams are always flushed immediately.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(p *Proxy) {
+		p.flush = interval
+	}
+}
+
+// WithLogger sets the logger used to report upstream failures.
+func WithLogger(logger *log.Log) Option {
+	return func(p *Proxy) {
+		p.logger = logger
+	}
+}
+
+// New creates a Proxy to upstream, e.g. "http://orders.internal:8080/v1".
+func New(upstream string, options ...Option) (*Proxy, error) {
+	target, err := url.Parse(upstream)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("proxy: invalid upstream %q", upstream)
+	}
+	p := &Proxy{
+		target:      target,
+		tokenHeader: constant.AuthorizationHeader,
+		timeout:     DefaultTimeout,
+		transport:   http.DefaultTransport,
+	}
+	WithHeaderAllowlist(DefaultHeaders...)(p)
+	for _, opt := range options {
+		opt(p)
+	}
+	if p.logger == nil {
+		p.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+
+	transport := p.transport
+	if p.breakerOpts != nil {
+		name := circuitBreaker.WithName("proxy:" + target.Host)
+		p.breaker = circuitBreaker.NewCircuitBreaker(append([]circuitBreaker.CircuitBreakerOption{name}, p.breakerOpts...)...)
+		transport = &breakerTransport{next: p.transport, breaker: p.breaker}
+	}
+	p.reverseProxy = &httputil.ReverseProxy{
+		Rewrite:       p.rewriteRequest,
+		Transport:     transport,
+		FlushInterval: p.flush,
+		ErrorHandler:  p.handleError,
+	}
+	return p, nil
+}
+
+// Handler returns the handler forwarding the requests of a route to the upstream.
+func (p *Proxy) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if p.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+		}
+		if p.tokenSource != nil {
+			token, err := p.tokenSource(ctx)
+			if err != nil {
+				p.logger.Error("Failed to fetch the service token", log.String("upstream", p.target.Host), log.Err(err))
+				c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{"error": "Upstream is unavailable"})
+				return
+			}
+			ctx = context.WithValue(ctx, tokenKey{}, token)
+		}
+		p.reverseProxy.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	}
+}
+
+// rewriteRequest builds the upstream request.
+func (p *Proxy) rewriteRequest(pr *httputil.ProxyRequest) {
+	if p.rewrite != nil {
+		pr.Out.URL.Path = p.rewrite(pr.In.URL.Path)
+		pr.Out.URL.RawPath = ""
+	}
+	pr.SetURL(p.target)
+
+	for name := range pr.Out.Header {
+		if !p.headers[name] {
+			pr.Out.Header.Del(name)
+		}
+	}
+	if token, ok := pr.In.Context().Value(tokenKey{}).(string); ok {
+		if p.tokenHeader == constant.AuthorizationHeader {
+			token = "Bearer " + token
+		}
+		pr.Out.Header.Set(p.tokenHeader, token)
+	}
+	pr.SetXForwarded()
+}
+
+// handleError answers the requests the upstream failed.
+func (p *Proxy) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := http.StatusBadGateway, "Upstream is unavailable"
+	switch {
+	case errors.Is(err, gobreaker.ErrOpenState), errors.Is(err, gobreaker.ErrTooManyRequests):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		status, message = http.StatusGatewayTimeout, "Upstream timed out"
+	case errors.Is(err, context.Canceled):
+		// The client went away.
+		return
+	}
+	p.logger.Error("Proxied request failed", log.String("upstream", p.target.Host), log.String("path", r.URL.Path), log.Err(err))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `{"error":%q}`, message)
+}
+
+// breakerTransport runs the upstream requests through a circuit breaker.
+type breakerTransport struct {
+	next    http.RoundTripper
+	breaker *gobreaker.CircuitBreaker
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	_, err := t.breaker.Execute(func() (any, error) {
+		var err error
+		resp, err = t.next.RoundTrip(req)
+		if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+			return nil, errUpstreamStatus
+		}
+		return nil, err
+	})
+	if errors.Is(err, errUpstreamStatus) {
+		return resp, nil
+	}
+	return resp, err
+}
@@ -0,0 +1,315 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/file/uploadFile"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+// sniffSize is how much of a file is read to detect its content type.
+const sniffSize = 3072
+
+// These bound the form values read by ExtractFiles by default.
+const (
+	DefaultMaxValues     = 1000
+	DefaultMaxValuesSize = 1 << 20
+)
+
+// FileStorage persists uploaded files. cloud.CloudManager satisfies it, storing the files in
+// S3 or OCI object storage.
+type FileStorage interface {
+	UploadFileFromReader(ctx context.Context, bucket, key string, reader io.Reader, contentLength int64, contentType string, metadata map[string]string) error
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
+// ExtractFilesOptions configures ExtractFiles.
+type ExtractFilesOptions struct {
+	// Rule bounds the size, content types and extensions of each file, e.g. an uploadFile
+	// profile. Required.
+	Rule *uploadFile.FileRule
+	// MaxFiles bounds the number of files; zero is unlimited.
+	MaxFiles int
+	// MaxTotalSize bounds the size of all the files together; zero is unlimited.
+	MaxTotalSize int64
+	// Fields are the form fields files are accepted from; empty accepts every field.
+	Fields []string
+	// MaxValues and MaxValuesSize bound the number and total size of the values of the
+	// fields that are not files. Default to DefaultMaxValues and DefaultMaxValuesSize.
+	MaxValues     int
+	MaxValuesSize int64
+	// Storage and Bucket persist the files. Required.
+	Storage FileStorage
+	Bucket  string
+	// Key names the object of a file. Defaults to "uploads/<uuid><extension>".
+	Key func(c *gin.Context, field, filename string) string
+	// Metadata is attached to every object.
+	Metadata map[string]string
+	// Scanner scans each file while it is stored, as uploadFile.WithClamAV does; infected
+	// files are deleted.
+	Scanner uploadFile.VirusScanner
+}
+
+// UploadedFile describes a file stored by ExtractFiles.
+type UploadedFile struct {
+	Field    string `json:"field"`
+	Filename string `json:"filename"`
+	Bucket   string `json:"bucket"`
+	Key      string `json:"key"`
+	// ContentType is sniffed from the content, not taken from the request.
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// ExtractedFiles are the files and values of a multipart form read by ExtractFiles.
+type ExtractedFiles struct {
+	Files []UploadedFile
+	// Values are the fields of the form that are not files.
+	Values map[string][]string
+}
+
+// ExtractFiles streams the files of a multipart request to storage as they arrive, without
+// buffering them in memory or on disk. The size, extension and sniffed content type of each
+// file are checked against the rule, and the virus scanner runs on the stream alongside the
+// upload. When a file is rejected the files already stored by the request are deleted.
+// The body is consumed, so the other form values are returned in ExtractedFiles.Values.
+func ExtractFiles(c *gin.Context, opts ExtractFilesOptions) result.Result[ExtractedFiles] {
+	if opts.Rule == nil || opts.Storage == nil {
+		return result.NewFailure[ExtractedFiles](blame.InternalServerError(errors.New("ExtractFiles requires a rule and a storage")))
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return result.NewFailure[ExtractedFiles](blame.RequestFormDataExtractionFailed(err))
+	}
+
+	ctx := c.Request.Context()
+	extracted := ExtractedFiles{Values: make(map[string][]string)}
+	fail := func(failure blame.Blame) result.Result[ExtractedFiles] {
+		for _, file := range extracted.Files {
+			_ = opts.Storage.DeleteObject(context.WithoutCancel(ctx), file.Bucket, file.Key)
+		}
+		return result.NewFailure[ExtractedFiles](failure)
+	}
+
+	maxValues, maxValuesSize := opts.MaxValues, opts.MaxValuesSize
+	if maxValues <= 0 {
+		maxValues = DefaultMaxValues
+	}
+	if maxValuesSize <= 0 {
+		maxValuesSize = DefaultMaxValuesSize
+	}
+	var total, valuesSize int64
+	values := 0
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if limit, ok := bodyLimit(err); ok {
+				return fail(blame.RequestBodyTooLargeError(limit))
+			}
+			return fail(blame.RequestFormDataExtractionFailed(err))
+		}
+
+		field, filename := part.FormName(), part.FileName()
+		if filename == "" {
+			if values++; values > maxValues {
+				_ = part.Close()
+				return fail(blame.RequestFormDataExtractionFailed(fmt.Errorf("the form has more than %d values", maxValues)))
+			}
+			value, err := io.ReadAll(io.LimitReader(part, maxValuesSize-valuesSize+1))
+			_ = part.Close()
+			if err != nil {
+				return fail(blame.RequestFormDataExtractionFailed(err))
+			}
+			if valuesSize += int64(len(value)); valuesSize > maxValuesSize {
+				return fail(blame.RequestBodyTooLargeError(maxValuesSize))
+			}
+			extracted.Values[field] = append(extracted.Values[field], string(value))
+			continue
+		}
+		if len(opts.Fields) > 0 && !slices.Contains(opts.Fields, field) {
+			_ = part.Close()
+			return fail(blame.UploadRejectedError(filename, fmt.Sprintf("files are not accepted in the field %s", field)))
+		}
+		if opts.MaxFiles > 0 && len(extracted.Files) == opts.MaxFiles {
+			_ = part.Close()
+			return fail(blame.UploadTooManyFilesError(opts.MaxFiles))
+		}
+
+		file, failure := storeFile(c, opts, field, filename, part, total)
+		_ = part.Close()
+		if failure != nil {
+			return fail(failure)
+		}
+		total += file.Size
+		extracted.Files = append(extracted.Files, *file)
+	}
+	return result.NewSuccess(&extracted)
+}
+
+// storeFile checks and stores the file of a part, total bytes having been stored before.
+func storeFile(c *gin.Context, opts ExtractFilesOptions, field, filename string, part io.Reader, total int64) (*UploadedFile, blame.Blame) {
+	ctx := c.Request.Context()
+	if !opts.Rule.AllowsExtension(filename) {
+		return nil, blame.UploadRejectedError(filename, "the extension is not allowed")
+	}
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(part, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		if limit, ok := bodyLimit(err); ok {
+			return nil, blame.RequestBodyTooLargeError(limit)
+		}
+		return nil, blame.RequestFormDataExtractionFailed(err)
+	}
+	head = head[:n]
+	mtype := mimetype.Detect(head)
+	if !opts.Rule.AllowsMIME(mtype) {
+		return nil, blame.UploadRejectedError(filename, fmt.Sprintf("the content type %s is not allowed", mtype.String()))
+	}
+
+	limit := opts.Rule.MaxSizeBytes
+	if opts.MaxTotalSize > 0 {
+		limit = max(min(limit, opts.MaxTotalSize-total), 0)
+	}
+	body := &uploadReader{r: io.MultiReader(bytes.NewReader(head), part), limit: limit, hash: sha256.New()}
+
+	var scanned chan scanResult
+	var pipe *io.PipeWriter
+	if opts.Scanner != nil {
+		var pr *io.PipeReader
+		pr, pipe = io.Pipe()
+		body.tee = pipe
+		scanned = make(chan scanResult, 1)
+		go func() {
+			clean, err := opts.Scanner.Scan(pr)
+			// Drain what the scanner left so the upload never blocks on the pipe
+			_, _ = io.Copy(io.Discard, pr)
+			scanned <- scanResult{clean: clean, err: err}
+		}()
+	}
+
+	file := &UploadedFile{
+		Field:       field,
+		Filename:    filename,
+		Bucket:      opts.Bucket,
+		Key:         fileKey(c, opts, field, filename),
+		ContentType: mtype.String(),
+	}
+	err = opts.Storage.UploadFileFromReader(ctx, opts.Bucket, file.Key, body, -1, file.ContentType, opts.Metadata)
+	if pipe != nil {
+		if err != nil {
+			pipe.CloseWithError(err)
+		} else {
+			_ = pipe.Close()
+		}
+	}
+
+	switch {
+	case body.exceeded:
+		drainScan(scanned)
+		if limit < opts.Rule.MaxSizeBytes {
+			return nil, blame.RequestBodyTooLargeError(opts.MaxTotalSize)
+		}
+		return nil, blame.UploadFileTooLargeError(filename, opts.Rule.MaxSizeBytes)
+	case body.err != nil:
+		drainScan(scanned)
+		if limit, ok := bodyLimit(body.err); ok {
+			return nil, blame.RequestBodyTooLargeError(limit)
+		}
+		return nil, blame.RequestFormDataExtractionFailed(body.err)
+	case err != nil:
+		drainScan(scanned)
+		return nil, blame.BucketUploadError(err)
+	}
+
+	if scanned != nil {
+		scan := <-scanned
+		if scan.err != nil || !scan.clean {
+			_ = opts.Storage.DeleteObject(context.WithoutCancel(ctx), file.Bucket, file.Key)
+			if scan.err != nil {
+				return nil, blame.UploadRejectedError(filename, "the virus scan failed")
+			}
+			return nil, blame.UploadInfectedError(filename)
+		}
+	}
+	file.Size = body.n
+	file.SHA256 = hex.EncodeToString(body.hash.Sum(nil))
+	return file, nil
+}
+
+// fileKey names the object of a file.
+func fileKey(c *gin.Context, opts ExtractFilesOptions, field, filename string) string {
+	if opts.Key != nil {
+		return opts.Key(c, field, filename)
+	}
+	return path.Join("uploads", random.GenerateUUIDString()+strings.ToLower(filepath.Ext(filename)))
+}
+
+// scanResult is the outcome of a virus scan.
+type scanResult struct {
+	clean bool
+	err   error
+}
+
+// drainScan waits for the scan of a failed upload to finish.
+func drainScan(scanned chan scanResult) {
+	if scanned != nil {
+		<-scanned
+	}
+}
+
+// uploadReader counts and hashes the content of a file while it is stored, copies it to the
+// virus scanner and stops it at the size limit.
+type uploadReader struct {
+	r        io.Reader
+	tee      io.Writer
+	hash     hash.Hash
+	limit    int64
+	n        int64
+	exceeded bool
+	// err is the error reading the request, as opposed to the errors of the storage.
+	err error
+}
+
+// errFileTooLarge stops the upload of a file exceeding its limit.
+var errFileTooLarge = errors.New("file exceeds its size limit")
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	u.n += int64(n)
+	if u.n > u.limit {
+		u.exceeded = true
+		return 0, errFileTooLarge
+	}
+	if n > 0 {
+		u.hash.Write(p[:n])
+		if u.tee != nil {
+			if _, werr := u.tee.Write(p[:n]); werr != nil {
+				u.tee = nil
+			}
+		}
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		u.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,273 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/gin/response"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/result"
+	"github.com/gin-gonic/gin"
+)
+
+// The defaults of FetchPageParams.
+const (
+	DefaultPageLimit = 20
+	DefaultMaxLimit  = 100
+)
+
+// SortDirection is the direction of a sort field.
+type SortDirection string
+
+const (
+	SortAsc  SortDirection = "asc"
+	SortDesc SortDirection = "desc"
+)
+
+// SortField is a field to sort by.
+type SortField struct {
+	Field     string        `json:"field"`
+	Direction SortDirection `json:"direction"`
+}
+
+// FilterOperator compares a field to the values of a Filter.
+type FilterOperator string
+
+const (
+	FilterEq       FilterOperator = "eq"
+	FilterNe       FilterOperator = "ne"
+	FilterGt       FilterOperator = "gt"
+	FilterGte      FilterOperator = "gte"
+	FilterLt       FilterOperator = "lt"
+	FilterLte      FilterOperator = "lte"
+	FilterIn       FilterOperator = "in"
+	FilterContains FilterOperator = "contains"
+)
+
+var filterOperators = []FilterOperator{FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterIn, FilterContains}
+
+// Filter restricts a listing to the items whose field compares to the values; only FilterIn
+// has more than one value.
+type Filter struct {
+	Field    string         `json:"field"`
+	Operator FilterOperator `json:"operator"`
+	Values   []string       `json:"values"`
+}
+
+// Value returns the first value of the filter.
+func (f Filter) Value() string {
+	if len(f.Values) == 0 {
+		return ""
+	}
+	return f.Values[0]
+}
+
+// PageParams are the pagination, sorting and filtering parameters of a listing.
+type PageParams struct {
+	Limit int `json:"limit"`
+	// Offset is zero when Cursor is set.
+	Offset  int         `json:"offset"`
+	Cursor  string      `json:"cursor,omitempty"`
+	Sort    []SortField `json:"sort,omitempty"`
+	Filters []Filter    `json:"filters,omitempty"`
+}
+
+// Filter returns the filters on field.
+func (p *PageParams) Filter(field string) []Filter {
+	var filters []Filter
+	for _, filter := range p.Filters {
+		if filter.Field == field {
+			filters = append(filters, filter)
+		}
+	}
+	return filters
+}
+
+// Pagination describes the page served for p, for response.Paginated. total is the number
+// of items across all pages, when known, and nextCursor the cursor of the next page.
+func (p *PageParams) Pagination(total *int64, nextCursor string) response.Pagination {
+	return response.Pagination{
+		Limit:      p.Limit,
+		Offset:     p.Offset,
+		Total:      total,
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
+	}
+}
+
+// pageConfig holds the options of FetchPageParams.
+type pageConfig struct {
+	defaultLimit int
+	maxLimit     int
+	sortFields   []string
+	defaultSort  []SortField
+	filterFields map[string][]FilterOperator
+}
+
+// PageOption configures FetchPageParams.
+type PageOption func(*pageConfig)
+
+// WithLimits sets the limit used when the request names none and the largest it may ask for.
+// Defaults to DefaultPageLimit and DefaultMaxLimit.
+func WithLimits(defaultLimit, maxLimit int) PageOption {
+	return func(c *pageConfig) {
+		c.defaultLimit = defaultLimit
+		c.maxLimit = maxLimit
+	}
+}
+
+// WithSortFields allows sorting by fields. Without it sorting is rejected.
+func WithSortFields(fields ...string) PageOption {
+	return func(c *pageConfig) {
+		c.sortFields = append(c.sortFields, fields...)
+	}
+}
+
+// WithDefaultSort sets the sort used when the request names none.
+func WithDefaultSort(fields ...SortField) PageOption {
+	return func(c *pageConfig) {
+		c.defaultSort = fields
+	}
+}
+
+// WithFilterField allows filtering on field with operators, or every operator when none is
+// given. Filters on other fields are rejected.
+func WithFilterField(field string, operators ...FilterOperator) PageOption {
+	return func(c *pageConfig) {
+		if len(operators) == 0 {
+			operators = filterOperators
+		}
+		c.filterFields[field] = operators
+	}
+}
+
+// FetchPageParams parses the listing parameters of the query string:
+//
+//	limit=20&offset=40           offset pagination
+//	limit=20&cursor=eyJpZCI6...  cursor pagination, exclusive with offset
+//	sort=-created_at,name:asc    sort fields, descending with "-" or ":desc"
+//	filter=status:eq:active,amount:gte:100,type:in:card|upi
+//
+// Filters may also be given as repeated filter parameters. Sort and filter fields must be
+// allowed with WithSortFields and WithFilterField. Malformed values return
+// blame.RequestValidationError naming each invalid parameter.
+func FetchPageParams(c *gin.Context, options ...PageOption) result.Result[PageParams] {
+	cfg := &pageConfig{
+		defaultLimit: DefaultPageLimit,
+		maxLimit:     DefaultMaxLimit,
+		filterFields: make(map[string][]FilterOperator),
+	}
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	params := PageParams{Limit: cfg.defaultLimit, Sort: cfg.defaultSort}
+	fieldErrors := make(map[string]string)
+
+	if raw, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || limit < 1:
+			fieldErrors["limit"] = "must be a positive integer"
+		case limit > cfg.maxLimit:
+			fieldErrors["limit"] = fmt.Sprintf("must be at most %d", cfg.maxLimit)
+		default:
+			params.Limit = limit
+		}
+	}
+	params.Cursor = c.Query("cursor")
+	if raw, ok := c.GetQuery("offset"); ok {
+		offset, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || offset < 0:
+			fieldErrors["offset"] = "must be a non-negative integer"
+		case params.Cursor != "":
+			fieldErrors["offset"] = "cannot be combined with cursor"
+		default:
+			params.Offset = offset
+		}
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		sort, err := parseSort(raw, cfg.sortFields)
+		if err != nil {
+			fieldErrors["sort"] = err.Error()
+		} else {
+			params.Sort = sort
+		}
+	}
+
+	for _, raw := range c.QueryArray("filter") {
+		filters, err := parseFilters(raw, cfg.filterFields)
+		if err != nil {
+			fieldErrors["filter"] = err.Error()
+			break
+		}
+		params.Filters = append(params.Filters, filters...)
+	}
+
+	if len(fieldErrors) > 0 {
+		return result.NewFailure[PageParams](blame.RequestValidationError(fieldErrors, errors.New("invalid listing parameters")))
+	}
+	return result.NewSuccess(&params)
+}
+
+// parseSort parses a comma-separated list of sort fields.
+func parseSort(raw string, allowed []string) ([]SortField, error) {
+	var sort []SortField
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		direction := SortAsc
+		if name, ok := strings.CutPrefix(part, "-"); ok {
+			direction, part = SortDesc, name
+		}
+		if name, suffix, ok := strings.Cut(part, ":"); ok {
+			direction = SortDirection(strings.ToLower(suffix))
+			if direction != SortAsc && direction != SortDesc {
+				return nil, fmt.Errorf("unknown direction %q", suffix)
+			}
+			part = name
+		}
+		if !slices.Contains(allowed, part) {
+			return nil, fmt.Errorf("cannot sort by %q", part)
+		}
+		sort = append(sort, SortField{Field: part, Direction: direction})
+	}
+	return sort, nil
+}
+
+// parseFilters parses a comma-separated list of field:operator:value filters.
+func parseFilters(raw string, allowed map[string][]FilterOperator) ([]Filter, error) {
+	var filters []Filter
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field, rest, ok := strings.Cut(part, ":")
+		operator, value, ok2 := strings.Cut(rest, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("%q is not field:operator:value", part)
+		}
+		operators, known := allowed[field]
+		if !known {
+			return nil, fmt.Errorf("cannot filter on %q", field)
+		}
+		op := FilterOperator(strings.ToLower(operator))
+		if !slices.Contains(operators, op) {
+			return nil, fmt.Errorf("cannot filter %q with %q", field, operator)
+		}
+		values := []string{value}
+		if op == FilterIn {
+			values = strings.Split(value, "|")
+		}
+		filters = append(filters, Filter{Field: field, Operator: op, Values: values})
+	}
+	return filters, nil
+}
@@ -2,6 +2,7 @@ package request
 
 import (
 	"errors"
+	"net/http"
 	"strconv"
 
 	"github.com/abhissng/neuron/blame"
@@ -192,6 +193,9 @@ func ExtractDataFromRequestBody[T any](c *gin.Context) result.Result[T] {
 	var payload T
 	err := c.ShouldBindJSON(&payload)
 	if err != nil {
+		if limit, ok := bodyLimit(err); ok {
+			return result.NewFailure[T](blame.RequestBodyTooLargeError(limit))
+		}
 		return result.NewFailure[T](blame.RequestBodyDataExtractionFailed(err))
 	}
 	return result.NewSuccess(&payload)
@@ -202,11 +206,24 @@ func ExtractDataFromRequestBody[T any](c *gin.Context) result.Result[T] {
 func ExtractDataFromForm[T any](c *gin.Context) result.Result[T] {
 	var form T
 	if bindErr := c.ShouldBind(&form); bindErr != nil {
+		if limit, ok := bodyLimit(bindErr); ok {
+			return result.NewFailure[T](blame.RequestBodyTooLargeError(limit))
+		}
 		return result.NewFailure[T](blame.RequestFormDataExtractionFailed(bindErr))
 	}
 	return result.NewSuccess(&form)
 }
 
+// bodyLimit returns the limit of the body size exceeded by err, set with http.MaxBytesReader,
+// e.g. by middleware.MaxBodySize.
+func bodyLimit(err error) (int64, bool) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return maxBytesErr.Limit, true
+	}
+	return 0, false
+}
+
 // FetchBusinessIDFromParams fetches the business ID from route parameters.
 // It converts the parameter to BusinessID type and validates it.
 func FetchBusinessIDFromParams(c *gin.Context) result.Result[types.BusinessID] {
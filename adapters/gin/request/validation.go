@@ -0,0 +1,154 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/context"
+	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// registerFieldNames makes the validation errors name fields as they appear in the payload.
+var registerFieldNames sync.Once
+
+// ExtractAndValidate binds the request body, as JSON or form data depending on its content
+// type, into T and validates it with its `binding` struct tags, e.g. `binding:"required,email"`.
+// Validation failures return blame.RequestValidationError, whose "errors" field maps each
+// invalid field, by its JSON or form name, to a readable message.
+func ExtractAndValidate[T any](c *gin.Context) result.Result[T] {
+	registerFieldNames.Do(useTaggedFieldNames)
+
+	var payload T
+	err := c.ShouldBind(&payload)
+	if err == nil {
+		return result.NewSuccess(&payload)
+	}
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return result.NewFailure[T](blame.RequestValidationError(FieldErrors(validationErrors), err))
+	}
+	if binding.Default(c.Request.Method, c.ContentType()) == binding.JSON {
+		return result.NewFailure[T](blame.RequestBodyDataExtractionFailed(err))
+	}
+	return result.NewFailure[T](blame.RequestFormDataExtractionFailed(err))
+}
+
+// ValidateBody returns a service middleware running ExtractAndValidate and storing the
+// payload under key, for the handler to read with RetrieveFromGinContext[T].
+func ValidateBody[T any](key string) func(*context.ServiceContext) result.Result[bool] {
+	return func(ctx *context.ServiceContext) result.Result[bool] {
+		res := ExtractAndValidate[T](ctx.Context)
+		payload, err := res.Value()
+		if err != nil {
+			return result.NewFailure[bool](err)
+		}
+		ctx.Set(key, *payload)
+		return result.NewSuccess(helpers.Valid())
+	}
+}
+
+// FieldErrors maps the fields of errs, by their path in the payload, e.g. "items[0].sku", to
+// readable messages.
+func FieldErrors(errs validator.ValidationErrors) map[string]string {
+	fields := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		fields[fieldPath(fe)] = fieldMessage(fe)
+	}
+	return fields
+}
+
+// fieldPath returns the namespace of fe without its root struct.
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// fieldMessage describes the failed rule of fe.
+func fieldMessage(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "e164":
+		return "must be a phone number in E.164 format"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(param, " ", ", ")
+	case "len":
+		return "must have a length of " + param
+	case "min":
+		if isNumber(fe.Kind()) {
+			return "must be at least " + param
+		}
+		return "must have a length of at least " + param
+	case "max":
+		if isNumber(fe.Kind()) {
+			return "must be at most " + param
+		}
+		return "must have a length of at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must be greater than or equal to " + param
+	case "lt":
+		return "must be less than " + param
+	case "lte":
+		return "must be less than or equal to " + param
+	case "eqfield":
+		return "must be equal to " + param
+	case "nefield":
+		return "must not be equal to " + param
+	}
+	if param != "" {
+		return fmt.Sprintf("failed the %s=%s validation", fe.Tag(), param)
+	}
+	return fmt.Sprintf("failed the %s validation", fe.Tag())
+}
+
+// isNumber reports whether kind is numeric.
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// useTaggedFieldNames names the fields in gin's validation errors by their json tag, then
+// their form tag, falling back to the Go field name.
+func useTaggedFieldNames() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	engine.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
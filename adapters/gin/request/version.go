@@ -0,0 +1,12 @@
+package request
+
+import (
+	"github.com/abhissng/neuron/adapters/gin/versioning"
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion returns the API version requested by c, or "" when the request names none. See
+// versioning.RequestedVersion.
+func APIVersion(c *gin.Context) string {
+	return versioning.RequestedVersion(c)
+}
@@ -0,0 +1,207 @@
+// Package response writes Gin responses in a consistent envelope, so success responses look
+// alike across services the way blame makes errors look alike:
+//
+//	{"success": true, "data": {...}, "meta": {...}, "message": "...",
+//	 "request_id": "...", "correlation_id": "..."}
+//
+// Handlers call OK, Created, Paginated or NoContent for successes and Error for blames.
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/gin-gonic/gin"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// Envelope is the body of every response written by this package.
+type Envelope[T any] struct {
+	Success       bool                 `json:"success"`
+	Data          T                    `json:"data"`
+	Meta          *Meta                `json:"meta,omitempty"`
+	Message       string               `json:"message,omitempty"`
+	Error         *blame.ErrorResponse `json:"error,omitempty"`
+	RequestID     types.RequestID      `json:"request_id,omitempty"`
+	CorrelationID types.CorrelationID  `json:"correlation_id,omitempty"`
+}
+
+// Meta describes the data of a response beyond the data itself.
+type Meta struct {
+	Pagination *Pagination    `json:"pagination,omitempty"`
+	Extra      map[string]any `json:"extra,omitempty"`
+}
+
+// Pagination describes the page of a Paginated response.
+type Pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset,omitempty"`
+	// Total is the number of items across all pages, when known.
+	Total      *int64 `json:"total,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+var (
+	bundleMu sync.RWMutex
+	bundle   *i18n.Bundle
+)
+
+// SetBundle sets the i18n bundle WithTranslation localizes messages with.
+func SetBundle(b *i18n.Bundle) {
+	bundleMu.Lock()
+	defer bundleMu.Unlock()
+	bundle = b
+}
+
+// options configures a response.
+type options struct {
+	messageID    string
+	message      string
+	templateData map[string]any
+	translate    bool
+	extra        map[string]any
+	location     string
+}
+
+// Option configures a response.
+type Option func(*options)
+
+// WithMessage adds message to the response. id names the message in the i18n bundle and
+// message is its default text; "{{.key}}" placeholders are filled from templateData.
+func WithMessage(id, message string, templateData map[string]any) Option {
+	return func(o *options) {
+		o.messageID = id
+		o.message = message
+		o.templateData = templateData
+	}
+}
+
+// WithTranslation localizes the message into the language of the Accept-Language header,
+// with the bundle set by SetBundle, as blame.WithTranslation does for errors.
+func WithTranslation() Option {
+	return func(o *options) {
+		o.translate = true
+	}
+}
+
+// WithMeta adds key to the extra metadata of the response.
+func WithMeta(key string, value any) Option {
+	return func(o *options) {
+		if o.extra == nil {
+			o.extra = make(map[string]any)
+		}
+		o.extra[key] = value
+	}
+}
+
+// WithLocation sets the Location header, e.g. to the URL of a created resource.
+func WithLocation(location string) Option {
+	return func(o *options) {
+		o.location = location
+	}
+}
+
+// OK writes data with 200 OK.
+func OK[T any](c *gin.Context, data T, opts ...Option) {
+	write(c, http.StatusOK, data, nil, opts)
+}
+
+// Created writes data with 201 Created; set its URL with WithLocation.
+func Created[T any](c *gin.Context, data T, opts ...Option) {
+	write(c, http.StatusCreated, data, nil, opts)
+}
+
+// Accepted writes data with 202 Accepted, for work that completes later.
+func Accepted[T any](c *gin.Context, data T, opts ...Option) {
+	write(c, http.StatusAccepted, data, nil, opts)
+}
+
+// Paginated writes a page of items with 200 OK and describes the page in the metadata. When
+// pagination has a Total and no HasMore, HasMore is derived from it.
+func Paginated[T any](c *gin.Context, items []T, pagination Pagination, opts ...Option) {
+	if items == nil {
+		items = []T{}
+	}
+	if !pagination.HasMore && pagination.Total != nil {
+		pagination.HasMore = int64(pagination.Offset+len(items)) < *pagination.Total
+	}
+	write(c, http.StatusOK, items, &pagination, opts)
+}
+
+// NoContent writes 204 No Content.
+func NoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// Error aborts c with the status of err and its error response; pass
+// blame.WithTranslation() to localize it.
+func Error(c *gin.Context, err blame.Blame, opts ...blame.SendErrorResponseOption) {
+	errorResponse := err.FetchErrorResponse(opts...)
+	c.AbortWithStatusJSON(helpers.FetchHTTPStatusCode(err.FetchResponseType()), Envelope[any]{
+		Success:       false,
+		Error:         &errorResponse,
+		RequestID:     types.RequestID(c.GetString(constant.RequestID)),
+		CorrelationID: types.CorrelationID(c.GetString(constant.CorrelationID)),
+	})
+}
+
+// write renders the envelope of data.
+func write[T any](c *gin.Context, status int, data T, pagination *Pagination, opts []Option) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.location != "" {
+		c.Header("Location", o.location)
+	}
+
+	envelope := Envelope[T]{
+		Success:       true,
+		Data:          data,
+		Message:       message(c, o),
+		RequestID:     types.RequestID(c.GetString(constant.RequestID)),
+		CorrelationID: types.CorrelationID(c.GetString(constant.CorrelationID)),
+	}
+	if pagination != nil || len(o.extra) > 0 {
+		envelope.Meta = &Meta{Pagination: pagination, Extra: o.extra}
+	}
+	c.JSON(status, envelope)
+}
+
+// message returns the message of o, localized when asked to.
+func message(c *gin.Context, o *options) string {
+	if o.message == "" {
+		return ""
+	}
+	text := o.message
+	for key, value := range o.templateData {
+		text = strings.ReplaceAll(text, "{{."+key+"}}", fmt.Sprintf("%v", value))
+	}
+	if !o.translate {
+		return text
+	}
+
+	bundleMu.RLock()
+	b := bundle
+	bundleMu.RUnlock()
+	if b == nil || o.messageID == "" {
+		return text
+	}
+	localizer := i18n.NewLocalizer(b, c.GetHeader("Accept-Language"))
+	localized, err := localizer.Localize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{ID: o.messageID, Other: o.message},
+		TemplateData:   o.templateData,
+	})
+	if err != nil {
+		helpers.Println(constant.ERROR, "Error localizing message: ", err)
+		return text
+	}
+	return localized
+}
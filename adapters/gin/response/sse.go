@@ -0,0 +1,455 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/gin/server"
+	"github.com/abhissng/neuron/blame"
+	"github.com/gin-gonic/gin"
+)
+
+// These are the errors of SSEStream.
+var (
+	ErrSSEClosed      = errors.New("response: event stream closed")
+	ErrSSEUnsupported = errors.New("response: the response writer cannot stream")
+)
+
+// lastEventIDQuery is the query parameter EventSource polyfills send the last event id in,
+// as they cannot set the Last-Event-ID header.
+const lastEventIDQuery = "lastEventId"
+
+// SSEEvent is an event sent on an SSEStream. Data is encoded as JSON unless it is a string,
+// []byte or json.RawMessage.
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  any
+	// Retry tells the browser how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// SSEStream writes Server-Sent Events to a Gin response, flushing every event and sending a
+// comment as heartbeat so proxies keep the connection open. Sends are safe for concurrent use.
+//
+//	stream, err := response.NewSSEStream(c)
+//	if err != nil { ... }
+//	defer stream.Close()
+//	for _, order := range ordersSince(stream.LastEventID()) {
+//		stream.Send(response.SSEEvent{ID: order.ID, Event: "order", Data: order})
+//	}
+//	stream.Wait()
+type SSEStream struct {
+	c           *gin.Context
+	mu          sync.Mutex
+	closed      bool
+	done        chan struct{}
+	lastEventID string
+	heartbeat   time.Duration
+	retry       time.Duration
+	stopped     chan struct{}
+	unregister  func()
+}
+
+// SSEOption configures an SSEStream.
+type SSEOption func(*SSEStream)
+
+// WithSSEHeartbeat sets how often a heartbeat comment is sent; zero disables it. Defaults to
+// 15 seconds.
+func WithSSEHeartbeat(d time.Duration) SSEOption {
+	return func(s *SSEStream) {
+		s.heartbeat = d
+	}
+}
+
+// WithSSERetry tells browsers how long to wait before reconnecting.
+func WithSSERetry(d time.Duration) SSEOption {
+	return func(s *SSEStream) {
+		s.retry = d
+	}
+}
+
+// NewSSEStream starts an event stream answering the request of c. The stream ends when the
+// client goes away, Close is called or, when the server has a Drainer, on shutdown after a
+// final "shutdown" event.
+func NewSSEStream(c *gin.Context, opts ...SSEOption) (*SSEStream, error) {
+	if _, ok := c.Writer.(http.Flusher); !ok {
+		return nil, ErrSSEUnsupported
+	}
+	s := &SSEStream{
+		c:           c,
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+		heartbeat:   15 * time.Second,
+		lastEventID: c.GetHeader("Last-Event-ID"),
+	}
+	if s.lastEventID == "" {
+		s.lastEventID = c.Query(lastEventIDQuery)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Keeps nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if s.retry > 0 {
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", s.retry.Milliseconds())
+	}
+	c.Writer.Flush()
+
+	if drainer, ok := server.GetDrainer(c); ok {
+		s.unregister = drainer.Register(func(context.Context) {
+			_ = s.Send(SSEEvent{Event: "shutdown"})
+			s.Close()
+		})
+	}
+	go s.watch()
+	return s, nil
+}
+
+// watch sends the heartbeats and closes the stream when the client goes away.
+func (s *SSEStream) watch() {
+	defer close(s.stopped)
+	var tick <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+			if err := s.write([]byte(": ping\n\n")); err != nil {
+				s.close()
+				return
+			}
+		case <-s.c.Request.Context().Done():
+			s.close()
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// LastEventID returns the id of the last event the client received before reconnecting, or
+// "" on its first connection.
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send sends event and flushes it.
+func (s *SSEStream) Send(event SSEEvent) error {
+	frame, err := encodeSSE(event)
+	if err != nil {
+		return err
+	}
+	if err := s.write(frame); err != nil {
+		if !errors.Is(err, ErrSSEClosed) {
+			s.close()
+		}
+		return err
+	}
+	return nil
+}
+
+// SendData sends an event named event carrying data.
+func (s *SSEStream) SendData(event string, data any) error {
+	return s.Send(SSEEvent{Event: event, Data: data})
+}
+
+func (s *SSEStream) write(frame []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSSEClosed
+	}
+	if _, err := s.c.Writer.Write(frame); err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+// Done returns a channel closed when the stream ends.
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait blocks until the stream ends.
+func (s *SSEStream) Wait() {
+	<-s.done
+}
+
+// Close ends the stream. Nothing is written to the response once it returns, so the handler
+// may return.
+func (s *SSEStream) Close() {
+	s.close()
+	<-s.stopped
+}
+
+func (s *SSEStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	if s.unregister != nil {
+		s.unregister()
+	}
+}
+
+// encodeSSE encodes event in the text/event-stream format, one data line per line of data.
+func encodeSSE(event SSEEvent) ([]byte, error) {
+	var data []byte
+	switch value := event.Data.(type) {
+	case nil:
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	case json.RawMessage:
+		data = value
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		data = encoded
+	}
+
+	var buf bytes.Buffer
+	if event.ID != "" {
+		buf.WriteString("id: " + sseLine(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		buf.WriteString("event: " + sseLine(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	for line := range strings.SplitSeq(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// sseLine strips the line breaks a field value cannot contain.
+func sseLine(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+// SSEFilter reports whether the event msg is streamed to the client of c, e.g. only the
+// events of its own user.
+type SSEFilter func(c *gin.Context, msg *events.Message) bool
+
+// SSEBridge streams the events of broker subjects to browsers. It subscribes once per
+// subject and fans the events out to the streams of that subject, keeping the latest events
+// so reconnecting clients receive those sent after their Last-Event-ID. Every replica must
+// receive the events to reach its own clients, so the broker must not share subscriptions in
+// a queue group, e.g. natsManager.Broker("").
+//
+//	bridge := response.NewSSEBridge(natsManager.Broker(""))
+//	router.GET("/events", func(c *gin.Context) { bridge.Serve(c, "orders.>", nil) })
+type SSEBridge struct {
+	broker events.Broker
+	replay int
+
+	mu       sync.Mutex
+	subjects map[string]*sseSubject
+}
+
+// sseSubject is a subject subscribed to by an SSEBridge.
+type sseSubject struct {
+	streams map[*sseSubscriber]struct{}
+	recent  []SSEEvent
+	// messages are the messages of recent, as the filters of the streams apply to replays.
+	messages []*events.Message
+}
+
+// sseSubscriber is a stream of an SSEBridge with its filter.
+type sseSubscriber struct {
+	stream *SSEStream
+	c      *gin.Context
+	filter SSEFilter
+
+	mu sync.Mutex
+	// replaying buffers the dispatched events in pending while the replay is sent.
+	replaying bool
+	pending   []SSEEvent
+}
+
+// SSEBridgeOption configures an SSEBridge.
+type SSEBridgeOption func(*SSEBridge)
+
+// WithSSEReplay keeps the latest n events of each subject for reconnecting clients. Defaults
+// to 100; zero disables replays.
+func WithSSEReplay(n int) SSEBridgeOption {
+	return func(b *SSEBridge) {
+		b.replay = n
+	}
+}
+
+// NewSSEBridge creates an SSEBridge streaming the events of broker.
+func NewSSEBridge(broker events.Broker, opts ...SSEBridgeOption) *SSEBridge {
+	b := &SSEBridge{broker: broker, replay: 100, subjects: make(map[string]*sseSubject)}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Serve streams the events of subject accepted by filter, nil accepting all, to the client
+// of c until it goes away. Events are named after their subject and identified by their
+// Message-ID. Serve answers with an error when the subscription fails.
+func (b *SSEBridge) Serve(c *gin.Context, subject string, filter SSEFilter, opts ...SSEOption) {
+	if err := b.subscribe(subject); err != nil {
+		Error(c, err)
+		return
+	}
+	stream, err := NewSSEStream(c, opts...)
+	if err != nil {
+		Error(c, blame.InternalServerError(err))
+		return
+	}
+	defer stream.Close()
+
+	// The filter runs on the goroutines of the broker, so it gets a copy of c that stays valid
+	// outside the handler
+	subscriber := &sseSubscriber{stream: stream, c: c.Copy(), filter: filter, replaying: true}
+	b.mu.Lock()
+	sub := b.subjects[subject]
+	var replay []SSEEvent
+	var messages []*events.Message
+	if lastEventID := stream.LastEventID(); lastEventID != "" {
+		for i, event := range sub.recent {
+			if event.ID == lastEventID {
+				replay = slices.Clone(sub.recent[i+1:])
+				messages = slices.Clone(sub.messages[i+1:])
+				break
+			}
+		}
+	}
+	// Registering with the replay taken under the same lock neither loses nor repeats events:
+	// those dispatched from now on are buffered until the replay is sent
+	sub.streams[subscriber] = struct{}{}
+	b.mu.Unlock()
+
+	for i, event := range replay {
+		if subscriber.accepts(messages[i]) && stream.Send(event) != nil {
+			break
+		}
+	}
+	subscriber.flush()
+
+	stream.Wait()
+	b.mu.Lock()
+	delete(sub.streams, subscriber)
+	b.mu.Unlock()
+}
+
+func (s *sseSubscriber) accepts(msg *events.Message) bool {
+	return s.filter == nil || s.filter(s.c, msg)
+}
+
+// deliver sends event to the stream, or buffers it while the replay is being sent.
+func (s *sseSubscriber) deliver(event SSEEvent) {
+	s.mu.Lock()
+	if s.replaying {
+		s.pending = append(s.pending, event)
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	// A failed send closes the stream, which then leaves the subject
+	_ = s.stream.Send(event)
+}
+
+// flush sends the events buffered during the replay and then delivers events directly.
+func (s *sseSubscriber) flush() {
+	for {
+		s.mu.Lock()
+		pending := s.pending
+		s.pending = nil
+		if len(pending) == 0 {
+			s.replaying = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		for _, event := range pending {
+			_ = s.stream.Send(event)
+		}
+	}
+}
+
+// subscribe subscribes to subject unless it already is.
+func (b *SSEBridge) subscribe(subject string) blame.Blame {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subjects[subject]; ok {
+		return nil
+	}
+	sub := &sseSubject{streams: make(map[*sseSubscriber]struct{})}
+	err := b.broker.Subscribe(subject, func(_ context.Context, msg *events.Message) blame.Blame {
+		b.dispatch(sub, msg)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b.subjects[subject] = sub
+	return nil
+}
+
+// dispatch sends msg to the streams of sub and keeps it for replays.
+func (b *SSEBridge) dispatch(sub *sseSubject, msg *events.Message) {
+	event := SSEEvent{ID: msg.MessageID(), Event: msg.Subject, Data: json.RawMessage(msg.Data)}
+	if !json.Valid(msg.Data) {
+		event.Data = string(msg.Data)
+	}
+	b.mu.Lock()
+	if b.replay > 0 && event.ID != "" {
+		sub.recent = append(sub.recent, event)
+		sub.messages = append(sub.messages, msg)
+		if len(sub.recent) > b.replay {
+			sub.recent = sub.recent[len(sub.recent)-b.replay:]
+			sub.messages = sub.messages[len(sub.messages)-b.replay:]
+		}
+	}
+	subscribers := make([]*sseSubscriber, 0, len(sub.streams))
+	for subscriber := range sub.streams {
+		subscribers = append(subscribers, subscriber)
+	}
+	b.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		select {
+		case <-subscriber.stream.Done():
+			// The stream ended and is leaving the subject
+			continue
+		default:
+		}
+		if subscriber.accepts(msg) {
+			subscriber.deliver(event)
+		}
+	}
+}
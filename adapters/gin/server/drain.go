@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainerKey is the gin context key the Drainer is stored under.
+const drainerKey = "server_drainer"
+
+// Drainer coordinates the shutdown of long-lived connections that http.Server.Shutdown does
+// not handle well: hijacked WebSocket connections are not tracked by the server at all and
+// SSE streams keep their request active until the drain deadline.
+//
+// Handlers register a notify function when such a connection starts and call the returned
+// done function when it ends. On shutdown every notify function is called, e.g. to send a
+// WebSocket close frame with status 1001 (going away) or a final SSE event, and the server
+// waits for the connections to finish until the graceful timeout expires.
+type Drainer struct {
+	mu       sync.Mutex
+	notifies map[int]func(ctx context.Context)
+	next     int
+	wg       sync.WaitGroup
+	draining chan struct{}
+	once     sync.Once
+}
+
+// NewDrainer creates a Drainer.
+func NewDrainer() *Drainer {
+	return &Drainer{
+		notifies: map[int]func(ctx context.Context){},
+		draining: make(chan struct{}),
+	}
+}
+
+// Register tracks a long-lived connection. notify is called once when shutdown starts, or
+// immediately when it already has; done must be called when the connection ends.
+func (d *Drainer) Register(notify func(ctx context.Context)) (done func()) {
+	d.mu.Lock()
+	id := d.next
+	d.next++
+	d.wg.Add(1)
+	select {
+	case <-d.draining:
+		d.mu.Unlock()
+		if notify != nil {
+			go notify(context.Background())
+		}
+	default:
+		d.notifies[id] = notify
+		d.mu.Unlock()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			delete(d.notifies, id)
+			d.mu.Unlock()
+			d.wg.Done()
+		})
+	}
+}
+
+// Draining returns a channel closed when shutdown starts, so streaming handlers can stop.
+func (d *Drainer) Draining() <-chan struct{} {
+	return d.draining
+}
+
+// Drain notifies every registered connection and waits for them to finish or ctx to end.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.once.Do(func() {
+		d.mu.Lock()
+		close(d.draining)
+		notifies := make([]func(ctx context.Context), 0, len(d.notifies))
+		for _, notify := range d.notifies {
+			if notify != nil {
+				notifies = append(notifies, notify)
+			}
+		}
+		d.mu.Unlock()
+		for _, notify := range notifies {
+			go notify(ctx)
+		}
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Middleware makes the Drainer available to handlers through GetDrainer.
+func (d *Drainer) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(drainerKey, d)
+		c.Next()
+	}
+}
+
+// GetDrainer returns the Drainer of the server handling the request.
+func GetDrainer(c *gin.Context) (*Drainer, bool) {
+	value, ok := c.Get(drainerKey)
+	if !ok {
+		return nil, false
+	}
+	d, ok := value.(*Drainer)
+	return d, ok
+}
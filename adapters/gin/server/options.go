@@ -168,6 +168,51 @@ func configureRouteGroups(baseGroup *gin.RouterGroup, routeGroups []RouteGroupCo
 	}
 }
 
+// WithH2C serves cleartext HTTP/2 with prior knowledge alongside HTTP/1.1, as used between
+// services inside a mesh that terminates TLS at the sidecar
+func WithH2C() ServerOption {
+	return func(o *ServerOptions) {
+		o.h2c = true
+	}
+}
+
+// WithReadTimeout sets the maximum duration for reading an entire request, including the body
+func WithReadTimeout(timeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.readTimeout = timeout
+	}
+}
+
+// WithReadHeaderTimeout sets the maximum duration for reading request headers
+func WithReadHeaderTimeout(timeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.readHeaderTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the maximum duration before timing out writes of the response.
+// Leave it unset when serving SSE or other streaming responses
+func WithWriteTimeout(timeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.writeTimeout = timeout
+	}
+}
+
+// WithIdleTimeout sets how long keep-alive connections are kept open between requests
+func WithIdleTimeout(timeout time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithDrainer sets the Drainer notified of shutdown, so long-lived connections registered by
+// handlers can be closed cleanly. A Drainer is created when none is supplied
+func WithDrainer(drainer *Drainer) ServerOption {
+	return func(o *ServerOptions) {
+		o.drainer = drainer
+	}
+}
+
 // WithLogger sets the logger for the server
 func WithLogger(log *log.Log) ServerOption {
 	return func(o *ServerOptions) {
@@ -1,17 +1,32 @@
 package server
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/blame"
 	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/diagnostics"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/gin-gonic/gin"
 )
 
-// StartServer initializes and starts the server based on the provided options
+// Server is a Gin HTTP server built from ServerOptions whose lifecycle is driven by the caller,
+// e.g. the neuron server runtime. StartServer runs one until SIGINT or SIGTERM.
+type Server struct {
+	srv     *http.Server
+	router  *gin.Engine
+	options *ServerOptions
+}
 
-func StartServer(opts ...ServerOption) error {
+// NewServer builds the router and the HTTP server of the provided options without serving.
+func NewServer(opts ...ServerOption) (*Server, error) {
 
 	// Merge options into a single ServerOptions instance
 	options := DefaultServerOptions()
@@ -19,7 +34,7 @@ func StartServer(opts ...ServerOption) error {
 		opt(options)
 	}
 	if options.log == nil {
-		return errors.New("logger is nil, you need to provide a logger")
+		return nil, errors.New("logger is nil, you need to provide a logger")
 	}
 
 	if helpers.IsProdEnvironment() {
@@ -31,6 +46,11 @@ func StartServer(opts ...ServerOption) error {
 	//gin.Logger()
 	router.Use(gin.Recovery())
 
+	if options.drainer == nil {
+		options.drainer = NewDrainer()
+	}
+	router.Use(options.drainer.Middleware())
+
 	// apply serve static
 	applyServeStatic(router, options.serveStatic)
 
@@ -53,20 +73,124 @@ func StartServer(opts ...ServerOption) error {
 		configureRouteGroups(baseGroup, options.RouteGroups)
 	}
 
+	// Mount single page applications after the API routes
+	for _, spa := range options.spas {
+		MountSPA(router, spa.prefix, spa.fsys, spa.options...)
+	}
+
 	port, err := helpers.GetAvailablePort(constant.TCP, options.port)
 	if err != nil {
 		helpers.Println(constant.ERROR, blame.ErrorServerStartFailed.String()+"\n"+err.Error())
-		return err
+		return nil, err
 	}
 
 	if port != options.port {
 		helpers.Println(constant.WARN, "Server will be running on ["+port+"] port, as configured port: "+options.port+" is not available")
 	}
 
-	// Start the server
-	if err := router.Run(":" + port); err != nil {
+	return &Server{srv: newHTTPServer(":"+port, router, options), router: router, options: options}, nil
+}
+
+// Router returns the Gin engine of the server.
+func (s *Server) Router() *gin.Engine {
+	return s.router
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.srv.Addr
+}
+
+// ListenAndServe serves until Shutdown is called, returning nil once it is.
+func (s *Server) ListenAndServe() error {
+	diagnostics.RegisterListener("http", s.srv.Addr)
+	if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		helpers.Println(constant.ERROR, blame.ErrorServerStartFailed.String()+"\n"+err.Error())
 		return errors.New(blame.ErrorServerStartFailed.String())
 	}
 	return nil
 }
+
+// Shutdown stops the server gracefully within ctx, see gracefulShutdown.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return gracefulShutdown(ctx, s.srv, s.options)
+}
+
+// StartServer initializes and starts the server based on the provided options, and shuts it
+// down gracefully on SIGINT or SIGTERM.
+func StartServer(opts ...ServerOption) error {
+	s, err := NewServer(opts...)
+	if err != nil {
+		return err
+	}
+
+	// Start the server
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.ListenAndServe()
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-quit:
+		s.options.log.Info("Shutdown signal received", log.String("signal", sig.String()))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.options.gracefulTimeOut)
+	defer cancel()
+	return s.Shutdown(ctx)
+}
+
+// newHTTPServer builds the http.Server serving router with the configured timeouts and
+// protocols
+func newHTTPServer(addr string, router http.Handler, options *ServerOptions) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadTimeout:       options.readTimeout,
+		ReadHeaderTimeout: options.readHeaderTimeout,
+		WriteTimeout:      options.writeTimeout,
+		IdleTimeout:       options.idleTimeout,
+	}
+	if options.h2c {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		srv.Protocols = protocols
+	}
+	return srv
+}
+
+// gracefulShutdown stops accepting connections, closes idle keep-alive connections, notifies
+// long-lived WebSocket and SSE connections registered with the Drainer and waits for
+// in-flight requests until ctx is done, after which remaining connections are closed
+func gracefulShutdown(ctx context.Context, srv *http.Server, options *ServerOptions) error {
+	timeout := options.gracefulTimeOut
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline).Round(time.Millisecond)
+	}
+	options.log.Info("Gracefully Shutting down server......", log.String("timeout", timeout.String()))
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- options.drainer.Drain(ctx)
+	}()
+
+	err := srv.Shutdown(ctx)
+	if drainErr := <-drained; drainErr != nil {
+		options.log.Warn("Long-lived connections did not close before the drain deadline", log.Err(drainErr))
+	}
+	if err != nil {
+		options.log.Warn("Graceful shutdown timed out, closing remaining connections", log.Err(err))
+		_ = srv.Close()
+	}
+
+	options.log.Info(constant.ConnectionClosed, log.Any("message", "Server stopped"))
+	_ = options.log.Sync()
+	return nil
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHashPattern matches file names produced by bundlers with content hashes, e.g.
+// index-BX3kd9aZ.js, main.3f2a9c1e.css or chunk.a1b2c3d4e5.js.
+var defaultHashPattern = regexp.MustCompile(`[.-][A-Za-z0-9_]{8,}\.[a-z0-9]+$`)
+
+// precompressed lists the encodings served from pre-compressed siblings, in preference order
+var precompressed = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// SPAOption configures SPA serving
+type SPAOption func(*spaConfig)
+
+type spaConfig struct {
+	index       string
+	hashPattern *regexp.Regexp
+	fallback    bool
+}
+
+// WithSPAIndex sets the document served for client-side routes. Defaults to index.html
+func WithSPAIndex(name string) SPAOption {
+	return func(c *spaConfig) {
+		c.index = strings.TrimPrefix(name, "/")
+	}
+}
+
+// WithSPAHashPattern sets the pattern identifying content-hashed assets, which are cached
+// for a year as immutable
+func WithSPAHashPattern(pattern *regexp.Regexp) SPAOption {
+	return func(c *spaConfig) {
+		c.hashPattern = pattern
+	}
+}
+
+// WithoutSPAFallback disables history-mode fallback, so unknown paths return 404
+func WithoutSPAFallback() SPAOption {
+	return func(c *spaConfig) {
+		c.fallback = false
+	}
+}
+
+// spaMount is an SPA registered through WithSPA
+type spaMount struct {
+	prefix  string
+	fsys    fs.FS
+	options []SPAOption
+}
+
+// WithSPA serves the single page application in fsys, e.g. an embedded build directory
+// obtained with fs.Sub, under prefix
+func WithSPA(prefix string, fsys fs.FS, options ...SPAOption) ServerOption {
+	return func(o *ServerOptions) {
+		o.spas = append(o.spas, spaMount{prefix: prefix, fsys: fsys, options: options})
+	}
+}
+
+// MountSPA serves the single page application in fsys under prefix.
+//
+// Files are served with their pre-compressed .br or .gz sibling when one exists and the client
+// accepts it. Content-hashed assets are cached as immutable while other files, including the
+// index, must be revalidated using their ETag. Requests for paths without a file extension
+// that accept HTML fall back to the index so client-side routing works on reload.
+func MountSPA(router *gin.Engine, prefix string, fsys fs.FS, options ...SPAOption) {
+	handler := SPAHandler(prefix, fsys, options...)
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		// A catch-all at the root would conflict with API routes.
+		router.NoRoute(handler)
+		return
+	}
+	router.GET(prefix, handler)
+	router.HEAD(prefix, handler)
+	router.GET(prefix+"/*filepath", handler)
+	router.HEAD(prefix+"/*filepath", handler)
+}
+
+// SPAHandler returns a handler serving the single page application in fsys for requests
+// under prefix. See MountSPA.
+func SPAHandler(prefix string, fsys fs.FS, options ...SPAOption) gin.HandlerFunc {
+	config := spaConfig{index: "index.html", hashPattern: defaultHashPattern, fallback: true}
+	for _, opt := range options {
+		opt(&config)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	etags := &sync.Map{}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(c.Request.URL.Path, prefix)), "/")
+		if name == "" {
+			name = config.index
+		}
+		if !isFile(fsys, name) {
+			if !config.fallback || path.Ext(name) != "" || !acceptsHTML(c.Request) {
+				c.Status(http.StatusNotFound)
+				return
+			}
+			name = config.index
+		}
+
+		if config.hashPattern != nil && config.hashPattern.MatchString(name) {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			c.Header("Cache-Control", "no-cache")
+		}
+		serveFile(c, fsys, name, etags)
+	}
+}
+
+// serveFile writes name, or a pre-compressed variant accepted by the client
+func serveFile(c *gin.Context, fsys fs.FS, name string, etags *sync.Map) {
+	served := name
+	c.Writer.Header().Add("Vary", "Accept-Encoding")
+	accepted := c.GetHeader("Accept-Encoding")
+	for _, variant := range precompressed {
+		if strings.Contains(accepted, variant.encoding) && isFile(fsys, name+variant.extension) {
+			served = name + variant.extension
+			c.Header("Content-Encoding", variant.encoding)
+			break
+		}
+	}
+
+	data, err := fs.ReadFile(fsys, served)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+
+	etag, ok := etags.Load(served)
+	if !ok {
+		sum := sha256.Sum256(data)
+		etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+		etags.Store(served, etag)
+	}
+	c.Header("ETag", etag.(string))
+	http.ServeContent(c.Writer, c.Request, name, time.Time{}, bytes.NewReader(data))
+}
+
+func isFile(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && !info.IsDir()
+}
+
+func acceptsHTML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return accept == "" || strings.Contains(accept, "text/html") || strings.Contains(accept, "*/*")
+}
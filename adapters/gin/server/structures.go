@@ -21,6 +21,13 @@ type ServerOptions struct {
 	// A custom routing configurator allows complete control over route registration.
 	RoutingConfigurator func(*gin.Engine)
 	log                 *log.Log
+	h2c                 bool
+	readTimeout         time.Duration
+	readHeaderTimeout   time.Duration
+	writeTimeout        time.Duration
+	idleTimeout         time.Duration
+	drainer             *Drainer
+	spas                []spaMount
 }
 
 // DefaultServerOptions returns the default server options
@@ -33,6 +40,8 @@ func DefaultServerOptions() *ServerOptions {
 		gracefulTimeOut:   time.Duration(10) * time.Second, // Default TimeOut
 		GlobalMiddlewares: []gin.HandlerFunc{},
 		RouteGroups:       []RouteGroupConfig{},
+		readHeaderTimeout: 10 * time.Second,
+		idleTimeout:       120 * time.Second,
 	}
 }
 
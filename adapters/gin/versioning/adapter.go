@@ -0,0 +1,117 @@
+package versioning
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Adapter rewrites a response body decoded into generic JSON values (maps, slices, strings,
+// float64 numbers) from the shape of the next newer version into the shape of its own
+// version, e.g. renaming a field that changed in the newer version.
+type Adapter func(body any) (any, error)
+
+// JSON writes body, shaped for the newest version, as JSON in the shape of the version
+// resolved for the request. The adapters of every version newer than the requested one are
+// applied in turn, newest first, so handlers only ever produce the latest shape.
+func (v *Versioner) JSON(c *gin.Context, status int, body any) {
+	adapted, err := v.Adapt(GetVersion(c), body)
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to render response for API version"})
+		return
+	}
+	c.JSON(status, adapted)
+}
+
+// Adapt converts body from the newest version's shape to the shape of version.
+func (v *Versioner) Adapt(version string, body any) (any, error) {
+	target := -1
+	for i, known := range v.versions {
+		if known.Name == version {
+			target = i
+			break
+		}
+	}
+	if target < 0 || !v.hasAdapters(target) {
+		return body, nil
+	}
+
+	generic, err := toGeneric(body)
+	if err != nil {
+		return nil, err
+	}
+	// The adapter of version i converts the shape of version i+1 into the shape of version i.
+	for i := len(v.versions) - 2; i >= target; i-- {
+		if adapter := v.versions[i].Adapter; adapter != nil {
+			if generic, err = adapter(generic); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return generic, nil
+}
+
+// hasAdapters reports whether any version from target up to the newest has an adapter.
+func (v *Versioner) hasAdapters(target int) bool {
+	for _, version := range v.versions[target : len(v.versions)-1] {
+		if version.Adapter != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// toGeneric round-trips body through JSON so adapters work on plain maps and slices.
+func toGeneric(body any) (any, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// RenameField returns an Adapter that renames a top-level field of object bodies, or of the
+// result field of acknowledgment.APIResponse envelopes.
+func RenameField(from, to string) Adapter {
+	return func(body any) (any, error) {
+		object := resultObject(body)
+		if object != nil {
+			if value, ok := object[from]; ok {
+				delete(object, from)
+				object[to] = value
+			}
+		}
+		return body, nil
+	}
+}
+
+// RemoveField returns an Adapter that drops a top-level field added in the newer version.
+func RemoveField(name string) Adapter {
+	return func(body any) (any, error) {
+		if object := resultObject(body); object != nil {
+			delete(object, name)
+		}
+		return body, nil
+	}
+}
+
+// resultObject returns the object an adapter should edit: the result of an APIResponse
+// envelope when present, else the body itself.
+func resultObject(body any) map[string]any {
+	object, ok := body.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if _, isEnvelope := object["success"]; isEnvelope {
+		if result, ok := object["result"].(map[string]any); ok {
+			return result
+		}
+	}
+	return object
+}
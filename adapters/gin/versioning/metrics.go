@@ -0,0 +1,40 @@
+package versioning
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics counts requests per API version, so traffic still reaching deprecated versions is
+// visible before they are retired.
+type Metrics struct {
+	requests *prometheus.CounterVec
+}
+
+// NewMetrics registers the versioning collectors with registerer. An already registered
+// collector is reused.
+func NewMetrics(registerer prometheus.Registerer) (*Metrics, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_api_version_requests_total",
+		Help: "Number of HTTP requests per API version.",
+	}, []string{"version", "path", "deprecated"})
+
+	if err := registerer.Register(requests); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			return nil, err
+		}
+		existing, ok := already.ExistingCollector.(*prometheus.CounterVec)
+		if !ok {
+			return nil, err
+		}
+		requests = existing
+	}
+	return &Metrics{requests: requests}, nil
+}
+
+func (m *Metrics) observe(version, path string, deprecated bool) {
+	m.requests.WithLabelValues(version, path, strconv.FormatBool(deprecated)).Inc()
+}
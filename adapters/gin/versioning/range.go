@@ -0,0 +1,92 @@
+package versioning
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route serves the versions of an inclusive range with a handler. An empty bound leaves that
+// end of the range open.
+type Route struct {
+	From    string
+	To      string
+	Handler gin.HandlerFunc
+}
+
+// Since serves from and every newer version with handler.
+func Since(from string, handler gin.HandlerFunc) Route {
+	return Route{From: from, Handler: handler}
+}
+
+// Until serves to and every older version with handler.
+func Until(to string, handler gin.HandlerFunc) Route {
+	return Route{To: to, Handler: handler}
+}
+
+// Between serves the versions from from to to, both included, with handler.
+func Between(from, to string, handler gin.HandlerFunc) Route {
+	return Route{From: from, To: to, Handler: handler}
+}
+
+// Only serves the single version name with handler.
+func Only(name string, handler gin.HandlerFunc) Route {
+	return Route{From: name, To: name, Handler: handler}
+}
+
+// HandleRanges registers one route whose handler is chosen by the version range the requested
+// version falls in, e.g.
+//
+//	versioner.HandleRanges(router, http.MethodGet, "/users/:id",
+//		versioning.Until("v1", getUserV1),
+//		versioning.Since("v2", getUser),
+//	)
+//
+// Deprecated versions get their deprecation headers as with Middleware.
+func (v *Versioner) HandleRanges(router gin.IRouter, method, relativePath string, routes ...Route) gin.IRoutes {
+	return router.Handle(method, relativePath, v.Middleware(), v.Dispatch(routes...))
+}
+
+// Dispatch returns a handler calling the handler of the first route whose range holds the
+// version resolved by Middleware or Group. Versions outside every range get 404 Not Found.
+func (v *Versioner) Dispatch(routes ...Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handler := v.routeFor(GetVersion(c), routes); handler != nil {
+			handler(c)
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Route is not available in this API version"})
+	}
+}
+
+// routeFor returns the handler of the first route holding name.
+func (v *Versioner) routeFor(name string, routes []Route) gin.HandlerFunc {
+	position, ok := v.position(name)
+	if !ok {
+		return nil
+	}
+	for _, route := range routes {
+		if route.From != "" {
+			if from, ok := v.position(route.From); !ok || position < from {
+				continue
+			}
+		}
+		if route.To != "" {
+			if to, ok := v.position(route.To); !ok || position > to {
+				continue
+			}
+		}
+		return route.Handler
+	}
+	return nil
+}
+
+// position returns the index of the version name, oldest first.
+func (v *Versioner) position(name string) (int, bool) {
+	for i, version := range v.versions {
+		if version.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
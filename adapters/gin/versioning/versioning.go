@@ -0,0 +1,389 @@
+// Package versioning provides version-aware Gin routing. Versions are selected by a path
+// prefix (/v1, /v2) or by a request header, deprecated versions advertise the Deprecation,
+// Sunset and Link headers, and usage per version is counted so old clients can be tracked
+// down before a version is retired.
+package versioning
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultHeader is the request header naming the version when no Versioner sets another.
+const DefaultHeader = constant.XAPIVersion
+
+// Strategy selects how the requested version is read.
+type Strategy int
+
+const (
+	// StrategyPath reads the version from the route group prefix, e.g. /v1/users.
+	StrategyPath Strategy = iota
+	// StrategyHeader reads the version from a request header or the version parameter of the
+	// Accept header, e.g. "application/json; version=v2". Requests without one get the default
+	// version.
+	StrategyHeader
+)
+
+// Version describes an API version and its lifecycle.
+type Version struct {
+	Name         string
+	DeprecatedAt time.Time
+	Sunset       time.Time
+	// Link points clients at migration docs; it is sent as a deprecation link relation.
+	Link string
+	// Adapter converts a response body shaped for the next newer version into this version's
+	// shape. See JSON.
+	Adapter Adapter
+}
+
+// Deprecated reports whether the version is deprecated at now.
+func (v *Version) Deprecated(now time.Time) bool {
+	return !v.DeprecatedAt.IsZero() && !now.Before(v.DeprecatedAt)
+}
+
+// Retired reports whether the sunset date of the version has passed at now.
+func (v *Version) Retired(now time.Time) bool {
+	return !v.Sunset.IsZero() && !now.Before(v.Sunset)
+}
+
+// VersionOption configures a Version.
+type VersionOption func(*Version)
+
+// WithDeprecation marks the version as deprecated from at, with an optional sunset date.
+func WithDeprecation(at, sunset time.Time) VersionOption {
+	return func(v *Version) {
+		v.DeprecatedAt = at
+		v.Sunset = sunset
+	}
+}
+
+// WithLink sets the documentation link sent with deprecation headers.
+func WithLink(link string) VersionOption {
+	return func(v *Version) {
+		v.Link = link
+	}
+}
+
+// WithAdapter sets the response adapter of the version.
+func WithAdapter(adapter Adapter) VersionOption {
+	return func(v *Version) {
+		v.Adapter = adapter
+	}
+}
+
+// NewVersion creates a Version.
+func NewVersion(name string, options ...VersionOption) *Version {
+	v := &Version{Name: name}
+	for _, opt := range options {
+		opt(v)
+	}
+	return v
+}
+
+// Versioner resolves the API version of requests and applies the version lifecycle.
+type Versioner struct {
+	versions       []*Version
+	byName         map[string]*Version
+	strategy       Strategy
+	header         string
+	defaultVersion string
+	rejectRetired  bool
+	metrics        *Metrics
+	logger         *log.Log
+	now            func() time.Time
+}
+
+// Option configures a Versioner.
+type Option func(*Versioner)
+
+// WithStrategy sets how the version is selected. Defaults to StrategyPath.
+func WithStrategy(strategy Strategy) Option {
+	return func(v *Versioner) {
+		v.strategy = strategy
+	}
+}
+
+// WithHeader sets the header read by StrategyHeader. Defaults to DefaultHeader.
+func WithHeader(header string) Option {
+	return func(v *Versioner) {
+		v.header = header
+	}
+}
+
+// WithDefaultVersion sets the version used when a request names none. Defaults to the newest
+// version.
+func WithDefaultVersion(name string) Option {
+	return func(v *Versioner) {
+		v.defaultVersion = name
+	}
+}
+
+// WithRejectRetired answers requests to versions past their sunset date with 410 Gone.
+func WithRejectRetired() Option {
+	return func(v *Versioner) {
+		v.rejectRetired = true
+	}
+}
+
+// WithMetrics counts requests per version.
+func WithMetrics(metrics *Metrics) Option {
+	return func(v *Versioner) {
+		v.metrics = metrics
+	}
+}
+
+// WithLogger sets the logger used to report calls to deprecated versions.
+func WithLogger(logger *log.Log) Option {
+	return func(v *Versioner) {
+		v.logger = logger
+	}
+}
+
+// NewVersioner creates a Versioner for versions, listed from oldest to newest.
+func NewVersioner(versions []*Version, options ...Option) *Versioner {
+	v := &Versioner{
+		versions: versions,
+		byName:   make(map[string]*Version, len(versions)),
+		header:   DefaultHeader,
+		logger:   log.NewBasicLogger(helpers.IsProdEnvironment(), true),
+		now:      time.Now,
+	}
+	for _, version := range versions {
+		v.byName[version.Name] = version
+	}
+	if len(versions) > 0 {
+		v.defaultVersion = versions[len(versions)-1].Name
+	}
+	for _, opt := range options {
+		opt(v)
+	}
+	return v
+}
+
+// Versions returns the known versions from oldest to newest.
+func (v *Versioner) Versions() []*Version {
+	return v.versions
+}
+
+// Lookup returns the version named name.
+func (v *Versioner) Lookup(name string) (*Version, bool) {
+	version, ok := v.byName[name]
+	return version, ok
+}
+
+// Group returns a route group for version. With StrategyPath the group is mounted under
+// /<version>; with StrategyHeader it shares the router's paths, so use Handle to register
+// routes served by several versions.
+func (v *Versioner) Group(router gin.IRouter, version string, handlers ...gin.HandlerFunc) *gin.RouterGroup {
+	relativePath := ""
+	if v.strategy == StrategyPath {
+		relativePath = "/" + version
+	}
+	handlers = append([]gin.HandlerFunc{v.pinned(version)}, handlers...)
+	return router.Group(relativePath, handlers...)
+}
+
+// Handle registers one route whose handler is chosen by the requested version. Versions
+// without an entry in handlers fall back to the newest older version that has one, so a
+// handler only needs to be registered for the version that changed it.
+func (v *Versioner) Handle(router gin.IRouter, method, relativePath string, handlers map[string]gin.HandlerFunc) gin.IRoutes {
+	return router.Handle(method, relativePath, v.Middleware(), func(c *gin.Context) {
+		handler := v.handlerFor(GetVersion(c), handlers)
+		if handler == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Route is not available in this API version"})
+			return
+		}
+		handler(c)
+	})
+}
+
+// Middleware resolves the requested version with the configured strategy and applies its
+// lifecycle. Unknown versions are rejected with 400 Bad Request.
+func (v *Versioner) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(constant.APIVersion); ok {
+			// Already resolved by a group or an outer middleware.
+			c.Next()
+			return
+		}
+		name := v.resolve(c)
+		version, ok := v.byName[name]
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Unsupported API version"})
+			return
+		}
+		v.apply(c, version)
+	}
+}
+
+// pinned applies the lifecycle of a fixed version, as used by path groups.
+func (v *Versioner) pinned(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v.strategy == StrategyHeader {
+			// Header routed groups share paths; resolve from the request instead.
+			v.Middleware()(c)
+			return
+		}
+		version, ok := v.byName[name]
+		if !ok {
+			version = &Version{Name: name}
+		}
+		v.apply(c, version)
+	}
+}
+
+// apply records the version on the context, writes deprecation headers and counts the call.
+func (v *Versioner) apply(c *gin.Context, version *Version) {
+	now := v.now()
+	c.Set(constant.APIVersion, version.Name)
+	c.Header(v.header, version.Name)
+
+	deprecated := version.Deprecated(now)
+	if v.metrics != nil {
+		v.metrics.observe(version.Name, c.FullPath(), deprecated)
+	}
+
+	if version.Retired(now) && v.rejectRetired {
+		writeDeprecationHeaders(c, version)
+		c.AbortWithStatusJSON(http.StatusGone, gin.H{"error": "API version " + version.Name + " has been retired"})
+		return
+	}
+	if deprecated {
+		writeDeprecationHeaders(c, version)
+		v.logger.Debug("deprecated API version called",
+			log.String(constant.APIVersion, version.Name),
+			log.String("path", c.FullPath()),
+			log.String("user_agent", c.Request.UserAgent()))
+	}
+	c.Next()
+}
+
+// resolve returns the version named by the request, or the default version.
+func (v *Versioner) resolve(c *gin.Context) string {
+	switch v.strategy {
+	case StrategyHeader:
+		if name := ParseVersion(c, v.header); name != "" {
+			return name
+		}
+	default:
+		segment, _, _ := strings.Cut(strings.TrimPrefix(c.Request.URL.Path, "/"), "/")
+		if _, ok := v.byName[segment]; ok {
+			return segment
+		}
+	}
+	return v.defaultVersion
+}
+
+// handlerFor returns the handler registered for name or the closest older version.
+func (v *Versioner) handlerFor(name string, handlers map[string]gin.HandlerFunc) gin.HandlerFunc {
+	found := false
+	for i := len(v.versions) - 1; i >= 0; i-- {
+		if v.versions[i].Name == name {
+			found = true
+		}
+		if found {
+			if handler, ok := handlers[v.versions[i].Name]; ok {
+				return handler
+			}
+		}
+	}
+	return nil
+}
+
+// GetVersion returns the API version resolved for the request.
+func GetVersion(c *gin.Context) string {
+	return c.GetString(constant.APIVersion)
+}
+
+// writeDeprecationHeaders sets the Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers.
+func writeDeprecationHeaders(c *gin.Context, version *Version) {
+	if !version.DeprecatedAt.IsZero() {
+		c.Header("Deprecation", "@"+strconv.FormatInt(version.DeprecatedAt.Unix(), 10))
+	}
+	if !version.Sunset.IsZero() {
+		c.Header("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if version.Link != "" {
+		c.Writer.Header().Add("Link", "<"+version.Link+`>; rel="deprecation"`)
+	}
+}
+
+// RequestedVersion returns the API version requested by c, or "" when the request names none.
+// The version resolved by a Versioner wins; otherwise it is read from a /v<major> path prefix
+// or with ParseVersion from DefaultHeader.
+func RequestedVersion(c *gin.Context) string {
+	if version := GetVersion(c); version != "" {
+		return version
+	}
+	segment, _, _ := strings.Cut(strings.TrimPrefix(c.Request.URL.Path, "/"), "/")
+	if isVersion(segment) {
+		return segment
+	}
+	return ParseVersion(c, DefaultHeader)
+}
+
+// ParseVersion returns the version named by the header of c or, failing that, by the Accept
+// header, either as a version parameter ("application/json; version=2") or a vendor media type
+// ("application/vnd.acme.v2+json"). Numeric versions are normalised to "v<major>". It returns ""
+// when the request names none.
+func ParseVersion(c *gin.Context, header string) string {
+	if name := strings.TrimSpace(c.GetHeader(header)); name != "" {
+		return normalise(name)
+	}
+	return normalise(acceptVersion(c.GetHeader("Accept")))
+}
+
+// acceptVersion extracts the version named by an Accept header.
+func acceptVersion(accept string) string {
+	for _, mediaType := range strings.Split(accept, ",") {
+		parts := strings.Split(mediaType, ";")
+		for _, param := range parts[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "version") {
+				if version := strings.TrimSpace(strings.Trim(value, `"`)); version != "" {
+					return version
+				}
+			}
+		}
+		// Vendor media types carry the version as a dot separated segment before the suffix.
+		subtype := strings.TrimSpace(parts[0])
+		subtype, _, _ = strings.Cut(subtype, "+")
+		if !strings.Contains(subtype, "/vnd.") {
+			continue
+		}
+		for _, segment := range strings.Split(subtype, ".") {
+			if isVersion(segment) {
+				return segment
+			}
+		}
+	}
+	return ""
+}
+
+// normalise accepts both "2" and "v2".
+func normalise(name string) string {
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		return "v" + name
+	}
+	return name
+}
+
+// isVersion reports whether segment has the form "v<major>".
+func isVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	for _, r := range segment[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
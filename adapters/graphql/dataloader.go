@@ -0,0 +1,206 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLoaderWait is how long a loader collects keys before fetching a batch.
+	DefaultLoaderWait = 2 * time.Millisecond
+	// DefaultLoaderMaxBatch caps the number of keys fetched at once.
+	DefaultLoaderMaxBatch = 100
+)
+
+// ErrNotFound is returned by Loader.Load when the batch function returns no value for a key.
+var ErrNotFound = errors.New("graphql: loader key not found")
+
+// BatchFunc fetches the values of keys in one round trip. Keys missing from the returned map
+// resolve to ErrNotFound.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches and caches lookups made while resolving a single request, avoiding N+1
+// queries. Create one per request, e.g. from a RequestContextFunc passed to Handler.
+type Loader[K comparable, V any] struct {
+	fetch    BatchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*loaderResult[V]
+	batch *loaderBatch[K, V]
+}
+
+// LoaderOption configures a Loader.
+type LoaderOption func(*loaderConfig)
+
+type loaderConfig struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+// WithLoaderWait sets how long keys are collected before a batch is fetched.
+func WithLoaderWait(wait time.Duration) LoaderOption {
+	return func(c *loaderConfig) {
+		c.wait = wait
+	}
+}
+
+// WithLoaderMaxBatch caps the number of keys per batch.
+func WithLoaderMaxBatch(size int) LoaderOption {
+	return func(c *loaderConfig) {
+		if size > 0 {
+			c.maxBatch = size
+		}
+	}
+}
+
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	results []*loaderResult[V]
+}
+
+// NewLoader creates a Loader around fetch.
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V], options ...LoaderOption) *Loader[K, V] {
+	config := loaderConfig{wait: DefaultLoaderWait, maxBatch: DefaultLoaderMaxBatch}
+	for _, opt := range options {
+		opt(&config)
+	}
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     config.wait,
+		maxBatch: config.maxBatch,
+		cache:    make(map[K]*loaderResult[V]),
+	}
+}
+
+// Load returns the value for key, fetching it with other keys requested in the same window.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	res := l.enqueue(ctx, key)
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany returns the values for keys in order. The first error is returned.
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	pending := make([]*loaderResult[V], len(keys))
+	for i, key := range keys {
+		pending[i] = l.enqueue(ctx, key)
+	}
+	values := make([]V, len(keys))
+	for i, res := range pending {
+		select {
+		case <-res.done:
+			if res.err != nil {
+				return nil, res.err
+			}
+			values[i] = res.value
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return values, nil
+}
+
+// Prime stores a known value, e.g. one returned by a mutation.
+func (l *Loader[K, V]) Prime(key K, value V) {
+	res := &loaderResult[V]{done: make(chan struct{}), value: value}
+	close(res.done)
+	l.mu.Lock()
+	l.cache[key] = res
+	l.mu.Unlock()
+}
+
+// Clear drops the cached value for key.
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	delete(l.cache, key)
+	l.mu.Unlock()
+}
+
+// enqueue returns the pending result for key, adding it to the current batch if needed.
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) *loaderResult[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if res, ok := l.cache[key]; ok {
+		return res
+	}
+	res := &loaderResult[V]{done: make(chan struct{})}
+	l.cache[key] = res
+
+	if l.batch == nil {
+		l.batch = &loaderBatch[K, V]{}
+		batch := l.batch
+		time.AfterFunc(l.wait, func() { l.dispatch(ctx, batch) })
+	}
+	l.batch.keys = append(l.batch.keys, key)
+	l.batch.results = append(l.batch.results, res)
+
+	if len(l.batch.keys) >= l.maxBatch {
+		batch := l.batch
+		l.batch = nil
+		go l.run(ctx, batch)
+	}
+	return res
+}
+
+// dispatch runs batch when its wait window ends, unless it was already sent for being full.
+func (l *Loader[K, V]) dispatch(ctx context.Context, batch *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if l.batch != batch {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+	l.run(ctx, batch)
+}
+
+// run fetches a batch and resolves its results. Failed lookups are evicted so they can be
+// retried.
+func (l *Loader[K, V]) run(ctx context.Context, batch *loaderBatch[K, V]) {
+	values, err := l.fetch(context.WithoutCancel(ctx), batch.keys)
+	for i, key := range batch.keys {
+		res := batch.results[i]
+		switch value, ok := values[key]; {
+		case err != nil:
+			res.err = err
+		case !ok:
+			res.err = ErrNotFound
+		default:
+			res.value = value
+		}
+		if res.err != nil {
+			l.Clear(key)
+		}
+		close(res.done)
+	}
+}
+
+// loaderKey identifies a loader attached to a context.
+type loaderKey string
+
+// WithLoader attaches loader to ctx under name.
+func WithLoader[K comparable, V any](ctx context.Context, name string, loader *Loader[K, V]) context.Context {
+	return context.WithValue(ctx, loaderKey(name), loader)
+}
+
+// GetLoader returns the loader attached under name.
+func GetLoader[K comparable, V any](ctx context.Context, name string) (*Loader[K, V], bool) {
+	loader, ok := ctx.Value(loaderKey(name)).(*Loader[K, V])
+	return loader, ok
+}
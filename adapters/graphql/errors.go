@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	gql "github.com/99designs/gqlgen/graphql"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorPresenter renders blame errors as GraphQL errors with the translated message and the
+// error details in extensions. Other resolver errors are masked in production.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := gql.DefaultErrorPresenter(ctx, err)
+
+	var cause blame.Blame
+	if errors.As(err, &cause) {
+		message, description := cause.Translate()
+		if message == "" {
+			message = cause.FetchErrCode().String()
+		}
+		gqlErr.Message = message
+		gqlErr.Extensions = map[string]any{
+			"code":          cause.FetchErrCode(),
+			"reason_code":   cause.FetchReasonCode(),
+			"description":   description,
+			"response_type": cause.FetchResponseType(),
+			"status":        helpers.FetchHTTPStatusCode(cause.FetchResponseType()),
+		}
+		if fields := cause.FetchFields(); len(fields) > 0 {
+			gqlErr.Extensions["fields"] = fields
+		}
+		return gqlErr
+	}
+
+	// Parser and validation errors are already client-facing.
+	var known *gqlerror.Error
+	if !errors.As(err, &known) && helpers.IsProdEnvironment() {
+		gqlErr.Message = "internal server error"
+		gqlErr.Extensions = map[string]any{"code": "INTERNAL_SERVER_ERROR"}
+	}
+	return gqlErr
+}
+
+// RecoverFunc turns a resolver panic into an internal server error.
+func RecoverFunc(_ context.Context, p any) error {
+	helpers.Println(constant.ERROR, "exception: occurred in graphql resolver", p, "stack:", string(debug.Stack()))
+	return blame.InternalServerError(fmt.Errorf("panic: %v", p))
+}
+
+// FromResult converts a result.Result to the (value, error) pair expected by resolvers, so
+// existing handlers can back GraphQL fields.
+func FromResult[T any](res result.Result[T]) (*T, error) {
+	value, cause := res.Value()
+	if !res.IsSuccess() {
+		return nil, cause
+	}
+	return value, nil
+}
@@ -0,0 +1,137 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	gql "github.com/99designs/gqlgen/graphql"
+	"github.com/redis/go-redis/v9"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// PersistedQueries serves operations registered ahead of time, referenced by the SHA-256 hash
+// clients send in the persistedQuery extension. In strict mode any other query is rejected,
+// turning the registered set into an allowlist.
+type PersistedQueries struct {
+	queries map[string]string
+	strict  bool
+}
+
+// NewPersistedQueries registers queries, keyed by their SHA-256 hash.
+func NewPersistedQueries(strict bool, queries ...string) *PersistedQueries {
+	p := &PersistedQueries{queries: make(map[string]string, len(queries)), strict: strict}
+	for _, query := range queries {
+		p.queries[hashQuery(query)] = query
+	}
+	return p
+}
+
+// LoadPersistedQueries reads a JSON manifest mapping hashes to queries, as produced by
+// persisted query generators. Hashes are verified against the queries.
+func LoadPersistedQueries(path string, strict bool) (*PersistedQueries, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("graphql: invalid persisted query manifest: %w", err)
+	}
+	p := &PersistedQueries{queries: make(map[string]string, len(manifest)), strict: strict}
+	for hash, query := range manifest {
+		if hashQuery(query) != hash {
+			return nil, fmt.Errorf("graphql: persisted query hash mismatch for %s", hash)
+		}
+		p.queries[hash] = query
+	}
+	return p, nil
+}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (p *PersistedQueries) ExtensionName() string {
+	return "PersistedQueries"
+}
+
+// Validate implements graphql.HandlerExtension.
+func (p *PersistedQueries) Validate(gql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationParameters resolves the query from its hash before parsing.
+func (p *PersistedQueries) MutateOperationParameters(_ context.Context, params *gql.RawParams) *gqlerror.Error {
+	hash := persistedQueryHash(params.Extensions)
+	if hash == "" {
+		if p.strict {
+			return persistedQueryError("PERSISTED_QUERY_REQUIRED", "only persisted queries are allowed")
+		}
+		return nil
+	}
+
+	query, ok := p.queries[hash]
+	if !ok {
+		if p.strict {
+			return persistedQueryError("PERSISTED_QUERY_NOT_ALLOWED", "persisted query is not registered")
+		}
+		// Leave unknown hashes to automatic persisted queries, when enabled.
+		return nil
+	}
+	if params.Query != "" && params.Query != query {
+		return persistedQueryError("PERSISTED_QUERY_MISMATCH", "query does not match its hash")
+	}
+	params.Query = query
+	return nil
+}
+
+// persistedQueryHash extracts extensions.persistedQuery.sha256Hash.
+func persistedQueryHash(extensions map[string]any) string {
+	pq, ok := extensions["persistedQuery"].(map[string]any)
+	if !ok {
+		return ""
+	}
+	hash, _ := pq["sha256Hash"].(string)
+	return hash
+}
+
+func persistedQueryError(code, message string) *gqlerror.Error {
+	return &gqlerror.Error{Message: message, Extensions: map[string]any{"code": code}}
+}
+
+func hashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedisQueryCache stores automatic persisted queries in Redis so every replica can serve a
+// hash registered through any of them.
+type RedisQueryCache struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisQueryCache creates a RedisQueryCache. prefix defaults to "neuron:graphql:apq".
+func NewRedisQueryCache(client redis.UniversalClient, prefix string, ttl time.Duration) *RedisQueryCache {
+	if prefix == "" {
+		prefix = "neuron:graphql:apq"
+	}
+	return &RedisQueryCache{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Get implements graphql.Cache.
+func (r *RedisQueryCache) Get(ctx context.Context, key string) (string, bool) {
+	query, err := r.client.Get(ctx, r.prefix+":"+key).Result()
+	if err != nil {
+		return "", false
+	}
+	return query, true
+}
+
+// Add implements graphql.Cache.
+func (r *RedisQueryCache) Add(ctx context.Context, key, value string) {
+	_ = r.client.Set(ctx, r.prefix+":"+key, value, r.ttl).Err()
+}
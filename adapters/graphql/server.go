@@ -0,0 +1,166 @@
+// Package graphql integrates gqlgen servers with neuron: resolvers receive the request's
+// ServiceContext, blame errors are rendered as GraphQL errors with extensions, and helpers are
+// provided for dataloaders and persisted queries.
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	gql "github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/abhissng/neuron/adapters/gin/middleware"
+	"github.com/abhissng/neuron/blame"
+	neuronctx "github.com/abhissng/neuron/context"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// DefaultQueryCacheSize is the number of parsed queries kept in memory.
+const DefaultQueryCacheSize = 1000
+
+// serviceContextKey stores the ServiceContext in the request context.
+type serviceContextKey struct{}
+
+// ServerConfig holds the gqlgen server settings.
+type ServerConfig struct {
+	introspection   bool
+	complexityLimit int
+	websocket       bool
+	apqCache        gql.Cache[string]
+	persisted       *PersistedQueries
+	extensions      []gql.HandlerExtension
+}
+
+// Option configures the server built by NewServer.
+type Option func(*ServerConfig)
+
+// WithIntrospection enables schema introspection. It is disabled by default in production.
+func WithIntrospection(enabled bool) Option {
+	return func(c *ServerConfig) {
+		c.introspection = enabled
+	}
+}
+
+// WithComplexityLimit rejects operations whose complexity exceeds limit.
+func WithComplexityLimit(limit int) Option {
+	return func(c *ServerConfig) {
+		c.complexityLimit = limit
+	}
+}
+
+// WithWebsocket enables subscriptions over websockets.
+func WithWebsocket() Option {
+	return func(c *ServerConfig) {
+		c.websocket = true
+	}
+}
+
+// WithAutomaticPersistedQueries enables Apollo automatic persisted queries backed by cache,
+// e.g. a RedisQueryCache shared by all replicas. A nil cache uses an in-memory LRU.
+func WithAutomaticPersistedQueries(cache gql.Cache[string]) Option {
+	return func(c *ServerConfig) {
+		if cache == nil {
+			cache = lru.New[string](DefaultQueryCacheSize)
+		}
+		c.apqCache = cache
+	}
+}
+
+// WithPersistedQueries serves operations from a registered set of queries. See
+// PersistedQueries for allowlist mode.
+func WithPersistedQueries(queries *PersistedQueries) Option {
+	return func(c *ServerConfig) {
+		c.persisted = queries
+	}
+}
+
+// WithExtension adds a gqlgen handler extension.
+func WithExtension(ext gql.HandlerExtension) Option {
+	return func(c *ServerConfig) {
+		c.extensions = append(c.extensions, ext)
+	}
+}
+
+// NewServer creates a gqlgen server for schema with the neuron error presenter and panic
+// recovery installed.
+func NewServer(schema gql.ExecutableSchema, options ...Option) *handler.Server {
+	config := ServerConfig{introspection: !helpers.IsProdEnvironment()}
+	for _, opt := range options {
+		opt(&config)
+	}
+
+	srv := handler.New(schema)
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	if config.websocket {
+		srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+	}
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](DefaultQueryCacheSize))
+	srv.SetErrorPresenter(ErrorPresenter)
+	srv.SetRecoverFunc(RecoverFunc)
+
+	if config.introspection {
+		srv.Use(extension.Introspection{})
+	}
+	if config.persisted != nil {
+		srv.Use(config.persisted)
+	}
+	if config.apqCache != nil {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: config.apqCache})
+	}
+	if config.complexityLimit > 0 {
+		srv.Use(extension.FixedComplexityLimit(config.complexityLimit))
+	}
+	for _, ext := range config.extensions {
+		srv.Use(ext)
+	}
+	return srv
+}
+
+// RequestContextFunc prepares the request context before the operation runs, e.g. to attach
+// per-request dataloaders.
+type RequestContextFunc func(ctx context.Context, svcCtx *neuronctx.ServiceContext) context.Context
+
+// Handler serves srv from gin, making the ServiceContext created by
+// middleware.ServiceContextMiddleware available to resolvers through GetServiceContext.
+func Handler(srv http.Handler, prepare ...RequestContextFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svcCtx, err := middleware.GetServiceContext(c)
+		if err != nil {
+			err := blame.ServiceContextFetchError(viper.GetString(constant.SupportEmail), err)
+			res := err.FetchErrorResponse(blame.WithTranslation())
+			c.AbortWithStatusJSON(http.StatusInternalServerError, acknowledgment.NewAPIResponse(false, "", res))
+			return
+		}
+
+		ctx := context.WithValue(c.Request.Context(), serviceContextKey{}, svcCtx)
+		for _, fn := range prepare {
+			ctx = fn(ctx, svcCtx)
+		}
+		srv.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+	}
+}
+
+// PlaygroundHandler serves the GraphiQL playground for the endpoint.
+func PlaygroundHandler(title, endpoint string) gin.HandlerFunc {
+	return gin.WrapH(playground.Handler(title, endpoint))
+}
+
+// GetServiceContext returns the ServiceContext of the request being resolved.
+func GetServiceContext(ctx context.Context) (*neuronctx.ServiceContext, bool) {
+	svcCtx, ok := ctx.Value(serviceContextKey{}).(*neuronctx.ServiceContext)
+	return svcCtx, ok
+}
@@ -0,0 +1,341 @@
+// Package grpcbridge exposes NATS request/reply subjects as gRPC methods and gRPC methods as
+// NATS subjects, translating headers, deadlines and blame errors. It lets legacy gRPC clients
+// reach NATS-native services, and NATS services reach gRPC backends, during a migration.
+//
+// Only unary methods are bridged. Messages are forwarded as raw protobuf bytes unless a route
+// is given message prototypes, in which case payloads are converted between protobuf and JSON.
+package grpcbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/structures/message"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultTimeout bounds a bridged call when neither the route nor the caller sets a deadline.
+const DefaultTimeout = 30 * time.Second
+
+// Route maps a gRPC method to a NATS subject.
+type Route struct {
+	// Method is the full gRPC method name, e.g. "/orders.v1.OrderService/GetOrder".
+	Method string
+	// Subject is the NATS request/reply subject.
+	Subject string
+	// Queue is the NATS queue group used when the route is served from NATS.
+	Queue string
+	// Timeout overrides the bridge default for this route.
+	Timeout time.Duration
+
+	request  proto.Message
+	response proto.Message
+}
+
+// NewRoute creates a route that forwards protobuf bytes unchanged.
+func NewRoute(method, subject string) Route {
+	return Route{Method: method, Subject: subject}
+}
+
+// NewJSONRoute creates a route whose NATS side speaks JSON. request and response are
+// prototypes of the gRPC messages, e.g. &orderspb.GetOrderRequest{}.
+func NewJSONRoute(method, subject string, request, response proto.Message) Route {
+	return Route{Method: method, Subject: subject, request: request, response: response}
+}
+
+// natsRequest converts a gRPC request to a NATS payload.
+func (r Route) natsRequest(data []byte) ([]byte, error) {
+	if r.request == nil {
+		return data, nil
+	}
+	return protoToJSON(r.request, data)
+}
+
+// grpcRequest converts a NATS payload to a gRPC request.
+func (r Route) grpcRequest(data []byte) ([]byte, error) {
+	if r.request == nil {
+		return data, nil
+	}
+	return jsonToProto(r.request, data)
+}
+
+// natsResponse converts a gRPC response to a NATS payload.
+func (r Route) natsResponse(data []byte) ([]byte, error) {
+	if r.response == nil {
+		return data, nil
+	}
+	return protoToJSON(r.response, data)
+}
+
+// grpcResponse converts a NATS reply to a gRPC response.
+func (r Route) grpcResponse(data []byte) ([]byte, error) {
+	if r.response == nil {
+		return data, nil
+	}
+	return jsonToProto(r.response, data)
+}
+
+// Bridge forwards calls between gRPC and NATS.
+type Bridge struct {
+	nc      *nats.Conn
+	codec   encoding.Codec
+	timeout time.Duration
+	logger  *log.Log
+
+	mu      sync.RWMutex
+	inbound map[string]Route
+	subs    []*nats.Subscription
+}
+
+// Option configures a Bridge.
+type Option func(*Bridge)
+
+// WithTimeout sets the default timeout of bridged calls.
+func WithTimeout(timeout time.Duration) Option {
+	return func(b *Bridge) {
+		if timeout > 0 {
+			b.timeout = timeout
+		}
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(b *Bridge) {
+		b.logger = logger
+	}
+}
+
+// NewBridge creates a Bridge on an established NATS connection, e.g. NATSManager.Conn().
+func NewBridge(nc *nats.Conn, options ...Option) (*Bridge, error) {
+	if nc == nil {
+		return nil, errors.New("grpcbridge: nats connection is required")
+	}
+	b := &Bridge{
+		nc:      nc,
+		codec:   newFrameCodec(),
+		timeout: DefaultTimeout,
+		inbound: make(map[string]Route),
+	}
+	for _, opt := range options {
+		opt(b)
+	}
+	if b.logger == nil {
+		b.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return b, nil
+}
+
+// ExposeNATS serves the routes' NATS subjects as gRPC methods on any server built with
+// ServerOptions.
+func (b *Bridge) ExposeNATS(routes ...Route) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, route := range routes {
+		b.inbound[route.Method] = route
+	}
+}
+
+// ServerOptions returns the options that let a gRPC server answer the methods registered
+// with ExposeNATS. Methods of services registered on the server itself take precedence.
+func (b *Bridge) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ForceServerCodec(b.codec),
+		grpc.UnknownServiceHandler(b.handleGRPC),
+	}
+}
+
+// handleGRPC forwards an unknown gRPC method to its NATS subject.
+func (b *Bridge) handleGRPC(_ any, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	b.mu.RLock()
+	route, ok := b.inbound[method]
+	b.mu.RUnlock()
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "method %s is not bridged", method)
+	}
+
+	var in Frame
+	if err := stream.RecvMsg(&in); err != nil {
+		return err
+	}
+	payload, err := route.natsRequest(in.Data)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	msg := nats.NewMsg(route.Subject)
+	msg.Data = payload
+	msg.Header = headersFromMetadata(md)
+	if msg.Header.Get(constant.CorrelationIDHeader) == "" {
+		msg.Header.Set(constant.CorrelationIDHeader, random.GenerateUUIDString())
+	}
+
+	ctx, cancel := withDeadline(stream.Context(), nil, b.routeTimeout(route))
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		msg.Header.Set(DeadlineHeader, strconv.FormatInt(deadline.UnixMilli(), 10))
+	}
+
+	reply, err := b.nc.RequestMsgWithContext(ctx, msg)
+	if err != nil {
+		b.logger.Warn("Bridged NATS request failed", log.String("method", method), log.String("subject", route.Subject), log.Err(err))
+		return statusFromNATSError(err)
+	}
+	if len(reply.Header) > 0 {
+		_ = stream.SetHeader(metadataFromHeaders(reply.Header))
+	}
+	if resp := replyError(reply); resp != nil {
+		trailer, err := statusFromErrorResponse(resp)
+		stream.SetTrailer(trailer)
+		return err
+	}
+
+	out, err := route.grpcResponse(reply.Data)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.SendMsg(&Frame{Data: out})
+}
+
+// ExposeGRPC subscribes to the routes' NATS subjects and forwards each request to its gRPC
+// method on conn. Subscriptions stay active until Close.
+func (b *Bridge) ExposeGRPC(conn grpc.ClientConnInterface, routes ...Route) error {
+	if conn == nil {
+		return errors.New("grpcbridge: grpc connection is required")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, route := range routes {
+		handler := b.grpcHandler(conn, route)
+		var sub *nats.Subscription
+		var err error
+		if route.Queue != "" {
+			sub, err = b.nc.QueueSubscribe(route.Subject, route.Queue, handler)
+		} else {
+			sub, err = b.nc.Subscribe(route.Subject, handler)
+		}
+		if err != nil {
+			return fmt.Errorf("grpcbridge: failed to subscribe to %s: %w", route.Subject, err)
+		}
+		b.subs = append(b.subs, sub)
+	}
+	return nil
+}
+
+// grpcHandler returns the NATS handler forwarding requests for route to conn.
+func (b *Bridge) grpcHandler(conn grpc.ClientConnInterface, route Route) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		defer func() {
+			if r := recover(); r != nil {
+				helpers.Println(constant.ERROR, "exception: occurred in grpc bridge", r, "stack:", string(debug.Stack()))
+			}
+		}()
+
+		in, err := route.grpcRequest(msg.Data)
+		if err != nil {
+			b.respondError(msg, blame.ErrorResponse{
+				ErrorCode:    types.ErrorCode(codes.InvalidArgument.String()),
+				Message:      err.Error(),
+				Component:    constant.ErrAdaptors,
+				ResponseType: constant.BadRequest,
+			})
+			return
+		}
+
+		ctx, cancel := withDeadline(context.Background(), msg.Header, b.routeTimeout(route))
+		defer cancel()
+		ctx = metadata.NewOutgoingContext(ctx, metadataFromHeaders(msg.Header))
+
+		var out Frame
+		var header, trailer metadata.MD
+		err = conn.Invoke(ctx, route.Method, &Frame{Data: in}, &out,
+			grpc.ForceCodec(b.codec), grpc.Header(&header), grpc.Trailer(&trailer))
+		if err != nil {
+			b.logger.Warn("Bridged gRPC call failed", log.String("method", route.Method), log.String("subject", route.Subject), log.Err(err))
+			b.respondError(msg, ErrorResponseFromStatus(err, trailer))
+			return
+		}
+
+		data, err := route.natsResponse(out.Data)
+		if err != nil {
+			b.respondError(msg, blame.ErrorResponse{
+				ErrorCode:    types.ErrorCode(codes.Internal.String()),
+				Message:      err.Error(),
+				Component:    constant.ErrAdaptors,
+				ResponseType: constant.InternalServer,
+			})
+			return
+		}
+		reply := nats.NewMsg(msg.Reply)
+		reply.Data = data
+		reply.Header = headersFromMetadata(header)
+		if correlationID := helpers.CorrelationIDFromNatsMsg(msg); correlationID != "" {
+			reply.Header.Set(constant.CorrelationIDHeader, correlationID)
+		}
+		if err := msg.RespondMsg(reply); err != nil {
+			b.logger.Error("Failed to respond to bridged request", log.String("subject", route.Subject), log.Err(err))
+		}
+	}
+}
+
+// respondError replies the way engine handlers do: a failed message envelope with the
+// error code in the X-Error header.
+func (b *Bridge) respondError(msg *nats.Msg, resp blame.ErrorResponse) {
+	if msg.Reply == "" {
+		return
+	}
+	correlationID := helpers.CorrelationIDFromNatsMsg(msg)
+	envelope := message.NewMessage[any](constant.Execute, constant.Failed, types.CorrelationID(correlationID), nil)
+	envelope.AddError(resp)
+	data, err := codec.Encode(envelope, codec.JSON)
+	if err != nil {
+		return
+	}
+	reply := nats.NewMsg(msg.Reply)
+	reply.Data = data
+	reply.Header.Set(constant.ErrorHeader, resp.ErrorCode.String())
+	if correlationID != "" {
+		reply.Header.Set(constant.CorrelationIDHeader, correlationID)
+	}
+	if err := msg.RespondMsg(reply); err != nil {
+		b.logger.Error("Failed to respond to bridged request", log.String("subject", msg.Subject), log.Err(err))
+	}
+}
+
+// routeTimeout returns the timeout for route.
+func (b *Bridge) routeTimeout(route Route) time.Duration {
+	if route.Timeout > 0 {
+		return route.Timeout
+	}
+	return b.timeout
+}
+
+// Close unsubscribes the NATS subjects served by ExposeGRPC.
+func (b *Bridge) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sub := range b.subs {
+		_ = sub.Drain()
+	}
+	b.subs = nil
+}
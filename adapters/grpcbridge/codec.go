@@ -0,0 +1,76 @@
+package grpcbridge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Frame carries an undecoded gRPC message so the bridge can forward methods it has no
+// generated code for.
+type Frame struct {
+	Data []byte
+}
+
+// frameCodec passes Frames through untouched and encodes everything else as protobuf, so
+// registered services keep working on a server that also hosts the bridge.
+type frameCodec struct{}
+
+func newFrameCodec() encoding.Codec {
+	return frameCodec{}
+}
+
+func (frameCodec) Marshal(v any) ([]byte, error) {
+	switch msg := v.(type) {
+	case *Frame:
+		return msg.Data, nil
+	case proto.Message:
+		return proto.Marshal(msg)
+	}
+	return nil, fmt.Errorf("grpcbridge: cannot marshal %T", v)
+}
+
+func (frameCodec) Unmarshal(data []byte, v any) error {
+	switch msg := v.(type) {
+	case *Frame:
+		msg.Data = append(msg.Data[:0], data...)
+		return nil
+	case proto.Message:
+		return proto.Unmarshal(data, msg)
+	}
+	return fmt.Errorf("grpcbridge: cannot unmarshal into %T", v)
+}
+
+func (frameCodec) Name() string {
+	return "proto"
+}
+
+// protoToJSON converts protobuf wire bytes to JSON using the prototype's descriptor.
+func protoToJSON(prototype proto.Message, data []byte) ([]byte, error) {
+	msg := prototype.ProtoReflect().New().Interface()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("grpcbridge: invalid protobuf payload: %w", err)
+	}
+	return protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+}
+
+// jsonToProto converts JSON to protobuf wire bytes. A neuron message envelope is unwrapped
+// to its payload first, so replies from engine handlers decode directly.
+func jsonToProto(prototype proto.Message, data []byte) ([]byte, error) {
+	var envelope struct {
+		Status  *string         `json:"status"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if json.Unmarshal(data, &envelope) == nil && envelope.Status != nil && len(envelope.Payload) > 0 {
+		data = envelope.Payload
+	}
+
+	msg := prototype.ProtoReflect().New().Interface()
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("grpcbridge: invalid json payload: %w", err)
+	}
+	return proto.Marshal(msg)
+}
@@ -0,0 +1,200 @@
+package grpcbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/structures/message"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DeadlineHeader carries the caller's deadline as Unix milliseconds across NATS.
+	DeadlineHeader = "X-Deadline"
+	// ErrorTrailer carries the JSON blame.ErrorResponse of a failed call in gRPC trailers.
+	ErrorTrailer = "x-error-response"
+)
+
+// knownHeaders keeps the exact casing of neuron headers, as NATS header lookups are
+// case-sensitive while gRPC metadata keys are always lower case.
+var knownHeaders = func() map[string]string {
+	headers := map[string]string{}
+	for _, h := range []string{
+		constant.CorrelationIDHeader, constant.AuthorizationHeader, constant.MessageIdHeader,
+		constant.ErrorHeader, constant.IPHeader, constant.XSignature, constant.XPasetoToken,
+		constant.XRefreshToken, constant.XSubject, constant.XUserRole, constant.XOrgId,
+		constant.XUserId, constant.XFeatureFlags, constant.XLocationId, DeadlineHeader,
+	} {
+		headers[strings.ToLower(h)] = h
+	}
+	return headers
+}()
+
+// skipMetadata reports whether a gRPC metadata key is transport-specific.
+func skipMetadata(key string) bool {
+	switch key {
+	case "content-type", "user-agent", "te", ":authority", "grpc-timeout":
+		return true
+	}
+	return strings.HasPrefix(key, "grpc-") || strings.HasSuffix(key, "-bin")
+}
+
+// headersFromMetadata converts gRPC metadata to NATS headers.
+func headersFromMetadata(md metadata.MD) nats.Header {
+	header := nats.Header{}
+	for key, values := range md {
+		if skipMetadata(key) {
+			continue
+		}
+		name, ok := knownHeaders[key]
+		if !ok {
+			name = textproto.CanonicalMIMEHeaderKey(key)
+		}
+		for _, v := range values {
+			header.Add(name, v)
+		}
+	}
+	return header
+}
+
+// metadataFromHeaders converts NATS headers to gRPC metadata.
+func metadataFromHeaders(header nats.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		lower := strings.ToLower(key)
+		if skipMetadata(lower) || lower == strings.ToLower(DeadlineHeader) {
+			continue
+		}
+		md.Append(lower, values...)
+	}
+	return md
+}
+
+// withDeadline bounds ctx by the route timeout and, when present, the caller deadline
+// carried in header.
+func withDeadline(ctx context.Context, header nats.Header, timeout time.Duration) (context.Context, context.CancelFunc) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if ms, err := strconv.ParseInt(header.Get(DeadlineHeader), 10, 64); err == nil {
+		if caller := time.UnixMilli(ms); deadline.IsZero() || caller.Before(deadline) {
+			deadline = caller
+		}
+	}
+	if deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// codeFor maps a blame response type to a gRPC status code.
+func codeFor(responseType types.ResponseErrorType) codes.Code {
+	switch responseType {
+	case constant.BadRequest:
+		return codes.InvalidArgument
+	case constant.Unauthorized:
+		return codes.Unauthenticated
+	case constant.Forbidden:
+		return codes.PermissionDenied
+	case constant.NotFound:
+		return codes.NotFound
+	case constant.AlreadyExists:
+		return codes.AlreadyExists
+	case constant.RequestTimeout:
+		return codes.DeadlineExceeded
+	case constant.TooLarge:
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
+
+// responseTypeFor maps a gRPC status code to a blame response type.
+func responseTypeFor(code codes.Code) types.ResponseErrorType {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return constant.BadRequest
+	case codes.Unauthenticated:
+		return constant.Unauthorized
+	case codes.PermissionDenied:
+		return constant.Forbidden
+	case codes.NotFound:
+		return constant.NotFound
+	case codes.AlreadyExists:
+		return constant.AlreadyExists
+	}
+	return constant.InternalServer
+}
+
+// replyError returns the error carried by a NATS reply, or nil for a successful reply.
+// Engine handlers set the X-Error header and reply with a failed message envelope.
+func replyError(reply *nats.Msg) *blame.ErrorResponse {
+	code := reply.Header.Get(constant.ErrorHeader)
+	var envelope message.Message[json.RawMessage]
+	if json.Unmarshal(reply.Data, &envelope) == nil && envelope.Status == constant.Failed {
+		if envelope.Error.ErrorCode == "" {
+			envelope.Error.ErrorCode = types.ErrorCode(code)
+		}
+		return &envelope.Error
+	}
+	if code == "" {
+		return nil
+	}
+	return &blame.ErrorResponse{ErrorCode: types.ErrorCode(code), Message: code}
+}
+
+// statusFromErrorResponse converts a blame error response to a gRPC status and the
+// trailer that carries the full response to bridge-aware clients.
+func statusFromErrorResponse(resp *blame.ErrorResponse) (metadata.MD, error) {
+	msg := resp.Message
+	if msg == "" {
+		msg = resp.ErrorCode.String()
+	}
+	trailer := metadata.MD{}
+	if data, err := json.Marshal(resp); err == nil {
+		trailer.Set(ErrorTrailer, string(data))
+	}
+	return trailer, status.Error(codeFor(resp.ResponseType), msg)
+}
+
+// statusFromNATSError converts a NATS request failure to a gRPC status.
+func statusFromNATSError(err error) error {
+	switch {
+	case errors.Is(err, nats.ErrNoResponders):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, nats.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+// ErrorResponseFromStatus converts a failed gRPC call to a blame error response, preferring
+// the full response when the server is itself a bridge.
+func ErrorResponseFromStatus(err error, trailer metadata.MD) blame.ErrorResponse {
+	if values := trailer.Get(ErrorTrailer); len(values) > 0 {
+		var resp blame.ErrorResponse
+		if json.Unmarshal([]byte(values[0]), &resp) == nil {
+			return resp
+		}
+	}
+	st := status.Convert(err)
+	return blame.ErrorResponse{
+		ErrorCode:    types.ErrorCode(strings.ToLower(st.Code().String())),
+		Message:      st.Message(),
+		Component:    constant.ErrAdaptors,
+		ResponseType: responseTypeFor(st.Code()),
+	}
+}
@@ -0,0 +1,18 @@
+package grpcclient
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	DefaultPoolSize     = 4
+	DefaultRetryBackoff = 100 * time.Millisecond
+	BreakerName         = "GRPCClient"
+	RequestIDMetadata   = "x-request-id"
+	AuthorizationScheme = "Bearer "
+)
+
+// DefaultRetryCodes are the codes retried by WithRetry when none are given.
+var DefaultRetryCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted}
@@ -0,0 +1,164 @@
+// Package grpcclient is the client counterpart of grpcserver: it keeps pools of connections
+// per target and propagates the correlation and request ids, attaches bearer tokens, retries
+// transient failures and trips a circuit breaker per target.
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/circuitBreaker"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ErrManagerClosed is returned by Conn once the manager is closed.
+var ErrManagerClosed = errors.New("grpcclient: manager is closed")
+
+// ClientManager keeps a pool of connections per target, shared by the clients of that target.
+type ClientManager struct {
+	config ClientConfig
+	mu     sync.Mutex
+	pools  map[string]*Pool
+	closed bool
+}
+
+// NewClientManager creates a ClientManager with the provided options.
+func NewClientManager(opts ...Option) *ClientManager {
+	config := ClientConfig{poolSize: DefaultPoolSize}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.log == nil {
+		config.log = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	if config.poolSize <= 0 {
+		config.poolSize = DefaultPoolSize
+	}
+	return &ClientManager{config: config, pools: make(map[string]*Pool)}
+}
+
+// Conn returns the pool of target, connecting on first use. Pass it to generated clients,
+// e.g. pb.NewOrdersClient(pool).
+func (m *ClientManager) Conn(target string) (*Pool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+	if pool, ok := m.pools[target]; ok {
+		return pool, nil
+	}
+
+	pool, err := m.dial(target)
+	if err != nil {
+		return nil, err
+	}
+	m.pools[target] = pool
+	m.config.log.Info("gRPC client pool created", log.String("target", target), log.Int("connections", len(pool.conns)))
+	return pool, nil
+}
+
+// Close closes the connections of every pool.
+func (m *ClientManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	var errs []error
+	for target, pool := range m.pools {
+		if err := pool.close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(m.pools, target)
+	}
+	return errors.Join(errs...)
+}
+
+// dial opens the connections of the pool of target.
+func (m *ClientManager) dial(target string) (*Pool, error) {
+	cfg := m.config
+	pool := &Pool{target: target}
+	if cfg.breakerEnabled {
+		options := append([]circuitBreaker.CircuitBreakerOption{
+			circuitBreaker.WithName(BreakerName + ":" + target),
+		}, cfg.breakerOptions...)
+		options = append(options, func(s *gobreaker.Settings) { s.IsSuccessful = isSuccessful })
+		pool.breaker = circuitBreaker.NewCircuitBreaker(options...)
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg.tlsConfig != nil {
+		creds = credentials.NewTLS(cfg.tlsConfig)
+	}
+	unary, stream := cfg.interceptors(pool.breaker)
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}, cfg.dialOptions...)
+
+	for range cfg.poolSize {
+		conn, err := grpc.NewClient(target, dialOptions...)
+		if err != nil {
+			_ = pool.close()
+			return nil, fmt.Errorf("failed to create gRPC client for %s: %w", target, err)
+		}
+		pool.conns = append(pool.conns, conn)
+	}
+	return pool, nil
+}
+
+// Pool is a set of connections to a target used round robin. It implements
+// grpc.ClientConnInterface.
+type Pool struct {
+	target  string
+	conns   []*grpc.ClientConn
+	next    atomic.Uint64
+	breaker *gobreaker.CircuitBreaker
+}
+
+// Target returns the target of the pool.
+func (p *Pool) Target() string {
+	return p.target
+}
+
+// Invoke performs a unary call on the next connection.
+func (p *Pool) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return p.conn().Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream begins a stream on the next connection.
+func (p *Pool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.conn().NewStream(ctx, desc, method, opts...)
+}
+
+// BreakerState returns the state of the circuit breaker of the target, or "disabled".
+func (p *Pool) BreakerState() string {
+	if p.breaker == nil {
+		return "disabled"
+	}
+	return p.breaker.State().String()
+}
+
+// conn returns the next connection.
+func (p *Pool) conn() *grpc.ClientConn {
+	return p.conns[p.next.Add(1)%uint64(len(p.conns))]
+}
+
+// close closes the connections of the pool.
+func (p *Pool) close() error {
+	var errs []error
+	for _, conn := range p.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
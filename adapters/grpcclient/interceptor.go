@@ -0,0 +1,166 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"github.com/sony/gobreaker"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// interceptors returns the interceptor chains of a pool: metadata, token, circuit breaker,
+// retry, then the interceptors of the options.
+func (c ClientConfig) interceptors(breaker *gobreaker.CircuitBreaker) ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor) {
+	unary := []grpc.UnaryClientInterceptor{c.unaryMetadataInterceptor()}
+	stream := []grpc.StreamClientInterceptor{c.streamMetadataInterceptor()}
+
+	if breaker != nil {
+		unary = append(unary, unaryBreakerInterceptor(breaker))
+		stream = append(stream, streamBreakerInterceptor(breaker))
+	}
+
+	if c.retryMax > 0 {
+		backoff := c.retryBackoff
+		if backoff <= 0 {
+			backoff = DefaultRetryBackoff
+		}
+		retryCodes := c.retryCodes
+		if len(retryCodes) == 0 {
+			retryCodes = DefaultRetryCodes
+		}
+		unary = append(unary, retry.UnaryClientInterceptor(
+			retry.WithMax(c.retryMax),
+			retry.WithBackoff(retry.BackoffExponentialWithJitter(backoff, 0.1)),
+			retry.WithCodes(retryCodes...),
+		))
+	}
+
+	return append(unary, c.unary...), append(stream, c.stream...)
+}
+
+// outgoingContext adds the correlation id, request id, fixed metadata, bearer token and trace
+// context to the outgoing metadata of ctx. Values already set by the caller are kept.
+func (c ClientConfig) outgoingContext(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	setIfMissing := func(key, value string) {
+		if value != "" && len(md.Get(key)) == 0 {
+			md.Set(key, value)
+		}
+	}
+
+	correlationID := events.CorrelationIDFromContext(ctx)
+	if correlationID == "" {
+		correlationID = random.GenerateUUIDString()
+	}
+	setIfMissing(constant.CorrelationIDHeader, correlationID)
+	requestID, _ := ctx.Value(types.StringConstant(constant.RequestID)).(string)
+	setIfMissing(RequestIDMetadata, requestID)
+	for key, value := range c.metadataHeaders {
+		setIfMissing(key, value)
+	}
+
+	if c.tokenSource != nil && len(md.Get(constant.AuthorizationHeader)) == 0 {
+		token, err := c.tokenSource(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "failed to obtain token: %v", err)
+		}
+		setIfMissing(constant.AuthorizationHeader, AuthorizationScheme+token)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), nil
+}
+
+func (c ClientConfig) unaryMetadataInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := c.outgoingContext(ctx)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (c ClientConfig) streamMetadataInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := c.outgoingContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// unaryBreakerInterceptor runs calls through breaker, failing fast with Unavailable while it
+// is open.
+func unaryBreakerInterceptor(breaker *gobreaker.CircuitBreaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		_, err := breaker.Execute(func() (any, error) {
+			return nil, invoker(ctx, method, req, reply, cc, opts...)
+		})
+		return breakerError(err)
+	}
+}
+
+// streamBreakerInterceptor runs the creation of streams through breaker.
+func streamBreakerInterceptor(breaker *gobreaker.CircuitBreaker) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := breaker.Execute(func() (any, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		})
+		if err != nil {
+			return nil, breakerError(err)
+		}
+		return stream.(grpc.ClientStream), nil
+	}
+}
+
+// breakerError converts the errors of an open breaker into Unavailable.
+func breakerError(err error) error {
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
+
+// isSuccessful reports whether err leaves the circuit breaker closed: only errors of an
+// unhealthy target count as failures.
+func isSuccessful(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal:
+		return false
+	default:
+		return true
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,160 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/jwt"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/circuitBreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// TokenSource returns the bearer token attached to the calls made with ctx.
+type TokenSource func(ctx context.Context) (string, error)
+
+// ClientConfig holds the gRPC client configuration.
+type ClientConfig struct {
+	log             *log.Log
+	poolSize        int
+	tlsConfig       *tls.Config
+	tokenSource     TokenSource
+	retryMax        uint
+	retryBackoff    time.Duration
+	retryCodes      []codes.Code
+	breakerOptions  []circuitBreaker.CircuitBreakerOption
+	breakerEnabled  bool
+	dialOptions     []grpc.DialOption
+	unary           []grpc.UnaryClientInterceptor
+	stream          []grpc.StreamClientInterceptor
+	metadataHeaders map[string]string
+}
+
+// Option is a function that modifies ClientConfig.
+type Option func(*ClientConfig)
+
+// WithLogger sets the logger of the client manager.
+func WithLogger(log *log.Log) Option {
+	return func(c *ClientConfig) {
+		c.log = log
+	}
+}
+
+// WithPoolSize sets the number of connections opened per target, used round robin.
+// Defaults to DefaultPoolSize.
+func WithPoolSize(size int) Option {
+	return func(c *ClientConfig) {
+		c.poolSize = size
+	}
+}
+
+// WithTLS connects with TLS using cfg. Connections are insecure otherwise.
+func WithTLS(cfg *tls.Config) Option {
+	return func(c *ClientConfig) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithTokenSource attaches the token returned by source as a bearer Authorization header
+// to every call, as expected by the jwt and paseto auth modes of grpcserver.
+func WithTokenSource(source TokenSource) Option {
+	return func(c *ClientConfig) {
+		c.tokenSource = source
+	}
+}
+
+// WithBearerToken attaches a fixed bearer token, e.g. a paseto token issued to the service.
+func WithBearerToken(token string) Option {
+	return WithTokenSource(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithJWT attaches a JWT of serviceName and roles signed with secret, renewed before it expires.
+func WithJWT(serviceName string, roles []string, secret string, expiry time.Duration) Option {
+	source := &jwtSource{serviceName: serviceName, roles: roles, secret: secret, expiry: expiry}
+	return WithTokenSource(source.token)
+}
+
+// WithRetry retries unary calls failing with the given codes, or DefaultRetryCodes, up to
+// maxRetries times with exponential backoff starting at backoff. Streams are not retried.
+func WithRetry(maxRetries uint, backoff time.Duration, retryCodes ...codes.Code) Option {
+	return func(c *ClientConfig) {
+		c.retryMax = maxRetries
+		c.retryBackoff = backoff
+		c.retryCodes = retryCodes
+	}
+}
+
+// WithCircuitBreaker gives every target a circuit breaker configured with options. Only
+// Unavailable, DeadlineExceeded, ResourceExhausted and Internal errors count as failures.
+func WithCircuitBreaker(options ...circuitBreaker.CircuitBreakerOption) Option {
+	return func(c *ClientConfig) {
+		c.breakerEnabled = true
+		c.breakerOptions = options
+	}
+}
+
+// WithMetadata adds fixed metadata to every call.
+func WithMetadata(headers map[string]string) Option {
+	return func(c *ClientConfig) {
+		if c.metadataHeaders == nil {
+			c.metadataHeaders = make(map[string]string, len(headers))
+		}
+		for k, v := range headers {
+			c.metadataHeaders[k] = v
+		}
+	}
+}
+
+// WithUnaryInterceptors adds unary interceptors, run after those of the manager.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(c *ClientConfig) {
+		c.unary = append(c.unary, interceptors...)
+	}
+}
+
+// WithStreamInterceptors adds stream interceptors, run after those of the manager.
+func WithStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) Option {
+	return func(c *ClientConfig) {
+		c.stream = append(c.stream, interceptors...)
+	}
+}
+
+// WithDialOptions adds options to the connections.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *ClientConfig) {
+		c.dialOptions = append(c.dialOptions, opts...)
+	}
+}
+
+// jwtSource issues JWTs and reuses them until shortly before they expire.
+type jwtSource struct {
+	serviceName string
+	roles       []string
+	secret      string
+	expiry      time.Duration
+
+	mu      sync.Mutex
+	current string
+	renewAt time.Time
+}
+
+// token returns the current JWT, issuing a new one once it is close to expiry.
+func (s *jwtSource) token(context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != "" && time.Now().Before(s.renewAt) {
+		return s.current, nil
+	}
+	token, err := jwt.GenerateJWT(s.serviceName, s.roles, s.secret, s.expiry)
+	if err != nil {
+		return "", err
+	}
+	s.current = token
+	s.renewAt = time.Now().Add(s.expiry * 9 / 10)
+	return token, nil
+}
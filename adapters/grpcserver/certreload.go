@@ -0,0 +1,230 @@
+package grpcmanager
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultCertReloadInterval is how often the certificate files are checked for changes when
+// WithCertReload sets no interval.
+const DefaultCertReloadInterval = time.Minute
+
+// certExpiry exposes the expiry of the server certificate and of the client CA, labelled by
+// service and certificate ("server" or "client_ca").
+var certExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "grpc_server_certificate_expiry_timestamp_seconds",
+	Help: "Unix time at which the TLS certificate of the gRPC server, or the earliest of its client CAs, expires.",
+}, []string{"service", "certificate"})
+
+// certReloader serves the TLS certificate and client CA pool of the server, and swaps them
+// when their files change or on SIGHUP, so certificates rotate without a restart. A failed
+// reload keeps the previous certificates.
+type certReloader struct {
+	certFile, keyFile, caFile string
+	serviceName               string
+	log                       *log.Log
+	metrics                   bool
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	caPool    *x509.CertPool
+	expiresAt time.Time
+	modTimes  map[string]time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newCertReloader loads the certificate files of config.
+func newCertReloader(config ServerConfig) (*certReloader, error) {
+	r := &certReloader{
+		certFile:    config.certFile,
+		keyFile:     config.keyFile,
+		caFile:      config.caFile,
+		serviceName: config.serviceName,
+		log:         config.log,
+		metrics:     config.enableMetrics,
+		stop:        make(chan struct{}),
+	}
+	if r.metrics {
+		if err := prometheus.Register(certExpiry); err != nil {
+			var already prometheus.AlreadyRegisteredError
+			if !errors.As(err, &already) {
+				return nil, fmt.Errorf("failed to register certificate metrics: %v", err)
+			}
+		}
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads the certificate, key and CA files and swaps them in.
+func (r *certReloader) load() error {
+	modTimes := r.statFiles()
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load key pair: %v", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("failed to parse certificate: %v", err)
+		}
+	}
+
+	var caPool *x509.CertPool
+	var caExpiry time.Time
+	if r.caFile != "" {
+		caCert, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA cert: %v", err)
+		}
+		caPool = x509.NewCertPool()
+		if !caPool.AppendCertsFromPEM(caCert) {
+			return fmt.Errorf("failed to add CA cert")
+		}
+		caExpiry = earliestExpiry(caCert)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.caPool = caPool
+	r.expiresAt = cert.Leaf.NotAfter
+	r.modTimes = modTimes
+	r.mu.Unlock()
+
+	if r.metrics {
+		certExpiry.WithLabelValues(r.serviceName, "server").Set(float64(cert.Leaf.NotAfter.Unix()))
+		if !caExpiry.IsZero() {
+			certExpiry.WithLabelValues(r.serviceName, "client_ca").Set(float64(caExpiry.Unix()))
+		}
+	}
+	return nil
+}
+
+// statFiles returns the modification times of the certificate files.
+func (r *certReloader) statFiles() map[string]time.Time {
+	modTimes := make(map[string]time.Time, 3)
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// changed reports whether a certificate file was modified since the last load.
+func (r *certReloader) changed() bool {
+	current := r.statFiles()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for file, modTime := range current {
+		if !modTime.Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch reloads the certificates when their files change, checked every interval, and on
+// SIGHUP, until stopped.
+func (r *certReloader) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if r.changed() {
+				r.reload("file change")
+			}
+		case <-hangup:
+			r.reload("SIGHUP")
+		}
+	}
+}
+
+// reload loads the certificates again, logging the outcome.
+func (r *certReloader) reload(trigger string) {
+	if err := r.load(); err != nil {
+		r.log.Error("Failed to reload TLS certificates, keeping the previous ones", log.String("trigger", trigger), log.Err(err))
+		return
+	}
+	r.log.Info("TLS certificates reloaded", log.String("trigger", trigger), log.Time("expires_at", r.expiry()))
+}
+
+// close stops watch.
+func (r *certReloader) close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+// expiry returns the expiry of the current server certificate.
+func (r *certReloader) expiry() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.expiresAt
+}
+
+// getCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// getConfigForClient returns a tls.Config.GetConfigForClient verifying client certificates
+// against the current CA pool, on top of base.
+func (r *certReloader) getConfigForClient(base *tls.Config) func(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		pool := r.caPool
+		r.mu.RUnlock()
+		config := base.Clone()
+		config.GetConfigForClient = nil
+		config.ClientCAs = pool
+		return config, nil
+	}
+}
+
+// earliestExpiry returns the earliest expiry of the certificates of a PEM bundle.
+func earliestExpiry(bundle []byte) time.Time {
+	var earliest time.Time
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			return earliest
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package grpcmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/diagnostics"
+	"github.com/abhissng/neuron/utils/helpers"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// DebugPath is the path of the listing served by DebugHandler.
+const DebugPath = "/debug/grpc/services"
+
+// reflectionMethods are the methods of the reflection service, exempt from authentication.
+var reflectionMethods = []string{
+	reflectionv1.ServerReflection_ServerReflectionInfo_FullMethodName,
+	reflectionv1alpha.ServerReflection_ServerReflectionInfo_FullMethodName,
+}
+
+// ServiceDescriptor describes a registered service.
+type ServiceDescriptor struct {
+	Name    string             `json:"name"`
+	Methods []MethodDescriptor `json:"methods"`
+}
+
+// MethodDescriptor describes a method of a registered service.
+type MethodDescriptor struct {
+	Name            string `json:"name"`
+	FullMethod      string `json:"full_method"`
+	ClientStreaming bool   `json:"client_streaming"`
+	ServerStreaming bool   `json:"server_streaming"`
+}
+
+// debugEnabled reports whether the debug features are allowed, i.e. outside production.
+func debugEnabled() bool {
+	return !helpers.IsProdEnvironment()
+}
+
+// registerReflection registers the reflection service when enabled outside production.
+func (s *Server) registerReflection() {
+	if !s.config.reflection {
+		return
+	}
+	if !debugEnabled() {
+		s.config.log.Warn("gRPC reflection is disabled in production")
+		return
+	}
+	reflection.Register(s.server)
+	s.config.log.Info("gRPC reflection enabled", log.String("service", s.config.serviceName))
+}
+
+// Services returns the registered services and their methods, sorted by name.
+func (s *Server) Services() []ServiceDescriptor {
+	info := s.server.GetServiceInfo()
+	services := make([]ServiceDescriptor, 0, len(info))
+	for name, service := range info {
+		descriptor := ServiceDescriptor{Name: name, Methods: make([]MethodDescriptor, 0, len(service.Methods))}
+		for _, method := range service.Methods {
+			descriptor.Methods = append(descriptor.Methods, MethodDescriptor{
+				Name:            method.Name,
+				FullMethod:      "/" + name + "/" + method.Name,
+				ClientStreaming: method.IsClientStream,
+				ServerStreaming: method.IsServerStream,
+			})
+		}
+		sort.Slice(descriptor.Methods, func(i, j int) bool { return descriptor.Methods[i].Name < descriptor.Methods[j].Name })
+		services = append(services, descriptor)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+// DebugHandler serves the registered services and methods as JSON. It responds 404 in
+// production.
+func (s *Server) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !debugEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"service":    s.config.serviceName,
+			"port":       s.config.port,
+			"reflection": s.config.reflection,
+			"services":   s.Services(),
+		})
+	})
+}
+
+// startDebugEndpoint serves DebugHandler on the configured address outside production.
+func (s *Server) startDebugEndpoint() {
+	if s.config.debugAddr == "" {
+		return
+	}
+	if !debugEnabled() {
+		s.config.log.Warn("gRPC debug endpoint is disabled in production")
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle(DebugPath, s.DebugHandler())
+	mux.Handle(diagnostics.Path, diagnostics.Handler())
+	diagnostics.RegisterListener("grpc-debug", s.config.debugAddr)
+	s.debugServer = &http.Server{Addr: s.config.debugAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		s.config.log.Info("gRPC debug endpoint listening", log.String("address", s.config.debugAddr+DebugPath))
+		if err := s.debugServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.config.log.Error("gRPC debug endpoint stopped", log.Err(err))
+		}
+	}()
+}
+
+// stopDebugEndpoint shuts the debug endpoint down.
+func (s *Server) stopDebugEndpoint(ctx context.Context) {
+	if s.debugServer != nil {
+		_ = s.debugServer.Shutdown(ctx)
+	}
+}
@@ -0,0 +1,231 @@
+// Package discovery implements the discovery workflow contract published in
+// proto/neuron/discovery/v1: it packs and unpacks the payloads of DiscoveryMessage through
+// anypb, maps its status and action enums to the neuron constants and converts it to and from
+// message.Message.
+package discovery
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/grpcserver/discovery/discoverypb"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/structures/message"
+	"github.com/abhissng/neuron/utils/types"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	statusToType = map[discoverypb.DiscoveryMessage_Status]types.Status{
+		discoverypb.DiscoveryMessage_STATUS_PENDING:   constant.Pending,
+		discoverypb.DiscoveryMessage_STATUS_COMPLETED: constant.Completed,
+		discoverypb.DiscoveryMessage_STATUS_FAILED:    constant.Failed,
+		discoverypb.DiscoveryMessage_STATUS_SUCCESS:   constant.Success,
+	}
+	actionToType = map[discoverypb.DiscoveryMessage_Action]types.Action{
+		discoverypb.DiscoveryMessage_ACTION_PROCESS:  constant.Process,
+		discoverypb.DiscoveryMessage_ACTION_EXECUTE:  constant.Execute,
+		discoverypb.DiscoveryMessage_ACTION_ROLLBACK: constant.Rollback,
+	}
+	statusFromType = invert(statusToType)
+	actionFromType = invert(actionToType)
+)
+
+// StatusToType returns the neuron status of status, or "" for STATUS_UNSPECIFIED.
+func StatusToType(status discoverypb.DiscoveryMessage_Status) types.Status {
+	return statusToType[status]
+}
+
+// StatusFromType returns the enum of status, or STATUS_UNSPECIFIED when it has none.
+func StatusFromType(status types.Status) discoverypb.DiscoveryMessage_Status {
+	return statusFromType[status]
+}
+
+// ActionToType returns the neuron action of action, or "" for ACTION_UNSPECIFIED.
+func ActionToType(action discoverypb.DiscoveryMessage_Action) types.Action {
+	return actionToType[action]
+}
+
+// ActionFromType returns the enum of action, or ACTION_UNSPECIFIED when it has none.
+func ActionFromType(action types.Action) discoverypb.DiscoveryMessage_Action {
+	return actionFromType[action]
+}
+
+// NewCore creates a Core payload, converting input to a protobuf Struct.
+func NewCore(user *discoverypb.Core_UserInformation, primary *discoverypb.Core_PrimaryInfo, input map[string]any) (*discoverypb.Core, error) {
+	core := &discoverypb.Core{User: user, Primary: primary}
+	if input != nil {
+		fields, err := structpb.NewStruct(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert core input: %w", err)
+		}
+		core.Input = fields
+	}
+	return core, nil
+}
+
+// NewMessage creates a DiscoveryMessage carrying payload, with action execute and status
+// pending, like discovery.NewDiscoveryMessagePayload.
+func NewMessage(correlationID types.CorrelationID, payload proto.Message) (*discoverypb.DiscoveryMessage, error) {
+	packed, err := anypb.New(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack discovery payload: %w", err)
+	}
+	return &discoverypb.DiscoveryMessage{
+		CorrelationId:  correlationID.String(),
+		RequestId:      random.GenerateUUIDString(),
+		Payload:        packed,
+		Status:         discoverypb.DiscoveryMessage_STATUS_PENDING,
+		Action:         discoverypb.DiscoveryMessage_ACTION_EXECUTE,
+		Timestamp:      timestamppb.Now(),
+		CurrentService: helpers.GetServiceName(),
+	}, nil
+}
+
+// Unpack unpacks the payload of msg into a new T, e.g. Unpack[*discoverypb.Core](msg).
+func Unpack[T proto.Message](msg *discoverypb.DiscoveryMessage) (T, error) {
+	var zero T
+	if msg.GetPayload() == nil {
+		return zero, fmt.Errorf("discovery message %s has no payload", msg.GetRequestId())
+	}
+	target, ok := zero.ProtoReflect().Type().New().Interface().(T)
+	if !ok {
+		return zero, fmt.Errorf("cannot instantiate %T", zero)
+	}
+	if err := msg.GetPayload().UnmarshalTo(target); err != nil {
+		return zero, fmt.Errorf("failed to unpack discovery payload: %w", err)
+	}
+	return target, nil
+}
+
+// UnpackCore unpacks the Core payload of msg.
+func UnpackCore(msg *discoverypb.DiscoveryMessage) (*discoverypb.Core, error) {
+	return Unpack[*discoverypb.Core](msg)
+}
+
+// Reply returns the response to req carrying payload with status, stamped by the service.
+func Reply(req *discoverypb.DiscoveryMessage, payload proto.Message, status types.Status) (*discoverypb.DiscoveryMessage, error) {
+	packed, err := anypb.New(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack discovery payload: %w", err)
+	}
+	reply := respond(req, StatusFromType(status))
+	reply.Payload = packed
+	return reply, nil
+}
+
+// Failure returns the failed response to req describing err.
+func Failure(req *discoverypb.DiscoveryMessage, err blame.Blame) *discoverypb.DiscoveryMessage {
+	reply := respond(req, discoverypb.DiscoveryMessage_STATUS_FAILED)
+	reply.Payload = req.GetPayload()
+	reply.Error = ErrorDetailFromResponse(err.FetchErrorResponse(blame.WithTranslation()))
+	return reply
+}
+
+// respond returns the response skeleton of req.
+func respond(req *discoverypb.DiscoveryMessage, status discoverypb.DiscoveryMessage_Status) *discoverypb.DiscoveryMessage {
+	return &discoverypb.DiscoveryMessage{
+		CorrelationId:  req.GetCorrelationId(),
+		RequestId:      req.GetRequestId(),
+		Status:         status,
+		Action:         req.GetAction(),
+		Timestamp:      timestamppb.Now(),
+		CurrentService: helpers.GetServiceName(),
+		Metadata:       req.GetMetadata(),
+	}
+}
+
+// ErrorDetailFromResponse converts a blame error response to its protobuf form.
+func ErrorDetailFromResponse(res blame.ErrorResponse) *discoverypb.ErrorDetail {
+	return &discoverypb.ErrorDetail{
+		ReasonCode:   res.ReasonCode,
+		ErrorCode:    res.ErrorCode.String(),
+		Message:      res.Message,
+		Description:  res.Description,
+		Component:    string(res.Component),
+		ResponseType: string(res.ResponseType),
+		Causes:       res.Causes,
+	}
+}
+
+// ErrorResponseFromDetail converts an ErrorDetail back to a blame error response.
+func ErrorResponseFromDetail(detail *discoverypb.ErrorDetail) blame.ErrorResponse {
+	if detail == nil {
+		return blame.ErrorResponse{}
+	}
+	return blame.ErrorResponse{
+		ReasonCode:   detail.GetReasonCode(),
+		ErrorCode:    types.ErrorCode(detail.GetErrorCode()),
+		Message:      detail.GetMessage(),
+		Description:  detail.GetDescription(),
+		Component:    types.ComponentErrorType(detail.GetComponent()),
+		ResponseType: types.ResponseErrorType(detail.GetResponseType()),
+		Causes:       detail.GetCauses(),
+	}
+}
+
+// ToMessage converts msg to the message.Message exchanged over NATS, unpacking its payload
+// into a T.
+func ToMessage[T proto.Message](msg *discoverypb.DiscoveryMessage) (*message.Message[T], error) {
+	payload, err := Unpack[T](msg)
+	if err != nil {
+		return nil, err
+	}
+	return &message.Message[T]{
+		CorrelationID:  types.CorrelationID(msg.GetCorrelationId()),
+		RequestId:      types.RequestID(msg.GetRequestId()),
+		Payload:        payload,
+		Status:         StatusToType(msg.GetStatus()),
+		Action:         ActionToType(msg.GetAction()),
+		Error:          ErrorResponseFromDetail(msg.GetError()),
+		Timestamp:      msg.GetTimestamp().AsTime(),
+		CurrentService: msg.GetCurrentService(),
+	}, nil
+}
+
+// FromMessage converts a message.Message to a DiscoveryMessage, packing its payload.
+func FromMessage[T proto.Message](m *message.Message[T]) (*discoverypb.DiscoveryMessage, error) {
+	packed, err := anypb.New(m.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack discovery payload: %w", err)
+	}
+	msg := &discoverypb.DiscoveryMessage{
+		CorrelationId:  m.CorrelationID.String(),
+		RequestId:      m.RequestId.String(),
+		Payload:        packed,
+		Status:         StatusFromType(m.Status),
+		Action:         ActionFromType(m.Action),
+		Timestamp:      timestamppb.New(m.Timestamp),
+		CurrentService: m.CurrentService,
+	}
+	if m.Timestamp.IsZero() {
+		msg.Timestamp = timestamppb.New(time.Now())
+	}
+	if m.Error.ErrorCode != "" {
+		msg.Error = ErrorDetailFromResponse(m.Error)
+	}
+	return msg, nil
+}
+
+// Registrar returns the grpcserver ServiceRegistrar registering impl, for WithServiceRegistrar.
+func Registrar(impl discoverypb.DiscoveryServiceServer) func(*grpc.Server) {
+	return func(server *grpc.Server) {
+		discoverypb.RegisterDiscoveryServiceServer(server, impl)
+	}
+}
+
+// invert returns the reverse of m.
+func invert[K, V comparable](m map[K]V) map[V]K {
+	inverted := make(map[V]K, len(m))
+	for k, v := range m {
+		inverted[v] = k
+	}
+	return inverted
+}
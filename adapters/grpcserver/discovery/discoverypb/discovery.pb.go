@@ -0,0 +1,653 @@
+// Contract of the discovery workflow: services hand a DiscoveryMessage to the next service of a
+// workflow, carrying the Core payload packed in an Any, and receive it back with the outcome.
+//
+// Generated code lives in adapters/grpcserver/discovery/discoverypb. Regenerate it with
+// `make proto`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: neuron/discovery/v1/discovery.proto
+
+package discoverypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Status of the step, mapped to the neuron status constants.
+type DiscoveryMessage_Status int32
+
+const (
+	DiscoveryMessage_STATUS_UNSPECIFIED DiscoveryMessage_Status = 0
+	DiscoveryMessage_STATUS_PENDING     DiscoveryMessage_Status = 1
+	DiscoveryMessage_STATUS_COMPLETED   DiscoveryMessage_Status = 2
+	DiscoveryMessage_STATUS_FAILED      DiscoveryMessage_Status = 3
+	DiscoveryMessage_STATUS_SUCCESS     DiscoveryMessage_Status = 4
+)
+
+// Enum value maps for DiscoveryMessage_Status.
+var (
+	DiscoveryMessage_Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_PENDING",
+		2: "STATUS_COMPLETED",
+		3: "STATUS_FAILED",
+		4: "STATUS_SUCCESS",
+	}
+	DiscoveryMessage_Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_PENDING":     1,
+		"STATUS_COMPLETED":   2,
+		"STATUS_FAILED":      3,
+		"STATUS_SUCCESS":     4,
+	}
+)
+
+func (x DiscoveryMessage_Status) Enum() *DiscoveryMessage_Status {
+	p := new(DiscoveryMessage_Status)
+	*p = x
+	return p
+}
+
+func (x DiscoveryMessage_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DiscoveryMessage_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_neuron_discovery_v1_discovery_proto_enumTypes[0].Descriptor()
+}
+
+func (DiscoveryMessage_Status) Type() protoreflect.EnumType {
+	return &file_neuron_discovery_v1_discovery_proto_enumTypes[0]
+}
+
+func (x DiscoveryMessage_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DiscoveryMessage_Status.Descriptor instead.
+func (DiscoveryMessage_Status) EnumDescriptor() ([]byte, []int) {
+	return file_neuron_discovery_v1_discovery_proto_rawDescGZIP(), []int{2, 0}
+}
+
+// Action requested from the receiving service, mapped to the neuron action constants.
+type DiscoveryMessage_Action int32
+
+const (
+	DiscoveryMessage_ACTION_UNSPECIFIED DiscoveryMessage_Action = 0
+	DiscoveryMessage_ACTION_PROCESS     DiscoveryMessage_Action = 1
+	DiscoveryMessage_ACTION_EXECUTE     DiscoveryMessage_Action = 2
+	DiscoveryMessage_ACTION_ROLLBACK    DiscoveryMessage_Action = 3
+)
+
+// Enum value maps for DiscoveryMessage_Action.
+var (
+	DiscoveryMessage_Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "ACTION_PROCESS",
+		2: "ACTION_EXECUTE",
+		3: "ACTION_ROLLBACK",
+	}
+	DiscoveryMessage_Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED": 0,
+		"ACTION_PROCESS":     1,
+		"ACTION_EXECUTE":     2,
+		"ACTION_ROLLBACK":    3,
+	}
+)
+
+func (x DiscoveryMessage_Action) Enum() *DiscoveryMessage_Action {
+	p := new(DiscoveryMessage_Action)
+	*p = x
+	return p
+}
+
+func (x DiscoveryMessage_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DiscoveryMessage_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_neuron_discovery_v1_discovery_proto_enumTypes[1].Descriptor()
+}
+
+func (DiscoveryMessage_Action) Type() protoreflect.EnumType {
+	return &file_neuron_discovery_v1_discovery_proto_enumTypes[1]
+}
+
+func (x DiscoveryMessage_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DiscoveryMessage_Action.Descriptor instead.
+func (DiscoveryMessage_Action) EnumDescriptor() ([]byte, []int) {
+	return file_neuron_discovery_v1_discovery_proto_rawDescGZIP(), []int{2, 1}
+}
+
+// Core is the standard payload of a discovery workflow.
+type Core struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	User    *Core_UserInformation  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Primary *Core_PrimaryInfo      `protobuf:"bytes,2,opt,name=primary,proto3" json:"primary,omitempty"`
+	// Input holds the workflow specific fields.
+	Input         *structpb.Struct `protobuf:"bytes,3,opt,name=input,proto3" json:"input,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Core) Reset() {
+	*x = Core{}
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Core) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Core) ProtoMessage() {}
+
+func (x *Core) ProtoReflect() protoreflect.Message {
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Core.ProtoReflect.Descriptor instead.
+func (*Core) Descriptor() ([]byte, []int) {
+	return file_neuron_discovery_v1_discovery_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Core) GetUser() *Core_UserInformation {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *Core) GetPrimary() *Core_PrimaryInfo {
+	if x != nil {
+		return x.Primary
+	}
+	return nil
+}
+
+func (x *Core) GetInput() *structpb.Struct {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+// ErrorDetail is the blame error response of a failed step.
+type ErrorDetail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReasonCode    string                 `protobuf:"bytes,1,opt,name=reason_code,json=reasonCode,proto3" json:"reason_code,omitempty"`
+	ErrorCode     string                 `protobuf:"bytes,2,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Component     string                 `protobuf:"bytes,5,opt,name=component,proto3" json:"component,omitempty"`
+	ResponseType  string                 `protobuf:"bytes,6,opt,name=response_type,json=responseType,proto3" json:"response_type,omitempty"`
+	Causes        []string               `protobuf:"bytes,7,rep,name=causes,proto3" json:"causes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorDetail) Reset() {
+	*x = ErrorDetail{}
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorDetail) ProtoMessage() {}
+
+func (x *ErrorDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorDetail.ProtoReflect.Descriptor instead.
+func (*ErrorDetail) Descriptor() ([]byte, []int) {
+	return file_neuron_discovery_v1_discovery_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ErrorDetail) GetReasonCode() string {
+	if x != nil {
+		return x.ReasonCode
+	}
+	return ""
+}
+
+func (x *ErrorDetail) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *ErrorDetail) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ErrorDetail) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ErrorDetail) GetComponent() string {
+	if x != nil {
+		return x.Component
+	}
+	return ""
+}
+
+func (x *ErrorDetail) GetResponseType() string {
+	if x != nil {
+		return x.ResponseType
+	}
+	return ""
+}
+
+func (x *ErrorDetail) GetCauses() []string {
+	if x != nil {
+		return x.Causes
+	}
+	return nil
+}
+
+// DiscoveryMessage is a step of a discovery workflow.
+type DiscoveryMessage struct {
+	state          protoimpl.MessageState  `protogen:"open.v1"`
+	CorrelationId  string                  `protobuf:"bytes,1,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	RequestId      string                  `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Payload        *anypb.Any              `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Status         DiscoveryMessage_Status `protobuf:"varint,4,opt,name=status,proto3,enum=neuron.discovery.v1.DiscoveryMessage_Status" json:"status,omitempty"`
+	Action         DiscoveryMessage_Action `protobuf:"varint,5,opt,name=action,proto3,enum=neuron.discovery.v1.DiscoveryMessage_Action" json:"action,omitempty"`
+	Timestamp      *timestamppb.Timestamp  `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	CurrentService string                  `protobuf:"bytes,7,opt,name=current_service,json=currentService,proto3" json:"current_service,omitempty"`
+	Error          *ErrorDetail            `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	Metadata       map[string]string       `protobuf:"bytes,9,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DiscoveryMessage) Reset() {
+	*x = DiscoveryMessage{}
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiscoveryMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscoveryMessage) ProtoMessage() {}
+
+func (x *DiscoveryMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscoveryMessage.ProtoReflect.Descriptor instead.
+func (*DiscoveryMessage) Descriptor() ([]byte, []int) {
+	return file_neuron_discovery_v1_discovery_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DiscoveryMessage) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *DiscoveryMessage) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *DiscoveryMessage) GetPayload() *anypb.Any {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *DiscoveryMessage) GetStatus() DiscoveryMessage_Status {
+	if x != nil {
+		return x.Status
+	}
+	return DiscoveryMessage_STATUS_UNSPECIFIED
+}
+
+func (x *DiscoveryMessage) GetAction() DiscoveryMessage_Action {
+	if x != nil {
+		return x.Action
+	}
+	return DiscoveryMessage_ACTION_UNSPECIFIED
+}
+
+func (x *DiscoveryMessage) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *DiscoveryMessage) GetCurrentService() string {
+	if x != nil {
+		return x.CurrentService
+	}
+	return ""
+}
+
+func (x *DiscoveryMessage) GetError() *ErrorDetail {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *DiscoveryMessage) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// UserInformation identifies the user the workflow runs for.
+type Core_UserInformation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	OrgId         string                 `protobuf:"bytes,3,opt,name=org_id,json=orgId,proto3" json:"org_id,omitempty"`
+	Roles         []string               `protobuf:"bytes,4,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Core_UserInformation) Reset() {
+	*x = Core_UserInformation{}
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Core_UserInformation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Core_UserInformation) ProtoMessage() {}
+
+func (x *Core_UserInformation) ProtoReflect() protoreflect.Message {
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Core_UserInformation.ProtoReflect.Descriptor instead.
+func (*Core_UserInformation) Descriptor() ([]byte, []int) {
+	return file_neuron_discovery_v1_discovery_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *Core_UserInformation) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Core_UserInformation) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Core_UserInformation) GetOrgId() string {
+	if x != nil {
+		return x.OrgId
+	}
+	return ""
+}
+
+func (x *Core_UserInformation) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+// PrimaryInfo identifies the primary record of the workflow.
+type Core_PrimaryInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PrimaryId     string                 `protobuf:"bytes,1,opt,name=primary_id,json=primaryId,proto3" json:"primary_id,omitempty"`
+	PrimaryType   string                 `protobuf:"bytes,2,opt,name=primary_type,json=primaryType,proto3" json:"primary_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Core_PrimaryInfo) Reset() {
+	*x = Core_PrimaryInfo{}
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Core_PrimaryInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Core_PrimaryInfo) ProtoMessage() {}
+
+func (x *Core_PrimaryInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_neuron_discovery_v1_discovery_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Core_PrimaryInfo.ProtoReflect.Descriptor instead.
+func (*Core_PrimaryInfo) Descriptor() ([]byte, []int) {
+	return file_neuron_discovery_v1_discovery_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *Core_PrimaryInfo) GetPrimaryId() string {
+	if x != nil {
+		return x.PrimaryId
+	}
+	return ""
+}
+
+func (x *Core_PrimaryInfo) GetPrimaryType() string {
+	if x != nil {
+		return x.PrimaryType
+	}
+	return ""
+}
+
+var File_neuron_discovery_v1_discovery_proto protoreflect.FileDescriptor
+
+const file_neuron_discovery_v1_discovery_proto_rawDesc = "" +
+	"\n" +
+	"#neuron/discovery/v1/discovery.proto\x12\x13neuron.discovery.v1\x1a\x19google/protobuf/any.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf5\x02\n" +
+	"\x04Core\x12=\n" +
+	"\x04user\x18\x01 \x01(\v2).neuron.discovery.v1.Core.UserInformationR\x04user\x12?\n" +
+	"\aprimary\x18\x02 \x01(\v2%.neuron.discovery.v1.Core.PrimaryInfoR\aprimary\x12-\n" +
+	"\x05input\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x05input\x1am\n" +
+	"\x0fUserInformation\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x15\n" +
+	"\x06org_id\x18\x03 \x01(\tR\x05orgId\x12\x14\n" +
+	"\x05roles\x18\x04 \x03(\tR\x05roles\x1aO\n" +
+	"\vPrimaryInfo\x12\x1d\n" +
+	"\n" +
+	"primary_id\x18\x01 \x01(\tR\tprimaryId\x12!\n" +
+	"\fprimary_type\x18\x02 \x01(\tR\vprimaryType\"\xe4\x01\n" +
+	"\vErrorDetail\x12\x1f\n" +
+	"\vreason_code\x18\x01 \x01(\tR\n" +
+	"reasonCode\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x02 \x01(\tR\terrorCode\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1c\n" +
+	"\tcomponent\x18\x05 \x01(\tR\tcomponent\x12#\n" +
+	"\rresponse_type\x18\x06 \x01(\tR\fresponseType\x12\x16\n" +
+	"\x06causes\x18\a \x03(\tR\x06causes\"\x8f\x06\n" +
+	"\x10DiscoveryMessage\x12%\n" +
+	"\x0ecorrelation_id\x18\x01 \x01(\tR\rcorrelationId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12.\n" +
+	"\apayload\x18\x03 \x01(\v2\x14.google.protobuf.AnyR\apayload\x12D\n" +
+	"\x06status\x18\x04 \x01(\x0e2,.neuron.discovery.v1.DiscoveryMessage.StatusR\x06status\x12D\n" +
+	"\x06action\x18\x05 \x01(\x0e2,.neuron.discovery.v1.DiscoveryMessage.ActionR\x06action\x128\n" +
+	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12'\n" +
+	"\x0fcurrent_service\x18\a \x01(\tR\x0ecurrentService\x126\n" +
+	"\x05error\x18\b \x01(\v2 .neuron.discovery.v1.ErrorDetailR\x05error\x12O\n" +
+	"\bmetadata\x18\t \x03(\v23.neuron.discovery.v1.DiscoveryMessage.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"q\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSTATUS_PENDING\x10\x01\x12\x14\n" +
+	"\x10STATUS_COMPLETED\x10\x02\x12\x11\n" +
+	"\rSTATUS_FAILED\x10\x03\x12\x12\n" +
+	"\x0eSTATUS_SUCCESS\x10\x04\"]\n" +
+	"\x06Action\x12\x16\n" +
+	"\x12ACTION_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eACTION_PROCESS\x10\x01\x12\x12\n" +
+	"\x0eACTION_EXECUTE\x10\x02\x12\x13\n" +
+	"\x0fACTION_ROLLBACK\x10\x032t\n" +
+	"\x10DiscoveryService\x12`\n" +
+	"\x10ProcessDiscovery\x12%.neuron.discovery.v1.DiscoveryMessage\x1a%.neuron.discovery.v1.DiscoveryMessageBRZPgithub.com/abhissng/neuron/adapters/grpcserver/discovery/discoverypb;discoverypbb\x06proto3"
+
+var (
+	file_neuron_discovery_v1_discovery_proto_rawDescOnce sync.Once
+	file_neuron_discovery_v1_discovery_proto_rawDescData []byte
+)
+
+func file_neuron_discovery_v1_discovery_proto_rawDescGZIP() []byte {
+	file_neuron_discovery_v1_discovery_proto_rawDescOnce.Do(func() {
+		file_neuron_discovery_v1_discovery_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_neuron_discovery_v1_discovery_proto_rawDesc), len(file_neuron_discovery_v1_discovery_proto_rawDesc)))
+	})
+	return file_neuron_discovery_v1_discovery_proto_rawDescData
+}
+
+var file_neuron_discovery_v1_discovery_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_neuron_discovery_v1_discovery_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_neuron_discovery_v1_discovery_proto_goTypes = []any{
+	(DiscoveryMessage_Status)(0),  // 0: neuron.discovery.v1.DiscoveryMessage.Status
+	(DiscoveryMessage_Action)(0),  // 1: neuron.discovery.v1.DiscoveryMessage.Action
+	(*Core)(nil),                  // 2: neuron.discovery.v1.Core
+	(*ErrorDetail)(nil),           // 3: neuron.discovery.v1.ErrorDetail
+	(*DiscoveryMessage)(nil),      // 4: neuron.discovery.v1.DiscoveryMessage
+	(*Core_UserInformation)(nil),  // 5: neuron.discovery.v1.Core.UserInformation
+	(*Core_PrimaryInfo)(nil),      // 6: neuron.discovery.v1.Core.PrimaryInfo
+	nil,                           // 7: neuron.discovery.v1.DiscoveryMessage.MetadataEntry
+	(*structpb.Struct)(nil),       // 8: google.protobuf.Struct
+	(*anypb.Any)(nil),             // 9: google.protobuf.Any
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_neuron_discovery_v1_discovery_proto_depIdxs = []int32{
+	5,  // 0: neuron.discovery.v1.Core.user:type_name -> neuron.discovery.v1.Core.UserInformation
+	6,  // 1: neuron.discovery.v1.Core.primary:type_name -> neuron.discovery.v1.Core.PrimaryInfo
+	8,  // 2: neuron.discovery.v1.Core.input:type_name -> google.protobuf.Struct
+	9,  // 3: neuron.discovery.v1.DiscoveryMessage.payload:type_name -> google.protobuf.Any
+	0,  // 4: neuron.discovery.v1.DiscoveryMessage.status:type_name -> neuron.discovery.v1.DiscoveryMessage.Status
+	1,  // 5: neuron.discovery.v1.DiscoveryMessage.action:type_name -> neuron.discovery.v1.DiscoveryMessage.Action
+	10, // 6: neuron.discovery.v1.DiscoveryMessage.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 7: neuron.discovery.v1.DiscoveryMessage.error:type_name -> neuron.discovery.v1.ErrorDetail
+	7,  // 8: neuron.discovery.v1.DiscoveryMessage.metadata:type_name -> neuron.discovery.v1.DiscoveryMessage.MetadataEntry
+	4,  // 9: neuron.discovery.v1.DiscoveryService.ProcessDiscovery:input_type -> neuron.discovery.v1.DiscoveryMessage
+	4,  // 10: neuron.discovery.v1.DiscoveryService.ProcessDiscovery:output_type -> neuron.discovery.v1.DiscoveryMessage
+	10, // [10:11] is the sub-list for method output_type
+	9,  // [9:10] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_neuron_discovery_v1_discovery_proto_init() }
+func file_neuron_discovery_v1_discovery_proto_init() {
+	if File_neuron_discovery_v1_discovery_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_neuron_discovery_v1_discovery_proto_rawDesc), len(file_neuron_discovery_v1_discovery_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_neuron_discovery_v1_discovery_proto_goTypes,
+		DependencyIndexes: file_neuron_discovery_v1_discovery_proto_depIdxs,
+		EnumInfos:         file_neuron_discovery_v1_discovery_proto_enumTypes,
+		MessageInfos:      file_neuron_discovery_v1_discovery_proto_msgTypes,
+	}.Build()
+	File_neuron_discovery_v1_discovery_proto = out.File
+	file_neuron_discovery_v1_discovery_proto_goTypes = nil
+	file_neuron_discovery_v1_discovery_proto_depIdxs = nil
+}
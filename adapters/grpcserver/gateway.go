@@ -0,0 +1,280 @@
+package grpcmanager
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/grpcbridge"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/diagnostics"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GatewayPeerMetadata is the metadata the gateway forwards the address of its HTTP client
+// in, so that calls made through it are rate limited and logged per client rather than as
+// the gateway itself.
+const GatewayPeerMetadata = "x-gateway-peer"
+
+// gatewayTokenMetadata carries the per-server token proving GatewayPeerMetadata was set by
+// the gateway and not by the caller.
+const gatewayTokenMetadata = "x-gateway-token"
+
+// GatewayRegistrar registers the REST handlers of a service on the gateway mux. Its signature
+// matches the generated Register<Service>Handler functions, e.g. pb.RegisterOrderServiceHandler.
+type GatewayRegistrar func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// gatewayForwardedHeaders are the HTTP headers forwarded to the gRPC server as metadata, in
+// addition to the Authorization header forwarded by grpc-gateway itself.
+var gatewayForwardedHeaders = func() map[string]bool {
+	headers := map[string]bool{}
+	for _, h := range []string{
+		constant.CorrelationIDHeader, constant.XRequestID, constant.XPasetoToken,
+		constant.XOrgId, constant.XUserId, constant.XFeatureFlags, constant.XLocationId,
+	} {
+		headers[textproto.CanonicalMIMEHeaderKey(h)] = true
+	}
+	return headers
+}()
+
+// gatewayConfig holds the grpc-gateway configuration of a server.
+type gatewayConfig struct {
+	port        int
+	registrars  []GatewayRegistrar
+	muxOptions  []runtime.ServeMuxOption
+	dialOptions []grpc.DialOption
+	token       string // Value of gatewayTokenMetadata, set by NewServer
+}
+
+// sharedPort reports whether the gateway is served on the gRPC port.
+func (g *gatewayConfig) sharedPort(grpcPort int) bool {
+	return g.port == 0 || g.port == grpcPort
+}
+
+// validateGateway checks that the gateway can reach the gRPC server with the configured TLS.
+func validateGateway(config ServerConfig) error {
+	if config.gateway == nil || config.certFile == "" || config.keyFile == "" {
+		return nil
+	}
+	if config.gateway.sharedPort(config.port) {
+		return fmt.Errorf("gateway cannot share the gRPC port when TLS is enabled, set WithGatewayPort")
+	}
+	if len(config.gateway.dialOptions) == 0 {
+		return fmt.Errorf("gateway requires WithGatewayDialOptions with transport credentials when TLS is enabled")
+	}
+	return nil
+}
+
+// gatewayHeaderMatcher forwards the neuron headers as lower-case metadata and the rest as
+// grpc-gateway does by default, except the peer metadata only the gateway may set.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if gatewayForwardedHeaders[textproto.CanonicalMIMEHeaderKey(key)] {
+		return strings.ToLower(key), true
+	}
+	name, ok := runtime.DefaultHeaderMatcher(key)
+	if ok && (strings.EqualFold(name, GatewayPeerMetadata) || strings.EqualFold(name, gatewayTokenMetadata)) {
+		return "", false
+	}
+	return name, ok
+}
+
+// gatewayPeerMetadata returns a runtime.WithMetadata function forwarding the address of the
+// HTTP client, authenticated by token.
+func gatewayPeerMetadata(token string) func(context.Context, *http.Request) metadata.MD {
+	return func(_ context.Context, r *http.Request) metadata.MD {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return metadata.Pairs(GatewayPeerMetadata, host, gatewayTokenMetadata, token)
+	}
+}
+
+// gatewayPeerContext replaces the peer of ctx with the HTTP client forwarded by the gateway,
+// when the call carries the gateway token.
+func gatewayPeerContext(ctx context.Context, token string) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	tokens, hosts := md.Get(gatewayTokenMetadata), md.Get(GatewayPeerMetadata)
+	if len(tokens) != 1 || len(hosts) != 1 || subtle.ConstantTimeCompare([]byte(tokens[0]), []byte(token)) != 1 {
+		return ctx
+	}
+	ip := net.ParseIP(hosts[0])
+	if ip == nil {
+		return ctx
+	}
+	client := &peer.Peer{Addr: &net.TCPAddr{IP: ip}}
+	if p, ok := peer.FromContext(ctx); ok {
+		client.AuthInfo = p.AuthInfo
+		client.LocalAddr = p.LocalAddr
+	}
+	return peer.NewContext(ctx, client)
+}
+
+// unaryGatewayPeerInterceptor makes the peer of calls made through the gateway its HTTP client.
+func unaryGatewayPeerInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(gatewayPeerContext(ctx, token), req)
+	}
+}
+
+// streamGatewayPeerInterceptor makes the peer of streams opened through the gateway its HTTP
+// client.
+func streamGatewayPeerInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStreamWithContext{ServerStream: ss, ctx: gatewayPeerContext(ss.Context(), token)})
+	}
+}
+
+// gatewayErrorHandler writes failed calls as the blame error envelope used by the Gin
+// handlers, preferring the full blame response carried in the trailers of bridged calls.
+func gatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	md, _ := runtime.ServerMetadataFromContext(ctx)
+	res := grpcbridge.ErrorResponseFromStatus(err, md.TrailerMD)
+	correlationID := types.CorrelationID(r.Header.Get(constant.CorrelationIDHeader))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(runtime.HTTPStatusFromCode(status.Code(err)))
+	_ = json.NewEncoder(w).Encode(acknowledgment.NewAPIResponse(false, correlationID, res))
+}
+
+// withGatewayCorrelationID makes sure every gateway request carries a correlation ID, echoed
+// in the response headers.
+func withGatewayCorrelationID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		correlationID := r.Header.Get(constant.CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = random.GenerateUUIDString()
+			r.Header.Set(constant.CorrelationIDHeader, correlationID)
+		}
+		w.Header().Set(constant.CorrelationIDHeader, correlationID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newGateway dials the gRPC server and registers the gateway handlers. Calls made through
+// the gateway go through the server interceptors, authentication included.
+func (s *Server) newGateway(ctx context.Context) (http.Handler, error) {
+	gw := s.config.gateway
+	dialOptions := gw.dialOptions
+	if len(dialOptions) == 0 {
+		dialOptions = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", s.config.port), dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial gRPC server for gateway: %v", err)
+	}
+
+	muxOptions := append([]runtime.ServeMuxOption{
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithErrorHandler(gatewayErrorHandler),
+		runtime.WithMetadata(gatewayPeerMetadata(gw.token)),
+	}, gw.muxOptions...)
+	mux := runtime.NewServeMux(muxOptions...)
+	for _, register := range gw.registrars {
+		if err := register(ctx, mux, conn); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to register gateway handler: %v", err)
+		}
+	}
+	s.gatewayConn = conn
+	return withGatewayCorrelationID(mux), nil
+}
+
+// serve serves the gRPC server on lis and, when configured, the gateway either on its own
+// port or multiplexed with gRPC on lis. It blocks until the server stops.
+func (s *Server) serve(lis net.Listener) error {
+	if s.config.gateway == nil {
+		return s.server.Serve(lis)
+	}
+
+	handler, err := s.newGateway(context.Background())
+	if err != nil {
+		return err
+	}
+	s.gatewayServer = &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+
+	if !s.config.gateway.sharedPort(s.config.port) {
+		s.gatewayServer.Addr = fmt.Sprintf(":%d", s.config.gateway.port)
+		diagnostics.RegisterListener("grpc-gateway", s.gatewayServer.Addr)
+		go func() {
+			s.config.log.Info(fmt.Sprintf("gRPC gateway listening on port %d", s.config.gateway.port))
+			if err := s.listenGateway(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.config.log.Error("gRPC gateway stopped", log.Err(err))
+			}
+		}()
+		return s.server.Serve(lis)
+	}
+
+	m := cmux.New(lis)
+	grpcListener := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+	httpListener := m.Match(cmux.Any())
+	s.gatewayListener = lis
+
+	go func() {
+		if err := s.server.Serve(grpcListener); err != nil && !errors.Is(err, cmux.ErrListenerClosed) {
+			s.config.log.Error("gRPC server stopped", log.Err(err))
+		}
+	}()
+	go func() {
+		if err := s.gatewayServer.Serve(httpListener); err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, cmux.ErrListenerClosed) {
+			s.config.log.Error("gRPC gateway stopped", log.Err(err))
+		}
+	}()
+	s.config.log.Info(fmt.Sprintf("gRPC gateway sharing port %d", s.config.port))
+
+	if err := m.Serve(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// listenGateway serves the gateway on its own port, over TLS with the certificates of the
+// gRPC server when it uses TLS, so that WithCertReload rotates them for both.
+func (s *Server) listenGateway() error {
+	if s.certReloader != nil {
+		s.gatewayServer.TLSConfig = &tls.Config{
+			GetCertificate: s.certReloader.getCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}
+		return s.gatewayServer.ListenAndServeTLS("", "")
+	}
+	return s.gatewayServer.ListenAndServe()
+}
+
+// stopGateway shuts the gateway down and closes its connection to the gRPC server.
+func (s *Server) stopGateway(ctx context.Context) {
+	if s.gatewayServer != nil {
+		_ = s.gatewayServer.Shutdown(ctx)
+	}
+	if s.gatewayConn != nil {
+		_ = s.gatewayConn.Close()
+	}
+}
+
+// closeGatewayListener closes the listener shared by gRPC and the gateway, which ends serve.
+func (s *Server) closeGatewayListener() {
+	if s.gatewayListener != nil {
+		_ = s.gatewayListener.Close()
+	}
+}
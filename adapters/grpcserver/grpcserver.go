@@ -3,16 +3,17 @@ package grpcmanager
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"net"
-	"os"
+	"net/http"
+	"time"
 
 	"github.com/abhissng/neuron/adapters/jwt"
 	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/adapters/paseto"
 	neuronctx "github.com/abhissng/neuron/context"
 	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/diagnostics"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/random"
 	"github.com/abhissng/neuron/utils/structures/claims"
@@ -46,8 +47,13 @@ type CustomValidatorFunc func(ctx context.Context, fullMethod string, claims *cl
 
 // Server represents a gRPC server
 type Server struct {
-	server *grpc.Server
-	config ServerConfig
+	server          *grpc.Server
+	config          ServerConfig
+	debugServer     *http.Server
+	gatewayServer   *http.Server
+	gatewayConn     *grpc.ClientConn
+	gatewayListener net.Listener
+	certReloader    *certReloader
 }
 
 // NeuronServer is an enhanced gRPC server wrapper with lifecycle management,
@@ -80,11 +86,13 @@ func (ns *NeuronServer) Start() error {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
 	ns.listener = lis
+	diagnostics.RegisterListener("grpc", lis.Addr().String())
 	ns.config.log.Info(fmt.Sprintf("NeuronServer starting on port %d", ns.config.port),
 		zap.String("service", ns.config.serviceName),
 		zap.String("auth_mode", ns.config.authMode),
 	)
-	return ns.server.Serve(lis)
+	ns.startDebugEndpoint()
+	return ns.serve(lis)
 }
 
 // Stop gracefully stops the NeuronServer.
@@ -124,8 +132,15 @@ func NewServer(opts ...Option) (*Server, error) {
 	// Setup gRPC options
 	grpcOpts := []grpc.ServerOption{}
 
+	if err := validateGateway(config); err != nil {
+		return nil, err
+	}
+	if config.gateway != nil {
+		config.gateway.token = random.GenerateUUIDString()
+	}
+
 	// TLS Configuration
-	tlsConfig, err := createTLSConfig(config)
+	tlsConfig, reloader, err := createTLSConfig(config)
 	if err != nil {
 		return nil, err
 	}
@@ -133,6 +148,10 @@ func NewServer(opts ...Option) (*Server, error) {
 		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 
+	if len(config.rateLimits) > 0 {
+		config.limiter = newPeerRateLimiter(config.rateLimits, config.rateLimitTTL)
+	}
+
 	// Interceptors (Middleware)
 	unaryInterceptors, streamInterceptors := buildInterceptors(config)
 	grpcOpts = append(grpcOpts,
@@ -141,51 +160,50 @@ func NewServer(opts ...Option) (*Server, error) {
 		grpc.MaxRecvMsgSize(config.maxRecvMsgSize*1024*1024),
 		grpc.MaxSendMsgSize(config.maxSendMsgSize*1024*1024),
 	)
+	grpcOpts = append(grpcOpts, config.serverOptions...)
 
 	// Create gRPC Server
 	s := &Server{
-		server: grpc.NewServer(grpcOpts...),
-		config: config,
+		server:       grpc.NewServer(grpcOpts...),
+		config:       config,
+		certReloader: reloader,
+	}
+	if reloader != nil && config.certReload > 0 {
+		go reloader.watch(config.certReload)
 	}
 
 	if config.enableMetrics {
 		grpc_prometheus.Register(s.server)
 	}
+	s.registerReflection()
 
 	return s, nil
 }
 
-// createTLSConfig sets up TLS for gRPC server
-func createTLSConfig(config ServerConfig) (*tls.Config, error) {
+// createTLSConfig sets up TLS for gRPC server. The certificates are served by a certReloader,
+// so that WithCertReload can rotate them.
+func createTLSConfig(config ServerConfig) (*tls.Config, *certReloader, error) {
 	if config.certFile == "" || config.keyFile == "" {
-		return nil, nil
+		return nil, nil, nil
 	}
 
-	cert, err := tls.LoadX509KeyPair(config.certFile, config.keyFile)
+	reloader, err := newCertReloader(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load key pair: %v", err)
+		return nil, nil, err
 	}
 
 	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		MinVersion:   tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+		MinVersion:     tls.VersionTLS12,
 	}
 
 	// Mutual TLS (mTLS)
 	if config.caFile != "" {
-		caCert, err := os.ReadFile(config.caFile)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read CA cert: %v", err)
-		}
-		caPool := x509.NewCertPool()
-		if !caPool.AppendCertsFromPEM(caCert) {
-			return nil, fmt.Errorf("failed to add CA cert")
-		}
-		tlsConfig.ClientCAs = caPool
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		tlsConfig.GetConfigForClient = reloader.getConfigForClient(tlsConfig.Clone())
 	}
 
-	return tlsConfig, nil
+	return tlsConfig, reloader, nil
 }
 
 // buildInterceptors sets up gRPC middlewares
@@ -199,23 +217,44 @@ func buildInterceptors(config ServerConfig) ([]grpc.UnaryServerInterceptor, []gr
 	unary = append(unary, recovery.UnaryServerInterceptor(recoveryOpts...))
 	stream = append(stream, recovery.StreamServerInterceptor(recoveryOpts...))
 
+	if config.gateway != nil {
+		unary = append(unary, unaryGatewayPeerInterceptor(config.gateway.token))
+		stream = append(stream, streamGatewayPeerInterceptor(config.gateway.token))
+	}
+
 	unary = append(unary, unaryCorrelationIDInterceptor())
 	stream = append(stream, streamCorrelationIDInterceptor())
 
 	unary = append(unary, unaryRequestIDInterceptor())
 	stream = append(stream, streamRequestIDInterceptor())
 
+	if config.tracer != nil {
+		unary = append(unary, unaryTracingInterceptor(config.tracer))
+		stream = append(stream, streamTracingInterceptor(config.tracer))
+	}
+
 	// Add ServiceContext propagation interceptor
 	if config.appContext != nil {
 		unary = append(unary, unaryServiceContextInterceptor(config.appContext))
 		stream = append(stream, streamServiceContextInterceptor(config.appContext))
 	}
 
+	events := []logging.LoggableEvent{logging.StartCall, logging.FinishCall}
+	var redactors []*PayloadRedactor
+	if config.payloadRedactor != nil {
+		events = append(events, logging.PayloadReceived, logging.PayloadSent)
+		redactors = append(redactors, config.payloadRedactor)
+	}
 	loggingOpts := []logging.Option{
-		logging.WithLogOnEvents(logging.StartCall, logging.FinishCall),
+		logging.WithLogOnEvents(events...),
+	}
+	unary = append(unary, logging.UnaryServerInterceptor(InterceptorLogger(config.log, redactors...), loggingOpts...))
+	stream = append(stream, logging.StreamServerInterceptor(InterceptorLogger(config.log, redactors...), loggingOpts...))
+
+	if config.limiter != nil {
+		unary = append(unary, unaryRateLimitInterceptor(config.limiter))
+		stream = append(stream, streamRateLimitInterceptor(config.limiter))
 	}
-	unary = append(unary, logging.UnaryServerInterceptor(InterceptorLogger(config.log), loggingOpts...))
-	stream = append(stream, logging.StreamServerInterceptor(InterceptorLogger(config.log), loggingOpts...))
 
 	switch config.authMode {
 	case "jwt":
@@ -232,6 +271,16 @@ func buildInterceptors(config ServerConfig) ([]grpc.UnaryServerInterceptor, []gr
 		}
 	}
 
+	if len(config.scopeRules) > 0 {
+		unary = append(unary, unaryScopeInterceptor(config))
+		stream = append(stream, streamScopeInterceptor(config))
+	}
+
+	if len(config.methodRoles) > 0 {
+		unary = append(unary, unaryRoleInterceptor(config))
+		stream = append(stream, streamRoleInterceptor(config))
+	}
+
 	if config.enableMetrics {
 		grpc_prometheus.EnableHandlingTimeHistogram()
 		unary = append(unary, grpc_prometheus.UnaryServerInterceptor)
@@ -309,22 +358,38 @@ type serverStreamWithContext struct {
 
 func (w *serverStreamWithContext) Context() context.Context { return w.ctx }
 
-// InterceptorLogger is a simple logging manager
-func InterceptorLogger(l *log.Log) logging.Logger {
+// InterceptorLogger is a simple logging manager. Given a PayloadRedactor, it logs the request
+// and response payloads at Debug level with their sensitive fields redacted, and skips them
+// when Debug is disabled.
+func InterceptorLogger(l *log.Log, redactor ...*PayloadRedactor) logging.Logger {
+	var payloads *PayloadRedactor
+	if len(redactor) > 0 {
+		payloads = redactor[0]
+	}
+
 	// convert key-value pairs from interceptor to zap fields
-	toZapFields := func(kvs ...any) []zap.Field {
+	toZapFields := func(kvs ...any) ([]zap.Field, bool) {
 		zfs := make([]zap.Field, 0, len(kvs)/2)
+		hasPayload := false
 		for i := 0; i+1 < len(kvs); i += 2 {
 			key, ok := kvs[i].(string)
 			if !ok {
 				key = fmt.Sprintf("arg_%d", i)
 			}
-			zfs = append(zfs, zap.Any(key, kvs[i+1]))
+			value := kvs[i+1]
+			if payloads != nil && isPayloadField(key) {
+				hasPayload = true
+				value = payloads.RedactValue(value)
+			}
+			zfs = append(zfs, zap.Any(key, value))
 		}
-		return zfs
+		return zfs, hasPayload
 	}
 
 	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		if payloads != nil && hasPayloadField(fields) && !l.Enabled(zapcore.DebugLevel) {
+			return
+		}
 		requestID, _ := ctx.Value(constant.RequestID).(types.StringConstant)
 		correlationID, _ := ctx.Value(constant.CorrelationIDHeader).(types.StringConstant)
 
@@ -332,7 +397,12 @@ func InterceptorLogger(l *log.Log) logging.Logger {
 			zap.String("correlation_id", string(correlationID)),
 			zap.String("request_id", string(requestID)),
 		}
-		zFields = append(zFields, toZapFields(fields...)...)
+		payloadFields, hasPayload := toZapFields(fields...)
+		zFields = append(zFields, payloadFields...)
+		if hasPayload {
+			l.Debug(msg, zFields...)
+			return
+		}
 
 		// #nosec: G115 - We are intentionally converting logging.Level to zapcore.Level
 		// The logging.Level is guaranteed to be within the valid range for zapcore.Level
@@ -354,6 +424,17 @@ func InterceptorLogger(l *log.Log) logging.Logger {
 	})
 }
 
+// hasPayloadField reports whether the key-value pairs of the logging interceptors hold a
+// payload.
+func hasPayloadField(kvs []any) bool {
+	for i := 0; i+1 < len(kvs); i += 2 {
+		if key, ok := kvs[i].(string); ok && isPayloadField(key) {
+			return true
+		}
+	}
+	return false
+}
+
 // recoveryHandler handles panics in gRPC calls
 func recoveryHandler(p interface{}) error {
 	helpers.Println(constant.ERROR, "panic recovered: ", p)
@@ -484,6 +565,7 @@ func unaryPasetoAuthInterceptor(config ServerConfig) grpc.UnaryServerInterceptor
 			zap.String("method", info.FullMethod),
 			zap.String("user_id", cl.Sub),
 		)
+		auditImpersonation(config, info.FullMethod, cl)
 
 		return handler(ctx, req)
 	}
@@ -544,10 +626,24 @@ func streamPasetoAuthInterceptor(config ServerConfig) grpc.StreamServerIntercept
 			zap.String("method", info.FullMethod),
 			zap.String("user_id", cl.Sub),
 		)
+		auditImpersonation(config, info.FullMethod, cl)
 
 		wrapped := &serverStreamWithContext{ServerStream: ss, ctx: ctx}
-		return handler(srv, wrapped)
+		return handleWithReauth(config, srv, wrapped, info, handler, token, cl)
+	}
+}
+
+// auditImpersonation logs the calls made with an impersonation token.
+func auditImpersonation(config ServerConfig, fullMethod string, cl *claims.StandardClaims) {
+	if !cl.IsImpersonated() {
+		return
 	}
+	config.log.Info(constant.ImpersonatedCall,
+		zap.String("method", fullMethod),
+		zap.String(constant.UserID, cl.Sub),
+		zap.String(constant.ActorID, cl.ActorSubject()),
+		zap.Strings("actor_chain", cl.ActorChain()),
+	)
 }
 
 // populateContextWithClaims adds claim values to the context.
@@ -565,13 +661,13 @@ func populateContextWithClaims(ctx context.Context, cl *claims.StandardClaims) c
 	if cl.Jti != "" {
 		ctx = context.WithValue(ctx, types.StringConstant(constant.TokenID), cl.Jti)
 	}
+	if cl.IsImpersonated() {
+		ctx = context.WithValue(ctx, types.StringConstant(constant.ActorID), cl.ActorSubject())
+	}
 	if cl.Data != nil {
-		// if svc, ok := cl.Data["service"].(string); ok {
-		// 	ctx = context.WithValue(ctx, types.StringConstant(constant.Service), svc)
-		// }
-		// if roles, ok := cl.Data["roles"].([]string); ok {
-		// 	ctx = context.WithValue(ctx, types.StringConstant(constant.Roles), roles)
-		// }
+		if roles := cl.Roles(); roles != nil {
+			ctx = context.WithValue(ctx, types.StringConstant(constant.Roles), roles)
+		}
 		// Store all custom data
 		ctx = context.WithValue(ctx, types.StringConstant(constant.ClaimsData), cl.Data)
 	}
@@ -610,6 +706,15 @@ func GetUserIDFromContext(ctx context.Context) string {
 	return ""
 }
 
+// GetActorIDFromContext extracts the actor of an impersonation token from a gRPC context, or
+// "" when the user acts itself.
+func GetActorIDFromContext(ctx context.Context) string {
+	if actorID, ok := ctx.Value(types.StringConstant(constant.ActorID)).(string); ok {
+		return actorID
+	}
+	return ""
+}
+
 // GetRolesFromContext extracts the roles from a gRPC context.
 func GetRolesFromContext(ctx context.Context) []string {
 	if roles, ok := ctx.Value(types.StringConstant(constant.Roles)).([]string); ok {
@@ -624,15 +729,40 @@ func (s *Server) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
+	diagnostics.RegisterListener("grpc", lis.Addr().String())
 	s.config.log.Info(fmt.Sprintf("Starting gRPC server on port %d", s.config.port))
+	s.startDebugEndpoint()
 
-	return s.server.Serve(lis)
+	return s.serve(lis)
+}
+
+// CertificateExpiry returns when the TLS certificate currently served expires, and false
+// without TLS. It follows the rotations of WithCertReload, e.g. for health checks.
+func (s *Server) CertificateExpiry() (time.Time, bool) {
+	if s.certReloader == nil {
+		return time.Time{}, false
+	}
+	return s.certReloader.expiry(), true
 }
 
 // GracefulStop stops the gRPC server gracefully
 func (s *Server) GracefulStop() {
 	ctx, cancel := context.WithTimeout(context.Background(), constant.ServerDefaultGracefulTime)
 	defer cancel()
+	s.Shutdown(ctx)
+}
+
+// Shutdown stops the gRPC server gracefully, waiting for the in-flight calls until ctx is done
+// before closing the remaining connections.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.stopDebugEndpoint(ctx)
+	s.stopGateway(ctx)
+	if s.config.limiter != nil {
+		s.config.limiter.Stop()
+	}
+	if s.certReloader != nil {
+		s.certReloader.close()
+	}
 
 	stopped := make(chan struct{})
 	go func() {
@@ -645,108 +775,9 @@ func (s *Server) GracefulStop() {
 		s.server.Stop()
 	case <-stopped:
 	}
-}
-
-// Will remove the commented code
-
-/*
-		lis, err := net.Listen("tcp", ":50051")
-		if err != nil {
-			log.Fatalf("failed to listen: %v", err)
-		}
-
-		s := grpc.NewServer()
-		pb.RegisterDiscoveryServiceServer(s, &server{})
-		log.Printf("server listening at %v", lis.Addr())
-		if err := s.Serve(lis); err != nil {
-			log.Fatalf("failed to serve: %v", err)
-		}
-
-
-
-
-type server struct {
-	pb.UnimplementedDiscoveryServiceServer
-}
-
-func (s *server) ProcessDiscovery(ctx context.Context, req *pb.DiscoveryMessage) (*pb.DiscoveryMessage, error) {
-	// Unpack core payload
-	var core pb.Core
-	if err := req.Payload.UnmarshalTo(&core); err != nil {
-		return nil, fmt.Errorf("failed to unpack payload: %v", err)
-	}
-
-	// Process the core data
-	log.Printf("Processing request from service: %s", req.CurrentService)
-	log.Printf("Core data: %+v", core)
-
-	// Create response
-	resPayload, _ := anypb.New(&pb.Core{
-		User:    core.User,
-		Primary: core.Primary,
-		Input:   core.Input,
-	})
-
-	return &pb.DiscoveryMessage{
-		CorrelationId:  req.CorrelationId,
-		RequestId:      req.RequestId,
-		Payload:        resPayload,
-		Status:         pb.DiscoveryMessage_STATUS_COMPLETED,
-		Timestamp:      timestamppb.New(time.Now()),
-		CurrentService: "discovery-service",
-	}, nil
+	s.closeGatewayListener()
 }
 
-/*
-		conn, err := grpc.NewClient("localhost:50051", grpc.WithInsecure())
-		if err != nil {
-			fmt.Printf("did not connect: %v", err)
-		}
-		defer conn.Close()
-
-		c := pb.NewDiscoveryServiceClient(conn)
-
-		// Create core payload
-		core := &pb.Core{
-			User: &pb.Core_UserInformation{
-				UserId: "user-123",
-				Email:  "user@example.com",
-			},
-			Primary: &pb.Core_PrimaryInfo{
-				PrimaryId:   "primary-456",
-				PrimaryType: "default",
-			},
-		}
-
-		payload, _ := anypb.New(core)
-
-		// Create request
-		reqg := &pb.DiscoveryMessage{
-			CorrelationId:  "corr-001",
-			RequestId:      "req-002",
-			Payload:        payload,
-			Status:         pb.DiscoveryMessage_STATUS_PENDING,
-			Action:         pb.DiscoveryMessage_ACTION_EXECUTE,
-			Timestamp:      timestamppb.New(time.Now()),
-			CurrentService: "client-service",
-		}
-
-		// Send request
-		ctxg, cancel := ct.WithTimeout(ct.Background(), 5*time.Second)
-		defer cancel()
-
-		resg, err := c.ProcessDiscovery(ctxg, reqg)
-		if err != nil {
-			fmt.Printf("RPC failed: %v", err)
-		}
-
-		fmt.Printf("Response Status: %v", resg.Status)
-		fmt.Printf("Response Service: %s", resg.CurrentService)
-		fmt.Printf("Response Timestamp: %v", resg.Timestamp.AsTime())
-
-
-*/
-
 /*
 USAGE EXAMPLES
 
@@ -783,6 +814,19 @@ func startPasetoServer(pubKey ed25519.PublicKey) error {
     return s.Start()
 }
 
+// ===== Server: serve JSON/REST on the gRPC port through grpc-gateway =====
+func startGatewayServer(pm *paseto.PasetoManager) error {
+    ns, err := NewNeuronServer(
+        WithPort(50051),
+        WithAuthMode("paseto"),
+        WithPasetoManager(pm),
+        WithServiceRegistrar(func(s *grpc.Server) { pb.RegisterYourServiceServer(s, yourImpl) }),
+        WithGateway(pb.RegisterYourServiceHandler),
+    )
+    if err != nil { return err }
+    return ns.Start()
+}
+
 // ===== Unary handler: read correlation_id/request_id from context =====
 func (h *handler) SomeRPC(ctx context.Context, req *pb.SomeRequest) (*pb.SomeReply, error) {
     corr, _ := ctx.Value(types.StringConstant(constant.CorrelationIDHeader)).(types.StringConstant)
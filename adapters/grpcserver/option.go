@@ -1,9 +1,15 @@
 package grpcmanager
 
 import (
+	"time"
+
 	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/adapters/paseto"
 	neuronctx "github.com/abhissng/neuron/context"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
 )
 
 // ServerConfig holds gRPC server configurations
@@ -24,6 +30,19 @@ type ServerConfig struct {
 	serviceRegistrar ServiceRegistrar
 	customValidator  CustomValidatorFunc
 	skipAuthMethods  map[string]bool
+	serverOptions    []grpc.ServerOption
+	reflection       bool
+	debugAddr        string
+	rateLimits       []rateLimitRule
+	rateLimitTTL     time.Duration
+	limiter          *peerRateLimiter
+	tracer           trace.Tracer
+	gateway          *gatewayConfig
+	scopeRules       map[string][]string
+	streamReauth     map[string]streamReauthRule
+	methodRoles      map[string][]string
+	certReload       time.Duration
+	payloadRedactor  *PayloadRedactor
 }
 
 // Option is a function that modifies ServerConfig
@@ -135,3 +154,164 @@ func WithServiceName(name string) Option {
 		c.serviceName = name
 	}
 }
+
+// WithServerOptions appends raw grpc.ServerOptions, e.g. those returned by
+// grpcbridge.Bridge.ServerOptions.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(c *ServerConfig) {
+		c.serverOptions = append(c.serverOptions, opts...)
+	}
+}
+
+// WithReflection registers the gRPC reflection service so that tools such as grpcurl can list
+// and call the services. Reflection calls skip authentication. Ignored in production.
+func WithReflection() Option {
+	return func(c *ServerConfig) {
+		c.reflection = true
+		WithSkipAuthMethods(reflectionMethods...)(c)
+	}
+}
+
+// WithCertReload reloads the WithTLS certificate, key and CA files when they change, checked
+// every interval (DefaultCertReloadInterval when zero), and on SIGHUP, so certificates rotate
+// without a restart. New handshakes use the new certificates; a failed reload is logged and
+// keeps the previous ones. With metrics enabled, the certificate expiry is exported as
+// grpc_server_certificate_expiry_timestamp_seconds.
+func WithCertReload(interval time.Duration) Option {
+	return func(c *ServerConfig) {
+		if interval <= 0 {
+			interval = DefaultCertReloadInterval
+		}
+		c.certReload = interval
+	}
+}
+
+// WithPayloadLogging logs the request and response protos of every call at Debug level, with
+// the fields matching DefaultSensitiveFieldPattern, the given patterns and field names, or
+// annotated with debug_redact redacted. Payloads are not serialized when Debug is disabled.
+func WithPayloadLogging(opts ...PayloadRedactorOption) Option {
+	return func(c *ServerConfig) {
+		c.payloadRedactor = NewPayloadRedactor(opts...)
+	}
+}
+
+// WithDebugEndpoint serves the registered services and methods as JSON at DebugPath, and the
+// startup diagnostics at diagnostics.Path, on addr, e.g. "localhost:6061", while the server
+// runs. Ignored in production.
+func WithDebugEndpoint(addr string) Option {
+	return func(c *ServerConfig) {
+		c.debugAddr = addr
+	}
+}
+
+// WithRateLimit limits each peer to r calls per second with bursts of burst on method: a full
+// method ("/package.Service/Method"), a service ("/package.Service/*") or AllMethods. A call
+// is limited by its most specific limit; calls over it fail with ResourceExhausted and a
+// retry-after header.
+func WithRateLimit(method string, r rate.Limit, burst int) Option {
+	return func(c *ServerConfig) {
+		c.rateLimits = append(c.rateLimits, rateLimitRule{method: method, limit: r, burst: burst})
+	}
+}
+
+// WithRateLimitTTL sets how long the buckets of inactive peers are kept. Defaults to
+// DefaultRateLimitTTL.
+func WithRateLimitTTL(ttl time.Duration) Option {
+	return func(c *ServerConfig) {
+		c.rateLimitTTL = ttl
+	}
+}
+
+// WithTracer creates a span per call with tracer, continuing the trace of the traceparent in
+// the call metadata. The trace context is read with the propagator set by
+// otel.SetTextMapPropagator.
+func WithTracer(tracer trace.Tracer) Option {
+	return func(c *ServerConfig) {
+		c.tracer = tracer
+	}
+}
+
+// WithRequiredScopes requires the scopes of the Paseto token for calls to method: a full
+// method ("/package.Service/Method"), a service ("/package.Service/*") or AllMethods. A call
+// needs the scopes of its most specific rule and fails with PermissionDenied without them,
+// or without Paseto claims at all, e.g. under JWT auth. Methods skipping auth are not checked.
+func WithRequiredScopes(method string, scopes ...string) Option {
+	return func(c *ServerConfig) {
+		if c.scopeRules == nil {
+			c.scopeRules = make(map[string][]string)
+		}
+		c.scopeRules[method] = scopes
+	}
+}
+
+// WithMethodRoles requires callers to hold one of the roles of their method, read from the
+// Paseto or JWT claims. Keys are full methods ("/package.Service/Method"), services
+// ("/package.Service/*") or AllMethods; a call is checked against its most specific entry and
+// fails with PermissionDenied without any of its roles. Methods skipping auth are not checked.
+func WithMethodRoles(roles map[string][]string) Option {
+	return func(c *ServerConfig) {
+		if c.methodRoles == nil {
+			c.methodRoles = make(map[string][]string, len(roles))
+		}
+		for method, r := range roles {
+			c.methodRoles[method] = r
+		}
+	}
+}
+
+// WithStreamReauth validates the Paseto token of the streams of method again every interval,
+// and when it expires: a full method ("/package.Service/Method"), a service
+// ("/package.Service/*") or AllMethods. A stream whose token fails is ended with
+// Unauthenticated after grace. interval defaults to DefaultReauthInterval.
+func WithStreamReauth(method string, interval, grace time.Duration) Option {
+	return func(c *ServerConfig) {
+		if c.streamReauth == nil {
+			c.streamReauth = make(map[string]streamReauthRule)
+		}
+		c.streamReauth[method] = streamReauthRule{interval: interval, grace: grace}
+	}
+}
+
+// WithGateway serves the services as JSON/REST through grpc-gateway, with the handlers
+// registered by registrars. The gateway shares the gRPC port unless WithGatewayPort is set;
+// its calls go through the server interceptors and failures are written as blame errors.
+func WithGateway(registrars ...GatewayRegistrar) Option {
+	return func(c *ServerConfig) {
+		if c.gateway == nil {
+			c.gateway = &gatewayConfig{}
+		}
+		c.gateway.registrars = append(c.gateway.registrars, registrars...)
+	}
+}
+
+// WithGatewayPort serves the gateway on its own port instead of sharing the gRPC port.
+// Required when TLS is enabled.
+func WithGatewayPort(port int) Option {
+	return func(c *ServerConfig) {
+		if c.gateway == nil {
+			c.gateway = &gatewayConfig{}
+		}
+		c.gateway.port = port
+	}
+}
+
+// WithGatewayMuxOptions appends options to the gateway mux, e.g. runtime.WithMarshalerOption.
+func WithGatewayMuxOptions(opts ...runtime.ServeMuxOption) Option {
+	return func(c *ServerConfig) {
+		if c.gateway == nil {
+			c.gateway = &gatewayConfig{}
+		}
+		c.gateway.muxOptions = append(c.gateway.muxOptions, opts...)
+	}
+}
+
+// WithGatewayDialOptions sets the options the gateway uses to dial the gRPC server. It dials
+// without transport security by default, so TLS servers must provide credentials here.
+func WithGatewayDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *ServerConfig) {
+		if c.gateway == nil {
+			c.gateway = &gatewayConfig{}
+		}
+		c.gateway.dialOptions = append(c.gateway.dialOptions, opts...)
+	}
+}
@@ -0,0 +1,222 @@
+package grpcmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DefaultSensitiveFieldPattern matches the proto field names redacted by default: credentials,
+// one-time codes and card or identity numbers, as whole words of snake_case names.
+var DefaultSensitiveFieldPattern = regexp.MustCompile(
+	`(?i)(^|_)(password|passwd|secret|token|otp|pin|cvv|ssn|pan|aadhaar|authorization|api_key|private_key|access_key|card_number)($|_)`,
+)
+
+// defaultPayloadMask replaces the redacted string fields.
+const defaultPayloadMask = "****"
+
+// PayloadRedactor redacts the sensitive fields of logged request and response protos. A field
+// is sensitive when its name matches a pattern, its full name (e.g. "mypackage.User.email") is
+// listed, or it is annotated with the debug_redact field option. Sensitive string fields are
+// masked; other sensitive fields are cleared. The JSON of the payload is then redacted by key
+// with the patterns, which covers the contents of google.protobuf.Struct and Any fields and
+// payloads that are not protos.
+type PayloadRedactor struct {
+	patterns []*regexp.Regexp
+	fields   map[protoreflect.FullName]bool
+	mask     string
+}
+
+// PayloadRedactorOption configures a PayloadRedactor.
+type PayloadRedactorOption func(*PayloadRedactor)
+
+// WithRedactedFieldPattern redacts the fields whose name matches pattern, in addition to
+// DefaultSensitiveFieldPattern.
+func WithRedactedFieldPattern(pattern *regexp.Regexp) PayloadRedactorOption {
+	return func(r *PayloadRedactor) {
+		r.patterns = append(r.patterns, pattern)
+	}
+}
+
+// WithRedactedFields redacts the fields of the given full names, e.g. "mypackage.User.email".
+func WithRedactedFields(fullNames ...string) PayloadRedactorOption {
+	return func(r *PayloadRedactor) {
+		for _, name := range fullNames {
+			r.fields[protoreflect.FullName(strings.TrimPrefix(name, "."))] = true
+		}
+	}
+}
+
+// WithPayloadMask sets the replacement of redacted string fields. Defaults to "****".
+func WithPayloadMask(mask string) PayloadRedactorOption {
+	return func(r *PayloadRedactor) {
+		r.mask = mask
+	}
+}
+
+// NewPayloadRedactor creates a PayloadRedactor with DefaultSensitiveFieldPattern.
+func NewPayloadRedactor(opts ...PayloadRedactorOption) *PayloadRedactor {
+	r := &PayloadRedactor{
+		patterns: []*regexp.Regexp{DefaultSensitiveFieldPattern},
+		fields:   make(map[protoreflect.FullName]bool),
+		mask:     defaultPayloadMask,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// unmarshalablePayload is logged for payloads that cannot be encoded.
+var unmarshalablePayload = json.RawMessage(`"[unmarshalable payload]"`)
+
+// Redact returns the JSON of a copy of m with its sensitive fields redacted.
+func (r *PayloadRedactor) Redact(m proto.Message) json.RawMessage {
+	clone := proto.Clone(m)
+	r.redact(clone.ProtoReflect())
+	data, err := protojson.Marshal(clone)
+	if err != nil {
+		return unmarshalablePayload
+	}
+	return r.redactJSON(data)
+}
+
+// RedactValue returns the JSON of v with its sensitive fields redacted, using Redact for
+// protos and the JSON keys otherwise.
+func (r *PayloadRedactor) RedactValue(v any) json.RawMessage {
+	if m, ok := v.(proto.Message); ok {
+		return r.Redact(m)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return unmarshalablePayload
+	}
+	return r.redactJSON(data)
+}
+
+// redactJSON redacts the members of the objects in data whose keys are sensitive.
+func (r *PayloadRedactor) redactJSON(data []byte) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return unmarshalablePayload
+	}
+	redacted, err := json.Marshal(r.redactJSONValue(value))
+	if err != nil {
+		return unmarshalablePayload
+	}
+	return redacted
+}
+
+// redactJSONValue redacts value and the values nested in it.
+func (r *PayloadRedactor) redactJSONValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, member := range v {
+			if !r.sensitiveKey(key) {
+				v[key] = r.redactJSONValue(member)
+				continue
+			}
+			if _, ok := member.(string); ok {
+				v[key] = r.mask
+			} else {
+				delete(v, key)
+			}
+		}
+	case []any:
+		for i, element := range v {
+			v[i] = r.redactJSONValue(element)
+		}
+	}
+	return value
+}
+
+// sensitiveKey reports whether a JSON key matches the patterns. lowerCamelCase keys, as
+// written by protojson, are matched by their snake_case form.
+func (r *PayloadRedactor) sensitiveKey(key string) bool {
+	name := snakeCase(key)
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// snakeCase converts a lowerCamelCase name such as cardNumber to card_number.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		if unicode.IsUpper(c) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// redact redacts the sensitive fields of m and of its nested messages in place.
+func (r *PayloadRedactor) redact(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if r.sensitive(fd) {
+			if fd.Kind() == protoreflect.StringKind && fd.Cardinality() != protoreflect.Repeated {
+				m.Set(fd, protoreflect.ValueOfString(r.mask))
+			} else {
+				m.Clear(fd)
+			}
+			return true
+		}
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+					r.redact(value.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				list := v.List()
+				for i := 0; i < list.Len(); i++ {
+					r.redact(list.Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			r.redact(v.Message())
+		}
+		return true
+	})
+}
+
+// sensitive reports whether fd is redacted.
+func (r *PayloadRedactor) sensitive(fd protoreflect.FieldDescriptor) bool {
+	if r.fields[fd.FullName()] {
+		return true
+	}
+	if options, ok := fd.Options().(*descriptorpb.FieldOptions); ok && options.GetDebugRedact() {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(string(fd.Name())) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPayloadField reports whether key is a payload field of the logging interceptors, e.g.
+// "grpc.request.content".
+func isPayloadField(key string) bool {
+	return strings.HasPrefix(key, "grpc.") && strings.HasSuffix(key, ".content")
+}
@@ -0,0 +1,192 @@
+package grpcmanager
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// AllMethods is the WithRateLimit method matching every method without a more specific limit.
+	AllMethods = "*"
+	// RetryAfterMetadata is the header telling rate limited clients how many seconds to wait.
+	RetryAfterMetadata = "retry-after"
+	// RateLimitMetadata is the header holding the burst of the exceeded limit.
+	RateLimitMetadata = "x-ratelimit-limit"
+	// DefaultRateLimitTTL is how long the bucket of an inactive peer is kept.
+	DefaultRateLimitTTL = 5 * time.Minute
+)
+
+// rateLimitRule is a limit set by WithRateLimit.
+type rateLimitRule struct {
+	method string
+	limit  rate.Limit
+	burst  int
+}
+
+// peerBucket is the token bucket of a peer for a rule.
+type peerBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// peerRateLimiter keeps a token bucket per peer and rule, like the IPRateLimiter of the gin
+// middleware. A call is limited by the most specific rule matching its method: the method
+// itself, then its service ("/package.Service/*"), then AllMethods.
+type peerRateLimiter struct {
+	rules    map[string]rateLimitRule
+	ttl      time.Duration
+	mu       sync.Mutex
+	buckets  map[string]*peerBucket
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// newPeerRateLimiter creates the limiter of rules and starts its cleanup.
+func newPeerRateLimiter(rules []rateLimitRule, ttl time.Duration) *peerRateLimiter {
+	if ttl <= 0 {
+		ttl = DefaultRateLimitTTL
+	}
+	l := &peerRateLimiter{
+		rules:   make(map[string]rateLimitRule, len(rules)),
+		ttl:     ttl,
+		buckets: make(map[string]*peerBucket),
+		stop:    make(chan struct{}),
+	}
+	for _, rule := range rules {
+		l.rules[rule.method] = rule
+	}
+	go l.cleanup()
+	return l
+}
+
+// rule returns the rule limiting fullMethod.
+func (l *peerRateLimiter) rule(fullMethod string) (rateLimitRule, bool) {
+	return methodRule(l.rules, fullMethod)
+}
+
+// methodRule returns the most specific rule of fullMethod: the method itself, then its
+// service ("/package.Service/*"), then AllMethods.
+func methodRule[T any](rules map[string]T, fullMethod string) (T, bool) {
+	if rule, ok := rules[fullMethod]; ok {
+		return rule, true
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		if rule, ok := rules[fullMethod[:i+1]+AllMethods]; ok {
+			return rule, true
+		}
+	}
+	rule, ok := rules[AllMethods]
+	return rule, ok
+}
+
+// allow takes a token for the call of ctx to fullMethod. When none is available it returns
+// the time until one is and the burst of the limit.
+func (l *peerRateLimiter) allow(ctx context.Context, fullMethod string) (bool, time.Duration, int) {
+	rule, ok := l.rule(fullMethod)
+	if !ok {
+		return true, 0, 0
+	}
+	now := time.Now()
+	key := peerAddress(ctx) + "|" + rule.method
+
+	l.mu.Lock()
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = &peerBucket{limiter: rate.NewLimiter(rule.limit, rule.burst)}
+		l.buckets[key] = bucket
+	}
+	bucket.lastSeen = now
+	l.mu.Unlock()
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second, rule.burst
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay, rule.burst
+	}
+	return true, 0, rule.burst
+}
+
+// cleanup periodically removes the buckets of inactive peers.
+func (l *peerRateLimiter) cleanup() {
+	interval := max(l.ttl/2, time.Minute)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case now := <-ticker.C:
+			l.mu.Lock()
+			for key, bucket := range l.buckets {
+				if now.Sub(bucket.lastSeen) > l.ttl {
+					delete(l.buckets, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// Stop stops the cleanup of the limiter.
+func (l *peerRateLimiter) Stop() {
+	l.stopOnce.Do(func() { close(l.stop) })
+}
+
+// exhausted returns the ResourceExhausted status and the headers of a limited call.
+func exhausted(retryAfter time.Duration, burst int) (metadata.MD, error) {
+	seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+	md := metadata.Pairs(RetryAfterMetadata, seconds, RateLimitMetadata, strconv.Itoa(burst))
+	return md, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %ss", seconds)
+}
+
+// unaryRateLimitInterceptor rejects the unary calls exceeding their limit.
+func unaryRateLimitInterceptor(l *peerRateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if ok, retryAfter, burst := l.allow(ctx, info.FullMethod); !ok {
+			md, err := exhausted(retryAfter, burst)
+			_ = grpc.SetHeader(ctx, md)
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamRateLimitInterceptor rejects the streams exceeding their limit.
+func streamRateLimitInterceptor(l *peerRateLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if ok, retryAfter, burst := l.allow(ss.Context(), info.FullMethod); !ok {
+			md, err := exhausted(retryAfter, burst)
+			_ = ss.SetHeader(md)
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// peerAddress returns the host of the peer of ctx.
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
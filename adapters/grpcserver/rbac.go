@@ -0,0 +1,58 @@
+package grpcmanager
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkRoles fails with PermissionDenied when the caller of ctx has none of the roles
+// fullMethod requires, set by its most specific WithMethodRoles rule. The roles are those of
+// the Paseto or JWT claims stored by the auth interceptors.
+func checkRoles(ctx context.Context, config ServerConfig, fullMethod string) error {
+	if config.skipAuthMethods[fullMethod] {
+		return nil
+	}
+	required, _ := methodRule(config.methodRoles, fullMethod)
+	if len(required) == 0 {
+		return nil
+	}
+	roles := GetRolesFromContext(ctx)
+	for _, role := range required {
+		if slices.Contains(roles, role) {
+			return nil
+		}
+	}
+	config.log.Warn("Role not allowed",
+		zap.String("method", fullMethod),
+		zap.String("user_id", GetUserIDFromContext(ctx)),
+		zap.Strings("roles", roles),
+		zap.Strings("required_roles", required),
+	)
+	return status.Errorf(codes.PermissionDenied, "requires one of the roles: %s", strings.Join(required, ", "))
+}
+
+// unaryRoleInterceptor enforces the WithMethodRoles rules on unary calls.
+func unaryRoleInterceptor(config ServerConfig) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkRoles(ctx, config, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamRoleInterceptor enforces the WithMethodRoles rules on streams.
+func streamRoleInterceptor(config ServerConfig) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkRoles(ss.Context(), config, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,159 @@
+package grpcmanager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/paseto"
+	"github.com/abhissng/neuron/utils/structures/claims"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultReauthInterval is how often the token of a stream is validated again when its
+// WithStreamReauth rule sets no interval.
+const DefaultReauthInterval = time.Minute
+
+// errStreamReauth is returned by the streams whose token is no longer valid.
+var errStreamReauth = status.Error(codes.Unauthenticated, "token is no longer valid, re-authenticate the stream")
+
+// streamReauthRule is a re-authentication rule set by WithStreamReauth.
+type streamReauthRule struct {
+	interval time.Duration
+	grace    time.Duration
+}
+
+// reauthServerStream is a stream whose token is validated again while it runs. Once the token
+// fails and the grace period is over, its context is cancelled and its messages fail.
+type reauthServerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	expired chan struct{}
+	once    sync.Once
+}
+
+func (s *reauthServerStream) Context() context.Context { return s.ctx }
+
+func (s *reauthServerStream) SendMsg(m any) error {
+	if s.isExpired() {
+		return errStreamReauth
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *reauthServerStream) RecvMsg(m any) error {
+	if s.isExpired() {
+		return errStreamReauth
+	}
+	return s.ServerStream.RecvMsg(m)
+}
+
+// expire marks the stream as expired.
+func (s *reauthServerStream) expire() {
+	s.once.Do(func() { close(s.expired) })
+}
+
+// isExpired reports whether the stream expired.
+func (s *reauthServerStream) isExpired() bool {
+	select {
+	case <-s.expired:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleWithReauth runs the stream handler and validates token again on the schedule of the
+// WithStreamReauth rule of the method, if any. When the token fails, the stream is ended with
+// Unauthenticated once the grace period is over.
+func handleWithReauth(config ServerConfig, srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler, token string, cl *claims.StandardClaims) error {
+	rule, ok := methodRule(config.streamReauth, info.FullMethod)
+	if !ok {
+		return handler(srv, ss)
+	}
+
+	ctx, cancel := context.WithCancel(ss.Context())
+	defer cancel()
+	stream := &reauthServerStream{ServerStream: ss, ctx: ctx, expired: make(chan struct{})}
+	go watchStreamToken(config, stream, cancel, info.FullMethod, token, cl, rule)
+
+	done := make(chan error, 1)
+	go func() { done <- handler(srv, stream) }()
+
+	select {
+	case err := <-done:
+		if stream.isExpired() {
+			return errStreamReauth
+		}
+		return err
+	case <-stream.expired:
+		return errStreamReauth
+	}
+}
+
+// watchStreamToken validates token again every interval, and at its expiry, until the stream
+// ends. A failed validation expires the stream after the grace period.
+func watchStreamToken(config ServerConfig, stream *reauthServerStream, cancel context.CancelFunc, fullMethod, token string, cl *claims.StandardClaims, rule streamReauthRule) {
+	interval := rule.interval
+	if interval <= 0 {
+		interval = DefaultReauthInterval
+	}
+
+	for {
+		wait := interval
+		if untilExpiry := time.Until(cl.Exp); untilExpiry > 0 && untilExpiry < wait {
+			wait = untilExpiry
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-stream.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		err := revalidateStreamToken(stream.ctx, config, fullMethod, token)
+		if err == nil {
+			continue
+		}
+		config.log.Warn("Stream token is no longer valid",
+			zap.String("method", fullMethod),
+			zap.String("user_id", cl.Sub),
+			zap.Duration("grace", rule.grace),
+			zap.Error(err),
+		)
+
+		if rule.grace > 0 {
+			grace := time.NewTimer(rule.grace)
+			select {
+			case <-stream.ctx.Done():
+				grace.Stop()
+				return
+			case <-grace.C:
+			}
+		}
+		stream.expire()
+		cancel()
+		return
+	}
+}
+
+// revalidateStreamToken validates token, and runs the custom validator on its claims, again.
+func revalidateStreamToken(ctx context.Context, config ServerConfig, fullMethod, token string) error {
+	res := config.pasetoManager.ValidateToken(token, nil, paseto.WithValidateEssentialTags)
+	if res.IsFailure() {
+		return errors.New(res.Blame().FetchErrCode().String())
+	}
+	cl, err := res.Value()
+	if err != nil {
+		return err
+	}
+	if config.customValidator != nil {
+		return config.customValidator(ctx, fullMethod, cl)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package htmlsanitizer
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"sync"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Preset identifies a named sanitization policy.
+type Preset string
+
+const (
+	// Strict removes every HTML element and keeps only text content.
+	Strict Preset = "strict"
+	// UGC allows the common formatting elements safe for user generated content.
+	UGC Preset = "ugc"
+	// RichText extends UGC with tables, code highlighting classes and images.
+	RichText Preset = "rich"
+	// Markdown renders markdown to HTML and then applies the RichText policy.
+	Markdown Preset = "markdown"
+)
+
+// HTMLSanitizer holds named bluemonday policies and a markdown renderer.
+// Policies are safe for concurrent use once registered.
+type HTMLSanitizer struct {
+	mu       sync.RWMutex
+	policies map[Preset]*bluemonday.Policy
+	markdown goldmark.Markdown
+}
+
+// Option defines a functional option for configuring HTMLSanitizer.
+type Option func(*HTMLSanitizer)
+
+// WithPolicy registers (or overrides) a named policy.
+func WithPolicy(name Preset, policy *bluemonday.Policy) Option {
+	return func(s *HTMLSanitizer) {
+		if policy != nil {
+			s.policies[name] = policy
+		}
+	}
+}
+
+// WithMarkdownRenderer overrides the goldmark renderer used for markdown input.
+func WithMarkdownRenderer(md goldmark.Markdown) Option {
+	return func(s *HTMLSanitizer) {
+		if md != nil {
+			s.markdown = md
+		}
+	}
+}
+
+// NewHTMLSanitizer creates a sanitizer with the Strict, UGC and RichText presets registered.
+func NewHTMLSanitizer(options ...Option) *HTMLSanitizer {
+	s := &HTMLSanitizer{
+		policies: map[Preset]*bluemonday.Policy{
+			Strict:   StrictPolicy(),
+			UGC:      UGCPolicy(),
+			RichText: RichTextPolicy(),
+		},
+		markdown: goldmark.New(goldmark.WithExtensions(extension.GFM)),
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// StrictPolicy returns a policy that strips all HTML.
+func StrictPolicy() *bluemonday.Policy {
+	return bluemonday.StrictPolicy()
+}
+
+// UGCPolicy returns a policy suitable for comments, descriptions and similar user input.
+// Links are forced to rel="nofollow noopener" and open in a new tab.
+func UGCPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// RichTextPolicy returns a policy for rich text editors and rendered markdown.
+func RichTextPolicy() *bluemonday.Policy {
+	p := UGCPolicy()
+	p.AllowTables()
+	p.AllowImages()
+	p.AllowDataURIImages()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9_+-]+$`)).OnElements("code")
+	p.AllowAttrs("checked", "disabled", "type").OnElements("input")
+	return p
+}
+
+// RegisterPolicy registers (or overrides) a named policy at runtime.
+func (s *HTMLSanitizer) RegisterPolicy(name Preset, policy *bluemonday.Policy) {
+	if policy == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policies[name] = policy
+}
+
+// policy returns the policy registered under name.
+func (s *HTMLSanitizer) policy(name Preset) (*bluemonday.Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.policies[name]
+	if !ok {
+		return nil, fmt.Errorf("htmlsanitizer: unknown policy %q", name)
+	}
+	return p, nil
+}
+
+// Sanitize cleans input with the named preset. The Markdown preset renders the input first.
+func (s *HTMLSanitizer) Sanitize(preset Preset, input string) (string, error) {
+	if preset == Markdown {
+		return s.MarkdownToSafeHTML(input)
+	}
+	p, err := s.policy(preset)
+	if err != nil {
+		return "", err
+	}
+	return p.Sanitize(input), nil
+}
+
+// StripTags removes all markup from input and returns plain text.
+func (s *HTMLSanitizer) StripTags(input string) string {
+	p, err := s.policy(Strict)
+	if err != nil {
+		return StrictPolicy().Sanitize(input)
+	}
+	return p.Sanitize(input)
+}
+
+// MarkdownToSafeHTML renders markdown to HTML and sanitizes the output with the RichText policy.
+// Raw HTML embedded in the markdown is escaped by the renderer and never reaches the output.
+func (s *HTMLSanitizer) MarkdownToSafeHTML(input string) (string, error) {
+	var buf bytes.Buffer
+	if err := s.markdown.Convert([]byte(input), &buf); err != nil {
+		return "", fmt.Errorf("htmlsanitizer: failed to render markdown: %w", err)
+	}
+	p, err := s.policy(RichText)
+	if err != nil {
+		return "", err
+	}
+	return p.Sanitize(buf.String()), nil
+}
+
+// IsSafe reports whether input is unchanged by the named preset, i.e. it carries no disallowed
+// markup. Both sides are compared with their entities decoded, since the sanitizer escapes
+// characters such as &, ' and " in the text it keeps.
+func (s *HTMLSanitizer) IsSafe(preset Preset, input string) bool {
+	if preset == Markdown {
+		preset = RichText
+	}
+	p, err := s.policy(preset)
+	if err != nil {
+		return false
+	}
+	return html.UnescapeString(p.Sanitize(input)) == html.UnescapeString(input)
+}
+
+// defaultSanitizer is shared by the package level helpers.
+var defaultSanitizer = NewHTMLSanitizer()
+
+// Default returns the package level HTMLSanitizer.
+func Default() *HTMLSanitizer {
+	return defaultSanitizer
+}
+
+// SanitizeUGC cleans input with the UGC preset of the default sanitizer.
+func SanitizeUGC(input string) string {
+	out, _ := defaultSanitizer.Sanitize(UGC, input)
+	return out
+}
+
+// StripTags removes all markup from input using the default sanitizer.
+func StripTags(input string) string {
+	return defaultSanitizer.StripTags(input)
+}
+
+// MarkdownToSafeHTML renders markdown to sanitized HTML using the default sanitizer.
+func MarkdownToSafeHTML(input string) (string, error) {
+	return defaultSanitizer.MarkdownToSafeHTML(input)
+}
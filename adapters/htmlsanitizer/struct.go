@@ -0,0 +1,100 @@
+package htmlsanitizer
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TagName is the struct tag read by SanitizeStruct, e.g. `sanitize:"ugc"`.
+const TagName = "sanitize"
+
+// SanitizeStruct walks the struct pointed to by v and rewrites every string field tagged with
+// `sanitize:"<preset>"` in place. Nested structs, pointers to structs and string slices are
+// supported; fields tagged with "-" are skipped.
+func (s *HTMLSanitizer) SanitizeStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("htmlsanitizer: SanitizeStruct requires a non-nil pointer to a struct")
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return errors.New("htmlsanitizer: SanitizeStruct requires a pointer to a struct")
+	}
+	return s.sanitizeStruct(rv, 0)
+}
+
+// sanitizeStruct applies field tags on a settable struct value.
+func (s *HTMLSanitizer) sanitizeStruct(rv reflect.Value, depth int) error {
+	const maxDepth = 8
+	if depth > maxDepth {
+		return nil
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		tag := strings.TrimSpace(field.Tag.Get(TagName))
+		if tag == "-" {
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.String:
+			if tag == "" {
+				continue
+			}
+			out, err := s.Sanitize(Preset(tag), fv.String())
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			fv.SetString(out)
+		case reflect.Slice:
+			if tag == "" || fv.Type().Elem().Kind() != reflect.String {
+				continue
+			}
+			for j := 0; j < fv.Len(); j++ {
+				out, err := s.Sanitize(Preset(tag), fv.Index(j).String())
+				if err != nil {
+					return fmt.Errorf("field %s[%d]: %w", field.Name, j, err)
+				}
+				fv.Index(j).SetString(out)
+			}
+		case reflect.Struct:
+			if err := s.sanitizeStruct(fv, depth+1); err != nil {
+				return err
+			}
+		case reflect.Pointer:
+			if fv.IsNil() {
+				continue
+			}
+			elem := fv.Elem()
+			switch elem.Kind() {
+			case reflect.Struct:
+				if err := s.sanitizeStruct(elem, depth+1); err != nil {
+					return err
+				}
+			case reflect.String:
+				if tag == "" {
+					continue
+				}
+				out, err := s.Sanitize(Preset(tag), elem.String())
+				if err != nil {
+					return fmt.Errorf("field %s: %w", field.Name, err)
+				}
+				elem.SetString(out)
+			}
+		}
+	}
+	return nil
+}
+
+// SanitizeStruct rewrites tagged string fields of v using the default sanitizer.
+func SanitizeStruct(v any) error {
+	return defaultSanitizer.SanitizeStruct(v)
+}
@@ -5,14 +5,14 @@ import (
 	"net/http"
 
 	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/random"
 	"github.com/abhissng/neuron/utils/types"
-	"github.com/google/uuid"
 )
 
 // Middleware adds a unique UUID to the context for each request.
 func RequestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		reqID := uuid.New().String()
+		reqID := random.GenerateUUIDString()
 		ctx := context.WithValue(r.Context(), types.RequestID(constant.RequestID), reqID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
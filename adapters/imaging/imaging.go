@@ -0,0 +1,251 @@
+// Package imaging provides image transformations for uploaded media: resizing, cropping,
+// thumbnail generation, metadata stripping and format conversion.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder
+)
+
+// Format identifies an image encoding.
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatGIF  Format = "gif"
+	FormatWebP Format = "webp"
+	// FormatAVIF has no pure Go codec, so none is built in: it is encoded once an encoder is
+	// registered with RegisterEncoder and decoded once a decoder is registered with
+	// image.RegisterFormat. Until then Encode fails with ErrUnsupportedFormat.
+	FormatAVIF Format = "avif"
+)
+
+// DefaultJPEGQuality is used when no quality is supplied.
+const DefaultJPEGQuality = 85
+
+// DefaultMaxPixels is the largest image Decode accepts, 50 megapixels, so a small compressed
+// upload cannot claim gigabytes of memory once decoded.
+const DefaultMaxPixels = 50_000_000
+
+var (
+	ErrUnsupportedFormat = errors.New("imaging: unsupported image format")
+	ErrInvalidDimensions = errors.New("imaging: invalid dimensions")
+	ErrImageTooLarge     = errors.New("imaging: image exceeds the pixel limit")
+)
+
+// maxPixels is the pixel limit of Decode.
+var maxPixels atomic.Int64
+
+func init() {
+	maxPixels.Store(DefaultMaxPixels)
+}
+
+// SetMaxPixels sets the largest width x height Decode accepts; zero or less removes the limit.
+// Defaults to DefaultMaxPixels.
+func SetMaxPixels(n int64) {
+	maxPixels.Store(n)
+}
+
+// Encoder writes img to w. quality is in the range 1-100 and may be ignored by lossless formats.
+type Encoder func(w io.Writer, img image.Image, quality int) error
+
+var (
+	encoders = map[Format]Encoder{
+		FormatJPEG: func(w io.Writer, img image.Image, quality int) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+		FormatPNG: func(w io.Writer, img image.Image, _ int) error {
+			return png.Encode(w, img)
+		},
+		FormatGIF: func(w io.Writer, img image.Image, _ int) error {
+			return gif.Encode(w, img, nil)
+		},
+		// WebP output is lossless (VP8L); quality is ignored.
+		FormatWebP: func(w io.Writer, img image.Image, _ int) error {
+			return nativewebp.Encode(w, img, nil)
+		},
+	}
+	encodersMu sync.RWMutex
+)
+
+// RegisterEncoder registers (or overrides) the encoder for a format, e.g. FormatAVIF, which has
+// no built-in encoder.
+func RegisterEncoder(format Format, encoder Encoder) {
+	if encoder == nil {
+		return
+	}
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	encoders[format] = encoder
+}
+
+// ParseFormat converts a format name, file extension or MIME type into a Format.
+func ParseFormat(value string) (Format, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	v = strings.TrimPrefix(v, ".")
+	v = strings.TrimPrefix(v, "image/")
+	switch v {
+	case "jpg", "jpeg", "jpe":
+		return FormatJPEG, nil
+	case "png":
+		return FormatPNG, nil
+	case "gif":
+		return FormatGIF, nil
+	case "webp":
+		return FormatWebP, nil
+	case "avif":
+		return FormatAVIF, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, value)
+}
+
+// Extension returns the file extension for the format, including the dot.
+func (f Format) Extension() string {
+	if f == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// ContentType returns the MIME type for the format.
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// Decode decodes an image and applies its EXIF orientation so the returned pixels are upright.
+// The dimensions are read from the header first, and images larger than the pixel limit fail
+// with ErrImageTooLarge before their pixels are decoded.
+func Decode(data []byte) (image.Image, Format, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imaging: failed to decode image: %w", err)
+	}
+	if limit := maxPixels.Load(); limit > 0 && int64(config.Width)*int64(config.Height) > limit {
+		return nil, "", fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+	img, name, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imaging: failed to decode image: %w", err)
+	}
+	format, err := ParseFormat(name)
+	if err != nil {
+		return nil, "", err
+	}
+	if format == FormatJPEG {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// Encode writes img in the given format. Encoding never carries metadata from the source.
+func Encode(w io.Writer, img image.Image, format Format, quality int) error {
+	encodersMu.RLock()
+	encoder, ok := encoders[format]
+	encodersMu.RUnlock()
+	if !ok {
+		if format == FormatAVIF {
+			return fmt.Errorf("%w: no encoder registered for %s", ErrUnsupportedFormat, format)
+		}
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if quality <= 0 || quality > 100 {
+		quality = DefaultJPEGQuality
+	}
+	return encoder(w, img, quality)
+}
+
+// Resize scales img to width x height. When one dimension is zero it is derived from the
+// aspect ratio.
+func Resize(img image.Image, width, height int) (image.Image, error) {
+	b := img.Bounds()
+	if width <= 0 && height <= 0 {
+		return nil, ErrInvalidDimensions
+	}
+	if width <= 0 {
+		width = max(1, b.Dx()*height/b.Dy())
+	}
+	if height <= 0 {
+		height = max(1, b.Dy()*width/b.Dx())
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst, nil
+}
+
+// Crop returns the part of img inside rect, clipped to the image bounds.
+func Crop(img image.Image, rect image.Rectangle) (image.Image, error) {
+	rect = rect.Add(img.Bounds().Min).Intersect(img.Bounds())
+	if rect.Empty() {
+		return nil, ErrInvalidDimensions
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst, nil
+}
+
+// Fit scales img down to fit within width x height, preserving the aspect ratio.
+// Images already inside the box are returned unchanged.
+func Fit(img image.Image, width, height int) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, ErrInvalidDimensions
+	}
+	b := img.Bounds()
+	if b.Dx() <= width && b.Dy() <= height {
+		return img, nil
+	}
+	if b.Dx()*height > b.Dy()*width {
+		return Resize(img, width, 0)
+	}
+	return Resize(img, 0, height)
+}
+
+// Thumbnail scales and centre-crops img to exactly width x height.
+func Thumbnail(img image.Image, width, height int) (image.Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, ErrInvalidDimensions
+	}
+	b := img.Bounds()
+	// Scale so the shorter side covers the box, then crop the overflow.
+	var scaled image.Image
+	var err error
+	if b.Dx()*height > b.Dy()*width {
+		scaled, err = Resize(img, 0, height)
+	} else {
+		scaled, err = Resize(img, width, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sb := scaled.Bounds()
+	x := (sb.Dx() - width) / 2
+	y := (sb.Dy() - height) / 2
+	return Crop(scaled, image.Rect(x, y, x+width, y+height))
+}
+
+// Convert re-encodes data into the target format. The output carries no source metadata.
+func Convert(data []byte, format Format, quality int) ([]byte, error) {
+	img, _, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, format, quality); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
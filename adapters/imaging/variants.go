@@ -0,0 +1,123 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"path"
+	"strings"
+
+	"github.com/abhissng/neuron/adapters/cloud"
+)
+
+// Mode selects how a variant is fitted into its box.
+type Mode string
+
+const (
+	// ModeFit scales the image down to fit inside the box, preserving the aspect ratio.
+	ModeFit Mode = "fit"
+	// ModeFill scales and centre-crops the image to exactly fill the box.
+	ModeFill Mode = "fill"
+)
+
+// Variant describes a derived image, e.g. a thumbnail, written next to the original.
+type Variant struct {
+	Name    string
+	Width   int
+	Height  int
+	Mode    Mode
+	Format  Format // empty keeps the source format
+	Quality int
+}
+
+// VariantResult describes a variant written to storage.
+type VariantResult struct {
+	Name        string
+	Key         string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int
+}
+
+// VariantKey returns the storage key of a variant derived from originalKey,
+// e.g. "avatars/u1.jpg" with variant "thumb" in WebP becomes "avatars/u1_thumb.webp".
+func VariantKey(originalKey, name string, format Format) string {
+	dir, file := path.Split(originalKey)
+	base := strings.TrimSuffix(file, path.Ext(file))
+	return dir + base + "_" + name + format.Extension()
+}
+
+// Render produces the encoded bytes of a single variant from a decoded image.
+func (v Variant) Render(img image.Image, source Format) ([]byte, image.Rectangle, error) {
+	var (
+		out image.Image
+		err error
+	)
+	switch v.Mode {
+	case ModeFill:
+		out, err = Thumbnail(img, v.Width, v.Height)
+	default:
+		out, err = Fit(img, v.Width, v.Height)
+	}
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+
+	format := v.Format
+	if format == "" {
+		format = source
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, out, format, v.Quality); err != nil {
+		return nil, image.Rectangle{}, err
+	}
+	return buf.Bytes(), out.Bounds(), nil
+}
+
+// WriteVariants decodes the original image, renders each variant and uploads it alongside
+// originalKey in bucket. Variants are stripped of metadata by construction. Processing stops
+// at the first failure and the variants written so far are returned with the error.
+func WriteVariants(ctx context.Context, cm cloud.CloudManager, bucket, originalKey string, original []byte, variants ...Variant) ([]VariantResult, error) {
+	if cm == nil {
+		return nil, cloud.ErrNotInitialized
+	}
+	if len(variants) == 0 {
+		return nil, errors.New("imaging: no variants requested")
+	}
+
+	img, source, err := Decode(original)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]VariantResult, 0, len(variants))
+	for _, v := range variants {
+		data, bounds, err := v.Render(img, source)
+		if err != nil {
+			return results, fmt.Errorf("imaging: variant %s: %w", v.Name, err)
+		}
+
+		format := v.Format
+		if format == "" {
+			format = source
+		}
+		key := VariantKey(originalKey, v.Name, format)
+		metadata := map[string]string{"variant": v.Name, "source-key": originalKey}
+		if err := cm.UploadFile(ctx, bucket, key, data, format.ContentType(), metadata); err != nil {
+			return results, fmt.Errorf("imaging: failed to upload variant %s: %w", v.Name, err)
+		}
+
+		results = append(results, VariantResult{
+			Name:        v.Name,
+			Key:         key,
+			ContentType: format.ContentType(),
+			Width:       bounds.Dx(),
+			Height:      bounds.Dy(),
+			Size:        len(data),
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,83 @@
+package kyc
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+)
+
+// AuditEvent records a verification, with the document number masked.
+type AuditEvent struct {
+	Time        time.Time    `json:"time"`
+	ReferenceID string       `json:"reference_id"`
+	ActorID     string       `json:"actor_id,omitempty"`
+	Document    DocumentType `json:"document"`
+	Number      string       `json:"number"`
+	Provider    string       `json:"provider,omitempty"`
+	// Status is empty when the provider could not be reached.
+	Status      Status `json:"status,omitempty"`
+	NameChecked bool   `json:"name_checked"`
+	NameMatched bool   `json:"name_matched"`
+	Consent     bool   `json:"consent"`
+	Cached      bool   `json:"cached"`
+	// ErrorCode is the blame code of a failed verification.
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// AuditSink receives the audit events of the verifications, e.g. to store them for compliance.
+type AuditSink interface {
+	RecordVerification(ctx context.Context, event *AuditEvent)
+}
+
+// AuditSinkFunc is a function implementing AuditSink.
+type AuditSinkFunc func(ctx context.Context, event *AuditEvent)
+
+// RecordVerification implements AuditSink.
+func (f AuditSinkFunc) RecordVerification(ctx context.Context, event *AuditEvent) {
+	f(ctx, event)
+}
+
+// LoggerAuditSink writes the audit events through logger.
+func LoggerAuditSink(logger *log.Log) AuditSink {
+	return AuditSinkFunc(func(_ context.Context, e *AuditEvent) {
+		logger.Info("KYC verification",
+			log.String("reference_id", e.ReferenceID),
+			log.String("actor_id", e.ActorID),
+			log.String("document", string(e.Document)),
+			log.String("number", e.Number),
+			log.String("provider", e.Provider),
+			log.String("status", string(e.Status)),
+			log.Bool("name_checked", e.NameChecked),
+			log.Bool("name_matched", e.NameMatched),
+			log.Bool("consent", e.Consent),
+			log.Bool("cached", e.Cached),
+			log.String("error_code", e.ErrorCode),
+		)
+	})
+}
+
+// record sends the audit event of a verification to the sinks.
+func (v *Verifier) record(ctx context.Context, req Request, result *Result, b blame.Blame) {
+	event := &AuditEvent{
+		Time:        time.Now(),
+		ReferenceID: req.ReferenceID,
+		ActorID:     req.ActorID,
+		Document:    req.Document,
+		Number:      Mask(req.Document, req.Number),
+		Provider:    v.providerFor(req.Document).Name(),
+		NameChecked: req.Name != "",
+		Consent:     req.Consent,
+	}
+	if result != nil {
+		event.Provider, event.Status = result.Provider, result.Status
+		event.NameMatched, event.Cached = result.NameMatched, result.Cached
+	}
+	if b != nil {
+		event.ErrorCode = string(b.FetchErrCode())
+	}
+	for _, sink := range v.audit {
+		sink.RecordVerification(ctx, event)
+	}
+}
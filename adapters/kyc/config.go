@@ -0,0 +1,42 @@
+package kyc
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/spf13/viper"
+)
+
+// NewProviderFromConfig creates the provider named by KYC_PROVIDER, read from the environment
+// or else the viper configuration, with its KYC_API_KEY and optional KYC_BASE_URL. "mock" creates
+// an empty MockProvider for local development.
+func NewProviderFromConfig() (Provider, error) {
+	name := configValue(constant.KYCProvider)
+	switch name {
+	case ProviderSurepass:
+		token := configValue(constant.KYCAPIKey)
+		if token == "" {
+			return nil, fmt.Errorf("kyc: %s is required for %s", constant.KYCAPIKey, name)
+		}
+		var options []SurepassOption
+		if baseURL := configValue(constant.KYCBaseURL); baseURL != "" {
+			options = append(options, WithSurepassBaseURL(baseURL))
+		}
+		return NewSurepass(token, options...), nil
+	case ProviderMock:
+		return NewMockProvider(), nil
+	case "":
+		return nil, fmt.Errorf("kyc: %s is not set", constant.KYCProvider)
+	default:
+		return nil, fmt.Errorf("kyc: unknown provider %q", name)
+	}
+}
+
+// configValue reads key from the environment or else the viper configuration.
+func configValue(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return viper.GetString(key)
+}
@@ -0,0 +1,157 @@
+package kyc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DocumentType is a KYC document.
+type DocumentType string
+
+const (
+	// DocumentPAN is the Permanent Account Number issued by the Income Tax Department.
+	DocumentPAN DocumentType = "pan"
+	// DocumentGSTIN is the Goods and Services Tax Identification Number of a business.
+	DocumentGSTIN DocumentType = "gstin"
+	// DocumentAadhaar is the Aadhaar number issued by UIDAI. Verifying it requires the consent
+	// of its holder.
+	DocumentAadhaar DocumentType = "aadhaar"
+)
+
+var (
+	// panPattern is five letters, the fourth naming the holder type, four digits and a check
+	// letter.
+	panPattern = regexp.MustCompile(`^[A-Z]{3}[ABCFGHJLPT][A-Z][0-9]{4}[A-Z]$`)
+	// gstinPattern is the state code, the PAN of the business, the entity number, a Z and the
+	// check character.
+	gstinPattern   = regexp.MustCompile(`^[0-9]{2}[A-Z]{3}[ABCFGHJLPT][A-Z][0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+	aadhaarPattern = regexp.MustCompile(`^[2-9][0-9]{11}$`)
+)
+
+// panHolderTypes names the holder types of the fourth character of a PAN.
+var panHolderTypes = map[byte]string{
+	'P': "individual",
+	'C': "company",
+	'H': "huf",
+	'F': "firm",
+	'A': "association_of_persons",
+	'T': "trust",
+	'B': "body_of_individuals",
+	'L': "local_authority",
+	'J': "artificial_juridical_person",
+	'G': "government",
+}
+
+// Normalize upper-cases number and removes its spaces and dashes.
+func Normalize(number string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(number)))
+}
+
+// Validate returns why number, normalized, is not a valid document of type document, or "".
+// GSTINs and Aadhaar numbers are checked against their check character.
+func Validate(document DocumentType, number string) string {
+	switch document {
+	case DocumentPAN:
+		if !panPattern.MatchString(number) {
+			return "must be five letters, four digits and a letter"
+		}
+	case DocumentGSTIN:
+		if !gstinPattern.MatchString(number) {
+			return "must be a state code, a PAN, an entity number, Z and a check character"
+		}
+		if gstinCheckCharacter(number[:14]) != number[14] {
+			return "check character does not match"
+		}
+	case DocumentAadhaar:
+		if !aadhaarPattern.MatchString(number) {
+			return "must be 12 digits not starting with 0 or 1"
+		}
+		if !verhoeffValid(number) {
+			return "check digit does not match"
+		}
+	default:
+		return "unsupported document type"
+	}
+	return ""
+}
+
+// Mask hides number except the characters needed to recognise it: the last four digits of an
+// Aadhaar number and the first and last characters of a PAN. GSTINs are public and returned
+// unchanged.
+func Mask(document DocumentType, number string) string {
+	switch document {
+	case DocumentGSTIN:
+		return number
+	case DocumentPAN:
+		if len(number) != 10 {
+			return strings.Repeat("X", len(number))
+		}
+		return number[:2] + strings.Repeat("X", 6) + number[8:]
+	default:
+		if len(number) <= 4 {
+			return strings.Repeat("X", len(number))
+		}
+		return strings.Repeat("X", len(number)-4) + number[len(number)-4:]
+	}
+}
+
+// PANHolderType returns the holder type encoded in pan, e.g. "individual" or "company", or "".
+func PANHolderType(pan string) string {
+	if len(pan) != 10 {
+		return ""
+	}
+	return panHolderTypes[pan[3]]
+}
+
+const gstinAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// gstinCheckCharacter computes the check character of the first 14 characters of a GSTIN.
+func gstinCheckCharacter(prefix string) byte {
+	sum := 0
+	for i := 0; i < len(prefix); i++ {
+		product := strings.IndexByte(gstinAlphabet, prefix[i]) * (i%2 + 1)
+		sum += product/36 + product%36
+	}
+	return gstinAlphabet[(36-sum%36)%36]
+}
+
+// The Verhoeff multiplication and permutation tables.
+var (
+	verhoeffD = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// verhoeffValid reports whether the digits of number end with their Verhoeff check digit.
+func verhoeffValid(number string) bool {
+	c := 0
+	for i := 0; i < len(number); i++ {
+		digit := int(number[len(number)-1-i] - '0')
+		c = verhoeffD[c][verhoeffP[i%8][digit]]
+	}
+	return c == 0
+}
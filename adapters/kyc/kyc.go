@@ -0,0 +1,266 @@
+// Package kyc verifies Indian KYC documents, PAN, GSTIN and Aadhaar numbers, through the
+// verification APIs of providers such as Surepass. The Verifier validates and normalizes the
+// document, routes it to its provider, matches the name, caches verified results and records an
+// audit event for every verification.
+//
+//	provider, err := kyc.NewProviderFromConfig()
+//	if err != nil {
+//		return err
+//	}
+//	verifier := kyc.NewVerifier(provider, kyc.WithCache(cache.NewBasicCache[string, kyc.Result](), 24*time.Hour))
+//	result, b := verifier.Verify(ctx, kyc.Request{Document: kyc.DocumentPAN, Number: pan, Name: name})
+package kyc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/adapters/verification"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/cache"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// DefaultNameMatchThreshold is the similarity between the name on a document and the expected
+// name below which the holder is considered a different person.
+const DefaultNameMatchThreshold = verification.DefaultNameMatchThreshold
+
+// Status is what the issuer, the Income Tax Department, GSTN or UIDAI, said about a document.
+type Status = verification.Status
+
+const (
+	// StatusVerified means the document is valid and, for PAN and GSTIN, its holder name known.
+	StatusVerified = verification.StatusVerified
+	// StatusNotFound means the issuer has no such document.
+	StatusNotFound = verification.StatusNotFound
+	// StatusInactive means the document exists but is not valid, e.g. a cancelled GSTIN or an
+	// inoperative PAN.
+	StatusInactive = verification.StatusInactive
+	// StatusPending means the issuer has not answered yet, as happens with Aadhaar OTP flows;
+	// retry with the same reference.
+	StatusPending = verification.StatusPending
+	// StatusFailed means the provider could not reach the issuer or read its answer.
+	StatusFailed = verification.StatusFailed
+)
+
+// Request asks to verify a document.
+type Request struct {
+	Document DocumentType `json:"document"`
+	Number   string       `json:"number"`
+	// Name is the expected holder name; leave empty to skip name matching.
+	Name string `json:"name,omitempty"`
+	// Consent records that the holder agreed to the verification. Required for Aadhaar.
+	Consent bool `json:"consent"`
+	// ReferenceID identifies the verification at the provider and in the audit trail.
+	// Generated when empty.
+	ReferenceID string `json:"reference_id"`
+	// ActorID is who asked for the verification, for the audit trail.
+	ActorID string `json:"actor_id,omitempty"`
+}
+
+// Result is the normalized outcome of a verification.
+type Result struct {
+	Document    DocumentType `json:"document"`
+	Status      Status       `json:"status"`
+	ReferenceID string       `json:"reference_id"`
+	Provider    string       `json:"provider"`
+	// Number is masked.
+	Number string `json:"number"`
+	// Name is the holder name registered with the issuer: the legal name of a GSTIN. Aadhaar
+	// verification APIs do not return it.
+	Name string `json:"name,omitempty"`
+	// NameMatchScore is the similarity of Name and the expected name, from 0 to 1.
+	NameMatchScore float64 `json:"name_match_score,omitempty"`
+	NameMatched    bool    `json:"name_matched"`
+	// Details are the other normalized attributes returned by the provider, e.g. the trade
+	// name and registration date of a GSTIN or the state and age band of an Aadhaar holder.
+	Details map[string]string `json:"details,omitempty"`
+	// Reason explains StatusInactive and StatusFailed.
+	Reason     string    `json:"reason,omitempty"`
+	VerifiedAt time.Time `json:"verified_at"`
+	// Cached is true when the result was served from the cache of verified results.
+	Cached bool `json:"cached"`
+}
+
+// Provider checks documents with their issuer through a KYC API such as Surepass. A document
+// the issuer rejects is a Result with StatusNotFound or StatusInactive, not an error; errors
+// mean the provider itself could not be used. Supports lets a Verifier route each document type
+// to a provider that handles it.
+type Provider interface {
+	Name() string
+	Supports(document DocumentType) bool
+	Verify(ctx context.Context, req Request) (*Result, error)
+}
+
+// Verifier runs KYC checks: it validates the document number, checks the holder's consent,
+// routes the document to its Provider, matches the holder name, caches verified documents and
+// audits every check.
+type Verifier struct {
+	provider  Provider
+	providers map[DocumentType]Provider
+	threshold float64
+	cache     cache.Cache[string, Result]
+	cacheTTL  time.Duration
+	audit     []AuditSink
+	logger    *log.Log
+}
+
+// Option configures a Verifier.
+type Option func(*Verifier)
+
+// WithProvider verifies document with provider instead of the default provider.
+func WithProvider(document DocumentType, provider Provider) Option {
+	return func(v *Verifier) {
+		v.providers[document] = provider
+	}
+}
+
+// WithNameMatchThreshold sets the similarity between the name on a document and the expected
+// name below which Verify reports a name mismatch. Defaults to DefaultNameMatchThreshold.
+func WithNameMatchThreshold(threshold float64) Option {
+	return func(v *Verifier) {
+		v.threshold = threshold
+	}
+}
+
+// WithCache caches verified results for ttl, keyed by a hash of the document, number and
+// expected name, so repeated checks do not hit the paid provider. Failed verifications are not
+// cached.
+func WithCache(c cache.Cache[string, Result], ttl time.Duration) Option {
+	return func(v *Verifier) {
+		v.cache = c
+		v.cacheTTL = ttl
+	}
+}
+
+// WithAuditSink adds a sink for the audit events. Without sinks the events go to the logger.
+func WithAuditSink(sink AuditSink) Option {
+	return func(v *Verifier) {
+		v.audit = append(v.audit, sink)
+	}
+}
+
+// WithLogger sets the logger of provider failures and, without audit sinks, of audit events.
+func WithLogger(logger *log.Log) Option {
+	return func(v *Verifier) {
+		v.logger = logger
+	}
+}
+
+// NewVerifier creates a Verifier using provider for every document, unless overridden with
+// WithProvider.
+func NewVerifier(provider Provider, options ...Option) *Verifier {
+	v := &Verifier{
+		provider:  provider,
+		providers: make(map[DocumentType]Provider),
+		threshold: DefaultNameMatchThreshold,
+	}
+	for _, opt := range options {
+		opt(v)
+	}
+	if v.logger == nil {
+		v.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	if len(v.audit) == 0 {
+		v.audit = []AuditSink{LoggerAuditSink(v.logger)}
+	}
+	return v
+}
+
+// Verify checks the document of req with its issuer. Invalid numbers and missing Aadhaar consent
+// are rejected before any provider call. A document the issuer rejects or whose name does not
+// match returns its Result with the blame, so onboarding flows can store what was checked; a
+// pending check returns no blame.
+func (v *Verifier) Verify(ctx context.Context, req Request) (*Result, blame.Blame) {
+	req.Number = Normalize(req.Number)
+	if reason := Validate(req.Document, req.Number); reason != "" {
+		return nil, blame.KYCDocumentInvalidError(string(req.Document), reason)
+	}
+	if req.Document == DocumentAadhaar && !req.Consent {
+		return nil, blame.KYCConsentRequiredError(string(req.Document))
+	}
+	if req.ReferenceID == "" {
+		req.ReferenceID = random.GenerateUUIDString()
+	}
+
+	key := cacheKey(req)
+	if v.cache != nil {
+		if cached, ok := v.cache.Get(key); ok {
+			cached.ReferenceID, cached.Cached = req.ReferenceID, true
+			v.record(ctx, req, &cached, nil)
+			return &cached, nil
+		}
+	}
+
+	provider := v.providerFor(req.Document)
+	if !provider.Supports(req.Document) {
+		err := fmt.Errorf("kyc: %s does not verify %s", provider.Name(), req.Document)
+		b := blame.KYCProviderUnavailableError(provider.Name(), err)
+		v.record(ctx, req, nil, b)
+		return nil, b
+	}
+	result, err := provider.Verify(ctx, req)
+	if err != nil {
+		v.logger.Error("KYC verification failed", log.String("provider", provider.Name()), log.String("reference_id", req.ReferenceID), log.Err(err))
+		b := blame.KYCProviderUnavailableError(provider.Name(), err)
+		v.record(ctx, req, nil, b)
+		return nil, b
+	}
+	result.Document, result.Provider = req.Document, provider.Name()
+	result.ReferenceID, result.Number = req.ReferenceID, Mask(req.Document, req.Number)
+	if result.VerifiedAt.IsZero() {
+		result.VerifiedAt = time.Now()
+	}
+
+	b := v.evaluate(req, result)
+	if b == nil && result.Status == StatusVerified && v.cache != nil {
+		v.cache.SetWithExpiry(key, *result, v.cacheTTL)
+	}
+	v.record(ctx, req, result, b)
+	return result, b
+}
+
+// evaluate matches the name of a result and returns the blame of its status.
+func (v *Verifier) evaluate(req Request, result *Result) blame.Blame {
+	document := string(req.Document)
+	switch result.Status {
+	case StatusVerified:
+		if req.Name == "" || result.Name == "" {
+			return nil
+		}
+		result.NameMatchScore, result.NameMatched = verification.MatchName(req.Name, result.Name, v.threshold)
+		if !result.NameMatched {
+			return blame.KYCNameMismatchError(document, result.NameMatchScore)
+		}
+		return nil
+	case StatusPending:
+		return nil
+	case StatusNotFound, StatusInactive:
+		return blame.KYCVerificationFailedError(document, string(result.Status), result.Reason)
+	default:
+		reason := result.Reason
+		if reason == "" {
+			reason = "verification " + string(result.Status)
+		}
+		return blame.KYCProviderUnavailableError(result.Provider, fmt.Errorf("kyc: %s", reason))
+	}
+}
+
+// providerFor returns the provider verifying document.
+func (v *Verifier) providerFor(document DocumentType) Provider {
+	if provider, ok := v.providers[document]; ok {
+		return provider
+	}
+	return v.provider
+}
+
+// cacheKey hashes the request so document numbers are not kept in clear as cache keys.
+func cacheKey(req Request) string {
+	sum := sha256.Sum256([]byte(string(req.Document) + "|" + req.Number + "|" + req.Name))
+	return "kyc:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package kyc
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// ProviderMock is the name of the mock provider.
+const ProviderMock = "mock"
+
+// MockDocument is a document as its issuer knows it, served by a MockProvider.
+type MockDocument struct {
+	Document DocumentType
+	Number   string
+	Name     string
+	// Status defaults to StatusVerified; set StatusInactive with a Reason for e.g. a cancelled
+	// GSTIN.
+	Status  Status
+	Reason  string
+	Details map[string]string
+}
+
+// MockProvider stands in for a KYC API in tests and local development. It supports PAN, GSTIN
+// and Aadhaar and answers from the documents it was given; any other number is StatusNotFound,
+// as the issuer would report it.
+type MockProvider struct {
+	mu        sync.RWMutex
+	documents map[string]MockDocument
+	err       error
+	calls     []Request
+}
+
+// NewMockProvider creates a MockProvider answering for documents.
+func NewMockProvider(documents ...MockDocument) *MockProvider {
+	m := &MockProvider{documents: make(map[string]MockDocument)}
+	for _, document := range documents {
+		m.AddDocument(document)
+	}
+	return m
+}
+
+// AddDocument adds document, replacing any with the same type and number.
+func (m *MockProvider) AddDocument(document MockDocument) {
+	document.Number = Normalize(document.Number)
+	if document.Status == "" {
+		document.Status = StatusVerified
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.documents[string(document.Document)+"/"+document.Number] = document
+}
+
+// FailWith makes every check fail with err, to exercise KYCProviderUnavailableError; nil
+// restores normal answers.
+func (m *MockProvider) FailWith(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Calls returns the requests checked so far, e.g. to assert that cached documents are not
+// checked again.
+func (m *MockProvider) Calls() []Request {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Request(nil), m.calls...)
+}
+
+func (m *MockProvider) Name() string {
+	return ProviderMock
+}
+
+func (m *MockProvider) Supports(document DocumentType) bool {
+	return document == DocumentPAN || document == DocumentGSTIN || document == DocumentAadhaar
+}
+
+func (m *MockProvider) Verify(_ context.Context, req Request) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, req)
+	if m.err != nil {
+		return nil, m.err
+	}
+	document, ok := m.documents[string(req.Document)+"/"+req.Number]
+	if !ok {
+		return &Result{Status: StatusNotFound, VerifiedAt: time.Now()}, nil
+	}
+	return &Result{
+		Status:     document.Status,
+		Name:       document.Name,
+		Reason:     document.Reason,
+		Details:    maps.Clone(document.Details),
+		VerifiedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,194 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ProviderSurepass is the name of the Surepass provider.
+	ProviderSurepass = "surepass"
+	// DefaultSurepassBaseURL is the production URL of the Surepass KYC API.
+	DefaultSurepassBaseURL = "https://kyc-api.surepass.io"
+	// DefaultTimeout bounds a provider call when the context has no deadline.
+	DefaultTimeout = 30 * time.Second
+)
+
+// surepassPaths are the verification endpoints of each document.
+var surepassPaths = map[DocumentType]string{
+	DocumentPAN:     "/api/v1/pan/pan",
+	DocumentGSTIN:   "/api/v1/corporate/gstin",
+	DocumentAadhaar: "/api/v1/aadhaar-validation/aadhaar-validation",
+}
+
+// Surepass verifies PAN, GSTIN and Aadhaar numbers with the Surepass KYC API.
+type Surepass struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	timeout    time.Duration
+}
+
+// SurepassOption configures a Surepass provider.
+type SurepassOption func(*Surepass)
+
+// WithSurepassBaseURL sets the API URL, e.g. the sandbox. Defaults to DefaultSurepassBaseURL.
+func WithSurepassBaseURL(baseURL string) SurepassOption {
+	return func(s *Surepass) {
+		s.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithSurepassHTTPClient sets the HTTP client. Defaults to http.DefaultClient.
+func WithSurepassHTTPClient(client *http.Client) SurepassOption {
+	return func(s *Surepass) {
+		s.httpClient = client
+	}
+}
+
+// WithSurepassTimeout bounds calls whose context has no deadline. Defaults to DefaultTimeout.
+func WithSurepassTimeout(timeout time.Duration) SurepassOption {
+	return func(s *Surepass) {
+		s.timeout = timeout
+	}
+}
+
+// NewSurepass creates a Surepass provider authenticating with the API token.
+func NewSurepass(token string, options ...SurepassOption) *Surepass {
+	s := &Surepass{
+		baseURL:    DefaultSurepassBaseURL,
+		token:      token,
+		httpClient: http.DefaultClient,
+		timeout:    DefaultTimeout,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Surepass) Name() string {
+	return ProviderSurepass
+}
+
+func (s *Surepass) Supports(document DocumentType) bool {
+	_, ok := surepassPaths[document]
+	return ok
+}
+
+// surepassResponse is the envelope of the Surepass responses.
+type surepassResponse struct {
+	Data        map[string]any `json:"data"`
+	StatusCode  int            `json:"status_code"`
+	Success     bool           `json:"success"`
+	Message     string         `json:"message"`
+	MessageCode string         `json:"message_code"`
+}
+
+func (s *Surepass) Verify(ctx context.Context, req Request) (*Result, error) {
+	if _, ok := ctx.Deadline(); !ok && s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	body, err := json.Marshal(map[string]any{"id_number": req.Number})
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+surepassPaths[req.Document], bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var envelope surepassResponse
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("kyc: surepass returned %d: %w", resp.StatusCode, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK && envelope.Success:
+		return surepassResult(req.Document, envelope.Data), nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity:
+		return &Result{Status: StatusNotFound, Reason: envelope.Message}, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden ||
+		resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("kyc: surepass returned %d: %s", resp.StatusCode, envelope.Message)
+	default:
+		return &Result{Status: StatusFailed, Reason: envelope.Message}, nil
+	}
+}
+
+// surepassResult normalizes the data of a successful response.
+func surepassResult(document DocumentType, data map[string]any) *Result {
+	result := &Result{Status: StatusVerified, Details: make(map[string]string)}
+	switch document {
+	case DocumentPAN:
+		result.Name = stringValue(data, "full_name")
+		result.Details["holder_type"] = PANHolderType(stringValue(data, "pan_number"))
+	case DocumentGSTIN:
+		result.Name = stringValue(data, "legal_name")
+		copyDetails(result.Details, data, map[string]string{
+			"business_name":            "trade_name",
+			"date_of_registration":     "registered_on",
+			"constitution_of_business": "constitution",
+			"taxpayer_type":            "taxpayer_type",
+			"gstin_status":             "status",
+			"address":                  "address",
+		})
+		if status := result.Details["status"]; status != "" && !strings.EqualFold(status, "active") {
+			result.Status, result.Reason = StatusInactive, strings.ToLower(status)
+		}
+	case DocumentAadhaar:
+		copyDetails(result.Details, data, map[string]string{
+			"age_range": "age_range",
+			"state":     "state",
+			"gender":    "gender",
+			"is_mobile": "mobile_linked",
+		})
+	}
+	for key, value := range result.Details {
+		if value == "" {
+			delete(result.Details, key)
+		}
+	}
+	return result
+}
+
+// copyDetails copies the fields of data named by the keys of names to details, under their
+// normalized names.
+func copyDetails(details map[string]string, data map[string]any, names map[string]string) {
+	for from, to := range names {
+		details[to] = stringValue(data, from)
+	}
+}
+
+// stringValue formats the field key of data, or returns "".
+func stringValue(data map[string]any, key string) string {
+	switch value := data[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
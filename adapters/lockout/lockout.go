@@ -0,0 +1,346 @@
+// Package lockout protects authentication endpoints against brute force attacks. Failed
+// attempts are counted per identity (e.g. username or email) and per client IP in Redis, so
+// limits hold across replicas. Exceeding a limit locks the identity or IP for a window that
+// doubles with every repeated lockout, and a CAPTCHA is requested before the limit is reached.
+package lockout
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	neuronredis "github.com/abhissng/neuron/adapters/redis"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// Event types emitted by LockoutManager
+const (
+	EventFailure         = "login_failure"
+	EventCaptchaRequired = "captcha_required"
+	EventLocked          = "account_locked"
+	EventBlockedAttempt  = "blocked_attempt"
+	EventUnlocked        = "account_unlocked"
+)
+
+// ErrLocked is returned by Guard when the identity or IP is locked.
+var ErrLocked = errors.New("too many failed login attempts")
+
+// Status is the brute force state of a login attempt.
+type Status struct {
+	Locked          bool          `json:"locked"`
+	RetryAfter      time.Duration `json:"retry_after,omitempty"`
+	CaptchaRequired bool          `json:"captcha_required"`
+	Failures        int           `json:"failures"`
+	Remaining       int           `json:"remaining"`
+}
+
+// Event describes a security relevant change, for monitoring and alerting.
+type Event struct {
+	Type      string        `json:"type"`
+	Identity  string        `json:"identity,omitempty"`
+	IP        string        `json:"ip,omitempty"`
+	Failures  int           `json:"failures"`
+	LockedFor time.Duration `json:"locked_for,omitempty"`
+	Time      time.Time     `json:"time"`
+}
+
+// EventHook receives the events of a LockoutManager. It is called synchronously.
+type EventHook func(ctx context.Context, event Event)
+
+// LockoutManager counts failed logins and locks out identities and IPs.
+type LockoutManager struct {
+	client         *redis.Client
+	prefix         string
+	identityLimit  int
+	ipLimit        int
+	captchaAfter   int
+	window         time.Duration
+	baseLockout    time.Duration
+	maxLockout     time.Duration
+	levelRetention time.Duration
+	hook           EventHook
+	logger         *log.Log
+	loggerSet      bool
+}
+
+// Option configures a LockoutManager.
+type Option func(*LockoutManager)
+
+// WithPrefix sets the prefix of the Redis keys. Defaults to "lockout:".
+func WithPrefix(prefix string) Option {
+	return func(m *LockoutManager) {
+		m.prefix = prefix
+	}
+}
+
+// WithIdentityLimit sets how many failures an identity may have within the window before it
+// is locked. Defaults to 5.
+func WithIdentityLimit(n int) Option {
+	return func(m *LockoutManager) {
+		m.identityLimit = n
+	}
+}
+
+// WithIPLimit sets how many failures, across all identities, an IP may have within the
+// window before it is locked. Defaults to 20.
+func WithIPLimit(n int) Option {
+	return func(m *LockoutManager) {
+		m.ipLimit = n
+	}
+}
+
+// WithCaptchaAfter requires a CAPTCHA once an identity or IP has n failures. Defaults to 3;
+// zero disables CAPTCHA signaling.
+func WithCaptchaAfter(n int) Option {
+	return func(m *LockoutManager) {
+		m.captchaAfter = n
+	}
+}
+
+// WithWindow sets how long failures are counted. Defaults to 15 minutes.
+func WithWindow(window time.Duration) Option {
+	return func(m *LockoutManager) {
+		m.window = window
+	}
+}
+
+// WithLockoutBackoff sets the first lockout and its upper bound; each repeated lockout within
+// retention doubles the previous one. Defaults to 1 minute, 24 hours and 24 hours.
+func WithLockoutBackoff(base, max, retention time.Duration) Option {
+	return func(m *LockoutManager) {
+		m.baseLockout = base
+		m.maxLockout = max
+		m.levelRetention = retention
+	}
+}
+
+// WithEventHook sets a hook receiving security events.
+func WithEventHook(hook EventHook) Option {
+	return func(m *LockoutManager) {
+		m.hook = hook
+	}
+}
+
+// WithLogger sets the logger events are written to.
+func WithLogger(logger *log.Log) Option {
+	return func(m *LockoutManager) {
+		m.logger = logger
+		m.loggerSet = true
+	}
+}
+
+// NewLockoutManager creates a LockoutManager storing its counters in store.
+func NewLockoutManager(store *neuronredis.RedisManager, opts ...Option) *LockoutManager {
+	defaultLog := log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	m := &LockoutManager{
+		client:         store.Client(),
+		prefix:         "lockout:",
+		identityLimit:  5,
+		ipLimit:        20,
+		captchaAfter:   3,
+		window:         15 * time.Minute,
+		baseLockout:    time.Minute,
+		maxLockout:     24 * time.Hour,
+		levelRetention: 24 * time.Hour,
+		logger:         defaultLog,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.loggerSet {
+		_ = defaultLog.Sync()
+	}
+	return m
+}
+
+// subject is an identity or IP tracked by the manager.
+type subject struct {
+	kind  string
+	value string
+	limit int
+}
+
+// subjects returns the tracked subjects of an attempt. Identities are normalised and hashed
+// so that no usernames or emails are stored in Redis.
+func (m *LockoutManager) subjects(identity, ip string) []subject {
+	var subjects []subject
+	if identity = strings.ToLower(strings.TrimSpace(identity)); identity != "" {
+		sum := sha256.Sum256([]byte(identity))
+		subjects = append(subjects, subject{kind: "id", value: hex.EncodeToString(sum[:16]), limit: m.identityLimit})
+	}
+	if ip != "" {
+		subjects = append(subjects, subject{kind: "ip", value: ip, limit: m.ipLimit})
+	}
+	return subjects
+}
+
+func (m *LockoutManager) key(name string, s subject) string {
+	return m.prefix + name + ":" + s.kind + ":" + s.value
+}
+
+// Check returns the status of an attempt before the credentials are verified. Callers must
+// reject locked attempts and demand a CAPTCHA when CaptchaRequired is set.
+func (m *LockoutManager) Check(ctx context.Context, identity, ip string) (Status, error) {
+	subjects := m.subjects(identity, ip)
+	if len(subjects) == 0 {
+		return Status{}, nil
+	}
+	pipe := m.client.Pipeline()
+	locks := make([]*redis.DurationCmd, len(subjects))
+	failures := make([]*redis.StringCmd, len(subjects))
+	for i, s := range subjects {
+		locks[i] = pipe.PTTL(ctx, m.key("lock", s))
+		failures[i] = pipe.Get(ctx, m.key("fail", s))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return Status{}, err
+	}
+
+	status := Status{Remaining: -1}
+	for i, s := range subjects {
+		if ttl := locks[i].Val(); ttl > 0 {
+			status.Locked = true
+			if ttl > status.RetryAfter {
+				status.RetryAfter = ttl
+			}
+		}
+		count, _ := failures[i].Int()
+		m.merge(&status, s, count)
+	}
+	if status.Locked {
+		m.emit(ctx, Event{Type: EventBlockedAttempt, Identity: identity, IP: ip, Failures: status.Failures, LockedFor: status.RetryAfter})
+	}
+	return status, nil
+}
+
+// RecordFailure counts a failed attempt and locks the identity or IP once its limit is
+// reached. The returned status reflects the attempt that follows.
+func (m *LockoutManager) RecordFailure(ctx context.Context, identity, ip string) (Status, error) {
+	subjects := m.subjects(identity, ip)
+	if len(subjects) == 0 {
+		return Status{}, nil
+	}
+	pipe := m.client.TxPipeline()
+	counts := make([]*redis.IntCmd, len(subjects))
+	for i, s := range subjects {
+		counts[i] = pipe.Incr(ctx, m.key("fail", s))
+		pipe.ExpireNX(ctx, m.key("fail", s), m.window)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Status{}, err
+	}
+
+	status := Status{Remaining: -1}
+	wasCaptcha := false
+	for i, s := range subjects {
+		count := int(counts[i].Val())
+		wasCaptcha = wasCaptcha || (m.captchaAfter > 0 && count-1 >= m.captchaAfter)
+		if s.limit > 0 && count >= s.limit {
+			lockedFor, err := m.lock(ctx, s)
+			if err != nil {
+				return Status{}, err
+			}
+			status.Locked = true
+			if lockedFor > status.RetryAfter {
+				status.RetryAfter = lockedFor
+			}
+			m.emit(ctx, Event{Type: EventLocked, Identity: identity, IP: ip, Failures: count, LockedFor: lockedFor})
+		}
+		m.merge(&status, s, count)
+	}
+
+	m.emit(ctx, Event{Type: EventFailure, Identity: identity, IP: ip, Failures: status.Failures})
+	if status.CaptchaRequired && !wasCaptcha {
+		m.emit(ctx, Event{Type: EventCaptchaRequired, Identity: identity, IP: ip, Failures: status.Failures})
+	}
+	return status, nil
+}
+
+// RecordSuccess clears the failures and lockout history of identity after a successful login.
+// The IP counters are kept, so one IP cannot alternate valid and guessed accounts.
+func (m *LockoutManager) RecordSuccess(ctx context.Context, identity, ip string) error {
+	for _, s := range m.subjects(identity, "") {
+		if err := m.client.Del(ctx, m.key("fail", s), m.key("level", s)).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unlock lifts the lockout of identity and of ip, when not empty, and clears their counters,
+// e.g. after a support verified password reset.
+func (m *LockoutManager) Unlock(ctx context.Context, identity, ip string) error {
+	for _, s := range m.subjects(identity, ip) {
+		if err := m.client.Del(ctx, m.key("fail", s), m.key("lock", s), m.key("level", s)).Err(); err != nil {
+			return err
+		}
+	}
+	m.emit(ctx, Event{Type: EventUnlocked, Identity: identity, IP: ip})
+	return nil
+}
+
+// lock locks s for the backoff of its lockout level and resets its failures.
+func (m *LockoutManager) lock(ctx context.Context, s subject) (time.Duration, error) {
+	pipe := m.client.TxPipeline()
+	level := pipe.Incr(ctx, m.key("level", s))
+	pipe.Expire(ctx, m.key("level", s), m.levelRetention)
+	pipe.Del(ctx, m.key("fail", s))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	lockedFor := m.baseLockout
+	for i := int64(1); i < level.Val() && lockedFor < m.maxLockout; i++ {
+		lockedFor *= 2
+	}
+	if m.maxLockout > 0 && lockedFor > m.maxLockout {
+		lockedFor = m.maxLockout
+	}
+	if err := m.client.Set(ctx, m.key("lock", s), level.Val(), lockedFor).Err(); err != nil {
+		return 0, err
+	}
+	return lockedFor, nil
+}
+
+// merge adds the failures of s to status.
+func (m *LockoutManager) merge(status *Status, s subject, count int) {
+	if count > status.Failures {
+		status.Failures = count
+	}
+	if m.captchaAfter > 0 && count >= m.captchaAfter {
+		status.CaptchaRequired = true
+	}
+	if s.limit > 0 {
+		remaining := max(s.limit-count, 0)
+		if status.Remaining < 0 || remaining < status.Remaining {
+			status.Remaining = remaining
+		}
+	}
+}
+
+// emit logs event and passes it to the hook. Identities are logged as given to the manager.
+func (m *LockoutManager) emit(ctx context.Context, event Event) {
+	event.Time = time.Now()
+	fields := []types.Field{
+		log.String("type", event.Type),
+		log.String("identity", event.Identity),
+		log.String("ip", event.IP),
+		log.Any("failures", event.Failures),
+	}
+	if event.LockedFor > 0 {
+		fields = append(fields, log.Any("locked_for", event.LockedFor.String()))
+	}
+	if event.Type == EventFailure {
+		m.logger.Info("login security event", fields...)
+	} else {
+		m.logger.Warn("login security event", fields...)
+	}
+	if m.hook != nil {
+		m.hook(ctx, event)
+	}
+}
@@ -0,0 +1,97 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CaptchaRequiredHeader is set on responses when the next attempt must carry a CAPTCHA.
+	CaptchaRequiredHeader = "X-Captcha-Required"
+	// StatusContextKey is the gin context key holding the Status of the current attempt.
+	StatusContextKey = "lockout_status"
+)
+
+// Guard checks an attempt and returns ErrLocked when it must be rejected, for auth services
+// verifying credentials outside of gin, e.g. in gRPC handlers.
+func (m *LockoutManager) Guard(ctx context.Context, identity, ip string) (Status, error) {
+	status, err := m.Check(ctx, identity, ip)
+	if err != nil {
+		return status, err
+	}
+	if status.Locked {
+		return status, ErrLocked
+	}
+	return status, nil
+}
+
+// Middleware protects a login route. identity extracts the identity being logged into from
+// the request and may return an empty string to only track the client IP. Locked attempts
+// are rejected with 429 Too Many Requests and a Retry-After header. After the handler, a 401
+// response counts as a failure and a 2xx response as a success; handlers needing other rules
+// call RecordFailure and RecordSuccess themselves and should not use this middleware.
+// The Status of the attempt is stored under StatusContextKey so handlers can demand a CAPTCHA.
+//
+// Redis errors fail open: the attempt is let through and the error is logged.
+func (m *LockoutManager) Middleware(identity func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		id, ip := identity(c), c.ClientIP()
+
+		status, err := m.Check(ctx, id, ip)
+		if err != nil {
+			m.logger.Warn(constant.SystemWarning, log.String("ip", ip), log.Err(err))
+			c.Next()
+			return
+		}
+		if status.Locked {
+			writeLocked(c, status)
+			return
+		}
+		setStatus(c, status)
+
+		c.Next()
+
+		switch code := c.Writer.Status(); {
+		case code == http.StatusUnauthorized:
+			if _, err = m.RecordFailure(ctx, id, ip); err != nil {
+				m.logger.Warn(constant.SystemWarning, log.String("ip", ip), log.Err(err))
+			}
+		case code >= 200 && code < 300:
+			if err = m.RecordSuccess(ctx, id, ip); err != nil {
+				m.logger.Warn(constant.SystemWarning, log.String("ip", ip), log.Err(err))
+			}
+		}
+	}
+}
+
+// writeLocked aborts the request of a locked attempt.
+func writeLocked(c *gin.Context, status Status) {
+	setStatus(c, status)
+	c.Header("Retry-After", fmt.Sprintf("%d", int(math.Ceil(status.RetryAfter.Seconds()))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": ErrLocked.Error()})
+}
+
+// setStatus exposes status to handlers and signals CAPTCHA requirements to the client.
+func setStatus(c *gin.Context, status Status) {
+	c.Set(StatusContextKey, status)
+	if status.CaptchaRequired {
+		c.Header(CaptchaRequiredHeader, "true")
+	}
+}
+
+// StatusFromContext returns the Status stored by Middleware.
+func StatusFromContext(c *gin.Context) (Status, bool) {
+	value, ok := c.Get(StatusContextKey)
+	if !ok {
+		return Status{}, false
+	}
+	status, ok := value.(Status)
+	return status, ok
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/utils/types"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxPooledFields is the capacity above which builders are dropped instead of pooled.
+const maxPooledFields = 32
+
+// lazyValue defers fn until the field is encoded, i.e. until an entry at an enabled level is
+// written.
+type lazyValue func() any
+
+// MarshalJSON evaluates the value.
+func (v lazyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v())
+}
+
+// Lazy creates a field whose value is computed by fn only when the entry is written, so
+// expensive values cost nothing at disabled levels or when the entry is sampled out.
+func Lazy(key string, fn func() any) types.Field {
+	return zap.Reflect(key, lazyValue(fn))
+}
+
+// LazySanitizeAny is SanitizeAny with the sanitizing deferred until the entry is written.
+func (l *Log) LazySanitizeAny(key string, value any) types.Field {
+	if l.sanitizer == nil {
+		return zap.Any(key, value)
+	}
+	return Lazy(key, func() any { return l.sanitizer.Sanitize(value) })
+}
+
+// Enabled reports whether entries at level are written, to skip building their fields.
+func (l *Log) Enabled(level zapcore.Level) bool {
+	return l.Core().Enabled(level)
+}
+
+// FieldSet is a slice of fields bound once, e.g. the service and subject of a handler, and
+// reused for every entry through Builder.
+type FieldSet []types.Field
+
+// BindFields returns a FieldSet of a copy of fields.
+func BindFields(fields ...types.Field) FieldSet {
+	return append(FieldSet(nil), fields...)
+}
+
+// Builder returns a pooled FieldBuilder starting with the fields of the set.
+func (s FieldSet) Builder() *FieldBuilder {
+	return NewFieldBuilder(s...)
+}
+
+// FieldBuilder accumulates the fields of an entry in a pooled slice. Release it once the
+// entry is logged; the slice of Fields must not be used afterwards.
+type FieldBuilder struct {
+	fields []types.Field
+}
+
+var fieldBuilderPool = sync.Pool{
+	New: func() any { return &FieldBuilder{fields: make([]types.Field, 0, 8)} },
+}
+
+// NewFieldBuilder returns a builder from the pool starting with fields.
+func NewFieldBuilder(fields ...types.Field) *FieldBuilder {
+	b := fieldBuilderPool.Get().(*FieldBuilder)
+	b.fields = append(b.fields, fields...)
+	return b
+}
+
+// Add appends fields.
+func (b *FieldBuilder) Add(fields ...types.Field) *FieldBuilder {
+	b.fields = append(b.fields, fields...)
+	return b
+}
+
+// String appends a string field.
+func (b *FieldBuilder) String(key, value string) *FieldBuilder {
+	b.fields = append(b.fields, zap.String(key, value))
+	return b
+}
+
+// Int appends an int field.
+func (b *FieldBuilder) Int(key string, value int) *FieldBuilder {
+	b.fields = append(b.fields, zap.Int(key, value))
+	return b
+}
+
+// Duration appends a time.Duration field.
+func (b *FieldBuilder) Duration(key string, value time.Duration) *FieldBuilder {
+	b.fields = append(b.fields, zap.Duration(key, value))
+	return b
+}
+
+// Err appends an error field.
+func (b *FieldBuilder) Err(err error) *FieldBuilder {
+	b.fields = append(b.fields, zap.Error(err))
+	return b
+}
+
+// Any appends a field of any value.
+func (b *FieldBuilder) Any(key string, value any) *FieldBuilder {
+	b.fields = append(b.fields, zap.Any(key, value))
+	return b
+}
+
+// Fields returns the accumulated fields.
+func (b *FieldBuilder) Fields() []types.Field {
+	return b.fields
+}
+
+// Release returns the builder to the pool.
+func (b *FieldBuilder) Release() {
+	if cap(b.fields) > maxPooledFields {
+		return
+	}
+	clear(b.fields)
+	b.fields = b.fields[:0]
+	fieldBuilderPool.Put(b)
+}
@@ -104,6 +104,9 @@ func NewLogger(cfg *LoggerConfig) (*Log, error) {
 	// ✅ 9. Combine all cores using NewTee.
 	// Every log message will now be sent to every core in the 'cores' slice.
 	finalCore := zapcore.NewTee(cores...)
+	if cfg.SecretScanner != nil {
+		finalCore = NewSecretScanningCore(finalCore, cfg.SecretScanner)
+	}
 
 	// ✅ 10. Build the logger with additional options
 	l := zap.New(finalCore, options...)
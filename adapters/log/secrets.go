@@ -0,0 +1,85 @@
+package log
+
+import (
+	"fmt"
+
+	"github.com/abhissng/neuron/utils/helpers"
+	"go.uber.org/zap/zapcore"
+)
+
+// secretScanningCore masks secrets in the entries written to the wrapped core.
+type secretScanningCore struct {
+	zapcore.Core
+	scanner *helpers.SecretScanner
+}
+
+// NewSecretScanningCore wraps core so that secrets found by scanner in messages and in string,
+// byte string, error and stringer fields are masked. Other field types are written as is.
+func NewSecretScanningCore(core zapcore.Core, scanner *helpers.SecretScanner) zapcore.Core {
+	return &secretScanningCore{Core: core, scanner: scanner}
+}
+
+func (c *secretScanningCore) With(fields []zapcore.Field) zapcore.Core {
+	return &secretScanningCore{Core: c.Core.With(c.maskFields(fields)), scanner: c.scanner}
+}
+
+func (c *secretScanningCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *secretScanningCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message, _ = c.scanner.Mask("log", entry.Message)
+	return c.Core.Write(entry, c.maskFields(fields))
+}
+
+// maskFields returns fields with secrets masked, copying the slice only when needed.
+func (c *secretScanningCore) maskFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+	for i, field := range fields {
+		masked, ok := c.maskField(field)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = append(make([]zapcore.Field, 0, len(fields)), fields...)
+		}
+		out[i] = masked
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (c *secretScanningCore) maskField(field zapcore.Field) (zapcore.Field, bool) {
+	var text string
+	switch field.Type {
+	case zapcore.StringType:
+		text = field.String
+	case zapcore.ByteStringType:
+		b, _ := field.Interface.([]byte)
+		text = string(b)
+	case zapcore.ErrorType:
+		err, _ := field.Interface.(error)
+		if err == nil {
+			return field, false
+		}
+		text = err.Error()
+	case zapcore.StringerType:
+		stringer, _ := field.Interface.(fmt.Stringer)
+		if stringer == nil {
+			return field, false
+		}
+		text = stringer.String()
+	default:
+		return field, false
+	}
+	masked, found := c.scanner.Mask("log", text)
+	if !found {
+		return field, false
+	}
+	return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: masked}, true
+}
@@ -193,6 +193,9 @@ type LoggerConfig struct {
 
 	// Sanitizer masks sensitive fields when using logger.Any(); nil means no sanitization
 	Sanitizer *helpers.Sanitizer
+
+	// SecretScanner masks secret formats in every message and string field; nil disables it
+	SecretScanner *helpers.SecretScanner
 }
 
 // LoggerOption defines a function that modifies LoggerConfig
@@ -285,3 +288,11 @@ func WithSanitizer(sanitizer *helpers.Sanitizer) LoggerOption {
 		c.Sanitizer = sanitizer
 	}
 }
+
+// WithSecretScanner masks known secret formats (AWS keys, private keys, bearer tokens, ...)
+// found in log messages and string or error fields, before they reach any output.
+func WithSecretScanner(scanner *helpers.SecretScanner) LoggerOption {
+	return func(c *LoggerConfig) {
+		c.SecretScanner = scanner
+	}
+}
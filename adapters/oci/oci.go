@@ -15,6 +15,7 @@ import (
 	"github.com/oracle/oci-go-sdk/v65/core"
 	"github.com/oracle/oci-go-sdk/v65/identity"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage/transfer"
 )
 
 // ========================= CONFIG =========================
@@ -218,11 +219,26 @@ func WithCtxTimeout(ctx context.Context, d time.Duration) (context.Context, cont
 // ========================= OBJECT STORAGE METHODS =========================
 
 // UploadObjectFromReader uploads data from an io.Reader to OCI Object Storage.
-// This method supports in-memory uploads and large files.
+// This method supports in-memory uploads and large files. A negative contentLength marks a
+// stream of unknown size, uploaded in parts with the multipart upload manager.
 func (cm *OCIManager) UploadObjectFromReader(ctx context.Context, namespace, bucket, objectName string, reader io.Reader, contentLength int64, metadata map[string]string) error {
 	if cm.objectClient == nil {
 		return errors.New("object storage client not initialized")
 	}
+	if contentLength < 0 {
+		// PutObject needs the length up front; a consumed stream cannot be retried either
+		_, err := transfer.NewUploadManager().UploadStream(ctx, transfer.UploadStreamRequest{
+			UploadRequest: transfer.UploadRequest{
+				NamespaceName:       &namespace,
+				BucketName:          &bucket,
+				ObjectName:          &objectName,
+				ObjectStorageClient: cm.objectClient,
+				Metadata:            metadata,
+			},
+			StreamReader: reader,
+		})
+		return err
+	}
 
 	// Convert io.Reader to io.ReadCloser if necessary
 	var readCloser io.ReadCloser
@@ -416,6 +432,41 @@ func (cm *OCIManager) IsObjectExists(ctx context.Context, namespace, bucket, obj
 	return true, nil
 }
 
+// CreatePreauthenticatedRequest creates a pre-authenticated request (PAR) for a single object and
+// returns its full URL. accessType is one of the objectstorage access types, e.g. "ObjectRead".
+func (cm *OCIManager) CreatePreauthenticatedRequest(ctx context.Context, namespace, bucket, objectName, accessType string, expiresAt time.Time) (string, error) {
+	if cm.objectClient == nil {
+		return "", errors.New("object storage client not initialized")
+	}
+	name := fmt.Sprintf("par-%s-%d", accessType, expiresAt.Unix())
+	var result string
+	err := cm.withRetry(ctx, func() error {
+		resp, e := cm.objectClient.CreatePreauthenticatedRequest(ctx, objectstorage.CreatePreauthenticatedRequestRequest{
+			NamespaceName: &namespace,
+			BucketName:    &bucket,
+			CreatePreauthenticatedRequestDetails: objectstorage.CreatePreauthenticatedRequestDetails{
+				Name:        &name,
+				ObjectName:  &objectName,
+				AccessType:  objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeEnum(accessType),
+				TimeExpires: &common.SDKTime{Time: expiresAt},
+			},
+		})
+		if e != nil {
+			return e
+		}
+		if resp.FullPath != nil {
+			result = *resp.FullPath
+			return nil
+		}
+		if resp.AccessUri == nil {
+			return errors.New("pre-authenticated request returned no access uri")
+		}
+		result = "https://" + cm.objectClient.Host + *resp.AccessUri
+		return nil
+	})
+	return result, err
+}
+
 // ========================= COMPUTE METHODS =========================
 
 func (cm *OCIManager) LaunchInstance(ctx context.Context, compartmentOCID, ad, shape, imageID, subnetID, displayName string) (*core.Instance, error) {
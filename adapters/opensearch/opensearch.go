@@ -48,6 +48,17 @@ func (w *OpenSearchWriter) Sync() error {
 	return nil
 }
 
+// Start runs the worker shipping the written documents, for writers used directly rather than
+// through GetOpenSearchLogCore, e.g. to write audit entries to a dedicated index.
+func (w *OpenSearchWriter) Start() {
+	w.start()
+}
+
+// Close flushes the buffered documents and stops the worker.
+func (w *OpenSearchWriter) Close() error {
+	return w.close()
+}
+
 // start runs the background worker goroutine.
 func (w *OpenSearchWriter) start() {
 	w.wg.Add(1)
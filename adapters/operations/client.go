@@ -0,0 +1,101 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+)
+
+// Client awaits operations of another service through its polling API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	interval   time.Duration
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client. Defaults to http.DefaultClient.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithHeader sends header with every poll, e.g. an Authorization header.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithPollInterval sets how often an operation is polled. Defaults to DefaultPollInterval.
+func WithPollInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.interval = interval
+	}
+}
+
+// NewClient creates a Client for the operations mounted at baseURL, e.g.
+// "http://exports.internal/v1/operations".
+func NewClient(baseURL string, options ...ClientOption) *Client {
+	c := &Client{
+		baseURL:    baseURL,
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+		interval:   DefaultPollInterval,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// Get fetches the operation id.
+func (c *Client) Get(ctx context.Context, id string) (*Operation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrOperationNotFound
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return nil, fmt.Errorf("operations: polling %s failed with status %d: %s", id, resp.StatusCode, body)
+	}
+	var envelope acknowledgment.APIResponse[Operation]
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("operations: invalid response for %s: %w", id, err)
+	}
+	return &envelope.Result, nil
+}
+
+// Await polls the operation id until it completes or timeout elapses; zero waits as long
+// as ctx allows. On timeout the last known state is returned with the context error.
+func (c *Client) Await(ctx context.Context, id string, timeout time.Duration) (*Operation, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return await(ctx, c.interval, func(ctx context.Context) (*Operation, error) {
+		return c.Get(ctx, id)
+	})
+}
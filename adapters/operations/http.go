@@ -0,0 +1,65 @@
+package operations
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/gin-gonic/gin"
+)
+
+// pollAfterSeconds is the Retry-After sent with the operations still running.
+const pollAfterSeconds = 1
+
+// RegisterRoutes mounts the polling API of the operations on group:
+//
+//	GET  /operations/:id         fetch an operation
+//	POST /operations/:id/cancel  cancel a running operation
+//
+// Handlers starting operations answer with Accepted, pointing clients at these routes.
+func RegisterRoutes(group *gin.RouterGroup, m *Manager) {
+	operations := group.Group("/operations")
+	m.basePath = operations.BasePath()
+	operations.GET("/:id", m.getHandler)
+	operations.POST("/:id/cancel", m.cancelHandler)
+}
+
+// Accepted answers c with 202, op and the Location to poll it at.
+func (m *Manager) Accepted(c *gin.Context, op *Operation) {
+	c.Header("Location", m.basePath+"/"+op.ID)
+	c.Header("Retry-After", strconv.Itoa(pollAfterSeconds))
+	c.JSON(http.StatusAccepted, acknowledgment.NewAPIResponse(true, "", op))
+}
+
+func (m *Manager) getHandler(c *gin.Context) {
+	op, err := m.store.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		operationError(c, err)
+		return
+	}
+	if !op.Done() {
+		c.Header("Retry-After", strconv.Itoa(pollAfterSeconds))
+	}
+	c.JSON(http.StatusOK, acknowledgment.NewAPIResponse(true, "", op))
+}
+
+func (m *Manager) cancelHandler(c *gin.Context) {
+	if err := m.Cancel(c.Request.Context(), c.Param("id")); err != nil {
+		operationError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, acknowledgment.NewAPIResponse(true, "", gin.H{"id": c.Param("id"), "status": StatusCancelled}))
+}
+
+// operationError writes an error response in the acknowledgment envelope.
+func operationError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrOperationNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrOperationCompleted), errors.Is(err, ErrOperationRemote):
+		status = http.StatusConflict
+	}
+	c.AbortWithStatusJSON(status, acknowledgment.NewAPIResponse(false, "", gin.H{"error": err.Error()}))
+}
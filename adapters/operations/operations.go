@@ -0,0 +1,338 @@
+// Package operations implements long-running operations: work started by a request that
+// answers right away with an operation ID, while the work runs in the background and persists
+// its status, progress and result. Clients poll the operation over HTTP, or wait for its
+// completion event on a broker such as NATS.
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+const (
+	// DefaultRetention is how long a RedisStore keeps an operation after its last update.
+	DefaultRetention = 24 * time.Hour
+	// DefaultCompletionSubject prefixes the subjects of the completion events; the event of
+	// an operation is published on "<subject>.<name>".
+	DefaultCompletionSubject = "neuron.operations.completed"
+	// DefaultPollInterval is how often Await checks an operation.
+	DefaultPollInterval = time.Second
+)
+
+// Status is the state of an operation.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// These are the errors of Cancel.
+var (
+	ErrOperationCompleted = errors.New("operations: operation already completed")
+	ErrOperationRemote    = errors.New("operations: operation is running on another replica")
+)
+
+// Operation is the persisted state of a long-running operation.
+type Operation struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Status      Status            `json:"status"`
+	Progress    int               `json:"progress"`
+	Message     string            `json:"message,omitempty"`
+	Result      json.RawMessage   `json:"result,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+}
+
+// Done reports whether the operation completed, whatever its outcome.
+func (o *Operation) Done() bool {
+	switch o.Status {
+	case StatusSucceeded, StatusFailed, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Decode unmarshals the result of a succeeded operation into v.
+func (o *Operation) Decode(v any) error {
+	if o.Status != StatusSucceeded {
+		return fmt.Errorf("operations: operation %s is %s", o.ID, o.Status)
+	}
+	return json.Unmarshal(o.Result, v)
+}
+
+// RunFunc performs the work of an operation, reporting its progress on tracker. The returned
+// value is stored, as JSON, as the result of the operation.
+type RunFunc func(ctx context.Context, tracker *Tracker) (any, error)
+
+// Tracker reports the progress of a running operation.
+type Tracker struct {
+	manager *Manager
+	mu      sync.Mutex
+	op      *Operation
+}
+
+// ID returns the ID of the operation.
+func (t *Tracker) ID() string {
+	return t.op.ID
+}
+
+// Progress records percent, from 0 to 100, and message as the progress of the operation.
+func (t *Tracker) Progress(ctx context.Context, percent int, message string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.op.Progress = min(max(percent, 0), 100)
+	t.op.Message = message
+	t.op.UpdatedAt = time.Now()
+	return t.manager.store.Save(ctx, t.op)
+}
+
+// Manager starts operations and tracks them in a Store.
+type Manager struct {
+	store    Store
+	broker   events.Broker
+	subject  string
+	logger   *log.Log
+	basePath string
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithBroker publishes the operations on broker once completed, on "<subject>.<name>".
+// subject defaults to DefaultCompletionSubject.
+func WithBroker(broker events.Broker, subject string) Option {
+	return func(m *Manager) {
+		m.broker = broker
+		if subject != "" {
+			m.subject = subject
+		}
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// NewManager creates a Manager persisting the operations in store.
+func NewManager(store Store, options ...Option) *Manager {
+	m := &Manager{
+		store:    store,
+		subject:  DefaultCompletionSubject,
+		basePath: "/operations",
+		running:  make(map[string]context.CancelFunc),
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return m
+}
+
+// StartOption configures an operation started with Start.
+type StartOption func(*Operation)
+
+// WithMetadata attaches metadata to the operation, e.g. the ID of the resource it works on.
+func WithMetadata(metadata map[string]string) StartOption {
+	return func(op *Operation) {
+		op.Metadata = metadata
+	}
+}
+
+// Start persists a pending operation name and runs it in the background. The operation
+// outlives ctx, whose values it keeps; stop it with Cancel.
+func (m *Manager) Start(ctx context.Context, name string, run RunFunc, options ...StartOption) (*Operation, error) {
+	now := time.Now()
+	op := &Operation{
+		ID:        random.GenerateSecureUUIDString(),
+		Name:      name,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, opt := range options {
+		opt(op)
+	}
+	if err := m.store.Save(ctx, op); err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.mu.Lock()
+	m.running[op.ID] = cancel
+	m.mu.Unlock()
+
+	started := *op
+	m.wg.Add(1)
+	go m.run(runCtx, cancel, &started, run)
+	return op, nil
+}
+
+// run performs the operation op and records its outcome.
+func (m *Manager) run(ctx context.Context, cancel context.CancelFunc, op *Operation, run RunFunc) {
+	defer m.wg.Done()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, op.ID)
+		m.mu.Unlock()
+		cancel()
+	}()
+
+	tracker := &Tracker{manager: m, op: op}
+	tracker.mu.Lock()
+	op.Status = StatusRunning
+	op.UpdatedAt = time.Now()
+	if err := m.store.Save(ctx, op); err != nil {
+		m.logger.Warn("Failed to save operation", log.String("operation_id", op.ID), log.Err(err))
+	}
+	tracker.mu.Unlock()
+
+	result, err := m.execute(ctx, tracker, run)
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	now := time.Now()
+	op.UpdatedAt, op.CompletedAt = now, &now
+	switch {
+	case ctx.Err() != nil:
+		op.Status, op.Error = StatusCancelled, context.Canceled.Error()
+	case err != nil:
+		op.Status, op.Error = StatusFailed, err.Error()
+	default:
+		op.Status, op.Progress = StatusSucceeded, 100
+		if result != nil {
+			if op.Result, err = json.Marshal(result); err != nil {
+				op.Status, op.Error = StatusFailed, fmt.Sprintf("failed to encode result: %v", err)
+			}
+		}
+	}
+
+	saveCtx := context.WithoutCancel(ctx)
+	if err := m.store.Save(saveCtx, op); err != nil {
+		m.logger.Error("Failed to save completed operation", log.String("operation_id", op.ID), log.Err(err))
+	}
+	if m.broker != nil {
+		if err := m.broker.Publish(saveCtx, m.subject+"."+op.Name, op); err != nil {
+			m.logger.Error("Failed to publish operation completion", log.String("operation_id", op.ID), log.Err(err))
+		}
+	}
+}
+
+// execute runs run, turning a panic into an error.
+func (m *Manager) execute(ctx context.Context, tracker *Tracker, run RunFunc) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("Operation panicked", log.String("operation_id", tracker.ID()), log.Any("panic", r), log.String("stack", string(debug.Stack())))
+			err = fmt.Errorf("operation panicked: %v", r)
+		}
+	}()
+	return run(ctx, tracker)
+}
+
+// Get returns the operation id.
+func (m *Manager) Get(ctx context.Context, id string) (*Operation, error) {
+	return m.store.Get(ctx, id)
+}
+
+// Cancel stops the operation id, which must be running on this replica.
+func (m *Manager) Cancel(ctx context.Context, id string) error {
+	m.mu.Lock()
+	cancel, ok := m.running[id]
+	m.mu.Unlock()
+	if ok {
+		cancel()
+		return nil
+	}
+	op, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if op.Done() {
+		return ErrOperationCompleted
+	}
+	return ErrOperationRemote
+}
+
+// Await polls the operation id every interval, DefaultPollInterval when zero, until it
+// completes or ctx is done.
+func (m *Manager) Await(ctx context.Context, id string, interval time.Duration) (*Operation, error) {
+	return await(ctx, interval, func(ctx context.Context) (*Operation, error) {
+		return m.store.Get(ctx, id)
+	})
+}
+
+// Shutdown cancels the operations running on this replica and waits for them to record
+// their outcome, until ctx is done.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	for _, cancel := range m.running {
+		cancel()
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// await calls get every interval until the operation completes or ctx is done.
+func await(ctx context.Context, interval time.Duration, get func(context.Context) (*Operation, error)) (*Operation, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last *Operation
+	for {
+		op, err := get(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return last, ctx.Err()
+			}
+			return nil, err
+		}
+		if op.Done() {
+			return op, nil
+		}
+		last = op
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
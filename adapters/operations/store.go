@@ -0,0 +1,107 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrOperationNotFound is returned for unknown or expired operations.
+var ErrOperationNotFound = errors.New("operations: operation not found")
+
+// Store persists the operations, so any replica can answer their polling.
+type Store interface {
+	// Save creates or replaces op.
+	Save(ctx context.Context, op *Operation) error
+	// Get returns the operation id, or ErrOperationNotFound.
+	Get(ctx context.Context, id string) (*Operation, error)
+}
+
+// MemoryStore keeps the operations in memory, for a single replica or tests.
+type MemoryStore struct {
+	mu  sync.RWMutex
+	ops map[string]Operation
+	ttl time.Duration
+}
+
+// NewMemoryStore creates a MemoryStore forgetting completed operations after ttl; zero keeps
+// them.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{ops: make(map[string]Operation), ttl: ttl}
+}
+
+func (m *MemoryStore) Save(_ context.Context, op *Operation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ttl > 0 {
+		now := time.Now()
+		for id, existing := range m.ops {
+			if existing.CompletedAt != nil && now.Sub(*existing.CompletedAt) > m.ttl {
+				delete(m.ops, id)
+			}
+		}
+	}
+	m.ops[op.ID] = *op
+	return nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, id string) (*Operation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	op, ok := m.ops[id]
+	if !ok {
+		return nil, ErrOperationNotFound
+	}
+	return &op, nil
+}
+
+// RedisStore keeps the operations in Redis, expiring them ttl after their last update.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a RedisStore. prefix defaults to "neuron:operations" and ttl to
+// DefaultRetention.
+func NewRedisStore(client redis.UniversalClient, prefix string, ttl time.Duration) *RedisStore {
+	if prefix == "" {
+		prefix = "neuron:operations"
+	}
+	if ttl <= 0 {
+		ttl = DefaultRetention
+	}
+	return &RedisStore{client: client, prefix: prefix, ttl: ttl}
+}
+
+func (r *RedisStore) key(id string) string {
+	return r.prefix + ":" + id
+}
+
+func (r *RedisStore) Save(ctx context.Context, op *Operation) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, r.key(op.ID), data, r.ttl).Err()
+}
+
+func (r *RedisStore) Get(ctx context.Context, id string) (*Operation, error) {
+	data, err := r.client.Get(ctx, r.key(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrOperationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var op Operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, fmt.Errorf("operations: corrupt operation %s: %w", id, err)
+	}
+	return &op, nil
+}
@@ -4,9 +4,11 @@ import (
 	"crypto/ed25519"
 	"time"
 
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/structures"
+	"github.com/abhissng/neuron/utils/structures/claims"
 )
 
 const (
@@ -20,6 +22,7 @@ type PasetoOption func(*PasetoManager)
 func NewPasetoManager(opts ...PasetoOption) *PasetoManager {
 	pw := &PasetoManager{
 		basicTokenExpiry: time.Minute * 5,
+		clock:            clock.System,
 	}
 
 	for _, opt := range opts {
@@ -89,6 +92,37 @@ func WithBasicTokenExpiry(basicToken time.Duration) PasetoOption {
 	}
 }
 
+// WithActAsTokenExpiry sets the expiry of act-as and delegated tokens. Defaults to the access
+// token expiry.
+func WithActAsTokenExpiry(actAsToken time.Duration) PasetoOption {
+	return func(p *PasetoManager) {
+		p.actAsTokenExpiry = actAsToken
+	}
+}
+
+// WithMachineTokenExpiry sets the expiry of machine tokens. Defaults to the access token expiry.
+func WithMachineTokenExpiry(machineToken time.Duration) PasetoOption {
+	return func(p *PasetoManager) {
+		p.machineTokenExpiry = machineToken
+	}
+}
+
+// WithClock sets the clock tokens are issued and validated with. Defaults to clock.System.
+func WithClock(c clock.Clock) PasetoOption {
+	return func(p *PasetoManager) {
+		p.clock = clock.OrSystem(c)
+	}
+}
+
+// WithClaimSchemas sets the schemas of the custom claims, by audience. Tokens whose claims do
+// not match the schema of their audience, and of claims.AnyAudience, are neither created nor
+// validated.
+func WithClaimSchemas(schemas ...*claims.Schema) PasetoOption {
+	return func(p *PasetoManager) {
+		p.claimSchemas = claims.NewSchemas(schemas...)
+	}
+}
+
 // WithPasetoMiddlewareOption sets the middleware options for the PASETO wrapper.
 func WithPasetoMiddlewareOption(opts ...PasetoMiddlewareOption) PasetoOption {
 	return func(p *PasetoManager) {
@@ -3,10 +3,12 @@ package paseto
 import (
 	"crypto/ed25519"
 	"errors"
+	"maps"
 	"time"
 
 	"github.com/abhissng/neuron/blame"
 	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/structures/claims"
 	"github.com/abhissng/neuron/utils/types"
@@ -20,7 +22,11 @@ type PasetoManager struct {
 	basicTokenExpiry       time.Duration
 	accessTokenExpiry      time.Duration
 	refreshTokenExpiry     time.Duration
+	actAsTokenExpiry       time.Duration
+	machineTokenExpiry     time.Duration
 	pasetoMiddlewareOption *PasetoMiddlewareOptions
+	clock                  clock.Clock
+	claimSchemas           claims.Schemas
 }
 
 // **Token Generation**
@@ -40,12 +46,66 @@ func (p *PasetoManager) FetchBasicToken(options ...claims.StandardClaimsOption)
 	return p.createToken(p.issuer, p.basicTokenExpiry, options...)
 }
 
+// FetchActAsToken generates an access token letting actor act as subject, e.g. a support agent
+// acting as a customer. The token carries subject as its subject and actor in its act claim.
+func (p *PasetoManager) FetchActAsToken(actor, subject string, options ...claims.StandardClaimsOption) result.Result[TokenDetails] {
+	if helpers.IsEmpty(actor) || helpers.IsEmpty(subject) || actor == subject {
+		return result.NewFailure[TokenDetails](blame.InvalidTokenClaimsError("", errors.New("act-as tokens need an actor distinct from the subject")))
+	}
+	options = append(options, claims.WithSubject(subject), claims.WithActor(actor))
+	return p.createToken(p.issuer, p.actAsExpiry(), options...)
+}
+
+// FetchDelegatedToken generates an access token for the subject, audience and data of parent,
+// delegated to actor. The actors of parent are kept in the act claim, after actor.
+func (p *PasetoManager) FetchDelegatedToken(parent *claims.StandardClaims, actor string, options ...claims.StandardClaimsOption) result.Result[TokenDetails] {
+	if parent == nil || helpers.IsEmpty(actor) || actor == parent.Sub {
+		return result.NewFailure[TokenDetails](blame.InvalidTokenClaimsError("", errors.New("delegated tokens need a parent token and an actor distinct from its subject")))
+	}
+	options = append([]claims.StandardClaimsOption{
+		claims.WithSubject(parent.Sub),
+		claims.WithAudience(parent.Aud),
+		claims.WithData(maps.Clone(parent.Data)),
+		claims.WithActorChain(parent.Act),
+		claims.WithActor(actor),
+	}, options...)
+	return p.createToken(p.issuer, p.actAsExpiry(), options...)
+}
+
+// FetchMachineToken generates a token for service-to-service calls made by service, granting
+// scopes such as "billing:read" or "orders:*". The token carries service as its subject.
+func (p *PasetoManager) FetchMachineToken(service string, scopes []string, options ...claims.StandardClaimsOption) result.Result[TokenDetails] {
+	if helpers.IsEmpty(service) || len(scopes) == 0 {
+		return result.NewFailure[TokenDetails](blame.InvalidTokenClaimsError("", errors.New("machine tokens need a service and at least one scope")))
+	}
+	options = append(options, claims.WithSubject(service), claims.WithScopes(scopes...))
+	expiry := p.machineTokenExpiry
+	if expiry <= 0 {
+		expiry = p.accessTokenExpiry
+	}
+	return p.createToken(p.issuer, expiry, options...)
+}
+
+// actAsExpiry returns the expiry of act-as and delegated tokens.
+func (p *PasetoManager) actAsExpiry() time.Duration {
+	if p.actAsTokenExpiry > 0 {
+		return p.actAsTokenExpiry
+	}
+	return p.accessTokenExpiry
+}
+
 // createToken generates a new token with the given issuer, expiry, and options
 func (p *PasetoManager) createToken(issuer string, expiry time.Duration, options ...claims.StandardClaimsOption) result.Result[TokenDetails] {
 
 	// Create standard claims
+	options = append([]claims.StandardClaimsOption{claims.WithIssuedAt(p.clock.Now())}, options...)
 	standardClaims := claims.NewStandardClaims(issuer, expiry, options...).WithPid()
 
+	// Refuse tokens whose custom claims do not match the schema of their audience
+	if err := p.claimSchemas.Validate(standardClaims); err != nil {
+		return result.NewFailure[TokenDetails](blame.InvalidTokenClaimsError(standardClaims.Aud, err))
+	}
+
 	// Encrypt the token
 	token, err := GetPasetoObj().Sign(p.privateKey, standardClaims, nil)
 	if err != nil {
@@ -85,10 +145,15 @@ func (p *PasetoManager) ValidateToken(
 	if helpers.IsEmpty(claim.Exp) {
 		return result.NewFailure[claims.StandardClaims](blame.MalformedAuthToken(nil))
 	}
-	if time.Now().After(claim.Exp) {
+	if p.clock.Now().After(claim.Exp) {
 		return result.NewFailure[claims.StandardClaims](blame.ExpiredAuthToken())
 	}
 
+	// Validate custom claims
+	if err := p.claimSchemas.Validate(&claim); err != nil {
+		return result.NewFailure[claims.StandardClaims](blame.InvalidTokenClaimsError(claim.Aud, err))
+	}
+
 	// Run custom validators
 	for _, validator := range validators {
 		if validator == nil {
@@ -102,6 +167,11 @@ func (p *PasetoManager) ValidateToken(
 	return result.NewSuccess(&claim)
 }
 
+// ClaimSchemas returns the claim schemas tokens are created and validated with.
+func (p *PasetoManager) ClaimSchemas() claims.Schemas {
+	return p.claimSchemas
+}
+
 // PasetoMiddlewareOption returns the middleware options for the PASETO wrapper.
 func (p *PasetoManager) PasetoMiddlewareOption() *PasetoMiddlewareOptions {
 	return p.pasetoMiddlewareOption
@@ -0,0 +1,279 @@
+// Package bankverify verifies Indian bank accounts before payouts and in KYC flows. Providers
+// run a penny drop, crediting a nominal amount to learn the name registered with the bank, or
+// a pennyless lookup; the Verifier validates the input, calls the provider, matches the name
+// and turns each failure category into its blame code.
+package bankverify
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/adapters/verification"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// DefaultNameMatchThreshold is the name similarity below which a verification is a mismatch.
+const DefaultNameMatchThreshold = verification.DefaultNameMatchThreshold
+
+// Method is how a provider verifies an account.
+type Method string
+
+const (
+	// MethodPennyDrop credits a nominal amount and reads the beneficiary name of the transfer.
+	MethodPennyDrop Method = "penny_drop"
+	// MethodPennyless queries the bank without moving money.
+	MethodPennyless Method = "pennyless"
+)
+
+// Status is the normalized outcome of an account verification.
+type Status = verification.Status
+
+const (
+	StatusVerified = verification.StatusVerified
+	// StatusNotFound means the bank has no such account.
+	StatusNotFound = verification.StatusNotFound
+	// StatusInactive means the account exists but cannot be credited.
+	StatusInactive = verification.StatusInactive
+	// StatusPending means the bank has not answered yet; retry with the same reference.
+	StatusPending = verification.StatusPending
+	// StatusFailed means the provider could not verify the account, e.g. because the bank is
+	// down.
+	StatusFailed = verification.StatusFailed
+)
+
+// Request asks to verify an account.
+type Request struct {
+	AccountNumber string `json:"account_number"`
+	IFSC          string `json:"ifsc"`
+	// Name is the expected account holder name; leave empty to skip name matching.
+	Name   string `json:"name,omitempty"`
+	Method Method `json:"method,omitempty"`
+	// ReferenceID makes the verification idempotent at the provider. Generated when empty.
+	ReferenceID string `json:"reference_id"`
+}
+
+// Result is the normalized outcome of a verification.
+type Result struct {
+	Status      Status `json:"status"`
+	ReferenceID string `json:"reference_id"`
+	Provider    string `json:"provider"`
+	Method      Method `json:"method"`
+	// AccountNumber is masked.
+	AccountNumber string `json:"account_number"`
+	IFSC          string `json:"ifsc"`
+	// NameAtBank is the account holder name registered with the bank.
+	NameAtBank string `json:"name_at_bank,omitempty"`
+	// NameMatchScore is the similarity of NameAtBank and the expected name, from 0 to 1.
+	NameMatchScore float64 `json:"name_match_score,omitempty"`
+	NameMatched    bool    `json:"name_matched"`
+	// Reason explains StatusInactive and StatusFailed, e.g. "closed" or "frozen".
+	Reason string `json:"reason,omitempty"`
+	// UTR is the bank reference of the penny drop transfer.
+	UTR        string    `json:"utr,omitempty"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// Branch describes the branch of an IFSC.
+type Branch struct {
+	IFSC     string `json:"ifsc"`
+	Bank     string `json:"bank"`
+	Branch   string `json:"branch"`
+	City     string `json:"city,omitempty"`
+	State    string `json:"state,omitempty"`
+	IMPS     bool   `json:"imps"`
+	NEFT     bool   `json:"neft"`
+	RTGS     bool   `json:"rtgs"`
+	UPI      bool   `json:"upi"`
+	MICRCode string `json:"micr,omitempty"`
+}
+
+// Provider verifies accounts through a bank verification API. Providers report what the bank
+// said in the Result; errors are reserved for failures to reach the provider.
+type Provider interface {
+	Name() string
+	Verify(ctx context.Context, req Request) (*Result, error)
+}
+
+// BranchLookup is implemented by providers that resolve IFSC codes to branches.
+type BranchLookup interface {
+	LookupIFSC(ctx context.Context, ifsc string) (*Branch, error)
+}
+
+// Verifier validates requests, verifies them with a Provider and reports failures as blames.
+type Verifier struct {
+	provider  Provider
+	threshold float64
+	logger    *log.Log
+}
+
+// Option configures a Verifier.
+type Option func(*Verifier)
+
+// WithNameMatchThreshold sets the name similarity below which a verification is a mismatch.
+// Defaults to DefaultNameMatchThreshold.
+func WithNameMatchThreshold(threshold float64) Option {
+	return func(v *Verifier) {
+		v.threshold = threshold
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(v *Verifier) {
+		v.logger = logger
+	}
+}
+
+// NewVerifier creates a Verifier using provider.
+func NewVerifier(provider Provider, options ...Option) *Verifier {
+	v := &Verifier{provider: provider, threshold: DefaultNameMatchThreshold}
+	for _, opt := range options {
+		opt(v)
+	}
+	if v.logger == nil {
+		v.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return v
+}
+
+// Verify verifies the account of req. The Result is returned alongside the blame of a failed
+// verification, so callers can record it; a pending verification returns no blame.
+func (v *Verifier) Verify(ctx context.Context, req Request) (*Result, blame.Blame) {
+	req.IFSC = NormalizeIFSC(req.IFSC)
+	req.AccountNumber = NormalizeAccountNumber(req.AccountNumber)
+	if !ValidIFSC(req.IFSC) {
+		return nil, blame.InvalidIFSCError(req.IFSC)
+	}
+	if reason := validateAccountNumber(req.AccountNumber); reason != "" {
+		return nil, blame.InvalidBankAccountError(reason)
+	}
+	if req.Method == "" {
+		req.Method = MethodPennyDrop
+	}
+	if req.ReferenceID == "" {
+		req.ReferenceID = random.GenerateUUIDString()
+	}
+	masked := MaskAccountNumber(req.AccountNumber)
+
+	result, err := v.provider.Verify(ctx, req)
+	if err != nil {
+		v.logger.Error("Bank verification failed", log.String("provider", v.provider.Name()), log.String("reference_id", req.ReferenceID), log.Err(err))
+		return nil, blame.BankVerificationUnavailableError(v.provider.Name(), err)
+	}
+	result.Provider = v.provider.Name()
+	result.ReferenceID, result.Method = req.ReferenceID, req.Method
+	result.AccountNumber, result.IFSC = masked, req.IFSC
+	if result.VerifiedAt.IsZero() {
+		result.VerifiedAt = time.Now()
+	}
+
+	switch result.Status {
+	case StatusVerified:
+		if req.Name == "" {
+			return result, nil
+		}
+		result.NameMatchScore, result.NameMatched = verification.MatchName(req.Name, result.NameAtBank, v.threshold)
+		if !result.NameMatched {
+			return result, blame.BankNameMismatchError(req.Name, result.NameMatchScore)
+		}
+		return result, nil
+	case StatusPending:
+		return result, nil
+	case StatusNotFound:
+		return result, blame.BankAccountNotFoundError(masked, req.IFSC)
+	case StatusInactive:
+		return result, blame.BankAccountInactiveError(masked, result.Reason)
+	default:
+		return result, blame.BankVerificationUnavailableError(v.provider.Name(), statusError(result))
+	}
+}
+
+// LookupIFSC validates ifsc and, when the provider implements BranchLookup, resolves its
+// branch. Without a BranchLookup only the format is checked and the branch is nil.
+func (v *Verifier) LookupIFSC(ctx context.Context, ifsc string) (*Branch, blame.Blame) {
+	ifsc = NormalizeIFSC(ifsc)
+	if !ValidIFSC(ifsc) {
+		return nil, blame.InvalidIFSCError(ifsc)
+	}
+	lookup, ok := v.provider.(BranchLookup)
+	if !ok {
+		return nil, nil
+	}
+	branch, err := lookup.LookupIFSC(ctx, ifsc)
+	if err != nil {
+		return nil, blame.BankVerificationUnavailableError(v.provider.Name(), err)
+	}
+	if branch == nil {
+		return nil, blame.InvalidIFSCError(ifsc)
+	}
+	return branch, nil
+}
+
+// statusError describes a failed result.
+func statusError(result *Result) error {
+	reason := result.Reason
+	if reason == "" {
+		reason = "verification " + string(result.Status)
+	}
+	return &providerError{reason: reason}
+}
+
+type providerError struct {
+	reason string
+}
+
+func (e *providerError) Error() string {
+	return "bankverify: " + e.reason
+}
+
+// ifscPattern is four letters of the bank, a zero and six characters of the branch.
+var ifscPattern = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+
+// NormalizeIFSC upper-cases ifsc and removes spaces.
+func NormalizeIFSC(ifsc string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(ifsc), " ", ""))
+}
+
+// ValidIFSC reports whether ifsc, normalized, has the IFSC format.
+func ValidIFSC(ifsc string) bool {
+	return ifscPattern.MatchString(ifsc)
+}
+
+// NormalizeAccountNumber removes the spaces and dashes of an account number.
+func NormalizeAccountNumber(account string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(account))
+}
+
+// validateAccountNumber returns why account, normalized, is not an account number, or "".
+func validateAccountNumber(account string) string {
+	if len(account) < 9 || len(account) > 18 {
+		return "must have 9 to 18 digits"
+	}
+	for _, r := range account {
+		if r < '0' || r > '9' {
+			return "must contain digits only"
+		}
+	}
+	if strings.Trim(account, "0") == "" {
+		return "must not be all zeros"
+	}
+	return ""
+}
+
+// MaskAccountNumber keeps the last four digits of account.
+func MaskAccountNumber(account string) string {
+	if len(account) <= 4 {
+		return account
+	}
+	return strings.Repeat("X", len(account)-4) + account[len(account)-4:]
+}
@@ -0,0 +1,110 @@
+package bankverify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// MockAccount is an account known to a MockProvider.
+type MockAccount struct {
+	AccountNumber string
+	IFSC          string
+	Name          string
+	// Status defaults to StatusVerified.
+	Status Status
+	Reason string
+}
+
+// MockProvider verifies accounts against an in-memory list, for tests and local development.
+// Unknown accounts are StatusNotFound.
+type MockProvider struct {
+	mu       sync.RWMutex
+	accounts map[string]MockAccount
+	branches map[string]Branch
+	err      error
+	calls    []Request
+}
+
+// NewMockProvider creates a MockProvider knowing accounts.
+func NewMockProvider(accounts ...MockAccount) *MockProvider {
+	m := &MockProvider{accounts: make(map[string]MockAccount), branches: make(map[string]Branch)}
+	for _, account := range accounts {
+		m.AddAccount(account)
+	}
+	return m
+}
+
+// AddAccount adds or replaces account.
+func (m *MockProvider) AddAccount(account MockAccount) {
+	account.AccountNumber = NormalizeAccountNumber(account.AccountNumber)
+	account.IFSC = NormalizeIFSC(account.IFSC)
+	if account.Status == "" {
+		account.Status = StatusVerified
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accounts[account.IFSC+"/"+account.AccountNumber] = account
+}
+
+// AddBranch adds or replaces branch for LookupIFSC.
+func (m *MockProvider) AddBranch(branch Branch) {
+	branch.IFSC = NormalizeIFSC(branch.IFSC)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.branches[branch.IFSC] = branch
+}
+
+// FailWith makes every call fail with err, as if the provider were down; nil recovers.
+func (m *MockProvider) FailWith(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Calls returns the requests received so far.
+func (m *MockProvider) Calls() []Request {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Request(nil), m.calls...)
+}
+
+func (m *MockProvider) Name() string {
+	return "mock"
+}
+
+func (m *MockProvider) Verify(_ context.Context, req Request) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, req)
+	if m.err != nil {
+		return nil, m.err
+	}
+	account, ok := m.accounts[req.IFSC+"/"+req.AccountNumber]
+	if !ok {
+		return &Result{Status: StatusNotFound, VerifiedAt: time.Now()}, nil
+	}
+	result := &Result{Status: account.Status, Reason: account.Reason, VerifiedAt: time.Now()}
+	if account.Status == StatusVerified {
+		result.NameAtBank = account.Name
+		if req.Method == MethodPennyDrop {
+			result.UTR = random.GenerateUUIDString()
+		}
+	}
+	return result, nil
+}
+
+func (m *MockProvider) LookupIFSC(_ context.Context, ifsc string) (*Branch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	branch, ok := m.branches[ifsc]
+	if !ok {
+		return nil, nil
+	}
+	return &branch, nil
+}
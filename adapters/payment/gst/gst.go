@@ -0,0 +1,269 @@
+// Package gst computes the Indian Goods and Services Tax of invoices. A Calculator looks up
+// the rate of every line by its HSN or SAC code, prices it tax inclusive or exclusive, and
+// splits the tax by place of supply: CGST and SGST, or CGST and UTGST in union territories
+// without a legislature, within a state, and IGST across states and for exports.
+//
+//	calc := gst.NewCalculator(gst.DefaultRates(), gst.WithOverride("998314", gst.Rate{GST: 1800}))
+//	breakdown, err := calc.Compute(gst.Supply{SupplierState: "27", PlaceOfSupply: "29"}, lines...)
+//
+// Amounts are in paise and rates in basis points, so 18% is 1800, keeping the math exact.
+package gst
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// These are the errors of the Calculator.
+var (
+	ErrInvalidState = errors.New("gst: invalid state code")
+	ErrUnknownCode  = errors.New("gst: no rate for the HSN/SAC code")
+	ErrInvalidLine  = errors.New("gst: invalid line")
+)
+
+// Rate is the tax rate of an HSN or SAC code, in basis points.
+type Rate struct {
+	GST int64 `json:"gst"`
+	// Cess is the compensation cess levied on top of GST, e.g. on tobacco or motor cars.
+	Cess int64 `json:"cess,omitempty"`
+}
+
+// Supply describes who supplies where, deciding how the tax is split.
+type Supply struct {
+	// SupplierState is the state code of the supplier, the first two digits of its GSTIN.
+	SupplierState string `json:"supplier_state"`
+	// PlaceOfSupply is the state code of the place of supply, usually the state of the
+	// recipient. ForeignCountry marks exports.
+	PlaceOfSupply string `json:"place_of_supply"`
+	// SEZ marks supplies to special economic zones, taxed as inter-state supplies.
+	SEZ bool `json:"sez,omitempty"`
+	// ZeroRated marks exports and SEZ supplies made under a letter of undertaking, which bear
+	// no tax.
+	ZeroRated bool `json:"zero_rated,omitempty"`
+}
+
+// Interstate reports whether s is taxed with IGST.
+func (s Supply) Interstate() bool {
+	return s.SEZ || s.PlaceOfSupply == ForeignCountry || s.SupplierState != s.PlaceOfSupply
+}
+
+// Line is a line of an invoice.
+type Line struct {
+	// Code is the HSN code of goods or the SAC code of services.
+	Code        string `json:"code"`
+	Description string `json:"description,omitempty"`
+	UnitPrice   int64  `json:"unit_price"`
+	Quantity    int64  `json:"quantity"`
+	// Discount is taken off the line before tax.
+	Discount int64 `json:"discount,omitempty"`
+	// Inclusive marks prices that include the tax.
+	Inclusive bool `json:"inclusive,omitempty"`
+}
+
+// LineTax is the tax of a line.
+type LineTax struct {
+	Line    Line  `json:"line"`
+	Rate    Rate  `json:"rate"`
+	Taxable int64 `json:"taxable"`
+	CGST    int64 `json:"cgst"`
+	SGST    int64 `json:"sgst"`
+	UTGST   int64 `json:"utgst"`
+	IGST    int64 `json:"igst"`
+	Cess    int64 `json:"cess"`
+	// Total is the taxable value with every tax.
+	Total int64 `json:"total"`
+}
+
+// Tax returns the sum of the taxes of the line.
+func (t LineTax) Tax() int64 {
+	return t.CGST + t.SGST + t.UTGST + t.IGST + t.Cess
+}
+
+// CodeSummary totals the lines of an HSN or SAC code, as printed in the HSN summary of an
+// invoice.
+type CodeSummary struct {
+	Code     string `json:"code"`
+	Rate     Rate   `json:"rate"`
+	Quantity int64  `json:"quantity"`
+	Taxable  int64  `json:"taxable"`
+	Tax      int64  `json:"tax"`
+}
+
+// Breakdown is the tax of an invoice.
+type Breakdown struct {
+	Supply  Supply        `json:"supply"`
+	Lines   []LineTax     `json:"lines"`
+	Codes   []CodeSummary `json:"codes"`
+	Taxable int64         `json:"taxable"`
+	CGST    int64         `json:"cgst"`
+	SGST    int64         `json:"sgst"`
+	UTGST   int64         `json:"utgst"`
+	IGST    int64         `json:"igst"`
+	Cess    int64         `json:"cess"`
+	Total   int64         `json:"total"`
+}
+
+// Tax returns the sum of the taxes of the invoice.
+func (b *Breakdown) Tax() int64 {
+	return b.CGST + b.SGST + b.UTGST + b.IGST + b.Cess
+}
+
+// Calculator computes the tax of invoices with a table of rates.
+type Calculator struct {
+	rates       map[string]Rate
+	defaultRate *Rate
+}
+
+// Option configures a Calculator.
+type Option func(*Calculator)
+
+// WithOverride sets the rate of code, replacing the rate of the table.
+func WithOverride(code string, rate Rate) Option {
+	return func(c *Calculator) {
+		c.rates[normalizeCode(code)] = rate
+	}
+}
+
+// WithDefaultRate sets the rate of the codes missing from the table. Without one they fail
+// with ErrUnknownCode.
+func WithDefaultRate(rate Rate) Option {
+	return func(c *Calculator) {
+		c.defaultRate = &rate
+	}
+}
+
+// NewCalculator creates a Calculator with rates, keyed by HSN or SAC code.
+func NewCalculator(rates map[string]Rate, options ...Option) *Calculator {
+	c := &Calculator{rates: make(map[string]Rate, len(rates))}
+	for code, rate := range rates {
+		c.rates[normalizeCode(code)] = rate
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+func normalizeCode(code string) string {
+	return strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+}
+
+// RateFor returns the rate of code. Codes match their longest prefix in the table, so an
+// 8 digit HSN code falls back to the rate of its 6 or 4 digit heading.
+func (c *Calculator) RateFor(code string) (Rate, error) {
+	code = normalizeCode(code)
+	for n := len(code); n >= 2; n-- {
+		if rate, ok := c.rates[code[:n]]; ok {
+			return rate, nil
+		}
+	}
+	if c.defaultRate != nil {
+		return *c.defaultRate, nil
+	}
+	return Rate{}, fmt.Errorf("%w: %q", ErrUnknownCode, code)
+}
+
+// Compute computes the tax of lines supplied as described by supply.
+func (c *Calculator) Compute(supply Supply, lines ...Line) (*Breakdown, error) {
+	if _, ok := States[supply.SupplierState]; !ok {
+		return nil, fmt.Errorf("%w: supplier state %q", ErrInvalidState, supply.SupplierState)
+	}
+	if _, ok := States[supply.PlaceOfSupply]; !ok && supply.PlaceOfSupply != ForeignCountry {
+		return nil, fmt.Errorf("%w: place of supply %q", ErrInvalidState, supply.PlaceOfSupply)
+	}
+
+	breakdown := &Breakdown{Supply: supply, Lines: make([]LineTax, 0, len(lines))}
+	codes := make(map[string]*CodeSummary)
+	for i, line := range lines {
+		tax, err := c.computeLine(supply, line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		breakdown.Lines = append(breakdown.Lines, tax)
+		breakdown.Taxable += tax.Taxable
+		breakdown.CGST += tax.CGST
+		breakdown.SGST += tax.SGST
+		breakdown.UTGST += tax.UTGST
+		breakdown.IGST += tax.IGST
+		breakdown.Cess += tax.Cess
+		breakdown.Total += tax.Total
+
+		key := normalizeCode(line.Code) + "/" + fmt.Sprint(tax.Rate.GST, "/", tax.Rate.Cess)
+		summary, ok := codes[key]
+		if !ok {
+			summary = &CodeSummary{Code: normalizeCode(line.Code), Rate: tax.Rate}
+			codes[key] = summary
+		}
+		summary.Quantity += line.Quantity
+		summary.Taxable += tax.Taxable
+		summary.Tax += tax.Tax()
+	}
+	for _, summary := range codes {
+		breakdown.Codes = append(breakdown.Codes, *summary)
+	}
+	sort.Slice(breakdown.Codes, func(i, j int) bool {
+		if breakdown.Codes[i].Code != breakdown.Codes[j].Code {
+			return breakdown.Codes[i].Code < breakdown.Codes[j].Code
+		}
+		return breakdown.Codes[i].Rate.GST < breakdown.Codes[j].Rate.GST
+	})
+	return breakdown, nil
+}
+
+// computeLine computes the tax of line. Each component is rounded to the paisa on its own;
+// for inclusive prices the taxable value is what remains of the price once they are taken
+// out, so the line still totals its price.
+func (c *Calculator) computeLine(supply Supply, line Line) (LineTax, error) {
+	if line.Quantity <= 0 || line.UnitPrice < 0 || line.Discount < 0 {
+		return LineTax{}, fmt.Errorf("%w: quantity must be positive and amounts not negative", ErrInvalidLine)
+	}
+	gross := line.UnitPrice*line.Quantity - line.Discount
+	if gross < 0 {
+		return LineTax{}, fmt.Errorf("%w: discount exceeds the line amount", ErrInvalidLine)
+	}
+	rate, err := c.RateFor(line.Code)
+	if err != nil {
+		return LineTax{}, err
+	}
+	if supply.ZeroRated && supply.Interstate() {
+		rate = Rate{}
+	}
+
+	tax := LineTax{Line: line, Rate: rate, Taxable: gross}
+	if line.Inclusive {
+		tax.Taxable = roundDiv(gross*basisPoints, basisPoints+rate.GST+rate.Cess)
+	}
+	if supply.Interstate() {
+		tax.IGST = percentOf(tax.Taxable, rate.GST)
+	} else {
+		// The rate is split evenly between the centre and the state
+		half := roundDiv(tax.Taxable*rate.GST, 2*basisPoints)
+		tax.CGST = half
+		if States[supply.PlaceOfSupply].UnionTerritory {
+			tax.UTGST = half
+		} else {
+			tax.SGST = half
+		}
+	}
+	tax.Cess = percentOf(tax.Taxable, rate.Cess)
+	if line.Inclusive {
+		tax.Taxable = gross - tax.Tax()
+	}
+	tax.Total = tax.Taxable + tax.Tax()
+	return tax, nil
+}
+
+// basisPoints is 100%.
+const basisPoints = 10000
+
+// percentOf returns rate basis points of amount, rounded half up to the paisa.
+func percentOf(amount, rate int64) int64 {
+	return roundDiv(amount*rate, basisPoints)
+}
+
+// roundDiv divides a by b, rounding half up.
+func roundDiv(a, b int64) int64 {
+	return (2*a + b) / (2 * b)
+}
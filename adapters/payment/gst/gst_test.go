@@ -0,0 +1,121 @@
+package gst
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	calc := NewCalculator(DefaultRates(),
+		WithOverride("8471", Rate{GST: 1800}),
+		WithOverride("87032", Rate{GST: 2800, Cess: 1500}),
+		WithOverride("7113", Rate{GST: 300}),
+	)
+
+	tests := []struct {
+		name   string
+		supply Supply
+		line   Line
+		want   LineTax
+	}{
+		{
+			name:   "intra-state exclusive",
+			supply: Supply{SupplierState: "27", PlaceOfSupply: "27"},
+			line:   Line{Code: "998314", UnitPrice: 100000, Quantity: 2},
+			want:   LineTax{Taxable: 200000, CGST: 18000, SGST: 18000, Total: 236000},
+		},
+		{
+			name:   "inter-state exclusive",
+			supply: Supply{SupplierState: "27", PlaceOfSupply: "29"},
+			line:   Line{Code: "84713010", UnitPrice: 4999900, Quantity: 1, Discount: 99900},
+			want:   LineTax{Taxable: 4900000, IGST: 882000, Total: 5782000},
+		},
+		{
+			name:   "union territory",
+			supply: Supply{SupplierState: "04", PlaceOfSupply: "04"},
+			line:   Line{Code: "9983", UnitPrice: 1000, Quantity: 1},
+			want:   LineTax{Taxable: 1000, CGST: 90, UTGST: 90, Total: 1180},
+		},
+		{
+			name:   "inclusive keeps the price",
+			supply: Supply{SupplierState: "27", PlaceOfSupply: "27"},
+			line:   Line{Code: "9983", UnitPrice: 99900, Quantity: 1, Inclusive: true},
+			want:   LineTax{Taxable: 84662, CGST: 7619, SGST: 7619, Total: 99900},
+		},
+		{
+			name:   "cess",
+			supply: Supply{SupplierState: "27", PlaceOfSupply: "24"},
+			line:   Line{Code: "870323", UnitPrice: 100000, Quantity: 1},
+			want:   LineTax{Taxable: 100000, IGST: 28000, Cess: 15000, Total: 143000},
+		},
+		{
+			name:   "odd half rate",
+			supply: Supply{SupplierState: "27", PlaceOfSupply: "27"},
+			line:   Line{Code: "7113", UnitPrice: 333, Quantity: 1},
+			want:   LineTax{Taxable: 333, CGST: 5, SGST: 5, Total: 343},
+		},
+		{
+			name:   "zero rated export",
+			supply: Supply{SupplierState: "27", PlaceOfSupply: ForeignCountry, ZeroRated: true},
+			line:   Line{Code: "9983", UnitPrice: 100000, Quantity: 1},
+			want:   LineTax{Taxable: 100000, Total: 100000},
+		},
+		{
+			name:   "export with tax paid",
+			supply: Supply{SupplierState: "27", PlaceOfSupply: ForeignCountry},
+			line:   Line{Code: "9983", UnitPrice: 100000, Quantity: 1},
+			want:   LineTax{Taxable: 100000, IGST: 18000, Total: 118000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := calc.Compute(tt.supply, tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := breakdown.Lines[0]
+			got.Line, got.Rate = Line{}, Rate{}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if breakdown.Total != got.Total || breakdown.Tax() != got.Tax() {
+				t.Errorf("breakdown %+v does not total its line", breakdown)
+			}
+		})
+	}
+}
+
+func TestComputeSummarisesCodes(t *testing.T) {
+	calc := NewCalculator(DefaultRates())
+	breakdown, err := calc.Compute(Supply{SupplierState: "27", PlaceOfSupply: "27"},
+		Line{Code: "9983", UnitPrice: 1000, Quantity: 1},
+		Line{Code: "9983", UnitPrice: 2000, Quantity: 2},
+		Line{Code: "9984", UnitPrice: 500, Quantity: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(breakdown.Codes) != 2 || breakdown.Codes[0] != (CodeSummary{Code: "9983", Rate: Rate{GST: 1800}, Quantity: 3, Taxable: 5000, Tax: 900}) {
+		t.Errorf("unexpected codes %+v", breakdown.Codes)
+	}
+	if breakdown.Taxable != 5500 || breakdown.CGST != 495 || breakdown.SGST != 495 || breakdown.Total != 6490 {
+		t.Errorf("unexpected totals %+v", breakdown)
+	}
+}
+
+func TestComputeErrors(t *testing.T) {
+	calc := NewCalculator(DefaultRates())
+	supply := Supply{SupplierState: "27", PlaceOfSupply: "27"}
+	if _, err := calc.Compute(supply, Line{Code: "1006", UnitPrice: 100, Quantity: 1}); !errors.Is(err, ErrUnknownCode) {
+		t.Errorf("want ErrUnknownCode, got %v", err)
+	}
+	if _, err := calc.Compute(supply, Line{Code: "9983", UnitPrice: 100, Quantity: 1, Discount: 200}); !errors.Is(err, ErrInvalidLine) {
+		t.Errorf("want ErrInvalidLine, got %v", err)
+	}
+	if _, err := calc.Compute(Supply{SupplierState: "99", PlaceOfSupply: "27"}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("want ErrInvalidState, got %v", err)
+	}
+	if state, err := StateFromGSTIN("27AAPFU0939F1ZV"); err != nil || state != "27" {
+		t.Errorf("StateFromGSTIN = %q, %v", state, err)
+	}
+}
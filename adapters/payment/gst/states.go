@@ -0,0 +1,97 @@
+package gst
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ForeignCountry is the place of supply of exports.
+const ForeignCountry = "96"
+
+// State is a state or union territory of the GST.
+type State struct {
+	Code string
+	Name string
+	// UnionTerritory marks union territories without a legislature, which levy UTGST
+	// instead of SGST.
+	UnionTerritory bool
+}
+
+// States are the states and union territories, keyed by their GST state code.
+var States = map[string]State{
+	"01": {Code: "01", Name: "Jammu and Kashmir"},
+	"02": {Code: "02", Name: "Himachal Pradesh"},
+	"03": {Code: "03", Name: "Punjab"},
+	"04": {Code: "04", Name: "Chandigarh", UnionTerritory: true},
+	"05": {Code: "05", Name: "Uttarakhand"},
+	"06": {Code: "06", Name: "Haryana"},
+	"07": {Code: "07", Name: "Delhi"},
+	"08": {Code: "08", Name: "Rajasthan"},
+	"09": {Code: "09", Name: "Uttar Pradesh"},
+	"10": {Code: "10", Name: "Bihar"},
+	"11": {Code: "11", Name: "Sikkim"},
+	"12": {Code: "12", Name: "Arunachal Pradesh"},
+	"13": {Code: "13", Name: "Nagaland"},
+	"14": {Code: "14", Name: "Manipur"},
+	"15": {Code: "15", Name: "Mizoram"},
+	"16": {Code: "16", Name: "Tripura"},
+	"17": {Code: "17", Name: "Meghalaya"},
+	"18": {Code: "18", Name: "Assam"},
+	"19": {Code: "19", Name: "West Bengal"},
+	"20": {Code: "20", Name: "Jharkhand"},
+	"21": {Code: "21", Name: "Odisha"},
+	"22": {Code: "22", Name: "Chhattisgarh"},
+	"23": {Code: "23", Name: "Madhya Pradesh"},
+	"24": {Code: "24", Name: "Gujarat"},
+	"26": {Code: "26", Name: "Dadra and Nagar Haveli and Daman and Diu", UnionTerritory: true},
+	"27": {Code: "27", Name: "Maharashtra"},
+	"29": {Code: "29", Name: "Karnataka"},
+	"30": {Code: "30", Name: "Goa"},
+	"31": {Code: "31", Name: "Lakshadweep", UnionTerritory: true},
+	"32": {Code: "32", Name: "Kerala"},
+	"33": {Code: "33", Name: "Tamil Nadu"},
+	"34": {Code: "34", Name: "Puducherry"},
+	"35": {Code: "35", Name: "Andaman and Nicobar Islands", UnionTerritory: true},
+	"36": {Code: "36", Name: "Telangana"},
+	"37": {Code: "37", Name: "Andhra Pradesh"},
+	"38": {Code: "38", Name: "Ladakh", UnionTerritory: true},
+	"97": {Code: "97", Name: "Other Territory", UnionTerritory: true},
+}
+
+// StateFromGSTIN returns the state code of gstin, its first two digits.
+func StateFromGSTIN(gstin string) (string, error) {
+	gstin = strings.ToUpper(strings.TrimSpace(gstin))
+	if len(gstin) != 15 {
+		return "", fmt.Errorf("%w: GSTIN %q", ErrInvalidState, gstin)
+	}
+	if _, ok := States[gstin[:2]]; !ok {
+		return "", fmt.Errorf("%w: GSTIN %q", ErrInvalidState, gstin)
+	}
+	return gstin[:2], nil
+}
+
+// DefaultRates returns the rates of common SAC codes of services. Rates change with the
+// notifications of the GST council, so services override or extend them with WithOverride
+// rather than rely on them for goods.
+func DefaultRates() map[string]Rate {
+	return map[string]Rate{
+		// Financial and related services
+		"9971": {GST: 1800},
+		// Leasing or rental services without operator
+		"9973": {GST: 1800},
+		// Legal and accounting services
+		"9982": {GST: 1800},
+		// Other professional, technical and business services, including IT services
+		"9983": {GST: 1800},
+		// Telecommunications, broadcasting and information supply services
+		"9984": {GST: 1800},
+		// Support services
+		"9985": {GST: 1800},
+		// Maintenance, repair and installation services
+		"9987": {GST: 1800},
+		// Education services
+		"9992": {GST: 0},
+		// Human health and social care services
+		"9993": {GST: 0},
+	}
+}
@@ -0,0 +1,32 @@
+package razorpay
+
+import (
+	"github.com/abhissng/neuron/adapters/payment/gst"
+)
+
+// ApplyGST computes the GST of the line items of r with calc, by their HSN or SAC code, and
+// sets their tax amounts. The breakdown holds the CGST, SGST or IGST split for the invoice.
+func (r *InvoiceRequest) ApplyGST(calc *gst.Calculator, supply gst.Supply) (*gst.Breakdown, error) {
+	lines := make([]gst.Line, 0, len(r.LineItems))
+	for _, item := range r.LineItems {
+		code := item.HSNCode
+		if code == "" {
+			code = item.SACCode
+		}
+		lines = append(lines, gst.Line{
+			Code:        code,
+			Description: item.Name,
+			UnitPrice:   item.Amount,
+			Quantity:    int64(item.Quantity),
+			Inclusive:   item.TaxInclusive,
+		})
+	}
+	breakdown, err := calc.Compute(supply, lines...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range r.LineItems {
+		r.LineItems[i].TaxAmount = breakdown.Lines[i].Tax()
+	}
+	return breakdown, nil
+}
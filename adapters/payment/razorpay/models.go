@@ -291,6 +291,10 @@ type InvoiceLineItem struct {
 	Amount      int64  `json:"amount"`
 	Quantity    int    `json:"quantity"`
 	TaxAmount   int64  `json:"tax_amount,omitempty"`
+	// HSNCode or SACCode classifies the item for GST, see ApplyGST.
+	HSNCode      string `json:"hsn_code,omitempty"`
+	SACCode      string `json:"sac_code,omitempty"`
+	TaxInclusive bool   `json:"tax_inclusive,omitempty"`
 }
 
 func NewInvoiceLineItem() *InvoiceLineItem {
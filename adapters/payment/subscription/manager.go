@@ -0,0 +1,513 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/clock"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// DefaultEventSubject prefixes the subjects of the subscription events; the Change of a
+// transition is published on "<subject>.<event>", e.g. "neuron.subscription.payment_failed".
+const DefaultEventSubject = "neuron.subscription"
+
+// DunningPolicy decides how long failed subscriptions keep their entitlement and when their
+// payments are retried.
+type DunningPolicy struct {
+	// Grace is how long a past due subscription stays entitled before it is suspended.
+	Grace time.Duration
+	// Retries are the delays of the retries after each failed payment; once they are
+	// exhausted the subscription is suspended. Without retries dunning ends with the grace
+	// period or EventDunningExhausted, e.g. when the gateway gives up.
+	Retries []time.Duration
+	// CancelAfter cancels suspended subscriptions after it; zero keeps them suspended.
+	CancelAfter time.Duration
+}
+
+// DefaultDunningPolicy grants a week of grace and retries after one, three and five days.
+func DefaultDunningPolicy() DunningPolicy {
+	return DunningPolicy{
+		Grace:   7 * 24 * time.Hour,
+		Retries: []time.Duration{24 * time.Hour, 3 * 24 * time.Hour, 5 * 24 * time.Hour},
+	}
+}
+
+// Manager moves the subscriptions of a Store through their lifecycle.
+type Manager struct {
+	store   Store
+	catalog Catalog
+	policy  DunningPolicy
+	broker  events.Broker
+	subject string
+	logger  *log.Log
+	clock   clock.Clock
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithDunning sets the dunning policy. Defaults to DefaultDunningPolicy.
+func WithDunning(policy DunningPolicy) Option {
+	return func(m *Manager) {
+		m.policy = policy
+	}
+}
+
+// WithBroker publishes a Change for every transition on broker, on "<subject>.<event>".
+// subject defaults to DefaultEventSubject.
+func WithBroker(broker events.Broker, subject string) Option {
+	return func(m *Manager) {
+		m.broker = broker
+		if subject != "" {
+			m.subject = subject
+		}
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// WithClock sets the clock deciding deadlines, e.g. in tests. Defaults to clock.System.
+func WithClock(c clock.Clock) Option {
+	return func(m *Manager) {
+		m.clock = clock.OrSystem(c)
+	}
+}
+
+// NewManager creates a Manager keeping the subscriptions to the plans of catalog in store.
+func NewManager(store Store, catalog Catalog, options ...Option) *Manager {
+	m := &Manager{
+		store:   store,
+		catalog: catalog,
+		policy:  DefaultDunningPolicy(),
+		subject: DefaultEventSubject,
+		clock:   clock.System,
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return m
+}
+
+// CreateRequest creates a subscription.
+type CreateRequest struct {
+	CustomerID string
+	PlanID     string
+	// GatewayID is the ID of the subscription created on the payment gateway.
+	GatewayID string
+	// Trial overrides the trial of the plan; a negative trial skips it.
+	Trial time.Duration
+}
+
+// Create creates a subscription, trialing when its plan has a trial and pending its first
+// payment otherwise.
+func (m *Manager) Create(ctx context.Context, req CreateRequest) (*Subscription, error) {
+	plan, ok := m.catalog[req.PlanID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, req.PlanID)
+	}
+	now := m.clock.Now()
+	sub := &Subscription{
+		ID:         random.GenerateUUIDString(),
+		CustomerID: req.CustomerID,
+		PlanID:     plan.ID,
+		GatewayID:  req.GatewayID,
+		Status:     StatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	trial := plan.Trial
+	if req.Trial != 0 {
+		trial = req.Trial
+	}
+	if trial > 0 {
+		sub.Status = StatusTrialing
+		sub.TrialEndsAt = now.Add(trial)
+		sub.CurrentPeriodStart, sub.CurrentPeriodEnd = now, sub.TrialEndsAt
+	}
+	sub.DueAt = m.dueAt(sub)
+	if err := m.store.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	m.publish(ctx, sub, []Transition{{To: sub.Status, Event: EventCreated, At: now}})
+	return sub, nil
+}
+
+// Get returns a subscription.
+func (m *Manager) Get(ctx context.Context, id string) (*Subscription, error) {
+	return m.store.Get(ctx, id)
+}
+
+// Apply applies event to a subscription. Events already applied are no-ops, and events the
+// status of the subscription does not accept fail with ErrInvalidTransition.
+func (m *Manager) Apply(ctx context.Context, id string, event Event) (*Subscription, error) {
+	return m.update(ctx, id, func(sub *Subscription, now time.Time) ([]Transition, error) {
+		return m.fire(sub, event, now)
+	})
+}
+
+// ApplyGateway applies event to the subscription of a gateway ID, as translated from a
+// webhook.
+func (m *Manager) ApplyGateway(ctx context.Context, gatewayID string, event Event) (*Subscription, error) {
+	sub, err := m.store.FindByGatewayID(ctx, gatewayID)
+	if err != nil {
+		return nil, err
+	}
+	return m.Apply(ctx, sub.ID, event)
+}
+
+// Cancel cancels a subscription, at the end of its period when atPeriodEnd is set and it is
+// trialing or active. The cancellation must be mirrored on the gateway.
+func (m *Manager) Cancel(ctx context.Context, id string, atPeriodEnd bool) (*Subscription, error) {
+	return m.update(ctx, id, func(sub *Subscription, now time.Time) ([]Transition, error) {
+		if atPeriodEnd && (sub.Status == StatusTrialing || sub.Status == StatusActive) {
+			sub.CancelAtPeriodEnd = true
+			sub.DueAt = m.dueAt(sub)
+			return nil, nil
+		}
+		return m.fire(sub, Event{Type: EventCancelled, At: now}, now)
+	})
+}
+
+// Timing is when a plan change takes effect.
+type Timing int
+
+const (
+	// TimingAuto applies upgrades immediately and defers downgrades to the end of the period.
+	TimingAuto Timing = iota
+	TimingImmediate
+	TimingPeriodEnd
+)
+
+// ChangePlan moves a trialing or active subscription to another plan and returns its
+// proration. Changes during a trial take effect immediately and cost nothing; other
+// changes take effect as timing says, the caller charging or refunding the net of immediate
+// ones. Changing back to the current plan drops a scheduled change.
+func (m *Manager) ChangePlan(ctx context.Context, id, planID string, timing Timing) (*Proration, error) {
+	to, ok := m.catalog[planID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, planID)
+	}
+	var proration Proration
+	_, err := m.update(ctx, id, func(sub *Subscription, now time.Time) ([]Transition, error) {
+		if sub.Status != StatusTrialing && sub.Status != StatusActive {
+			return nil, fmt.Errorf("%w: cannot change the plan of a %s subscription", ErrInvalidTransition, sub.Status)
+		}
+		if sub.PlanID == planID {
+			if sub.ScheduledChange == nil {
+				return nil, ErrSamePlan
+			}
+			sub.ScheduledChange = nil
+			sub.DueAt = m.dueAt(sub)
+			proration = Proration{FromPlanID: planID, ToPlanID: planID, EffectiveAt: now}
+			return nil, nil
+		}
+
+		if sub.Status == StatusTrialing {
+			proration = Proration{FromPlanID: sub.PlanID, ToPlanID: planID, EffectiveAt: now}
+			return m.fire(sub, Event{Type: EventPlanChanged, PlanID: planID, At: now}, now)
+		}
+		from, ok := m.catalog[sub.PlanID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, sub.PlanID)
+		}
+		proration = Prorate(from, to, sub.CurrentPeriodStart, sub.CurrentPeriodEnd, now)
+		if timing == TimingPeriodEnd || timing == TimingAuto && proration.Net <= 0 {
+			sub.ScheduledChange = &ScheduledChange{PlanID: planID, EffectiveAt: sub.CurrentPeriodEnd}
+			sub.DueAt = m.dueAt(sub)
+			proration = Proration{
+				FromPlanID:  from.ID,
+				ToPlanID:    to.ID,
+				Scheduled:   true,
+				EffectiveAt: sub.CurrentPeriodEnd,
+			}
+			return nil, nil
+		}
+		return m.fire(sub, Event{
+			Type:        EventPlanChanged,
+			PlanID:      planID,
+			PeriodStart: proration.PeriodStart,
+			PeriodEnd:   proration.PeriodEnd,
+			At:          now,
+		}, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proration, nil
+}
+
+// maxSteps bounds the events a tick fires on a subscription.
+const maxSteps = 4
+
+// Tick advances up to limit subscriptions whose deadlines passed: trials end, retries fall
+// due, grace periods expire, scheduled plan changes apply and cancellations take effect. It
+// returns the number of subscriptions advanced; run it periodically, e.g. from a cron job.
+func (m *Manager) Tick(ctx context.Context, limit int) (int, error) {
+	due, err := m.store.Due(ctx, m.clock.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	advanced := 0
+	for _, sub := range due {
+		_, err := m.update(ctx, sub.ID, func(sub *Subscription, now time.Time) ([]Transition, error) {
+			var transitions []Transition
+			for range maxSteps {
+				event, ok := m.dueEvent(sub, now)
+				if !ok {
+					break
+				}
+				fired, err := m.fire(sub, event, now)
+				if err != nil {
+					return nil, err
+				}
+				transitions = append(transitions, fired...)
+			}
+			sub.DueAt = m.dueAt(sub)
+			return transitions, nil
+		})
+		if err != nil {
+			m.logger.Error("Failed to advance subscription", log.String("subscription_id", sub.ID), log.Err(err))
+			errs = append(errs, err)
+			continue
+		}
+		advanced++
+	}
+	return advanced, errors.Join(errs...)
+}
+
+// update applies fn to a subscription in the store and publishes its transitions.
+func (m *Manager) update(ctx context.Context, id string, fn func(sub *Subscription, now time.Time) ([]Transition, error)) (*Subscription, error) {
+	var transitions []Transition
+	sub, err := m.store.Update(ctx, id, func(sub *Subscription) error {
+		now := m.clock.Now()
+		var err error
+		if transitions, err = fn(sub, now); err != nil {
+			return err
+		}
+		sub.UpdatedAt = now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.publish(ctx, sub, transitions)
+	return sub, nil
+}
+
+// fire moves sub through the state machine with event.
+func (m *Manager) fire(sub *Subscription, event Event, now time.Time) ([]Transition, error) {
+	if sub.seen(event.ID) {
+		return nil, nil
+	}
+	// Gateways repeat the final event, e.g. when a cancellation made here is mirrored there
+	if sub.Status == StatusCancelled && event.Type == EventCancelled || sub.Status == StatusExpired && event.Type == EventCompleted {
+		sub.remember(event.ID)
+		return nil, nil
+	}
+	to, ok := lifecycle[sub.Status][event.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s on a %s subscription", ErrInvalidTransition, event.Type, sub.Status)
+	}
+	at := event.At
+	if at.IsZero() {
+		at = now
+	}
+	from := sub.Status
+
+	switch event.Type {
+	case EventAuthorized:
+		sub.AuthorizedAt = at
+	case EventActivated, EventRenewed:
+		if err := m.startPeriod(sub, event, at); err != nil {
+			return nil, err
+		}
+		sub.PastDueSince, sub.GraceEndsAt, sub.NextRetryAt, sub.SuspendedAt = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+		sub.FailedAttempts = 0
+	case EventPaymentFailed:
+		if from != StatusPastDue && from != StatusSuspended {
+			m.startDunning(sub, at)
+		}
+		sub.FailedAttempts++
+		sub.NextRetryAt = time.Time{}
+		if sub.FailedAttempts <= len(m.policy.Retries) {
+			sub.NextRetryAt = at.Add(m.policy.Retries[sub.FailedAttempts-1])
+		}
+	case EventTrialEnded:
+		m.startDunning(sub, at)
+	case EventRetryDue:
+		sub.NextRetryAt = time.Time{}
+	case EventGraceExpired, EventDunningExhausted:
+		if from != StatusSuspended {
+			sub.SuspendedAt = at
+		}
+		sub.NextRetryAt = time.Time{}
+	case EventPlanChanged:
+		plan, err := m.eventPlan(event)
+		if err != nil {
+			return nil, err
+		}
+		sub.PlanID = plan.ID
+		sub.ScheduledChange = nil
+		if !event.PeriodStart.IsZero() && !event.PeriodEnd.IsZero() {
+			sub.CurrentPeriodStart, sub.CurrentPeriodEnd = event.PeriodStart, event.PeriodEnd
+		}
+	case EventPaused:
+		sub.PausedAt = at
+	case EventResumed:
+		sub.PausedAt = time.Time{}
+	case EventCancelled:
+		sub.CancelledAt, sub.EndedAt = at, at
+		sub.ScheduledChange = nil
+	case EventCompleted:
+		sub.EndedAt = at
+	}
+
+	sub.Status = to
+	sub.remember(event.ID)
+	transitions := []Transition{{From: from, To: to, Event: event.Type, EventID: event.ID, At: at}}
+	if to == StatusPastDue && event.Type == EventPaymentFailed && len(m.policy.Retries) > 0 && sub.FailedAttempts > len(m.policy.Retries) {
+		exhausted, err := m.fire(sub, Event{Type: EventDunningExhausted, At: at}, now)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, exhausted...)
+	}
+	sub.DueAt = m.dueAt(sub)
+	return transitions, nil
+}
+
+// startPeriod sets the period paid by an activation or renewal. Without bounds from the
+// gateway, renewals start where the current period ends and activations start at at; a
+// change scheduled by then applies first.
+func (m *Manager) startPeriod(sub *Subscription, event Event, at time.Time) error {
+	start, end := event.PeriodStart, event.PeriodEnd
+	if start.IsZero() {
+		if event.Type == EventActivated && sub.Status == StatusActive {
+			return nil
+		}
+		start = at
+		if event.Type == EventRenewed && !sub.CurrentPeriodEnd.IsZero() {
+			start = sub.CurrentPeriodEnd
+		}
+	}
+	if change := sub.ScheduledChange; change != nil && !change.EffectiveAt.After(start) {
+		sub.PlanID = change.PlanID
+		sub.ScheduledChange = nil
+	}
+	if end.IsZero() {
+		plan, ok := m.catalog[sub.PlanID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrPlanNotFound, sub.PlanID)
+		}
+		end = plan.Next(start)
+	}
+	sub.CurrentPeriodStart, sub.CurrentPeriodEnd = start, end
+	return nil
+}
+
+func (m *Manager) startDunning(sub *Subscription, at time.Time) {
+	sub.PastDueSince = at
+	sub.GraceEndsAt = at.Add(m.policy.Grace)
+	sub.FailedAttempts = 0
+	sub.NextRetryAt = time.Time{}
+}
+
+// eventPlan returns the plan of a plan change.
+func (m *Manager) eventPlan(event Event) (Plan, error) {
+	if event.PlanID != "" {
+		if plan, ok := m.catalog[event.PlanID]; ok {
+			return plan, nil
+		}
+		return Plan{}, fmt.Errorf("%w: %s", ErrPlanNotFound, event.PlanID)
+	}
+	if plan, ok := m.catalog.ByGatewayID(event.GatewayPlanID); ok && event.GatewayPlanID != "" {
+		return plan, nil
+	}
+	return Plan{}, fmt.Errorf("%w: gateway plan %q", ErrPlanNotFound, event.GatewayPlanID)
+}
+
+// deadline is an event sub fires at a time.
+type deadline struct {
+	at    time.Time
+	event Event
+}
+
+// deadlines returns the pending deadlines of sub, in the order they fire when several are
+// due.
+func (m *Manager) deadlines(sub *Subscription) []deadline {
+	var out []deadline
+	add := func(at time.Time, event Event) {
+		if !at.IsZero() {
+			event.At = at
+			out = append(out, deadline{at: at, event: event})
+		}
+	}
+	switch sub.Status {
+	case StatusTrialing, StatusActive:
+		if sub.CancelAtPeriodEnd {
+			add(sub.CurrentPeriodEnd, Event{Type: EventCancelled})
+		}
+		if sub.Status == StatusTrialing {
+			add(sub.TrialEndsAt, Event{Type: EventTrialEnded})
+		}
+		if sub.Status == StatusActive && sub.ScheduledChange != nil {
+			add(sub.ScheduledChange.EffectiveAt, Event{Type: EventPlanChanged, PlanID: sub.ScheduledChange.PlanID})
+		}
+	case StatusPastDue:
+		add(sub.GraceEndsAt, Event{Type: EventGraceExpired})
+		add(sub.NextRetryAt, Event{Type: EventRetryDue})
+	case StatusSuspended:
+		if m.policy.CancelAfter > 0 && !sub.SuspendedAt.IsZero() {
+			add(sub.SuspendedAt.Add(m.policy.CancelAfter), Event{Type: EventCancelled})
+		}
+	}
+	return out
+}
+
+// dueEvent returns the first event of sub due at now.
+func (m *Manager) dueEvent(sub *Subscription, now time.Time) (Event, bool) {
+	for _, d := range m.deadlines(sub) {
+		if !d.at.After(now) {
+			return d.event, true
+		}
+	}
+	return Event{}, false
+}
+
+// dueAt returns the earliest deadline of sub, or zero.
+func (m *Manager) dueAt(sub *Subscription) time.Time {
+	var due time.Time
+	for _, d := range m.deadlines(sub) {
+		if due.IsZero() || d.at.Before(due) {
+			due = d.at
+		}
+	}
+	return due
+}
+
+func (m *Manager) publish(ctx context.Context, sub *Subscription, transitions []Transition) {
+	if m.broker == nil {
+		return
+	}
+	for _, transition := range transitions {
+		change := Change{Subscription: *sub, Transition: transition}
+		if b := m.broker.Publish(ctx, m.subject+"."+string(transition.Event), change); b != nil {
+			m.logger.Error("Failed to publish subscription event", log.String("subscription_id", sub.ID), log.String("event", string(transition.Event)), log.Err(b))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package subscription
+
+import "time"
+
+// Period is the billing period of a plan, as named by Razorpay.
+type Period string
+
+const (
+	Daily     Period = "daily"
+	Weekly    Period = "weekly"
+	Monthly   Period = "monthly"
+	Quarterly Period = "quarterly"
+	Yearly    Period = "yearly"
+)
+
+// Plan is what a subscription pays for. Amounts are in minor units, e.g. paise.
+type Plan struct {
+	ID string `json:"id"`
+	// GatewayID is the ID of the plan on the payment gateway.
+	GatewayID string `json:"gateway_id,omitempty"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Period    Period `json:"period"`
+	// Interval is the number of periods billed at once. Defaults to 1.
+	Interval int `json:"interval,omitempty"`
+	// Trial is the free trial of new subscriptions.
+	Trial time.Duration `json:"trial,omitempty"`
+}
+
+// Next returns the end of a billing period of p starting at start.
+func (p Plan) Next(start time.Time) time.Time {
+	n := max(p.Interval, 1)
+	switch p.Period {
+	case Daily:
+		return start.AddDate(0, 0, n)
+	case Weekly:
+		return start.AddDate(0, 0, 7*n)
+	case Quarterly:
+		return start.AddDate(0, 3*n, 0)
+	case Yearly:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, n, 0)
+	}
+}
+
+func (p Plan) sameCycle(o Plan) bool {
+	return p.Period == o.Period && max(p.Interval, 1) == max(o.Interval, 1)
+}
+
+// Catalog is the plans subscriptions may be on, keyed by ID.
+type Catalog map[string]Plan
+
+// NewCatalog creates a Catalog of plans.
+func NewCatalog(plans ...Plan) Catalog {
+	c := make(Catalog, len(plans))
+	for _, plan := range plans {
+		c[plan.ID] = plan
+	}
+	return c
+}
+
+// ByGatewayID returns the plan of a gateway plan ID.
+func (c Catalog) ByGatewayID(gatewayID string) (Plan, bool) {
+	for _, plan := range c {
+		if plan.GatewayID == gatewayID {
+			return plan, true
+		}
+	}
+	return Plan{}, false
+}
+
+// Proration is the cost of changing plans during a period.
+type Proration struct {
+	FromPlanID string `json:"from_plan_id"`
+	ToPlanID   string `json:"to_plan_id"`
+	// Credit is the unused part of the current period on the old plan.
+	Credit int64 `json:"credit"`
+	// Charge is the price of the new plan until PeriodEnd.
+	Charge int64 `json:"charge"`
+	// Net is Charge less Credit; negative amounts are owed to the customer.
+	Net         int64     `json:"net"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// Scheduled marks changes deferred to EffectiveAt, the end of the period, which cost
+	// nothing.
+	Scheduled   bool      `json:"scheduled,omitempty"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// Prorate prices changing from one plan to another at at, during the period [start, end).
+// Plans billed on the same cycle keep the period and charge the new plan for what remains of
+// it; otherwise a new period of the new plan starts at at and is charged in full. The credit
+// is the unused time of the old plan either way, rounded to the minor unit.
+func Prorate(from, to Plan, start, end, at time.Time) Proration {
+	p := Proration{FromPlanID: from.ID, ToPlanID: to.ID, PeriodStart: start, PeriodEnd: end, EffectiveAt: at}
+	period := int64(end.Sub(start) / time.Second)
+	remaining := int64(end.Sub(at) / time.Second)
+	if period > 0 && remaining > 0 {
+		p.Credit = roundDiv(from.Amount*min(remaining, period), period)
+	}
+	if from.sameCycle(to) && period > 0 {
+		p.Charge = roundDiv(to.Amount*max(min(remaining, period), 0), period)
+	} else {
+		p.Charge = to.Amount
+		p.PeriodStart, p.PeriodEnd = at, to.Next(at)
+	}
+	p.Net = p.Charge - p.Credit
+	return p
+}
+
+// roundDiv divides a by b, rounding half up.
+func roundDiv(a, b int64) int64 {
+	return (2*a + b) / (2 * b)
+}
@@ -0,0 +1,67 @@
+package subscription
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/payment/razorpay"
+	"github.com/abhissng/neuron/utils/helpers"
+)
+
+// razorpayEvents maps the subscription webhooks of Razorpay to events.
+var razorpayEvents = map[string]EventType{
+	"subscription.authenticated": EventAuthorized,
+	"subscription.activated":     EventActivated,
+	"subscription.charged":       EventRenewed,
+	"subscription.pending":       EventPaymentFailed,
+	"subscription.halted":        EventDunningExhausted,
+	"subscription.updated":       EventPlanChanged,
+	"subscription.paused":        EventPaused,
+	"subscription.resumed":       EventResumed,
+	"subscription.cancelled":     EventCancelled,
+	"subscription.completed":     EventCompleted,
+}
+
+// razorpaySubscription is the part of the subscription entity of a webhook the events need.
+type razorpaySubscription struct {
+	ID           string `json:"id"`
+	PlanID       string `json:"plan_id"`
+	CurrentStart int64  `json:"current_start"`
+	CurrentEnd   int64  `json:"current_end"`
+}
+
+// RazorpayEvent translates a Razorpay subscription webhook into an Event, returning the
+// Razorpay ID of the subscription it is about. eventID is the X-Razorpay-Event-Id header of
+// the delivery, which makes redeliveries no-ops. Other webhooks fail with ErrIgnoredEvent.
+// The signature of the webhook must have been verified, e.g. with VerifyWebhookSignature.
+func RazorpayEvent(webhook *razorpay.WebhookEvent, eventID string) (string, Event, error) {
+	eventType, ok := razorpayEvents[webhook.Event]
+	if !ok {
+		return "", Event{}, fmt.Errorf("%w: %s", ErrIgnoredEvent, webhook.Event)
+	}
+	payload, _ := webhook.Payload["subscription"].(map[string]any)
+	entity, _ := payload["entity"].(map[string]any)
+	sub, err := helpers.MapToStruct[razorpaySubscription](entity)
+	if err != nil {
+		return "", Event{}, fmt.Errorf("subscription: decoding the %s webhook: %w", webhook.Event, err)
+	}
+	if sub.ID == "" {
+		return "", Event{}, fmt.Errorf("subscription: the %s webhook has no subscription", webhook.Event)
+	}
+
+	event := Event{ID: eventID, Type: eventType, At: unixTime(webhook.CreatedAt)}
+	switch eventType {
+	case EventActivated, EventRenewed:
+		event.PeriodStart, event.PeriodEnd = unixTime(sub.CurrentStart), unixTime(sub.CurrentEnd)
+	case EventPlanChanged:
+		event.GatewayPlanID = sub.PlanID
+	}
+	return sub.ID, event, nil
+}
+
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
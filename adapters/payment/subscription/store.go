@@ -0,0 +1,109 @@
+package subscription
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UpdateFunc mutates a subscription.
+type UpdateFunc func(sub *Subscription) error
+
+// Store persists subscriptions. Update must be atomic per subscription: a SQL store runs it in
+// a transaction locking the subscription row, so a webhook and a tick cannot both move it.
+type Store interface {
+	// Create stores a new subscription, or fails with ErrSubscriptionExists when its ID or
+	// gateway ID is taken.
+	Create(ctx context.Context, sub *Subscription) error
+	// Get returns a subscription, or ErrSubscriptionNotFound.
+	Get(ctx context.Context, id string) (*Subscription, error)
+	// FindByGatewayID returns the subscription of a gateway ID, or ErrSubscriptionNotFound.
+	FindByGatewayID(ctx context.Context, gatewayID string) (*Subscription, error)
+	// Update applies fn to a subscription and, when it succeeds, saves it with its version
+	// incremented.
+	Update(ctx context.Context, id string, fn UpdateFunc) (*Subscription, error)
+	// Due returns up to limit subscriptions whose DueAt is set and not after now, earliest
+	// first.
+	Due(ctx context.Context, now time.Time, limit int) ([]Subscription, error)
+}
+
+// MemoryStore is a Store for a single replica, e.g. in tests.
+type MemoryStore struct {
+	mu        sync.Mutex
+	subs      map[string]*Subscription
+	byGateway map[string]string
+}
+
+// NewMemoryStore creates a MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{subs: make(map[string]*Subscription), byGateway: make(map[string]string)}
+}
+
+func (s *MemoryStore) Create(_ context.Context, sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub.ID]; ok {
+		return ErrSubscriptionExists
+	}
+	if _, ok := s.byGateway[sub.GatewayID]; ok && sub.GatewayID != "" {
+		return ErrSubscriptionExists
+	}
+	s.subs[sub.ID] = sub.clone()
+	if sub.GatewayID != "" {
+		s.byGateway[sub.GatewayID] = sub.ID
+	}
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	return sub.clone(), nil
+}
+
+func (s *MemoryStore) FindByGatewayID(ctx context.Context, gatewayID string) (*Subscription, error) {
+	s.mu.Lock()
+	id, ok := s.byGateway[gatewayID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *MemoryStore) Update(_ context.Context, id string, fn UpdateFunc) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	sub := stored.clone()
+	if err := fn(sub); err != nil {
+		return nil, err
+	}
+	sub.Version++
+	s.subs[id] = sub
+	return sub.clone(), nil
+}
+
+func (s *MemoryStore) Due(_ context.Context, now time.Time, limit int) ([]Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Subscription
+	for _, sub := range s.subs {
+		if !sub.DueAt.IsZero() && !sub.DueAt.After(now) {
+			due = append(due, *sub.clone())
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].DueAt.Before(due[j].DueAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
@@ -0,0 +1,260 @@
+// Package subscription keeps a local model of subscriptions so that entitlements, trials,
+// dunning and plan changes are decided by the business rather than by the states of a payment
+// gateway. Every change goes through one state machine: gateway webhooks are translated into
+// Events, e.g. with RazorpayEvent, and the deadlines of trials, grace periods, dunning retries
+// and scheduled plan changes fire the same Events when the Manager ticks.
+//
+//	manager := subscription.NewManager(store, catalog, subscription.WithBroker(broker, ""))
+//	sub, err := manager.Create(ctx, subscription.CreateRequest{CustomerID: userID, PlanID: "pro", GatewayID: rzpSubID})
+//
+//	gatewayID, event, err := subscription.RazorpayEvent(webhook, c.GetHeader("X-Razorpay-Event-Id"))
+//	sub, err = manager.ApplyGateway(ctx, gatewayID, event)
+//
+//	// in a scheduled job
+//	n, err := manager.Tick(ctx, 100)
+//
+// The Manager only records decisions: charging prorations, retrying payments on retry_due
+// and mirroring plan changes and cancellations on the gateway are left to the subscribers of
+// its events.
+package subscription
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// These are the errors of the Manager.
+var (
+	ErrSubscriptionNotFound = errors.New("subscription: subscription not found")
+	ErrSubscriptionExists   = errors.New("subscription: subscription already exists")
+	ErrPlanNotFound         = errors.New("subscription: plan not found")
+	ErrSamePlan             = errors.New("subscription: already on the plan")
+	ErrInvalidTransition    = errors.New("subscription: invalid transition")
+	// ErrIgnoredEvent is returned for gateway webhooks that do not change subscriptions.
+	ErrIgnoredEvent = errors.New("subscription: ignored event")
+)
+
+// Status is the local state of a subscription.
+type Status string
+
+const (
+	// StatusPending waits for the first payment.
+	StatusPending Status = "pending"
+	// StatusTrialing is a free trial; it needs no payment until the trial ends.
+	StatusTrialing Status = "trialing"
+	StatusActive   Status = "active"
+	// StatusPastDue failed to renew and is being dunned; it stays entitled during the grace
+	// period.
+	StatusPastDue Status = "past_due"
+	// StatusSuspended exhausted its grace period or dunning retries; a successful payment
+	// reactivates it.
+	StatusSuspended Status = "suspended"
+	StatusPaused    Status = "paused"
+	StatusCancelled Status = "cancelled"
+	// StatusExpired ran its full term.
+	StatusExpired Status = "expired"
+)
+
+// Entitled reports whether a subscription in s grants access to its plan.
+func (s Status) Entitled() bool {
+	return s == StatusTrialing || s == StatusActive || s == StatusPastDue
+}
+
+// Terminal reports whether s is final.
+func (s Status) Terminal() bool {
+	return s == StatusCancelled || s == StatusExpired
+}
+
+// EventType is what happened to a subscription.
+type EventType string
+
+const (
+	// EventCreated is published for new subscriptions; it is not applied.
+	EventCreated EventType = "created"
+	// EventAuthorized records the payment mandate of the customer, e.g. a card or UPI
+	// autopay.
+	EventAuthorized EventType = "authorized"
+	// EventActivated is the first successful payment.
+	EventActivated EventType = "activated"
+	// EventRenewed is a successful payment of a period.
+	EventRenewed       EventType = "renewed"
+	EventPaymentFailed EventType = "payment_failed"
+	// EventTrialEnded fires when a trial ends before the subscription is activated.
+	EventTrialEnded EventType = "trial_ended"
+	// EventRetryDue fires when a dunning retry is due.
+	EventRetryDue         EventType = "retry_due"
+	EventGraceExpired     EventType = "grace_expired"
+	EventDunningExhausted EventType = "dunning_exhausted"
+	EventPlanChanged      EventType = "plan_changed"
+	EventPaused           EventType = "paused"
+	EventResumed          EventType = "resumed"
+	EventCancelled        EventType = "cancelled"
+	// EventCompleted is the end of the full term of a subscription.
+	EventCompleted EventType = "completed"
+)
+
+// lifecycle is the state machine of subscriptions: the status an event moves each status to.
+// Events missing from the status of a subscription fail with ErrInvalidTransition.
+var lifecycle = map[Status]map[EventType]Status{
+	StatusPending: {
+		EventAuthorized:  StatusPending,
+		EventActivated:   StatusActive,
+		EventRenewed:     StatusActive,
+		EventPlanChanged: StatusPending,
+		EventCancelled:   StatusCancelled,
+	},
+	StatusTrialing: {
+		EventAuthorized:    StatusTrialing,
+		EventActivated:     StatusActive,
+		EventRenewed:       StatusActive,
+		EventPaymentFailed: StatusPastDue,
+		EventTrialEnded:    StatusPastDue,
+		EventPlanChanged:   StatusTrialing,
+		EventPaused:        StatusPaused,
+		EventCancelled:     StatusCancelled,
+	},
+	StatusActive: {
+		EventActivated:        StatusActive,
+		EventRenewed:          StatusActive,
+		EventPaymentFailed:    StatusPastDue,
+		EventDunningExhausted: StatusSuspended,
+		EventPlanChanged:      StatusActive,
+		EventPaused:           StatusPaused,
+		EventCancelled:        StatusCancelled,
+		EventCompleted:        StatusExpired,
+	},
+	StatusPastDue: {
+		EventActivated:        StatusActive,
+		EventRenewed:          StatusActive,
+		EventPaymentFailed:    StatusPastDue,
+		EventRetryDue:         StatusPastDue,
+		EventGraceExpired:     StatusSuspended,
+		EventDunningExhausted: StatusSuspended,
+		EventPlanChanged:      StatusPastDue,
+		EventCancelled:        StatusCancelled,
+	},
+	StatusSuspended: {
+		EventActivated:        StatusActive,
+		EventRenewed:          StatusActive,
+		EventPaymentFailed:    StatusSuspended,
+		EventDunningExhausted: StatusSuspended,
+		EventPlanChanged:      StatusSuspended,
+		EventCancelled:        StatusCancelled,
+	},
+	StatusPaused: {
+		EventResumed:     StatusActive,
+		EventPlanChanged: StatusPaused,
+		EventCancelled:   StatusCancelled,
+	},
+	StatusCancelled: {},
+	StatusExpired:   {},
+}
+
+// Can reports whether event applies to a subscription in status from.
+func Can(from Status, event EventType) bool {
+	_, ok := lifecycle[from][event]
+	return ok
+}
+
+// Event is applied to a subscription by the state machine.
+type Event struct {
+	// ID deduplicates the event, e.g. the ID of a webhook delivery; redelivered events are
+	// no-ops.
+	ID   string    `json:"id,omitempty"`
+	Type EventType `json:"type"`
+	// At is when the event happened. Defaults to now.
+	At time.Time `json:"at,omitempty"`
+	// PeriodStart and PeriodEnd are the billing period set by EventActivated, EventRenewed
+	// and EventPlanChanged. When missing, renewals start the period following the current one.
+	PeriodStart time.Time `json:"period_start,omitempty"`
+	PeriodEnd   time.Time `json:"period_end,omitempty"`
+	// PlanID is the plan of EventPlanChanged; GatewayPlanID names it by its ID on the gateway
+	// instead.
+	PlanID        string `json:"plan_id,omitempty"`
+	GatewayPlanID string `json:"gateway_plan_id,omitempty"`
+}
+
+// ScheduledChange is a plan change waiting for the end of the period.
+type ScheduledChange struct {
+	PlanID      string    `json:"plan_id"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// Subscription is the local state of a subscription.
+type Subscription struct {
+	ID         string `json:"id"`
+	CustomerID string `json:"customer_id"`
+	PlanID     string `json:"plan_id"`
+	// GatewayID is the ID of the subscription on the payment gateway.
+	GatewayID string `json:"gateway_id,omitempty"`
+	Status    Status `json:"status"`
+	// CurrentPeriodStart and CurrentPeriodEnd bound the paid period, or the trial.
+	CurrentPeriodStart time.Time `json:"current_period_start,omitempty"`
+	CurrentPeriodEnd   time.Time `json:"current_period_end,omitempty"`
+	TrialEndsAt        time.Time `json:"trial_ends_at,omitempty"`
+	AuthorizedAt       time.Time `json:"authorized_at,omitempty"`
+	// PastDueSince, GraceEndsAt, FailedAttempts and NextRetryAt track the dunning of a
+	// subscription; they are cleared by the next successful payment.
+	PastDueSince   time.Time `json:"past_due_since,omitempty"`
+	GraceEndsAt    time.Time `json:"grace_ends_at,omitempty"`
+	FailedAttempts int       `json:"failed_attempts,omitempty"`
+	NextRetryAt    time.Time `json:"next_retry_at,omitempty"`
+	SuspendedAt    time.Time `json:"suspended_at,omitempty"`
+	PausedAt       time.Time `json:"paused_at,omitempty"`
+	// CancelAtPeriodEnd cancels the subscription when its current period ends.
+	CancelAtPeriodEnd bool             `json:"cancel_at_period_end,omitempty"`
+	ScheduledChange   *ScheduledChange `json:"scheduled_change,omitempty"`
+	CancelledAt       time.Time        `json:"cancelled_at,omitempty"`
+	EndedAt           time.Time        `json:"ended_at,omitempty"`
+	// DueAt is the next deadline of the subscription, zero when it has none. Stores index
+	// it to find the subscriptions Tick has to advance.
+	DueAt time.Time `json:"due_at,omitempty"`
+	// Events are the IDs of the latest events applied, deduplicating redeliveries.
+	Events    []string  `json:"events,omitempty"`
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// rememberedEvents bounds Subscription.Events.
+const rememberedEvents = 32
+
+func (s *Subscription) seen(eventID string) bool {
+	return eventID != "" && slices.Contains(s.Events, eventID)
+}
+
+func (s *Subscription) remember(eventID string) {
+	if eventID == "" {
+		return
+	}
+	s.Events = append(s.Events, eventID)
+	if len(s.Events) > rememberedEvents {
+		s.Events = slices.Clone(s.Events[len(s.Events)-rememberedEvents:])
+	}
+}
+
+func (s *Subscription) clone() *Subscription {
+	c := *s
+	c.Events = slices.Clone(s.Events)
+	if s.ScheduledChange != nil {
+		change := *s.ScheduledChange
+		c.ScheduledChange = &change
+	}
+	return &c
+}
+
+// Transition is a change of a subscription made by an event.
+type Transition struct {
+	From    Status    `json:"from"`
+	To      Status    `json:"to"`
+	Event   EventType `json:"event"`
+	EventID string    `json:"event_id,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Change is published on the broker for every transition.
+type Change struct {
+	Subscription Subscription `json:"subscription"`
+	Transition   Transition   `json:"transition"`
+}
@@ -0,0 +1,414 @@
+// Package promotions evaluates and redeems coupons at checkout. A coupon takes a percentage or
+// a fixed amount off an order within its validity window, subject to a minimum order, usage
+// limits overall, per user and per organisation, and stacking rules deciding which coupons may
+// be combined.
+//
+//	engine := promotions.NewEngine(store)
+//	evaluation, err := engine.Evaluate(ctx, promotions.Order{UserID: userID, Currency: "INR", Subtotal: 125000, Codes: codes})
+//	// show evaluation.Total and evaluation.Rejected to the user, then on payment:
+//	redemption, err := engine.Redeem(ctx, order, orderID, idempotencyKey)
+package promotions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/clock"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// These are the errors of the Engine and the stores.
+var (
+	ErrCouponNotFound     = errors.New("promotions: coupon not found")
+	ErrCouponExists       = errors.New("promotions: coupon already exists")
+	ErrInvalidCoupon      = errors.New("promotions: invalid coupon")
+	ErrRejected           = errors.New("promotions: coupon rejected")
+	ErrRedemptionNotFound = errors.New("promotions: redemption not found")
+	// ErrIdempotencyConflict is returned when an idempotency key is reused for another order.
+	ErrIdempotencyConflict = errors.New("promotions: idempotency key used for another order")
+)
+
+// DiscountType is how a coupon computes its discount.
+type DiscountType string
+
+const (
+	// DiscountPercentage takes Percent of the order, up to MaxDiscount.
+	DiscountPercentage DiscountType = "percentage"
+	// DiscountFixed takes Amount off the order.
+	DiscountFixed DiscountType = "fixed"
+)
+
+// Coupon is a discount redeemable with a code. Amounts are in minor units, e.g. paise.
+type Coupon struct {
+	Code        string       `json:"code"`
+	Description string       `json:"description,omitempty"`
+	Type        DiscountType `json:"type"`
+	// Percent is the discount of a percentage coupon, from 0 to 100.
+	Percent float64 `json:"percent,omitempty"`
+	// Amount is the discount of a fixed coupon.
+	Amount int64 `json:"amount,omitempty"`
+	// MaxDiscount caps the discount of a percentage coupon; zero is uncapped.
+	MaxDiscount int64 `json:"max_discount,omitempty"`
+	// MinSubtotal is the smallest order the coupon applies to.
+	MinSubtotal int64 `json:"min_subtotal,omitempty"`
+	// Currency restricts the coupon to orders in a currency; required for fixed coupons.
+	Currency string `json:"currency,omitempty"`
+	// StartsAt and EndsAt bound the validity window; zero bounds are open.
+	StartsAt time.Time `json:"starts_at,omitempty"`
+	EndsAt   time.Time `json:"ends_at,omitempty"`
+	// UsageLimit, PerUserLimit and PerOrgLimit bound the redemptions overall, per user and per
+	// organisation; zero is unlimited.
+	UsageLimit   int `json:"usage_limit,omitempty"`
+	PerUserLimit int `json:"per_user_limit,omitempty"`
+	PerOrgLimit  int `json:"per_org_limit,omitempty"`
+	// Stackable coupons combine with other stackable coupons; a coupon that is not stackable
+	// applies alone.
+	Stackable bool `json:"stackable"`
+	// StackGroup keeps stackable coupons of the same group from combining, e.g. two welcome
+	// offers.
+	StackGroup string `json:"stack_group,omitempty"`
+	// Priority orders the coupons of an order, highest first; each discount applies to what
+	// the previous ones left.
+	Priority int  `json:"priority,omitempty"`
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Validate checks the definition of c.
+func (c *Coupon) Validate() error {
+	switch {
+	case c.Code == "":
+		return fmt.Errorf("%w: code is required", ErrInvalidCoupon)
+	case c.Type == DiscountPercentage && (c.Percent <= 0 || c.Percent > 100):
+		return fmt.Errorf("%w: percent must be in (0, 100]", ErrInvalidCoupon)
+	case c.Type == DiscountFixed && c.Amount <= 0:
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidCoupon)
+	case c.Type == DiscountFixed && c.Currency == "":
+		return fmt.Errorf("%w: fixed coupons require a currency", ErrInvalidCoupon)
+	case c.Type != DiscountPercentage && c.Type != DiscountFixed:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidCoupon, c.Type)
+	case !c.StartsAt.IsZero() && !c.EndsAt.IsZero() && !c.EndsAt.After(c.StartsAt):
+		return fmt.Errorf("%w: ends before it starts", ErrInvalidCoupon)
+	}
+	return nil
+}
+
+// RejectReason explains why a coupon does not apply to an order.
+type RejectReason string
+
+const (
+	RejectNotFound      RejectReason = "not_found"
+	RejectDisabled      RejectReason = "disabled"
+	RejectNotStarted    RejectReason = "not_started"
+	RejectExpired       RejectReason = "expired"
+	RejectCurrency      RejectReason = "currency_mismatch"
+	RejectMinSubtotal   RejectReason = "below_min_subtotal"
+	RejectUsageLimit    RejectReason = "usage_limit_reached"
+	RejectUserLimit     RejectReason = "user_limit_reached"
+	RejectOrgLimit      RejectReason = "org_limit_reached"
+	RejectNotStackable  RejectReason = "not_stackable"
+	RejectStackGroup    RejectReason = "stack_group_taken"
+	RejectDuplicate     RejectReason = "duplicate"
+	RejectNoDiscountDue RejectReason = "no_discount"
+)
+
+// Order is the checkout an evaluation applies coupons to.
+type Order struct {
+	UserID   string   `json:"user_id"`
+	OrgID    string   `json:"org_id,omitempty"`
+	Currency string   `json:"currency"`
+	Subtotal int64    `json:"subtotal"`
+	Codes    []string `json:"codes"`
+}
+
+// AppliedCoupon is a coupon applied to an order.
+type AppliedCoupon struct {
+	Code     string `json:"code"`
+	Discount int64  `json:"discount"`
+}
+
+// Rejection is a coupon not applied to an order.
+type Rejection struct {
+	Code   string       `json:"code"`
+	Reason RejectReason `json:"reason"`
+}
+
+// Evaluation is the outcome of applying the coupons of an order.
+type Evaluation struct {
+	Subtotal int64           `json:"subtotal"`
+	Discount int64           `json:"discount"`
+	Total    int64           `json:"total"`
+	Applied  []AppliedCoupon `json:"applied"`
+	Rejected []Rejection     `json:"rejected,omitempty"`
+}
+
+// Engine evaluates and redeems the coupons of a Store.
+type Engine struct {
+	store  Store
+	logger *log.Log
+	clock  clock.Clock
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(e *Engine) {
+		e.logger = logger
+	}
+}
+
+// WithClock sets the clock deciding validity windows, e.g. in tests. Defaults to clock.System.
+func WithClock(c clock.Clock) Option {
+	return func(e *Engine) {
+		e.clock = clock.OrSystem(c)
+	}
+}
+
+// NewEngine creates an Engine using the coupons of store.
+func NewEngine(store Store, options ...Option) *Engine {
+	e := &Engine{store: store, clock: clock.System}
+	for _, opt := range options {
+		opt(e)
+	}
+	if e.logger == nil {
+		e.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return e
+}
+
+// NormalizeCode upper-cases code and trims its spaces.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Evaluate applies the coupons of order, without redeeming them. Coupons that do not apply are
+// listed in Rejected; an error means the store failed.
+func (e *Engine) Evaluate(ctx context.Context, order Order) (*Evaluation, error) {
+	evaluation := &Evaluation{Subtotal: order.Subtotal, Total: order.Subtotal}
+	var candidates []*Coupon
+	seen := make(map[string]bool)
+	for _, raw := range order.Codes {
+		code := NormalizeCode(raw)
+		if seen[code] {
+			evaluation.Rejected = append(evaluation.Rejected, Rejection{Code: code, Reason: RejectDuplicate})
+			continue
+		}
+		seen[code] = true
+		coupon, err := e.store.Coupon(ctx, code)
+		if errors.Is(err, ErrCouponNotFound) {
+			evaluation.Rejected = append(evaluation.Rejected, Rejection{Code: code, Reason: RejectNotFound})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		reason, err := e.check(ctx, coupon, order)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			evaluation.Rejected = append(evaluation.Rejected, Rejection{Code: code, Reason: reason})
+			continue
+		}
+		candidates = append(candidates, coupon)
+	}
+
+	slices.SortStableFunc(candidates, func(a, b *Coupon) int {
+		return b.Priority - a.Priority
+	})
+	groups := make(map[string]bool)
+	for _, coupon := range candidates {
+		if reason := stackReason(coupon, evaluation.Applied, groups); reason != "" {
+			evaluation.Rejected = append(evaluation.Rejected, Rejection{Code: coupon.Code, Reason: reason})
+			continue
+		}
+		discount := discountOf(coupon, evaluation.Total)
+		if discount <= 0 {
+			evaluation.Rejected = append(evaluation.Rejected, Rejection{Code: coupon.Code, Reason: RejectNoDiscountDue})
+			continue
+		}
+		if coupon.StackGroup != "" {
+			groups[coupon.StackGroup] = true
+		}
+		evaluation.Applied = append(evaluation.Applied, AppliedCoupon{Code: coupon.Code, Discount: discount})
+		evaluation.Discount += discount
+		evaluation.Total -= discount
+		if !coupon.Stackable {
+			groups[nonStackable] = true
+		}
+	}
+	return evaluation, nil
+}
+
+// nonStackable marks, in the groups of an evaluation, that a coupon that is not stackable
+// was applied.
+const nonStackable = "\x00"
+
+// stackReason returns why coupon cannot join the applied coupons, or "".
+func stackReason(coupon *Coupon, applied []AppliedCoupon, groups map[string]bool) RejectReason {
+	if len(applied) == 0 {
+		return ""
+	}
+	if groups[nonStackable] || !coupon.Stackable {
+		return RejectNotStackable
+	}
+	if coupon.StackGroup != "" && groups[coupon.StackGroup] {
+		return RejectStackGroup
+	}
+	return ""
+}
+
+// check returns why coupon does not apply to order, or "".
+func (e *Engine) check(ctx context.Context, coupon *Coupon, order Order) (RejectReason, error) {
+	now := e.clock.Now()
+	switch {
+	case coupon.Disabled:
+		return RejectDisabled, nil
+	case !coupon.StartsAt.IsZero() && now.Before(coupon.StartsAt):
+		return RejectNotStarted, nil
+	case !coupon.EndsAt.IsZero() && !now.Before(coupon.EndsAt):
+		return RejectExpired, nil
+	case coupon.Currency != "" && !strings.EqualFold(coupon.Currency, order.Currency):
+		return RejectCurrency, nil
+	case order.Subtotal < coupon.MinSubtotal:
+		return RejectMinSubtotal, nil
+	}
+	if coupon.UsageLimit == 0 && coupon.PerUserLimit == 0 && coupon.PerOrgLimit == 0 {
+		return "", nil
+	}
+	usage, err := e.store.Usage(ctx, coupon.Code, order.UserID, order.OrgID)
+	if err != nil {
+		return "", err
+	}
+	return usage.exceeded(coupon, order), nil
+}
+
+// discountOf returns the discount of coupon on the remaining amount of an order.
+func discountOf(coupon *Coupon, remaining int64) int64 {
+	var discount int64
+	switch coupon.Type {
+	case DiscountPercentage:
+		discount = int64(math.Floor(float64(remaining) * coupon.Percent / 100))
+		if coupon.MaxDiscount > 0 {
+			discount = min(discount, coupon.MaxDiscount)
+		}
+	case DiscountFixed:
+		discount = coupon.Amount
+	}
+	return min(discount, remaining)
+}
+
+// Usage counts the redemptions of a coupon.
+type Usage struct {
+	Total int `json:"total"`
+	User  int `json:"user"`
+	Org   int `json:"org"`
+}
+
+// exceeded returns the limit of coupon that one more redemption would exceed, or "".
+func (u Usage) exceeded(coupon *Coupon, order Order) RejectReason {
+	switch {
+	case coupon.UsageLimit > 0 && u.Total >= coupon.UsageLimit:
+		return RejectUsageLimit
+	case coupon.PerUserLimit > 0 && order.UserID != "" && u.User >= coupon.PerUserLimit:
+		return RejectUserLimit
+	case coupon.PerOrgLimit > 0 && order.OrgID != "" && u.Org >= coupon.PerOrgLimit:
+		return RejectOrgLimit
+	}
+	return ""
+}
+
+// RedemptionStatus is the state of a redemption.
+type RedemptionStatus string
+
+const (
+	RedemptionRedeemed RedemptionStatus = "redeemed"
+	// RedemptionCancelled redemptions no longer count towards the usage limits.
+	RedemptionCancelled RedemptionStatus = "cancelled"
+)
+
+// Redemption records the coupons redeemed by an order.
+type Redemption struct {
+	ID             string           `json:"id"`
+	IdempotencyKey string           `json:"idempotency_key"`
+	OrderID        string           `json:"order_id"`
+	UserID         string           `json:"user_id"`
+	OrgID          string           `json:"org_id,omitempty"`
+	Currency       string           `json:"currency"`
+	Evaluation     Evaluation       `json:"evaluation"`
+	Status         RedemptionStatus `json:"status"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// Redeem evaluates order and records the redemption of its applied coupons, counting them
+// towards their usage limits. The store checks the limits again atomically, so concurrent
+// checkouts cannot exceed them. Retrying with the same idempotency key returns the first
+// redemption. An order whose coupons were all rejected fails with ErrRejected.
+func (e *Engine) Redeem(ctx context.Context, order Order, orderID, idempotencyKey string) (*Redemption, error) {
+	if idempotencyKey == "" {
+		idempotencyKey = orderID
+	}
+	if existing, err := e.store.Redemption(ctx, idempotencyKey); err == nil {
+		if existing.OrderID != orderID {
+			return nil, ErrIdempotencyConflict
+		}
+		return existing, nil
+	} else if !errors.Is(err, ErrRedemptionNotFound) {
+		return nil, err
+	}
+
+	evaluation, err := e.Evaluate(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if len(evaluation.Applied) == 0 && len(evaluation.Rejected) > 0 {
+		rejection := evaluation.Rejected[0]
+		return nil, fmt.Errorf("%w: %s: %s", ErrRejected, rejection.Code, rejection.Reason)
+	}
+	now := e.clock.Now()
+	redemption := &Redemption{
+		ID:             random.GenerateUUIDString(),
+		IdempotencyKey: idempotencyKey,
+		OrderID:        orderID,
+		UserID:         order.UserID,
+		OrgID:          order.OrgID,
+		Currency:       order.Currency,
+		Evaluation:     *evaluation,
+		Status:         RedemptionRedeemed,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	limits := make(map[string]*Coupon, len(evaluation.Applied))
+	for _, applied := range evaluation.Applied {
+		coupon, err := e.store.Coupon(ctx, applied.Code)
+		if err != nil {
+			return nil, err
+		}
+		limits[applied.Code] = coupon
+	}
+	stored, err := e.store.Redeem(ctx, redemption, limits)
+	if err != nil {
+		return nil, err
+	}
+	e.logger.Info("Coupons redeemed",
+		log.String("redemption_id", stored.ID),
+		log.String("order_id", orderID),
+		log.Int64("discount", stored.Evaluation.Discount),
+	)
+	return stored, nil
+}
+
+// Cancel cancels the redemption of idempotencyKey, e.g. when the order is refunded, so its
+// coupons no longer count towards their usage limits.
+func (e *Engine) Cancel(ctx context.Context, idempotencyKey string) (*Redemption, error) {
+	return e.store.Cancel(ctx, idempotencyKey, e.clock.Now())
+}
@@ -0,0 +1,136 @@
+package promotions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store keeps the coupons and their redemptions. Redeem must check the limits and record the
+// redemption atomically: a SQL store runs it in a transaction locking the coupon rows.
+type Store interface {
+	// Create stores a new coupon, or fails with ErrCouponExists.
+	Create(ctx context.Context, coupon *Coupon) error
+	// Coupon returns the coupon of code, or ErrCouponNotFound.
+	Coupon(ctx context.Context, code string) (*Coupon, error)
+	// Usage counts the redemptions of code overall, by user and by org.
+	Usage(ctx context.Context, code, userID, orgID string) (Usage, error)
+	// Redeem records redemption unless its idempotency key is known, in which case the stored
+	// redemption is returned. It fails with ErrRejected when a coupon of coupons, keyed by
+	// code, reached a usage limit.
+	Redeem(ctx context.Context, redemption *Redemption, coupons map[string]*Coupon) (*Redemption, error)
+	// Redemption returns the redemption of idempotencyKey, or ErrRedemptionNotFound.
+	Redemption(ctx context.Context, idempotencyKey string) (*Redemption, error)
+	// Cancel marks the redemption of idempotencyKey cancelled at now.
+	Cancel(ctx context.Context, idempotencyKey string, now time.Time) (*Redemption, error)
+}
+
+// MemoryStore is a Store for a single replica, e.g. in tests.
+type MemoryStore struct {
+	mu          sync.Mutex
+	coupons     map[string]Coupon
+	redemptions map[string]*Redemption
+}
+
+// NewMemoryStore creates a MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{coupons: make(map[string]Coupon), redemptions: make(map[string]*Redemption)}
+}
+
+func (s *MemoryStore) Create(_ context.Context, coupon *Coupon) error {
+	coupon.Code = NormalizeCode(coupon.Code)
+	if err := coupon.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.coupons[coupon.Code]; ok {
+		return ErrCouponExists
+	}
+	s.coupons[coupon.Code] = *coupon
+	return nil
+}
+
+func (s *MemoryStore) Coupon(_ context.Context, code string) (*Coupon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	coupon, ok := s.coupons[NormalizeCode(code)]
+	if !ok {
+		return nil, ErrCouponNotFound
+	}
+	return &coupon, nil
+}
+
+func (s *MemoryStore) Usage(_ context.Context, code, userID, orgID string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage(code, userID, orgID), nil
+}
+
+// usage counts the redeemed redemptions of code.
+func (s *MemoryStore) usage(code, userID, orgID string) Usage {
+	var usage Usage
+	for _, redemption := range s.redemptions {
+		if redemption.Status != RedemptionRedeemed {
+			continue
+		}
+		for _, applied := range redemption.Evaluation.Applied {
+			if applied.Code != code {
+				continue
+			}
+			usage.Total++
+			if userID != "" && redemption.UserID == userID {
+				usage.User++
+			}
+			if orgID != "" && redemption.OrgID == orgID {
+				usage.Org++
+			}
+		}
+	}
+	return usage
+}
+
+func (s *MemoryStore) Redeem(_ context.Context, redemption *Redemption, coupons map[string]*Coupon) (*Redemption, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.redemptions[redemption.IdempotencyKey]; ok {
+		stored := *existing
+		return &stored, nil
+	}
+	order := Order{UserID: redemption.UserID, OrgID: redemption.OrgID}
+	for code, coupon := range coupons {
+		if reason := s.usage(code, redemption.UserID, redemption.OrgID).exceeded(coupon, order); reason != "" {
+			return nil, fmt.Errorf("%w: %s: %s", ErrRejected, code, reason)
+		}
+	}
+	stored := *redemption
+	s.redemptions[redemption.IdempotencyKey] = &stored
+	result := stored
+	return &result, nil
+}
+
+func (s *MemoryStore) Redemption(_ context.Context, idempotencyKey string) (*Redemption, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	redemption, ok := s.redemptions[idempotencyKey]
+	if !ok {
+		return nil, ErrRedemptionNotFound
+	}
+	stored := *redemption
+	return &stored, nil
+}
+
+func (s *MemoryStore) Cancel(_ context.Context, idempotencyKey string, now time.Time) (*Redemption, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	redemption, ok := s.redemptions[idempotencyKey]
+	if !ok {
+		return nil, ErrRedemptionNotFound
+	}
+	if redemption.Status != RedemptionCancelled {
+		redemption.Status, redemption.UpdatedAt = RedemptionCancelled, now
+	}
+	stored := *redemption
+	return &stored, nil
+}
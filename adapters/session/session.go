@@ -4,17 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"net/http"
 	"time"
 
 	"github.com/abhissng/neuron/adapters/redis"
 	"github.com/abhissng/neuron/blame"
 	"github.com/abhissng/neuron/result"
+	"github.com/abhissng/neuron/utils/clock"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/cookie"
 	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
 	"github.com/abhissng/neuron/utils/types"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
 )
 
+// ErrSessionConflict is returned when a session keeps changing while it is being updated.
+var ErrSessionConflict = errors.New("session: too many concurrent updates")
+
 // SessionData represents the data stored in a session
 type SessionData struct {
 	OrgID           types.OrgID    `json:"org_id,omitempty"`
@@ -39,6 +47,8 @@ type SessionManager struct {
 	sessionPrefix           string
 	defaultExpiry           time.Duration
 	sessionMiddlewareOption *SessionMiddlewareOptions
+	cookies                 *cookie.Manager
+	clock                   clock.Clock
 }
 
 // Option is a function that configures the SessionManager
@@ -72,6 +82,21 @@ func WithSessionMiddlewareOption(option *SessionMiddlewareOptions) Option {
 	}
 }
 
+// WithCookieManager encrypts the session id cookie with manager instead of storing the
+// plain session id.
+func WithCookieManager(manager *cookie.Manager) Option {
+	return func(sm *SessionManager) {
+		sm.cookies = manager
+	}
+}
+
+// WithClock sets the clock session access times are taken from. Defaults to clock.System.
+func WithClock(c clock.Clock) Option {
+	return func(sm *SessionManager) {
+		sm.clock = clock.OrSystem(c)
+	}
+}
+
 // NewSessionManager creates a new session manager with the provided options
 func NewSessionManager(opts ...Option) (*SessionManager, error) {
 	sm := &SessionManager{
@@ -79,6 +104,7 @@ func NewSessionManager(opts ...Option) (*SessionManager, error) {
 		sessionPrefix:           "session:",     // Default prefix
 		defaultExpiry:           24 * time.Hour, // Default expiry
 		sessionMiddlewareOption: NewSessionMiddlewareOptions(),
+		clock:                   clock.System,
 	}
 
 	// Apply all the options
@@ -91,8 +117,8 @@ func NewSessionManager(opts ...Option) (*SessionManager, error) {
 
 // CreateSession creates a new session
 func (sm *SessionManager) CreateSession(ctx context.Context, userData SessionData) (string, error) {
-	sessionID := uuid.New().String()
-	userData.LastAccess = time.Now()
+	sessionID := random.GenerateSecureUUIDString()
+	userData.LastAccess = sm.clock.Now()
 
 	data, err := json.Marshal(userData)
 	if err != nil {
@@ -123,7 +149,7 @@ func (sm *SessionManager) GetSession(ctx context.Context, sessionID string) (*Se
 	}
 
 	// Update last access time
-	sessionData.LastAccess = time.Now()
+	sessionData.LastAccess = sm.clock.Now()
 	_ = sm.RefreshSession(ctx, sessionID, sessionData)
 
 	return &sessionData, nil
@@ -166,6 +192,55 @@ func (sm *SessionManager) UpdateSessionData(ctx context.Context, sessionID strin
 	return sm.RefreshSession(ctx, sessionID, *session)
 }
 
+// TakeSessionValue removes key from the custom data of the session and returns its value, or
+// false when the session has none. The removal is atomic, so a concurrent take of the same key
+// gets nothing, e.g. a challenge answered twice.
+func (sm *SessionManager) TakeSessionValue(ctx context.Context, sessionID, key string) (any, bool, error) {
+	redisKey := sm.sessionPrefix + sessionID
+	for attempt := 0; attempt < 5; attempt++ {
+		var (
+			value any
+			found bool
+		)
+		err := sm.store.Client().Watch(ctx, func(tx *goredis.Tx) error {
+			data, err := tx.Get(ctx, redisKey).Bytes()
+			if errors.Is(err, goredis.Nil) {
+				return errors.New("session not found")
+			}
+			if err != nil {
+				return err
+			}
+			var session SessionData
+			if err := json.Unmarshal(data, &session); err != nil {
+				return err
+			}
+			value, found = session.CustomData[key]
+			if !found {
+				return nil
+			}
+			delete(session.CustomData, key)
+			session.LastAccess = sm.clock.Now()
+			updated, err := json.Marshal(session)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+				pipe.Set(ctx, redisKey, updated, sm.defaultExpiry)
+				return nil
+			})
+			return err
+		}, redisKey)
+		if errors.Is(err, goredis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return value, found, nil
+	}
+	return nil, false, ErrSessionConflict
+}
+
 // IsAuthenticated checks if the session exists and is authenticated
 func (sm *SessionManager) IsAuthenticated(ctx context.Context, sessionID string) bool {
 	session, err := sm.GetSession(ctx, sessionID)
@@ -219,3 +294,33 @@ func (s *SessionManager) ValidateSession(
 	// ✅ Valid session
 	return result.NewSuccess(data)
 }
+
+// WriteSessionCookie writes the session id cookie, encrypted when a cookie manager is set.
+func (sm *SessionManager) WriteSessionCookie(w http.ResponseWriter, sessionID string) error {
+	if sm.cookies != nil {
+		return sm.cookies.SetStringWithMaxAge(w, constant.SessionID, sessionID, sm.defaultExpiry)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     constant.SessionID,
+		Value:    sessionID,
+		Path:     "/",
+		MaxAge:   int(sm.defaultExpiry.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// SessionIDFromRequest returns the session id of the request cookie, decrypting it when a
+// cookie manager is set.
+func (sm *SessionManager) SessionIDFromRequest(r *http.Request) (string, error) {
+	if sm.cookies != nil {
+		return sm.cookies.GetString(r, constant.SessionID)
+	}
+	c, err := r.Cookie(constant.SessionID)
+	if err != nil {
+		return "", err
+	}
+	return c.Value, nil
+}
@@ -0,0 +1,64 @@
+package soap
+
+import (
+	"strings"
+
+	"github.com/abhissng/neuron/blame"
+)
+
+// Fault is a SOAP fault returned by a service, in either version.
+type Fault struct {
+	// Code is the fault code, e.g. "soap:Server" or "soap:Receiver".
+	Code   string
+	Reason string
+	// Actor is the faultactor (1.1) or Node (1.2) that raised the fault.
+	Actor string
+	// Detail is the raw XML of the application specific detail.
+	Detail string
+}
+
+// Error returns the code and reason of the fault.
+func (f *Fault) Error() string {
+	return "soap: fault " + f.Code + ": " + f.Reason
+}
+
+// Blame converts the fault into a blame.SOAPFaultError.
+func (f *Fault) Blame() blame.Blame {
+	return blame.SOAPFaultError(f.Code, f.Reason, f.Detail, f)
+}
+
+// rawFault decodes the fault elements of both versions.
+type rawFault struct {
+	// SOAP 1.1
+	FaultCode   string   `xml:"faultcode"`
+	FaultString string   `xml:"faultstring"`
+	FaultActor  string   `xml:"faultactor"`
+	Detail11    innerXML `xml:"detail"`
+	// SOAP 1.2
+	Code     string   `xml:"Code>Value"`
+	Subcode  string   `xml:"Code>Subcode>Value"`
+	Reason   string   `xml:"Reason>Text"`
+	Node     string   `xml:"Node"`
+	Detail12 innerXML `xml:"Detail"`
+}
+
+type innerXML struct {
+	Content string `xml:",innerxml"`
+}
+
+// fault returns the Fault decoded into r.
+func (r *rawFault) fault() *Fault {
+	if r.Code != "" || r.Reason != "" {
+		code := r.Code
+		if r.Subcode != "" {
+			code += "/" + r.Subcode
+		}
+		return &Fault{Code: code, Reason: r.Reason, Actor: r.Node, Detail: strings.TrimSpace(r.Detail12.Content)}
+	}
+	return &Fault{
+		Code:   r.FaultCode,
+		Reason: r.FaultString,
+		Actor:  r.FaultActor,
+		Detail: strings.TrimSpace(r.Detail11.Content),
+	}
+}
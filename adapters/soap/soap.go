@@ -0,0 +1,275 @@
+// Package soap is a minimal SOAP 1.1 and 1.2 client for the bank and partner integrations that
+// still speak SOAP. It wraps request structs in an envelope, optionally signs it with a
+// WS-Security username token, and decodes the response body or its fault.
+//
+// Requests and responses are plain encoding/xml structs naming their element and namespace:
+//
+//	type BalanceRequest struct {
+//		XMLName xml.Name `xml:"urn:bank:accounts GetBalance"`
+//		Account string   `xml:"account"`
+//	}
+//
+//	client := soap.NewClient("https://bank.example.com/accounts",
+//		soap.WithUsernameToken("svc-user", password, true))
+//	var resp BalanceResponse
+//	if err := client.Call(ctx, "urn:bank:accounts/GetBalance", BalanceRequest{Account: id}, &resp); err != nil {
+//		var fault *soap.Fault
+//		if errors.As(err, &fault) {
+//			return fault.Blame()
+//		}
+//		return err
+//	}
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/utils/codec"
+)
+
+// Version is a SOAP protocol version.
+type Version int
+
+const (
+	// SOAP11 sends SOAP 1.1 envelopes with a SOAPAction header.
+	SOAP11 Version = iota
+	// SOAP12 sends SOAP 1.2 envelopes with the action in the content type.
+	SOAP12
+)
+
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+
+	// DefaultTimeout bounds a call when the context has no deadline.
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxResponseSize bounds the size of the responses read.
+	DefaultMaxResponseSize = 10 << 20
+)
+
+// namespace returns the envelope namespace of v.
+func (v Version) namespace() string {
+	if v == SOAP12 {
+		return soap12Namespace
+	}
+	return soap11Namespace
+}
+
+// Client calls the operations of one SOAP endpoint.
+type Client struct {
+	endpoint        string
+	httpClient      *http.Client
+	version         Version
+	token           *UsernameToken
+	headers         http.Header
+	headerBlocks    []any
+	timeout         time.Duration
+	maxResponseSize int64
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client, e.g. one with client certificates. Defaults to
+// http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithVersion sets the SOAP version. Defaults to SOAP11.
+func WithVersion(version Version) Option {
+	return func(c *Client) {
+		c.version = version
+	}
+}
+
+// WithUsernameToken signs every envelope with a WS-Security username token. digest sends
+// the password as a PasswordDigest with a fresh nonce instead of in plain text.
+func WithUsernameToken(username, password string, digest bool) Option {
+	return func(c *Client) {
+		c.token = &UsernameToken{Username: username, Password: password, Digest: digest}
+	}
+}
+
+// WithHTTPHeader sends an HTTP header with every call.
+func WithHTTPHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithHeaderBlock adds block, an encoding/xml struct, to the SOAP header of every call.
+func WithHeaderBlock(block any) Option {
+	return func(c *Client) {
+		c.headerBlocks = append(c.headerBlocks, block)
+	}
+}
+
+// WithTimeout bounds calls whose context has no deadline. Defaults to DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxResponseSize bounds the size of the responses read. Defaults to
+// DefaultMaxResponseSize.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = size
+	}
+}
+
+// NewClient creates a Client for endpoint.
+func NewClient(endpoint string, options ...Option) *Client {
+	c := &Client{
+		endpoint:        endpoint,
+		httpClient:      http.DefaultClient,
+		version:         SOAP11,
+		headers:         make(http.Header),
+		timeout:         DefaultTimeout,
+		maxResponseSize: DefaultMaxResponseSize,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// Call invokes action with request as the body and decodes the response body into response,
+// which may be nil for one-way operations. A fault is returned as a *Fault.
+func (c *Client) Call(ctx context.Context, action string, request, response any) error {
+	body, err := c.Envelope(request)
+	if err != nil {
+		return err
+	}
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	if c.version == SOAP12 {
+		contentType := "application/soap+xml; charset=utf-8"
+		if action != "" {
+			contentType += "; action=" + strconv.Quote(action)
+		}
+		req.Header.Set("Content-Type", contentType)
+	} else {
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		req.Header.Set("SOAPAction", strconv.Quote(action))
+	}
+
+	//#nosec G704
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("soap: %s failed: %w", action, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("soap: failed to read the response of %s: %w", action, err)
+	}
+	return decodeResponse(data, resp.StatusCode, response)
+}
+
+// Envelope returns the envelope Call sends for request.
+func (c *Client) Envelope(request any) ([]byte, error) {
+	env := envelope{Namespace: c.version.namespace(), Body: body{Content: request}}
+	if c.token != nil || len(c.headerBlocks) > 0 {
+		env.Header = &header{Blocks: c.headerBlocks}
+		if c.token != nil {
+			security, err := c.token.security()
+			if err != nil {
+				return nil, err
+			}
+			env.Header.Blocks = append([]any{security}, env.Header.Blocks...)
+		}
+	}
+	data, err := xml.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("soap: failed to encode the envelope: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// envelope is an outgoing SOAP envelope. The "soap" prefix is written literally, as
+// encoding/xml cannot choose namespace prefixes.
+type envelope struct {
+	XMLName   xml.Name `xml:"soap:Envelope"`
+	Namespace string   `xml:"xmlns:soap,attr"`
+	Header    *header  `xml:"soap:Header,omitempty"`
+	Body      body     `xml:"soap:Body"`
+}
+
+type header struct {
+	Blocks []any
+}
+
+type body struct {
+	Content any
+}
+
+// responseEnvelope is an incoming envelope of either version.
+type responseEnvelope struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Fault   *rawFault `xml:"Fault"`
+		Content []byte    `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// decodeResponse decodes the body or fault of a response with status.
+func decodeResponse(data []byte, status int, response any) error {
+	var env responseEnvelope
+	if err := codec.NewXMLDecoder(bytes.NewReader(data)).Decode(&env); err != nil {
+		if status >= http.StatusBadRequest {
+			return fmt.Errorf("soap: request failed with status %d: %s", status, truncate(data))
+		}
+		return fmt.Errorf("soap: invalid response envelope: %w", err)
+	}
+	if env.Body.Fault != nil {
+		return env.Body.Fault.fault()
+	}
+	if status >= http.StatusBadRequest {
+		return fmt.Errorf("soap: request failed with status %d", status)
+	}
+	if response == nil {
+		return nil
+	}
+	if err := codec.NewXMLDecoder(bytes.NewReader(env.Body.Content)).Decode(response); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("soap: response body is empty")
+		}
+		return fmt.Errorf("soap: invalid response body: %w", err)
+	}
+	return nil
+}
+
+// truncate shortens a response body quoted in an error.
+func truncate(data []byte) string {
+	const limit = 512
+	text := strings.TrimSpace(string(data))
+	if len(text) > limit {
+		return text[:limit] + "..."
+	}
+	return text
+}
@@ -0,0 +1,88 @@
+package tasks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes mounts a small inspection API on group:
+//
+//	GET    /tasks?status=failed&limit=50  list tasks by status (default failed)
+//	GET    /tasks/:id                     fetch a task
+//	POST   /tasks/:id/retry               move a failed task back to pending
+//	DELETE /tasks/:id                     delete a task
+//	GET    /tasks/handlers                list handlers registered on this instance
+//
+// The routes expose task payloads; protect group with authentication middleware.
+func RegisterAdminRoutes(group *gin.RouterGroup, m *TaskManager) {
+	tasks := group.Group("/tasks")
+	tasks.GET("", m.listTasksHandler)
+	tasks.GET("/handlers", func(c *gin.Context) {
+		c.JSON(http.StatusOK, acknowledgment.NewAPIResponse(true, "", m.Handlers()))
+	})
+	tasks.GET("/:id", m.getTaskHandler)
+	tasks.POST("/:id/retry", m.requeueTaskHandler)
+	tasks.DELETE("/:id", m.deleteTaskHandler)
+}
+
+func (m *TaskManager) listTasksHandler(c *gin.Context) {
+	status := Status(c.DefaultQuery("status", string(StatusFailed)))
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		adminError(c, http.StatusBadRequest, errors.New("limit must be a positive integer"))
+		return
+	}
+
+	list, err := m.store.List(c.Request.Context(), status, limit)
+	if err != nil {
+		adminError(c, statusForError(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, acknowledgment.NewAPIResponse(true, "", list))
+}
+
+func (m *TaskManager) getTaskHandler(c *gin.Context) {
+	task, err := m.store.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		adminError(c, statusForError(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, acknowledgment.NewAPIResponse(true, "", task))
+}
+
+func (m *TaskManager) requeueTaskHandler(c *gin.Context) {
+	if err := m.store.Requeue(c.Request.Context(), c.Param("id")); err != nil {
+		adminError(c, statusForError(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, acknowledgment.NewAPIResponse(true, "", gin.H{"id": c.Param("id"), "status": StatusPending}))
+}
+
+func (m *TaskManager) deleteTaskHandler(c *gin.Context) {
+	if err := m.store.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		adminError(c, statusForError(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// statusForError maps store errors to HTTP status codes.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrStatusUnsupported):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// adminError writes an error response in the acknowledgment envelope.
+func adminError(c *gin.Context, status int, err error) {
+	c.AbortWithStatusJSON(status, acknowledgment.NewAPIResponse(false, "", gin.H{"error": err.Error()}))
+}
@@ -0,0 +1,337 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// DefaultPollInterval is how often idle workers check the store for due tasks.
+const DefaultPollInterval = time.Second
+
+// registration holds a handler and its execution settings.
+type registration struct {
+	name        string
+	handler     Handler
+	concurrency int
+	retry       RetryPolicy
+	timeout     time.Duration
+}
+
+// TaskManager enqueues named tasks and runs registered handlers against a Store.
+type TaskManager struct {
+	store        Store
+	logger       *log.Log
+	pollInterval time.Duration
+	retry        RetryPolicy
+
+	mu       sync.RWMutex
+	handlers map[string]*registration
+	started  bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// Option configures a TaskManager.
+type Option func(*TaskManager)
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(m *TaskManager) {
+		m.logger = logger
+	}
+}
+
+// WithPollInterval sets how often idle workers poll the store.
+func WithPollInterval(interval time.Duration) Option {
+	return func(m *TaskManager) {
+		if interval > 0 {
+			m.pollInterval = interval
+		}
+	}
+}
+
+// WithDefaultRetryPolicy sets the retry policy for handlers registered without one.
+func WithDefaultRetryPolicy(policy RetryPolicy) Option {
+	return func(m *TaskManager) {
+		m.retry = policy
+	}
+}
+
+// NewTaskManager creates a TaskManager backed by store. Call Start to begin processing.
+func NewTaskManager(store Store, options ...Option) *TaskManager {
+	m := &TaskManager{
+		store:        store,
+		pollInterval: DefaultPollInterval,
+		retry:        DefaultRetryPolicy,
+		handlers:     make(map[string]*registration),
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return m
+}
+
+// HandlerOption configures a registered handler.
+type HandlerOption func(*registration)
+
+// WithConcurrency sets how many tasks of this name run in parallel on this instance.
+func WithConcurrency(n int) HandlerOption {
+	return func(r *registration) {
+		if n > 0 {
+			r.concurrency = n
+		}
+	}
+}
+
+// WithRetryPolicy overrides the retry policy for this handler.
+func WithRetryPolicy(policy RetryPolicy) HandlerOption {
+	return func(r *registration) {
+		r.retry = policy
+	}
+}
+
+// WithTimeout bounds a single execution of the handler.
+func WithTimeout(timeout time.Duration) HandlerOption {
+	return func(r *registration) {
+		r.timeout = timeout
+	}
+}
+
+// Register adds a handler for the named task. Registering after Start launches its workers immediately.
+func (m *TaskManager) Register(name string, handler Handler, options ...HandlerOption) error {
+	if helpers.IsEmpty(name) || handler == nil {
+		return errors.New("tasks: name and handler are required")
+	}
+	reg := &registration{name: name, handler: handler, concurrency: 1, retry: m.retry}
+	for _, opt := range options {
+		opt(reg)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.handlers[name]; exists {
+		return fmt.Errorf("tasks: handler already registered for %s", name)
+	}
+	m.handlers[name] = reg
+	if m.started {
+		m.startWorkers(reg)
+	}
+	return nil
+}
+
+// EnqueueOption configures a task at enqueue time.
+type EnqueueOption func(*Task)
+
+// WithDelay schedules the task to run after d.
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(t *Task) {
+		t.RunAt = time.Now().Add(d)
+	}
+}
+
+// WithRunAt schedules the task to run at a specific time.
+func WithRunAt(at time.Time) EnqueueOption {
+	return func(t *Task) {
+		t.RunAt = at
+	}
+}
+
+// WithMaxRetries overrides the handler retry policy for this task.
+func WithMaxRetries(n int) EnqueueOption {
+	return func(t *Task) {
+		t.MaxRetries = n
+	}
+}
+
+// WithTaskID sets the task ID, e.g. to make enqueueing idempotent on stores that deduplicate.
+func WithTaskID(id string) EnqueueOption {
+	return func(t *Task) {
+		t.ID = id
+	}
+}
+
+// Enqueue persists a task with the JSON encoded payload. The task runs once it is due and a
+// handler for name is registered on any instance sharing the store.
+func (m *TaskManager) Enqueue(ctx context.Context, name string, payload any, options ...EnqueueOption) (*Task, error) {
+	if helpers.IsEmpty(name) {
+		return nil, errors.New("tasks: task name is required")
+	}
+	var data json.RawMessage
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("tasks: failed to encode payload: %w", err)
+		}
+		data = encoded
+	}
+
+	now := time.Now()
+	task := &Task{
+		ID:         random.GenerateUUIDString(),
+		Name:       name,
+		Payload:    data,
+		Status:     StatusPending,
+		MaxRetries: -1,
+		RunAt:      now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	for _, opt := range options {
+		opt(task)
+	}
+
+	if err := m.store.Enqueue(ctx, task); err != nil {
+		return nil, fmt.Errorf("tasks: failed to enqueue %s: %w", name, err)
+	}
+	return task, nil
+}
+
+// Start launches the workers of every registered handler. Workers stop when ctx is
+// cancelled or Stop is called.
+func (m *TaskManager) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return
+	}
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	m.started = true
+	for _, reg := range m.handlers {
+		m.startWorkers(reg)
+	}
+	m.logger.Info("Task manager started", log.Any("handlers", len(m.handlers)))
+}
+
+// startWorkers spawns the worker goroutines of a registration. m.mu must be held.
+func (m *TaskManager) startWorkers(reg *registration) {
+	for i := 0; i < reg.concurrency; i++ {
+		m.wg.Add(1)
+		go m.worker(reg)
+	}
+}
+
+// Stop cancels the workers and waits for running tasks to return.
+func (m *TaskManager) Stop() {
+	m.stopOnce.Do(func() {
+		m.mu.Lock()
+		if m.cancel != nil {
+			m.cancel()
+		}
+		m.mu.Unlock()
+		m.wg.Wait()
+		m.logger.Info("Task manager stopped")
+	})
+}
+
+// worker polls the store for due tasks of one name until the manager stops.
+func (m *TaskManager) worker(reg *registration) {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		default:
+		}
+
+		task, err := m.store.Dequeue(m.ctx, reg.name)
+		if err != nil && m.ctx.Err() == nil {
+			m.logger.Error("Failed to dequeue task", log.String("task", reg.name), log.Err(err))
+		}
+		if task == nil {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-time.After(m.pollInterval):
+			}
+			continue
+		}
+		m.execute(reg, task)
+	}
+}
+
+// execute runs the handler for a claimed task and records the outcome in the store.
+func (m *TaskManager) execute(reg *registration, task *Task) {
+	ctx := m.ctx
+	if reg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, reg.timeout)
+		defer cancel()
+	}
+
+	task.Attempts++
+	err := m.runHandler(ctx, reg, task)
+
+	// Use a fresh context so the outcome is recorded even while shutting down.
+	storeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err == nil {
+		if err := m.store.Complete(storeCtx, task); err != nil {
+			m.logger.Error("Failed to complete task", log.String("task", task.Name), log.String("task_id", task.ID), log.Err(err))
+		}
+		return
+	}
+
+	task.LastError = err.Error()
+	maxRetries := reg.retry.MaxRetries
+	if task.MaxRetries >= 0 {
+		maxRetries = task.MaxRetries
+	}
+
+	if task.Attempts > maxRetries {
+		m.logger.Error("Task failed permanently", log.String("task", task.Name), log.String("task_id", task.ID), log.Any("attempts", task.Attempts), log.Err(err))
+		if err := m.store.Fail(storeCtx, task); err != nil {
+			m.logger.Error("Failed to mark task as failed", log.String("task_id", task.ID), log.Err(err))
+		}
+		return
+	}
+
+	runAt := time.Now().Add(reg.retry.Backoff(task.Attempts))
+	m.logger.Warn("Task failed, retrying", log.String("task", task.Name), log.String("task_id", task.ID), log.Any("attempts", task.Attempts), log.Time("retry_at", runAt), log.Err(err))
+	if err := m.store.Retry(storeCtx, task, runAt); err != nil {
+		m.logger.Error("Failed to reschedule task", log.String("task_id", task.ID), log.Err(err))
+	}
+}
+
+// runHandler invokes the handler, turning a panic into an error.
+func (m *TaskManager) runHandler(ctx context.Context, reg *registration, task *Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			helpers.Println(constant.ERROR, "exception: occurred in task "+task.Name, "stack:", string(debug.Stack()))
+			err = fmt.Errorf("tasks: handler panicked: %v", r)
+		}
+	}()
+	return reg.handler(ctx, task)
+}
+
+// Store returns the underlying store, e.g. for admin inspection.
+func (m *TaskManager) Store() Store {
+	return m.store
+}
+
+// Handlers returns the names of the registered handlers.
+func (m *TaskManager) Handlers() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.handlers))
+	for name := range m.handlers {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,135 @@
+package tasks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps tasks in process memory. It is intended for tests and local development;
+// tasks are lost on restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	tasks map[string]*Task
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tasks: make(map[string]*Task)}
+}
+
+func (m *MemoryStore) Enqueue(_ context.Context, task *Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *task
+	copied.Status = StatusPending
+	m.tasks[task.ID] = &copied
+	return nil
+}
+
+func (m *MemoryStore) Dequeue(_ context.Context, name string) (*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var next *Task
+	for _, t := range m.tasks {
+		if t.Name != name || t.Status != StatusPending || t.RunAt.After(now) {
+			continue
+		}
+		if next == nil || t.RunAt.Before(next.RunAt) {
+			next = t
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.Status = StatusRunning
+	next.UpdatedAt = now
+	copied := *next
+	return &copied, nil
+}
+
+func (m *MemoryStore) Complete(_ context.Context, task *Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tasks, task.ID)
+	return nil
+}
+
+func (m *MemoryStore) Retry(_ context.Context, task *Task, runAt time.Time) error {
+	return m.update(task, StatusPending, runAt)
+}
+
+func (m *MemoryStore) Fail(_ context.Context, task *Task) error {
+	return m.update(task, StatusFailed, task.RunAt)
+}
+
+// update stores task with the given status and run time.
+func (m *MemoryStore) update(task *Task, status Status, runAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tasks[task.ID]; !ok {
+		return ErrTaskNotFound
+	}
+	copied := *task
+	copied.Status = status
+	copied.RunAt = runAt
+	copied.UpdatedAt = time.Now()
+	m.tasks[task.ID] = &copied
+	return nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, id string) (*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tasks[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (m *MemoryStore) List(_ context.Context, status Status, limit int) ([]*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]*Task, 0)
+	for _, t := range m.tasks {
+		if t.Status == status {
+			copied := *t
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RunAt.Before(result[j].RunAt) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *MemoryStore) Requeue(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tasks[id]
+	if !ok || t.Status != StatusFailed {
+		return ErrTaskNotFound
+	}
+	t.Status = StatusPending
+	t.Attempts = 0
+	t.RunAt = time.Now()
+	t.UpdatedAt = t.RunAt
+	return nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tasks[id]; !ok {
+		return ErrTaskNotFound
+	}
+	delete(m.tasks, id)
+	return nil
+}
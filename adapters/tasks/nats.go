@@ -0,0 +1,331 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// DefaultNATSStream is the JetStream work-queue stream holding pending tasks.
+	DefaultNATSStream = "NEURON_TASKS"
+	// DefaultNATSSubjectPrefix prefixes every task subject.
+	DefaultNATSSubjectPrefix = "neuron.tasks"
+	// DefaultNATSFetchWait bounds how long a Dequeue call waits for a message.
+	DefaultNATSFetchWait = time.Second
+)
+
+// NATSStore persists tasks in JetStream. Pending tasks live in a work-queue stream with one
+// durable pull consumer per task name, so workers in every replica compete for them. Failed
+// tasks are kept in a companion stream, one message per task, where they can be listed,
+// requeued or deleted. Pending and running tasks cannot be listed or fetched by ID.
+type NATSStore struct {
+	js                nats.JetStreamContext
+	stream            string
+	prefix            string
+	visibilityTimeout time.Duration
+	fetchWait         time.Duration
+
+	mu       sync.Mutex
+	subs     map[string]*nats.Subscription
+	inflight map[string]*nats.Msg
+}
+
+// NATSStoreOption configures a NATSStore.
+type NATSStoreOption func(*NATSStore)
+
+// WithNATSStream overrides the stream name and subject prefix.
+func WithNATSStream(stream, subjectPrefix string) NATSStoreOption {
+	return func(s *NATSStore) {
+		if stream != "" {
+			s.stream = stream
+		}
+		if subjectPrefix != "" {
+			s.prefix = subjectPrefix
+		}
+	}
+}
+
+// WithNATSAckWait sets how long a claimed task is leased to a worker before redelivery.
+func WithNATSAckWait(timeout time.Duration) NATSStoreOption {
+	return func(s *NATSStore) {
+		if timeout > 0 {
+			s.visibilityTimeout = timeout
+		}
+	}
+}
+
+// WithNATSFetchWait sets how long Dequeue waits for a message before returning empty.
+func WithNATSFetchWait(wait time.Duration) NATSStoreOption {
+	return func(s *NATSStore) {
+		if wait > 0 {
+			s.fetchWait = wait
+		}
+	}
+}
+
+// NewNATSStore creates a NATSStore and provisions its streams when missing,
+// e.g. NewNATSStore(natsManager.JetStream()).
+func NewNATSStore(js nats.JetStreamContext, options ...NATSStoreOption) (*NATSStore, error) {
+	if js == nil {
+		return nil, errors.New("tasks: jetstream is not enabled")
+	}
+	s := &NATSStore{
+		js:                js,
+		stream:            DefaultNATSStream,
+		prefix:            DefaultNATSSubjectPrefix,
+		visibilityTimeout: DefaultVisibilityTimeout,
+		fetchWait:         DefaultNATSFetchWait,
+		subs:              make(map[string]*nats.Subscription),
+		inflight:          make(map[string]*nats.Msg),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	streams := []*nats.StreamConfig{
+		{Name: s.stream, Subjects: []string{s.prefix + ".queue.>"}, Retention: nats.WorkQueuePolicy},
+		{Name: s.failedStream(), Subjects: []string{s.prefix + ".failed.>"}, Retention: nats.LimitsPolicy, MaxMsgsPerSubject: 1},
+	}
+	for _, cfg := range streams {
+		if _, err := js.StreamInfo(cfg.Name); err == nil {
+			continue
+		}
+		if _, err := js.AddStream(cfg); err != nil {
+			return nil, fmt.Errorf("tasks: failed to create stream %s: %w", cfg.Name, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *NATSStore) failedStream() string            { return s.stream + "_FAILED" }
+func (s *NATSStore) queueSubject(name string) string { return s.prefix + ".queue." + name }
+func (s *NATSStore) failedSubject(id string) string  { return s.prefix + ".failed." + id }
+
+// durableName returns the consumer name of the task name. Consumer names cannot contain '.',
+// '*', '>' or whitespace, so other characters are replaced with '_' and suffixed with a hash of
+// name to keep distinct names apart.
+func durableName(name string) string {
+	clean := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	if clean == name {
+		return "tasks_" + name
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return fmt.Sprintf("tasks_%s_%08x", clean, h.Sum32())
+}
+
+// publish writes task to subject, deduplicated by msgID.
+func (s *NATSStore) publish(ctx context.Context, subject, msgID string, task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("tasks: failed to encode task: %w", err)
+	}
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, msgID)
+	_, err = s.js.PublishMsg(msg, nats.Context(ctx))
+	return err
+}
+
+func (s *NATSStore) Enqueue(ctx context.Context, task *Task) error {
+	task.Status = StatusPending
+	return s.publish(ctx, s.queueSubject(task.Name), task.ID, task)
+}
+
+// subscription returns the pull subscription for name, creating it on first use.
+func (s *NATSStore) subscription(name string) (*nats.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subs[name]; ok {
+		return sub, nil
+	}
+	sub, err := s.js.PullSubscribe(s.queueSubject(name), durableName(name),
+		nats.BindStream(s.stream),
+		nats.ManualAck(),
+		nats.AckWait(s.visibilityTimeout),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tasks: failed to subscribe to %s: %w", name, err)
+	}
+	s.subs[name] = sub
+	return sub, nil
+}
+
+func (s *NATSStore) Dequeue(ctx context.Context, name string) (*Task, error) {
+	sub, err := s.subscription(name)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := sub.Fetch(1, nats.MaxWait(s.fetchWait))
+	if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	msg := msgs[0]
+	var task Task
+	if err := json.Unmarshal(msg.Data, &task); err != nil {
+		// Poison message: drop it rather than redelivering forever.
+		_ = msg.Term()
+		return nil, fmt.Errorf("tasks: failed to decode task: %w", err)
+	}
+
+	// Not yet due: hand it back to the stream until its run time.
+	if wait := time.Until(task.RunAt); wait > 0 {
+		_ = msg.NakWithDelay(wait)
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	s.inflight[task.ID] = msg
+	s.mu.Unlock()
+
+	task.Status = StatusRunning
+	return &task, nil
+}
+
+// claim removes and returns the in-flight message for id.
+func (s *NATSStore) claim(id string) (*nats.Msg, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.inflight[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	delete(s.inflight, id)
+	return msg, nil
+}
+
+func (s *NATSStore) Complete(_ context.Context, task *Task) error {
+	msg, err := s.claim(task.ID)
+	if err != nil {
+		return err
+	}
+	return msg.Ack()
+}
+
+// Retry publishes the updated task as a new message and acknowledges the claimed one,
+// so the attempt count and last error survive redelivery.
+func (s *NATSStore) Retry(ctx context.Context, task *Task, runAt time.Time) error {
+	msg, err := s.claim(task.ID)
+	if err != nil {
+		return err
+	}
+	task.Status = StatusPending
+	task.RunAt = runAt
+	task.UpdatedAt = time.Now()
+	if err := s.publish(ctx, s.queueSubject(task.Name), fmt.Sprintf("%s-%d", task.ID, task.Attempts), task); err != nil {
+		_ = msg.Nak()
+		return err
+	}
+	return msg.Ack()
+}
+
+func (s *NATSStore) Fail(ctx context.Context, task *Task) error {
+	msg, err := s.claim(task.ID)
+	if err != nil {
+		return err
+	}
+	task.Status = StatusFailed
+	task.UpdatedAt = time.Now()
+	if err := s.publish(ctx, s.failedSubject(task.ID), fmt.Sprintf("%s-failed-%d", task.ID, task.Attempts), task); err != nil {
+		_ = msg.Nak()
+		return err
+	}
+	return msg.Ack()
+}
+
+// Get returns a failed task by ID. Pending and running tasks are not addressable in JetStream.
+func (s *NATSStore) Get(_ context.Context, id string) (*Task, error) {
+	raw, err := s.js.GetLastMsg(s.failedStream(), s.failedSubject(id))
+	if errors.Is(err, nats.ErrMsgNotFound) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var task Task
+	if err := json.Unmarshal(raw.Data, &task); err != nil {
+		return nil, fmt.Errorf("tasks: failed to decode task %s: %w", id, err)
+	}
+	return &task, nil
+}
+
+// List returns failed tasks in the order they failed. Other statuses are not supported.
+func (s *NATSStore) List(_ context.Context, status Status, limit int) ([]*Task, error) {
+	if status != StatusFailed {
+		return nil, ErrStatusUnsupported
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	info, err := s.js.StreamInfo(s.failedStream())
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Task, 0)
+	for seq := info.State.FirstSeq; seq <= info.State.LastSeq && len(result) < limit; seq++ {
+		raw, err := s.js.GetMsg(s.failedStream(), seq)
+		if errors.Is(err, nats.ErrMsgNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var task Task
+		if err := json.Unmarshal(raw.Data, &task); err != nil {
+			continue
+		}
+		result = append(result, &task)
+	}
+	return result, nil
+}
+
+func (s *NATSStore) Requeue(ctx context.Context, id string) error {
+	task, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	task.Attempts = 0
+	task.LastError = ""
+	task.RunAt = time.Now()
+	task.Status = StatusPending
+	if err := s.publish(ctx, s.queueSubject(task.Name), fmt.Sprintf("%s-requeue-%d", id, task.RunAt.UnixNano()), task); err != nil {
+		return err
+	}
+	return s.Delete(ctx, id)
+}
+
+// Delete removes a failed task.
+func (s *NATSStore) Delete(_ context.Context, id string) error {
+	return s.js.PurgeStream(s.failedStream(), &nats.StreamPurgeRequest{Subject: s.failedSubject(id)})
+}
+
+// Close drains the pull subscriptions. In-flight tasks are redelivered after their ack wait.
+func (s *NATSStore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, sub := range s.subs {
+		_ = sub.Drain()
+		delete(s.subs, name)
+	}
+}
@@ -0,0 +1,281 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultRedisPrefix namespaces all keys written by RedisStore.
+	DefaultRedisPrefix = "neuron:tasks"
+	// DefaultVisibilityTimeout is how long a claimed task stays invisible before it is handed out again.
+	DefaultVisibilityTimeout = 5 * time.Minute
+)
+
+// dequeueScript returns expired leases to the queue and then claims the earliest due task.
+var dequeueScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+redis.call('ZREM', KEYS[1], ids[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ids[1])
+return ids[1]
+`)
+
+// RedisStore persists tasks in Redis. Pending and claimed tasks are kept in per-name sorted
+// sets scored by due time and lease deadline, so tasks claimed by a crashed worker become
+// visible again once their lease expires.
+type RedisStore struct {
+	client            redis.UniversalClient
+	prefix            string
+	visibilityTimeout time.Duration
+}
+
+// RedisStoreOption configures a RedisStore.
+type RedisStoreOption func(*RedisStore)
+
+// WithRedisPrefix overrides the key prefix.
+func WithRedisPrefix(prefix string) RedisStoreOption {
+	return func(s *RedisStore) {
+		if prefix != "" {
+			s.prefix = prefix
+		}
+	}
+}
+
+// WithVisibilityTimeout sets how long a claimed task is leased to a worker.
+// It should exceed the longest expected task run time.
+func WithVisibilityTimeout(timeout time.Duration) RedisStoreOption {
+	return func(s *RedisStore) {
+		if timeout > 0 {
+			s.visibilityTimeout = timeout
+		}
+	}
+}
+
+// NewRedisStore creates a RedisStore on top of an existing client, e.g. RedisManager.Client().
+func NewRedisStore(client redis.UniversalClient, options ...RedisStoreOption) *RedisStore {
+	s := &RedisStore{
+		client:            client,
+		prefix:            DefaultRedisPrefix,
+		visibilityTimeout: DefaultVisibilityTimeout,
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+func (s *RedisStore) taskKey(id string) string      { return s.prefix + ":task:" + id }
+func (s *RedisStore) queueKey(name string) string   { return s.prefix + ":queue:" + name }
+func (s *RedisStore) runningKey(name string) string { return s.prefix + ":running:" + name }
+func (s *RedisStore) failedKey() string             { return s.prefix + ":failed" }
+func (s *RedisStore) namesKey() string              { return s.prefix + ":names" }
+
+func (s *RedisStore) Enqueue(ctx context.Context, task *Task) error {
+	task.Status = StatusPending
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("tasks: failed to encode task: %w", err)
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.taskKey(task.ID), data, 0)
+		pipe.ZAdd(ctx, s.queueKey(task.Name), redis.Z{Score: float64(task.RunAt.UnixMilli()), Member: task.ID})
+		pipe.SAdd(ctx, s.namesKey(), task.Name)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Dequeue(ctx context.Context, name string) (*Task, error) {
+	now := time.Now()
+	deadline := now.Add(s.visibilityTimeout)
+
+	id, err := dequeueScript.Run(ctx, s.client,
+		[]string{s.queueKey(name), s.runningKey(name)},
+		now.UnixMilli(), deadline.UnixMilli(),
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.Get(ctx, id)
+	if errors.Is(err, ErrTaskNotFound) {
+		// Deleted between scheduling and claiming.
+		s.client.ZRem(ctx, s.runningKey(name), id)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	task.Status = StatusRunning
+	return task, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, task *Task) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.taskKey(task.ID))
+		pipe.ZRem(ctx, s.runningKey(task.Name), task.ID)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Retry(ctx context.Context, task *Task, runAt time.Time) error {
+	task.Status = StatusPending
+	task.RunAt = runAt
+	return s.move(ctx, task, s.queueKey(task.Name), float64(runAt.UnixMilli()))
+}
+
+func (s *RedisStore) Fail(ctx context.Context, task *Task) error {
+	task.Status = StatusFailed
+	return s.move(ctx, task, s.failedKey(), float64(time.Now().UnixMilli()))
+}
+
+// move saves task and moves it from the running set of its name to the target sorted set.
+func (s *RedisStore) move(ctx context.Context, task *Task, target string, score float64) error {
+	task.UpdatedAt = time.Now()
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("tasks: failed to encode task: %w", err)
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.taskKey(task.ID), data, 0)
+		pipe.ZRem(ctx, s.runningKey(task.Name), task.ID)
+		pipe.ZAdd(ctx, target, redis.Z{Score: score, Member: task.ID})
+		return nil
+	})
+	return err
+}
+
+func (s *RedisStore) Get(ctx context.Context, id string) (*Task, error) {
+	data, err := s.client.Get(ctx, s.taskKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var task Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("tasks: failed to decode task %s: %w", id, err)
+	}
+	return &task, nil
+}
+
+func (s *RedisStore) List(ctx context.Context, status Status, limit int) ([]*Task, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var ids []string
+	switch status {
+	case StatusFailed:
+		var err error
+		ids, err = s.client.ZRange(ctx, s.failedKey(), 0, int64(limit-1)).Result()
+		if err != nil {
+			return nil, err
+		}
+	case StatusPending, StatusRunning:
+		names, err := s.client.SMembers(ctx, s.namesKey()).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			key := s.queueKey(name)
+			if status == StatusRunning {
+				key = s.runningKey(name)
+			}
+			found, err := s.client.ZRange(ctx, key, 0, int64(limit-len(ids)-1)).Result()
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, found...)
+			if len(ids) >= limit {
+				break
+			}
+		}
+	default:
+		return nil, ErrStatusUnsupported
+	}
+
+	return s.load(ctx, ids, status)
+}
+
+// load fetches the tasks for ids, skipping any that were removed concurrently.
+func (s *RedisStore) load(ctx context.Context, ids []string, status Status) ([]*Task, error) {
+	result := make([]*Task, 0, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.taskKey(id)
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var task Task
+		if err := json.Unmarshal([]byte(raw), &task); err != nil {
+			continue
+		}
+		task.Status = status
+		result = append(result, &task)
+	}
+	return result, nil
+}
+
+func (s *RedisStore) Requeue(ctx context.Context, id string) error {
+	task, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	removed, err := s.client.ZRem(ctx, s.failedKey(), id).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrTaskNotFound
+	}
+
+	task.Attempts = 0
+	task.LastError = ""
+	task.RunAt = time.Now()
+	return s.Retry(ctx, task, task.RunAt)
+}
+
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	task, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, s.taskKey(id))
+		pipe.ZRem(ctx, s.queueKey(task.Name), id)
+		pipe.ZRem(ctx, s.runningKey(task.Name), id)
+		pipe.ZRem(ctx, s.failedKey(), id)
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,102 @@
+// Package tasks runs named background tasks inside a service. Tasks are persisted in a Store
+// (Redis, NATS JetStream or memory), executed by per-task worker goroutines and retried
+// according to a RetryPolicy.
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
+
+// Status describes where a task is in its lifecycle.
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusFailed  Status = "failed"
+)
+
+var (
+	ErrTaskNotFound      = errors.New("tasks: task not found")
+	ErrHandlerNotFound   = errors.New("tasks: no handler registered for task")
+	ErrStatusUnsupported = errors.New("tasks: listing this status is not supported by the store")
+	ErrManagerStopped    = errors.New("tasks: manager is stopped")
+)
+
+// Task is a unit of background work.
+type Task struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Status     Status          `json:"status"`
+	Attempts   int             `json:"attempts"`
+	MaxRetries int             `json:"max_retries"`
+	RunAt      time.Time       `json:"run_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	LastError  string          `json:"last_error,omitempty"`
+}
+
+// Decode unmarshals the task payload into v.
+func (t *Task) Decode(v any) error {
+	if len(t.Payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(t.Payload, v)
+}
+
+// Handler executes a task. Returning an error schedules a retry until the retry policy is exhausted.
+type Handler func(ctx context.Context, task *Task) error
+
+// RetryPolicy controls how failed tasks are retried.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy retries three times with exponential backoff starting at one second.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Second,
+	MaxDelay:   10 * time.Minute,
+}
+
+// Backoff returns the delay before the given (1-based) retry attempt.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(max(attempt-1, 0))))
+	if p.MaxDelay > 0 && (delay > p.MaxDelay || delay <= 0) {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Store persists tasks and hands them out to workers. Implementations must make Dequeue
+// safe across processes so that a task is claimed by one worker at a time.
+type Store interface {
+	// Enqueue persists a new pending task.
+	Enqueue(ctx context.Context, task *Task) error
+	// Dequeue claims the next due task with the given name, or returns nil when none is due.
+	Dequeue(ctx context.Context, name string) (*Task, error)
+	// Complete removes a successfully processed task.
+	Complete(ctx context.Context, task *Task) error
+	// Retry reschedules a claimed task to run again at runAt.
+	Retry(ctx context.Context, task *Task, runAt time.Time) error
+	// Fail moves a claimed task to the failed set.
+	Fail(ctx context.Context, task *Task) error
+	// Get returns a task by ID.
+	Get(ctx context.Context, id string) (*Task, error)
+	// List returns up to limit tasks with the given status.
+	List(ctx context.Context, status Status, limit int) ([]*Task, error)
+	// Requeue moves a failed task back to pending, resetting its attempts.
+	Requeue(ctx context.Context, id string) error
+	// Delete removes a task regardless of its status.
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,215 @@
+package upload
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/gin-gonic/gin"
+)
+
+// These are the headers of the upload protocol, following tus 1.0.
+const (
+	HeaderResumable = "Tus-Resumable"
+	HeaderOffset    = "Upload-Offset"
+	HeaderLength    = "Upload-Length"
+	HeaderMetadata  = "Upload-Metadata"
+	HeaderChecksum  = "Upload-Checksum"
+	HeaderExpires   = "Upload-Expires"
+
+	// ChunkContentType is the content type of the chunks.
+	ChunkContentType = "application/offset+octet-stream"
+	protocolVersion  = "1.0.0"
+	// statusChecksumMismatch is the tus status of a chunk failing its checksum.
+	statusChecksumMismatch = 460
+)
+
+// These are the keys of Upload-Metadata read into the session; the other keys are kept as
+// its metadata.
+const (
+	metadataFilename    = "filename"
+	metadataContentType = "filetype"
+	metadataChecksum    = "checksum"
+)
+
+// RegisterRoutes mounts the upload protocol on group:
+//
+//	POST   /uploads      create a session from Upload-Length and Upload-Metadata
+//	HEAD   /uploads/:id  fetch the Upload-Offset to resume from
+//	PATCH  /uploads/:id  append a chunk at Upload-Offset, with an optional Upload-Checksum
+//	GET    /uploads/:id  fetch the session, including the object key once completed
+//	DELETE /uploads/:id  abandon the upload
+//
+// Upload-Metadata holds comma-separated "key base64(value)" pairs; "filename", "filetype"
+// and "checksum" (hex SHA-256 of the whole file) are recognised. Upload-Checksum is
+// "sha256 base64(digest)". Protect group with authentication middleware.
+func RegisterRoutes(group *gin.RouterGroup, m *Manager) {
+	uploads := group.Group("/uploads")
+	uploads.POST("", m.createHandler(uploads.BasePath()))
+	uploads.HEAD("/:id", m.headHandler)
+	uploads.PATCH("/:id", m.patchHandler)
+	uploads.GET("/:id", m.getHandler)
+	uploads.DELETE("/:id", m.deleteHandler)
+}
+
+func (m *Manager) createHandler(basePath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(HeaderResumable, protocolVersion)
+		size, err := strconv.ParseInt(c.GetHeader(HeaderLength), 10, 64)
+		if err != nil {
+			uploadError(c, http.StatusBadRequest, errors.New("upload: Upload-Length must be an integer"))
+			return
+		}
+		metadata, err := parseMetadata(c.GetHeader(HeaderMetadata))
+		if err != nil {
+			uploadError(c, http.StatusBadRequest, err)
+			return
+		}
+		req := CreateRequest{
+			Size:        size,
+			Filename:    metadata[metadataFilename],
+			ContentType: metadata[metadataContentType],
+			Checksum:    metadata[metadataChecksum],
+		}
+		delete(metadata, metadataFilename)
+		delete(metadata, metadataContentType)
+		delete(metadata, metadataChecksum)
+		if len(metadata) > 0 {
+			req.Metadata = metadata
+		}
+
+		session, err := m.Create(c.Request.Context(), req)
+		if err != nil {
+			uploadError(c, statusForError(err), err)
+			return
+		}
+		c.Header("Location", basePath+"/"+session.ID)
+		writeOffset(c, session)
+		c.JSON(http.StatusCreated, acknowledgment.NewAPIResponse(true, "", session))
+	}
+}
+
+func (m *Manager) headHandler(c *gin.Context) {
+	c.Header(HeaderResumable, protocolVersion)
+	c.Header("Cache-Control", "no-store")
+	session, err := m.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Status(statusForError(err))
+		return
+	}
+	writeOffset(c, session)
+	c.Status(http.StatusOK)
+}
+
+func (m *Manager) patchHandler(c *gin.Context) {
+	c.Header(HeaderResumable, protocolVersion)
+	if c.ContentType() != ChunkContentType {
+		uploadError(c, http.StatusUnsupportedMediaType, errors.New("upload: chunks must be sent as "+ChunkContentType))
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader(HeaderOffset), 10, 64)
+	if err != nil || offset < 0 {
+		uploadError(c, http.StatusBadRequest, errors.New("upload: Upload-Offset must be a non-negative integer"))
+		return
+	}
+	digest, err := parseChecksum(c.GetHeader(HeaderChecksum))
+	if err != nil {
+		uploadError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	session, err := m.Append(c.Request.Context(), c.Param("id"), offset, c.Request.Body, digest)
+	if err != nil {
+		uploadError(c, statusForError(err), err)
+		return
+	}
+	writeOffset(c, session)
+	c.Status(http.StatusNoContent)
+}
+
+func (m *Manager) getHandler(c *gin.Context) {
+	session, err := m.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		uploadError(c, statusForError(err), err)
+		return
+	}
+	c.JSON(http.StatusOK, acknowledgment.NewAPIResponse(true, "", session))
+}
+
+func (m *Manager) deleteHandler(c *gin.Context) {
+	c.Header(HeaderResumable, protocolVersion)
+	if err := m.Terminate(c.Request.Context(), c.Param("id")); err != nil {
+		uploadError(c, statusForError(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// writeOffset sets the offset, length and expiry headers of session.
+func writeOffset(c *gin.Context, session *Session) {
+	c.Header(HeaderOffset, strconv.FormatInt(session.Offset, 10))
+	c.Header(HeaderLength, strconv.FormatInt(session.Size, 10))
+	if !session.Completed {
+		c.Header(HeaderExpires, session.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseMetadata decodes an Upload-Metadata header.
+func parseMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("upload: Upload-Metadata values must be base64 encoded")
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// parseChecksum decodes an Upload-Checksum header into a SHA-256 digest.
+func parseChecksum(header string) ([]byte, error) {
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, _ := strings.Cut(header, " ")
+	if algorithm != "sha256" {
+		return nil, errors.New("upload: only sha256 checksums are supported")
+	}
+	digest, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("upload: Upload-Checksum digest must be base64 encoded")
+	}
+	return digest, nil
+}
+
+// statusForError maps upload errors to HTTP status codes.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrOffsetMismatch), errors.Is(err, ErrCompleted), errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrChecksumMismatch):
+		return statusChecksumMismatch
+	case errors.Is(err, ErrTooLarge), errors.Is(err, ErrChunkTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrIncomplete), errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// uploadError writes an error response in the acknowledgment envelope.
+func uploadError(c *gin.Context, status int, err error) {
+	c.AbortWithStatusJSON(status, acknowledgment.NewAPIResponse(false, "", gin.H{"error": err.Error()}))
+}
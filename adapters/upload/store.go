@@ -0,0 +1,160 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionStore persists the upload sessions, so a client can resume on any replica.
+type SessionStore interface {
+	// Create stores a new session.
+	Create(ctx context.Context, session *Session) error
+	// Get returns the session id, or ErrSessionNotFound.
+	Get(ctx context.Context, id string) (*Session, error)
+	// Update applies update to the session id atomically; an error of update is returned
+	// and leaves the session unchanged.
+	Update(ctx context.Context, id string, update func(*Session) error) (*Session, error)
+	// Delete removes the session id.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemorySessionStore keeps the sessions in memory, for a single replica or tests.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+// NewMemorySessionStore creates a MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]Session)}
+}
+
+func (m *MemorySessionStore) Create(_ context.Context, session *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID] = session.clone()
+	return nil
+}
+
+func (m *MemorySessionStore) Get(_ context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok || session.Expired(time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+	clone := session.clone()
+	return &clone, nil
+}
+
+func (m *MemorySessionStore) Update(_ context.Context, id string, update func(*Session) error) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok || session.Expired(time.Now()) {
+		return nil, ErrSessionNotFound
+	}
+	updated := session.clone()
+	if err := update(&updated); err != nil {
+		return nil, err
+	}
+	m.sessions[id] = updated.clone()
+	return &updated, nil
+}
+
+func (m *MemorySessionStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+// RedisSessionStore keeps the sessions in Redis until they expire. Updates are optimistic
+// transactions retried on conflict.
+type RedisSessionStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisSessionStore creates a RedisSessionStore. prefix defaults to "neuron:uploads".
+func NewRedisSessionStore(client redis.UniversalClient, prefix string) *RedisSessionStore {
+	if prefix == "" {
+		prefix = "neuron:uploads"
+	}
+	return &RedisSessionStore{client: client, prefix: prefix}
+}
+
+func (r *RedisSessionStore) key(id string) string {
+	return r.prefix + ":" + id
+}
+
+func (r *RedisSessionStore) Create(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, r.key(session.ID), data, time.Until(session.ExpiresAt)).Err()
+}
+
+func (r *RedisSessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	return r.load(ctx, r.client, id)
+}
+
+func (r *RedisSessionStore) load(ctx context.Context, client redis.Cmdable, id string) (*Session, error) {
+	data, err := client.Get(ctx, r.key(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("upload: corrupt session %s: %w", id, err)
+	}
+	return &session, nil
+}
+
+func (r *RedisSessionStore) Update(ctx context.Context, id string, update func(*Session) error) (*Session, error) {
+	key := r.key(id)
+	for attempt := 0; attempt < 5; attempt++ {
+		var updated *Session
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			session, err := r.load(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if err := update(session); err != nil {
+				return err
+			}
+			data, err := json.Marshal(session)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, data, time.Until(session.ExpiresAt))
+				return nil
+			})
+			updated = session
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+	return nil, ErrConflict
+}
+
+func (r *RedisSessionStore) Delete(ctx context.Context, id string) error {
+	return r.client.Del(ctx, r.key(id)).Err()
+}
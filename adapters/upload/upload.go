@@ -0,0 +1,407 @@
+// Package upload implements resumable chunked uploads, modelled on the tus protocol, for
+// clients on unreliable networks where single-shot multipart uploads keep failing. A client
+// creates an upload session, sends the file in chunks at increasing offsets, asks for the
+// current offset after a failure and resumes from there. Chunks are staged in object storage
+// so any replica can take the next one; once the last chunk arrives they are concatenated
+// into the final object and the staged chunks removed.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/cloud"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+const (
+	// DefaultMaxChunkSize is the largest chunk accepted without WithMaxChunkSize.
+	DefaultMaxChunkSize = 8 << 20
+	// DefaultMaxSize is the largest upload accepted without WithMaxSize.
+	DefaultMaxSize = 5 << 30
+	// DefaultExpiry is how long an unfinished session can be resumed without WithExpiry.
+	DefaultExpiry = 24 * time.Hour
+	// DefaultStagingPrefix prefixes the staged chunks without WithStagingPrefix.
+	DefaultStagingPrefix = "uploads/.staging"
+)
+
+// These are the errors of the upload operations.
+var (
+	ErrSessionNotFound  = errors.New("upload: session not found")
+	ErrOffsetMismatch   = errors.New("upload: offset does not match the upload offset")
+	ErrChecksumMismatch = errors.New("upload: checksum mismatch")
+	ErrTooLarge         = errors.New("upload: upload exceeds the maximum size")
+	ErrChunkTooLarge    = errors.New("upload: chunk exceeds the maximum chunk size")
+	ErrCompleted        = errors.New("upload: upload already completed")
+	ErrIncomplete       = errors.New("upload: upload is not complete")
+	ErrConflict         = errors.New("upload: concurrent updates of the session")
+	ErrInvalidRequest   = errors.New("upload: invalid request")
+)
+
+// Chunk is a staged part of an upload.
+type Chunk struct {
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Key    string `json:"key"`
+}
+
+// Session is the state of an upload.
+type Session struct {
+	ID          string `json:"id"`
+	Size        int64  `json:"size"`
+	Offset      int64  `json:"offset"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Checksum is the hex SHA-256 of the whole file, verified on completion when set.
+	Checksum  string            `json:"checksum,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Chunks    []Chunk           `json:"chunks,omitempty"`
+	Completed bool              `json:"completed"`
+	// Key is the object key of the completed upload.
+	Key       string    `json:"key,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the unfinished session can no longer be resumed at now.
+func (s *Session) Expired(now time.Time) bool {
+	return !s.Completed && !now.Before(s.ExpiresAt)
+}
+
+// clone returns a deep copy of the session.
+func (s *Session) clone() Session {
+	c := *s
+	c.Metadata = maps.Clone(s.Metadata)
+	c.Chunks = slices.Clone(s.Chunks)
+	return c
+}
+
+// CreateRequest describes a new upload.
+type CreateRequest struct {
+	Size        int64
+	Filename    string
+	ContentType string
+	// Checksum is the optional hex SHA-256 of the whole file.
+	Checksum string
+	Metadata map[string]string
+}
+
+// Manager runs the upload sessions.
+type Manager struct {
+	storage       cloud.CloudManager
+	bucket        string
+	sessions      SessionStore
+	stagingPrefix string
+	maxSize       int64
+	maxChunkSize  int64
+	expiry        time.Duration
+	objectKey     func(*Session) string
+	onComplete    func(context.Context, *Session) error
+	logger        *log.Log
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithMaxSize sets the largest upload accepted. Defaults to DefaultMaxSize.
+func WithMaxSize(size int64) Option {
+	return func(m *Manager) {
+		m.maxSize = size
+	}
+}
+
+// WithMaxChunkSize sets the largest chunk accepted, which is held in memory while it is
+// staged. Defaults to DefaultMaxChunkSize.
+func WithMaxChunkSize(size int64) Option {
+	return func(m *Manager) {
+		m.maxChunkSize = size
+	}
+}
+
+// WithExpiry sets how long an unfinished session can be resumed. Defaults to DefaultExpiry.
+func WithExpiry(expiry time.Duration) Option {
+	return func(m *Manager) {
+		m.expiry = expiry
+	}
+}
+
+// WithStagingPrefix sets the prefix of the staged chunks. Defaults to DefaultStagingPrefix;
+// give it a lifecycle rule deleting old objects to clean up abandoned uploads.
+func WithStagingPrefix(prefix string) Option {
+	return func(m *Manager) {
+		m.stagingPrefix = prefix
+	}
+}
+
+// WithObjectKey sets the key of the completed uploads. Defaults to "uploads/<id>/<filename>".
+func WithObjectKey(objectKey func(*Session) string) Option {
+	return func(m *Manager) {
+		m.objectKey = objectKey
+	}
+}
+
+// WithOnComplete calls fn once an upload is stored, e.g. to record the file or publish an
+// event.
+func WithOnComplete(fn func(ctx context.Context, session *Session) error) Option {
+	return func(m *Manager) {
+		m.onComplete = fn
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// NewManager creates a Manager storing the uploads in bucket of storage and their sessions in
+// sessions.
+func NewManager(storage cloud.CloudManager, bucket string, sessions SessionStore, options ...Option) *Manager {
+	m := &Manager{
+		storage:       storage,
+		bucket:        bucket,
+		sessions:      sessions,
+		stagingPrefix: DefaultStagingPrefix,
+		maxSize:       DefaultMaxSize,
+		maxChunkSize:  DefaultMaxChunkSize,
+		expiry:        DefaultExpiry,
+		objectKey:     defaultObjectKey,
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return m
+}
+
+// Create opens an upload session.
+func (m *Manager) Create(ctx context.Context, req CreateRequest) (*Session, error) {
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("%w: size must be positive", ErrInvalidRequest)
+	}
+	if req.Size > m.maxSize {
+		return nil, ErrTooLarge
+	}
+	if req.Checksum != "" {
+		if digest, err := hex.DecodeString(req.Checksum); err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("%w: checksum must be a hex SHA-256 digest", ErrInvalidRequest)
+		}
+	}
+	filename := ""
+	if req.Filename != "" {
+		filename = path.Base(req.Filename)
+	}
+	now := time.Now()
+	session := &Session{
+		ID:          random.GenerateSecureUUIDString(),
+		Size:        req.Size,
+		Filename:    filename,
+		ContentType: req.ContentType,
+		Checksum:    req.Checksum,
+		Metadata:    req.Metadata,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(m.expiry),
+	}
+	if err := m.sessions.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Get returns the session id.
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	return m.sessions.Get(ctx, id)
+}
+
+// Append stages the chunk read from r at offset, which must be the current offset of the
+// session. digest, when set, is the SHA-256 of the chunk. The upload is finalized once its
+// last chunk is staged.
+func (m *Manager) Append(ctx context.Context, id string, offset int64, r io.Reader, digest []byte) (*Session, error) {
+	session, err := m.sessions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Completed {
+		return nil, ErrCompleted
+	}
+	if offset != session.Offset {
+		return nil, ErrOffsetMismatch
+	}
+
+	limit := min(m.maxChunkSize, session.Size-offset)
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("upload: failed to read chunk: %w", err)
+	}
+	if int64(len(data)) > limit {
+		if limit == m.maxChunkSize {
+			return nil, ErrChunkTooLarge
+		}
+		return nil, ErrTooLarge
+	}
+	if len(data) == 0 {
+		if session.Offset == session.Size {
+			return m.Finalize(ctx, id)
+		}
+		return session, nil
+	}
+	if digest != nil {
+		sum := sha256.Sum256(data)
+		if !bytes.Equal(sum[:], digest) {
+			return nil, ErrChecksumMismatch
+		}
+	}
+
+	chunk := Chunk{
+		Offset: offset,
+		Size:   int64(len(data)),
+		Key:    path.Join(m.stagingPrefix, id, fmt.Sprintf("%020d-%s", offset, random.GenerateUUIDString())),
+	}
+	if err := m.storage.UploadFile(ctx, m.bucket, chunk.Key, data, "application/octet-stream", nil); err != nil {
+		return nil, fmt.Errorf("upload: failed to stage chunk: %w", err)
+	}
+	session, err = m.sessions.Update(ctx, id, func(s *Session) error {
+		if s.Completed {
+			return ErrCompleted
+		}
+		if s.Offset != offset {
+			return ErrOffsetMismatch
+		}
+		s.Chunks = append(s.Chunks, chunk)
+		s.Offset += chunk.Size
+		return nil
+	})
+	if err != nil {
+		// Another request staged this offset first.
+		m.deleteObject(ctx, chunk.Key)
+		return nil, err
+	}
+	if session.Offset == session.Size {
+		return m.Finalize(ctx, id)
+	}
+	return session, nil
+}
+
+// Finalize concatenates the staged chunks of a fully received upload into its object, checks
+// its checksum and removes the chunks. Append calls it after the last chunk; call it again if
+// that failed. A checksum mismatch discards the upload.
+func (m *Manager) Finalize(ctx context.Context, id string) (*Session, error) {
+	session, err := m.sessions.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Completed {
+		return session, nil
+	}
+	if session.Offset != session.Size {
+		return nil, ErrIncomplete
+	}
+
+	key := m.objectKey(session)
+	hash := sha256.New()
+	pr, pw := io.Pipe()
+	go func() {
+		for _, chunk := range session.Chunks {
+			data, err := m.storage.DownloadFile(ctx, m.bucket, chunk.Key)
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("upload: failed to read chunk at %d: %w", chunk.Offset, err))
+				return
+			}
+			if _, err := pw.Write(data); err != nil {
+				return
+			}
+		}
+		_ = pw.Close()
+	}()
+	err = m.storage.UploadFileFromReader(ctx, m.bucket, key, io.TeeReader(pr, hash), session.Size, session.ContentType, session.Metadata)
+	_ = pr.CloseWithError(err)
+	if err != nil {
+		return nil, fmt.Errorf("upload: failed to store upload: %w", err)
+	}
+	if session.Checksum != "" && hex.EncodeToString(hash.Sum(nil)) != session.Checksum {
+		m.deleteObject(ctx, key)
+		m.discard(ctx, session)
+		return nil, ErrChecksumMismatch
+	}
+
+	session, err = m.sessions.Update(ctx, id, func(s *Session) error {
+		s.Completed = true
+		s.Key = key
+		s.Chunks = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.deleteStaged(ctx, id)
+	if m.onComplete != nil {
+		if err := m.onComplete(ctx, session); err != nil {
+			m.logger.Error("Upload completion callback failed", log.String("upload_id", id), log.Err(err))
+		}
+	}
+	return session, nil
+}
+
+// Terminate abandons the upload id and removes its staged chunks.
+func (m *Manager) Terminate(ctx context.Context, id string) error {
+	session, err := m.sessions.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if session.Completed {
+		return ErrCompleted
+	}
+	m.discard(ctx, session)
+	return nil
+}
+
+// discard deletes the staged chunks and the session.
+func (m *Manager) discard(ctx context.Context, session *Session) {
+	m.deleteStaged(ctx, session.ID)
+	if err := m.sessions.Delete(ctx, session.ID); err != nil {
+		m.logger.Warn("Failed to delete upload session", log.String("upload_id", session.ID), log.Err(err))
+	}
+}
+
+// deleteStaged deletes every staged chunk of the upload id, including the ones of requests
+// that lost a race.
+func (m *Manager) deleteStaged(ctx context.Context, id string) {
+	objects, err := m.storage.ListObjects(ctx, m.bucket, path.Join(m.stagingPrefix, id)+"/")
+	if err != nil {
+		m.logger.Warn("Failed to list staged chunks", log.String("upload_id", id), log.Err(err))
+		return
+	}
+	for _, object := range objects {
+		m.deleteObject(ctx, object.Key)
+	}
+}
+
+// deleteObject deletes key, logging failures.
+func (m *Manager) deleteObject(ctx context.Context, key string) {
+	if err := m.storage.DeleteObject(ctx, m.bucket, key); err != nil {
+		m.logger.Warn("Failed to delete upload object", log.String("key", key), log.Err(err))
+	}
+}
+
+// defaultObjectKey stores the uploads under uploads/<id>/<filename>.
+func defaultObjectKey(s *Session) string {
+	name := s.Filename
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	return path.Join("uploads", s.ID, name)
+}
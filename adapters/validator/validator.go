@@ -2,7 +2,9 @@ package validator
 
 import (
 	"fmt"
+	"html"
 
+	"github.com/abhissng/neuron/adapters/htmlsanitizer"
 	"github.com/asaskevich/govalidator"
 	"github.com/go-playground/validator/v10"
 )
@@ -10,13 +12,58 @@ import (
 // Validator is a high-level wrapper for go-playground/validator.
 type Validator struct {
 	validator *validator.Validate
+	sanitizer *htmlsanitizer.HTMLSanitizer
 }
 
 // NewValidator creates a new Validator instance.
+// The "nohtml" and "safehtml" tags are registered so structs can reject markup or unsafe HTML.
 func NewValidator() *Validator {
-	return &Validator{
+	v := &Validator{
 		validator: validator.New(),
+		sanitizer: htmlsanitizer.Default(),
 	}
+	v.registerHTMLValidations()
+	return v
+}
+
+// WithHTMLSanitizer replaces the sanitizer used by the html tags and SanitizeStruct.
+func (v *Validator) WithHTMLSanitizer(sanitizer *htmlsanitizer.HTMLSanitizer) *Validator {
+	if sanitizer != nil {
+		v.sanitizer = sanitizer
+	}
+	return v
+}
+
+// registerHTMLValidations registers the html related validation tags.
+//
+//	nohtml   : the string must not contain any tags; text with &, < or quotes is fine.
+//	safehtml : the string must be unchanged by the UGC policy.
+func (v *Validator) registerHTMLValidations() {
+	_ = v.validator.RegisterValidation("nohtml", func(fl validator.FieldLevel) bool {
+		// The sanitizer escapes the text it keeps, so plain text like "Tom & Jerry" or
+		// "Tom &amp; Jerry" only differs from its stripped form, once both are decoded,
+		// when tags were removed
+		input := fl.Field().String()
+		return html.UnescapeString(v.sanitizer.StripTags(input)) == html.UnescapeString(input)
+	})
+	_ = v.validator.RegisterValidation("safehtml", func(fl validator.FieldLevel) bool {
+		return v.sanitizer.IsSafe(htmlsanitizer.UGC, fl.Field().String())
+	})
+}
+
+// SanitizeStruct rewrites the fields of s tagged with `sanitize:"<preset>"` in place.
+// s must be a pointer to a struct.
+func (v *Validator) SanitizeStruct(s interface{}) error {
+	return v.sanitizer.SanitizeStruct(s)
+}
+
+// SanitizeAndValidate sanitizes the tagged fields of s and then validates it.
+// A sanitization failure is reported under the "sanitize" key.
+func (v *Validator) SanitizeAndValidate(s interface{}) map[string]string {
+	if err := v.SanitizeStruct(s); err != nil {
+		return map[string]string{"sanitize": err.Error()}
+	}
+	return v.ValidateStruct(s)
 }
 
 // ValidateStruct validates a struct and returns a map of field names to error messages.
@@ -85,6 +132,10 @@ func (v *Validator) getErrorMessage(fieldError validator.FieldError) string {
 		return fmt.Sprintf("%s must be greater than or equal to %s", fieldError.Field(), fieldError.Param())
 	case "lte":
 		return fmt.Sprintf("%s must be less than or equal to %s", fieldError.Field(), fieldError.Param())
+	case "nohtml":
+		return fmt.Sprintf("%s must not contain HTML", fieldError.Field())
+	case "safehtml":
+		return fmt.Sprintf("%s contains unsafe HTML", fieldError.Field())
 	default:
 		return fmt.Sprintf("invalid %s", fieldError.Field())
 	}
@@ -41,6 +41,7 @@ type Vault struct {
 	cryptoManager *cryptography.CryptoManager
 	timeOut       time.Duration
 	vaultSecrets  []*models.Secret
+	memorySecrets map[string]string // Secrets of NewMemoryVault
 }
 
 // NewVault creates a new Vault with options
@@ -198,6 +199,23 @@ func (v *Vault) retrieveAWSParameterStoreSecret(ctx context.Context, paramName s
 	return result, nil
 }
 
+// NewMemoryVault creates a Vault serving secrets from memory, keyed without backend prefix,
+// for local development and tests. Keys of any backend prefix resolve to the same secret.
+func NewMemoryVault(secrets map[string]string, opts ...Option) *Vault {
+	v := &Vault{
+		timeOut:       timeout,
+		defaultSource: "memory",
+		memorySecrets: make(map[string]string, len(secrets)),
+	}
+	for key, value := range secrets {
+		v.memorySecrets[key] = value
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
 // FetchVaultValue fetches a secret value from the configured backend based on key prefix.
 // Prefixes: "aws-sm:", "aws-ssm:", "infisical:" (or no prefix defaults to Infisical).
 func (v *Vault) FetchVaultValue(key string) (string, error) {
@@ -207,6 +225,10 @@ func (v *Vault) FetchVaultValue(key string) (string, error) {
 	key = strings.Replace(key, ":enc:", ":", 1)
 	key = strings.Replace(key, "enc:", "", 1)
 
+	if v.memorySecrets != nil {
+		return v.retrieveMemorySecret(key)
+	}
+
 	switch {
 	case strings.HasPrefix(key, SecretsManagerPrefix):
 		// source = "AWS Secrets Manager"
@@ -255,6 +277,117 @@ func (v *Vault) FetchVaultValue(key string) (string, error) {
 	}
 }
 
+// StoreVaultValue creates or updates the secret of key in the backend chosen by its prefix,
+// like FetchVaultValue. Parameter Store values are stored as SecureString. AWS KMS keys
+// cannot be written.
+func (v *Vault) StoreVaultValue(key, value string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), v.timeOut)
+	defer cancel()
+
+	if v.memorySecrets != nil {
+		for _, prefix := range []string{SecretsManagerPrefix, ParameterStorePrefix, InfisicalPrefix, AWSKMSPrefix} {
+			key = strings.TrimPrefix(key, prefix)
+		}
+		v.memorySecrets[key] = value
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(key, SecretsManagerPrefix):
+		return v.storeAWSSecretsManagerSecret(ctx, strings.TrimPrefix(key, SecretsManagerPrefix), value)
+	case strings.HasPrefix(key, ParameterStorePrefix):
+		return v.storeAWSParameterStoreSecret(ctx, strings.TrimPrefix(key, ParameterStorePrefix), value)
+	case strings.HasPrefix(key, InfisicalPrefix):
+		return v.storeInfisicalSecret(strings.TrimPrefix(key, InfisicalPrefix), value)
+	case strings.HasPrefix(key, AWSKMSPrefix):
+		return errors.New("AWS KMS keys cannot be stored through the vault")
+	default:
+		if v.defaultSource == "aws" {
+			return v.storeAWSParameterStoreSecret(ctx, key, value)
+		}
+		return v.storeInfisicalSecret(key, value)
+	}
+}
+
+// storeInfisicalSecret creates the secret in Infisical, or updates it when it exists.
+func (v *Vault) storeInfisicalSecret(key, value string) error {
+	if v.infisicalClient == nil {
+		return errors.New("infisical client not initialized")
+	}
+	// The cached list no longer reflects the backend
+	v.vaultSecrets = nil
+
+	if _, err := v.RetrieveInfisicalSingleSecret(key); err == nil {
+		_, err = v.infisicalClient.Secrets().Update(infisical.UpdateSecretOptions{
+			SecretKey:      key,
+			ProjectID:      v.projectID,
+			Environment:    v.env,
+			SecretPath:     v.path,
+			NewSecretValue: value,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update Infisical secret %s: %w", key, err)
+		}
+		return nil
+	}
+	_, err := v.infisicalClient.Secrets().Create(infisical.CreateSecretOptions{
+		SecretKey:   key,
+		ProjectID:   v.projectID,
+		Environment: v.env,
+		SecretPath:  v.path,
+		SecretValue: value,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Infisical secret %s: %w", key, err)
+	}
+	return nil
+}
+
+// storeAWSSecretsManagerSecret creates the secret in AWS Secrets Manager, or updates it when
+// it exists.
+func (v *Vault) storeAWSSecretsManagerSecret(ctx context.Context, secretId, value string) error {
+	if v.awsClient == nil || v.awsClient.GetSecretsManagerClient() == nil {
+		return errors.New("AWS Secrets Manager client not initialized")
+	}
+	_, err := v.awsClient.GetSecret(ctx, secretId)
+	switch {
+	case errors.Is(err, neuron_aws.ErrNotFound):
+		if _, err := v.awsClient.CreateSecret(ctx, secretId, value); err != nil {
+			return fmt.Errorf("failed to create secret %s in AWS Secrets Manager: %w", secretId, err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to store secret %s in AWS Secrets Manager: %w", secretId, err)
+	}
+	if err := v.awsClient.UpdateSecret(ctx, secretId, value); err != nil {
+		return fmt.Errorf("failed to update secret %s in AWS Secrets Manager: %w", secretId, err)
+	}
+	return nil
+}
+
+// storeAWSParameterStoreSecret writes the parameter to AWS Parameter Store as a SecureString.
+func (v *Vault) storeAWSParameterStoreSecret(ctx context.Context, paramName, value string) error {
+	if v.awsClient == nil || v.awsClient.GetSSMClient() == nil {
+		return errors.New("AWS Parameter Store (SSM) client not initialized")
+	}
+	if err := v.awsClient.PutParameter(ctx, paramName, value, "SecureString", true); err != nil {
+		return fmt.Errorf("failed to store parameter %s in AWS Parameter Store: %w", paramName, err)
+	}
+	return nil
+}
+
+// retrieveMemorySecret returns the secret of key from the secrets of NewMemoryVault.
+func (v *Vault) retrieveMemorySecret(key string) (string, error) {
+	for _, prefix := range []string{SecretsManagerPrefix, ParameterStorePrefix, InfisicalPrefix, AWSKMSPrefix} {
+		key = strings.TrimPrefix(key, prefix)
+	}
+	value, ok := v.memorySecrets[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in memory vault", key)
+	}
+	return value, nil
+}
+
 func (v *Vault) DecryptVaultValues(key, value string) (string, error) {
 	if strings.Contains(key, EncryptedPrefix) {
 		if v.cryptoManager == nil {
@@ -0,0 +1,87 @@
+// Package verification holds what the bank account and KYC verifiers share: the normalized
+// status of a verification and the matching of the holder name returned by the issuer against
+// the expected name.
+package verification
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultNameMatchThreshold is the name similarity below which a verification is a mismatch.
+const DefaultNameMatchThreshold = 0.8
+
+// Status is the normalized outcome of a verification. The verifiers document what each status
+// means for what they verify.
+type Status string
+
+const (
+	StatusVerified Status = "verified"
+	StatusNotFound Status = "not_found"
+	StatusInactive Status = "inactive"
+	StatusPending  Status = "pending"
+	StatusFailed   Status = "failed"
+)
+
+// MatchName scores the holder name returned by the issuer against the expected name and
+// reports whether the score reaches threshold.
+func MatchName(expected, actual string, threshold float64) (float64, bool) {
+	score := NameMatchScore(expected, actual)
+	return score, score >= threshold
+}
+
+// honorifics are dropped before names are compared.
+var honorifics = map[string]bool{
+	"MR": true, "MRS": true, "MS": true, "MISS": true, "DR": true, "SHRI": true, "SMT": true,
+	"KUM": true, "SRI": true, "M/S": true, "MESSRS": true,
+}
+
+// NameMatchScore returns the similarity of two names from 0 to 1, ignoring case, punctuation,
+// honorifics and word order, and matching initials against whole words.
+func NameMatchScore(a, b string) float64 {
+	left, right := nameTokens(a), nameTokens(b)
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+	used := make([]bool, len(right))
+	matched := 0.0
+	for _, token := range left {
+		best, bestScore := -1, 0.0
+		for i, other := range right {
+			if used[i] {
+				continue
+			}
+			score := 0.0
+			switch {
+			case token == other:
+				score = 1
+			case len(token) == 1 && strings.HasPrefix(other, token),
+				len(other) == 1 && strings.HasPrefix(token, other):
+				score = 0.75
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best >= 0 {
+			used[best] = true
+			matched += bestScore
+		}
+	}
+	return matched / float64(max(len(left), len(right)))
+}
+
+// nameTokens splits a name into upper-case words without honorifics.
+func nameTokens(name string) []string {
+	fields := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '/'
+	})
+	tokens := fields[:0]
+	for _, field := range fields {
+		field = strings.Trim(field, "/")
+		if field != "" && !honorifics[field] {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
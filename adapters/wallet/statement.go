@@ -0,0 +1,65 @@
+package wallet
+
+import (
+	"context"
+	"time"
+)
+
+// Statement lists the entries of a wallet over a period with its opening and closing balances.
+type Statement struct {
+	WalletID string    `json:"wallet_id"`
+	OwnerID  string    `json:"owner_id"`
+	Currency string    `json:"currency"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Opening  Balance   `json:"opening"`
+	Closing  Balance   `json:"closing"`
+	// Credited, Debited and Expired total the amounts credited, spent by debits and captures,
+	// and lost to expiry over the period.
+	Credited int64   `json:"credited"`
+	Debited  int64   `json:"debited"`
+	Expired  int64   `json:"expired"`
+	Entries  []Entry `json:"entries"`
+}
+
+// Statement returns the statement of a wallet for [from, to).
+func (m *Manager) Statement(ctx context.Context, walletID string, from, to time.Time) (*Statement, error) {
+	state, err := m.store.Load(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := m.store.Entries(ctx, walletID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	statement := &Statement{
+		WalletID: walletID,
+		OwnerID:  state.Wallet.OwnerID,
+		Currency: state.Wallet.Currency,
+		From:     from,
+		To:       to,
+		Entries:  entries,
+	}
+	if !from.IsZero() {
+		previous, err := m.store.LastEntryBefore(ctx, walletID, from)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			statement.Opening = previous.BalanceAfter
+		}
+	}
+	statement.Closing = statement.Opening
+	for _, entry := range entries {
+		switch entry.Type {
+		case EntryCredit:
+			statement.Credited += entry.Amount
+		case EntryDebit, EntryCapture:
+			statement.Debited += entry.Amount
+		case EntryExpire:
+			statement.Expired += entry.Amount
+		}
+		statement.Closing = entry.BalanceAfter
+	}
+	return statement, nil
+}
@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the mutable state of a wallet, loaded and saved atomically by a Store.
+type State struct {
+	Wallet Wallet
+	// Lots are the unspent promotional credits, soonest expiring first.
+	Lots  []Lot
+	Holds map[string]*Hold
+	// Keys maps the idempotency keys of the recent entries of the wallet to the entry they
+	// created; the Manager forgets them after its idempotency TTL.
+	Keys map[string]Entry
+}
+
+// clone deep-copies the state so a failed update leaves the stored state untouched.
+func (s *State) clone() *State {
+	c := &State{
+		Wallet: s.Wallet,
+		Lots:   append([]Lot(nil), s.Lots...),
+		Holds:  make(map[string]*Hold, len(s.Holds)),
+		Keys:   make(map[string]Entry, len(s.Keys)),
+	}
+	for id, hold := range s.Holds {
+		h := *hold
+		h.Lots = append([]HeldLot(nil), hold.Lots...)
+		c.Holds[id] = &h
+	}
+	for key, entry := range s.Keys {
+		c.Keys[key] = entry
+	}
+	return c
+}
+
+// UpdateFunc mutates the state of a wallet and returns the entries recording the change.
+type UpdateFunc func(state *State) ([]Entry, error)
+
+// Ledger is the append-only journal of the entries of wallets, which statements are built
+// from. Entries are only ever appended by Store.Update, in the same transaction as the state
+// they produce.
+type Ledger interface {
+	// Entries returns the entries of a wallet created in [from, to), oldest first. A zero
+	// bound is open.
+	Entries(ctx context.Context, walletID string, from, to time.Time) ([]Entry, error)
+	// LastEntryBefore returns the latest entry of a wallet created before t, or nil.
+	LastEntryBefore(ctx context.Context, walletID string, t time.Time) (*Entry, error)
+}
+
+// Store persists wallets and their Ledger. Update must be atomic per wallet: a SQL store runs
+// it in a transaction locking the wallet row, so concurrent debits cannot both pass the
+// balance check.
+type Store interface {
+	Ledger
+
+	// Create stores a new wallet.
+	Create(ctx context.Context, wallet *Wallet) error
+	// Find returns the wallet of owner in currency, or ErrWalletNotFound.
+	Find(ctx context.Context, ownerID, currency string) (*Wallet, error)
+	// Load returns the state of a wallet, or ErrWalletNotFound.
+	Load(ctx context.Context, walletID string) (*State, error)
+	// Update applies fn to the state of a wallet and, when it succeeds, saves the state and
+	// appends the entries it returned.
+	Update(ctx context.Context, walletID string, fn UpdateFunc) ([]Entry, error)
+}
+
+// MemoryStore is a Store for a single replica, e.g. in tests.
+type MemoryStore struct {
+	mu      sync.Mutex
+	states  map[string]*State
+	owners  map[string]string
+	entries map[string][]Entry
+}
+
+// NewMemoryStore creates a MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		states:  make(map[string]*State),
+		owners:  make(map[string]string),
+		entries: make(map[string][]Entry),
+	}
+}
+
+func ownerKey(ownerID, currency string) string {
+	return ownerID + "/" + currency
+}
+
+func (s *MemoryStore) Create(_ context.Context, wallet *Wallet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := ownerKey(wallet.OwnerID, wallet.Currency)
+	if _, ok := s.owners[key]; ok {
+		return ErrWalletExists
+	}
+	s.owners[key] = wallet.ID
+	s.states[wallet.ID] = &State{Wallet: *wallet, Holds: make(map[string]*Hold), Keys: make(map[string]Entry)}
+	return nil
+}
+
+func (s *MemoryStore) Find(_ context.Context, ownerID, currency string) (*Wallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.owners[ownerKey(ownerID, currency)]
+	if !ok {
+		return nil, ErrWalletNotFound
+	}
+	wallet := s.states[id].Wallet
+	return &wallet, nil
+}
+
+func (s *MemoryStore) Load(_ context.Context, walletID string) (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[walletID]
+	if !ok {
+		return nil, ErrWalletNotFound
+	}
+	return state.clone(), nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, walletID string, fn UpdateFunc) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.states[walletID]
+	if !ok {
+		return nil, ErrWalletNotFound
+	}
+	state := stored.clone()
+	entries, err := fn(state)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IdempotencyKey != "" {
+			state.Keys[entry.IdempotencyKey] = entry
+		}
+	}
+	s.states[walletID] = state
+	s.entries[walletID] = append(s.entries[walletID], entries...)
+	return entries, nil
+}
+
+func (s *MemoryStore) Entries(_ context.Context, walletID string, from, to time.Time) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []Entry
+	for _, entry := range s.entries[walletID] {
+		if (!from.IsZero() && entry.CreatedAt.Before(from)) || (!to.IsZero() && !entry.CreatedAt.Before(to)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+func (s *MemoryStore) LastEntryBefore(_ context.Context, walletID string, t time.Time) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var last *Entry
+	for i, entry := range s.entries[walletID] {
+		if entry.CreatedAt.Before(t) && (last == nil || !entry.CreatedAt.Before(last.CreatedAt)) {
+			last = &s.entries[walletID][i]
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	entry := *last
+	return &entry, nil
+}
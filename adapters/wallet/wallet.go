@@ -0,0 +1,676 @@
+// Package wallet keeps prepaid balances and credits. Every change to a wallet is an entry of
+// its append-only Ledger, recording the amounts moved and the balance after it, so balances can
+// be audited and statements rebuilt from the entries. The tree has no shared ledger package, so
+// the Ledger is kept by the Store of the wallets, in the transaction changing their state.
+//
+// A wallet holds cash, and promotional credits that expire. Debits spend the promotional
+// credits expiring first before the cash. Holds reserve an amount for a pending purchase,
+// reducing the available balance until they are captured, released or expire.
+//
+//	manager := wallet.NewManager(store, wallet.WithBroker(broker, ""))
+//	w, err := manager.Open(ctx, userID, "INR")
+//	_, err = manager.Credit(ctx, wallet.CreditRequest{WalletID: w.ID, Amount: 50000, Reference: paymentID})
+//	hold, err := manager.Hold(ctx, wallet.HoldRequest{WalletID: w.ID, Amount: 12000, TTL: 15 * time.Minute})
+//	_, err = manager.Capture(ctx, w.ID, hold.ID, 12000)
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/clock"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+)
+
+// DefaultEventSubject prefixes the subjects of the wallet events; the event of an entry is
+// published on "<subject>.<type>", e.g. "neuron.wallet.debit".
+const DefaultEventSubject = "neuron.wallet"
+
+// DefaultIdempotencyTTL is how long the idempotency keys of a wallet are remembered.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// These are the errors of the Manager.
+var (
+	ErrWalletNotFound    = errors.New("wallet: wallet not found")
+	ErrWalletExists      = errors.New("wallet: wallet already exists")
+	ErrInvalidAmount     = errors.New("wallet: amount must be positive")
+	ErrInsufficientFunds = errors.New("wallet: insufficient funds")
+	ErrHoldNotFound      = errors.New("wallet: hold not found")
+	ErrHoldNotActive     = errors.New("wallet: hold is not active")
+	ErrCaptureExceedHold = errors.New("wallet: capture exceeds the held amount")
+)
+
+// CreditKind is the kind of money credited to a wallet.
+type CreditKind string
+
+const (
+	// KindCash is money paid by the owner; it never expires.
+	KindCash CreditKind = "cash"
+	// KindPromotional is credit granted by the business, e.g. a cashback; it may expire.
+	KindPromotional CreditKind = "promotional"
+)
+
+// EntryType is the kind of change recorded by an entry.
+type EntryType string
+
+const (
+	EntryCredit  EntryType = "credit"
+	EntryDebit   EntryType = "debit"
+	EntryHold    EntryType = "hold"
+	EntryRelease EntryType = "release"
+	EntryCapture EntryType = "capture"
+	// EntryExpire records promotional credits lost to their expiry.
+	EntryExpire EntryType = "expire"
+)
+
+// HoldStatus is the state of a hold.
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+	HoldExpired  HoldStatus = "expired"
+)
+
+// Wallet is the balance of an owner in a currency. Amounts are in minor units, e.g. paise.
+type Wallet struct {
+	ID       string `json:"id"`
+	OwnerID  string `json:"owner_id"`
+	Currency string `json:"currency"`
+	Cash     int64  `json:"cash"`
+	// Promotional is the unspent and unexpired promotional credit.
+	Promotional int64 `json:"promotional"`
+	// Held is the amount reserved by the active holds.
+	Held      int64     `json:"held"`
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Balance is the balance of a wallet at a point in time.
+type Balance struct {
+	Cash        int64 `json:"cash"`
+	Promotional int64 `json:"promotional"`
+	Held        int64 `json:"held"`
+	// Available is what can be debited or held: the total less the held amount.
+	Available int64 `json:"available"`
+}
+
+// Balance returns the balance of w.
+func (w *Wallet) Balance() Balance {
+	return Balance{
+		Cash:        w.Cash,
+		Promotional: w.Promotional,
+		Held:        w.Held,
+		Available:   w.Cash + w.Promotional - w.Held,
+	}
+}
+
+// Lot is an unspent promotional credit.
+type Lot struct {
+	EntryID string `json:"entry_id"`
+	Amount  int64  `json:"amount"`
+	// Held is the part of the lot reserved by active holds. It is neither spent by debits
+	// nor lost to expiry until the holds end.
+	Held int64 `json:"held,omitempty"`
+	// ExpiresAt is zero for credits that do not expire.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Hold reserves an amount of a wallet.
+type Hold struct {
+	ID        string     `json:"id"`
+	WalletID  string     `json:"wallet_id"`
+	Amount    int64      `json:"amount"`
+	Status    HoldStatus `json:"status"`
+	Reference string     `json:"reference,omitempty"`
+	// Lots are the promotional credits backing the hold; the rest of its amount is cash.
+	Lots      []HeldLot `json:"lots,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HeldLot is the part of a promotional lot reserved by a hold.
+type HeldLot struct {
+	EntryID string `json:"entry_id"`
+	Amount  int64  `json:"amount"`
+}
+
+// Entry is a change to a wallet. Cash, Promotional and Held are the signed amounts moved.
+type Entry struct {
+	ID             string    `json:"id"`
+	WalletID       string    `json:"wallet_id"`
+	Type           EntryType `json:"type"`
+	Amount         int64     `json:"amount"`
+	Cash           int64     `json:"cash"`
+	Promotional    int64     `json:"promotional"`
+	Held           int64     `json:"held"`
+	BalanceAfter   Balance   `json:"balance_after"`
+	HoldID         string    `json:"hold_id,omitempty"`
+	Reference      string    `json:"reference,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	// ExpiresAt is the expiry of a promotional credit.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Event is published on the broker for every entry.
+type Event struct {
+	WalletID string `json:"wallet_id"`
+	OwnerID  string `json:"owner_id"`
+	Currency string `json:"currency"`
+	Entry    Entry  `json:"entry"`
+}
+
+// Manager moves money in and out of the wallets of a Store.
+type Manager struct {
+	store   Store
+	broker  events.Broker
+	subject string
+	logger  *log.Log
+	clock   clock.Clock
+	keyTTL  time.Duration
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithBroker publishes an Event for every entry on broker, on "<subject>.<type>". subject
+// defaults to DefaultEventSubject.
+func WithBroker(broker events.Broker, subject string) Option {
+	return func(m *Manager) {
+		m.broker = broker
+		if subject != "" {
+			m.subject = subject
+		}
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// WithClock sets the clock deciding expiries, e.g. a clock.Fake in tests. Defaults to
+// clock.System.
+func WithClock(c clock.Clock) Option {
+	return func(m *Manager) {
+		m.clock = clock.OrSystem(c)
+	}
+}
+
+// WithIdempotencyTTL sets how long idempotency keys are remembered; retries after it create
+// new entries. Defaults to DefaultIdempotencyTTL.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(m *Manager) {
+		if ttl > 0 {
+			m.keyTTL = ttl
+		}
+	}
+}
+
+// NewManager creates a Manager keeping the wallets in store.
+func NewManager(store Store, options ...Option) *Manager {
+	m := &Manager{store: store, subject: DefaultEventSubject, clock: clock.System, keyTTL: DefaultIdempotencyTTL}
+	for _, opt := range options {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return m
+}
+
+// Open returns the wallet of owner in currency, creating it when missing.
+func (m *Manager) Open(ctx context.Context, ownerID, currency string) (*Wallet, error) {
+	existing, err := m.store.Find(ctx, ownerID, currency)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrWalletNotFound) {
+		return nil, err
+	}
+	now := m.clock.Now()
+	w := &Wallet{
+		ID:        random.GenerateUUIDString(),
+		OwnerID:   ownerID,
+		Currency:  currency,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := m.store.Create(ctx, w); err != nil {
+		if errors.Is(err, ErrWalletExists) {
+			return m.store.Find(ctx, ownerID, currency)
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// Get returns a wallet, with its expired credits and holds applied.
+func (m *Manager) Get(ctx context.Context, walletID string) (*Wallet, error) {
+	if _, err := m.ExpireCredits(ctx, walletID); err != nil {
+		return nil, err
+	}
+	state, err := m.store.Load(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return &state.Wallet, nil
+}
+
+// Balance returns the current balance of a wallet.
+func (m *Manager) Balance(ctx context.Context, walletID string) (Balance, error) {
+	w, err := m.Get(ctx, walletID)
+	if err != nil {
+		return Balance{}, err
+	}
+	return w.Balance(), nil
+}
+
+// CreditRequest adds money to a wallet.
+type CreditRequest struct {
+	WalletID string
+	Amount   int64
+	// Kind defaults to KindCash.
+	Kind CreditKind
+	// ExpiresAt is when promotional credit expires; zero never expires.
+	ExpiresAt   time.Time
+	Reference   string
+	Description string
+	// IdempotencyKey makes retries return the entry of the first attempt.
+	IdempotencyKey string
+}
+
+// Credit adds money to a wallet.
+func (m *Manager) Credit(ctx context.Context, req CreditRequest) (*Entry, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if req.Kind == "" {
+		req.Kind = KindCash
+	}
+	return m.apply(ctx, req.WalletID, req.IdempotencyKey, func(state *State, now time.Time) ([]Entry, error) {
+		entry := Entry{Type: EntryCredit, Amount: req.Amount, Reference: req.Reference, Description: req.Description}
+		if req.Kind == KindPromotional {
+			entry.Promotional, entry.ExpiresAt = req.Amount, req.ExpiresAt
+		} else {
+			entry.Cash = req.Amount
+		}
+		entry = m.record(state, entry, now)
+		if req.Kind == KindPromotional {
+			state.Lots = append(state.Lots, Lot{EntryID: entry.ID, Amount: req.Amount, ExpiresAt: req.ExpiresAt})
+			sortLots(state.Lots)
+		}
+		return []Entry{entry}, nil
+	})
+}
+
+// DebitRequest takes money from a wallet.
+type DebitRequest struct {
+	WalletID string
+	Amount   int64
+	// CashOnly spends cash only, e.g. for withdrawals, leaving the promotional credit.
+	CashOnly       bool
+	Reference      string
+	Description    string
+	IdempotencyKey string
+}
+
+// Debit takes money from the available balance of a wallet, spending the promotional credit
+// expiring first before the cash. It fails with ErrInsufficientFunds without changing the
+// wallet when the available balance is short.
+func (m *Manager) Debit(ctx context.Context, req DebitRequest) (*Entry, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return m.apply(ctx, req.WalletID, req.IdempotencyKey, func(state *State, now time.Time) ([]Entry, error) {
+		available := state.Wallet.Balance().Available
+		if req.CashOnly {
+			available = min(available, state.Wallet.Cash-heldCash(state))
+		}
+		if available < req.Amount {
+			return nil, ErrInsufficientFunds
+		}
+		entry := Entry{Type: EntryDebit, Amount: req.Amount, Reference: req.Reference, Description: req.Description}
+		entry.Cash, entry.Promotional = spend(state, req.Amount, req.CashOnly)
+		return []Entry{m.record(state, entry, now)}, nil
+	})
+}
+
+// HoldRequest reserves an amount of a wallet.
+type HoldRequest struct {
+	WalletID string
+	Amount   int64
+	// TTL is how long the hold lasts before it is released.
+	TTL            time.Duration
+	Reference      string
+	IdempotencyKey string
+}
+
+// Hold reserves an amount of the available balance of a wallet until it is captured, released
+// or expires.
+func (m *Manager) Hold(ctx context.Context, req HoldRequest) (*Hold, error) {
+	if req.Amount <= 0 || req.TTL <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	var hold *Hold
+	entry, err := m.apply(ctx, req.WalletID, req.IdempotencyKey, func(state *State, now time.Time) ([]Entry, error) {
+		if state.Wallet.Balance().Available < req.Amount {
+			return nil, ErrInsufficientFunds
+		}
+		hold = &Hold{
+			ID:        random.GenerateUUIDString(),
+			WalletID:  req.WalletID,
+			Amount:    req.Amount,
+			Status:    HoldActive,
+			Reference: req.Reference,
+			Lots:      pin(state, req.Amount),
+			ExpiresAt: now.Add(req.TTL),
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		state.Holds[hold.ID] = hold
+		entry := Entry{Type: EntryHold, Amount: req.Amount, Held: req.Amount, HoldID: hold.ID, Reference: req.Reference}
+		return []Entry{m.record(state, entry, now)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		// A retry: return the hold created by the first attempt.
+		return m.hold(ctx, req.WalletID, entry.HoldID)
+	}
+	return hold, nil
+}
+
+// Capture debits amount of an active hold, at most its amount, and releases the rest.
+func (m *Manager) Capture(ctx context.Context, walletID, holdID string, amount int64) (*Entry, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return m.apply(ctx, walletID, "", func(state *State, now time.Time) ([]Entry, error) {
+		hold, err := activeHold(state, holdID)
+		if err != nil {
+			return nil, err
+		}
+		if amount > hold.Amount {
+			return nil, ErrCaptureExceedHold
+		}
+		hold.Status, hold.UpdatedAt = HoldCaptured, now
+		entry := Entry{Type: EntryCapture, Amount: amount, Held: -hold.Amount, HoldID: hold.ID, Reference: hold.Reference}
+		entry.Cash, entry.Promotional = spendHold(state, hold, amount)
+		if state.Wallet.Cash+entry.Cash < 0 {
+			return nil, ErrInsufficientFunds
+		}
+		return []Entry{m.record(state, entry, now)}, nil
+	})
+}
+
+// Release cancels an active hold, making its amount available again.
+func (m *Manager) Release(ctx context.Context, walletID, holdID string) (*Entry, error) {
+	return m.apply(ctx, walletID, "", func(state *State, now time.Time) ([]Entry, error) {
+		hold, err := activeHold(state, holdID)
+		if err != nil {
+			return nil, err
+		}
+		return []Entry{m.release(state, hold, HoldReleased, now)}, nil
+	})
+}
+
+// ExpireCredits expires the promotional credits and holds of a wallet past their expiry and
+// returns the entries recording it. The other operations expire them first as well, so
+// balances never include expired credit; run it periodically to publish the expiries.
+func (m *Manager) ExpireCredits(ctx context.Context, walletID string) ([]Entry, error) {
+	entries, err := m.store.Update(ctx, walletID, func(state *State) ([]Entry, error) {
+		return m.expire(state, m.clock.Now()), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.publish(ctx, entries)
+	return entries, nil
+}
+
+// applyFunc changes a wallet whose expired credits and holds are already applied.
+type applyFunc func(state *State, now time.Time) ([]Entry, error)
+
+// apply runs fn in a store update after expiring the credits and holds of the wallet, and
+// publishes the entries. A known idempotency key returns the entry it created instead.
+func (m *Manager) apply(ctx context.Context, walletID, idempotencyKey string, fn applyFunc) (*Entry, error) {
+	var replayed *Entry
+	entries, err := m.store.Update(ctx, walletID, func(state *State) ([]Entry, error) {
+		now := m.clock.Now()
+		for key, entry := range state.Keys {
+			if now.Sub(entry.CreatedAt) > m.keyTTL {
+				delete(state.Keys, key)
+			}
+		}
+		if entry, ok := state.Keys[idempotencyKey]; ok && idempotencyKey != "" {
+			replayed = &entry
+			return nil, nil
+		}
+		expired := m.expire(state, now)
+		changed, err := fn(state, now)
+		if err != nil {
+			return nil, err
+		}
+		if idempotencyKey != "" && len(changed) > 0 {
+			changed[len(changed)-1].IdempotencyKey = idempotencyKey
+		}
+		return append(expired, changed...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if replayed != nil {
+		return replayed, nil
+	}
+	m.publish(ctx, entries)
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("wallet: no entry recorded for %s", walletID)
+	}
+	return &entries[len(entries)-1], nil
+}
+
+// expire records the expiry of the holds and lots of state past now. Holds go first, so the
+// lots they reserved expire with them; the reserved part of a lot outlives its expiry until
+// its holds end.
+func (m *Manager) expire(state *State, now time.Time) []Entry {
+	var entries []Entry
+	ids := make([]string, 0, len(state.Holds))
+	for id := range state.Holds {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		if hold := state.Holds[id]; hold.Status == HoldActive && !now.Before(hold.ExpiresAt) {
+			entries = append(entries, m.release(state, hold, HoldExpired, now))
+		}
+	}
+
+	lots := state.Lots[:0]
+	for _, lot := range state.Lots {
+		if lot.ExpiresAt.IsZero() || now.Before(lot.ExpiresAt) || lot.Amount == lot.Held {
+			lots = append(lots, lot)
+			continue
+		}
+		lost := lot.Amount - lot.Held
+		entry := Entry{Type: EntryExpire, Amount: lost, Promotional: -lost, Reference: lot.EntryID, ExpiresAt: lot.ExpiresAt}
+		entries = append(entries, m.record(state, entry, now))
+		if lot.Amount = lot.Held; lot.Amount > 0 {
+			lots = append(lots, lot)
+		}
+	}
+	state.Lots = lots
+	return entries
+}
+
+// release ends an active hold with status, freeing its lots, and records it.
+func (m *Manager) release(state *State, hold *Hold, status HoldStatus, now time.Time) Entry {
+	unpin(state, hold.Lots)
+	hold.Status, hold.UpdatedAt = status, now
+	entry := Entry{Type: EntryRelease, Amount: hold.Amount, Held: -hold.Amount, HoldID: hold.ID, Reference: hold.Reference}
+	if status == HoldExpired {
+		entry.Description = "hold expired"
+	}
+	return m.record(state, entry, now)
+}
+
+// record applies the amounts of entry to the wallet of state and completes the entry.
+func (m *Manager) record(state *State, entry Entry, now time.Time) Entry {
+	w := &state.Wallet
+	w.Cash += entry.Cash
+	w.Promotional += entry.Promotional
+	w.Held += entry.Held
+	w.Version++
+	w.UpdatedAt = now
+	entry.ID = random.GenerateUUIDString()
+	entry.WalletID = w.ID
+	entry.BalanceAfter = w.Balance()
+	entry.CreatedAt = now
+	return entry
+}
+
+// spend takes amount from the unheld part of the promotional lots expiring first, unless
+// cashOnly, then from the cash, and returns the signed cash and promotional amounts to record.
+func spend(state *State, amount int64, cashOnly bool) (cash, promotional int64) {
+	remaining := amount
+	if !cashOnly {
+		lots := state.Lots[:0]
+		for _, lot := range state.Lots {
+			used := min(lot.Amount-lot.Held, remaining)
+			lot.Amount -= used
+			remaining -= used
+			promotional -= used
+			if lot.Amount > 0 {
+				lots = append(lots, lot)
+			}
+		}
+		state.Lots = lots
+	}
+	return -remaining, promotional
+}
+
+// spendHold takes amount of a hold from the lots it reserved, then from the cash, frees the
+// rest of its lots and returns the signed cash and promotional amounts to record.
+func spendHold(state *State, hold *Hold, amount int64) (cash, promotional int64) {
+	unpin(state, hold.Lots)
+	remaining := amount
+	for _, held := range hold.Lots {
+		i := slices.IndexFunc(state.Lots, func(lot Lot) bool { return lot.EntryID == held.EntryID })
+		if i < 0 {
+			continue
+		}
+		used := min(held.Amount, remaining)
+		state.Lots[i].Amount -= used
+		remaining -= used
+		promotional -= used
+	}
+	state.Lots = slices.DeleteFunc(state.Lots, func(lot Lot) bool { return lot.Amount == 0 })
+	return -remaining, promotional
+}
+
+// pin reserves up to amount of the unheld promotional credit, expiring first, for a hold and
+// returns the lots reserved.
+func pin(state *State, amount int64) []HeldLot {
+	var held []HeldLot
+	for i := range state.Lots {
+		lot := &state.Lots[i]
+		used := min(lot.Amount-lot.Held, amount)
+		if used <= 0 {
+			continue
+		}
+		lot.Held += used
+		amount -= used
+		held = append(held, HeldLot{EntryID: lot.EntryID, Amount: used})
+	}
+	return held
+}
+
+// unpin frees the lots reserved by a hold.
+func unpin(state *State, held []HeldLot) {
+	for _, h := range held {
+		if i := slices.IndexFunc(state.Lots, func(lot Lot) bool { return lot.EntryID == h.EntryID }); i >= 0 {
+			state.Lots[i].Held -= h.Amount
+		}
+	}
+}
+
+// heldCash is the cash reserved by the active holds, the part of the held amount not backed by
+// promotional lots.
+func heldCash(state *State) int64 {
+	held := state.Wallet.Held
+	for _, lot := range state.Lots {
+		held -= lot.Held
+	}
+	return held
+}
+
+// sortLots orders lots soonest expiring first, the ones not expiring last.
+func sortLots(lots []Lot) {
+	slices.SortStableFunc(lots, func(a, b Lot) int {
+		switch {
+		case a.ExpiresAt.Equal(b.ExpiresAt):
+			return 0
+		case a.ExpiresAt.IsZero():
+			return 1
+		case b.ExpiresAt.IsZero():
+			return -1
+		}
+		return a.ExpiresAt.Compare(b.ExpiresAt)
+	})
+}
+
+// activeHold returns the active hold id of state.
+func activeHold(state *State, id string) (*Hold, error) {
+	hold, ok := state.Holds[id]
+	if !ok {
+		return nil, ErrHoldNotFound
+	}
+	if hold.Status != HoldActive {
+		return nil, ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+// hold returns a hold of a wallet.
+func (m *Manager) hold(ctx context.Context, walletID, holdID string) (*Hold, error) {
+	state, err := m.store.Load(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	hold, ok := state.Holds[holdID]
+	if !ok {
+		return nil, ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// publish publishes the events of entries, logging failures: the entries are already stored.
+func (m *Manager) publish(ctx context.Context, entries []Entry) {
+	if m.broker == nil || len(entries) == 0 {
+		return
+	}
+	state, err := m.store.Load(ctx, entries[0].WalletID)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		event := Event{WalletID: entry.WalletID, OwnerID: state.Wallet.OwnerID, Currency: state.Wallet.Currency, Entry: entry}
+		if b := m.broker.Publish(ctx, m.subject+"."+string(entry.Type), event); b != nil {
+			m.logger.Error("Failed to publish wallet event", log.String("wallet_id", entry.WalletID), log.String("entry_id", entry.ID), log.Err(b))
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package webauthn adds passkey (WebAuthn) registration and login to services built on
+// neuron. Ceremony challenges are kept in the session of the client through a
+// session.SessionManager, and credentials are persisted through a CredentialStore provided by
+// the service.
+//
+// A ceremony spans two requests of the same session: Begin* returns the options passed to
+// navigator.credentials.create or navigator.credentials.get in the browser, and Finish*
+// verifies the browser's response against the challenge stored by Begin*. Passwordless login
+// needs a session before the user is known, so create an unauthenticated session first.
+package webauthn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/adapters/session"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/go-webauthn/webauthn/protocol"
+	gowebauthn "github.com/go-webauthn/webauthn/webauthn"
+)
+
+// Session keys holding the challenge of a pending ceremony.
+const (
+	RegistrationSessionKey = "webauthn_registration"
+	LoginSessionKey        = "webauthn_login"
+)
+
+var (
+	// ErrNoCeremony is returned by Finish* when the session has no pending ceremony.
+	ErrNoCeremony = errors.New("webauthn: no pending ceremony in session")
+	// ErrCredentialCloned is returned when the signature counter of a credential went
+	// backwards, which indicates a cloned authenticator.
+	ErrCredentialCloned = errors.New("webauthn: authenticator may be cloned")
+)
+
+// User is an account that can own passkeys.
+type User interface {
+	// WebAuthnID returns the user handle, an opaque id of at most 64 bytes. It must not
+	// contain personal data such as the email.
+	WebAuthnID() []byte
+	// WebAuthnName returns the account name shown by the authenticator, e.g. the email.
+	WebAuthnName() string
+	// WebAuthnDisplayName returns the name of the user shown by the authenticator.
+	WebAuthnDisplayName() string
+}
+
+// CredentialStore persists the passkeys of users.
+type CredentialStore interface {
+	// Credentials returns the passkeys of the user with the given handle.
+	Credentials(ctx context.Context, userID []byte) ([]gowebauthn.Credential, error)
+	// SaveCredential stores a newly registered passkey.
+	SaveCredential(ctx context.Context, userID []byte, credential gowebauthn.Credential) error
+	// UpdateCredential stores the signature counter and flags of a passkey after a login.
+	UpdateCredential(ctx context.Context, userID []byte, credential gowebauthn.Credential) error
+}
+
+// UserLoader returns the user owning a passkey from its user handle, for passwordless login.
+type UserLoader func(ctx context.Context, userHandle []byte) (User, error)
+
+// Manager runs WebAuthn ceremonies.
+type Manager struct {
+	webauthn    *gowebauthn.WebAuthn
+	config      *gowebauthn.Config
+	sessions    *session.SessionManager
+	credentials CredentialStore
+	residentKey protocol.ResidentKeyRequirement
+	verify      protocol.UserVerificationRequirement
+	logger      *log.Log
+	loggerSet   bool
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithRelyingParty sets the relying party id, usually the domain without scheme and port,
+// its display name and the origins the browser may run the ceremony from.
+func WithRelyingParty(id, displayName string, origins ...string) Option {
+	return func(m *Manager) {
+		m.config.RPID = id
+		m.config.RPDisplayName = displayName
+		m.config.RPOrigins = origins
+	}
+}
+
+// WithTimeout sets how long the browser waits for the user during a ceremony. Defaults to 5
+// minutes.
+func WithTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.config.Timeouts.Registration = gowebauthn.TimeoutConfig{Enforce: true, Timeout: timeout, TimeoutUVD: timeout}
+		m.config.Timeouts.Login = gowebauthn.TimeoutConfig{Enforce: true, Timeout: timeout, TimeoutUVD: timeout}
+	}
+}
+
+// WithResidentKey sets whether passkeys must be discoverable, which passwordless login
+// requires. Defaults to protocol.ResidentKeyRequirementRequired.
+func WithResidentKey(requirement protocol.ResidentKeyRequirement) Option {
+	return func(m *Manager) {
+		m.residentKey = requirement
+	}
+}
+
+// WithUserVerification sets whether the authenticator must verify the user, e.g. with a
+// fingerprint or PIN. Defaults to protocol.VerificationPreferred.
+func WithUserVerification(requirement protocol.UserVerificationRequirement) Option {
+	return func(m *Manager) {
+		m.verify = requirement
+	}
+}
+
+// WithLogger sets the logger of the manager.
+func WithLogger(logger *log.Log) Option {
+	return func(m *Manager) {
+		m.logger = logger
+		m.loggerSet = true
+	}
+}
+
+// NewManager creates a Manager storing challenges in sessions and passkeys in credentials.
+// WithRelyingParty is required.
+func NewManager(sessions *session.SessionManager, credentials CredentialStore, opts ...Option) (*Manager, error) {
+	defaultLog := log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	m := &Manager{
+		config:      &gowebauthn.Config{},
+		sessions:    sessions,
+		credentials: credentials,
+		residentKey: protocol.ResidentKeyRequirementRequired,
+		verify:      protocol.VerificationPreferred,
+		logger:      defaultLog,
+	}
+	WithTimeout(5 * time.Minute)(m)
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.loggerSet {
+		_ = defaultLog.Sync()
+	}
+
+	webauthn, err := gowebauthn.New(m.config)
+	if err != nil {
+		return nil, err
+	}
+	m.webauthn = webauthn
+	return m, nil
+}
+
+// BeginRegistration starts registering a passkey for user, who is typically logged in. The
+// passkeys user already owns are excluded so the same authenticator is not registered twice.
+func (m *Manager) BeginRegistration(ctx context.Context, sessionID string, user User) (*protocol.CredentialCreation, error) {
+	account, err := m.account(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(account.credentials))
+	for _, credential := range account.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, data, err := m.webauthn.BeginRegistration(account,
+		gowebauthn.WithExclusions(exclusions),
+		gowebauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:      m.residentKey,
+			UserVerification: m.verify,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.saveCeremony(ctx, sessionID, RegistrationSessionKey, data); err != nil {
+		return nil, err
+	}
+	return creation, nil
+}
+
+// FinishRegistration verifies the browser's response to BeginRegistration, read from the body
+// of r, and stores the new passkey.
+func (m *Manager) FinishRegistration(ctx context.Context, sessionID string, user User, r *http.Request) (*gowebauthn.Credential, error) {
+	data, err := m.takeCeremony(ctx, sessionID, RegistrationSessionKey)
+	if err != nil {
+		return nil, err
+	}
+	account, err := m.account(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := m.webauthn.FinishRegistration(account, *data, r)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.credentials.SaveCredential(ctx, user.WebAuthnID(), *credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// BeginLogin starts a login of user with one of their passkeys, e.g. as a second factor
+// after the username was entered.
+func (m *Manager) BeginLogin(ctx context.Context, sessionID string, user User) (*protocol.CredentialAssertion, error) {
+	account, err := m.account(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	assertion, data, err := m.webauthn.BeginLogin(account, gowebauthn.WithUserVerification(m.verify))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.saveCeremony(ctx, sessionID, LoginSessionKey, data); err != nil {
+		return nil, err
+	}
+	return assertion, nil
+}
+
+// FinishLogin verifies the browser's response to BeginLogin, read from the body of r, and
+// returns the passkey used.
+func (m *Manager) FinishLogin(ctx context.Context, sessionID string, user User, r *http.Request) (*gowebauthn.Credential, error) {
+	data, err := m.takeCeremony(ctx, sessionID, LoginSessionKey)
+	if err != nil {
+		return nil, err
+	}
+	account, err := m.account(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := m.webauthn.FinishLogin(account, *data, r)
+	if err != nil {
+		return nil, err
+	}
+	return credential, m.updateCredential(ctx, account.WebAuthnID(), credential)
+}
+
+// BeginPasswordlessLogin starts a login where the browser lets the user pick any of their
+// discoverable passkeys, without a username.
+func (m *Manager) BeginPasswordlessLogin(ctx context.Context, sessionID string) (*protocol.CredentialAssertion, error) {
+	assertion, data, err := m.webauthn.BeginDiscoverableLogin(gowebauthn.WithUserVerification(m.verify))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.saveCeremony(ctx, sessionID, LoginSessionKey, data); err != nil {
+		return nil, err
+	}
+	return assertion, nil
+}
+
+// FinishPasswordlessLogin verifies the browser's response to BeginPasswordlessLogin, read from
+// the body of r, and returns the user owning the passkey, found with load, and the passkey.
+func (m *Manager) FinishPasswordlessLogin(ctx context.Context, sessionID string, load UserLoader, r *http.Request) (User, *gowebauthn.Credential, error) {
+	data, err := m.takeCeremony(ctx, sessionID, LoginSessionKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler := func(_, userHandle []byte) (gowebauthn.User, error) {
+		user, err := load(ctx, userHandle)
+		if err != nil {
+			return nil, err
+		}
+		return m.account(ctx, user)
+	}
+	found, credential, err := m.webauthn.FinishPasskeyLogin(handler, *data, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	account := found.(*account)
+	if err := m.updateCredential(ctx, account.WebAuthnID(), credential); err != nil {
+		return nil, nil, err
+	}
+	return account.User, credential, nil
+}
+
+// updateCredential stores the new signature counter of credential and rejects clones.
+func (m *Manager) updateCredential(ctx context.Context, userID []byte, credential *gowebauthn.Credential) error {
+	if credential.Authenticator.CloneWarning {
+		m.logger.Warn("webauthn authenticator may be cloned", log.Any("credential_id", credential.ID))
+		return ErrCredentialCloned
+	}
+	return m.credentials.UpdateCredential(ctx, userID, *credential)
+}
+
+// account loads the passkeys of user.
+func (m *Manager) account(ctx context.Context, user User) (*account, error) {
+	credentials, err := m.credentials.Credentials(ctx, user.WebAuthnID())
+	if err != nil {
+		return nil, err
+	}
+	return &account{User: user, credentials: credentials}, nil
+}
+
+// saveCeremony stores the challenge of a ceremony in the session, replacing any pending one.
+func (m *Manager) saveCeremony(ctx context.Context, sessionID, key string, data *gowebauthn.SessionData) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return m.sessions.UpdateSessionData(ctx, sessionID, map[string]any{key: string(raw)})
+}
+
+// takeCeremony removes and returns the challenge of a ceremony from the session, so that a
+// challenge can only be answered once.
+func (m *Manager) takeCeremony(ctx context.Context, sessionID, key string) (*gowebauthn.SessionData, error) {
+	value, ok, err := m.sessions.TakeSessionValue(ctx, sessionID, key)
+	if err != nil {
+		return nil, err
+	}
+	raw, isString := value.(string)
+	if !ok || !isString {
+		return nil, ErrNoCeremony
+	}
+
+	var data gowebauthn.SessionData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// account adapts a User and its passkeys to the WebAuthn library.
+type account struct {
+	User
+	credentials []gowebauthn.Credential
+}
+
+func (a *account) WebAuthnCredentials() []gowebauthn.Credential {
+	return a.credentials
+}
@@ -0,0 +1,72 @@
+package ws
+
+import (
+	"errors"
+
+	"github.com/abhissng/neuron/adapters/gin/request"
+	"github.com/abhissng/neuron/adapters/paseto"
+	"github.com/abhissng/neuron/adapters/session"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/structures/claims"
+	"github.com/abhissng/neuron/utils/types"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenQueryParam is the query parameter PasetoAuthenticator reads the token from when the
+// request has no Authorization header, as browsers cannot set headers on WebSocket requests.
+const TokenQueryParam = "access_token"
+
+// Identity is who authenticated a connection. Connections of a user join its UserRoom, and
+// its OrgRoom when the user belongs to an org.
+type Identity struct {
+	UserID  string
+	OrgID   string
+	Claims  *claims.StandardClaims
+	Session *session.SessionData
+}
+
+// Authenticator authenticates a request before it is upgraded. The blame it returns is sent
+// as the HTTP response.
+type Authenticator func(c *gin.Context) (*Identity, blame.Blame)
+
+// PasetoAuthenticator authenticates requests carrying a paseto bearer token, in the
+// Authorization header or the access_token query parameter, validated with validators
+// besides paseto.WithValidateEssentialTags.
+func PasetoAuthenticator(manager *paseto.PasetoManager, validators ...paseto.TokenValidator) Authenticator {
+	validators = append([]paseto.TokenValidator{paseto.WithValidateEssentialTags}, validators...)
+	return func(c *gin.Context) (*Identity, blame.Blame) {
+		token := c.Query(TokenQueryParam)
+		if c.GetHeader("Authorization") != "" || token == "" {
+			bearer, err := request.FetchPasetoBearerToken(c).Value()
+			if err != nil {
+				return nil, err
+			}
+			token = *bearer
+		}
+		claim, err := manager.ValidateToken(token, map[string]any{"ip": c.ClientIP()}, validators...).Value()
+		if err != nil {
+			return nil, err
+		}
+		return &Identity{UserID: claim.Subject(), OrgID: claim.OrgID(), Claims: claim}, nil
+	}
+}
+
+// SessionAuthenticator authenticates requests carrying the cookie of a session, validated
+// with validators.
+func SessionAuthenticator(manager *session.SessionManager, validators ...session.SessionValidator) Authenticator {
+	return func(c *gin.Context) (*Identity, blame.Blame) {
+		sessionID, err := manager.SessionIDFromRequest(c.Request)
+		if err != nil || sessionID == "" {
+			return nil, blame.SessionMalformed(errors.New("session cookie is missing"))
+		}
+		data, failure := manager.ValidateSession(c, sessionID, nil, validators...).Value()
+		if failure != nil {
+			return nil, failure
+		}
+		identity := &Identity{UserID: data.UserID.String(), Session: data}
+		if data.OrgID != (types.OrgID{}) {
+			identity.OrgID = data.OrgID.String()
+		}
+		return identity, nil
+	}
+}
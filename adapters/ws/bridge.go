@@ -0,0 +1,51 @@
+package ws
+
+import (
+	"context"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+)
+
+// RouteFunc returns the rooms an event is broadcast to; none drops it.
+type RouteFunc func(msg *events.Message) []string
+
+// RouteByHeader routes events to the room named by their header.
+func RouteByHeader(header string) RouteFunc {
+	return func(msg *events.Message) []string {
+		if room := msg.Header(header); room != "" {
+			return []string{room}
+		}
+		return nil
+	}
+}
+
+// RouteToRoom routes every event to room.
+func RouteToRoom(room string) RouteFunc {
+	return func(*events.Message) []string {
+		return []string{room}
+	}
+}
+
+// Bridge broadcasts the events of subject to the rooms route returns, as messages typed with
+// the subject of the event. Every replica must receive the events to reach its own
+// connections, so broker must not share subscriptions in a queue group, e.g.
+// natsManager.Broker("").
+func (h *Hub) Bridge(broker events.Broker, subject string, route RouteFunc) blame.Blame {
+	return broker.Subscribe(subject, func(_ context.Context, msg *events.Message) blame.Blame {
+		rooms := route(msg)
+		if len(rooms) == 0 {
+			return nil
+		}
+		for _, room := range rooms {
+			frame, err := encode(Message{Type: msg.Subject, Room: room, ID: msg.MessageID()}, msg.Data)
+			if err != nil {
+				h.logger.Warn("Dropping event not encodable for WebSocket clients", log.String("subject", msg.Subject), log.Err(err))
+				return nil
+			}
+			h.BroadcastFrame(room, frame)
+		}
+		return nil
+	})
+}
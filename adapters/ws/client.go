@@ -0,0 +1,197 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/gorilla/websocket"
+)
+
+// Client is a connection served by a Hub.
+type Client struct {
+	id       string
+	hub      *Hub
+	conn     *websocket.Conn
+	identity *Identity
+	cancel   context.CancelFunc
+	send     chan []byte
+	closing  chan closeFrame
+	done     chan struct{}
+	once     sync.Once
+	// rooms is guarded by the mutex of the hub.
+	rooms map[string]struct{}
+}
+
+// closeFrame is the close status sent before the connection is closed.
+type closeFrame struct {
+	code   int
+	reason string
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, identity *Identity, cancel context.CancelFunc) *Client {
+	return &Client{
+		id:       random.GenerateUUIDString(),
+		hub:      hub,
+		conn:     conn,
+		identity: identity,
+		cancel:   cancel,
+		send:     make(chan []byte, hub.sendBuffer),
+		closing:  make(chan closeFrame, 1),
+		done:     make(chan struct{}),
+		rooms:    make(map[string]struct{}),
+	}
+}
+
+// ID returns the id of the connection.
+func (c *Client) ID() string {
+	return c.id
+}
+
+// Identity returns who authenticated the connection.
+func (c *Client) Identity() *Identity {
+	return c.identity
+}
+
+// Rooms returns the rooms the connection joined.
+func (c *Client) Rooms() []string {
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Send sends a message of msgType carrying data, encoded as JSON, to the connection.
+func (c *Client) Send(msgType string, data any) error {
+	frame, err := encode(Message{Type: msgType}, data)
+	if err != nil {
+		return err
+	}
+	if !c.sendFrame(frame) {
+		return ErrClosed
+	}
+	return nil
+}
+
+// ErrClosed is returned when sending to a closed connection.
+var ErrClosed = errors.New("ws: connection closed")
+
+// sendFrame queues frame, closing the connection when its queue is full.
+func (c *Client) sendFrame(frame []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- frame:
+		return true
+	default:
+		c.hub.logger.Warn("Closing slow WebSocket connection", log.String("client_id", c.id))
+		c.CloseWithReason(websocket.ClosePolicyViolation, "too slow")
+		return false
+	}
+}
+
+// CloseWithReason closes the connection, sending a close frame with code and reason.
+func (c *Client) CloseWithReason(code int, reason string) {
+	select {
+	case c.closing <- closeFrame{code: code, reason: reason}:
+	default:
+	}
+}
+
+// close releases the connection once it is unregistered.
+func (c *Client) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.cancel()
+		_ = c.conn.Close()
+	})
+}
+
+// readPump reads the messages of the connection until it fails, e.g. when the client goes
+// away or misses its pongs.
+func (c *Client) readPump(ctx context.Context) {
+	hub := c.hub
+	c.conn.SetReadLimit(hub.maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(hub.pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(hub.pongWait))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				hub.logger.Debug("WebSocket connection lost", log.String("client_id", c.id), log.Err(err))
+			}
+			return
+		}
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+			c.sendError(msg.ID, "invalid_message", "messages must be JSON objects with a type")
+			continue
+		}
+		switch msg.Type {
+		case TypeJoin:
+			if msg.Room == "" || hub.joinPolicy == nil || !hub.joinPolicy(c, msg.Room) {
+				c.sendError(msg.ID, "join_denied", "the room cannot be joined")
+				continue
+			}
+			hub.Join(c, msg.Room)
+		case TypeLeave:
+			hub.Leave(c, msg.Room)
+		default:
+			if hub.onMessage != nil {
+				hub.onMessage(ctx, c, msg)
+			}
+		}
+	}
+}
+
+// writePump writes the queued messages and the pings of the connection.
+func (c *Client) writePump() {
+	hub := c.hub
+	ticker := time.NewTicker(hub.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case frame := <-c.send:
+			if err := c.write(websocket.TextMessage, frame); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			if err := c.write(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+		case frame := <-c.closing:
+			_ = c.write(websocket.CloseMessage, websocket.FormatCloseMessage(frame.code, frame.reason))
+			c.close()
+			return
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *Client) write(messageType int, data []byte) error {
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.hub.writeWait))
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// sendError sends an error message answering the message id.
+func (c *Client) sendError(id, code, message string) {
+	frame, err := encode(Message{Type: TypeError, ID: id}, map[string]string{"code": code, "message": message})
+	if err == nil {
+		c.sendFrame(frame)
+	}
+}
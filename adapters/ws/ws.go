@@ -0,0 +1,403 @@
+// Package ws serves WebSocket connections from Gin. A Hub upgrades authenticated requests,
+// keeps the connections alive with ping/pong heartbeats, groups them in rooms and broadcasts
+// messages to rooms, users or every connection. Bridge fans the events of a broker out to the
+// connections, so any replica can publish to browsers connected to another one.
+//
+//	hub := ws.NewHub(ws.WithAuthenticator(ws.PasetoAuthenticator(pasetoManager)))
+//	router.GET("/ws", hub.Handler())
+//	hub.Bridge(natsManager.Broker(""), "notifications.>", ws.RouteByHeader("ws-room"))
+//	hub.Broadcast(ws.UserRoom(userID), "invoice.paid", invoice)
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/gin/response"
+	"github.com/abhissng/neuron/adapters/gin/server"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Message is the JSON frame exchanged with clients. Clients send "join" and "leave" messages
+// naming a Room to change their rooms; the others are passed to the message handler.
+type Message struct {
+	Type string          `json:"type"`
+	Room string          `json:"room,omitempty"`
+	ID   string          `json:"id,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Message types handled by the hub.
+const (
+	TypeJoin  = "join"
+	TypeLeave = "leave"
+	TypeError = "error"
+)
+
+// UserRoom returns the room every connection of userID joins.
+func UserRoom(userID string) string {
+	return "user:" + userID
+}
+
+// OrgRoom returns the room every connection of the users of orgID joins.
+func OrgRoom(orgID string) string {
+	return "org:" + orgID
+}
+
+// MessageHandler handles a message sent by client.
+type MessageHandler func(ctx context.Context, client *Client, msg Message)
+
+// JoinPolicy reports whether client may join room on its own request.
+type JoinPolicy func(client *Client, room string) bool
+
+// Hub tracks the connections of a server and their rooms.
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+	rooms   map[string]map[*Client]struct{}
+
+	upgrader       websocket.Upgrader
+	authenticator  Authenticator
+	onMessage      MessageHandler
+	onConnect      func(ctx context.Context, client *Client)
+	onDisconnect   func(client *Client)
+	joinPolicy     JoinPolicy
+	logger         *log.Log
+	pingInterval   time.Duration
+	pongWait       time.Duration
+	writeWait      time.Duration
+	maxMessageSize int64
+	sendBuffer     int
+}
+
+// Option configures a Hub.
+type Option func(*Hub)
+
+// WithAuthenticator authenticates the requests before upgrading them. Without one every
+// connection is anonymous.
+func WithAuthenticator(authenticator Authenticator) Option {
+	return func(h *Hub) {
+		h.authenticator = authenticator
+	}
+}
+
+// WithMessageHandler handles the messages clients send, other than join and leave.
+func WithMessageHandler(handler MessageHandler) Option {
+	return func(h *Hub) {
+		h.onMessage = handler
+	}
+}
+
+// WithOnConnect runs fn once a connection is registered, e.g. to join rooms of its own.
+func WithOnConnect(fn func(ctx context.Context, client *Client)) Option {
+	return func(h *Hub) {
+		h.onConnect = fn
+	}
+}
+
+// WithOnDisconnect runs fn once a connection is closed.
+func WithOnDisconnect(fn func(client *Client)) Option {
+	return func(h *Hub) {
+		h.onDisconnect = fn
+	}
+}
+
+// WithJoinPolicy lets clients join the rooms policy allows. By default clients only join
+// their user and org rooms and the rooms joined for them by the server.
+func WithJoinPolicy(policy JoinPolicy) Option {
+	return func(h *Hub) {
+		h.joinPolicy = policy
+	}
+}
+
+// WithAllowedOrigins accepts upgrades from origins, e.g. "https://app.example.com", besides
+// the host of the server itself.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(h *Hub) {
+		h.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || slices.Contains(origins, origin) {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && u.Host == r.Host
+		}
+	}
+}
+
+// WithHeartbeat sets how often connections are pinged and how long a pong may take. Defaults
+// to 30 seconds and 60 seconds.
+func WithHeartbeat(pingInterval, pongWait time.Duration) Option {
+	return func(h *Hub) {
+		h.pingInterval = pingInterval
+		h.pongWait = pongWait
+	}
+}
+
+// WithWriteWait sets the time allowed to write a frame. Defaults to 10 seconds.
+func WithWriteWait(d time.Duration) Option {
+	return func(h *Hub) {
+		h.writeWait = d
+	}
+}
+
+// WithMaxMessageSize sets the largest message read from clients. Defaults to 64 KB.
+func WithMaxMessageSize(size int64) Option {
+	return func(h *Hub) {
+		h.maxMessageSize = size
+	}
+}
+
+// WithSendBuffer sets how many messages are queued per connection. A connection whose queue
+// is full is too slow to keep up and is closed. Defaults to 64.
+func WithSendBuffer(size int) Option {
+	return func(h *Hub) {
+		h.sendBuffer = size
+	}
+}
+
+// WithLogger sets the logger.
+func WithLogger(logger *log.Log) Option {
+	return func(h *Hub) {
+		h.logger = logger
+	}
+}
+
+// NewHub creates a Hub.
+func NewHub(options ...Option) *Hub {
+	h := &Hub{
+		clients:        make(map[*Client]struct{}),
+		rooms:          make(map[string]map[*Client]struct{}),
+		pingInterval:   30 * time.Second,
+		pongWait:       60 * time.Second,
+		writeWait:      10 * time.Second,
+		maxMessageSize: 64 << 10,
+		sendBuffer:     64,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+		},
+	}
+	for _, opt := range options {
+		opt(h)
+	}
+	if h.logger == nil {
+		h.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return h
+}
+
+// Handler authenticates and upgrades the request, then serves the connection until it
+// closes. When the server has a Drainer, shutdown closes the connection with status 1001
+// (going away).
+func (h *Hub) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := &Identity{}
+		if h.authenticator != nil {
+			authenticated, err := h.authenticator(c)
+			if err != nil {
+				response.Error(c, err)
+				return
+			}
+			identity = authenticated
+		}
+
+		conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader has already answered the request
+			h.logger.Warn("WebSocket upgrade failed", log.Err(err))
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.WithoutCancel(c.Request.Context()))
+		defer cancel()
+		client := newClient(h, conn, identity, cancel)
+		if drainer, ok := server.GetDrainer(c); ok {
+			done := drainer.Register(func(context.Context) {
+				client.CloseWithReason(websocket.CloseGoingAway, "server shutting down")
+			})
+			defer done()
+		}
+
+		h.register(client)
+		defer h.unregister(client)
+		if h.onConnect != nil {
+			h.onConnect(ctx, client)
+		}
+		go client.writePump()
+		client.readPump(ctx)
+	}
+}
+
+func (h *Hub) register(client *Client) {
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+	if client.identity.UserID != "" {
+		h.Join(client, UserRoom(client.identity.UserID))
+	}
+	if client.identity.OrgID != "" {
+		h.Join(client, OrgRoom(client.identity.OrgID))
+	}
+}
+
+func (h *Hub) unregister(client *Client) {
+	h.mu.Lock()
+	delete(h.clients, client)
+	for room := range client.rooms {
+		h.leave(client, room)
+	}
+	h.mu.Unlock()
+	client.close()
+	if h.onDisconnect != nil {
+		h.onDisconnect(client)
+	}
+}
+
+// Join adds client to room.
+func (h *Hub) Join(client *Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	members, ok := h.rooms[room]
+	if !ok {
+		members = make(map[*Client]struct{})
+		h.rooms[room] = members
+	}
+	members[client] = struct{}{}
+	client.rooms[room] = struct{}{}
+}
+
+// Leave removes client from room.
+func (h *Hub) Leave(client *Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(client, room)
+}
+
+// leave removes client from room; h.mu must be held.
+func (h *Hub) leave(client *Client, room string) {
+	delete(client.rooms, room)
+	if members, ok := h.rooms[room]; ok {
+		delete(members, client)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+// Broadcast sends a message of msgType carrying data, encoded as JSON, to the connections
+// in room. It returns how many connections the message was queued for.
+func (h *Hub) Broadcast(room, msgType string, data any) (int, error) {
+	frame, err := encode(Message{Type: msgType, Room: room}, data)
+	if err != nil {
+		return 0, err
+	}
+	return h.BroadcastFrame(room, frame), nil
+}
+
+// BroadcastFrame sends an encoded Message to the connections in room.
+func (h *Hub) BroadcastFrame(room string, frame []byte) int {
+	h.mu.RLock()
+	members := make([]*Client, 0, len(h.rooms[room]))
+	for client := range h.rooms[room] {
+		members = append(members, client)
+	}
+	h.mu.RUnlock()
+	return h.sendAll(members, frame)
+}
+
+// BroadcastAll sends a message of msgType carrying data to every connection.
+func (h *Hub) BroadcastAll(msgType string, data any) (int, error) {
+	frame, err := encode(Message{Type: msgType}, data)
+	if err != nil {
+		return 0, err
+	}
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+	return h.sendAll(clients, frame), nil
+}
+
+func (h *Hub) sendAll(clients []*Client, frame []byte) int {
+	sent := 0
+	for _, client := range clients {
+		if client.sendFrame(frame) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Rooms returns the rooms with at least one connection.
+func (h *Hub) Rooms() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	rooms := make([]string, 0, len(h.rooms))
+	for room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Len returns the number of connections.
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Close closes every connection with status 1001 (going away).
+func (h *Hub) Close() {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+	for _, client := range clients {
+		client.CloseWithReason(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// encode encodes msg carrying data as a frame. Data already encoded as JSON, as []byte or
+// json.RawMessage, is sent as is.
+func encode(msg Message, data any) ([]byte, error) {
+	switch value := data.(type) {
+	case nil:
+	case json.RawMessage:
+		msg.Data = value
+	case []byte:
+		msg.Data = rawJSON(value)
+	default:
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		msg.Data = encoded
+	}
+	return json.Marshal(msg)
+}
+
+// rawJSON returns data as JSON, quoting it as a string when it is not JSON already.
+func rawJSON(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return data
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
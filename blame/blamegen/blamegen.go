@@ -0,0 +1,156 @@
+// Package blamegen generates Go code and i18n message files from a blame catalog, the
+// error_definition.json (or YAML) file loaded by the BlameManager, so that error codes,
+// their constructors and their translations are declared once.
+package blamegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/abhissng/neuron/blame"
+	"gopkg.in/yaml.v3"
+)
+
+// placeholderPattern matches the template fields of messages, e.g. {{.name}}.
+var placeholderPattern = regexp.MustCompile(`{{\s*\.(\w+)\s*}}`)
+
+// initialisms are the words written in upper case in generated identifiers.
+var initialisms = map[string]bool{
+	"api": true, "db": true, "grpc": true, "http": true, "id": true, "ip": true, "json": true,
+	"jwt": true, "nats": true, "otp": true, "sql": true, "tls": true, "url": true, "uuid": true,
+}
+
+// Entry is a catalog definition with the identifiers generated for it.
+type Entry struct {
+	blame.BlameDefinition
+	Constant    string   // e.g. ErrorParamNotFound
+	Constructor string   // e.g. ParamNotFoundError
+	Fields      []string // fields of the constructor, by default the template fields in order of appearance
+	Params      []string // parameter names of the fields
+}
+
+// LoadCatalog reads the definitions of a JSON or YAML catalog, chosen by file extension.
+func LoadCatalog(path string) ([]blame.BlameDefinition, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	var definitions []blame.BlameDefinition
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &definitions)
+	default:
+		err = json.Unmarshal(data, &definitions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode catalog %s: %w", path, err)
+	}
+	return definitions, nil
+}
+
+// Entries derives the identifiers of definitions. trimPrefix is removed from the codes before
+// naming them, e.g. "error-payments-" for a service whose codes all start with it. Manual
+// definitions are only checked for their codes. It fails on empty or duplicate codes, on
+// colliding identifiers and on Fields missing a template field.
+func Entries(definitions []blame.BlameDefinition, trimPrefix string) ([]Entry, error) {
+	entries := make([]Entry, 0, len(definitions))
+	codes := make(map[string]bool, len(definitions))
+	names := make(map[string]string, 2*len(definitions))
+	for _, def := range definitions {
+		if def.Code == "" {
+			return nil, fmt.Errorf("definition with message %q has no code", def.Message)
+		}
+		if codes[def.Code] {
+			return nil, fmt.Errorf("duplicate code %s", def.Code)
+		}
+		codes[def.Code] = true
+
+		entry := Entry{BlameDefinition: def}
+		if def.Manual {
+			entries = append(entries, entry)
+			continue
+		}
+		base := strings.TrimSuffix(def.Name, "Error")
+		if base == "" {
+			base = exportedName(strings.TrimPrefix(strings.TrimPrefix(def.Code, trimPrefix), "error-"))
+		}
+		if !token.IsIdentifier(base) || !token.IsExported(base) {
+			return nil, fmt.Errorf("code %s does not give an exported identifier (%q): set its Name", def.Code, base)
+		}
+		entry.Constant = "Error" + base
+		entry.Constructor = base + "Error"
+		for _, name := range []string{entry.Constant, entry.Constructor} {
+			if other, ok := names[name]; ok {
+				return nil, fmt.Errorf("codes %s and %s both generate %s: set the Name of one of them", other, def.Code, name)
+			}
+			names[name] = def.Code
+		}
+
+		entry.Fields = templateFields(def.Message, def.Description)
+		if len(def.Fields) > 0 {
+			for _, field := range entry.Fields {
+				if !slices.Contains(def.Fields, field) {
+					return nil, fmt.Errorf("fields of code %s miss the template field %s", def.Code, field)
+				}
+			}
+			entry.Fields = def.Fields
+		}
+		for _, field := range entry.Fields {
+			entry.Params = append(entry.Params, paramName(field))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// exportedName converts a code such as "param-not-found" to ParamNotFound.
+func exportedName(code string) string {
+	words := strings.FieldsFunc(code, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// templateFields returns the distinct template fields of texts in order of appearance.
+func templateFields(texts ...string) []string {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				fields = append(fields, match[1])
+			}
+		}
+	}
+	return fields
+}
+
+// paramName converts a template field to a parameter name that does not shadow the
+// identifiers used by the constructors.
+func paramName(field string) string {
+	name := exportedName(field)
+	name = strings.ToLower(name[:1]) + name[1:]
+	if initialisms[strings.ToLower(name)] {
+		name = strings.ToLower(name)
+	}
+	switch {
+	case token.IsKeyword(name), name == "causes", name == "manager", name == "blame", name == "errors", name == "types":
+		return name + "Value"
+	}
+	return name
+}
@@ -0,0 +1,117 @@
+package blamegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// GoOptions configures GenerateGo.
+type GoOptions struct {
+	// Package of the generated file.
+	Package string
+	// Source is the catalog path recorded in the header of the file.
+	Source string
+	// Builtin generates into the blame package itself, whose constructors build blames with
+	// its local manager.
+	Builtin bool
+}
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"quote":   strconv.Quote,
+	"comment": commentText,
+	"params":  params,
+	"fields":  fieldsLiteral,
+}).Parse(`// Code generated by neuron gen blame from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"errors"
+{{if not .Builtin}}
+	"github.com/abhissng/neuron/blame"
+{{- end}}
+	"github.com/abhissng/neuron/utils/types"
+)
+
+// Error codes of the catalog.
+const (
+{{- range .Entries}}
+	{{.Constant}} types.ErrorCode = {{quote .Code}}
+{{- end}}
+)
+{{if .Builtin}}{{range .Entries}}
+// {{.Constructor}} returns the {{.Code}} blame: {{comment .Message}}
+func {{.Constructor}}({{params .Params}}) Blame {
+	return BuildBlame({{.Constant}}, {{fields .Fields .Params}}, errors.Join(causes...), nil)
+}
+{{end}}{{else}}
+// manager builds the blames of the constructors.
+var manager *blame.BlameManager
+
+// SetBlameManager sets the manager the constructors build blames with, i.e. the BlameManager
+// of the AppContext loading the catalog. Call it once at startup.
+func SetBlameManager(m *blame.BlameManager) {
+	manager = m
+}
+{{range .Entries}}
+// {{.Constructor}} returns the {{.Code}} blame: {{comment .Message}}
+func {{.Constructor}}({{params .Params}}) blame.Blame {
+	return blame.BuildBlame({{.Constant}}, {{fields .Fields .Params}}, errors.Join(causes...), manager)
+}
+{{end}}{{end}}`))
+
+// GenerateGo returns the formatted Go source declaring a constant and a constructor per entry
+// that is not manual. Constructors take the fields of the entry followed by its causes.
+func GenerateGo(entries []Entry, opts GoOptions) ([]byte, error) {
+	if opts.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+	generated := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Manual {
+			generated = append(generated, entry)
+		}
+	}
+	var buf bytes.Buffer
+	err := goTemplate.Execute(&buf, struct {
+		GoOptions
+		Entries []Entry
+	}{opts, generated})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate go source: %w", err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated source: %w", err)
+	}
+	return source, nil
+}
+
+// commentText flattens text to a single comment line.
+func commentText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// params returns the parameter list of a constructor.
+func params(names []string) string {
+	if len(names) == 0 {
+		return "causes ...error"
+	}
+	return strings.Join(names, ", ") + " any, causes ...error"
+}
+
+// fieldsLiteral returns the fields map literal of a constructor.
+func fieldsLiteral(fields, names []string) string {
+	if len(fields) == 0 {
+		return "nil"
+	}
+	pairs := make([]string, len(fields))
+	for i, field := range fields {
+		pairs[i] = strconv.Quote(field) + ": " + names[i]
+	}
+	return "map[string]any{" + strings.Join(pairs, ", ") + "}"
+}
@@ -0,0 +1,73 @@
+package blamegen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// descriptionSuffix is appended to the code to form the message id of the description, as
+// localized by blame.Error.Translate.
+const descriptionSuffix = ".description"
+
+// LocaleFileName returns the name of the message file of lang, e.g. active.en.json.
+func LocaleFileName(lang string) string {
+	return "active." + lang + ".json"
+}
+
+// GenerateLocales writes a go-i18n message file per language into dir, holding the message and
+// description of every entry. Existing files are merged: translations already present are
+// kept and missing ids are stubbed with the catalog text. It returns the written paths.
+func GenerateLocales(entries []Entry, dir string, langs ...string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create locale directory: %w", err)
+	}
+	paths := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		path := filepath.Join(dir, LocaleFileName(lang))
+		messages, err := readMessages(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			stub(messages, entry.Code, entry.Message)
+			stub(messages, entry.Code+descriptionSuffix, entry.Description)
+		}
+		data, err := json.MarshalIndent(messages, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil { // #nosec G306 -- message files are shared
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// readMessages reads the flat message file at path, or returns an empty set if it does not exist.
+func readMessages(path string) (map[string]string, error) {
+	messages := make(map[string]string)
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return messages, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return messages, nil
+}
+
+// stub sets the message of id to text unless it is already translated.
+func stub(messages map[string]string, id, text string) {
+	if text == "" || messages[id] != "" {
+		return
+	}
+	messages[id] = text
+}
@@ -0,0 +1,181 @@
+// Code generated by neuron gen blame from error_definition.json. DO NOT EDIT.
+
+package blame
+
+import (
+	"errors"
+
+	"github.com/abhissng/neuron/utils/types"
+)
+
+// Error codes of the catalog.
+const (
+	ErrorFieldUpdateForbidden        types.ErrorCode = "error-field-update-forbidden"
+	ErrorKeyValueOperation           types.ErrorCode = "error-key-value-operation-failed"
+	ErrorKeyValueKeyNotFound         types.ErrorCode = "error-key-value-key-not-found"
+	ErrorInvalidSchedule             types.ErrorCode = "error-invalid-schedule"
+	ErrorSchemaValidation            types.ErrorCode = "error-schema-validation-failed"
+	ErrorSagaStepTimeout             types.ErrorCode = "error-saga-step-timeout"
+	ErrorStreamProvision             types.ErrorCode = "error-stream-provision-failed"
+	ErrorWarmupFailed                types.ErrorCode = "error-warmup-failed"
+	ErrorInvalidTokenClaims          types.ErrorCode = "error-invalid-token-claims"
+	ErrorInsufficientScopes          types.ErrorCode = "error-insufficient-scopes"
+	ErrorSOAPFault                   types.ErrorCode = "error-soap-fault"
+	ErrorInvalidIFSC                 types.ErrorCode = "error-bank-ifsc-invalid"
+	ErrorInvalidBankAccount          types.ErrorCode = "error-bank-account-invalid"
+	ErrorBankAccountNotFound         types.ErrorCode = "error-bank-account-not-found"
+	ErrorBankAccountInactive         types.ErrorCode = "error-bank-account-inactive"
+	ErrorBankNameMismatch            types.ErrorCode = "error-bank-name-mismatch"
+	ErrorBankVerificationUnavailable types.ErrorCode = "error-bank-verification-unavailable"
+	ErrorKYCDocumentInvalid          types.ErrorCode = "error-kyc-document-invalid"
+	ErrorKYCConsentRequired          types.ErrorCode = "error-kyc-consent-required"
+	ErrorKYCVerificationFailed       types.ErrorCode = "error-kyc-verification-failed"
+	ErrorKYCNameMismatch             types.ErrorCode = "error-kyc-name-mismatch"
+	ErrorKYCProviderUnavailable      types.ErrorCode = "error-kyc-provider-unavailable"
+	ErrorRequestTimeout              types.ErrorCode = "error-request-timeout"
+	ErrorRequestBodyTooLarge         types.ErrorCode = "error-request-body-too-large"
+	ErrorUploadRejected              types.ErrorCode = "error-upload-rejected"
+	ErrorUploadFileTooLarge          types.ErrorCode = "error-upload-file-too-large"
+	ErrorUploadTooManyFiles          types.ErrorCode = "error-upload-too-many-files"
+	ErrorUploadInfected              types.ErrorCode = "error-upload-infected"
+)
+
+// FieldUpdateForbiddenError returns the error-field-update-forbidden blame: Updating these fields is not allowed: {{.fields}}
+func FieldUpdateForbiddenError(fields any, causes ...error) Blame {
+	return BuildBlame(ErrorFieldUpdateForbidden, map[string]any{"fields": fields}, errors.Join(causes...), nil)
+}
+
+// KeyValueOperationError returns the error-key-value-operation-failed blame: Key value operation {{.operation}} failed on bucket {{.bucket}}
+func KeyValueOperationError(bucket, key, operation any, causes ...error) Blame {
+	return BuildBlame(ErrorKeyValueOperation, map[string]any{"bucket": bucket, "key": key, "operation": operation}, errors.Join(causes...), nil)
+}
+
+// KeyValueKeyNotFoundError returns the error-key-value-key-not-found blame: Key {{.key}} not found in bucket {{.bucket}}
+func KeyValueKeyNotFoundError(bucket, key any, causes ...error) Blame {
+	return BuildBlame(ErrorKeyValueKeyNotFound, map[string]any{"bucket": bucket, "key": key}, errors.Join(causes...), nil)
+}
+
+// InvalidScheduleError returns the error-invalid-schedule blame: Invalid schedule {{.schedule}}
+func InvalidScheduleError(name, schedule any, causes ...error) Blame {
+	return BuildBlame(ErrorInvalidSchedule, map[string]any{"name": name, "schedule": schedule}, errors.Join(causes...), nil)
+}
+
+// SchemaValidationError returns the error-schema-validation-failed blame: Message on {{.subject}} does not match its schema
+func SchemaValidationError(subject, violations any, causes ...error) Blame {
+	return BuildBlame(ErrorSchemaValidation, map[string]any{"subject": subject, "violations": violations}, errors.Join(causes...), nil)
+}
+
+// SagaStepTimeoutError returns the error-saga-step-timeout blame: Step {{.step}} of saga {{.saga}} timed out
+func SagaStepTimeoutError(saga, step, timeout any, causes ...error) Blame {
+	return BuildBlame(ErrorSagaStepTimeout, map[string]any{"saga": saga, "step": step, "timeout": timeout}, errors.Join(causes...), nil)
+}
+
+// StreamProvisionError returns the error-stream-provision-failed blame: Failed to provision JetStream {{.resource}} {{.name}}
+func StreamProvisionError(resource, name, operation any, causes ...error) Blame {
+	return BuildBlame(ErrorStreamProvision, map[string]any{"resource": resource, "name": name, "operation": operation}, errors.Join(causes...), nil)
+}
+
+// WarmupFailedError returns the error-warmup-failed blame: Service is not ready
+func WarmupFailedError(checks any, causes ...error) Blame {
+	return BuildBlame(ErrorWarmupFailed, map[string]any{"checks": checks}, errors.Join(causes...), nil)
+}
+
+// InvalidTokenClaimsError returns the error-invalid-token-claims blame: Token claims are invalid.
+func InvalidTokenClaimsError(audience any, causes ...error) Blame {
+	return BuildBlame(ErrorInvalidTokenClaims, map[string]any{"audience": audience}, errors.Join(causes...), nil)
+}
+
+// InsufficientScopesError returns the error-insufficient-scopes blame: The token lacks the required scopes.
+func InsufficientScopesError(scopes any, causes ...error) Blame {
+	return BuildBlame(ErrorInsufficientScopes, map[string]any{"scopes": scopes}, errors.Join(causes...), nil)
+}
+
+// SOAPFaultError returns the error-soap-fault blame: The partner service could not process the request.
+func SOAPFaultError(code, reason, detail any, causes ...error) Blame {
+	return BuildBlame(ErrorSOAPFault, map[string]any{"code": code, "reason": reason, "detail": detail}, errors.Join(causes...), nil)
+}
+
+// InvalidIFSCError returns the error-bank-ifsc-invalid blame: The IFSC code is invalid.
+func InvalidIFSCError(ifsc any, causes ...error) Blame {
+	return BuildBlame(ErrorInvalidIFSC, map[string]any{"ifsc": ifsc}, errors.Join(causes...), nil)
+}
+
+// InvalidBankAccountError returns the error-bank-account-invalid blame: The bank account number is invalid.
+func InvalidBankAccountError(reason any, causes ...error) Blame {
+	return BuildBlame(ErrorInvalidBankAccount, map[string]any{"reason": reason}, errors.Join(causes...), nil)
+}
+
+// BankAccountNotFoundError returns the error-bank-account-not-found blame: The bank account does not exist.
+func BankAccountNotFoundError(account, ifsc any, causes ...error) Blame {
+	return BuildBlame(ErrorBankAccountNotFound, map[string]any{"account": account, "ifsc": ifsc}, errors.Join(causes...), nil)
+}
+
+// BankAccountInactiveError returns the error-bank-account-inactive blame: The bank account cannot receive payments.
+func BankAccountInactiveError(account, reason any, causes ...error) Blame {
+	return BuildBlame(ErrorBankAccountInactive, map[string]any{"account": account, "reason": reason}, errors.Join(causes...), nil)
+}
+
+// BankNameMismatchError returns the error-bank-name-mismatch blame: The account holder name does not match.
+func BankNameMismatchError(expected, score any, causes ...error) Blame {
+	return BuildBlame(ErrorBankNameMismatch, map[string]any{"expected": expected, "score": score}, errors.Join(causes...), nil)
+}
+
+// BankVerificationUnavailableError returns the error-bank-verification-unavailable blame: Bank account verification is unavailable. Please try again later.
+func BankVerificationUnavailableError(provider any, causes ...error) Blame {
+	return BuildBlame(ErrorBankVerificationUnavailable, map[string]any{"provider": provider}, errors.Join(causes...), nil)
+}
+
+// KYCDocumentInvalidError returns the error-kyc-document-invalid blame: The document number is invalid.
+func KYCDocumentInvalidError(document, reason any, causes ...error) Blame {
+	return BuildBlame(ErrorKYCDocumentInvalid, map[string]any{"document": document, "reason": reason}, errors.Join(causes...), nil)
+}
+
+// KYCConsentRequiredError returns the error-kyc-consent-required blame: Consent of the document holder is required.
+func KYCConsentRequiredError(document any, causes ...error) Blame {
+	return BuildBlame(ErrorKYCConsentRequired, map[string]any{"document": document}, errors.Join(causes...), nil)
+}
+
+// KYCVerificationFailedError returns the error-kyc-verification-failed blame: The document could not be verified.
+func KYCVerificationFailedError(document, status, reason any, causes ...error) Blame {
+	return BuildBlame(ErrorKYCVerificationFailed, map[string]any{"document": document, "status": status, "reason": reason}, errors.Join(causes...), nil)
+}
+
+// KYCNameMismatchError returns the error-kyc-name-mismatch blame: The name does not match the document.
+func KYCNameMismatchError(document, score any, causes ...error) Blame {
+	return BuildBlame(ErrorKYCNameMismatch, map[string]any{"document": document, "score": score}, errors.Join(causes...), nil)
+}
+
+// KYCProviderUnavailableError returns the error-kyc-provider-unavailable blame: Document verification is unavailable. Please try again later.
+func KYCProviderUnavailableError(provider any, causes ...error) Blame {
+	return BuildBlame(ErrorKYCProviderUnavailable, map[string]any{"provider": provider}, errors.Join(causes...), nil)
+}
+
+// RequestTimeoutError returns the error-request-timeout blame: The request took too long to process. Please try again.
+func RequestTimeoutError(timeout any, causes ...error) Blame {
+	return BuildBlame(ErrorRequestTimeout, map[string]any{"timeout": timeout}, errors.Join(causes...), nil)
+}
+
+// RequestBodyTooLargeError returns the error-request-body-too-large blame: The request body is too large.
+func RequestBodyTooLargeError(limit any, causes ...error) Blame {
+	return BuildBlame(ErrorRequestBodyTooLarge, map[string]any{"limit": limit}, errors.Join(causes...), nil)
+}
+
+// UploadRejectedError returns the error-upload-rejected blame: The uploaded file is not accepted.
+func UploadRejectedError(file, reason any, causes ...error) Blame {
+	return BuildBlame(ErrorUploadRejected, map[string]any{"file": file, "reason": reason}, errors.Join(causes...), nil)
+}
+
+// UploadFileTooLargeError returns the error-upload-file-too-large blame: The uploaded file is too large.
+func UploadFileTooLargeError(file, limit any, causes ...error) Blame {
+	return BuildBlame(ErrorUploadFileTooLarge, map[string]any{"file": file, "limit": limit}, errors.Join(causes...), nil)
+}
+
+// UploadTooManyFilesError returns the error-upload-too-many-files blame: Too many files were uploaded.
+func UploadTooManyFilesError(limit any, causes ...error) Blame {
+	return BuildBlame(ErrorUploadTooManyFiles, map[string]any{"limit": limit}, errors.Join(causes...), nil)
+}
+
+// UploadInfectedError returns the error-upload-infected blame: The uploaded file failed the virus scan.
+func UploadInfectedError(file any, causes ...error) Blame {
+	return BuildBlame(ErrorUploadInfected, map[string]any{"file": file}, errors.Join(causes...), nil)
+}
@@ -84,5 +84,6 @@ const (
 	ErrorSessionUnauthenticated          types.ErrorCode = "error-session-unauthenticated"
 	ErrorMissingFeatureFlags             types.ErrorCode = "error-missing-feature-flags"
 	ErrorMissingXLocationId              types.ErrorCode = "error-missing-x-location-id"
+	ErrorRequestValidationFailed         types.ErrorCode = "error-request-validation-failed"
 	ErrGeneralKnownError                 types.ErrorCode = "error-general-known-error"
 )
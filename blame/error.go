@@ -357,14 +357,14 @@ func (e *Error) Translate() (string, string) {
 
 // ErrorResponse struct holds the error information for sending as a response
 type ErrorResponse struct {
-	ReasonCode   string                   `json:"reason_code,omitempty"`
-	ErrorCode    types.ErrorCode          `json:"error_code,omitempty"`
-	Message      string                   `json:"message,omitempty"`
-	Description  string                   `json:"description,omitempty"`
-	Fields       map[string]any           `json:"fields,omitempty"`
-	Component    types.ComponentErrorType `json:"component,omitempty"`
-	ResponseType types.ResponseErrorType  `json:"response_type,omitempty"`
-	Causes       []string                 `json:"causes,omitempty"`
+	ReasonCode   string                   `json:"reason_code,omitempty" xml:"reason_code,omitempty"`
+	ErrorCode    types.ErrorCode          `json:"error_code,omitempty" xml:"error_code,omitempty"`
+	Message      string                   `json:"message,omitempty" xml:"message,omitempty"`
+	Description  string                   `json:"description,omitempty" xml:"description,omitempty"`
+	Fields       map[string]any           `json:"fields,omitempty" xml:"-"` // maps have no XML encoding
+	Component    types.ComponentErrorType `json:"component,omitempty" xml:"component,omitempty"`
+	ResponseType types.ResponseErrorType  `json:"response_type,omitempty" xml:"response_type,omitempty"`
+	Causes       []string                 `json:"causes,omitempty" xml:"causes>cause,omitempty"`
 }
 
 // NewErrorResponseBlame creates a new Blame instance from the ErrorResponse
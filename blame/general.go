@@ -4,6 +4,8 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/helpers"
@@ -579,6 +581,22 @@ func MissingXLocationId() Blame {
 	return getLocalBlameManager().FetchBlameForError(ErrorMissingXLocationId)
 }
 
+// RequestValidationError is an error when fields of a request fail validation. The errors,
+// keyed by field path, are returned in the "errors" field of the error response.
+func RequestValidationError(fieldErrors map[string]string, cause error) Blame {
+	summary := make([]string, 0, len(fieldErrors))
+	for field, message := range fieldErrors {
+		summary = append(summary, field+" "+message)
+	}
+	slices.Sort(summary)
+	return getLocalBlameManager().FetchBlameForError(
+		ErrorRequestValidationFailed,
+		WithField("errors", fieldErrors),
+		WithField("summary", strings.Join(summary, "; ")),
+		WithCauses(cause),
+	)
+}
+
 // GeneralKnownError is an error when we want to return any kind of error
 func GeneralKnownError(cause error) Blame {
 	data := map[string]any{
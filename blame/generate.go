@@ -0,0 +1,3 @@
+package blame
+
+//go:generate go run ../cmd/neuron gen blame -catalog error_definition.json -package blame -out catalog_gen.go -builtin
@@ -12,16 +12,25 @@ import (
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/types"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"gopkg.in/yaml.v3"
 )
 
 // BlameDefinition represents a blame definition.
 type BlameDefinition struct {
-	ReasonCode   string `json:"ReasonCode"`
-	Code         string `json:"Code"`
-	Message      string `json:"Message"`
-	Description  string `json:"Description"`
-	Component    string `json:"Component"`
-	ResponseType string `json:"ResponseType"`
+	ReasonCode   string `json:"ReasonCode" yaml:"ReasonCode"`
+	Code         string `json:"Code" yaml:"Code"`
+	Message      string `json:"Message" yaml:"Message"`
+	Description  string `json:"Description" yaml:"Description"`
+	Component    string `json:"Component" yaml:"Component"`
+	ResponseType string `json:"ResponseType" yaml:"ResponseType"`
+	// Name optionally sets the constructor generated by blamegen, e.g. MissingParameterError.
+	Name string `json:"Name,omitempty" yaml:"Name,omitempty"`
+	// Fields optionally sets the parameters of the generated constructor, in order. It must
+	// hold every template field of the texts and may add fields that are only attached.
+	Fields []string `json:"Fields,omitempty" yaml:"Fields,omitempty"`
+	// Manual marks a definition whose constant and constructor are written by hand, so
+	// blamegen leaves it out of the generated code.
+	Manual bool `json:"Manual,omitempty" yaml:"Manual,omitempty"`
 }
 
 // CastToBlame casts the provided blame to the error code of the target blame.
@@ -102,6 +111,15 @@ func NewBlameManager(opt *BlameManagerOption) (*BlameManager, error) {
 		opt.Bundle = helpers.NewBundle(helpers.ParseLanguageTag(opt.LanguageTag))
 	}
 
+	if len(opt.TranslationFiles) > 0 {
+		opt.Bundle.RegisterUnmarshalFunc("json", json.Unmarshal)
+		for _, path := range opt.TranslationFiles {
+			if _, err := opt.Bundle.LoadMessageFile(filepath.Clean(path)); err != nil {
+				return nil, fmt.Errorf("failed to load translation file %s: %w", path, err)
+			}
+		}
+	}
+
 	err := InitLocalBlameManager(opt.Bundle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialise local blame manager: %w", err)
@@ -109,7 +127,7 @@ func NewBlameManager(opt *BlameManagerOption) (*BlameManager, error) {
 
 	var blameDefinitions []BlameDefinition
 
-	// Load error definitions from JSON file
+	// Load error definitions from the JSON or YAML file
 	if !helpers.IsEmpty(opt.LocaleDir) {
 		file, err := os.Open(filepath.Clean(opt.LocaleDir))
 		if err != nil {
@@ -121,7 +139,13 @@ func NewBlameManager(opt *BlameManagerOption) (*BlameManager, error) {
 			}
 		}()
 
-		if err := json.NewDecoder(file).Decode(&blameDefinitions); err != nil {
+		switch strings.ToLower(filepath.Ext(opt.LocaleDir)) {
+		case ".yaml", ".yml":
+			err = yaml.NewDecoder(file).Decode(&blameDefinitions)
+		default:
+			err = json.NewDecoder(file).Decode(&blameDefinitions)
+		}
+		if err != nil {
 			return nil, fmt.Errorf("failed to decode error definitions: %w", err)
 		}
 	}
@@ -235,10 +259,11 @@ func BuildBlame(
 
 // BlameManagerOption holds configuration
 type BlameManagerOption struct {
-	LocaleDir       string
-	LanguageTag     string
-	Bundle          *i18n.Bundle
-	ExistingManager *BlameManager
+	LocaleDir        string
+	LanguageTag      string
+	Bundle           *i18n.Bundle
+	ExistingManager  *BlameManager
+	TranslationFiles []string
 }
 
 // Option defines a function that configures BlameManager
@@ -272,6 +297,14 @@ func WithBundle(bundle *i18n.Bundle) Option {
 	}
 }
 
+// WithTranslationFiles loads go-i18n message files, e.g. the active.<lang>.json files generated
+// by blamegen, into the bundle.
+func WithTranslationFiles(paths ...string) Option {
+	return func(bw *BlameManagerOption) {
+		bw.TranslationFiles = append(bw.TranslationFiles, paths...)
+	}
+}
+
 func NewBlameManagerOption(opts ...Option) *BlameManagerOption {
 	bw := &BlameManagerOption{
 		LanguageTag: helpers.GetDefaultLanguageTag().String(),
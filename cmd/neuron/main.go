@@ -0,0 +1,147 @@
+// Command neuron is the command line of the framework.
+//
+// Usage:
+//
+//	neuron new service <name> [-module path] [-dir dir] [-grpc] [-neuron-version v] [-neuron-replace path]
+//	neuron gen blame -catalog file -package name [-out file] [-trim-prefix prefix] [-locale-dir dir] [-langs en,hi] [-builtin]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	"github.com/abhissng/neuron/blame/blamegen"
+	"github.com/abhissng/neuron/engine/scaffold"
+)
+
+const usage = `usage:
+  neuron new service <name> [flags]
+    Generates a service skeleton wired with AppContext, Gin and gRPC servers, health, metrics,
+    NATS consumers, a blame catalog and a Makefile.
+  neuron gen blame [flags]
+    Generates the error code constants, constructors and i18n message files of a blame catalog.
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "neuron:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command of args.
+func run(args []string) error {
+	if len(args) >= 2 {
+		switch args[0] + " " + args[1] {
+		case "new service":
+			return newService(args[2:])
+		case "gen blame":
+			return genBlame(args[2:])
+		}
+	}
+	fmt.Fprint(os.Stderr, usage)
+	return errors.New("unknown command")
+}
+
+// newService generates a service from args.
+func newService(args []string) error {
+	cfg := scaffold.ServiceConfig{NeuronVersion: neuronVersion()}
+	flags := newServiceFlags(&cfg)
+
+	// Accept the name before or after the flags.
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.Name, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if cfg.Name == "" {
+		cfg.Name = flags.Arg(0)
+	}
+	if cfg.Name == "" {
+		return errors.New("missing service name")
+	}
+
+	files, err := scaffold.GenerateService(cfg)
+	if err != nil {
+		return err
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		dir = cfg.Name
+	}
+	for _, file := range files {
+		fmt.Println("created", dir+"/"+file)
+	}
+	fmt.Printf("\nNext: cd %s && make tidy && make dev\n", dir)
+	return nil
+}
+
+// newServiceFlags declares the flags of "new service" into cfg.
+func newServiceFlags(cfg *scaffold.ServiceConfig) *flag.FlagSet {
+	flags := flag.NewFlagSet("new service", flag.ContinueOnError)
+	flags.StringVar(&cfg.Module, "module", "", "module path of the service (default: the name)")
+	flags.StringVar(&cfg.Dir, "dir", "", "output directory (default: the name)")
+	flags.BoolVar(&cfg.GRPC, "grpc", false, "add a gRPC server")
+	flags.StringVar(&cfg.NeuronVersion, "neuron-version", cfg.NeuronVersion, "neuron version to require (default: resolved by go mod tidy)")
+	flags.StringVar(&cfg.NeuronReplace, "neuron-replace", "", "local neuron checkout to replace the requirement with")
+	return flags
+}
+
+// neuronVersion returns the version of neuron the command was installed from, if released.
+func neuronVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || !strings.HasPrefix(info.Main.Version, "v") {
+		return ""
+	}
+	return info.Main.Version
+}
+
+// genBlame generates the code and message files of a blame catalog from args.
+func genBlame(args []string) error {
+	flags := flag.NewFlagSet("gen blame", flag.ContinueOnError)
+	catalog := flags.String("catalog", "error_definition.json", "blame catalog, JSON or YAML")
+	pkg := flags.String("package", "", "package of the generated file (required)")
+	out := flags.String("out", "blame_gen.go", "generated Go file")
+	trimPrefix := flags.String("trim-prefix", "", "prefix removed from the codes before naming them")
+	localeDir := flags.String("locale-dir", "", "directory of the i18n message files (default: none generated)")
+	langs := flags.String("langs", "en", "comma separated languages of the message files")
+	builtin := flags.Bool("builtin", false, "generate into the blame package itself")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	definitions, err := blamegen.LoadCatalog(*catalog)
+	if err != nil {
+		return err
+	}
+	entries, err := blamegen.Entries(definitions, *trimPrefix)
+	if err != nil {
+		return err
+	}
+	source, err := blamegen.GenerateGo(entries, blamegen.GoOptions{Package: *pkg, Source: filepath.ToSlash(*catalog), Builtin: *builtin})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, source, 0o644); err != nil { // #nosec G306 -- generated sources are shared
+		return err
+	}
+	fmt.Println("generated", *out)
+
+	if *localeDir == "" {
+		return nil
+	}
+	paths, err := blamegen.GenerateLocales(entries, *localeDir, strings.Split(*langs, ",")...)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		fmt.Println("generated", path)
+	}
+	return nil
+}
@@ -2,11 +2,14 @@ package context
 
 import (
 	"os"
+	"sync/atomic"
+	"time"
 
 	"github.com/abhissng/neuron/adapters/aws"
 	"github.com/abhissng/neuron/adapters/cloud"
 	"github.com/abhissng/neuron/adapters/email"
 	"github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/adapters/geoip"
 	"github.com/abhissng/neuron/adapters/http"
 	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/adapters/mongo"
@@ -20,8 +23,10 @@ import (
 	"github.com/abhissng/neuron/blame"
 	"github.com/abhissng/neuron/database"
 	"github.com/abhissng/neuron/utils/cache"
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/cryptography"
+	"github.com/abhissng/neuron/utils/diagnostics"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/structures/service"
 	_ "github.com/abhissng/neuron/utils/timeutil"
@@ -48,9 +53,17 @@ type AppContext struct {
 	*store.StoreManager
 	cloud.CloudManager
 	*payment.Manager
+	*geoip.GeoIPManager
 
 	serviceId      string
 	isDebugEnabled bool
+	clock          clock.Clock
+	warmupChecks   []WarmupCheck
+	warmupTimeout  time.Duration
+	vaultPreload   []string
+	tuning         *diagnostics.RuntimeTuning
+	tuningErr      error
+	ready          atomic.Bool // Set by a successful Warmup
 	// Add other fields as needed (e.g., user ID, authentication information)
 }
 
@@ -63,6 +76,7 @@ func NewAppContext(opts ...AppContextOption) *AppContext {
 	for _, opt := range opts {
 		opt(appCtx)
 	}
+	appCtx.logRuntimeTuning()
 	return appCtx
 }
 
@@ -135,6 +149,9 @@ func WithDatabase(database database.Database) AppContextOption {
 // WithPasetoManager sets the paseto manager for the AppContext.
 func WithPasetoManager(opts ...paseto.PasetoOption) AppContextOption {
 	return func(ctx *AppContext) {
+		if ctx.clock != nil {
+			opts = append([]paseto.PasetoOption{paseto.WithClock(ctx.clock)}, opts...)
+		}
 		ctx.PasetoManager = paseto.NewPasetoManager(opts...)
 	}
 
@@ -244,3 +261,29 @@ func WithPaymentManager(manager *payment.Manager) AppContextOption {
 func (ctx *AppContext) GetPaymentManager() *payment.Manager {
 	return ctx.Manager
 }
+
+// WithGeoIPManager sets the geoip manager for the AppContext.
+func WithGeoIPManager(manager *geoip.GeoIPManager) AppContextOption {
+	return func(ctx *AppContext) {
+		ctx.GeoIPManager = manager
+	}
+}
+
+// GetGeoIPManager retrieves the GeoIPManager from the AppContext.
+func (ctx *AppContext) GetGeoIPManager() *geoip.GeoIPManager {
+	return ctx.GeoIPManager
+}
+
+// WithClock sets the clock of the AppContext. Pass it before WithPasetoManager for tokens to
+// be issued and validated with it, and hand GetClock to the other time dependent components,
+// e.g. session.WithClock, schedule.WithClock and middleware.WithLimiterClock.
+func WithClock(c clock.Clock) AppContextOption {
+	return func(ctx *AppContext) {
+		ctx.clock = c
+	}
+}
+
+// GetClock retrieves the clock of the AppContext, clock.System unless WithClock was used.
+func (ctx *AppContext) GetClock() clock.Clock {
+	return clock.OrSystem(ctx.clock)
+}
@@ -10,10 +10,12 @@ import (
 
 	"github.com/abhissng/neuron/adapters/events/nats"
 	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/database"
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/random"
 	"github.com/abhissng/neuron/utils/types"
+	"github.com/google/uuid"
 )
 
 // DefaultContext is a default implementation of the Context interface.
@@ -216,3 +218,25 @@ func (ctx *ServiceContext) GetGinCtxRecordsName() (*string, error) {
 
 	return records.(*string), nil
 }
+
+// GetOrgID retrieves the organisation ID from the X-Org-Id request header.
+func (ctx *ServiceContext) GetOrgID() (types.OrgID, bool) {
+	if ctx.Context == nil || ctx.Request == nil {
+		return types.OrgID{}, false
+	}
+	orgID, err := uuid.Parse(ctx.GetHeader(constant.XOrgId))
+	if err != nil {
+		return types.OrgID{}, false
+	}
+	return types.ToOrgID(orgID), true
+}
+
+// TenantDatabase acquires the database of the request's organisation from manager.
+// The returned release function must be called once the database is no longer used.
+func (ctx *ServiceContext) TenantDatabase(manager *database.TenantPoolManager) (database.Database, func(), error) {
+	orgID, ok := ctx.GetOrgID()
+	if !ok {
+		return nil, nil, database.ErrTenantMissing
+	}
+	return manager.Acquire(ctx.Request.Context(), orgID.String())
+}
@@ -0,0 +1,89 @@
+package context
+
+import (
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/diagnostics"
+)
+
+// WithRuntimeTuning sizes GOMAXPROCS and the soft memory limit of the runtime to the cgroup
+// limits of the container with diagnostics.TuneRuntime, before the other dependencies are
+// used. Pass diagnostics.WithTuningMetrics to export the derived values. The outcome is logged
+// once the AppContext is created and returned by RuntimeTuning.
+func WithRuntimeTuning(opts ...diagnostics.TuneOption) AppContextOption {
+	return func(appCtx *AppContext) {
+		tuning, err := diagnostics.TuneRuntime(opts...)
+		appCtx.tuning = &tuning
+		appCtx.tuningErr = err
+	}
+}
+
+// RuntimeTuning returns the outcome of WithRuntimeTuning, false without it.
+func (ctx *AppContext) RuntimeTuning() (diagnostics.RuntimeTuning, bool) {
+	if ctx.tuning == nil {
+		return diagnostics.RuntimeTuning{}, false
+	}
+	return *ctx.tuning, true
+}
+
+// logRuntimeTuning logs the outcome of WithRuntimeTuning.
+func (ctx *AppContext) logRuntimeTuning() {
+	if ctx.tuning == nil || ctx.Log == nil {
+		return
+	}
+	if ctx.tuningErr != nil {
+		ctx.Log.Warn("Failed to register the runtime tuning metrics", log.Err(ctx.tuningErr))
+	}
+	ctx.Log.Info("Runtime tuned to the cgroup limits",
+		log.Int("gomaxprocs", ctx.tuning.GOMAXPROCS),
+		log.Bool("gomaxprocs_set", ctx.tuning.GOMAXPROCSSet),
+		log.Int64("memory_limit", ctx.tuning.MemoryLimit),
+		log.Bool("memory_limit_set", ctx.tuning.MemoryLimitSet),
+		log.Float64("cgroup_cpu_quota", ctx.tuning.Cgroup.CPUQuota),
+		log.Int64("cgroup_memory_limit", ctx.tuning.Cgroup.MemoryLimit),
+	)
+}
+
+// EnabledAdapters returns the names of the adapters the AppContext was configured with.
+func (ctx *AppContext) EnabledAdapters() []string {
+	candidates := []struct {
+		name    string
+		enabled bool
+	}{
+		{"blame", ctx.BlameManager != nil},
+		{"paseto", ctx.PasetoManager != nil},
+		{"log", ctx.Log != nil},
+		{"nats", ctx.NATSManager != nil},
+		{"http", ctx.HttpClientManager != nil},
+		{"vault", ctx.Vault != nil},
+		{"redis", ctx.RedisManager != nil},
+		{"aws", ctx.AWSManager != nil},
+		{"database", ctx.Database != nil},
+		{"cache", ctx.Cache != nil},
+		{"cryptography", ctx.CryptoManager != nil},
+		{"email", ctx.EmailClient != nil},
+		{"oci", ctx.OCIManager != nil},
+		{"mongo", ctx.MongoManager != nil},
+		{"session", ctx.SessionManager != nil},
+		{"store", ctx.StoreManager != nil},
+		{"cloud", ctx.CloudManager != nil},
+		{"payment", ctx.Manager != nil},
+		{"geoip", ctx.GeoIPManager != nil},
+	}
+	var adapters []string
+	for _, c := range candidates {
+		if c.enabled {
+			adapters = append(adapters, c.name)
+		}
+	}
+	return adapters
+}
+
+// LogStartupDiagnostics registers the enabled adapters on diagnostics.Default and logs its
+// report. Call it once the servers started, so that their listen addresses are included; the
+// same report is served by diagnostics.Handler.
+func (ctx *AppContext) LogStartupDiagnostics() diagnostics.Report {
+	diagnostics.RegisterAdapter(ctx.EnabledAdapters()...)
+	report := diagnostics.Collect()
+	report.Log(ctx.Log)
+	return report
+}
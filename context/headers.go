@@ -0,0 +1,124 @@
+package context
+
+import (
+	natsInternal "github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/structures"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// forwardedNatsHeaders are the request headers copied onto outgoing NATS messages, as read by
+// nats.GetEssentialHeadersValuesFrom and nats.GetRequestAuthValuesFromNatsMsg.
+var forwardedNatsHeaders = []string{
+	constant.XOrgId,
+	constant.XUserId,
+	constant.XUserRole,
+	constant.XLocationId,
+	constant.XFeatureFlags,
+	constant.XSubject,
+}
+
+// natsHeadersConfig holds the NatsHeadersOption settings.
+type natsHeadersConfig struct {
+	essentials           *structures.EssentialHeaders
+	withoutAuthorization bool
+	forward              []string
+}
+
+// NatsHeadersOption configures the headers built by NatsHeaders.
+type NatsHeadersOption func(*natsHeadersConfig)
+
+// WithEssentialHeaders sets the org, user, role, location and feature flag headers from
+// headers instead of the request, e.g. when they were resolved from a token.
+func WithEssentialHeaders(headers *structures.EssentialHeaders) NatsHeadersOption {
+	return func(c *natsHeadersConfig) {
+		c.essentials = headers
+	}
+}
+
+// WithoutAuthorization leaves out the Authorization header of the request, for messages to
+// services that must not act with the caller's token.
+func WithoutAuthorization() NatsHeadersOption {
+	return func(c *natsHeadersConfig) {
+		c.withoutAuthorization = true
+	}
+}
+
+// WithForwardedHeaders copies the given request headers too.
+func WithForwardedHeaders(keys ...string) NatsHeadersOption {
+	return func(c *natsHeadersConfig) {
+		c.forward = append(c.forward, keys...)
+	}
+}
+
+// NatsHeaders builds the headers of a NATS message published on behalf of the current request:
+// the correlation and request ids, the client IP, the X-Org-Id, X-User-Id, X-User-Role,
+// X-Location-Id, X-Feature-Flags and X-Subject headers and the Authorization header of the
+// request. It is the send side of nats.GetEssentialHeadersValuesFrom. Empty values are left out.
+func (ctx *ServiceContext) NatsHeaders(options ...NatsHeadersOption) nats.Header {
+	cfg := &natsHeadersConfig{}
+	for _, opt := range options {
+		opt(cfg)
+	}
+
+	header := nats.Header{}
+	set := func(key, value string) {
+		if value != "" {
+			header.Set(key, value)
+		}
+	}
+	set(constant.CorrelationIDHeader, ctx.GetCorrelationID().String())
+	set(constant.XRequestID, string(ctx.GetRequestID()))
+
+	if ctx.Context != nil && ctx.Request != nil {
+		set(constant.IPHeader, ctx.ClientIP())
+		for _, key := range forwardedNatsHeaders {
+			set(key, ctx.GetHeader(key))
+		}
+		if !cfg.withoutAuthorization {
+			set(constant.AuthorizationHeader, ctx.GetHeader(constant.AuthorizationHeader))
+		}
+		for _, key := range cfg.forward {
+			set(key, ctx.GetHeader(key))
+		}
+	}
+
+	if essentials := cfg.essentials; essentials != nil {
+		set(constant.XOrgId, uuidString(essentials.OrgId.UUID()))
+		set(constant.XUserId, uuidString(essentials.UserId.UUID()))
+		set(constant.XUserRole, essentials.UserRole)
+		set(constant.XLocationId, uuidString(essentials.LocationId))
+		set(constant.XFeatureFlags, essentials.FeatureFlags)
+	}
+	return header
+}
+
+// NatsHeadersMiddleware returns a middleware adding NatsHeaders to the messages it publishes,
+// for PublishWithMiddleware, PublishAndWait and nats.Request. Headers already set on the
+// message, e.g. by an earlier AddHeaderMiddleware, are kept.
+func (ctx *ServiceContext) NatsHeadersMiddleware(options ...NatsHeadersOption) natsInternal.MiddlewareFunc {
+	header := ctx.NatsHeaders(options...)
+	return func(next natsInternal.NATSMsgProcessor) natsInternal.NATSMsgProcessor {
+		return func(msg *nats.Msg) blame.Blame {
+			if msg.Header == nil {
+				msg.Header = nats.Header{}
+			}
+			for key, values := range header {
+				if msg.Header.Get(key) == "" {
+					msg.Header[key] = append([]string(nil), values...)
+				}
+			}
+			return next(msg)
+		}
+	}
+}
+
+// uuidString returns id as a string, or "" for the nil UUID.
+func uuidString(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
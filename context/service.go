@@ -4,8 +4,10 @@ import (
 	natsInternal "github.com/abhissng/neuron/adapters/events/nats"
 	"github.com/abhissng/neuron/adapters/log"
 	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/structures/claims"
 	"github.com/abhissng/neuron/utils/types"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"go.uber.org/zap"
 )
@@ -59,6 +61,12 @@ func (ctx *ServiceContext) FetchGinRequestSlogFields() []types.Field {
 	if ctx.GetCookieSessionID() != "" {
 		fields = append(fields, log.String(constant.SessionID, ctx.GetCookieSessionID()))
 	}
+	if countryCode := ctx.GetCountryCode(); countryCode != "" {
+		fields = append(fields, log.String(constant.CountryCode, countryCode))
+	}
+	if actorID := ctx.GetActorID(); actorID != "" {
+		fields = append(fields, log.String(constant.UserID, ctx.GetUserID()), log.String(constant.ActorID, actorID))
+	}
 	return fields
 }
 
@@ -209,3 +217,62 @@ func (ctx *ServiceContext) GetCookieSessionID() string {
 	}
 	return ""
 }
+
+// GetCountryCode returns the client country code resolved by the geoip middleware.
+func (ctx *ServiceContext) GetCountryCode() string {
+	if ctx.Context != nil {
+		return ctx.GetString(constant.CountryCode)
+	}
+	return ""
+}
+
+// GetClaims returns the token claims stored by the paseto verification middleware.
+func (ctx *ServiceContext) GetClaims() (*claims.StandardClaims, bool) {
+	if ctx.Context == nil {
+		return nil, false
+	}
+	value, exists := ctx.Get(constant.Claims)
+	if !exists {
+		return nil, false
+	}
+	claim, ok := value.(*claims.StandardClaims)
+	return claim, ok
+}
+
+// GetUserID returns the subject of the verified token, i.e. the user the request acts as.
+func (ctx *ServiceContext) GetUserID() string {
+	if claim, ok := ctx.GetClaims(); ok {
+		return claim.Sub
+	}
+	return ""
+}
+
+// GetActorID returns the actor of a verified impersonation token, or "" when the user acts
+// itself.
+func (ctx *ServiceContext) GetActorID() string {
+	if ctx.Context != nil {
+		return ctx.GetString(constant.ActorID)
+	}
+	return ""
+}
+
+// IsImpersonated reports whether the request is made by an actor acting as the user.
+func (ctx *ServiceContext) IsImpersonated() bool {
+	return ctx.GetActorID() != ""
+}
+
+// SetClaims stores the verified claim and the identities it carries: the user under
+// constant.UserID, as the types.UserID read by request.RetrieveUserIdFromContext when the
+// subject is a UUID, and, for impersonation tokens, the actor under constant.ActorID.
+func (ctx *ServiceContext) SetClaims(claim *claims.StandardClaims) {
+	if ctx.Context == nil || claim == nil {
+		return
+	}
+	ctx.Set(constant.Claims, claim)
+	if userID, err := uuid.Parse(claim.Sub); err == nil {
+		ctx.Set(constant.UserID, types.ToUserID(userID))
+	}
+	if claim.IsImpersonated() {
+		ctx.Set(constant.ActorID, claim.ActorSubject())
+	}
+}
@@ -0,0 +1,159 @@
+package context
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+)
+
+// DefaultWarmupTimeout bounds the checks of Warmup.
+const DefaultWarmupTimeout = 30 * time.Second
+
+// WarmupCheck is a startup step of Warmup, e.g. opening a connection or priming a cache.
+type WarmupCheck struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// WithWarmupCheck adds a check run by Warmup next to those of the configured dependencies.
+func WithWarmupCheck(name string, run func(ctx context.Context) error) AppContextOption {
+	return func(appCtx *AppContext) {
+		appCtx.warmupChecks = append(appCtx.warmupChecks, WarmupCheck{Name: name, Run: run})
+	}
+}
+
+// WithWarmupTimeout bounds the checks of Warmup. Defaults to DefaultWarmupTimeout.
+func WithWarmupTimeout(timeout time.Duration) AppContextOption {
+	return func(appCtx *AppContext) {
+		appCtx.warmupTimeout = timeout
+	}
+}
+
+// WithVaultPreload fetches the given vault keys during Warmup, verifying the vault credentials
+// and priming its secret cache.
+func WithVaultPreload(keys ...string) AppContextOption {
+	return func(appCtx *AppContext) {
+		appCtx.vaultPreload = append(appCtx.vaultPreload, keys...)
+	}
+}
+
+// Warmup runs the startup checks concurrently: it pings the database, Redis and NATS, loads
+// the blame catalog and its translations, fetches the WithVaultPreload keys and runs the
+// WithWarmupCheck checks. The service is ready once every check succeeds; otherwise the
+// returned blame lists the failed checks with their errors. Call it before serving traffic.
+func (ctx *AppContext) Warmup(parent context.Context) blame.Blame {
+	timeout := ctx.warmupTimeout
+	if timeout <= 0 {
+		timeout = DefaultWarmupTimeout
+	}
+	runCtx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	checks := append(ctx.dependencyChecks(), ctx.warmupChecks...)
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			errs[i] = runWarmupCheck(runCtx, check)
+			if ctx.Log != nil {
+				ctx.Log.Info("Warmup check finished", log.String("check", check.Name), log.Duration("duration", time.Since(start)), log.Err(errs[i]))
+			}
+		}()
+	}
+	wg.Wait()
+
+	var failed []string
+	var causes []error
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, checks[i].Name)
+			causes = append(causes, fmt.Errorf("%s: %w", checks[i].Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		ctx.ready.Store(false)
+		return blame.WarmupFailedError(strings.Join(failed, ", "), causes...)
+	}
+	ctx.ready.Store(true)
+	return nil
+}
+
+// IsReady reports whether the last Warmup succeeded, for readiness probes.
+func (ctx *AppContext) IsReady() bool {
+	return ctx.ready.Load()
+}
+
+// runWarmupCheck runs check, giving up when ctx is done first.
+func runWarmupCheck(ctx context.Context, check WarmupCheck) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- check.Run(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dependencyChecks returns the checks of the configured dependencies.
+func (ctx *AppContext) dependencyChecks() []WarmupCheck {
+	var checks []WarmupCheck
+	if ctx.Database != nil {
+		checks = append(checks, WarmupCheck{Name: "database", Run: func(context.Context) error {
+			return ctx.Database.Ping()
+		}})
+	}
+	if ctx.RedisManager != nil {
+		checks = append(checks, WarmupCheck{Name: "redis", Run: func(c context.Context) error {
+			return ctx.RedisManager.Client().Ping(c).Err()
+		}})
+	}
+	if ctx.NATSManager != nil {
+		checks = append(checks, WarmupCheck{Name: "nats", Run: func(context.Context) error {
+			return ctx.NATSManager.Ping()
+		}})
+	}
+	checks = append(checks, WarmupCheck{Name: "blame", Run: func(context.Context) error {
+		if ctx.BlameManager == nil {
+			return errors.New("blame manager is empty")
+		}
+		if len(ctx.BlameDefinitions) == 0 {
+			return errors.New("no blame definitions loaded")
+		}
+		// Translating loads the message templates and the i18n bundle.
+		if message, _ := blame.GeneralKnownError(errors.New("warmup")).Translate(); message == "" {
+			return errors.New("blame translation is empty")
+		}
+		return nil
+	}})
+	if len(ctx.vaultPreload) > 0 {
+		checks = append(checks, WarmupCheck{Name: "vault", Run: func(context.Context) error {
+			if ctx.Vault == nil {
+				return errors.New("vault is not configured")
+			}
+			for _, key := range ctx.vaultPreload {
+				if _, err := ctx.Vault.FetchVaultValue(key); err != nil {
+					return fmt.Errorf("fetch %s: %w", key, err)
+				}
+			}
+			return nil
+		}})
+	}
+	return checks
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/types"
+)
+
+// Executor is implemented by both Database and Transaction.
+type Executor interface {
+	Query(ctx context.Context, query string, args ...any) (Rows, error)
+	QueryRow(ctx context.Context, query string, args ...any) Row
+	Exec(ctx context.Context, query string, args ...any) (ExecResult, error)
+}
+
+// Auditable is implemented by records carrying created/updated audit columns.
+type Auditable interface {
+	StampCreated(actor string, at time.Time)
+	StampUpdated(actor string, at time.Time)
+}
+
+// SoftDeletable is implemented by records carrying a deleted_at column.
+type SoftDeletable interface {
+	StampDeleted(at time.Time)
+	IsDeleted() bool
+}
+
+// AuditFields holds the conventional audit columns. Embed it in a record to make it
+// Auditable and SoftDeletable.
+type AuditFields struct {
+	CreatedAt time.Time  `db:"created_at" json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at" bson:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	CreatedBy string     `db:"created_by" json:"created_by,omitempty" bson:"created_by,omitempty"`
+	UpdatedBy string     `db:"updated_by" json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+}
+
+// StampCreated sets the created and updated columns.
+func (a *AuditFields) StampCreated(actor string, at time.Time) {
+	a.CreatedAt, a.CreatedBy = at, actor
+	a.UpdatedAt, a.UpdatedBy = at, actor
+}
+
+// StampUpdated sets the updated columns.
+func (a *AuditFields) StampUpdated(actor string, at time.Time) {
+	a.UpdatedAt, a.UpdatedBy = at, actor
+}
+
+// StampDeleted marks the record as soft deleted.
+func (a *AuditFields) StampDeleted(at time.Time) {
+	a.DeletedAt = &at
+	a.UpdatedAt = at
+}
+
+// IsDeleted reports whether the record is soft deleted.
+func (a *AuditFields) IsDeleted() bool {
+	return a.DeletedAt != nil
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the user id written to created_by and updated_by.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, falling back to the user_id value
+// set by the request middlewares.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		return actor
+	}
+	switch actor := ctx.Value(constant.UserID).(type) {
+	case types.UserID:
+		return actor.String()
+	case string:
+		return actor
+	}
+	return ""
+}
+
+// NotDeleted returns the condition matching live rows, optionally qualified by a table alias,
+// e.g. NotDeleted("u") gives "u.deleted_at IS NULL".
+func NotDeleted(alias string) string {
+	if alias == "" {
+		return constant.DeletedAtColumn + " IS NULL"
+	}
+	return alias + "." + constant.DeletedAtColumn + " IS NULL"
+}
+
+// WhereNotDeleted appends the live row condition to a WHERE condition.
+func WhereNotDeleted(where string) string {
+	if strings.TrimSpace(where) == "" {
+		return NotDeleted("")
+	}
+	return "(" + where + ") AND " + NotDeleted("")
+}
+
+// SoftDelete sets deleted_at and updated_at on the live rows of table matching where. The
+// condition uses the placeholders of dbType; the timestamp is bound after its arguments.
+func SoftDelete(ctx context.Context, exec Executor, dbType types.DBType, table, where string, args ...any) (ExecResult, error) {
+	return stampDeleted(ctx, exec, dbType, table, where, time.Now().UTC(), ActorFromContext(ctx), args)
+}
+
+// Restore clears deleted_at on the soft deleted rows of table matching where.
+func Restore(ctx context.Context, exec Executor, dbType types.DBType, table, where string, args ...any) (ExecResult, error) {
+	if where == "" {
+		return nil, errors.New("restore requires a where condition")
+	}
+	now := placeholder(dbType, len(args)+1)
+	by := placeholder(dbType, len(args)+2)
+	query := fmt.Sprintf("UPDATE %s SET %s = NULL, %s = %s, %s = %s WHERE (%s) AND %s IS NOT NULL",
+		table, constant.DeletedAtColumn, constant.UpdatedAtColumn, now, constant.UpdatedByColumn, by, where, constant.DeletedAtColumn)
+	return exec.Exec(ctx, query, bindArgs(dbType, args, time.Now().UTC(), ActorFromContext(ctx))...)
+}
+
+// Purge permanently deletes rows of table soft deleted before cutoff, batchSize rows per
+// statement so large purges do not hold long locks. It returns the number of rows removed.
+func Purge(ctx context.Context, exec Executor, dbType types.DBType, table string, cutoff time.Time, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	var query string
+	switch dbType {
+	case constant.MySQL:
+		query = fmt.Sprintf("DELETE FROM %s WHERE %s < ? LIMIT %d", table, constant.DeletedAtColumn, batchSize)
+	default:
+		query = fmt.Sprintf("DELETE FROM %[1]s WHERE ctid IN (SELECT ctid FROM %[1]s WHERE %[2]s < $1 LIMIT %[3]d)",
+			table, constant.DeletedAtColumn, batchSize)
+	}
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		result, err := exec.Exec(ctx, query, cutoff)
+		if err != nil {
+			return total, fmt.Errorf("failed to purge %s: %w", table, err)
+		}
+		affected := result.RowsAffected()
+		total += affected
+		if affected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+func stampDeleted(ctx context.Context, exec Executor, dbType types.DBType, table, where string, at time.Time, actor string, args []any) (ExecResult, error) {
+	if where == "" {
+		return nil, errors.New("soft delete requires a where condition")
+	}
+	deletedAt := placeholder(dbType, len(args)+1)
+	updatedAt := placeholder(dbType, len(args)+2)
+	updatedBy := placeholder(dbType, len(args)+3)
+	query := fmt.Sprintf("UPDATE %s SET %s = %s, %s = %s, %s = %s WHERE %s",
+		table, constant.DeletedAtColumn, deletedAt, constant.UpdatedAtColumn, updatedAt,
+		constant.UpdatedByColumn, updatedBy, WhereNotDeleted(where))
+	return exec.Exec(ctx, query, bindArgs(dbType, args, at, at, actor)...)
+}
+
+// bindArgs combines the SET arguments with the WHERE arguments. Postgres placeholders number
+// the WHERE arguments first, MySQL binds in textual order with SET first.
+func bindArgs(dbType types.DBType, whereArgs []any, setArgs ...any) []any {
+	if dbType == constant.MySQL {
+		return append(setArgs, whereArgs...)
+	}
+	return append(slices.Clone(whereArgs), setArgs...)
+}
+
+// placeholder returns the n-th (1-based) bind placeholder for dbType.
+func placeholder(dbType types.DBType, n int) string {
+	if dbType == constant.MySQL {
+		return "?"
+	}
+	return "$" + strconv.Itoa(n)
+}
@@ -0,0 +1,376 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/abhissng/neuron/utils/cryptography"
+)
+
+// Column encryption modes for the encrypt struct tag
+const (
+	// EncryptDeterministic always produces the same ciphertext for the same value, so the
+	// column can be compared for equality. It reveals which rows share a value.
+	EncryptDeterministic = "deterministic"
+	// EncryptRandom uses a fresh nonce for every value.
+	EncryptRandom = "random"
+)
+
+// encryptedPrefix marks encrypted column values so scans can recognise them.
+const encryptedPrefix = "enc:v1:"
+
+// FieldCipher encrypts and decrypts column values and derives blind indexes for them.
+type FieldCipher interface {
+	Encrypt(plaintext []byte, deterministic bool) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+	BlindIndex(value []byte) string
+}
+
+// KMSDecrypter unwraps data keys with a key management service, e.g. aws.AWSManager.
+type KMSDecrypter interface {
+	DecryptWithKMS(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// AESFieldCipher encrypts columns with AES-256-GCM. Deterministic values use a nonce derived
+// from an HMAC of the plaintext; blind indexes are HMAC-SHA256 under a separate key.
+type AESFieldCipher struct {
+	aead     cipher.AEAD
+	nonceKey []byte
+	indexKey []byte
+}
+
+// NewAESFieldCipher creates an AESFieldCipher from a 32 byte data key and a blind index key of
+// at least 16 bytes.
+func NewAESFieldCipher(dataKey, indexKey []byte) (*AESFieldCipher, error) {
+	if len(dataKey) != 32 {
+		return nil, fmt.Errorf("data key must be 32 bytes, got %d", len(dataKey))
+	}
+	if len(indexKey) < 16 {
+		return nil, errors.New("blind index key must be at least 16 bytes")
+	}
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// Derive a separate key for synthetic nonces so they reveal nothing about the data key.
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte("neuron-deterministic-nonce"))
+	return &AESFieldCipher{aead: aead, nonceKey: mac.Sum(nil), indexKey: indexKey}, nil
+}
+
+// NewKMSFieldCipher unwraps the data and blind index keys with KMS and returns an
+// AESFieldCipher using them.
+func NewKMSFieldCipher(ctx context.Context, kms KMSDecrypter, wrappedDataKey, wrappedIndexKey []byte) (*AESFieldCipher, error) {
+	dataKey, err := kms.DecryptWithKMS(ctx, wrappedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	indexKey, err := kms.DecryptWithKMS(ctx, wrappedIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap blind index key: %w", err)
+	}
+	return NewAESFieldCipher(dataKey, indexKey)
+}
+
+// NewCryptoManagerFieldCipher unwraps data and blind index keys encrypted with
+// CryptoManager.Encrypt and returns an AESFieldCipher using them.
+func NewCryptoManagerFieldCipher(manager *cryptography.CryptoManager, wrappedDataKey, wrappedIndexKey string) (*AESFieldCipher, error) {
+	dataKey, err := manager.Decrypt(wrappedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	indexKey, err := manager.Decrypt(wrappedIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap blind index key: %w", err)
+	}
+	return NewAESFieldCipher(dataKey, indexKey)
+}
+
+// Encrypt encrypts plaintext and returns a prefixed base64 value for storage.
+func (c *AESFieldCipher) Encrypt(plaintext []byte, deterministic bool) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if deterministic {
+		mac := hmac.New(sha256.New, c.nonceKey)
+		mac.Write(plaintext)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt.
+func (c *AESFieldCipher) Decrypt(ciphertext string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(ciphertext, encryptedPrefix)
+	if !ok {
+		return nil, errors.New("value is not encrypted")
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+}
+
+// BlindIndex returns the keyed hash of value stored in blind index columns. Compute it for a
+// search term to look rows up without decrypting them.
+func (c *AESFieldCipher) BlindIndex(value []byte) string {
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write(value)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// IsEncrypted reports whether value was produced by a FieldCipher.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// EncryptRecord returns a copy of record with the fields tagged encrypt:"deterministic" or
+// encrypt:"random" encrypted and the fields tagged blind_index:"<Field>" set to the blind
+// index of the named field, ready to insert. Tagged fields must be string, *string or []byte.
+//
+//	type Customer struct {
+//		Email      string `db:"email" encrypt:"deterministic"`
+//		EmailIndex string `db:"email_bidx" blind_index:"Email"`
+//		Phone      string `db:"phone" encrypt:"random"`
+//	}
+func EncryptRecord[T any](c FieldCipher, record T) (T, error) {
+	out := reflect.New(reflect.TypeOf(&record).Elem()).Elem()
+	out.Set(reflect.ValueOf(&record).Elem())
+	target := out
+	if target.Kind() == reflect.Pointer {
+		if target.IsNil() {
+			return record, nil
+		}
+		clone := reflect.New(target.Type().Elem())
+		clone.Elem().Set(target.Elem())
+		out.Set(clone)
+		target = clone.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		return record, fmt.Errorf("cannot encrypt %s: not a struct", target.Type())
+	}
+
+	info := encryptedFieldsOf(target.Type())
+	for _, field := range info.indexes {
+		plaintext, ok, err := fieldBytes(target.Field(field.source))
+		if err != nil {
+			return record, err
+		}
+		if !ok {
+			continue
+		}
+		if err := setFieldBytes(target.Field(field.index), []byte(c.BlindIndex(plaintext))); err != nil {
+			return record, err
+		}
+	}
+	for _, field := range info.encrypted {
+		plaintext, ok, err := fieldBytes(target.Field(field.index))
+		if err != nil {
+			return record, err
+		}
+		if !ok {
+			continue
+		}
+		ciphertext, err := c.Encrypt(plaintext, field.deterministic)
+		if err != nil {
+			return record, fmt.Errorf("failed to encrypt %s: %w", field.name, err)
+		}
+		if err := setFieldBytes(target.Field(field.index), []byte(ciphertext)); err != nil {
+			return record, err
+		}
+	}
+	return out.Interface().(T), nil
+}
+
+// DecryptRecord decrypts the encrypt tagged fields of the struct record points to in place,
+// e.g. after scanning a row into it. Values that are not encrypted are left as they are so
+// columns can be migrated gradually.
+func DecryptRecord(c FieldCipher, record any) error {
+	target := reflect.ValueOf(record)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decrypt %T: not a pointer to a struct", record)
+	}
+	target = target.Elem()
+	for _, field := range encryptedFieldsOf(target.Type()).encrypted {
+		value, ok, err := fieldBytes(target.Field(field.index))
+		if err != nil {
+			return err
+		}
+		if !ok || !IsEncrypted(string(value)) {
+			continue
+		}
+		plaintext, err := c.Decrypt(string(value))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", field.name, err)
+		}
+		if err := setFieldBytes(target.Field(field.index), plaintext); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecryptingRows wraps rows so Scan decrypts encrypted values scanned into *string, **string
+// and *[]byte destinations.
+func DecryptingRows(rows Rows, c FieldCipher) Rows {
+	return &decryptingRows{Rows: rows, cipher: c}
+}
+
+// DecryptingRow wraps row so Scan decrypts encrypted values like DecryptingRows.
+func DecryptingRow(row Row, c FieldCipher) Row {
+	return &decryptingRow{row: row, cipher: c}
+}
+
+type decryptingRows struct {
+	Rows
+	cipher FieldCipher
+}
+
+func (r *decryptingRows) Scan(dest ...any) error {
+	if err := r.Rows.Scan(dest...); err != nil {
+		return err
+	}
+	return decryptDestinations(r.cipher, dest)
+}
+
+type decryptingRow struct {
+	row    Row
+	cipher FieldCipher
+}
+
+func (r *decryptingRow) Scan(dest ...any) error {
+	if err := r.row.Scan(dest...); err != nil {
+		return err
+	}
+	return decryptDestinations(r.cipher, dest)
+}
+
+func decryptDestinations(c FieldCipher, dest []any) error {
+	for i, d := range dest {
+		var value *string
+		switch v := d.(type) {
+		case *string:
+			value = v
+		case **string:
+			value = *v
+		case *[]byte:
+			if IsEncrypted(string(*v)) {
+				plaintext, err := c.Decrypt(string(*v))
+				if err != nil {
+					return fmt.Errorf("failed to decrypt column %d: %w", i, err)
+				}
+				*v = plaintext
+			}
+			continue
+		}
+		if value == nil || !IsEncrypted(*value) {
+			continue
+		}
+		plaintext, err := c.Decrypt(*value)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt column %d: %w", i, err)
+		}
+		*value = string(plaintext)
+	}
+	return nil
+}
+
+type encryptedField struct {
+	name          string
+	index         int
+	deterministic bool
+}
+
+type blindIndexField struct {
+	index  int
+	source int
+}
+
+type encryptedStruct struct {
+	encrypted []encryptedField
+	indexes   []blindIndexField
+}
+
+var encryptedFieldsCache sync.Map // map[reflect.Type]*encryptedStruct
+
+// encryptedFieldsOf returns the cached encrypt and blind_index fields of struct type t.
+func encryptedFieldsOf(t reflect.Type) *encryptedStruct {
+	if cached, ok := encryptedFieldsCache.Load(t); ok {
+		return cached.(*encryptedStruct)
+	}
+	info := &encryptedStruct{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		switch field.Tag.Get("encrypt") {
+		case EncryptDeterministic:
+			info.encrypted = append(info.encrypted, encryptedField{name: field.Name, index: i, deterministic: true})
+		case EncryptRandom:
+			info.encrypted = append(info.encrypted, encryptedField{name: field.Name, index: i})
+		}
+		if source := field.Tag.Get("blind_index"); source != "" {
+			if sourceField, ok := t.FieldByName(source); ok && len(sourceField.Index) == 1 {
+				info.indexes = append(info.indexes, blindIndexField{index: i, source: sourceField.Index[0]})
+			}
+		}
+	}
+	actual, _ := encryptedFieldsCache.LoadOrStore(t, info)
+	return actual.(*encryptedStruct)
+}
+
+// fieldBytes returns the content of a string, *string or []byte field and whether it is set.
+func fieldBytes(v reflect.Value) ([]byte, bool, error) {
+	switch {
+	case v.Kind() == reflect.String:
+		return []byte(v.String()), v.Len() > 0, nil
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.String:
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		return []byte(v.Elem().String()), true, nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return v.Bytes(), v.Len() > 0, nil
+	default:
+		return nil, false, fmt.Errorf("unsupported encrypted field type %s", v.Type())
+	}
+}
+
+// setFieldBytes stores data in a string, *string or []byte field.
+func setFieldBytes(v reflect.Value, data []byte) error {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(string(data))
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.String:
+		s := reflect.New(v.Type().Elem())
+		s.Elem().SetString(string(data))
+		v.Set(s)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		v.SetBytes(data)
+	default:
+		return fmt.Errorf("unsupported encrypted field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+)
+
+// ErrTenantMissing is returned when no tenant id can be resolved for a request.
+var ErrTenantMissing = errors.New("tenant id is missing")
+
+// TenantConnector opens the database of a tenant, e.g. with a tenant specific DSN or
+// search_path.
+type TenantConnector func(ctx context.Context, tenantID string) (Database, error)
+
+// TenantPoolManager keeps a connection pool per tenant for isolated-tenant deployments. The
+// least recently used idle pools are closed once more than the configured number of tenants
+// are open, and each tenant can be limited to a number of concurrent acquisitions.
+type TenantPoolManager struct {
+	connect      TenantConnector
+	maxTenants   int
+	idleTimeout  time.Duration
+	defaultLimit int
+	limits       map[string]int
+	logger       *log.Log
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	closed  bool
+}
+
+type tenantPool struct {
+	id       string
+	db       Database
+	err      error
+	ready    chan struct{}
+	slots    chan struct{}
+	refs     int
+	lastUsed time.Time
+}
+
+// TenantPoolOption configures a TenantPoolManager.
+type TenantPoolOption func(*TenantPoolManager)
+
+// WithMaxTenants sets how many tenant pools are kept open. Defaults to 50.
+func WithMaxTenants(n int) TenantPoolOption {
+	return func(m *TenantPoolManager) {
+		m.maxTenants = n
+	}
+}
+
+// WithTenantIdleTimeout closes pools that have not been used for d. Zero keeps them until
+// they are evicted.
+func WithTenantIdleTimeout(d time.Duration) TenantPoolOption {
+	return func(m *TenantPoolManager) {
+		m.idleTimeout = d
+	}
+}
+
+// WithDefaultTenantLimit limits every tenant to n concurrent acquisitions. Zero means no limit.
+func WithDefaultTenantLimit(n int) TenantPoolOption {
+	return func(m *TenantPoolManager) {
+		m.defaultLimit = n
+	}
+}
+
+// WithTenantLimit overrides the concurrent acquisition limit of one tenant.
+func WithTenantLimit(tenantID string, n int) TenantPoolOption {
+	return func(m *TenantPoolManager) {
+		m.limits[tenantID] = n
+	}
+}
+
+// WithTenantPoolLogger sets the logger used to report pools that fail to close.
+func WithTenantPoolLogger(logger *log.Log) TenantPoolOption {
+	return func(m *TenantPoolManager) {
+		m.logger = logger
+	}
+}
+
+// NewTenantPoolManager creates a TenantPoolManager opening pools with connect.
+func NewTenantPoolManager(connect TenantConnector, opts ...TenantPoolOption) *TenantPoolManager {
+	m := &TenantPoolManager{
+		connect:    connect,
+		maxTenants: 50,
+		limits:     map[string]int{},
+		lru:        list.New(),
+		entries:    map[string]*list.Element{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return m
+}
+
+// Acquire returns the database of tenantID, connecting it on first use, and reserves one of
+// the tenant's slots until release is called. A pool is never evicted while acquired.
+func (m *TenantPoolManager) Acquire(ctx context.Context, tenantID string) (Database, func(), error) {
+	if tenantID == "" {
+		return nil, nil, ErrTenantMissing
+	}
+	pool, err := m.pool(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pool.slots != nil {
+		select {
+		case pool.slots <- struct{}{}:
+		case <-ctx.Done():
+			m.release(pool)
+			return nil, nil, ctx.Err()
+		}
+	}
+	var once sync.Once
+	return pool.db, func() {
+		once.Do(func() {
+			if pool.slots != nil {
+				<-pool.slots
+			}
+			m.release(pool)
+		})
+	}, nil
+}
+
+// Get returns the database of tenantID without reserving a slot. The pool may be closed by a
+// later eviction, so prefer Acquire for work that outlives the call.
+func (m *TenantPoolManager) Get(ctx context.Context, tenantID string) (Database, error) {
+	db, release, err := m.Acquire(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return db, nil
+}
+
+// AcquireFromContext acquires the pool of the tenant set on ctx with WithTenantID.
+func (m *TenantPoolManager) AcquireFromContext(ctx context.Context) (Database, func(), error) {
+	return m.Acquire(ctx, TenantIDFromContext(ctx))
+}
+
+// Evict closes the pool of tenantID once it is no longer acquired.
+func (m *TenantPoolManager) Evict(tenantID string) {
+	m.mu.Lock()
+	element, ok := m.entries[tenantID]
+	var pool *tenantPool
+	if ok {
+		pool = element.Value.(*tenantPool)
+		if pool.refs > 0 {
+			pool, ok = nil, false
+		} else {
+			m.remove(element)
+		}
+	}
+	m.mu.Unlock()
+	if ok {
+		m.closePool(pool)
+	}
+}
+
+// Tenants returns the tenants with an open pool, most recently used first.
+func (m *TenantPoolManager) Tenants() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenants := make([]string, 0, m.lru.Len())
+	for element := m.lru.Front(); element != nil; element = element.Next() {
+		tenants = append(tenants, element.Value.(*tenantPool).id)
+	}
+	return tenants
+}
+
+// Close closes every pool. Acquire fails afterwards.
+func (m *TenantPoolManager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	pools := make([]*tenantPool, 0, m.lru.Len())
+	for element := m.lru.Front(); element != nil; element = element.Next() {
+		pools = append(pools, element.Value.(*tenantPool))
+	}
+	m.lru.Init()
+	m.entries = map[string]*list.Element{}
+	m.mu.Unlock()
+
+	var errs []error
+	for _, pool := range pools {
+		<-pool.ready
+		if pool.db != nil {
+			if err := pool.db.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: %w", pool.id, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pool returns the referenced pool of tenantID, connecting it when missing.
+func (m *TenantPoolManager) pool(ctx context.Context, tenantID string) (*tenantPool, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, errors.New("tenant pool manager is closed")
+	}
+	if element, ok := m.entries[tenantID]; ok {
+		pool := element.Value.(*tenantPool)
+		pool.refs++
+		m.lru.MoveToFront(element)
+		m.mu.Unlock()
+		<-pool.ready
+		if pool.err != nil {
+			m.release(pool)
+			return nil, pool.err
+		}
+		return pool, nil
+	}
+
+	pool := &tenantPool{id: tenantID, ready: make(chan struct{}), refs: 1}
+	limit := m.defaultLimit
+	if n, ok := m.limits[tenantID]; ok {
+		limit = n
+	}
+	if limit > 0 {
+		pool.slots = make(chan struct{}, limit)
+	}
+	m.entries[tenantID] = m.lru.PushFront(pool)
+	evicted := m.evictLocked()
+	m.mu.Unlock()
+
+	for _, old := range evicted {
+		m.closePool(old)
+	}
+
+	pool.db, pool.err = m.connect(ctx, tenantID)
+	if pool.err != nil {
+		pool.err = fmt.Errorf("failed to connect tenant %s: %w", tenantID, pool.err)
+	}
+	close(pool.ready)
+	if pool.err != nil {
+		m.mu.Lock()
+		if element, ok := m.entries[tenantID]; ok && element.Value == pool {
+			m.remove(element)
+		}
+		m.mu.Unlock()
+		return nil, pool.err
+	}
+	return pool, nil
+}
+
+// release drops a reference and records the use.
+func (m *TenantPoolManager) release(pool *tenantPool) {
+	m.mu.Lock()
+	pool.refs--
+	pool.lastUsed = time.Now()
+	m.mu.Unlock()
+}
+
+// evictLocked removes idle pools beyond the tenant limit or idle timeout, least recently used
+// first, and returns them for closing.
+func (m *TenantPoolManager) evictLocked() []*tenantPool {
+	var evicted []*tenantPool
+	now := time.Now()
+	for element := m.lru.Back(); element != nil; {
+		prev := element.Prev()
+		pool := element.Value.(*tenantPool)
+		expired := m.idleTimeout > 0 && !pool.lastUsed.IsZero() && now.Sub(pool.lastUsed) > m.idleTimeout
+		if pool.refs == 0 && (m.lru.Len() > m.maxTenants || expired) {
+			m.remove(element)
+			evicted = append(evicted, pool)
+		}
+		element = prev
+	}
+	return evicted
+}
+
+func (m *TenantPoolManager) remove(element *list.Element) {
+	pool := m.lru.Remove(element).(*tenantPool)
+	delete(m.entries, pool.id)
+}
+
+func (m *TenantPoolManager) closePool(pool *tenantPool) {
+	<-pool.ready
+	if pool.db == nil {
+		return
+	}
+	if err := pool.db.Close(); err != nil {
+		m.logger.Error(constant.SystemWarning, log.String("tenant", pool.id), log.Err(err))
+	}
+}
+
+type tenantKey struct{}
+
+// WithTenantID returns a context carrying the tenant id used by AcquireFromContext.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantIDFromContext returns the tenant id set with WithTenantID.
+func TenantIDFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
+
+// SearchPathDSN returns dsn with the Postgres search_path set to schema, for deployments that
+// isolate tenants by schema rather than by database.
+func SearchPathDSN(dsn, schema string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		query := parsed.Query()
+		query.Set("search_path", schema)
+		parsed.RawQuery = query.Encode()
+		return parsed.String(), nil
+	}
+	// Keyword/value DSN, e.g. "host=localhost dbname=app".
+	return strings.TrimSpace(dsn) + " search_path=" + schema, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/types"
+)
+
+// TxManager runs functions in transactions and gives them a single timestamp and actor to
+// populate audit columns with, so every row written by one transaction carries the same
+// created_at/updated_at.
+type TxManager struct {
+	db     Database
+	dbType types.DBType
+	clock  func() time.Time
+}
+
+// TxManagerOption configures a TxManager.
+type TxManagerOption func(*TxManager)
+
+// WithTxDBType sets the database type used for placeholders. Defaults to PostgreSQL.
+func WithTxDBType(dbType types.DBType) TxManagerOption {
+	return func(m *TxManager) {
+		m.dbType = dbType
+	}
+}
+
+// WithTxClock sets the clock audit timestamps are taken from. Defaults to time.Now in UTC.
+func WithTxClock(clock func() time.Time) TxManagerOption {
+	return func(m *TxManager) {
+		m.clock = clock
+	}
+}
+
+// NewTxManager creates a TxManager for db.
+func NewTxManager(db Database, opts ...TxManagerOption) *TxManager {
+	m := &TxManager{
+		db:     db,
+		dbType: constant.PostgreSQL,
+		clock:  func() time.Time { return time.Now().UTC() },
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// AuditTx is a Transaction carrying the timestamp and actor of a TxManager run.
+type AuditTx struct {
+	Transaction
+	dbType types.DBType
+	now    time.Time
+	actor  string
+}
+
+// Run begins a transaction, calls fn and commits when it returns nil. The transaction is
+// rolled back when fn returns an error or panics; panics are re-raised after the rollback.
+func (m *TxManager) Run(ctx context.Context, fn func(ctx context.Context, tx *AuditTx) error) (err error) {
+	tx, err := m.db.BeginTransaction(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	auditTx := &AuditTx{Transaction: tx, dbType: m.dbType, now: m.clock(), actor: ActorFromContext(ctx)}
+
+	defer func() {
+		if r := recover(); r != nil {
+			m.rollback(ctx, tx)
+			panic(r)
+		}
+	}()
+	if err = fn(ctx, auditTx); err != nil {
+		m.rollback(ctx, tx)
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (m *TxManager) rollback(ctx context.Context, tx Transaction) {
+	// The caller's context may already be cancelled, which would abort the rollback.
+	if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil && m.db.GetLogger() != nil {
+		m.db.GetLogger().Error(constant.SystemError, log.Err(err))
+	}
+}
+
+// Now returns the transaction timestamp.
+func (t *AuditTx) Now() time.Time {
+	return t.now
+}
+
+// Actor returns the user id recorded in created_by and updated_by.
+func (t *AuditTx) Actor() string {
+	return t.actor
+}
+
+// StampCreated fills the created and updated columns of records implementing Auditable.
+func (t *AuditTx) StampCreated(records ...any) {
+	for _, record := range records {
+		if auditable, ok := record.(Auditable); ok {
+			auditable.StampCreated(t.actor, t.now)
+		}
+	}
+}
+
+// StampUpdated fills the updated columns of records implementing Auditable.
+func (t *AuditTx) StampUpdated(records ...any) {
+	for _, record := range records {
+		if auditable, ok := record.(Auditable); ok {
+			auditable.StampUpdated(t.actor, t.now)
+		}
+	}
+}
+
+// SoftDelete soft deletes the live rows of table matching where with the transaction
+// timestamp.
+func (t *AuditTx) SoftDelete(ctx context.Context, table, where string, args ...any) (ExecResult, error) {
+	return stampDeleted(ctx, t, t.dbType, table, where, t.now, t.actor, args)
+}
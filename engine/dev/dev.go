@@ -0,0 +1,188 @@
+// Package dev runs a service end to end without external dependencies: it starts an embedded
+// NATS server, an in-process Redis, an in-memory vault and in-memory object storage, and
+// provides the AppContext options wiring them in place of the production ones.
+package dev
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/cloud"
+	natsInternal "github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/adapters/redis"
+	"github.com/abhissng/neuron/adapters/vault"
+	"github.com/abhissng/neuron/context"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/spf13/viper"
+)
+
+// startTimeout bounds the start of the embedded NATS server.
+const startTimeout = 10 * time.Second
+
+// Enabled reports whether the NEURON_DEV environment variable, or configuration key, is true.
+func Enabled() bool {
+	value := os.Getenv(constant.NeuronDev)
+	if value == "" {
+		value = viper.GetString(constant.NeuronDev)
+	}
+	enabled, _ := strconv.ParseBool(value)
+	return enabled
+}
+
+// Environment is a running set of embedded dependencies.
+type Environment struct {
+	NATS    *server.Server
+	Redis   *miniredis.Miniredis
+	Vault   *vault.Vault
+	Storage cloud.CloudManager
+
+	natsOptions []natsInternal.Option
+	jetStream   bool
+	storeDir    string
+}
+
+// Option configures an Environment.
+type Option func(*config)
+
+// config holds the Option settings.
+type config struct {
+	jetStream   bool
+	storeDir    string
+	natsPort    int
+	secrets     map[string]string
+	natsOptions []natsInternal.Option
+}
+
+// WithJetStream enables JetStream on the embedded NATS server, storing streams in storeDir,
+// or in a temporary directory removed on Close when storeDir is empty.
+func WithJetStream(storeDir string) Option {
+	return func(c *config) {
+		c.jetStream = true
+		c.storeDir = storeDir
+	}
+}
+
+// WithNATSPort sets the port of the embedded NATS server. Defaults to a random free port.
+func WithNATSPort(port int) Option {
+	return func(c *config) {
+		c.natsPort = port
+	}
+}
+
+// WithSecrets sets the secrets of the in-memory vault.
+func WithSecrets(secrets map[string]string) Option {
+	return func(c *config) {
+		c.secrets = secrets
+	}
+}
+
+// WithNATSOptions adds options to the NATS manager of AppContextOptions.
+func WithNATSOptions(options ...natsInternal.Option) Option {
+	return func(c *config) {
+		c.natsOptions = append(c.natsOptions, options...)
+	}
+}
+
+// StartIfEnabled starts an Environment when Enabled, and otherwise returns nil.
+func StartIfEnabled(opts ...Option) (*Environment, error) {
+	if !Enabled() {
+		return nil, nil
+	}
+	return Start(opts...)
+}
+
+// Start starts the embedded dependencies. Close stops them.
+func Start(opts ...Option) (*Environment, error) {
+	cfg := &config{natsPort: server.RANDOM_PORT}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	env := &Environment{
+		Vault:       vault.NewMemoryVault(cfg.secrets),
+		Storage:     cloud.NewMemoryCloudManager(),
+		natsOptions: cfg.natsOptions,
+		jetStream:   cfg.jetStream,
+	}
+
+	natsOpts := &server.Options{Host: "127.0.0.1", Port: cfg.natsPort, NoSigs: true}
+	if cfg.jetStream {
+		natsOpts.JetStream = true
+		natsOpts.StoreDir = cfg.storeDir
+		if natsOpts.StoreDir == "" {
+			dir, err := os.MkdirTemp("", "neuron-dev-jetstream-")
+			if err != nil {
+				return nil, fmt.Errorf("failed to create jetstream store: %w", err)
+			}
+			natsOpts.StoreDir = dir
+			env.storeDir = dir
+		}
+	}
+	ns, err := server.NewServer(natsOpts)
+	if err != nil {
+		env.Close()
+		return nil, fmt.Errorf("failed to create embedded nats server: %w", err)
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(startTimeout) {
+		ns.Shutdown()
+		env.Close()
+		return nil, fmt.Errorf("embedded nats server not ready after %s", startTimeout)
+	}
+	env.NATS = ns
+
+	rs, err := miniredis.Run()
+	if err != nil {
+		env.Close()
+		return nil, fmt.Errorf("failed to start embedded redis: %w", err)
+	}
+	env.Redis = rs
+	return env, nil
+}
+
+// NATSURL returns the client URL of the embedded NATS server.
+func (e *Environment) NATSURL() string {
+	return e.NATS.ClientURL()
+}
+
+// RedisAddr returns the address of the embedded Redis.
+func (e *Environment) RedisAddr() string {
+	return e.Redis.Addr()
+}
+
+// AppContextOptions returns the options attaching the NATS manager, Redis manager, vault and
+// cloud manager of the environment to an AppContext. Place them after the other options.
+func (e *Environment) AppContextOptions() ([]context.AppContextOption, error) {
+	rm, err := redis.NewRedisManager(redis.NewConfig(redis.WithAddress(e.RedisAddr())))
+	if err != nil {
+		return nil, err
+	}
+	natsOptions := e.natsOptions
+	if e.jetStream {
+		natsOptions = append([]natsInternal.Option{natsInternal.WithJetStream(natsInternal.NewJetStreamOptions())}, natsOptions...)
+	}
+	return []context.AppContextOption{
+		context.WithNATSManager(e.NATSURL(), natsOptions...),
+		context.WithRedisManager(rm),
+		context.WithVault(e.Vault),
+		context.WithCloudManager(e.Storage),
+	}, nil
+}
+
+// Close stops the embedded dependencies and removes the temporary JetStream store.
+func (e *Environment) Close() {
+	if e.NATS != nil {
+		e.NATS.Shutdown()
+		e.NATS.WaitForShutdown()
+	}
+	if e.Redis != nil {
+		e.Redis.Close()
+	}
+	if e.storeDir != "" {
+		_ = os.RemoveAll(e.storeDir)
+	}
+}
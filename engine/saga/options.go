@@ -0,0 +1,63 @@
+package saga
+
+import (
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/clock"
+)
+
+const (
+	// DefaultStepTimeout bounds the action and the compensation of steps without a Timeout.
+	DefaultStepTimeout = 30 * time.Second
+	// DefaultEventPrefix prefixes the subjects saga events are published on.
+	DefaultEventPrefix = "saga"
+)
+
+// config holds the Option settings of a Saga.
+type config struct {
+	store       Store
+	logger      *log.Log
+	timeout     time.Duration
+	eventPrefix string
+	clock       clock.Clock
+}
+
+// Option configures a Saga.
+type Option func(*config)
+
+// WithStore sets the store instances are tracked in. Defaults to a MemoryStore.
+func WithStore(store Store) Option {
+	return func(c *config) {
+		c.store = store
+	}
+}
+
+// WithLogger sets the logger of the saga.
+func WithLogger(logger *log.Log) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithStepTimeout sets the timeout of steps without their own. Defaults to DefaultStepTimeout.
+func WithStepTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.timeout = timeout
+	}
+}
+
+// WithEventPrefix sets the prefix of the subjects events are published on, as
+// "<prefix>.<saga>.<status>". Defaults to DefaultEventPrefix.
+func WithEventPrefix(prefix string) Option {
+	return func(c *config) {
+		c.eventPrefix = prefix
+	}
+}
+
+// WithClock sets the clock instances are timestamped with. Defaults to clock.System.
+func WithClock(c clock.Clock) Option {
+	return func(cfg *config) {
+		cfg.clock = clock.OrSystem(c)
+	}
+}
@@ -0,0 +1,296 @@
+// Package saga runs sagas: sequences of steps, each with a compensation, where the completed
+// steps are compensated in reverse order when a later step fails or times out. Every
+// execution is tracked by correlation id in a Store and its outcome is published over NATS.
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/clock"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/random"
+	"github.com/abhissng/neuron/utils/structures/message"
+	"github.com/abhissng/neuron/utils/types"
+)
+
+// Status is the status of a saga instance.
+type Status string
+
+const (
+	// Running instances are executing their steps.
+	Running Status = "running"
+	// Completed instances executed all their steps.
+	Completed Status = "completed"
+	// RollingBack instances had a step fail and are compensating the completed steps.
+	RollingBack Status = "rolling_back"
+	// RolledBack instances had a step fail and compensated all the completed steps.
+	RolledBack Status = "rolled_back"
+	// Failed instances had a compensation fail and need manual intervention.
+	Failed Status = "failed"
+)
+
+// StepFunc executes or compensates a step on the data of an instance. ctx carries the
+// correlation id of the instance and is done when the step times out; StepFunc should
+// return by then.
+type StepFunc[T any] func(ctx context.Context, data *T) blame.Blame
+
+// Step is a step of a saga. Compensate undoes Action and may be nil when there is nothing
+// to undo. A zero Timeout uses the timeout of the saga.
+type Step[T any] struct {
+	Name       string
+	Action     StepFunc[T]
+	Compensate StepFunc[T]
+	Timeout    time.Duration
+}
+
+// RemoteStep returns a step executed by the service answering executeSubject with
+// nats.HandleRequest; the reply replaces data. It is compensated by publishing data as a
+// rollback message.Message to rollbackSubject, as the engine package does, unless
+// rollbackSubject is empty.
+func RemoteStep[T any](w *nats.NATSManager, name, executeSubject, rollbackSubject string, timeout time.Duration) Step[T] {
+	step := Step[T]{
+		Name:    name,
+		Timeout: timeout,
+		Action: func(ctx context.Context, data *T) blame.Blame {
+			value, err := nats.Request[T, T](ctx, w, executeSubject, *data, remaining(ctx)).Value()
+			if err != nil {
+				return err
+			}
+			*data = *value
+			return nil
+		},
+	}
+	if rollbackSubject != "" {
+		step.Compensate = func(ctx context.Context, data *T) blame.Blame {
+			correlationID := events.CorrelationIDFromContext(ctx)
+			payload := message.NewMessage(constant.Rollback, constant.Pending, types.CorrelationID(correlationID), *data)
+			payload.CurrentService = name
+			_, err := w.PublishWithMiddleware(rollbackSubject, payload,
+				nats.AddHeaderMiddleware(constant.CorrelationIDHeader, correlationID))
+			return err
+		}
+	}
+	return step
+}
+
+// Event is published on every transition of an instance to a final or rolling back status.
+type Event struct {
+	Saga          string              `json:"saga"`
+	CorrelationID types.CorrelationID `json:"correlation_id"`
+	Status        Status              `json:"status"`
+	FailedStep    string              `json:"failed_step,omitempty"`
+	Completed     []string            `json:"completed_steps"`
+	Compensated   []string            `json:"compensated_steps,omitempty"`
+	ErrorCode     types.ErrorCode     `json:"error_code,omitempty"`
+	Error         string              `json:"error,omitempty"`
+	Timestamp     time.Time           `json:"timestamp"`
+}
+
+// Saga is a named sequence of steps executed on a T.
+type Saga[T any] struct {
+	name  string
+	steps []Step[T]
+	w     *nats.NATSManager
+	cfg   *config
+}
+
+// New creates a saga named name, which must be a valid NATS subject token, running steps in
+// order. Events are published through w; a nil w publishes none.
+func New[T any](name string, w *nats.NATSManager, steps []Step[T], opts ...Option) *Saga[T] {
+	cfg := &config{
+		timeout:     DefaultStepTimeout,
+		eventPrefix: DefaultEventPrefix,
+		clock:       clock.System,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.store == nil {
+		cfg.store = NewMemoryStore()
+	}
+	if cfg.logger == nil {
+		cfg.logger = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return &Saga[T]{name: name, steps: steps, w: w, cfg: cfg}
+}
+
+// Name returns the name of the saga.
+func (s *Saga[T]) Name() string {
+	return s.name
+}
+
+// Execute runs the steps on data under the correlation id carried by ctx, or a new one.
+//
+// When a step fails or times out, the completed steps are compensated in reverse order and
+// the blame of the step is returned; the instance is then RolledBack. When a compensation
+// fails too, the remaining ones still run, the instance is Failed and a StepRollbackFailed
+// blame is returned instead. The instance is returned in every case.
+func (s *Saga[T]) Execute(ctx context.Context, data *T) (*Instance, blame.Blame) {
+	correlationID := events.CorrelationIDFromContext(ctx)
+	if correlationID == "" {
+		correlationID = random.GenerateUUIDString()
+		ctx = events.WithCorrelationID(ctx, correlationID)
+	}
+	now := s.cfg.clock.Now()
+	instance := &Instance{
+		Saga:          s.name,
+		CorrelationID: types.CorrelationID(correlationID),
+		Status:        Running,
+		Completed:     []string{},
+		StartedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.save(ctx, instance, data)
+
+	for i, step := range s.steps {
+		instance.CurrentStep = step.Name
+		s.save(ctx, instance, data)
+
+		if err := s.run(ctx, step.Name, step.Action, step.Timeout, data); err != nil {
+			s.cfg.logger.Error("Saga step failed", s.fields(instance, log.String("step", step.Name), log.Err(err))...)
+			instance.FailedStep = step.Name
+			instance.ErrorCode = err.FetchErrCode()
+			instance.Error = err.Error()
+			if rollbackErr := s.rollback(ctx, instance, s.steps[:i], data); rollbackErr != nil {
+				return instance, rollbackErr
+			}
+			return instance, err
+		}
+		instance.Completed = append(instance.Completed, step.Name)
+	}
+
+	instance.CurrentStep = ""
+	s.transition(ctx, instance, Completed, data)
+	s.cfg.logger.Info("Saga completed", s.fields(instance)...)
+	return instance, nil
+}
+
+// Instance returns the tracked state of the execution with correlationID.
+func (s *Saga[T]) Instance(ctx context.Context, correlationID types.CorrelationID) (*Instance, blame.Blame) {
+	return s.cfg.store.Load(ctx, s.name, correlationID)
+}
+
+// rollback compensates the completed steps in reverse order. It runs without the
+// cancellation of ctx, so that a cancelled execution is still compensated.
+func (s *Saga[T]) rollback(ctx context.Context, instance *Instance, completed []Step[T], data *T) blame.Blame {
+	ctx = context.WithoutCancel(ctx)
+	s.transition(ctx, instance, RollingBack, data)
+
+	var rollbackErr blame.Blame
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if step.Compensate == nil {
+			continue
+		}
+		instance.CurrentStep = step.Name
+		s.save(ctx, instance, data)
+
+		if err := s.run(ctx, step.Name, step.Compensate, step.Timeout, data); err != nil {
+			s.cfg.logger.Error("Saga step compensation failed", s.fields(instance, log.String("step", step.Name), log.Err(err))...)
+			if rollbackErr == nil {
+				rollbackErr = blame.StepRollbackFailedError(step.Name, instance.CorrelationID, err)
+			}
+			continue
+		}
+		instance.Compensated = append(instance.Compensated, step.Name)
+	}
+
+	instance.CurrentStep = ""
+	if rollbackErr != nil {
+		s.transition(ctx, instance, Failed, data)
+		return rollbackErr
+	}
+	s.transition(ctx, instance, RolledBack, data)
+	s.cfg.logger.Warn("Saga rolled back", s.fields(instance, log.String("failed_step", instance.FailedStep))...)
+	return nil
+}
+
+// run runs fn for step within its timeout and turns a missed deadline into a
+// SagaStepTimeout blame.
+func (s *Saga[T]) run(ctx context.Context, step string, fn StepFunc[T], timeout time.Duration, data *T) blame.Blame {
+	if fn == nil {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = s.cfg.timeout
+	}
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fn(stepCtx, data)
+	if err != nil && ctx.Err() == nil && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		return blame.SagaStepTimeoutError(s.name, step, timeout.String()).WithCause(err)
+	}
+	return err
+}
+
+// transition moves instance to status, saves it and publishes its event.
+func (s *Saga[T]) transition(ctx context.Context, instance *Instance, status Status, data *T) {
+	instance.Status = status
+	s.save(ctx, instance, data)
+	s.publish(instance)
+}
+
+// save records data in instance and saves it. Failing to track an instance does not fail
+// its execution.
+func (s *Saga[T]) save(ctx context.Context, instance *Instance, data *T) {
+	instance.UpdatedAt = s.cfg.clock.Now()
+	if encoded, err := codec.Encode(data, codec.JSON); err == nil {
+		instance.Data = encoded
+	}
+	if err := s.cfg.store.Save(ctx, instance); err != nil {
+		s.cfg.logger.Error("Saga instance not saved", s.fields(instance, log.Err(err))...)
+	}
+}
+
+// publish publishes the event of instance to "<prefix>.<saga>.<status>".
+func (s *Saga[T]) publish(instance *Instance) {
+	if s.w == nil {
+		return
+	}
+	subject := s.cfg.eventPrefix + "." + s.name + "." + string(instance.Status)
+	event := Event{
+		Saga:          instance.Saga,
+		CorrelationID: instance.CorrelationID,
+		Status:        instance.Status,
+		FailedStep:    instance.FailedStep,
+		Completed:     instance.Completed,
+		Compensated:   instance.Compensated,
+		ErrorCode:     instance.ErrorCode,
+		Error:         instance.Error,
+		Timestamp:     instance.UpdatedAt,
+	}
+	_, err := s.w.PublishWithMiddleware(subject, event,
+		nats.AddHeaderMiddleware(constant.CorrelationIDHeader, string(instance.CorrelationID)))
+	if err == nil {
+		return
+	}
+	if instance.Status == RollingBack {
+		err = blame.PublishRollbackEventFailedError(err)
+	}
+	s.cfg.logger.Error(constant.EventPublishedFailed, s.fields(instance, log.String("subject", subject), log.Err(err))...)
+}
+
+// fields returns the log fields identifying instance followed by fields.
+func (s *Saga[T]) fields(instance *Instance, fields ...types.Field) []types.Field {
+	return append([]types.Field{
+		log.String("saga", s.name),
+		log.String(constant.CorrelationID, string(instance.CorrelationID)),
+	}, fields...)
+}
+
+// remaining returns the time left until the deadline of ctx, or DefaultStepTimeout.
+func remaining(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return DefaultStepTimeout
+}
@@ -0,0 +1,104 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/events/nats"
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/types"
+)
+
+// Instance is the tracked state of one execution of a saga, identified by its correlation id.
+type Instance struct {
+	Saga          string              `json:"saga"`
+	CorrelationID types.CorrelationID `json:"correlation_id"`
+	Status        Status              `json:"status"`
+	CurrentStep   string              `json:"current_step,omitempty"`
+	FailedStep    string              `json:"failed_step,omitempty"`
+	Completed     []string            `json:"completed_steps"`
+	Compensated   []string            `json:"compensated_steps,omitempty"`
+	ErrorCode     types.ErrorCode     `json:"error_code,omitempty"`
+	Error         string              `json:"error,omitempty"`
+	Data          json.RawMessage     `json:"data,omitempty"`
+	StartedAt     time.Time           `json:"started_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// Store persists saga instances. Save is called on every transition of an instance.
+type Store interface {
+	Save(ctx context.Context, instance *Instance) blame.Blame
+	Load(ctx context.Context, saga string, correlationID types.CorrelationID) (*Instance, blame.Blame)
+}
+
+// MemoryStore keeps saga instances in memory. It is intended for tests and local
+// development; instances are lost when the process exits.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	instances map[string]Instance
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{instances: make(map[string]Instance)}
+}
+
+func (s *MemoryStore) Save(_ context.Context, instance *Instance) blame.Blame {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.instances[instanceKey(instance.Saga, instance.CorrelationID)] = clone(*instance)
+	return nil
+}
+
+func (s *MemoryStore) Load(_ context.Context, saga string, correlationID types.CorrelationID) (*Instance, blame.Blame) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	instance, ok := s.instances[instanceKey(saga, correlationID)]
+	if !ok {
+		return nil, blame.UnknownCorrelationIDError(correlationID, nil)
+	}
+	instance = clone(instance)
+	return &instance, nil
+}
+
+// KVStore keeps saga instances in a JetStream Key-Value bucket, so every replica can
+// inspect them and they survive restarts.
+type KVStore struct {
+	kv *nats.KVStore
+}
+
+// NewKVStore creates a KVStore on kv, e.g. from NATSManager.KV("sagas").
+func NewKVStore(kv *nats.KVStore) *KVStore {
+	return &KVStore{kv: kv}
+}
+
+func (s *KVStore) Save(_ context.Context, instance *Instance) blame.Blame {
+	_, err := nats.KVPut(s.kv, instanceKey(instance.Saga, instance.CorrelationID), *instance)
+	return err
+}
+
+func (s *KVStore) Load(_ context.Context, saga string, correlationID types.CorrelationID) (*Instance, blame.Blame) {
+	instance, _, err := nats.KVGet[Instance](s.kv, instanceKey(saga, correlationID))
+	if err != nil {
+		if err.FetchErrCode() == blame.ErrorKeyValueKeyNotFound {
+			return nil, blame.UnknownCorrelationIDError(correlationID, err)
+		}
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// instanceKey returns the key of an instance, valid as a Key-Value key.
+func instanceKey(saga string, correlationID types.CorrelationID) string {
+	return saga + "." + string(correlationID)
+}
+
+// clone copies instance so stored instances do not share slices with the caller.
+func clone(instance Instance) Instance {
+	instance.Completed = append([]string(nil), instance.Completed...)
+	instance.Compensated = append([]string(nil), instance.Compensated...)
+	instance.Data = append(json.RawMessage(nil), instance.Data...)
+	return instance
+}
@@ -0,0 +1,189 @@
+// Package scaffold generates ready-to-run service skeletons following the framework's
+// conventions: an AppContext with the blame catalog, logger and NATS, a Gin server with health,
+// readiness and Prometheus metrics, an optional gRPC server, NATS consumers and a Makefile.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/abhissng/neuron/blame/blamegen"
+)
+
+// NeuronModule is the module path of the framework.
+const NeuronModule = "github.com/abhissng/neuron"
+
+const (
+	templateRoot    = "templates"
+	templateSuffix  = ".tmpl"
+	namePlaceholder = "__name__"
+	catalogPath     = "locales/error_definition.json"
+	blamesPath      = "internal/blames/blame_gen.go"
+)
+
+//go:embed all:templates
+var templates embed.FS
+
+// namePattern restricts service names to lowercase words separated by dashes.
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+// ServiceConfig describes the service to generate.
+type ServiceConfig struct {
+	// Name of the service, e.g. "payments" or "order-events".
+	Name string
+	// Module path of the service. Defaults to Name.
+	Module string
+	// Dir the service is written to. Defaults to Name in the working directory.
+	Dir string
+	// NeuronVersion required by the service, e.g. "v1.4.0". Left to go mod tidy when empty.
+	NeuronVersion string
+	// NeuronReplace points the neuron requirement to a local checkout.
+	NeuronReplace string
+	// GRPC adds a gRPC server next to the HTTP one.
+	GRPC bool
+}
+
+// templateData is the data the templates are executed with.
+type templateData struct {
+	ServiceConfig
+	Subject      string // NATS subject prefix, e.g. "order.events"
+	ErrorPrefix  string // Prefix of the blame codes of the service
+	NeuronModule string
+}
+
+// GenerateService writes the skeleton of the service described by cfg and returns the
+// created files relative to its directory. The directory must not exist or be empty.
+func GenerateService(cfg ServiceConfig) ([]string, error) {
+	if !namePattern.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("invalid service name %q: use lowercase letters, digits and dashes", cfg.Name)
+	}
+	if cfg.Module == "" {
+		cfg.Module = cfg.Name
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = cfg.Name
+	}
+	if err := ensureEmptyDir(cfg.Dir); err != nil {
+		return nil, err
+	}
+
+	data := newTemplateData(cfg)
+	var files []string
+	err := fs.WalkDir(templates, templateRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel := strings.TrimPrefix(path, templateRoot+"/")
+		if !cfg.GRPC && strings.HasPrefix(rel, "internal/rpc/") {
+			return nil
+		}
+		target := strings.ReplaceAll(strings.TrimSuffix(rel, templateSuffix), namePlaceholder, cfg.Name)
+		content, err := render(path, data)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(cfg.Dir, filepath.FromSlash(target)), content); err != nil {
+			return err
+		}
+		files = append(files, target)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := generateBlames(cfg.Dir, data.ErrorPrefix+"-")
+	if err != nil {
+		return nil, err
+	}
+	return append(files, generated...), nil
+}
+
+// generateBlames runs blamegen over the catalog of the service, as its go:generate directive
+// does, so that the skeleton builds before go generate is first run.
+func generateBlames(dir, trimPrefix string) ([]string, error) {
+	definitions, err := blamegen.LoadCatalog(filepath.Join(dir, catalogPath))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := blamegen.Entries(definitions, trimPrefix)
+	if err != nil {
+		return nil, err
+	}
+	source, err := blamegen.GenerateGo(entries, blamegen.GoOptions{Package: "blames", Source: "../../" + catalogPath})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, filepath.FromSlash(blamesPath)), source); err != nil {
+		return nil, err
+	}
+	if _, err := blamegen.GenerateLocales(entries, filepath.Join(dir, "locales"), "en"); err != nil {
+		return nil, err
+	}
+	return []string{blamesPath, "locales/" + blamegen.LocaleFileName("en")}, nil
+}
+
+// newTemplateData derives the names used by the templates from cfg.
+func newTemplateData(cfg ServiceConfig) templateData {
+	return templateData{
+		ServiceConfig: cfg,
+		Subject:       strings.ReplaceAll(cfg.Name, "-", "."),
+		ErrorPrefix:   "error-" + cfg.Name,
+		NeuronModule:  NeuronModule,
+	}
+}
+
+// render executes the template at path, formatting Go sources.
+func render(path string, data templateData) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", path, err)
+	}
+	if !strings.HasSuffix(path, ".go"+templateSuffix) {
+		return buf.Bytes(), nil
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", path, err)
+	}
+	return formatted, nil
+}
+
+// ensureEmptyDir fails when dir exists and holds files.
+func ensureEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("directory %s is not empty", dir)
+	}
+	return nil
+}
+
+// writeFile writes content to path, creating its directory.
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil { // #nosec G306 -- generated sources are shared
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
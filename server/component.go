@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+
+	"github.com/abhissng/neuron/adapters/events/nats"
+	ginserver "github.com/abhissng/neuron/adapters/gin/server"
+	grpcmanager "github.com/abhissng/neuron/adapters/grpcserver"
+)
+
+// HTTP returns the component of a Gin server built with ginserver.NewServer.
+func HTTP(s *ginserver.Server) Component {
+	return Component{
+		Name: "http",
+		Run:  func(context.Context) error { return s.ListenAndServe() },
+		Stop: s.Shutdown,
+	}
+}
+
+// GRPC returns the component of a NeuronServer.
+func GRPC(s *grpcmanager.NeuronServer) Component {
+	return Component{
+		Name: "grpc",
+		Run:  func(context.Context) error { return s.Start() },
+		Stop: func(ctx context.Context) error {
+			s.Shutdown(ctx)
+			return nil
+		},
+	}
+}
+
+// NATS returns the component of the subscriptions of manager: subscribe registers them on
+// start, and the manager is closed, draining its connection, on stop. Add it after the
+// components its handlers depend on.
+func NATS(manager *nats.NATSManager, subscribe func() error) Component {
+	return Component{
+		Name: "nats",
+		Run: func(ctx context.Context) error {
+			if err := subscribe(); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return nil
+		},
+		Stop: func(context.Context) error {
+			manager.Close()
+			return nil
+		},
+	}
+}
+
+// Worker returns the component of a background worker running until ctx is cancelled, e.g.
+// an outbox relay or a scheduler.
+func Worker(name string, run func(ctx context.Context) error) Component {
+	return Component{Name: name, Run: run}
+}
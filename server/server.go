@@ -0,0 +1,279 @@
+// Package server is the runtime of a neuron service. It owns the startup and shutdown order
+// of the Gin HTTP server, the NeuronServer gRPC server, the NATS subscriptions and the
+// background workers of the service, handles the termination signals, gates readiness and
+// bounds the shutdown with a budget, so main() only declares the components:
+//
+//	rt := server.New(server.WithAppContext(appCtx), server.WithShutdownTimeout(20*time.Second))
+//	rt.Add(server.GRPC(grpcServer), server.HTTP(httpServer), server.Worker("outbox", relay.Run))
+//	if err := rt.Run(context.Background()); err != nil {
+//		appCtx.Log.Fatal("service stopped", log.Err(err))
+//	}
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	neuronctx "github.com/abhissng/neuron/context"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+)
+
+// Defaults of the runtime options.
+const (
+	DefaultShutdownTimeout = 30 * time.Second
+	DefaultStartupTimeout  = time.Minute
+)
+
+// Component is a part of the service whose lifecycle the Runtime owns.
+type Component struct {
+	// Name identifies the component in the logs.
+	Name string
+	// Run runs the component until Stop is called or its context is cancelled, and returns
+	// nil when stopped cleanly. An error returned while the service runs shuts it down.
+	Run func(ctx context.Context) error
+	// Stop stops the component within ctx. Components without Stop stop when the context of
+	// Run is cancelled.
+	Stop func(ctx context.Context) error
+	// Ready reports whether the component is started. When set, the next component starts
+	// once it reports true.
+	Ready func() bool
+}
+
+// Runtime starts components in the order they were added and stops them in reverse order.
+type Runtime struct {
+	components      []Component
+	appCtx          *neuronctx.AppContext
+	log             *log.Log
+	shutdownTimeout time.Duration
+	startupTimeout  time.Duration
+	preStopDelay    time.Duration
+	signals         []os.Signal
+	ready           atomic.Bool
+}
+
+// Option is a functional option for configuring a Runtime.
+type Option func(*Runtime)
+
+// WithAppContext runs the Warmup of appCtx before starting the components and logs its
+// startup diagnostics once they are started; readiness also requires the warmup to succeed.
+func WithAppContext(appCtx *neuronctx.AppContext) Option {
+	return func(r *Runtime) {
+		r.appCtx = appCtx
+		if r.log == nil {
+			r.log = appCtx.Log
+		}
+	}
+}
+
+// WithLogger sets the logger of the runtime. Defaults to the logger of the AppContext.
+func WithLogger(logger *log.Log) Option {
+	return func(r *Runtime) {
+		r.log = logger
+	}
+}
+
+// WithShutdownTimeout sets the budget shared by the components to stop, including the
+// pre-stop delay. Defaults to DefaultShutdownTimeout.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(r *Runtime) {
+		r.shutdownTimeout = timeout
+	}
+}
+
+// WithStartupTimeout bounds the wait for the Ready of each component. Defaults to
+// DefaultStartupTimeout.
+func WithStartupTimeout(timeout time.Duration) Option {
+	return func(r *Runtime) {
+		r.startupTimeout = timeout
+	}
+}
+
+// WithPreStopDelay keeps serving for delay after the service turned unready on shutdown, so
+// load balancers stop routing to it before its servers stop accepting connections.
+func WithPreStopDelay(delay time.Duration) Option {
+	return func(r *Runtime) {
+		r.preStopDelay = delay
+	}
+}
+
+// WithSignals sets the signals shutting the service down. Defaults to SIGINT and SIGTERM.
+func WithSignals(signals ...os.Signal) Option {
+	return func(r *Runtime) {
+		r.signals = signals
+	}
+}
+
+// New creates a Runtime.
+func New(opts ...Option) *Runtime {
+	r := &Runtime{
+		shutdownTimeout: DefaultShutdownTimeout,
+		startupTimeout:  DefaultStartupTimeout,
+		signals:         []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.log == nil {
+		r.log = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	return r
+}
+
+// Add adds components, started after those already added.
+func (r *Runtime) Add(components ...Component) *Runtime {
+	r.components = append(r.components, components...)
+	return r
+}
+
+// Ready reports whether every component started and the service is not shutting down.
+func (r *Runtime) Ready() bool {
+	return r.ready.Load() && (r.appCtx == nil || r.appCtx.IsReady())
+}
+
+// ReadinessHandler responds 200 while Ready and 503 otherwise, for readiness probes.
+func (r *Runtime) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		ready := r.Ready()
+		w.Header().Set("Content-Type", "application/json")
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(acknowledgment.NewAPIResponse(ready, "", map[string]bool{"ready": ready}))
+	})
+}
+
+// Run warms the AppContext up, starts the components and blocks until ctx is done, a
+// signal is received or a component fails. It then turns unready, waits for the pre-stop
+// delay and stops the components in reverse order within the shutdown budget. It returns the
+// error of the failed component, if any.
+func (r *Runtime) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, r.signals...)
+	defer stopSignals()
+
+	if r.appCtx != nil {
+		if err := r.appCtx.Warmup(ctx); err != nil {
+			return err
+		}
+	}
+
+	// The components run on their own context, cancelled only once they are stopped, so they
+	// keep serving while the service drains.
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+
+	failures := make(chan error, len(r.components))
+	var running sync.WaitGroup
+	started := 0
+	var startErr error
+	for _, component := range r.components {
+		running.Add(1)
+		started++
+		go func() {
+			defer running.Done()
+			if err := component.Run(runCtx); err != nil {
+				failures <- fmt.Errorf("%s: %w", component.Name, err)
+			}
+		}()
+		if startErr = r.awaitReady(ctx, component, failures); startErr != nil {
+			break
+		}
+		r.log.Info("Component started", log.String("component", component.Name))
+	}
+
+	var cause error
+	if startErr == nil {
+		r.ready.Store(true)
+		r.log.Info("Service ready", log.Int("components", len(r.components)))
+		if r.appCtx != nil {
+			r.appCtx.LogStartupDiagnostics()
+		}
+		select {
+		case <-ctx.Done():
+			r.log.Info("Shutdown requested", log.Err(context.Cause(ctx)))
+		case cause = <-failures:
+			r.log.Error("Component failed, shutting down", log.Err(cause))
+		}
+	} else {
+		cause = startErr
+		r.log.Error("Startup failed, shutting down", log.Err(cause))
+	}
+
+	r.shutdown(r.components[:started], cancelRun, &running)
+	return cause
+}
+
+// awaitReady waits for the Ready of component, a failure, or the startup timeout.
+func (r *Runtime) awaitReady(ctx context.Context, component Component, failures chan error) error {
+	if component.Ready == nil {
+		return nil
+	}
+	timeout := time.NewTimer(r.startupTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for !component.Ready() {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case err := <-failures:
+			return err
+		case <-timeout.C:
+			return fmt.Errorf("%s: not ready after %s", component.Name, r.startupTimeout)
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// shutdown turns the service unready, waits for the pre-stop delay and stops components in
+// reverse order within the shutdown budget, then waits for their Run to return.
+func (r *Runtime) shutdown(components []Component, cancelRun context.CancelFunc, running *sync.WaitGroup) {
+	r.ready.Store(false)
+	ctx, cancel := context.WithTimeout(context.Background(), r.shutdownTimeout)
+	defer cancel()
+	r.log.Info("Shutting down", log.Duration("budget", r.shutdownTimeout))
+
+	if r.preStopDelay > 0 {
+		select {
+		case <-time.After(r.preStopDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		if component.Stop == nil {
+			continue
+		}
+		start := time.Now()
+		if err := component.Stop(ctx); err != nil {
+			r.log.Warn("Component did not stop cleanly", log.String("component", component.Name), log.Err(err))
+			continue
+		}
+		r.log.Info("Component stopped", log.String("component", component.Name), log.Duration("duration", time.Since(start)))
+	}
+	cancelRun()
+
+	done := make(chan struct{})
+	go func() {
+		running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		r.log.Info("Shutdown complete")
+	case <-ctx.Done():
+		r.log.Warn("Shutdown budget exhausted, components still running", log.Err(ctx.Err()))
+	}
+	_ = r.log.Sync()
+}
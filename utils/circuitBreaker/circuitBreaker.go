@@ -63,3 +63,19 @@ func NewCircuitBreaker(options ...CircuitBreakerOption) *gobreaker.CircuitBreake
 
 	return gobreaker.NewCircuitBreaker(settings)
 }
+
+// WithFailureThreshold trips the circuit breaker after the given number of consecutive failures.
+func WithFailureThreshold(consecutiveFailures uint32) CircuitBreakerOption {
+	return func(s *gobreaker.Settings) {
+		s.ReadyToTrip = func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= consecutiveFailures
+		}
+	}
+}
+
+// WithOnStateChange sets the function called when the circuit breaker changes state.
+func WithOnStateChange(onStateChange func(name string, from, to gobreaker.State)) CircuitBreakerOption {
+	return func(s *gobreaker.Settings) {
+		s.OnStateChange = onStateChange
+	}
+}
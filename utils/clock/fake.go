@@ -0,0 +1,200 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when Advance or Set is called. Timers, tickers,
+// After and Sleep fire when the fake time reaches their deadline, so code waiting on them
+// can be driven step by step. It is intended for tests.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending timer or ticker of a Fake.
+type fakeWaiter struct {
+	clock    *Fake
+	deadline time.Time
+	period   time.Duration // zero for timers
+	c        chan time.Time
+}
+
+// NewFake creates a Fake clock set to start. A zero start uses the current time.
+func NewFake(start time.Time) *Fake {
+	if start.IsZero() {
+		start = time.Now()
+	}
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) Until(t time.Time) time.Duration {
+	return t.Sub(f.Now())
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// Sleep blocks until another goroutine advances the clock by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(w, d)
+	return &fakeTimer{w}
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &fakeWaiter{clock: f, period: d, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.schedule(w, d)
+	return &fakeTicker{w}
+}
+
+// Advance moves the clock forward by d, firing the timers and ticks that fall due in order
+// of their deadline. Tickers fire once per elapsed period; like time.Ticker, ticks are
+// dropped while the previous one has not been received.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	target := f.now.Add(d)
+	for len(f.waiters) > 0 && !f.waiters[0].deadline.After(target) {
+		w := f.waiters[0]
+		f.now = w.deadline
+		select {
+		case w.c <- f.now:
+		default:
+		}
+		f.remove(w)
+		if w.period > 0 {
+			f.schedule(w, w.period)
+		}
+	}
+	if target.After(f.now) {
+		f.now = target
+	}
+}
+
+// Set moves the clock to t. Moving forward fires timers as Advance does; moving backward
+// fires nothing.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	d := t.Sub(f.now)
+	if d < 0 {
+		f.now = t
+		f.mu.Unlock()
+		return
+	}
+	f.mu.Unlock()
+	f.Advance(d)
+}
+
+// Waiters returns the number of pending timers and tickers.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil blocks until at least n timers and tickers are pending. Tests call it before
+// Advance to make sure the code under test started waiting on the clock.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// schedule adds w with a deadline d from now, firing timers that are already due. The caller
+// holds f.mu.
+func (f *Fake) schedule(w *fakeWaiter, d time.Duration) {
+	if d <= 0 && w.period == 0 {
+		select {
+		case w.c <- f.now:
+		default:
+		}
+		return
+	}
+	w.deadline = f.now.Add(d)
+	i := sort.Search(len(f.waiters), func(i int) bool { return f.waiters[i].deadline.After(w.deadline) })
+	f.waiters = append(f.waiters, nil)
+	copy(f.waiters[i+1:], f.waiters[i:])
+	f.waiters[i] = w
+	f.cond.Broadcast()
+}
+
+// remove removes w and reports whether it was pending. The caller holds f.mu.
+func (f *Fake) remove(w *fakeWaiter) bool {
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct{ *fakeWaiter }
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t.fakeWaiter)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.remove(t.fakeWaiter)
+	t.clock.schedule(t.fakeWaiter, d)
+	return active
+}
+
+type fakeTicker struct{ *fakeWaiter }
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.remove(t.fakeWaiter)
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.remove(t.fakeWaiter)
+	t.fakeWaiter.period = d
+	t.clock.schedule(t.fakeWaiter, d)
+}
@@ -0,0 +1,159 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hamba/avro/v2"
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// EncodeAvro serializes data as a single Avro datum using an explicit schema.
+func EncodeAvro[T any](data T, schema string) ([]byte, error) {
+	parsed, err := avro.Parse(schema)
+	if err != nil {
+		return nil, fmt.Errorf("avro: invalid schema: %w", err)
+	}
+	return avro.Marshal(parsed, data)
+}
+
+// DecodeAvro deserializes a single Avro datum using an explicit schema.
+func DecodeAvro[T any](data []byte, schema string) (T, error) {
+	var result T
+	parsed, err := avro.Parse(schema)
+	if err != nil {
+		return result, fmt.Errorf("avro: invalid schema: %w", err)
+	}
+	err = avro.Unmarshal(parsed, data, &result)
+	return result, err
+}
+
+// AvroSchemaOf derives an Avro schema from the Go type of T. Field names come from `avro`
+// struct tags, falling back to field names; "-" skips a field. Pointers become nullable
+// unions and time.Time becomes a timestamp-micros long.
+func AvroSchemaOf[T any]() (avro.Schema, error) {
+	definition, err := avroType(reflect.TypeFor[T](), map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(definition)
+	if err != nil {
+		return nil, err
+	}
+	return avro.Parse(string(raw))
+}
+
+// encodeAvro serializes data with a schema derived from its type.
+func encodeAvro[T any](data T) ([]byte, error) {
+	schema, err := AvroSchemaOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return avro.Marshal(schema, data)
+}
+
+// decodeAvro deserializes data with a schema derived from the type of T.
+func decodeAvro[T any](data []byte, result *T) error {
+	schema, err := AvroSchemaOf[T]()
+	if err != nil {
+		return err
+	}
+	return avro.Unmarshal(schema, data, result)
+}
+
+// avroType returns the JSON schema definition for t. Records already defined are referenced
+// by name, as Avro does not allow a named type to be declared twice.
+func avroType(t reflect.Type, defined map[reflect.Type]bool) (any, error) {
+	if t == timeType {
+		return map[string]string{"type": "long", "logicalType": "timestamp-micros"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := avroType(t.Elem(), defined)
+		if err != nil {
+			return nil, err
+		}
+		return []any{"null", elem}, nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "int", nil
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "long", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", nil
+		}
+		items, err := avroType(t.Elem(), defined)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("avro: map keys must be strings, got %s", t.Key())
+		}
+		values, err := avroType(t.Elem(), defined)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "map", "values": values}, nil
+	case reflect.Struct:
+		return avroRecord(t, defined)
+	default:
+		return nil, fmt.Errorf("avro: unsupported type %s", t)
+	}
+}
+
+// avroRecord builds a record definition for a struct type.
+func avroRecord(t reflect.Type, defined map[reflect.Type]bool) (any, error) {
+	name := t.Name()
+	if name == "" {
+		return nil, fmt.Errorf("avro: anonymous structs are not supported")
+	}
+	if defined[t] {
+		return name, nil
+	}
+	defined[t] = true
+
+	fields := make([]map[string]any, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldName := avroFieldName(field)
+		if fieldName == "-" {
+			continue
+		}
+		fieldType, err := avroType(field.Type, defined)
+		if err != nil {
+			return nil, fmt.Errorf("avro: field %s.%s: %w", name, field.Name, err)
+		}
+		definition := map[string]any{"name": fieldName, "type": fieldType}
+		if field.Type.Kind() == reflect.Pointer {
+			definition["default"] = nil
+		}
+		fields = append(fields, definition)
+	}
+	return map[string]any{"type": "record", "name": name, "fields": fields}, nil
+}
+
+// avroFieldName returns the schema name of a field.
+func avroFieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("avro"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/abhissng/neuron/utils/constant"
 	"github.com/abhissng/neuron/utils/helpers"
 	"github.com/abhissng/neuron/utils/types"
+	"github.com/vmihailenco/msgpack/v5"
 	"gopkg.in/yaml.v3"
 )
 
@@ -25,14 +26,24 @@ func Encode[T any](data T, codecType types.CodecType) ([]byte, error) {
 
 	switch codecType {
 	case JSON:
-		err = json.NewEncoder(&buf).Encode(data)
+		return encodeJSON(data)
 	case XML:
 		err = xml.NewEncoder(&buf).Encode(data)
 	case YAML:
 		err = yaml.NewEncoder(&buf).Encode(data)
+	case CSV:
+		return encodeCSV(data)
 	case Gob:
 		enc := gob.NewEncoder(&buf)
 		err = enc.Encode(data)
+	case MessagePack:
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		err = enc.Encode(data)
+	case Avro:
+		return encodeAvro(data)
+	case Parquet:
+		return encodeParquet(data)
 	case Base64:
 		encoded := base64.StdEncoding.EncodeToString([]byte(toString(data)))
 		return []byte(encoded), nil
@@ -69,7 +80,7 @@ func Decode[T any](data []byte, codecType types.CodecType) (T, error) {
 		err = json.Unmarshal(data, &result)
 
 	case XML:
-		err = xml.Unmarshal(data, &result)
+		err = NewXMLDecoder(bytes.NewReader(data)).Decode(&result)
 
 	case YAML:
 		err = yaml.Unmarshal(data, &result)
@@ -79,6 +90,17 @@ func Decode[T any](data []byte, codecType types.CodecType) (T, error) {
 		dec := gob.NewDecoder(buf)
 		err = dec.Decode(&result)
 
+	case MessagePack:
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.SetCustomStructTag("json")
+		err = dec.Decode(&result)
+
+	case Avro:
+		err = decodeAvro(data, &result)
+
+	case Parquet:
+		err = decodeParquet(data, &result)
+
 	case Base64:
 		var decoded []byte
 		decoded, err = base64.StdEncoding.DecodeString(toString(data))
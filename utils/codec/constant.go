@@ -10,6 +10,7 @@ const (
 	YAML types.CodecType = "yaml"
 	TOML types.CodecType = "toml"
 	INI  types.CodecType = "ini"
+	CSV  types.CodecType = "csv"
 
 	// Binary formats
 	Gob         types.CodecType = "gob"
@@ -17,6 +18,7 @@ const (
 	MessagePack types.CodecType = "msgpack"
 	CBOR        types.CodecType = "cbor"
 	Avro        types.CodecType = "avro"
+	Parquet     types.CodecType = "parquet"
 
 	// Encoding schemes
 	Base64 types.CodecType = "base64"
@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodeCSV writes a struct, or a slice of structs, as CSV with a header row. Columns come from
+// `csv` struct tags, falling back to `json` tags and then field names; "-" skips a field.
+func encodeCSV(data any) ([]byte, error) {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	rows := v
+	if v.Kind() == reflect.Struct {
+		rows = reflect.New(reflect.SliceOf(v.Type())).Elem()
+		rows = reflect.Append(rows, v)
+	}
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array {
+		return nil, fmt.Errorf("codec: csv needs a struct or a slice of structs, got %s", v.Type())
+	}
+	rowType := rows.Type().Elem()
+	for rowType.Kind() == reflect.Pointer {
+		rowType = rowType.Elem()
+	}
+	if rowType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("codec: csv rows must be structs, got %s", rowType)
+	}
+
+	var names []string
+	var indexes [][]int
+	for _, field := range reflect.VisibleFields(rowType) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := csvTagName(field, "csv")
+		if name == "" {
+			name = csvTagName(field, "json")
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+		indexes = append(indexes, field.Index)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(names); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(indexes))
+	for i := 0; i < rows.Len(); i++ {
+		row := reflect.Indirect(rows.Index(i))
+		for j, index := range indexes {
+			record[j] = ""
+			if row.IsValid() {
+				if field, err := row.FieldByIndexErr(index); err == nil {
+					record[j] = csvCell(field)
+				}
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvTagName returns the name part of a struct tag.
+func csvTagName(field reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+	return name
+}
+
+// csvCell renders a field as a CSV cell; nested values are written as JSON.
+func csvCell(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return ""
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return value.String()
+	case []byte:
+		return string(value)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,131 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// EncodeParquet writes rows to a Snappy-compressed Parquet file. The schema is derived from
+// the `parquet` struct tags of T unless one is provided explicitly.
+func EncodeParquet[T any](rows []T, schema ...*parquet.Schema) ([]byte, error) {
+	var buf bytes.Buffer
+	options := []parquet.WriterOption{parquet.Compression(&parquet.Snappy)}
+	if len(schema) > 0 && schema[0] != nil {
+		options = append(options, schema[0])
+	}
+	w := parquet.NewGenericWriter[T](&buf, options...)
+	if _, err := w.Write(rows); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeParquet reads all rows of a Parquet file. The schema is derived from the `parquet`
+// struct tags of T unless one is provided explicitly; columns missing from T are ignored.
+func DecodeParquet[T any](data []byte, schema ...*parquet.Schema) ([]T, error) {
+	var options []parquet.ReaderOption
+	if len(schema) > 0 && schema[0] != nil {
+		options = append(options, schema[0])
+	}
+	r := parquet.NewGenericReader[T](bytes.NewReader(data), options...)
+	defer func() {
+		_ = r.Close()
+	}()
+
+	rows := make([]T, r.NumRows())
+	n, err := r.Read(rows)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return rows[:n], nil
+}
+
+// encodeParquet serializes data for Encode. data must be a slice of structs, or a single
+// struct which is written as one row.
+func encodeParquet(data any) ([]byte, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		slice := reflect.MakeSlice(reflect.SliceOf(v.Type()), 1, 1)
+		slice.Index(0).Set(v)
+		v = slice
+	}
+
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("parquet: rows must be structs, got %s", elem)
+	}
+
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, parquet.SchemaOf(reflect.New(elem).Interface()), parquet.Compression(&parquet.Snappy))
+	for i := 0; i < v.Len(); i++ {
+		if err := w.Write(v.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeParquet deserializes data for Decode into a slice of structs, or a single struct
+// holding the first row.
+func decodeParquet[T any](data []byte, result *T) error {
+	target := reflect.ValueOf(result).Elem()
+	single := target.Kind() != reflect.Slice
+	sliceType := target.Type()
+	if single {
+		sliceType = reflect.SliceOf(target.Type())
+	}
+
+	elem := sliceType.Elem()
+	pointer := elem.Kind() == reflect.Pointer
+	if pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("parquet: rows must be structs, got %s", elem)
+	}
+
+	r := parquet.NewReader(bytes.NewReader(data), parquet.SchemaOf(reflect.New(elem).Interface()))
+	defer func() {
+		_ = r.Close()
+	}()
+
+	rows := reflect.MakeSlice(sliceType, 0, int(r.NumRows()))
+	for {
+		row := reflect.New(elem)
+		if err := r.Read(row.Interface()); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		if pointer {
+			rows = reflect.Append(rows, row)
+		} else {
+			rows = reflect.Append(rows, row.Elem())
+		}
+	}
+
+	if single {
+		if rows.Len() == 0 {
+			return errors.New("parquet: no rows")
+		}
+		target.Set(rows.Index(0))
+		return nil
+	}
+	target.Set(rows)
+	return nil
+}
@@ -0,0 +1,217 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/abhissng/neuron/blame"
+)
+
+// PathSegment is one step of a JSON path: an object key or an array index.
+type PathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// ParsePath parses a path such as "data.items[0].id" or `headers["x-request-id"]`.
+// Negative indexes count from the end of an array.
+func ParsePath(path string) ([]PathSegment, error) {
+	var segments []PathSegment
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			if i == 0 || i == len(path)-1 || path[i+1] == '.' || path[i+1] == '[' {
+				return nil, fmt.Errorf("unexpected '.' at %d", i)
+			}
+			i++
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' at %d", i)
+			}
+			inner := path[i+1 : i+end]
+			if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, PathSegment{Key: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q at %d", inner, i)
+				}
+				segments = append(segments, PathSegment{Index: index, IsIndex: true})
+			}
+			i += end + 1
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, PathSegment{Key: path[i : i+end]})
+			i += end
+		}
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("empty path")
+	}
+	return segments, nil
+}
+
+// GetPath extracts the value at path from a raw JSON document and converts it to T, so
+// single fields of webhook payloads and third-party responses can be read without defining
+// structs for the whole document.
+//
+// Missing paths and nulls (unless T is nilable) return blame.MissingParameterError, malformed
+// paths blame.MalformedParameterError and values that cannot be converted to T
+// blame.TypeConversionError. Strings holding numbers or booleans are converted when T asks
+// for one.
+func GetPath[T any](raw []byte, path string) (T, blame.Blame) {
+	var zero T
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return zero, blame.UnMarshalError(JSON, err)
+	}
+	return GetPathFrom[T](document, path)
+}
+
+// GetPathFrom is GetPath for a document already decoded into maps and slices.
+func GetPathFrom[T any](document any, path string) (T, blame.Blame) {
+	var zero T
+	segments, err := ParsePath(path)
+	if err != nil {
+		return zero, blame.MalformedParameterError(path)
+	}
+	value, found := walkPath(document, segments)
+	if !found {
+		return zero, blame.MissingParameterError(path)
+	}
+	return convertPathValue[T](value, path)
+}
+
+// GetPathOr returns the value at path, or fallback when it is missing or of another type.
+func GetPathOr[T any](raw []byte, path string, fallback T) T {
+	value, err := GetPath[T](raw, path)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// HasPath reports whether raw has a non-null value at path. Use IsNull to tell an explicit
+// null from a missing path.
+func HasPath(raw []byte, path string) bool {
+	value, found := lookupPath(raw, path)
+	return found && value != nil
+}
+
+// IsNull reports whether raw has an explicit null at path.
+func IsNull(raw []byte, path string) bool {
+	value, found := lookupPath(raw, path)
+	return found && value == nil
+}
+
+// lookupPath decodes raw and returns the value at path, if any.
+func lookupPath(raw []byte, path string) (any, bool) {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, false
+	}
+	return walkPath(document, segments)
+}
+
+// walkPath follows segments through decoded JSON.
+func walkPath(value any, segments []PathSegment) (any, bool) {
+	for _, segment := range segments {
+		switch node := value.(type) {
+		case map[string]any:
+			if segment.IsIndex {
+				return nil, false
+			}
+			next, ok := node[segment.Key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			if !segment.IsIndex {
+				return nil, false
+			}
+			index := segment.Index
+			if index < 0 {
+				index += len(node)
+			}
+			if index < 0 || index >= len(node) {
+				return nil, false
+			}
+			value = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// convertPathValue converts a decoded JSON value to T.
+func convertPathValue[T any](value any, path string) (T, blame.Blame) {
+	var zero T
+	target := reflect.TypeFor[T]()
+
+	if value == nil {
+		switch target.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			return zero, nil
+		default:
+			return zero, blame.MissingParameterError(path)
+		}
+	}
+	if typed, ok := value.(T); ok && target.Kind() != reflect.Interface {
+		return typed, nil
+	}
+	if target.Kind() == reflect.Interface {
+		if number, ok := value.(json.Number); ok {
+			// Hand out plain numbers rather than the decoder's json.Number.
+			if i, err := number.Int64(); err == nil {
+				value = i
+			} else if f, err := number.Float64(); err == nil {
+				value = f
+			}
+		}
+		if typed, ok := value.(T); ok {
+			return typed, nil
+		}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return zero, blame.MarshalError(JSON, err)
+	}
+	var result T
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		// Third-party payloads often quote numbers and booleans.
+		if text, ok := value.(string); ok && json.Unmarshal([]byte(text), &result) == nil {
+			return result, nil
+		}
+		return zero, blame.TypeConversionError(path, truncate(string(data), 64), target.String(), err)
+	}
+	return result, nil
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "..."
+}
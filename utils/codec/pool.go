@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// MaxPooledBufferSize is the capacity above which buffers are dropped instead of returned to
+// the pool, so one large payload does not pin its memory for the life of the process.
+const MaxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// GetBuffer returns an empty buffer from the pool. Return it with PutBuffer once its bytes
+// are no longer referenced.
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer resets buf and returns it to the pool.
+func PutBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > MaxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// jsonEncoder is a pooled buffer with a json.Encoder writing to it.
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonEncoderPool = sync.Pool{
+	New: func() any {
+		e := &jsonEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+func getJSONEncoder() *jsonEncoder {
+	return jsonEncoderPool.Get().(*jsonEncoder)
+}
+
+func putJSONEncoder(e *jsonEncoder) {
+	if e.buf.Cap() > MaxPooledBufferSize {
+		return
+	}
+	e.buf.Reset()
+	jsonEncoderPool.Put(e)
+}
+
+// encodeJSON encodes data with a pooled encoder and returns a copy of exactly the encoded size.
+func encodeJSON(data any) ([]byte, error) {
+	e := getJSONEncoder()
+	defer putJSONEncoder(e)
+	if err := e.enc.Encode(data); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(e.buf.Bytes()), nil
+}
+
+// EncodeJSONTo writes the JSON encoding of data to w, as Encode with JSON, using a pooled
+// buffer and encoder. Nothing is written when encoding fails.
+func EncodeJSONTo(w io.Writer, data any) error {
+	e := getJSONEncoder()
+	defer putJSONEncoder(e)
+	if err := e.enc.Encode(data); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf.Bytes())
+	return err
+}
+
+// EncodeJSONPooled encodes data as Encode with JSON into a buffer from the pool, avoiding the
+// copy of Encode. The caller must return the buffer with PutBuffer once its bytes are no
+// longer referenced; it is nil when encoding fails.
+func EncodeJSONPooled(data any) (*bytes.Buffer, error) {
+	buf := GetBuffer()
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		PutBuffer(buf)
+		return nil, err
+	}
+	return buf, nil
+}
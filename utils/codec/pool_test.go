@@ -0,0 +1,95 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+type benchPayload struct {
+	ID      string            `json:"id"`
+	Subject string            `json:"subject"`
+	Amount  float64           `json:"amount"`
+	Tags    []string          `json:"tags"`
+	Meta    map[string]string `json:"meta"`
+}
+
+var payload = benchPayload{
+	ID:      "7f0c2a3e-4b5d-4e6f-8a9b-0c1d2e3f4a5b",
+	Subject: "orders.created",
+	Amount:  1299.5,
+	Tags:    []string{"priority", "retail", "web"},
+	Meta:    map[string]string{"region": "ap-south-1", "channel": "checkout"},
+}
+
+func TestEncodeJSONPooledMatchesEncode(t *testing.T) {
+	want, err := Encode(payload, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := EncodeJSONPooled(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer PutBuffer(buf)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("EncodeJSONPooled = %q, want %q", buf.Bytes(), want)
+	}
+
+	var out bytes.Buffer
+	if err := EncodeJSONTo(&out, payload); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Fatalf("EncodeJSONTo = %q, want %q", out.Bytes(), want)
+	}
+}
+
+// BenchmarkEncodeJSONUnpooled is the encoding of Encode before pooling, for comparison.
+func BenchmarkEncodeJSONUnpooled(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var buf bytes.Buffer
+			if err := json.NewEncoder(&buf).Encode(payload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncodeJSON(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := Encode(payload, JSON); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkEncodeJSONPooled(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf, err := EncodeJSONPooled(payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			PutBuffer(buf)
+		}
+	})
+}
+
+func BenchmarkEncodeJSONTo(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := EncodeJSONTo(io.Discard, payload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// EncodeXMLElement encodes data as an XML document, with the XML declaration, whose root
+// element is named name. An empty name keeps the name of the XMLName field or type of data,
+// which generic types cannot use.
+func EncodeXMLElement(data any, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	var err error
+	if name == "" {
+		err = enc.Encode(data)
+	} else {
+		err = enc.EncodeElement(data, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NewXMLDecoder returns an XML decoder that also reads documents declared in a non UTF-8
+// charset, e.g. the ISO-8859-1 still common with bank and partner integrations.
+func NewXMLDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charsetReader
+	return dec
+}
+
+// DecodeXML decodes an XML document, in any charset known to IANA, into T.
+func DecodeXML[T any](data []byte) (T, error) {
+	var result T
+	err := NewXMLDecoder(bytes.NewReader(data)).Decode(&result)
+	return result, err
+}
+
+// charsetReader converts input from charset to UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if strings.EqualFold(charset, "utf-8") {
+		return input, nil
+	}
+	encoding, err := ianaindex.IANA.Encoding(charset)
+	if err != nil {
+		return nil, err
+	}
+	if encoding == nil {
+		return nil, fmt.Errorf("codec: unsupported xml charset %q", charset)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
@@ -13,6 +13,7 @@ const (
 	CorrelationID  = "correlation_id"
 	BusinessID     = "business_id"
 	UserID         = "user_id"
+	ActorID        = "actor_id"
 	Logger         = "logger"
 	TraceID        = "trace_id"
 	MetaData       = "meta_data"
@@ -21,6 +22,9 @@ const (
 	ClaimsData     = "claims_data"
 	Issuer         = "issuer"
 	TokenID        = "token_id"
+	CountryCode    = "country_code"
+	GeoInfo        = "geo_info"
+	APIVersion     = "api_version"
 
 	// These are general constant for config file
 	Service              = "Service"
@@ -39,10 +43,14 @@ const (
 	VaultPath            = "VaultPath"
 	IssuerKey            = "IssuerKey"
 	OpenSearchEnabled    = "OPENSEARCH_ENABLED"
+	NeuronDev            = "NEURON_DEV"
 	OpenSearchAddresses  = "OPENSEARCH_ADDRESSES"
 	OpenSearchIndex      = "OPENSEARCH_INDEX"
 	OpenSearchUsername   = "OPENSEARCH_USERNAME"
 	OpenSearchPassword   = "OPENSEARCH_PASSWORD"
+	KYCProvider          = "KYC_PROVIDER"
+	KYCBaseURL           = "KYC_BASE_URL"
+	KYCAPIKey            = "KYC_API_KEY"
 	Records              = "Records"
 	RSA_PRIVATE_KEY_PATH = "RSA_PRIVATE_KEY_PATH"
 	RSA_PUBLIC_KEY_PATH  = "RSA_PUBLIC_KEY_PATH"
@@ -15,6 +15,15 @@ const (
 	DatabaseCheckAliveInterval time.Duration = 60 * time.Second
 )
 
+// Audit and soft-delete column names
+const (
+	CreatedAtColumn = "created_at"
+	UpdatedAtColumn = "updated_at"
+	DeletedAtColumn = "deleted_at"
+	CreatedByColumn = "created_by"
+	UpdatedByColumn = "updated_by"
+)
+
 // Database constant Queries
 const (
 	DatabaseExistQuery = "SELECT 1 FROM pg_database WHERE datname = $1"
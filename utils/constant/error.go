@@ -24,6 +24,8 @@ const (
 	AlreadyExists  types.ResponseErrorType = "AlreadyExists"
 	InternalServer types.ResponseErrorType = "InternalServerError"
 	Unauthorized   types.ResponseErrorType = "Unauthorized"
+	RequestTimeout types.ResponseErrorType = "RequestTimeout"
+	TooLarge       types.ResponseErrorType = "PayloadTooLarge"
 )
 
 const (
@@ -71,6 +71,7 @@ const (
 	HandlerRedirect   = "HandlerRedirect"
 	MiddlewareSuccess = "MiddlewareSuccessful"
 	MiddlewareFailed  = "MiddlewareFailed"
+	ImpersonatedCall  = "ImpersonatedCall"
 
 	TransactionMessage    = "Transaction Message"
 	AdaptersMessage       = "Adapter Message"
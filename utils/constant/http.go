@@ -22,16 +22,33 @@ const (
 	XUserId             = "X-User-Id"
 	XFeatureFlags       = "X-Feature-Flags"
 	XLocationId         = "X-Location-Id"
+	XAPIVersion         = "X-API-Version"
+	XFieldMask          = "X-Field-Mask"
+	XRequestID          = "X-Request-ID"
+	XShadowRequest      = "X-Shadow-Request"
+	XCanaryVariant      = "X-Canary-Variant"
+	IdempotencyKey      = "Idempotency-Key"
+	IdempotentReplayed  = "Idempotent-Replayed"
 )
 
 // These are middlewares or plugin constant for the application
 const (
 	CorsAllowedOriginsKey = "CorsAllowedOrigins"
+	CorsAllowedMethodsKey = "CorsAllowedMethods"
+	CorsAllowedHeadersKey = "CorsAllowedHeaders"
+	CorsExposedHeadersKey = "CorsExposedHeaders"
+	CorsCredentialsKey    = "CorsAllowCredentials"
+	CorsMaxAgeKey         = "CorsMaxAge"
+	CorsStrictKey         = "CorsStrict"
 	RedisAddrKey          = "RedisAddr"
 	RedisPasswordKey      = "RedisPassword"
 	RedisDBKey            = "RedisDB"
 	RateLimitDefaultKey   = "RateLimitDefault"
 	RateLimitSpecialKey   = "RateLimitSpecial"
+	RequestTimeoutKey     = "RequestTimeout"
+	RouteTimeoutsKey      = "RouteTimeouts"
+	MaxBodySizeKey        = "MaxRequestBodySize"
+	RouteMaxBodySizesKey  = "RouteMaxRequestBodySizes"
 	// RateLimitDurationInSecondKey = "RateLimitDurationInSecond"
 )
 
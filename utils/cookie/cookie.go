@@ -0,0 +1,204 @@
+// Package cookie provides encrypted, authenticated cookies backed by a
+// cryptography.SymmetricManager. The cookie name is bound to the ciphertext, so a value cannot
+// be moved to another cookie, and the key ID it carries lets keys rotate without logging
+// users out.
+package cookie
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abhissng/neuron/utils/cryptography"
+)
+
+// maxCookieSize is the largest cookie value browsers are required to accept.
+const maxCookieSize = 4096
+
+var (
+	// ErrCookieMissing is returned when the request carries no cookie with the name.
+	ErrCookieMissing = http.ErrNoCookie
+	// ErrCookieInvalid is returned when a cookie cannot be decrypted or decoded.
+	ErrCookieInvalid = errors.New("cookie is invalid")
+	// ErrCookieTooLarge is returned when an encrypted value exceeds 4096 bytes.
+	ErrCookieTooLarge = errors.New("cookie value is too large")
+)
+
+// Manager writes and reads encrypted cookies with shared attributes. Defaults are Path "/",
+// Secure, HttpOnly and SameSite=Lax.
+type Manager struct {
+	crypto   *cryptography.SymmetricManager
+	path     string
+	domain   string
+	secure   bool
+	httpOnly bool
+	sameSite http.SameSite
+	maxAge   time.Duration
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithPath sets the cookie path.
+func WithPath(path string) Option {
+	return func(m *Manager) {
+		m.path = path
+	}
+}
+
+// WithDomain sets the cookie domain. Empty makes host-only cookies.
+func WithDomain(domain string) Option {
+	return func(m *Manager) {
+		m.domain = domain
+	}
+}
+
+// WithSecure sets the Secure attribute. Only disable it for local development over http.
+func WithSecure(secure bool) Option {
+	return func(m *Manager) {
+		m.secure = secure
+	}
+}
+
+// WithHTTPOnly sets the HttpOnly attribute.
+func WithHTTPOnly(httpOnly bool) Option {
+	return func(m *Manager) {
+		m.httpOnly = httpOnly
+	}
+}
+
+// WithSameSite sets the SameSite attribute. SameSite=None forces Secure.
+func WithSameSite(sameSite http.SameSite) Option {
+	return func(m *Manager) {
+		m.sameSite = sameSite
+	}
+}
+
+// WithMaxAge sets the lifetime of cookies. Zero makes session cookies.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(m *Manager) {
+		m.maxAge = maxAge
+	}
+}
+
+// NewManager creates a Manager encrypting cookies with crypto.
+func NewManager(crypto *cryptography.SymmetricManager, opts ...Option) *Manager {
+	m := &Manager{
+		crypto:   crypto,
+		path:     "/",
+		secure:   true,
+		httpOnly: true,
+		sameSite: http.SameSiteLaxMode,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.sameSite == http.SameSiteNoneMode {
+		m.secure = true
+	}
+	return m
+}
+
+// SetString encrypts value and writes it as the cookie name.
+func (m *Manager) SetString(w http.ResponseWriter, name, value string) error {
+	return m.set(w, name, []byte(value), m.maxAge)
+}
+
+// SetStringWithMaxAge is SetString with a lifetime overriding the manager's.
+func (m *Manager) SetStringWithMaxAge(w http.ResponseWriter, name, value string, maxAge time.Duration) error {
+	return m.set(w, name, []byte(value), maxAge)
+}
+
+// GetString returns the decrypted value of the cookie name.
+func (m *Manager) GetString(r *http.Request, name string) (string, error) {
+	value, _, err := m.get(r, name)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// Renew re-encrypts the cookie name with the active key when it was written with a rotated
+// one, and reports whether it did.
+func (m *Manager) Renew(w http.ResponseWriter, r *http.Request, name string) (bool, error) {
+	value, keyID, err := m.get(r, name)
+	if err != nil {
+		return false, err
+	}
+	if keyID == m.crypto.ActiveKeyID() {
+		return false, nil
+	}
+	return true, m.set(w, name, value, m.maxAge)
+}
+
+// Delete expires the cookie name.
+func (m *Manager) Delete(w http.ResponseWriter, name string) {
+	cookie := m.cookie(name, "", 0)
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0)
+	http.SetCookie(w, cookie)
+}
+
+// Set encodes value as JSON, encrypts it and writes it as the cookie name.
+func Set[T any](m *Manager, w http.ResponseWriter, name string, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.set(w, name, data, m.maxAge)
+}
+
+// Get decrypts the cookie name and decodes it into a T.
+func Get[T any](m *Manager, r *http.Request, name string) (T, error) {
+	var value T
+	data, _, err := m.get(r, name)
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, ErrCookieInvalid
+	}
+	return value, nil
+}
+
+func (m *Manager) set(w http.ResponseWriter, name string, value []byte, maxAge time.Duration) error {
+	encrypted, err := m.crypto.Encrypt(value, []byte(name))
+	if err != nil {
+		return err
+	}
+	if len(name)+len(encrypted) > maxCookieSize {
+		return ErrCookieTooLarge
+	}
+	http.SetCookie(w, m.cookie(name, encrypted, maxAge))
+	return nil
+}
+
+func (m *Manager) get(r *http.Request, name string) ([]byte, string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return nil, "", ErrCookieMissing
+	}
+	value, keyID, err := m.crypto.Decrypt(cookie.Value, []byte(name))
+	if err != nil {
+		return nil, keyID, ErrCookieInvalid
+	}
+	return value, keyID, nil
+}
+
+func (m *Manager) cookie(name, value string, maxAge time.Duration) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     m.path,
+		Domain:   m.domain,
+		Secure:   m.secure,
+		HttpOnly: m.httpOnly,
+		SameSite: m.sameSite,
+	}
+	if maxAge > 0 {
+		cookie.MaxAge = int(maxAge.Seconds())
+		cookie.Expires = time.Now().Add(maxAge)
+	}
+	return cookie
+}
@@ -0,0 +1,138 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/abhissng/neuron/utils/cryptography"
+	"golang.org/x/crypto/argon2"
+)
+
+// EncryptedPrivateKeyType is the PEM block type of the private keys encrypted with a
+// passphrase by EncryptedPrivateKeyPEM. The block holds the AES-256-GCM nonce followed by the
+// sealed PKCS#8 key; its headers hold the Argon2id parameters the AES key was derived with.
+const EncryptedPrivateKeyType = "NEURON ENCRYPTED PRIVATE KEY"
+
+// These are the Argon2id parameters of the passphrase key derivation.
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// These bound the Argon2id parameters read from a PEM block, so a crafted block cannot make
+// the key derivation panic or exhaust memory.
+const (
+	maxArgon2Time    = 10
+	maxArgon2Memory  = 1024 * 1024 // KiB, i.e. 1 GiB
+	maxArgon2Threads = 16
+)
+
+// These are the headers of an EncryptedPrivateKeyType block.
+const (
+	headerKDF     = "KDF"
+	headerSalt    = "Salt"
+	headerTime    = "Time"
+	headerMemory  = "Memory"
+	headerThreads = "Threads"
+	kdfArgon2id   = "argon2id"
+)
+
+// EncryptedPrivateKeyPEM returns the private key encrypted with passphrase, for storage at
+// rest. Read it back with DecryptPrivateKeyPEM.
+func (k *KeyPair) EncryptedPrivateKeyPEM(passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required to encrypt a private key")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := passphraseAEAD(passphrase, salt, argon2Time, argon2Memory, argon2Threads)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(der)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type: EncryptedPrivateKeyType,
+		Headers: map[string]string{
+			headerKDF:     kdfArgon2id,
+			headerSalt:    base64.StdEncoding.EncodeToString(salt),
+			headerTime:    strconv.Itoa(argon2Time),
+			headerMemory:  strconv.Itoa(argon2Memory),
+			headerThreads: strconv.Itoa(argon2Threads),
+		},
+		Bytes: aead.Seal(nonce, nonce, der, []byte(EncryptedPrivateKeyType)),
+	}), nil
+}
+
+// DecryptPrivateKeyPEM decrypts a private key exported with EncryptedPrivateKeyPEM.
+func DecryptPrivateKeyPEM(data []byte, passphrase string) (*KeyPair, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block containing private key")
+	}
+	if block.Type != EncryptedPrivateKeyType {
+		return nil, fmt.Errorf("invalid PEM block type for encrypted private key: expected %q, got %q", EncryptedPrivateKeyType, block.Type)
+	}
+	if kdf := block.Headers[headerKDF]; kdf != kdfArgon2id {
+		return nil, fmt.Errorf("unsupported key derivation %q", kdf)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(block.Headers[headerSalt])
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	iterations, errTime := strconv.ParseUint(block.Headers[headerTime], 10, 32)
+	memory, errMemory := strconv.ParseUint(block.Headers[headerMemory], 10, 32)
+	threads, errThreads := strconv.ParseUint(block.Headers[headerThreads], 10, 8)
+	if err := errors.Join(errTime, errMemory, errThreads); err != nil {
+		return nil, fmt.Errorf("invalid key derivation parameters: %w", err)
+	}
+	if iterations < 1 || iterations > maxArgon2Time || memory < 8*threads || memory > maxArgon2Memory ||
+		threads < 1 || threads > maxArgon2Threads {
+		return nil, fmt.Errorf("key derivation parameters out of range: time %d, memory %d KiB, threads %d", iterations, memory, threads)
+	}
+
+	aead, err := passphraseAEAD(passphrase, salt, uint32(iterations), uint32(memory), uint8(threads))
+	if err != nil {
+		return nil, err
+	}
+	if len(block.Bytes) < aead.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+	der, err := aead.Open(nil, block.Bytes[:aead.NonceSize()], block.Bytes[aead.NonceSize():], []byte(EncryptedPrivateKeyType))
+	if err != nil {
+		return nil, errors.New("failed to decrypt private key, wrong passphrase")
+	}
+	return ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: cryptography.PRIVATE_KEY, Bytes: der}))
+}
+
+// passphraseAEAD derives an AES-256-GCM cipher from passphrase with Argon2id.
+func passphraseAEAD(passphrase string, salt []byte, iterations, memory uint32, threads uint8) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), salt, iterations, memory, threads, argon2KeyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,128 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK is a JSON Web Key (RFC 7517) of an Ed25519 (RFC 8037) or RSA key pair. The private
+// members are only set by PrivateJWK.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	D   string `json:"d,omitempty"`
+	P   string `json:"p,omitempty"`
+	Q   string `json:"q,omitempty"`
+	Dp  string `json:"dp,omitempty"`
+	Dq  string `json:"dq,omitempty"`
+	Qi  string `json:"qi,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set, as served on a JWKS endpoint.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK returns the public key as a signing JWK identified by the KeyID.
+func (k *KeyPair) PublicJWK() (JWK, error) {
+	jwk := JWK{Kid: k.KeyID, Use: "sig"}
+	switch pub := k.PublicKey.(type) {
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv, jwk.Alg = "OKP", "Ed25519", "EdDSA"
+		jwk.X = encodeBytes(pub)
+	case *rsa.PublicKey:
+		jwk.Kty, jwk.Alg = "RSA", "RS256"
+		jwk.N = encodeInt(pub.N)
+		jwk.E = encodeInt(big.NewInt(int64(pub.E)))
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return jwk, nil
+}
+
+// PrivateJWK returns the key pair as a JWK holding the private key. Keep it secret.
+func (k *KeyPair) PrivateJWK() (JWK, error) {
+	jwk, err := k.PublicJWK()
+	if err != nil {
+		return JWK{}, err
+	}
+	switch private := k.PrivateKey.(type) {
+	case ed25519.PrivateKey:
+		jwk.D = encodeBytes(private.Seed())
+	case *rsa.PrivateKey:
+		if len(private.Primes) != 2 {
+			return JWK{}, fmt.Errorf("multi-prime RSA keys are not supported")
+		}
+		private.Precompute()
+		jwk.D = encodeInt(private.D)
+		jwk.P = encodeInt(private.Primes[0])
+		jwk.Q = encodeInt(private.Primes[1])
+		jwk.Dp = encodeInt(private.Precomputed.Dp)
+		jwk.Dq = encodeInt(private.Precomputed.Dq)
+		jwk.Qi = encodeInt(private.Precomputed.Qinv)
+	}
+	return jwk, nil
+}
+
+// PublicJWKSet returns the public JWKs of pairs, e.g. the current and the previous signing
+// keys during a rotation.
+func PublicJWKSet(pairs ...*KeyPair) (JWKSet, error) {
+	set := JWKSet{Keys: make([]JWK, 0, len(pairs))}
+	for _, k := range pairs {
+		jwk, err := k.PublicJWK()
+		if err != nil {
+			return JWKSet{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of the public key, base64url encoded.
+func (k *KeyPair) Thumbprint() (string, error) {
+	var members any
+	switch pub := k.PublicKey.(type) {
+	case ed25519.PublicKey:
+		// The required members, in lexicographic order
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{"Ed25519", "OKP", encodeBytes(pub)}
+	case *rsa.PublicKey:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{encodeInt(big.NewInt(int64(pub.E))), "RSA", encodeInt(pub.N)}
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return encodeBytes(sum[:]), nil
+}
+
+// encodeBytes encodes b as base64url without padding.
+func encodeBytes(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// encodeInt encodes the big-endian bytes of i as base64url without padding.
+func encodeInt(i *big.Int) string {
+	return encodeBytes(i.Bytes())
+}
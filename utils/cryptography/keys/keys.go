@@ -0,0 +1,152 @@
+// Package keys manages signing key pairs for bootstrap scripts and services: it generates
+// Ed25519 and RSA pairs, exports them as PEM and JWK, encrypts private keys at rest with a
+// passphrase and stores them in a secret backend such as a vault.Vault.
+//
+// PEM exports use the PKCS#8 and PKIX blocks read by cryptography.LoadEd25519PrivateKey and
+// cryptography.LoadEd25519PublicKey, so generated keys can be handed to the paseto adapter.
+package keys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/abhissng/neuron/utils/cryptography"
+)
+
+// Algorithm is the algorithm of a key pair.
+type Algorithm string
+
+// These are the supported algorithms.
+const (
+	Ed25519 Algorithm = "Ed25519"
+	RSA     Algorithm = "RSA"
+)
+
+// DefaultRSABits is the size of the RSA keys generated without WithRSABits.
+const DefaultRSABits = 4096
+
+// KeyPair is a private key with its public key. KeyID is the RFC 7638 thumbprint of the
+// public key, used as the "kid" of its JWKs.
+type KeyPair struct {
+	Algorithm  Algorithm
+	KeyID      string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// generateOptions holds the options of Generate.
+type generateOptions struct {
+	rsaBits int
+}
+
+// GenerateOption is a functional option for configuring Generate.
+type GenerateOption func(*generateOptions)
+
+// WithRSABits sets the size of generated RSA keys. Defaults to DefaultRSABits.
+func WithRSABits(bits int) GenerateOption {
+	return func(o *generateOptions) {
+		o.rsaBits = bits
+	}
+}
+
+// Generate generates a key pair of algorithm.
+func Generate(algorithm Algorithm, opts ...GenerateOption) (*KeyPair, error) {
+	options := generateOptions{rsaBits: DefaultRSABits}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var private crypto.Signer
+	switch algorithm {
+	case Ed25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key pair: %w", err)
+		}
+		private = key
+	case RSA:
+		key, err := cryptography.GenerateRSAKeypair(options.rsaBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key pair: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %q", algorithm)
+	}
+	return NewKeyPair(private)
+}
+
+// NewKeyPair creates the key pair of an existing Ed25519 or RSA private key.
+func NewKeyPair(private crypto.Signer) (*KeyPair, error) {
+	var algorithm Algorithm
+	switch private.(type) {
+	case ed25519.PrivateKey:
+		algorithm = Ed25519
+	case *rsa.PrivateKey:
+		algorithm = RSA
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+	k := &KeyPair{Algorithm: algorithm, PrivateKey: private, PublicKey: private.Public()}
+	kid, err := k.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	k.KeyID = kid
+	return k, nil
+}
+
+// PrivateKeyPEM returns the private key as a PKCS#8 "PRIVATE KEY" PEM block.
+func (k *KeyPair) PrivateKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: cryptography.PRIVATE_KEY, Bytes: der}), nil
+}
+
+// PublicKeyPEM returns the public key as a PKIX "PUBLIC KEY" PEM block.
+func (k *KeyPair) PublicKeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(k.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: cryptography.PUBLIC_KEY, Bytes: der}), nil
+}
+
+// ParsePrivateKeyPEM parses a PKCS#8 or PKCS#1 private key PEM block. Use
+// DecryptPrivateKeyPEM for keys exported with EncryptedPrivateKeyPEM.
+func ParsePrivateKeyPEM(data []byte) (*KeyPair, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block containing private key")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case cryptography.PRIVATE_KEY:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case cryptography.RSA_PRIVATE_KEY:
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case EncryptedPrivateKeyType:
+		return nil, errors.New("private key is encrypted, use DecryptPrivateKeyPEM")
+	default:
+		return nil, fmt.Errorf("invalid PEM block type for private key: %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return NewKeyPair(signer)
+}
@@ -0,0 +1,69 @@
+package keys
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SecretStore stores secret values by key. *vault.Vault implements it, the backend being
+// chosen by the key prefix, e.g. "aws-sm:" or "infisical:".
+type SecretStore interface {
+	StoreVaultValue(key, value string) error
+}
+
+// StoreOptions names the secrets Store writes. Empty names are skipped.
+type StoreOptions struct {
+	// PrivateKey is the key of the PEM private key, encrypted when Passphrase is set.
+	PrivateKey string
+	// PublicKey is the key of the PEM public key.
+	PublicKey string
+	// JWKSet is the key of the public JWK set holding the key pair.
+	JWKSet string
+	// Passphrase encrypts the private key with EncryptedPrivateKeyPEM when set.
+	Passphrase string
+}
+
+// Store writes the key pair to store under the keys of options, so bootstrap scripts can
+// provision signing keys without shelling out to openssl.
+func Store(store SecretStore, k *KeyPair, options StoreOptions) error {
+	if options.PrivateKey != "" {
+		var private []byte
+		var err error
+		if options.Passphrase != "" {
+			private, err = k.EncryptedPrivateKeyPEM(options.Passphrase)
+		} else {
+			private, err = k.PrivateKeyPEM()
+		}
+		if err != nil {
+			return err
+		}
+		if err := store.StoreVaultValue(options.PrivateKey, string(private)); err != nil {
+			return fmt.Errorf("failed to store private key: %w", err)
+		}
+	}
+
+	if options.PublicKey != "" {
+		public, err := k.PublicKeyPEM()
+		if err != nil {
+			return err
+		}
+		if err := store.StoreVaultValue(options.PublicKey, string(public)); err != nil {
+			return fmt.Errorf("failed to store public key: %w", err)
+		}
+	}
+
+	if options.JWKSet != "" {
+		set, err := PublicJWKSet(k)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(set)
+		if err != nil {
+			return err
+		}
+		if err := store.StoreVaultValue(options.JWKSet, string(data)); err != nil {
+			return fmt.Errorf("failed to store JWK set: %w", err)
+		}
+	}
+	return nil
+}
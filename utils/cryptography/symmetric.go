@@ -0,0 +1,151 @@
+package cryptography
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// SymmetricManager encrypts small values with AES-256-GCM using a ring of keys identified by
+// key IDs. Values are encrypted with the active key and carry its ID, so keys can be rotated
+// while values encrypted with older keys remain readable until those keys are removed.
+//
+// Format of an encrypted value:
+//
+//	<key id>.<base64url(12 bytes GCM nonce || ciphertext-with-gcm-tag)>
+type SymmetricManager struct {
+	mu     sync.RWMutex
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// SymmetricOption defines a function type for functional options.
+type SymmetricOption func(*SymmetricManager) error
+
+// WithSymmetricKey adds a 32 byte key under id. The last key added is active unless
+// WithActiveKeyID is used.
+func WithSymmetricKey(id string, key []byte) SymmetricOption {
+	return func(m *SymmetricManager) error {
+		return m.addKey(id, key)
+	}
+}
+
+// WithActiveKeyID selects the key used to encrypt new values.
+func WithActiveKeyID(id string) SymmetricOption {
+	return func(m *SymmetricManager) error {
+		if _, ok := m.keys[id]; !ok {
+			return fmt.Errorf("unknown key id %q", id)
+		}
+		m.active = id
+		return nil
+	}
+}
+
+// NewSymmetricManager creates a SymmetricManager. At least one key is required.
+func NewSymmetricManager(opts ...SymmetricOption) (*SymmetricManager, error) {
+	m := &SymmetricManager{keys: make(map[string]cipher.AEAD)}
+	for _, opt := range opts {
+		if err := opt(m); err != nil {
+			return nil, err
+		}
+	}
+	if m.active == "" {
+		return nil, errors.New("symmetric manager requires a key")
+	}
+	return m, nil
+}
+
+// ActiveKeyID returns the ID of the key encrypting new values.
+func (m *SymmetricManager) ActiveKeyID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active
+}
+
+// Rotate adds key under id and makes it active.
+func (m *SymmetricManager) Rotate(id string, key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addKey(id, key)
+}
+
+// RemoveKey drops a retired key; values encrypted with it can no longer be decrypted. The
+// active key cannot be removed.
+func (m *SymmetricManager) RemoveKey(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == m.active {
+		return errors.New("cannot remove the active key")
+	}
+	delete(m.keys, id)
+	return nil
+}
+
+// Encrypt encrypts plaintext with the active key. additionalData, e.g. a cookie name, is
+// authenticated but not encrypted and must be passed again to Decrypt.
+func (m *SymmetricManager) Encrypt(plaintext, additionalData []byte) (string, error) {
+	m.mu.RLock()
+	id, aead := m.active, m.keys[m.active]
+	m.mu.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return id + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt and returns the plaintext with the ID of the key that encrypted
+// it. Compare the ID with ActiveKeyID to re-encrypt values made with a rotated key.
+func (m *SymmetricManager) Decrypt(value string, additionalData []byte) ([]byte, string, error) {
+	id, encoded, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, "", errors.New("invalid encrypted value")
+	}
+	m.mu.RLock()
+	aead, known := m.keys[id]
+	m.mu.RUnlock()
+	if !known {
+		return nil, id, fmt.Errorf("unknown key id %q", id)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, id, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, id, errors.New("invalid ciphertext")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, id, err
+	}
+	return plaintext, id, nil
+}
+
+// addKey registers key under id and makes it active. The caller must hold m.mu.
+func (m *SymmetricManager) addKey(id string, key []byte) error {
+	if id == "" || strings.Contains(id, ".") {
+		return fmt.Errorf("invalid key id %q", id)
+	}
+	if len(key) != 32 {
+		return errors.New("symmetric key must be 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	m.keys[id] = aead
+	m.active = id
+	return nil
+}
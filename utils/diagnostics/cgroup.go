@@ -0,0 +1,82 @@
+package diagnostics
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroupRoot is where the cgroup hierarchy of the process is mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// unlimitedMemoryV1 is the smallest cgroup v1 memory limit treated as no limit; the kernel
+// reports an unlimited cgroup as the largest page-aligned int64.
+const unlimitedMemoryV1 = 1 << 62
+
+// CgroupLimits are the CPU and memory limits of the cgroup of the process, e.g. those of its
+// container. Zero values mean no limit, or that the process is not in a cgroup.
+type CgroupLimits struct {
+	// Version is 1 or 2, 0 when no cgroup hierarchy is mounted.
+	Version int `json:"version"`
+	// CPUQuota is the number of CPUs the cgroup may use, possibly fractional.
+	CPUQuota float64 `json:"cpu_quota,omitempty"`
+	// MemoryLimit is the memory limit of the cgroup in bytes.
+	MemoryLimit int64 `json:"memory_limit,omitempty"`
+}
+
+// ReadCgroupLimits reads the limits of the cgroup v2 or v1 hierarchy mounted at
+// /sys/fs/cgroup. Unreadable limits are left unset.
+func ReadCgroupLimits() CgroupLimits {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		return readCgroupV2()
+	}
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cpu")); err == nil {
+		return readCgroupV1()
+	}
+	return CgroupLimits{}
+}
+
+// readCgroupV2 reads cpu.max ("<quota> <period>" or "max <period>") and memory.max.
+func readCgroupV2() CgroupLimits {
+	limits := CgroupLimits{Version: 2}
+	if fields := strings.Fields(readCgroupFile("cpu.max")); len(fields) == 2 && fields[0] != "max" {
+		limits.CPUQuota = cpuQuota(fields[0], fields[1])
+	}
+	if memory := readCgroupFile("memory.max"); memory != "max" {
+		limits.MemoryLimit, _ = strconv.ParseInt(memory, 10, 64)
+	}
+	return limits
+}
+
+// readCgroupV1 reads the CFS quota and period of the cpu controller, where a quota of -1 is no
+// limit, and the limit of the memory controller.
+func readCgroupV1() CgroupLimits {
+	limits := CgroupLimits{Version: 1}
+	limits.CPUQuota = cpuQuota(readCgroupFile("cpu", "cpu.cfs_quota_us"), readCgroupFile("cpu", "cpu.cfs_period_us"))
+	if memory, err := strconv.ParseInt(readCgroupFile("memory", "memory.limit_in_bytes"), 10, 64); err == nil && memory < unlimitedMemoryV1 {
+		limits.MemoryLimit = memory
+	}
+	return limits
+}
+
+// cpuQuota returns quota over period in CPUs, 0 when either is invalid or the quota is unset.
+func cpuQuota(quota, period string) float64 {
+	q, errQuota := strconv.ParseFloat(quota, 64)
+	p, errPeriod := strconv.ParseFloat(period, 64)
+	if errQuota != nil || errPeriod != nil || q <= 0 || p <= 0 {
+		return 0
+	}
+	return math.Round(q/p*100) / 100
+}
+
+// readCgroupFile returns the trimmed content of a file of the cgroup hierarchy, empty when
+// unreadable.
+func readCgroupFile(path ...string) string {
+	data, err := os.ReadFile(filepath.Join(append([]string{cgroupRoot}, path...)...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
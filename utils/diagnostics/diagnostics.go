@@ -0,0 +1,241 @@
+// Package diagnostics assembles the startup report of a service: its resolved configuration
+// with the secrets redacted, the adapters it enabled, the addresses it listens on, its
+// dependency versions and the CPU and memory limits of its runtime. The report is logged once
+// at startup and served on an admin endpoint to speed up the debugging of misconfigurations.
+//
+// Servers register their listeners on the Default collector as they start; the AppContext
+// registers the adapters it was configured with.
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+	"github.com/abhissng/neuron/utils/structures/acknowledgment"
+	"github.com/spf13/viper"
+)
+
+// Path is the conventional path of the admin endpoint served by Handler.
+const Path = "/debug/diagnostics"
+
+// Report is the diagnostics report of a service.
+type Report struct {
+	Service      string         `json:"service"`
+	Environment  string         `json:"environment"`
+	Hostname     string         `json:"hostname"`
+	Version      string         `json:"version,omitempty"`
+	Revision     string         `json:"revision,omitempty"`
+	StartedAt    time.Time      `json:"started_at"`
+	Runtime      Runtime        `json:"runtime"`
+	Adapters     []string       `json:"adapters"`
+	Listeners    []Listener     `json:"listeners"`
+	Config       map[string]any `json:"config"`
+	Dependencies []Dependency   `json:"dependencies"`
+}
+
+// Runtime describes the Go runtime and its limits.
+type Runtime struct {
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	NumCPU     int    `json:"num_cpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	// MemoryLimit is the soft memory limit of the runtime (GOMEMLIMIT), math.MaxInt64 when unset.
+	MemoryLimit int64        `json:"memory_limit"`
+	Goroutines  int          `json:"goroutines"`
+	Cgroup      CgroupLimits `json:"cgroup"`
+}
+
+// Listener is an address a server listens on.
+type Listener struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// Dependency is a module the binary was built with.
+type Dependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// Collector gathers the adapters and listeners registered by a service as it starts.
+type Collector struct {
+	mu        sync.RWMutex
+	startedAt time.Time
+	adapters  map[string]struct{}
+	listeners map[string]string
+	config    func() map[string]any
+}
+
+// Default is the collector the neuron servers and the AppContext register on.
+var Default = NewCollector()
+
+// NewCollector creates a collector whose configuration source is viper.AllSettings.
+func NewCollector() *Collector {
+	return &Collector{
+		startedAt: time.Now(),
+		adapters:  make(map[string]struct{}),
+		listeners: make(map[string]string),
+		config:    viper.AllSettings,
+	}
+}
+
+// RegisterAdapter records adapters as enabled.
+func (c *Collector) RegisterAdapter(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		c.adapters[name] = struct{}{}
+	}
+}
+
+// RegisterListener records the address a server named name listens on, replacing any address
+// previously registered under name.
+func (c *Collector) RegisterListener(name, address string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners[name] = address
+}
+
+// SetConfigSource sets the function returning the resolved configuration, redacted before it
+// is reported. Defaults to viper.AllSettings.
+func (c *Collector) SetConfigSource(source func() map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config = source
+}
+
+// Collect returns the current report.
+func (c *Collector) Collect() Report {
+	c.mu.RLock()
+	adapters := make([]string, 0, len(c.adapters))
+	for name := range c.adapters {
+		adapters = append(adapters, name)
+	}
+	listeners := make([]Listener, 0, len(c.listeners))
+	for name, address := range c.listeners {
+		listeners = append(listeners, Listener{Name: name, Address: address})
+	}
+	source := c.config
+	c.mu.RUnlock()
+
+	sort.Strings(adapters)
+	sort.Slice(listeners, func(i, j int) bool { return listeners[i].Name < listeners[j].Name })
+	hostname, _ := os.Hostname()
+
+	report := Report{
+		Service:     helpers.GetServiceName(),
+		Environment: helpers.GetEnvironment(),
+		Hostname:    hostname,
+		StartedAt:   c.startedAt,
+		Runtime:     collectRuntime(),
+		Adapters:    adapters,
+		Listeners:   listeners,
+	}
+	if source != nil {
+		report.Config = RedactConfig(source())
+	}
+	report.Version, report.Revision, report.Dependencies = buildInfo()
+	return report
+}
+
+// Handler serves the current report as an APIResponse. The report exposes the topology of the
+// service; protect the endpoint with authentication or serve it on an internal port.
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(acknowledgment.NewAPIResponse(true, "", c.Collect()))
+	})
+}
+
+// RegisterAdapter records adapters as enabled on the Default collector.
+func RegisterAdapter(names ...string) {
+	Default.RegisterAdapter(names...)
+}
+
+// RegisterListener records the address of a server on the Default collector.
+func RegisterListener(name, address string) {
+	Default.RegisterListener(name, address)
+}
+
+// Collect returns the current report of the Default collector.
+func Collect() Report {
+	return Default.Collect()
+}
+
+// Handler serves the report of the Default collector, e.g. on Path of an admin router.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// Log emits the report as one structured entry, followed by a warning when GOMAXPROCS exceeds
+// the CPU quota of the cgroup, which leads to CPU throttling.
+func (r Report) Log(logger *log.Log) {
+	if logger == nil {
+		return
+	}
+	logger.Info("Startup diagnostics",
+		log.String("service", r.Service),
+		log.String("environment", r.Environment),
+		log.String("hostname", r.Hostname),
+		log.String("version", r.Version),
+		log.String("revision", r.Revision),
+		log.Any("runtime", r.Runtime),
+		log.Any("adapters", r.Adapters),
+		log.Any("listeners", r.Listeners),
+		log.Any("config", r.Config),
+		log.Any("dependencies", r.Dependencies),
+	)
+	if quota := r.Runtime.Cgroup.CPUQuota; quota > 0 && float64(r.Runtime.GOMAXPROCS) > quota+1 {
+		logger.Warn("GOMAXPROCS exceeds the cgroup CPU quota",
+			log.Int("gomaxprocs", r.Runtime.GOMAXPROCS),
+			log.Float64("cpu_quota", quota),
+		)
+	}
+}
+
+// collectRuntime describes the running Go runtime.
+func collectRuntime() Runtime {
+	return Runtime{
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		NumCPU:      runtime.NumCPU(),
+		GOMAXPROCS:  runtime.GOMAXPROCS(0),
+		MemoryLimit: debug.SetMemoryLimit(-1),
+		Goroutines:  runtime.NumGoroutine(),
+		Cgroup:      ReadCgroupLimits(),
+	}
+}
+
+// buildInfo returns the version and VCS revision of the main module and the modules it was
+// built with.
+func buildInfo() (version, revision string, dependencies []Dependency) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", "", nil
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			revision = setting.Value
+		}
+	}
+	dependencies = make([]Dependency, 0, len(info.Deps))
+	for _, dep := range info.Deps {
+		d := Dependency{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			d.Replace = dep.Replace.Path + "@" + dep.Replace.Version
+		}
+		dependencies = append(dependencies, d)
+	}
+	return info.Main.Version, revision, dependencies
+}
@@ -0,0 +1,78 @@
+package diagnostics
+
+import (
+	"net/url"
+	"strings"
+)
+
+// RedactedValue replaces the values of sensitive configuration keys.
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeyFragments mark a configuration key as sensitive when found in its lowercased
+// name, in addition to the keys ending with "key", e.g. "signing_key".
+var sensitiveKeyFragments = []string{
+	"password", "passwd", "secret", "token", "credential", "private", "apikey", "api_key", "dsn",
+}
+
+// RedactConfig returns a copy of settings, e.g. viper.AllSettings(), with the values of
+// sensitive keys replaced by RedactedValue and the passwords of URLs removed. Nested maps and
+// lists are redacted recursively.
+func RedactConfig(settings map[string]any) map[string]any {
+	redacted := make(map[string]any, len(settings))
+	for key, value := range settings {
+		if isSensitiveKey(key) {
+			redacted[key] = RedactedValue
+			continue
+		}
+		redacted[key] = redactValue(value)
+	}
+	return redacted
+}
+
+// redactValue redacts the maps and URLs within value.
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return RedactConfig(v)
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = redactValue(item)
+		}
+		return list
+	case string:
+		return redactURL(v)
+	default:
+		return value
+	}
+}
+
+// isSensitiveKey reports whether key names a secret.
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "key") {
+		return true
+	}
+	for _, fragment := range sensitiveKeyFragments {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL removes the password of s when it is a URL with credentials, e.g. a database DSN.
+func redactURL(s string) string {
+	if !strings.Contains(s, "://") || !strings.Contains(s, "@") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return u.String()
+}
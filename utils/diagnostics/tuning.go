@@ -0,0 +1,121 @@
+package diagnostics
+
+import (
+	"errors"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMemoryLimitRatio is the share of the cgroup memory limit given to the runtime as its
+// soft memory limit, leaving headroom for memory the Go runtime does not manage.
+const DefaultMemoryLimitRatio = 0.9
+
+// RuntimeTuning describes the runtime limits derived from the cgroup by TuneRuntime.
+type RuntimeTuning struct {
+	Cgroup CgroupLimits `json:"cgroup"`
+	// GOMAXPROCS is the value in effect after tuning; GOMAXPROCSSet reports whether TuneRuntime
+	// changed it.
+	GOMAXPROCS    int  `json:"gomaxprocs"`
+	GOMAXPROCSSet bool `json:"gomaxprocs_set"`
+	// MemoryLimit is the soft memory limit in effect after tuning, math.MaxInt64 when unset;
+	// MemoryLimitSet reports whether TuneRuntime changed it.
+	MemoryLimit    int64 `json:"memory_limit"`
+	MemoryLimitSet bool  `json:"memory_limit_set"`
+}
+
+// tuneOptions holds the options of TuneRuntime.
+type tuneOptions struct {
+	memoryLimitRatio float64
+	registerer       prometheus.Registerer
+}
+
+// TuneOption is a functional option for configuring TuneRuntime.
+type TuneOption func(*tuneOptions)
+
+// WithMemoryLimitRatio sets the share of the cgroup memory limit used as the soft memory
+// limit, in (0, 1]. Defaults to DefaultMemoryLimitRatio.
+func WithMemoryLimitRatio(ratio float64) TuneOption {
+	return func(o *tuneOptions) {
+		if ratio > 0 && ratio <= 1 {
+			o.memoryLimitRatio = ratio
+		}
+	}
+}
+
+// WithTuningMetrics registers gauges of the tuned values with registerer:
+// runtime_gomaxprocs, runtime_memory_limit_bytes, runtime_cgroup_cpu_quota and
+// runtime_cgroup_memory_limit_bytes.
+func WithTuningMetrics(registerer prometheus.Registerer) TuneOption {
+	return func(o *tuneOptions) {
+		o.registerer = registerer
+	}
+}
+
+// TuneRuntime sizes the runtime to the cgroup of the process, as automaxprocs does: it sets
+// GOMAXPROCS to the CPU quota rounded down, at least 1, and the soft memory limit to a share
+// of the memory limit. Values set through the GOMAXPROCS and GOMEMLIMIT environment variables
+// are left untouched, as are the limits of processes outside a limited cgroup.
+func TuneRuntime(opts ...TuneOption) (RuntimeTuning, error) {
+	options := tuneOptions{memoryLimitRatio: DefaultMemoryLimitRatio}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	tuning := RuntimeTuning{Cgroup: ReadCgroupLimits()}
+	if quota := tuning.Cgroup.CPUQuota; quota > 0 && os.Getenv("GOMAXPROCS") == "" {
+		procs := max(int(math.Floor(quota)), 1)
+		if procs != runtime.GOMAXPROCS(0) {
+			runtime.GOMAXPROCS(procs)
+			tuning.GOMAXPROCSSet = true
+		}
+	}
+	if limit := tuning.Cgroup.MemoryLimit; limit > 0 && os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(int64(float64(limit) * options.memoryLimitRatio))
+		tuning.MemoryLimitSet = true
+	}
+	tuning.GOMAXPROCS = runtime.GOMAXPROCS(0)
+	tuning.MemoryLimit = debug.SetMemoryLimit(-1)
+
+	if options.registerer != nil {
+		if err := registerTuningMetrics(options.registerer, tuning.Cgroup); err != nil {
+			return tuning, err
+		}
+	}
+	return tuning, nil
+}
+
+// registerTuningMetrics registers the gauges of WithTuningMetrics, reading the runtime values
+// on each scrape.
+func registerTuningMetrics(registerer prometheus.Registerer, cgroup CgroupLimits) error {
+	gauges := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "runtime_gomaxprocs",
+			Help: "GOMAXPROCS of the Go runtime.",
+		}, func() float64 { return float64(runtime.GOMAXPROCS(0)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "runtime_memory_limit_bytes",
+			Help: "Soft memory limit of the Go runtime (GOMEMLIMIT).",
+		}, func() float64 { return float64(debug.SetMemoryLimit(-1)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "runtime_cgroup_cpu_quota",
+			Help: "CPU quota of the cgroup of the process in CPUs, 0 when unlimited.",
+		}, func() float64 { return cgroup.CPUQuota }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "runtime_cgroup_memory_limit_bytes",
+			Help: "Memory limit of the cgroup of the process, 0 when unlimited.",
+		}, func() float64 { return float64(cgroup.MemoryLimit) }),
+	}
+	for _, gauge := range gauges {
+		if err := registerer.Register(gauge); err != nil {
+			var already prometheus.AlreadyRegisteredError
+			if !errors.As(err, &already) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+// Package watchdog captures heap and goroutine profiles when a service crosses its memory or
+// goroutine thresholds, uploads them to object storage with a retention period and emits an
+// alert, to debug leaks that only happen in production.
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhissng/neuron/adapters/cloud"
+	"github.com/abhissng/neuron/adapters/events"
+	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/helpers"
+)
+
+// Defaults of the watchdog options.
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultCooldown  = 15 * time.Minute
+	DefaultRetention = 7 * 24 * time.Hour
+	DefaultPrefix    = "profiles"
+)
+
+// profileContentType is the content type of the uploaded profiles, gzipped protobuf.
+const profileContentType = "application/octet-stream"
+
+// Alert describes a capture, emitted once its profiles are uploaded.
+type Alert struct {
+	Service            string    `json:"service"`
+	Hostname           string    `json:"hostname"`
+	Reason             string    `json:"reason"`
+	HeapBytes          uint64    `json:"heap_bytes"`
+	HeapThreshold      uint64    `json:"heap_threshold,omitempty"`
+	Goroutines         int       `json:"goroutines"`
+	GoroutineThreshold int       `json:"goroutine_threshold,omitempty"`
+	Bucket             string    `json:"bucket"`
+	Profiles           []string  `json:"profiles"`
+	CapturedAt         time.Time `json:"captured_at"`
+}
+
+// AlertFunc is called with each capture.
+type AlertFunc func(ctx context.Context, alert Alert)
+
+// PublishAlert returns an AlertFunc publishing the alerts to subject on broker.
+func PublishAlert(broker events.Broker, subject string) AlertFunc {
+	return func(ctx context.Context, alert Alert) {
+		_ = broker.Publish(ctx, subject, alert)
+	}
+}
+
+// Watchdog samples the heap and goroutine counts of the process and captures profiles when
+// they exceed their thresholds, at most once per cooldown.
+type Watchdog struct {
+	store              cloud.CloudManager
+	bucket             string
+	prefix             string
+	heapThreshold      uint64
+	goroutineThreshold int
+	interval           time.Duration
+	cooldown           time.Duration
+	retention          time.Duration
+	alert              AlertFunc
+	log                *log.Log
+	service            string
+	hostname           string
+
+	mu          sync.Mutex
+	lastCapture time.Time
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// Option is a functional option for configuring a Watchdog.
+type Option func(*Watchdog)
+
+// WithHeapThreshold captures profiles when the allocated heap exceeds bytes.
+func WithHeapThreshold(bytes uint64) Option {
+	return func(w *Watchdog) {
+		w.heapThreshold = bytes
+	}
+}
+
+// WithGoroutineThreshold captures profiles when the number of goroutines exceeds n.
+func WithGoroutineThreshold(n int) Option {
+	return func(w *Watchdog) {
+		w.goroutineThreshold = n
+	}
+}
+
+// WithInterval sets how often the thresholds are checked. Defaults to DefaultInterval.
+func WithInterval(interval time.Duration) Option {
+	return func(w *Watchdog) {
+		w.interval = interval
+	}
+}
+
+// WithCooldown sets the minimum time between two captures, so a sustained pressure does not
+// flood the storage. Defaults to DefaultCooldown.
+func WithCooldown(cooldown time.Duration) Option {
+	return func(w *Watchdog) {
+		w.cooldown = cooldown
+	}
+}
+
+// WithRetention sets how long uploaded profiles are kept; older ones under the prefix of the
+// service are deleted after each capture. Defaults to DefaultRetention.
+func WithRetention(retention time.Duration) Option {
+	return func(w *Watchdog) {
+		w.retention = retention
+	}
+}
+
+// WithPrefix sets the key prefix of the uploaded profiles. Defaults to DefaultPrefix.
+func WithPrefix(prefix string) Option {
+	return func(w *Watchdog) {
+		w.prefix = strings.Trim(prefix, "/")
+	}
+}
+
+// WithAlert sets the function called with each capture, e.g. PublishAlert.
+func WithAlert(alert AlertFunc) Option {
+	return func(w *Watchdog) {
+		w.alert = alert
+	}
+}
+
+// WithLogger sets the logger of the watchdog.
+func WithLogger(logger *log.Log) Option {
+	return func(w *Watchdog) {
+		w.log = logger
+	}
+}
+
+// WithServiceName sets the service the profiles are stored under. Defaults to the configured
+// service name.
+func WithServiceName(service string) Option {
+	return func(w *Watchdog) {
+		w.service = service
+	}
+}
+
+// New creates a Watchdog uploading its profiles to bucket of store. At least one of
+// WithHeapThreshold and WithGoroutineThreshold is required.
+func New(store cloud.CloudManager, bucket string, opts ...Option) (*Watchdog, error) {
+	hostname, _ := os.Hostname()
+	w := &Watchdog{
+		store:     store,
+		bucket:    bucket,
+		prefix:    DefaultPrefix,
+		interval:  DefaultInterval,
+		cooldown:  DefaultCooldown,
+		retention: DefaultRetention,
+		service:   helpers.GetServiceName(),
+		hostname:  hostname,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if store == nil || bucket == "" {
+		return nil, errors.New("watchdog: object storage and bucket are required")
+	}
+	if w.heapThreshold == 0 && w.goroutineThreshold == 0 {
+		return nil, errors.New("watchdog: a heap or goroutine threshold is required")
+	}
+	if w.log == nil {
+		w.log = log.NewBasicLogger(helpers.IsProdEnvironment(), true)
+	}
+	if w.service == "" {
+		w.service = "service"
+	}
+	return w, nil
+}
+
+// Start checks the thresholds every interval until ctx is done or Stop is called.
+func (w *Watchdog) Start(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+	go w.run(ctx)
+}
+
+// Stop stops the checks started by Start and waits for an ongoing capture.
+func (w *Watchdog) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel = nil
+	w.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// run checks the thresholds every interval until ctx is done.
+func (w *Watchdog) run(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.Check(ctx); err != nil {
+				w.log.Error("Watchdog failed to capture profiles", log.Err(err))
+			}
+		}
+	}
+}
+
+// Check samples the process once and captures profiles when a threshold is exceeded and the
+// cooldown is over. It returns the alert of the capture, nil without one.
+func (w *Watchdog) Check(ctx context.Context) (*Alert, error) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	goroutines := runtime.NumGoroutine()
+
+	var reasons []string
+	if w.heapThreshold > 0 && stats.HeapAlloc > w.heapThreshold {
+		reasons = append(reasons, fmt.Sprintf("heap %d bytes exceeds %d", stats.HeapAlloc, w.heapThreshold))
+	}
+	if w.goroutineThreshold > 0 && goroutines > w.goroutineThreshold {
+		reasons = append(reasons, fmt.Sprintf("%d goroutines exceed %d", goroutines, w.goroutineThreshold))
+	}
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	w.mu.Lock()
+	if !w.lastCapture.IsZero() && now.Sub(w.lastCapture) < w.cooldown {
+		w.mu.Unlock()
+		return nil, nil
+	}
+	w.lastCapture = now
+	w.mu.Unlock()
+
+	alert := &Alert{
+		Service:            w.service,
+		Hostname:           w.hostname,
+		Reason:             strings.Join(reasons, "; "),
+		HeapBytes:          stats.HeapAlloc,
+		HeapThreshold:      w.heapThreshold,
+		Goroutines:         goroutines,
+		GoroutineThreshold: w.goroutineThreshold,
+		Bucket:             w.bucket,
+		CapturedAt:         now,
+	}
+	w.log.Warn("Watchdog threshold exceeded, capturing profiles", log.String("reason", alert.Reason))
+
+	for _, profile := range []string{"heap", "goroutine"} {
+		key, err := w.upload(ctx, profile, now)
+		if err != nil {
+			return nil, err
+		}
+		alert.Profiles = append(alert.Profiles, key)
+	}
+	w.log.Info("Watchdog profiles uploaded", log.String("bucket", w.bucket), log.Any("profiles", alert.Profiles))
+
+	if w.alert != nil {
+		w.alert(ctx, *alert)
+	}
+	if err := w.prune(ctx, now); err != nil {
+		w.log.Warn("Watchdog failed to delete expired profiles", log.Err(err))
+	}
+	return alert, nil
+}
+
+// upload writes the named profile and uploads it, returning its key.
+func (w *Watchdog) upload(ctx context.Context, profile string, at time.Time) (string, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup(profile).WriteTo(&buf, 0); err != nil {
+		return "", fmt.Errorf("watchdog: failed to write %s profile: %w", profile, err)
+	}
+	key := path.Join(w.servicePrefix(), at.Format("20060102T150405Z")+"-"+w.hostname+"-"+profile+".pb.gz")
+	metadata := map[string]string{"service": w.service, "hostname": w.hostname, "profile": profile}
+	if err := w.store.UploadFile(ctx, w.bucket, key, buf.Bytes(), profileContentType, metadata); err != nil {
+		return "", fmt.Errorf("watchdog: failed to upload %s profile: %w", profile, err)
+	}
+	return key, nil
+}
+
+// prune deletes the profiles of the service older than the retention.
+func (w *Watchdog) prune(ctx context.Context, now time.Time) error {
+	if w.retention <= 0 {
+		return nil
+	}
+	objects, err := w.store.ListObjects(ctx, w.bucket, w.servicePrefix()+"/")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, object := range objects {
+		if now.Sub(object.LastModified) > w.retention {
+			errs = append(errs, w.store.DeleteObject(ctx, w.bucket, object.Key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// servicePrefix returns the key prefix of the profiles of the service.
+func (w *Watchdog) servicePrefix() string {
+	return path.Join(w.prefix, w.service)
+}
@@ -0,0 +1,260 @@
+package helpers
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DeepClone returns a copy of value that shares no maps, slices or pointers with it, so the
+// copy can be kept as a before snapshot while the original is modified. Cycles and shared
+// pointers are preserved. Unexported struct fields, channels and functions are copied
+// shallowly.
+func DeepClone[T any](value T) T {
+	rv := reflect.ValueOf(&value).Elem()
+	out := reflect.New(rv.Type()).Elem()
+	cloneValue(out, rv, map[visitKey]reflect.Value{})
+	return out.Interface().(T)
+}
+
+// visitKey identifies an already cloned pointer, map or slice.
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+func cloneValue(dst, src reflect.Value, visited map[visitKey]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		key := visitKey{ptr: src.Pointer(), typ: src.Type()}
+		if clone, ok := visited[key]; ok {
+			dst.Set(clone)
+			return
+		}
+		clone := reflect.New(src.Type().Elem())
+		visited[key] = clone
+		cloneValue(clone.Elem(), src.Elem(), visited)
+		dst.Set(clone)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		clone := reflect.New(elem.Type()).Elem()
+		cloneValue(clone, elem, visited)
+		dst.Set(clone)
+
+	case reflect.Struct:
+		// Copy everything first so unexported fields, e.g. inside time.Time, are kept.
+		dst.Set(src)
+		if src.Type() == timeType {
+			return
+		}
+		for i := 0; i < src.NumField(); i++ {
+			if src.Type().Field(i).IsExported() {
+				cloneValue(dst.Field(i), src.Field(i), visited)
+			}
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		key := visitKey{ptr: src.Pointer(), typ: src.Type(), len: src.Len()}
+		if clone, ok := visited[key]; ok && src.Len() > 0 {
+			dst.Set(clone)
+			return
+		}
+		clone := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		visited[key] = clone
+		for i := 0; i < src.Len(); i++ {
+			cloneValue(clone.Index(i), src.Index(i), visited)
+		}
+		dst.Set(clone)
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			cloneValue(dst.Index(i), src.Index(i), visited)
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		key := visitKey{ptr: src.Pointer(), typ: src.Type()}
+		if clone, ok := visited[key]; ok {
+			dst.Set(clone)
+			return
+		}
+		clone := reflect.MakeMapWithSize(src.Type(), src.Len())
+		visited[key] = clone
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			cloneValue(k, iter.Key(), visited)
+			v := reflect.New(src.Type().Elem()).Elem()
+			cloneValue(v, iter.Value(), visited)
+			clone.SetMapIndex(k, v)
+		}
+		dst.Set(clone)
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// Change is a difference found by Diff.
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// Changes lists the differences between two values in traversal order.
+type Changes []Change
+
+// Paths returns the changed paths.
+func (c Changes) Paths() []string {
+	paths := make([]string, len(c))
+	for i, change := range c {
+		paths[i] = change.Path
+	}
+	return paths
+}
+
+// String renders the changes as "path: old -> new" lines, e.g. for conflict messages.
+func (c Changes) String() string {
+	var b strings.Builder
+	for i, change := range c {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s: %v -> %v", change.Path, change.Old, change.New)
+	}
+	return b.String()
+}
+
+// Diff compares a and b field by field and returns the changed paths, such as
+// "items[2].amount" or "notes.source", with their old and new values. Struct fields are named
+// by their json tags (or the tag set with WithTagName); unexported fields are ignored. Values
+// of different types are reported as a single change at the root. Like reflect.DeepEqual, a
+// pair of pointers, maps or slices is compared once, so cyclic values terminate.
+func Diff(a, b any, options ...MapOption) Changes {
+	config := newMapConfig(options)
+	var changes Changes
+	config.diff("", reflect.ValueOf(a), reflect.ValueOf(b), &changes, make(map[diffVisit]bool))
+	return changes
+}
+
+// diffVisit is a pair of references compared by Diff.
+type diffVisit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+func (c *mapConfig) diff(path string, a, b reflect.Value, changes *Changes, visited map[diffVisit]bool) {
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		if a.IsValid() != b.IsValid() || (a.IsValid() && !reflect.DeepEqual(a.Interface(), b.Interface())) {
+			*changes = append(*changes, Change{Path: path, Old: interfaceOf(a), New: interfaceOf(b)})
+		}
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if !a.IsNil() && !b.IsNil() {
+			visit := diffVisit{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+			if visited[visit] {
+				return
+			}
+			visited[visit] = true
+		}
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				*changes = append(*changes, Change{Path: path, Old: interfaceOf(a), New: interfaceOf(b)})
+			}
+			return
+		}
+		c.diff(path, a.Elem(), b.Elem(), changes, visited)
+
+	case reflect.Struct:
+		if a.Type() == timeType {
+			if !a.Interface().(time.Time).Equal(b.Interface().(time.Time)) {
+				*changes = append(*changes, Change{Path: path, Old: a.Interface(), New: b.Interface()})
+			}
+			return
+		}
+		for _, field := range structFields(a.Type(), c.tagName).fields {
+			fa, okA := fieldByIndex(a, field.index)
+			fb, okB := fieldByIndex(b, field.index)
+			if !okA || !okB {
+				if okA != okB {
+					*changes = append(*changes, Change{Path: joinPath(path, field.name), Old: interfaceOf(fa), New: interfaceOf(fb)})
+				}
+				continue
+			}
+			c.diff(joinPath(path, field.name), fa, fb, changes, visited)
+		}
+
+	case reflect.Map:
+		keys := map[string]reflect.Value{}
+		for _, m := range []reflect.Value{a, b} {
+			iter := m.MapRange()
+			for iter.Next() {
+				keys[fmt.Sprint(iter.Key().Interface())] = iter.Key()
+			}
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			c.diff(joinPath(path, name), a.MapIndex(keys[name]), b.MapIndex(keys[name]), changes, visited)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if a.Kind() == reflect.Slice && a.Type().Elem().Kind() == reflect.Uint8 {
+			if string(a.Bytes()) != string(b.Bytes()) {
+				*changes = append(*changes, Change{Path: path, Old: a.Interface(), New: b.Interface()})
+			}
+			return
+		}
+		for i := 0; i < max(a.Len(), b.Len()); i++ {
+			var ea, eb reflect.Value
+			if i < a.Len() {
+				ea = a.Index(i)
+			}
+			if i < b.Len() {
+				eb = b.Index(i)
+			}
+			c.diff(indexPath(path, i), ea, eb, changes, visited)
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		// Not comparable by content.
+
+	default:
+		if a.Interface() != b.Interface() {
+			*changes = append(*changes, Change{Path: path, Old: a.Interface(), New: b.Interface()})
+		}
+	}
+}
+
+func interfaceOf(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
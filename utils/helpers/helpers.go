@@ -2,7 +2,6 @@ package helpers
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"maps"
@@ -195,6 +194,10 @@ func FetchHTTPStatusCode(response types.ResponseErrorType) int {
 		return http.StatusNotFound
 	case constant.AlreadyExists:
 		return http.StatusConflict
+	case constant.RequestTimeout:
+		return http.StatusRequestTimeout
+	case constant.TooLarge:
+		return http.StatusRequestEntityTooLarge
 	}
 	return http.StatusInternalServerError
 }
@@ -243,9 +246,10 @@ func GetDefaultPort() string {
 	}
 }
 
-// GetMaxConns returns the default value for MaxConns for postgres or sql.
+// GetMaxConns returns the default value for MaxConns for postgres or sql. It follows
+// GOMAXPROCS rather than the host CPUs, so it honours the CPU quota of a tuned container.
 func GetMaxConns(maxConn int) int {
-	numCPU := runtime.NumCPU()
+	numCPU := runtime.GOMAXPROCS(0)
 	if maxConn < 4 && numCPU < 4 {
 		return 4
 	}
@@ -1073,34 +1077,6 @@ func HasAnyPrefix(s string, prefixes ...string) bool {
 	return false
 }
 
-// StructToMap converts a struct to map[string]any via JSON (for SDK requests).
-func StructToMap(v any) (map[string]any, error) {
-	b, err := json.Marshal(v)
-	if err != nil {
-		return nil, err
-	}
-	var m map[string]any
-	if err := json.Unmarshal(b, &m); err != nil {
-		return nil, err
-	}
-	return m, nil
-}
-
-// MapToStruct converts map[string]any to a struct via JSON (for SDK responses).
-func MapToStruct[T any](m map[string]any) (T, error) {
-	var out T
-	b, err := json.Marshal(m)
-	if err != nil {
-		var zero T
-		return zero, err
-	}
-	if err := json.Unmarshal(b, &out); err != nil {
-		var zero T
-		return zero, err
-	}
-	return out, nil
-}
-
 // MapTo converts any to a struct or slice of structs.
 func MapTo[T any](input any) (T, error) {
 	var out T
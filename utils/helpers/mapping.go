@@ -0,0 +1,752 @@
+package helpers
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	timeType            = reflect.TypeFor[time.Time]()
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+	// structFieldsCache caches the tagged fields of struct types per tag name.
+	structFieldsCache sync.Map
+)
+
+// MapOption configures StructToMap and MapToStruct.
+type MapOption func(*mapConfig)
+
+type mapConfig struct {
+	tagName    string
+	strict     bool
+	timeLayout string
+	unixTime   bool
+}
+
+// WithTagName reads field names from the given struct tag instead of json, e.g. "db" or
+// "mapstructure". Tag options such as omitempty are honoured for any tag name.
+func WithTagName(name string) MapOption {
+	return func(c *mapConfig) {
+		c.tagName = name
+	}
+}
+
+// WithStrict makes MapToStruct fail on keys that match no struct field.
+func WithStrict() MapOption {
+	return func(c *mapConfig) {
+		c.strict = true
+	}
+}
+
+// WithTimeLayout sets the layout used to format times in StructToMap and the first layout
+// tried when parsing them in MapToStruct. Defaults to time.RFC3339Nano.
+func WithTimeLayout(layout string) MapOption {
+	return func(c *mapConfig) {
+		c.timeLayout = layout
+	}
+}
+
+// WithUnixTime makes StructToMap write times as Unix seconds, as many payment APIs expect.
+func WithUnixTime() MapOption {
+	return func(c *mapConfig) {
+		c.unixTime = true
+	}
+}
+
+func newMapConfig(options []MapOption) *mapConfig {
+	config := &mapConfig{tagName: "json", timeLayout: time.RFC3339Nano}
+	for _, opt := range options {
+		opt(config)
+	}
+	return config
+}
+
+// mappedField is a struct field reachable under a map key.
+type mappedField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	omitZero  bool
+	asString  bool
+	field     reflect.StructField
+}
+
+// mappedStruct lists the mapped fields of a struct type.
+type mappedStruct struct {
+	fields []mappedField
+	byName map[string]int
+	byFold map[string]int
+}
+
+type structCacheKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// structFields returns the cached field mapping of t for tag.
+func structFields(t reflect.Type, tag string) *mappedStruct {
+	key := structCacheKey{t: t, tag: tag}
+	if cached, ok := structFieldsCache.Load(key); ok {
+		return cached.(*mappedStruct)
+	}
+
+	info := &mappedStruct{byName: map[string]int{}, byFold: map[string]int{}}
+	for _, field := range reflect.VisibleFields(t) {
+		name, options, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				// Promoted fields are listed separately by VisibleFields.
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, shadowed := info.byName[name]; shadowed {
+			continue
+		}
+		info.byName[name] = len(info.fields)
+		if _, ok := info.byFold[strings.ToLower(name)]; !ok {
+			info.byFold[strings.ToLower(name)] = len(info.fields)
+		}
+		info.fields = append(info.fields, mappedField{
+			name:      name,
+			index:     field.Index,
+			omitEmpty: strings.Contains(options, "omitempty"),
+			omitZero:  strings.Contains(options, "omitzero"),
+			asString:  strings.Contains(options, "string"),
+			field:     field,
+		})
+	}
+
+	actual, _ := structFieldsCache.LoadOrStore(key, info)
+	return actual.(*mappedStruct)
+}
+
+// lookup finds the field for key, exactly or case-insensitively like encoding/json.
+func (s *mappedStruct) lookup(key string) (mappedField, bool) {
+	if i, ok := s.byName[key]; ok {
+		return s.fields[i], true
+	}
+	if i, ok := s.byFold[strings.ToLower(key)]; ok {
+		return s.fields[i], true
+	}
+	return mappedField{}, false
+}
+
+// StructField is a struct field as mapped by StructToMap and MapToStruct.
+type StructField struct {
+	// Key is the name of the field in maps: its tag name, or else its Go name.
+	Key string
+	// Field is the field itself; its Index reaches promoted fields of embedded structs.
+	Field reflect.StructField
+}
+
+// LookupStructField finds the field of struct type t mapped to key by tag, exactly or
+// case-insensitively like encoding/json. Field mappings are cached per type and tag.
+func LookupStructField(t reflect.Type, tag, key string) (StructField, bool) {
+	field, ok := structFields(t, tag).lookup(key)
+	if !ok {
+		return StructField{}, false
+	}
+	return StructField{Key: field.name, Field: field.field}, true
+}
+
+// StructToMap converts a struct to map[string]any using its json tags (or the tag set with
+// WithTagName). Nested structs become maps, times are formatted with the configured layout and
+// types implementing json.Marshaler or encoding.TextMarshaler (decimals, UUIDs) are converted
+// as encoding/json would. Field mappings are cached per type.
+func StructToMap(v any, options ...MapOption) (map[string]any, error) {
+	config := newMapConfig(options)
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map:
+		out, err := config.encode(rv)
+		if err != nil {
+			return nil, err
+		}
+		m, _ := out.(map[string]any)
+		return m, nil
+	default:
+		return nil, fmt.Errorf("StructToMap: expected a struct or map, got %s", rv.Type())
+	}
+}
+
+// encode converts rv to maps, slices and scalar values.
+func (c *mapConfig) encode(rv reflect.Value) (any, error) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+
+	t := rv.Type()
+	if t == timeType {
+		tm := rv.Interface().(time.Time)
+		if c.unixTime {
+			return tm.Unix(), nil
+		}
+		return tm.Format(c.timeLayout), nil
+	}
+	if marshaler, ok := implementer(rv, jsonMarshalerType); ok {
+		data, err := marshaler.(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var out any
+		if err := json.Unmarshal(data, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+	if marshaler, ok := implementer(rv, textMarshalerType); ok {
+		text, err := marshaler.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return c.encode(rv.Elem())
+	case reflect.Struct:
+		return c.encodeStruct(rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := mapKeyString(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			value, err := c.encode(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[key] = value
+		}
+		return out, nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(rv.Bytes()), nil
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]any, rv.Len())
+		for i := range out {
+			value, err := c.encode(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = value
+		}
+		return out, nil
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return nil, fmt.Errorf("StructToMap: unsupported type %s", t)
+	default:
+		return rv.Interface(), nil
+	}
+}
+
+func (c *mapConfig) encodeStruct(rv reflect.Value) (map[string]any, error) {
+	info := structFields(rv.Type(), c.tagName)
+	out := make(map[string]any, len(info.fields))
+	for _, field := range info.fields {
+		value, ok := fieldByIndex(rv, field.index)
+		if !ok {
+			// Embedded through a nil pointer.
+			continue
+		}
+		if (field.omitEmpty && isEmptyValue(value)) || (field.omitZero && value.IsZero()) {
+			continue
+		}
+		encoded, err := c.encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		if field.asString {
+			switch encoded.(type) {
+			case string, nil, map[string]any, []any:
+			default:
+				encoded = fmt.Sprint(encoded)
+			}
+		}
+		out[field.name] = encoded
+	}
+	return out, nil
+}
+
+// MapToStruct converts a map to T, a struct or pointer to struct, using json tags (or the tag
+// set with WithTagName). Keys match field names exactly or case-insensitively. Numbers and
+// numeric strings convert between numeric kinds without losing precision, times parse from
+// strings or Unix seconds and types implementing encoding.TextUnmarshaler or json.Unmarshaler
+// decode from their text form. WithStrict rejects keys that match no field.
+func MapToStruct[T any](m map[string]any, options ...MapOption) (T, error) {
+	config := newMapConfig(options)
+	var out T
+	rv := reflect.ValueOf(&out).Elem()
+	if m == nil {
+		return out, nil
+	}
+	if err := config.decode(rv, m, ""); err != nil {
+		var zero T
+		return zero, fmt.Errorf("MapToStruct: %w", err)
+	}
+	return out, nil
+}
+
+// decode stores src into dst, converting between compatible representations.
+func (c *mapConfig) decode(dst reflect.Value, src any, path string) error {
+	if src == nil {
+		dst.SetZero()
+		return nil
+	}
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return c.decode(dst.Elem(), src, path)
+	}
+
+	t := dst.Type()
+	sv := reflect.ValueOf(src)
+	if t == timeType {
+		tm, err := c.parseTime(src)
+		if err != nil {
+			return fieldError(path, err)
+		}
+		dst.Set(reflect.ValueOf(tm))
+		return nil
+	}
+	if sv.Type().AssignableTo(t) && t.Kind() != reflect.Struct {
+		dst.Set(sv)
+		return nil
+	}
+	if dst.CanAddr() {
+		addr := dst.Addr()
+		if addr.Type().Implements(textUnmarshalerType) {
+			if text, ok := scalarText(src); ok {
+				if err := addr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+					return fieldError(path, err)
+				}
+				return nil
+			}
+		}
+		if addr.Type().Implements(jsonUnmarshalerType) {
+			data, err := json.Marshal(src)
+			if err != nil {
+				return fieldError(path, err)
+			}
+			if err := addr.Interface().(json.Unmarshaler).UnmarshalJSON(data); err != nil {
+				return fieldError(path, err)
+			}
+			return nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if sv.Type().Implements(t) {
+			dst.Set(sv)
+			return nil
+		}
+	case reflect.Struct:
+		return c.decodeStruct(dst, sv, path)
+	case reflect.Map:
+		return c.decodeMap(dst, sv, path)
+	case reflect.Slice:
+		if text, ok := src.(string); ok && t.Elem().Kind() == reflect.Uint8 {
+			data, err := base64.StdEncoding.DecodeString(text)
+			if err != nil {
+				return fieldError(path, err)
+			}
+			dst.SetBytes(data)
+			return nil
+		}
+		if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+			break
+		}
+		out := reflect.MakeSlice(t, sv.Len(), sv.Len())
+		for i := 0; i < sv.Len(); i++ {
+			if err := c.decode(out.Index(i), sv.Index(i).Interface(), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+		return nil
+	case reflect.Array:
+		if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+			break
+		}
+		for i := 0; i < dst.Len() && i < sv.Len(); i++ {
+			if err := c.decode(dst.Index(i), sv.Index(i).Interface(), indexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		if text, ok := scalarText(src); ok {
+			dst.SetString(text)
+			return nil
+		}
+	case reflect.Bool:
+		switch value := src.(type) {
+		case bool:
+			dst.SetBool(value)
+			return nil
+		case string:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fieldError(path, err)
+			}
+			dst.SetBool(parsed)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(src)
+		if err != nil {
+			return fieldError(path, err)
+		}
+		if dst.OverflowInt(n) {
+			return fieldError(path, fmt.Errorf("%d overflows %s", n, t))
+		}
+		dst.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := toUint64(src)
+		if err != nil {
+			return fieldError(path, err)
+		}
+		if dst.OverflowUint(n) {
+			return fieldError(path, fmt.Errorf("%d overflows %s", n, t))
+		}
+		dst.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(src)
+		if err != nil {
+			return fieldError(path, err)
+		}
+		if dst.OverflowFloat(f) {
+			return fieldError(path, fmt.Errorf("%g overflows %s", f, t))
+		}
+		dst.SetFloat(f)
+		return nil
+	}
+	return fieldError(path, fmt.Errorf("cannot convert %T to %s", src, t))
+}
+
+func (c *mapConfig) decodeStruct(dst, sv reflect.Value, path string) error {
+	if sv.Kind() == reflect.Struct {
+		// Re-map structs of another type through their map form.
+		encoded, err := c.encode(sv)
+		if err != nil {
+			return fieldError(path, err)
+		}
+		sv = reflect.ValueOf(encoded)
+	}
+	if sv.Kind() != reflect.Map || sv.Type().Key().Kind() != reflect.String {
+		return fieldError(path, fmt.Errorf("cannot convert %s to %s", sv.Type(), dst.Type()))
+	}
+
+	info := structFields(dst.Type(), c.tagName)
+	iter := sv.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		field, ok := info.lookup(key)
+		if !ok {
+			if c.strict {
+				return fieldError(joinPath(path, key), fmt.Errorf("unknown field"))
+			}
+			continue
+		}
+		value := iter.Value().Interface()
+		if field.asString {
+			if text, isText := value.(string); isText {
+				value = json.Number(text)
+			}
+		}
+		target, err := allocFieldByIndex(dst, field.index)
+		if err != nil {
+			return fieldError(joinPath(path, key), err)
+		}
+		if err := c.decode(target, value, joinPath(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *mapConfig) decodeMap(dst, sv reflect.Value, path string) error {
+	if sv.Kind() != reflect.Map {
+		return fieldError(path, fmt.Errorf("cannot convert %s to %s", sv.Type(), dst.Type()))
+	}
+	t := dst.Type()
+	out := reflect.MakeMapWithSize(t, sv.Len())
+	iter := sv.MapRange()
+	for iter.Next() {
+		keyText := fmt.Sprint(iter.Key().Interface())
+		key := reflect.New(t.Key()).Elem()
+		if err := c.decode(key, keyText, joinPath(path, keyText)); err != nil {
+			return err
+		}
+		value := reflect.New(t.Elem()).Elem()
+		if err := c.decode(value, iter.Value().Interface(), joinPath(path, keyText)); err != nil {
+			return err
+		}
+		out.SetMapIndex(key, value)
+	}
+	dst.Set(out)
+	return nil
+}
+
+// parseTime accepts time.Time, strings in the configured layout or RFC 3339, and Unix seconds.
+func (c *mapConfig) parseTime(src any) (time.Time, error) {
+	switch value := src.(type) {
+	case time.Time:
+		return value, nil
+	case string:
+		for _, layout := range []string{c.timeLayout, time.RFC3339Nano, time.DateTime, time.DateOnly} {
+			if tm, err := time.Parse(layout, value); err == nil {
+				return tm, nil
+			}
+		}
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(seconds, 0), nil
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q as time", value)
+	default:
+		seconds, err := toFloat64(src)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot convert %T to time", src)
+		}
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(fraction*1e9)), nil
+	}
+}
+
+// scalarText returns the text form of strings, numbers and booleans.
+func scalarText(src any) (string, bool) {
+	switch value := src.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(value), 'f', -1, 32), true
+	}
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	}
+	return "", false
+}
+
+func toInt64(src any) (int64, error) {
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", rv.Uint())
+		}
+		return int64(rv.Uint()), nil // #nosec G115 -- checked above
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%g is not an integer", f)
+		}
+		return int64(f), nil
+	case reflect.String:
+		text := strings.TrimSpace(rv.String())
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n, nil
+		}
+		// Allow integral decimals such as "100.00".
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse %q as integer", text)
+		}
+		return toInt64(f)
+	}
+	return 0, fmt.Errorf("cannot convert %T to integer", src)
+}
+
+func toUint64(src any) (uint64, error) {
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.String:
+		if n, err := strconv.ParseUint(strings.TrimSpace(rv.String()), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	n, err := toInt64(src)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%d is negative", n)
+	}
+	return uint64(n), nil
+}
+
+func toFloat64(src any) (float64, error) {
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse %q as number", rv.String())
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to number", src)
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex that reports nil embedded pointers instead of
+// panicking.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// allocFieldByIndex returns the field at index, allocating nil embedded pointers on the way.
+func allocFieldByIndex(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
+
+// implementer returns rv as iface when its type implements it, or its address when only the
+// pointer type does and rv is addressable, as encoding/json does for pointer receivers.
+func implementer(rv reflect.Value, iface reflect.Type) (any, bool) {
+	if rv.Type().Implements(iface) {
+		return rv.Interface(), true
+	}
+	if rv.CanAddr() && reflect.PointerTo(rv.Type()).Implements(iface) {
+		return rv.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// isEmptyValue mirrors the omitempty rules of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+func mapKeyString(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(textMarshalerType) {
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if text, ok := scalarText(key.Interface()); ok {
+		return text, nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", key.Type())
+}
+
+func fieldError(path string, err error) error {
+	if path == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
@@ -0,0 +1,245 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// code marshals as upper-case text through pointer receivers only.
+type code string
+
+func (c *code) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(string(*c))), nil
+}
+
+func (c *code) UnmarshalText(text []byte) error {
+	*c = code(strings.ToLower(string(text)))
+	return nil
+}
+
+// point marshals as a JSON array through a value receiver.
+type point struct{ X, Y int }
+
+func (p point) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("[%d,%d]", p.X, p.Y)), nil
+}
+
+func (p *point) UnmarshalJSON(data []byte) error {
+	var xy [2]int
+	if err := json.Unmarshal(data, &xy); err != nil {
+		return err
+	}
+	p.X, p.Y = xy[0], xy[1]
+	return nil
+}
+
+type tagged struct {
+	ID     string `json:"id" db:"user_id"`
+	Name   string `json:"name,omitempty"`
+	Secret string `json:"-"`
+	Count  int    `json:"count,string"`
+	Plain  int
+}
+
+type Audit struct {
+	CreatedBy string `json:"created_by"`
+	Note      string `json:"note"`
+}
+
+type embedded struct {
+	Audit
+	*Owner
+	Note string `json:"note"`
+}
+
+type Owner struct {
+	OwnerID string `json:"owner_id"`
+}
+
+type timed struct {
+	At time.Time `json:"at"`
+}
+
+type marshalers struct {
+	Code    code   `json:"code"`
+	CodePtr *code  `json:"code_ptr"`
+	Point   point  `json:"point"`
+	Codes   []code `json:"codes"`
+}
+
+func TestStructToMap(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	ptr := code("b")
+
+	tests := []struct {
+		name    string
+		in      any
+		options []MapOption
+		want    map[string]any
+	}{
+		{
+			name: "json tags",
+			in:   tagged{ID: "u1", Secret: "s", Count: 3, Plain: 1},
+			want: map[string]any{"id": "u1", "count": "3", "Plain": 1},
+		},
+		{
+			name:    "other tag name",
+			in:      tagged{ID: "u1", Name: "n"},
+			options: []MapOption{WithTagName("db")},
+			want:    map[string]any{"user_id": "u1", "Name": "n", "Secret": "", "Count": 0, "Plain": 0},
+		},
+		{
+			name: "embedded structs",
+			in:   embedded{Audit: Audit{CreatedBy: "admin", Note: "shadowed"}, Note: "outer"},
+			want: map[string]any{"created_by": "admin", "note": "outer"},
+		},
+		{
+			name: "embedded pointer",
+			in:   embedded{Owner: &Owner{OwnerID: "o1"}},
+			want: map[string]any{"created_by": "", "note": "", "owner_id": "o1"},
+		},
+		{
+			name: "time",
+			in:   timed{At: at},
+			want: map[string]any{"at": "2024-03-01T12:30:00Z"},
+		},
+		{
+			name:    "time layout",
+			in:      timed{At: at},
+			options: []MapOption{WithTimeLayout(time.DateOnly)},
+			want:    map[string]any{"at": "2024-03-01"},
+		},
+		{
+			name:    "unix time",
+			in:      timed{At: at},
+			options: []MapOption{WithUnixTime()},
+			want:    map[string]any{"at": at.Unix()},
+		},
+		{
+			name: "marshalers",
+			in:   &marshalers{Code: "a", CodePtr: &ptr, Point: point{1, 2}, Codes: []code{"c"}},
+			want: map[string]any{"code": "A", "code_ptr": "B", "point": []any{float64(1), float64(2)}, "codes": []any{"C"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StructToMap(tt.in, tt.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("StructToMap = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapToStruct(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		decode  func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "json tags",
+			decode: func() (any, error) {
+				return MapToStruct[tagged](map[string]any{"id": "u1", "NAME": "n", "Secret": "s", "count": "3", "plain": 1.0})
+			},
+			want: tagged{ID: "u1", Name: "n", Count: 3, Plain: 1},
+		},
+		{
+			name: "other tag name",
+			decode: func() (any, error) {
+				return MapToStruct[tagged](map[string]any{"user_id": "u1"}, WithTagName("db"))
+			},
+			want: tagged{ID: "u1"},
+		},
+		{
+			name: "embedded structs",
+			decode: func() (any, error) {
+				return MapToStruct[embedded](map[string]any{"created_by": "admin", "note": "outer", "owner_id": "o1"})
+			},
+			want: embedded{Audit: Audit{CreatedBy: "admin"}, Owner: &Owner{OwnerID: "o1"}, Note: "outer"},
+		},
+		{
+			name: "time",
+			decode: func() (any, error) {
+				return MapToStruct[timed](map[string]any{"at": "2024-03-01T12:30:00Z"})
+			},
+			want: timed{At: at},
+		},
+		{
+			name: "time layout",
+			decode: func() (any, error) {
+				return MapToStruct[timed](map[string]any{"at": "01/03/2024 12:30"}, WithTimeLayout("02/01/2006 15:04"))
+			},
+			want: timed{At: at},
+		},
+		{
+			name: "unix time",
+			decode: func() (any, error) {
+				out, err := MapToStruct[timed](map[string]any{"at": float64(at.Unix())})
+				out.At = out.At.UTC()
+				return out, err
+			},
+			want: timed{At: at},
+		},
+		{
+			name: "marshalers",
+			decode: func() (any, error) {
+				out, err := MapToStruct[marshalers](map[string]any{"code": "A", "code_ptr": "B", "point": []any{1.0, 2.0}, "codes": []any{"C"}})
+				if err != nil || out.CodePtr == nil || *out.CodePtr != "b" {
+					return out, fmt.Errorf("code_ptr not decoded: %v", err)
+				}
+				out.CodePtr = nil
+				return out, nil
+			},
+			want: marshalers{Code: "a", Point: point{1, 2}, Codes: []code{"c"}},
+		},
+		{
+			name: "unknown keys",
+			decode: func() (any, error) {
+				return MapToStruct[tagged](map[string]any{"id": "u1", "extra": true})
+			},
+			want: tagged{ID: "u1"},
+		},
+		{
+			name: "strict",
+			decode: func() (any, error) {
+				return MapToStruct[tagged](map[string]any{"id": "u1", "extra": true}, WithStrict())
+			},
+			wantErr: true,
+		},
+		{
+			name: "conversion error",
+			decode: func() (any, error) {
+				return MapToStruct[tagged](map[string]any{"count": "three"})
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("MapToStruct = %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("MapToStruct = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
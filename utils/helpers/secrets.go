@@ -0,0 +1,138 @@
+package helpers
+
+import (
+	"regexp"
+)
+
+// Secret kinds detected by the default SecretScanner patterns.
+const (
+	SecretAWSAccessKey = "aws_access_key"
+	SecretAWSSecretKey = "aws_secret_key"
+	SecretPrivateKey   = "private_key"
+	SecretBearerToken  = "bearer_token"
+	SecretJWT          = "jwt"
+	SecretPaseto       = "paseto"
+	SecretGitHubToken  = "github_token"
+	SecretSlackToken   = "slack_token"
+)
+
+// secretPattern matches one kind of secret. When keep is set, that leading submatch is kept
+// and only the rest of the match is masked, e.g. the "Bearer " prefix.
+type secretPattern struct {
+	kind string
+	re   *regexp.Regexp
+	keep int
+}
+
+var defaultSecretPatterns = []secretPattern{
+	{kind: SecretPrivateKey, re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z0-9 ]*PRIVATE KEY-----|$)`)},
+	{kind: SecretAWSAccessKey, re: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA)[0-9A-Z]{16}\b`)},
+	{kind: SecretAWSSecretKey, re: regexp.MustCompile(`(?i)(aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`), keep: 1},
+	{kind: SecretBearerToken, re: regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]{16,}=*`), keep: 1},
+	{kind: SecretJWT, re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`)},
+	{kind: SecretPaseto, re: regexp.MustCompile(`\bv[1-4]\.(?:local|public)\.[A-Za-z0-9_-]{20,}(?:\.[A-Za-z0-9_-]+)?`)},
+	{kind: SecretGitHubToken, re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{kind: SecretSlackToken, re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+}
+
+// SecretScanner finds well known secret formats in text and masks them. It is the last line
+// of defense for log entries and responses that leak a credential despite field sanitization.
+type SecretScanner struct {
+	patterns  []secretPattern
+	maskValue string
+	onDetect  func(source, kind string)
+}
+
+// SecretScanOption configures a SecretScanner.
+type SecretScanOption func(*SecretScanner)
+
+// WithSecretPattern adds a pattern for a custom secret kind.
+func WithSecretPattern(kind string, re *regexp.Regexp) SecretScanOption {
+	return func(s *SecretScanner) {
+		s.patterns = append(s.patterns, secretPattern{kind: kind, re: re})
+	}
+}
+
+// WithSecretMask sets the replacement of detected secrets. Defaults to "[REDACTED:<kind>]".
+func WithSecretMask(mask string) SecretScanOption {
+	return func(s *SecretScanner) {
+		s.maskValue = mask
+	}
+}
+
+// WithSecretDetectedHook sets a callback invoked once per detected secret with where it was
+// found, e.g. "log" or "response", and its kind. Use it to count a metric or raise an alert.
+// The hook must not log through a scanning logger.
+func WithSecretDetectedHook(hook func(source, kind string)) SecretScanOption {
+	return func(s *SecretScanner) {
+		s.onDetect = hook
+	}
+}
+
+// NewSecretScanner creates a SecretScanner with the default patterns for AWS keys, PEM
+// private keys, bearer tokens, JWT, PASETO, GitHub and Slack tokens.
+func NewSecretScanner(opts ...SecretScanOption) *SecretScanner {
+	s := &SecretScanner{patterns: append([]secretPattern(nil), defaultSecretPatterns...)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Mask returns text with every secret replaced and reports the detections to the hook with
+// source. The returned bool is false, and text is returned unchanged, when nothing matched.
+func (s *SecretScanner) Mask(source, text string) (string, bool) {
+	if !s.Contains(text) {
+		return text, false
+	}
+	masked, found := s.MaskBytes(source, []byte(text))
+	if !found {
+		return text, false
+	}
+	return string(masked), true
+}
+
+// MaskBytes is Mask for byte slices. data is not modified.
+func (s *SecretScanner) MaskBytes(source string, data []byte) ([]byte, bool) {
+	found := false
+	for _, pattern := range s.patterns {
+		matches := pattern.re.FindAllSubmatchIndex(data, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		found = true
+		out := make([]byte, 0, len(data))
+		last := 0
+		for _, match := range matches {
+			start := match[0]
+			if pattern.keep > 0 && match[2*pattern.keep+1] > 0 {
+				start = match[2*pattern.keep+1]
+			}
+			out = append(out, data[last:start]...)
+			out = append(out, s.mask(pattern.kind)...)
+			last = match[1]
+			if s.onDetect != nil {
+				s.onDetect(source, pattern.kind)
+			}
+		}
+		data = append(out, data[last:]...)
+	}
+	return data, found
+}
+
+// Contains reports whether text holds a secret, without masking or calling the hook.
+func (s *SecretScanner) Contains(text string) bool {
+	for _, pattern := range s.patterns {
+		if pattern.re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SecretScanner) mask(kind string) string {
+	if s.maskValue != "" {
+		return s.maskValue
+	}
+	return "[REDACTED:" + kind + "]"
+}
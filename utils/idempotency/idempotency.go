@@ -3,6 +3,8 @@ package idempotency
 import (
 	"sync"
 	"time"
+
+	"github.com/abhissng/neuron/utils/clock"
 )
 
 const (
@@ -17,18 +19,40 @@ type IdempotencyManager[K comparable] struct {
 	trackedEvents   map[K]time.Time // Map to store the last processed time for each event
 	mu              sync.Mutex      // Mutex for thread-safe access to the trackedEvents map
 	cleanupInterval time.Duration   // Interval for cleaning up expired entries
-	cleanupTicker   *time.Ticker    // Ticker to trigger periodic cleanup
+	cleanupTicker   clock.Ticker    // Ticker to trigger periodic cleanup
 	done            chan struct{}   // Channel to signal the manager to stop the cleanup routine
+	clock           clock.Clock     // Clock processing times and expiry are measured with
+}
+
+// Option configures an IdempotencyManager.
+type Option func(*options)
+
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock sets the clock processing times and expiry are measured with. Defaults to
+// clock.System.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = clock.OrSystem(c)
+	}
 }
 
 // NewIdempotencyManager creates a new instance of IdempotencyManager with the specified cleanup interval.
 // It starts a background goroutine to perform periodic cleanup.
-func NewIdempotencyManager[K comparable](cleanupInterval time.Duration) *IdempotencyManager[K] {
+func NewIdempotencyManager[K comparable](cleanupInterval time.Duration, opts ...Option) *IdempotencyManager[K] {
+	cfg := &options{clock: clock.System}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	manager := &IdempotencyManager[K]{
 		trackedEvents:   make(map[K]time.Time),
 		cleanupInterval: cleanupInterval,
 		done:            make(chan struct{}),
+		clock:           cfg.clock,
 	}
+	manager.cleanupTicker = manager.clock.NewTicker(manager.cleanupInterval)
 	go manager.startCleanup()
 	return manager
 }
@@ -36,14 +60,13 @@ func NewIdempotencyManager[K comparable](cleanupInterval time.Duration) *Idempot
 // startCleanup starts the background goroutine for periodic cleanup.
 // It runs until the 'done' channel receives a signal.
 func (m *IdempotencyManager[K]) startCleanup() {
-	m.cleanupTicker = time.NewTicker(m.cleanupInterval)
 	defer m.cleanupTicker.Stop()
 
 	for {
 		select {
 		case <-m.done:
 			return
-		case <-m.cleanupTicker.C:
+		case <-m.cleanupTicker.C():
 			m.cleanupProcessedMessages()
 		}
 	}
@@ -55,7 +78,7 @@ func (m *IdempotencyManager[K]) cleanupProcessedMessages() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	now := time.Now()
+	now := m.clock.Now()
 	for trackingID, timestamp := range m.trackedEvents {
 		if now.Sub(timestamp) > m.cleanupInterval {
 			delete(m.trackedEvents, trackingID)
@@ -68,7 +91,14 @@ func (m *IdempotencyManager[K]) cleanupProcessedMessages() {
 func (m *IdempotencyManager[K]) MarkAsProcessed(trackingID K) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.trackedEvents[trackingID] = time.Now()
+	m.trackedEvents[trackingID] = m.clock.Now()
+}
+
+// UnmarkProcessed forgets trackingID, so that the event is processed again when redelivered.
+func (m *IdempotencyManager[K]) UnmarkProcessed(trackingID K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.trackedEvents, trackingID)
 }
 
 // IsProcessed checks if an event with the given trackingID has already been processed.
@@ -0,0 +1,485 @@
+package patch
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhissng/neuron/blame"
+	"github.com/abhissng/neuron/utils/codec"
+	"github.com/abhissng/neuron/utils/constant"
+	"github.com/abhissng/neuron/utils/helpers"
+)
+
+// UpdateMaskQueryParam is the query parameter FromRequest reads a field mask from when the
+// X-Field-Mask header is not set
+const UpdateMaskQueryParam = "update_mask"
+
+// Change is a single field update in a Changeset
+type Change struct {
+	// Path is the dotted json path of the field, e.g. "address.city"
+	Path string
+	// Column is the database column of the top level field, from its db tag
+	Column string
+	// Value is the new value converted to the field's type, with pointers dereferenced and
+	// driver.Valuer types such as types.Optional resolved. It is nil when Null is set
+	Value any
+	// Null reports that the field was cleared, either with null or by a field mask
+	Null bool
+
+	jsonPath []string
+	document []string
+	index    [][]int
+	value    reflect.Value
+	raw      json.RawMessage
+}
+
+// Changeset is a typed set of field updates for T parsed from a PATCH request. Nested struct
+// fields are tracked as separate leaf changes so sibling fields are left untouched
+type Changeset[T any] struct {
+	changes []Change
+	byPath  map[string]int
+}
+
+// Option configures Parse
+type Option func(*config)
+
+type config struct {
+	mask         []string
+	allowUnknown bool
+}
+
+// WithMask applies only the given field paths, following field mask semantics: masked fields
+// missing from the body are cleared and body fields outside the mask are ignored. An empty
+// mask or "*" applies the whole body as a merge patch
+func WithMask(paths ...string) Option {
+	return func(c *config) {
+		c.mask = append(c.mask, paths...)
+	}
+}
+
+// WithAllowUnknown ignores body fields T does not have instead of rejecting them
+func WithAllowUnknown() Option {
+	return func(c *config) {
+		c.allowUnknown = true
+	}
+}
+
+// ParseMask splits a comma separated field mask such as "name,address.city"
+func ParseMask(mask string) []string {
+	var paths []string
+	for path := range strings.SplitSeq(mask, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// FromRequest parses the request body into a Changeset for T. The field mask is read from the
+// X-Field-Mask header or the update_mask query parameter; without one the body is applied as
+// a JSON merge patch
+func FromRequest[T any](r *http.Request, options ...Option) (*Changeset[T], blame.Blame) {
+	if r.Body == nil {
+		return nil, blame.RequestBodyDataExtractionFailed(errors.New("request body is empty"))
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, blame.RequestBodyDataExtractionFailed(err)
+	}
+	mask := r.Header.Get(constant.XFieldMask)
+	if mask == "" {
+		mask = r.URL.Query().Get(UpdateMaskQueryParam)
+	}
+	if paths := ParseMask(mask); len(paths) > 0 {
+		options = append(options, WithMask(paths...))
+	}
+	return Parse[T](body, options...)
+}
+
+// Parse builds a Changeset for T from a JSON merge patch (RFC 7396): keys present in body are
+// updated, null clears a field and absent keys are left alone. Fields are matched by their json
+// tags and values converted to the field types.
+//
+// Unknown fields return blame.MalformedParameterError, values that do not fit the field
+// blame.TypeConversionError and fields tagged patch:"-" or patch:"readonly"
+// blame.FieldUpdateForbidden.
+func Parse[T any](body []byte, options ...Option) (*Changeset[T], blame.Blame) {
+	cfg := &config{}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	if slices.Contains(cfg.mask, "*") {
+		cfg.mask = nil
+	}
+
+	document := map[string]any{}
+	if len(bytes.TrimSpace(body)) > 0 || len(cfg.mask) == 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&document); err != nil {
+			return nil, blame.UnMarshalError(codec.JSON, err)
+		}
+	}
+
+	target := reflect.TypeFor[T]()
+	for target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		return nil, blame.TypeConversionError("body", "object", target.String(), errors.New("patch target must be a struct"))
+	}
+
+	changeset := &Changeset[T]{byPath: map[string]int{}}
+	p := &parser{config: cfg, changeset: changeset}
+	if len(cfg.mask) == 0 {
+		if err := p.walk(target, document, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, path := range cfg.mask {
+			if err := p.masked(target, document, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(p.forbidden) > 0 {
+		return nil, blame.FieldUpdateForbiddenError(strings.Join(p.forbidden, ", "))
+	}
+	return changeset, nil
+}
+
+// Len returns the number of changes.
+func (c *Changeset[T]) Len() int {
+	return len(c.changes)
+}
+
+// IsEmpty reports whether the changeset updates nothing.
+func (c *Changeset[T]) IsEmpty() bool {
+	return len(c.changes) == 0
+}
+
+// Changes returns the changes in the order they were parsed.
+func (c *Changeset[T]) Changes() []Change {
+	return slices.Clone(c.changes)
+}
+
+// Paths returns the changed field paths.
+func (c *Changeset[T]) Paths() []string {
+	paths := make([]string, len(c.changes))
+	for i, change := range c.changes {
+		paths[i] = change.Path
+	}
+	return paths
+}
+
+// Has reports whether path, or a field below it, is changed.
+func (c *Changeset[T]) Has(path string) bool {
+	for _, change := range c.changes {
+		if covers(path, change.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the change for path.
+func (c *Changeset[T]) Get(path string) (Change, bool) {
+	i, ok := c.byPath[path]
+	if !ok {
+		return Change{}, false
+	}
+	return c.changes[i], true
+}
+
+// Without returns a copy of the changeset without path and the fields below it, e.g. to
+// drop fields the handler sets itself.
+func (c *Changeset[T]) Without(paths ...string) *Changeset[T] {
+	out := &Changeset[T]{byPath: map[string]int{}}
+	for _, change := range c.changes {
+		if !slices.ContainsFunc(paths, func(path string) bool { return covers(path, change.Path) }) {
+			out.add(change)
+		}
+	}
+	return out
+}
+
+// Apply copies the changes onto dst, allocating nil nested struct pointers on the way.
+func (c *Changeset[T]) Apply(dst *T) {
+	root := reflect.ValueOf(dst).Elem()
+	for root.Kind() == reflect.Pointer {
+		if root.IsNil() {
+			root.Set(reflect.New(root.Type().Elem()))
+		}
+		root = root.Elem()
+	}
+	for _, change := range c.changes {
+		field := root
+		for _, index := range change.index {
+			field = allocField(field, index)
+		}
+		field.Set(change.value)
+	}
+}
+
+func (c *Changeset[T]) add(change Change) {
+	if i, ok := c.byPath[change.Path]; ok {
+		c.changes[i] = change
+		return
+	}
+	c.byPath[change.Path] = len(c.changes)
+	c.changes = append(c.changes, change)
+}
+
+// parser walks a decoded body against the fields of T
+type parser struct {
+	*config
+	changeset interface{ add(Change) }
+	forbidden []string
+}
+
+// walk records a change for every key of object, descending into nested structs.
+func (p *parser) walk(t reflect.Type, object map[string]any, parent *Change) blame.Blame {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		field, ok := lookupField(t, key)
+		if !ok {
+			if p.allowUnknown {
+				continue
+			}
+			return blame.MalformedParameterError(joinPath(parent, key))
+		}
+		if err := p.field(field, object[key], parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field records the change for a single field.
+func (p *parser) field(field patchField, value any, parent *Change) blame.Blame {
+	change := child(parent, field)
+	if field.readOnly {
+		p.forbidden = append(p.forbidden, change.Path)
+		return nil
+	}
+	if object, ok := value.(map[string]any); ok && field.nested != nil {
+		return p.walk(field.nested, object, &change)
+	}
+	return p.set(&change, field.typ, value)
+}
+
+// masked records the change for one field mask path.
+func (p *parser) masked(t reflect.Type, document map[string]any, path string) blame.Blame {
+	var (
+		parent *Change
+		object = document
+		field  patchField
+	)
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		if t == nil {
+			return blame.MalformedParameterError(path)
+		}
+		var ok bool
+		field, ok = lookupField(t, segment)
+		if !ok {
+			return blame.MalformedParameterError(path)
+		}
+		if i == len(segments)-1 {
+			break
+		}
+		change := child(parent, field)
+		if field.readOnly {
+			p.forbidden = append(p.forbidden, change.Path)
+			return nil
+		}
+		parent, t = &change, field.nested
+		next, _ := object[segment].(map[string]any)
+		if next == nil {
+			next, _ = object[field.name].(map[string]any)
+		}
+		object = next
+	}
+
+	value, found := object[segments[len(segments)-1]]
+	if !found {
+		value, found = object[field.name]
+	}
+	if !found {
+		// Masked fields missing from the body are cleared.
+		value = nil
+	}
+	return p.field(field, value, parent)
+}
+
+// set converts value to t and records it.
+func (p *parser) set(change *Change, t reflect.Type, value any) blame.Blame {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return blame.MarshalError(codec.JSON, err)
+	}
+	converted := reflect.New(t)
+	if err := json.Unmarshal(raw, converted.Interface()); err != nil {
+		return blame.TypeConversionError(change.Path, truncate(string(raw), 64), t.String(), err)
+	}
+	change.raw = raw
+	change.value = converted.Elem()
+	change.Null = value == nil
+	if !change.Null {
+		change.Value = plainValue(change.value)
+	}
+	p.changeset.add(*change)
+	return nil
+}
+
+// child returns the change for field below parent.
+func child(parent *Change, field patchField) Change {
+	change := Change{Column: field.column}
+	if parent != nil {
+		change.Column = parent.Column
+		change.jsonPath = slices.Clone(parent.jsonPath)
+		change.document = slices.Clone(parent.document)
+		change.index = slices.Clone(parent.index)
+	}
+	change.jsonPath = append(change.jsonPath, field.name)
+	change.document = append(change.document, field.document)
+	change.index = append(change.index, field.index)
+	change.Path = strings.Join(change.jsonPath, ".")
+	return change
+}
+
+func joinPath(parent *Change, key string) string {
+	if parent == nil {
+		return key
+	}
+	return parent.Path + "." + key
+}
+
+// covers reports whether pattern is path or one of its parents.
+func covers(pattern, path string) bool {
+	return pattern == path || strings.HasPrefix(path, pattern+".")
+}
+
+// plainValue dereferences pointers and resolves driver.Valuer types, so values can be passed
+// to SQL drivers and BSON encoders alike.
+func plainValue(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() != timeType {
+		if valuer, ok := v.Interface().(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err == nil {
+				return value
+			}
+		}
+	}
+	return v.Interface()
+}
+
+// allocField returns the field at index, allocating nil pointers on the way.
+func allocField(v reflect.Value, index []int) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[:limit] + "..."
+}
+
+var (
+	timeType        = reflect.TypeFor[time.Time]()
+	unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	valuerType      = reflect.TypeFor[driver.Valuer]()
+)
+
+// patchField describes a patchable struct field
+type patchField struct {
+	name     string
+	column   string
+	document string
+	index    []int
+	typ      reflect.Type
+	nested   reflect.Type
+	readOnly bool
+}
+
+// lookupField finds the patch field of struct type t for key, using the field mapping of
+// helpers.
+func lookupField(t reflect.Type, key string) (patchField, bool) {
+	mapped, ok := helpers.LookupStructField(t, "json", key)
+	if !ok {
+		return patchField{}, false
+	}
+	field := mapped.Field
+	patchTag := field.Tag.Get("patch")
+	return patchField{
+		name:     mapped.Key,
+		column:   tagName(field, "db", mapped.Key),
+		document: tagName(field, "bson", mapped.Key),
+		index:    field.Index,
+		typ:      field.Type,
+		nested:   nestedStruct(field.Type),
+		readOnly: patchTag == "-" || patchTag == "readonly",
+	}, true
+}
+
+// tagName returns the name in tag, or fallback when it is unset.
+func tagName(field reflect.StructField, tag, fallback string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "" || name == "-" {
+		return fallback
+	}
+	return name
+}
+
+// nestedStruct returns the struct type patched field by field, or nil for leaf values such as
+// times, nullable wrappers and types with custom JSON decoding.
+func nestedStruct(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+	pointer := reflect.PointerTo(t)
+	if pointer.Implements(unmarshalerType) || t.Implements(valuerType) || pointer.Implements(valuerType) {
+		return nil
+	}
+	return t
+}
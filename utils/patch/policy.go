@@ -0,0 +1,61 @@
+package patch
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abhissng/neuron/blame"
+)
+
+// AllFields grants every field in a Policy
+const AllFields = "*"
+
+// Policy lists the field paths each role may update. Granting a path also grants the fields
+// below it, so "address" covers "address.city"
+type Policy struct {
+	mu    sync.RWMutex
+	roles map[string][]string
+}
+
+// NewPolicy creates an empty Policy that denies every field.
+func NewPolicy() *Policy {
+	return &Policy{roles: map[string][]string{}}
+}
+
+// Allow grants role the given field paths, or every field with AllFields.
+func (p *Policy) Allow(role string, paths ...string) *Policy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roles[role] = append(p.roles[role], paths...)
+	return p
+}
+
+// Allowed reports whether any of roles may update path.
+func (p *Policy) Allowed(path string, roles ...string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, role := range roles {
+		for _, pattern := range p.roles[role] {
+			if pattern == AllFields || covers(pattern, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize checks every change against policy for the caller's roles and returns
+// blame.FieldUpdateForbidden listing the fields none of them may update.
+func (c *Changeset[T]) Authorize(policy *Policy, roles ...string) blame.Blame {
+	var forbidden []string
+	for _, change := range c.changes {
+		if !policy.Allowed(change.Path, roles...) && !slices.Contains(forbidden, change.Path) {
+			forbidden = append(forbidden, change.Path)
+		}
+	}
+	if len(forbidden) > 0 {
+		return blame.FieldUpdateForbiddenError(strings.Join(forbidden, ", "))
+	}
+	return nil
+}
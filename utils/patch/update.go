@@ -0,0 +1,128 @@
+package patch
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Dialect selects the SQL placeholder and JSON function syntax for SetClause
+type Dialect int
+
+// Supported SQL dialects
+const (
+	Postgres Dialect = iota
+	MySQL
+)
+
+// SetClause renders the changes as the body of an UPDATE ... SET clause, e.g.
+// "name = $1, address = jsonb_set(...)", with its arguments. Top level fields are assigned to
+// their db columns; changes below a top level field are merged into its JSON column with
+// jsonb_set or JSON_SET, which do not create missing intermediate objects. Postgres
+// placeholders start at startIndex and the next free index is returned so WHERE arguments
+// can follow.
+func (c *Changeset[T]) SetClause(dialect Dialect, startIndex int) (string, []any, int) {
+	type assignment struct {
+		column string
+		top    *Change
+		nested []Change
+	}
+	var (
+		assignments []*assignment
+		byColumn    = map[string]*assignment{}
+		args        []any
+		next        = startIndex
+	)
+	placeholder := func(arg any, cast string) string {
+		args = append(args, arg)
+		if dialect == MySQL {
+			return "?"
+		}
+		next++
+		return "$" + strconv.Itoa(next-1) + cast
+	}
+
+	for _, change := range c.changes {
+		a, ok := byColumn[change.Column]
+		if !ok {
+			a = &assignment{column: change.Column}
+			byColumn[change.Column] = a
+			assignments = append(assignments, a)
+		}
+		if len(change.jsonPath) == 1 {
+			a.top = &change
+		} else {
+			a.nested = append(a.nested, change)
+		}
+	}
+
+	clauses := make([]string, 0, len(assignments))
+	for _, a := range assignments {
+		expr := a.column
+		if a.top != nil {
+			expr = placeholder(a.top.Value, "")
+		}
+		for i, change := range a.nested {
+			sub := change.jsonPath[1:]
+			if dialect == MySQL {
+				if i == 0 {
+					expr = "COALESCE(" + expr + ", JSON_OBJECT())"
+				}
+				expr = "JSON_SET(" + expr + ", " + placeholder(mysqlJSONPath(sub), "") + ", CAST(" + placeholder(string(change.raw), "") + " AS JSON))"
+				continue
+			}
+			if i == 0 {
+				expr = "COALESCE(" + expr + ", '{}'::jsonb)"
+			}
+			expr = "jsonb_set(" + expr + ", " + placeholder(sub, "::text[]") + ", " + placeholder(string(change.raw), "::jsonb") + ", true)"
+		}
+		clauses = append(clauses, a.column+" = "+expr)
+	}
+	return strings.Join(clauses, ", "), args, next
+}
+
+// Columns returns the database columns touched by the changes.
+func (c *Changeset[T]) Columns() []string {
+	var columns []string
+	for _, change := range c.changes {
+		if !slices.Contains(columns, change.Column) {
+			columns = append(columns, change.Column)
+		}
+	}
+	return columns
+}
+
+// MongoUpdate renders the changes as a MongoDB update document: updated fields go to $set and
+// cleared fields to $unset, keyed by their dotted bson paths.
+func (c *Changeset[T]) MongoUpdate() bson.M {
+	set, unset := bson.M{}, bson.M{}
+	for _, change := range c.changes {
+		key := strings.Join(change.document, ".")
+		if change.Null {
+			unset[key] = ""
+			continue
+		}
+		set[key] = change.Value
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// mysqlJSONPath renders keys as a MySQL JSON path such as $."a"."b".
+func mysqlJSONPath(keys []string) string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, key := range keys {
+		b.WriteString(".")
+		b.WriteString(strconv.Quote(key))
+	}
+	return b.String()
+}
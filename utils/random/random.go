@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"math/big"
 	"strings"
 
@@ -12,13 +13,22 @@ import (
 
 // GenerateUUID generates a UUID
 func GenerateUUIDString() string {
-	// Implement your UUID generation logic here
-	return uuid.New().String()
+	return GenerateUUID().String()
 }
 
+// GenerateUUID generates a version 4 UUID from Reader, for reference IDs only.
 func GenerateUUID() uuid.UUID {
-	// Implement your UUID generation logic here
-	return uuid.New()
+	id, err := uuid.NewRandomFromReader(Reader())
+	if err != nil {
+		return uuid.New()
+	}
+	return id
+}
+
+// GenerateSecureUUIDString generates a version 4 UUID from crypto/rand, whatever the source,
+// for IDs that grant access such as session IDs.
+func GenerateSecureUUIDString() string {
+	return uuid.New().String()
 }
 
 // JoinComponentsToID joins multiple strings into a single ID
@@ -69,8 +79,8 @@ func GenerateRandomAlphanumeric(n int) (string, error) {
 
 // GenerateTokenID generates a random token ID
 func GenerateTokenID() (string, error) {
-	bytes := make([]byte, 16)  // 128 bits (UUID size)
-	_, err := rand.Read(bytes) // Use crypto/rand
+	bytes := make([]byte, 16) // 128 bits (UUID size)
+	_, err := io.ReadFull(rand.Reader, bytes)
 	if err != nil {
 		return "", fmt.Errorf("error generating token ID: %w", err) // Wrap error
 	}
@@ -0,0 +1,58 @@
+package random
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	mathrand "math/rand/v2"
+	"sync"
+)
+
+var (
+	sourceMu sync.RWMutex
+	source   io.Reader = rand.Reader
+)
+
+// Reader returns the source reference IDs draw from: crypto/rand unless SetSource installed
+// another one. It must not feed nonces, keys or secrets.
+func Reader() io.Reader {
+	sourceMu.RLock()
+	defer sourceMu.RUnlock()
+	return source
+}
+
+// SetSource makes GenerateUUID, and the reference IDs built on it, draw from r and returns a
+// function restoring the previous source. It is intended for golden file tests and
+// simulations, with a NewSeededSource, and must not be used in production. Nonces, keys,
+// session IDs, token IDs and OTPs always use crypto/rand.
+func SetSource(r io.Reader) (restore func()) {
+	sourceMu.Lock()
+	defer sourceMu.Unlock()
+	previous := source
+	source = &lockedReader{r: r}
+	return func() {
+		sourceMu.Lock()
+		defer sourceMu.Unlock()
+		source = previous
+	}
+}
+
+// NewSeededSource returns a deterministic source producing the same bytes for the same seed.
+// It is not cryptographically secure.
+func NewSeededSource(seed uint64) io.Reader {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	return mathrand.NewChaCha8(key)
+}
+
+// lockedReader serialises reads of a source that is not safe for concurrent use.
+type lockedReader struct {
+	mu sync.Mutex
+	r  io.Reader
+}
+
+func (l *lockedReader) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Read(p)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/timeutil"
 )
 
@@ -52,6 +53,14 @@ func WithDebugEnabled(enabled bool) Option {
 	}
 }
 
+// WithClock sets the clock runs are timed with. Defaults to clock.System; pass it before
+// WithStartAtTime.
+func WithClock(c clock.Clock) Option {
+	return func(s *Schedule) {
+		s.clock = clock.OrSystem(c)
+	}
+}
+
 // WithStartAt sets an exact start time (time.Time should include location).
 // If time.Time has no location, it will be used as-is (UTC).
 func WithStartAt(t time.Time) Option {
@@ -70,7 +79,7 @@ func WithStartAtTime(hour, minute int) Option {
 			// fallback to local if timezone not found
 			loc = time.UTC
 		}
-		now := time.Now().In(loc)
+		now := s.clock.Now().In(loc)
 		start := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, loc)
 		s.startAt = &start
 	}
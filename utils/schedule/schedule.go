@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/abhissng/neuron/adapters/log"
+	"github.com/abhissng/neuron/utils/clock"
 	"github.com/abhissng/neuron/utils/helpers"
 )
 
@@ -33,6 +34,7 @@ type Schedule struct {
 	StopChannel    chan struct{}
 	isDebugEnabled bool
 	stopOnce       sync.Once
+	clock          clock.Clock
 }
 
 // NewSchedule creates a new Schedule with functional options.
@@ -44,6 +46,7 @@ func NewSchedule(processor ScheduleProcessor, opts ...Option) *Schedule {
 		name:           DefaultName,
 		timeZone:       DefaultTimeZone,
 		isDebugEnabled: false,
+		clock:          clock.System,
 	}
 
 	// Apply functional options (these can use defaults above, like timeZone).
@@ -81,7 +84,7 @@ func (s *Schedule) Run() {
 		start := s.startAt.In(location)
 
 		// If start time already passed for today, roll to next day
-		now := time.Now().In(location)
+		now := s.clock.Now().In(location)
 		if now.After(start) || now.Equal(start) {
 			start = start.Add(24 * time.Hour)
 		}
@@ -92,13 +95,13 @@ func (s *Schedule) Run() {
 		// Wait until startAt, then run first job and continue with interval loop.
 		go func() {
 			// Compute wait duration
-			now := time.Now().In(location)
+			now := s.clock.Now().In(location)
 			wait := s.startAt.Sub(now)
-			timer := time.NewTimer(wait)
+			timer := s.clock.NewTimer(wait)
 			defer timer.Stop()
 
 			select {
-			case <-timer.C:
+			case <-timer.C():
 				// First run
 				s.log.Info("Executing first scheduled run", log.Any(s.name, s.startAt.Format(DefaultTimeFormat)))
 				s.processor.Start()
@@ -128,25 +131,25 @@ func (s *Schedule) startIntervalLoop() {
 	// Setup end time (counts from the moment this function is called)
 	var endTime time.Time
 	if s.duration > 0 {
-		endTime = time.Now().In(location).Add(s.duration)
+		endTime = s.clock.Now().In(location).Add(s.duration)
 	}
 
-	ticker := time.NewTicker(s.interval)
+	ticker := s.clock.NewTicker(s.interval)
 
 	go func() {
 		defer ticker.Stop()
 		for {
 			select {
-			case <-ticker.C:
+			case <-ticker.C():
 				// Check duration expiry
-				if s.duration > 0 && time.Now().In(location).After(endTime) {
+				if s.duration > 0 && s.clock.Now().In(location).After(endTime) {
 					s.log.Info("Schedule duration completed, stopping schedule", log.Any(s.name, "duration_expired"))
 					// Use Stop to close StopChannel safely
 					s.Stop()
 					return
 				}
 
-				now := time.Now().In(location)
+				now := s.clock.Now().In(location)
 				next := now.Add(s.interval).Format(DefaultTimeFormat)
 				nextMessage := fmt.Sprintf("Next schedule will start in (%s) %s", s.timeZone, next)
 				s.log.Info(SchedulerStarted, log.Any(s.name, nextMessage))
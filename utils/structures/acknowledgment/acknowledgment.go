@@ -6,10 +6,10 @@ import (
 
 // APIResponse structure for final response to REST clients will change later on
 type APIResponse[T any] struct {
-	Success       bool                `json:"success"`
-	CorrelationID types.CorrelationID `json:"correlation_id"`
-	Result        T                   `json:"result"`
-	Error         *string             `json:"error"`
+	Success       bool                `json:"success" xml:"success"`
+	CorrelationID types.CorrelationID `json:"correlation_id" xml:"correlation_id"`
+	Result        T                   `json:"result" xml:"result"`
+	Error         *string             `json:"error" xml:"error,omitempty"`
 }
 
 func NewAPIResponse[T any](
@@ -0,0 +1,54 @@
+package claims
+
+// Actor is the party acting as the subject of an impersonation or delegation token, like the
+// "act" claim of RFC 8693. Act holds the actor it acts for in turn, when the token was
+// delegated again.
+type Actor struct {
+	Sub string `json:"sub"`
+	Act *Actor `json:"act,omitempty"`
+}
+
+// WithActor sets actor as the party acting as the subject. An actor already set becomes the
+// prior actor of the chain, so tokens delegated again keep who delegated them.
+func WithActor(actor string) StandardClaimsOption {
+	return func(c *StandardClaims) {
+		c.Act = &Actor{Sub: actor, Act: c.Act}
+	}
+}
+
+// WithActorChain sets the actor chain of a token being delegated again, its current actor first.
+func WithActorChain(act *Actor) StandardClaimsOption {
+	return func(c *StandardClaims) {
+		c.Act = act.clone()
+	}
+}
+
+// IsImpersonated reports whether the token was issued to an actor acting as its subject.
+func (c *StandardClaims) IsImpersonated() bool {
+	return c.Act != nil && c.Act.Sub != ""
+}
+
+// ActorSubject returns the party acting as the subject, or "" when the subject acts itself.
+func (c *StandardClaims) ActorSubject() string {
+	if c.Act == nil {
+		return ""
+	}
+	return c.Act.Sub
+}
+
+// ActorChain returns the actors of the token, the current actor first.
+func (c *StandardClaims) ActorChain() []string {
+	var chain []string
+	for act := c.Act; act != nil; act = act.Act {
+		chain = append(chain, act.Sub)
+	}
+	return chain
+}
+
+// clone returns a deep copy of the actor chain.
+func (a *Actor) clone() *Actor {
+	if a == nil {
+		return nil
+	}
+	return &Actor{Sub: a.Sub, Act: a.Act.clone()}
+}
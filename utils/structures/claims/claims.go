@@ -18,6 +18,7 @@ type StandardClaims struct {
 	Sub  string         `json:"sub,omitempty"`  // Subject (Optional)
 	Ip   string         `json:"ip,omitempty"`   // IP address (Optional)
 	Pid  string         `json:"pid"`            // Payload ID created at payload time
+	Act  *Actor         `json:"act,omitempty"`  // Actor acting as the subject (Optional)
 	Data map[string]any `json:"data,omitempty"` // Data (Optional)
 }
 
@@ -38,6 +39,15 @@ func WithNotBefore(nbf time.Time) StandardClaimsOption {
 	}
 }
 
+// WithIssuedAt sets the IssuedAt claim to iat and moves the expiration with it, for issuers
+// taking the time from a clock.Clock.
+func WithIssuedAt(iat time.Time) StandardClaimsOption {
+	return func(c *StandardClaims) {
+		c.Exp = iat.Add(c.Exp.Sub(c.Iat))
+		c.Iat = iat
+	}
+}
+
 // WithSubject sets the Subject claim.
 func WithSubject(sub string) StandardClaimsOption {
 	return func(c *StandardClaims) {
@@ -80,11 +90,12 @@ func NewStandardClaims(issuer string, expiry time.Duration, options ...StandardC
 	if helpers.IsEmpty(tokenID) {
 		tokenID, _ = random.GenerateRandomAlphanumeric(15)
 	}
+	now := time.Now()
 	// Comment: Creates a new StandardClaims struct with the provided issuer, expiry, and optional configurations.
 	claims := &StandardClaims{
 		Iss: issuer,
-		Exp: time.Now().Add(expiry),
-		Iat: time.Now(),
+		Exp: now.Add(expiry),
+		Iat: now,
 		Jti: tokenID,
 	}
 
@@ -0,0 +1,290 @@
+package claims
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// These are the well-known custom claims kept in Data.
+const (
+	OrgIDClaim  = "org_id"
+	RolesClaim  = "roles"
+	ScopesClaim = "scopes"
+)
+
+// AnyAudience is the audience of a schema applying to every token.
+const AnyAudience = ""
+
+// ClaimType is the type a custom claim must have.
+type ClaimType string
+
+// These are the supported claim types.
+const (
+	StringType  ClaimType = "string"
+	StringsType ClaimType = "strings"
+	NumberType  ClaimType = "number"
+	BoolType    ClaimType = "bool"
+)
+
+// ClaimRule describes a custom claim of a schema.
+type ClaimRule struct {
+	Name     string
+	Type     ClaimType
+	Required bool
+	// Allowed, when set, lists the values a string claim, or each value of a strings claim,
+	// may take.
+	Allowed []string
+}
+
+// Schema defines the custom claims of the tokens of an audience.
+type Schema struct {
+	audience string
+	rules    []ClaimRule
+}
+
+// SchemaOption is a functional option for configuring a Schema.
+type SchemaOption func(*Schema)
+
+// Require adds a required claim of type typ to the schema, optionally restricted to allowed.
+func Require(name string, typ ClaimType, allowed ...string) SchemaOption {
+	return func(s *Schema) {
+		s.rules = append(s.rules, ClaimRule{Name: name, Type: typ, Required: true, Allowed: allowed})
+	}
+}
+
+// Optional adds a claim of type typ the tokens may carry, optionally restricted to allowed.
+func Optional(name string, typ ClaimType, allowed ...string) SchemaOption {
+	return func(s *Schema) {
+		s.rules = append(s.rules, ClaimRule{Name: name, Type: typ, Allowed: allowed})
+	}
+}
+
+// NewSchema creates the schema of the tokens of audience, or of every token for AnyAudience.
+func NewSchema(audience string, options ...SchemaOption) *Schema {
+	s := &Schema{audience: audience}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Audience returns the audience the schema applies to.
+func (s *Schema) Audience() string {
+	return s.audience
+}
+
+// Rules returns the claims of the schema.
+func (s *Schema) Rules() []ClaimRule {
+	return slices.Clone(s.rules)
+}
+
+// Validate checks the Data of c against the schema and returns every violation joined.
+func (s *Schema) Validate(c *StandardClaims) error {
+	var errs []error
+	for _, rule := range s.rules {
+		value, ok := c.Data[rule.Name]
+		if !ok || value == nil {
+			if rule.Required {
+				errs = append(errs, fmt.Errorf("claim %q is required", rule.Name))
+			}
+			continue
+		}
+		if err := rule.check(value); err != nil {
+			errs = append(errs, fmt.Errorf("claim %q: %w", rule.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check validates value against the rule.
+func (r ClaimRule) check(value any) error {
+	switch r.Type {
+	case StringType:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %T", value)
+		}
+		if r.Required && str == "" {
+			return errors.New("must not be empty")
+		}
+		return r.checkAllowed(str)
+	case StringsType:
+		values, ok := toStrings(value)
+		if !ok {
+			return fmt.Errorf("expected a list of strings, got %T", value)
+		}
+		if r.Required && len(values) == 0 {
+			return errors.New("must not be empty")
+		}
+		for _, v := range values {
+			if err := r.checkAllowed(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case NumberType:
+		if _, ok := toFloat(value); !ok {
+			return fmt.Errorf("expected a number, got %T", value)
+		}
+		return nil
+	case BoolType:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected a bool, got %T", value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported claim type %q", r.Type)
+	}
+}
+
+// checkAllowed checks value is one of the allowed values of the rule, if any.
+func (r ClaimRule) checkAllowed(value string) error {
+	if len(r.Allowed) > 0 && !slices.Contains(r.Allowed, value) {
+		return fmt.Errorf("value %q is not allowed", value)
+	}
+	return nil
+}
+
+// Schemas holds the claim schemas by audience.
+type Schemas map[string]*Schema
+
+// NewSchemas indexes schemas by audience. A later schema replaces an earlier one of the
+// same audience.
+func NewSchemas(schemas ...*Schema) Schemas {
+	indexed := make(Schemas, len(schemas))
+	for _, schema := range schemas {
+		if schema != nil {
+			indexed[schema.audience] = schema
+		}
+	}
+	return indexed
+}
+
+// Validate checks c against the AnyAudience schema and the schema of its audience. Tokens of
+// an audience without a schema only need to satisfy the AnyAudience schema.
+func (s Schemas) Validate(c *StandardClaims) error {
+	var errs []error
+	if schema, ok := s[AnyAudience]; ok {
+		errs = append(errs, schema.Validate(c))
+	}
+	if c.Aud != AnyAudience {
+		if schema, ok := s[c.Aud]; ok {
+			errs = append(errs, schema.Validate(c))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithClaim sets the custom claim name in Data, keeping the other claims.
+func WithClaim(name string, value any) StandardClaimsOption {
+	return func(c *StandardClaims) {
+		if c.Data == nil {
+			c.Data = make(map[string]any)
+		}
+		c.Data[name] = value
+	}
+}
+
+// WithOrgID sets the org_id claim.
+func WithOrgID(orgID string) StandardClaimsOption {
+	return WithClaim(OrgIDClaim, orgID)
+}
+
+// WithRoles sets the roles claim.
+func WithRoles(roles ...string) StandardClaimsOption {
+	return WithClaim(RolesClaim, roles)
+}
+
+// WithScopes sets the scopes claim.
+func WithScopes(scopes ...string) StandardClaimsOption {
+	return WithClaim(ScopesClaim, scopes)
+}
+
+// StringClaim returns the string claim name, whether it is set and a string.
+func (c *StandardClaims) StringClaim(name string) (string, bool) {
+	value, ok := c.Data[name].(string)
+	return value, ok
+}
+
+// StringsClaim returns the list of strings claim name, whether it is set and a list of
+// strings. It accepts the []any lists of decoded tokens.
+func (c *StandardClaims) StringsClaim(name string) ([]string, bool) {
+	value, ok := c.Data[name]
+	if !ok {
+		return nil, false
+	}
+	return toStrings(value)
+}
+
+// OrgID returns the org_id claim, or "" when it is missing.
+func (c *StandardClaims) OrgID() string {
+	orgID, _ := c.StringClaim(OrgIDClaim)
+	return orgID
+}
+
+// Roles returns the roles claim, or nil when it is missing.
+func (c *StandardClaims) Roles() []string {
+	roles, _ := c.StringsClaim(RolesClaim)
+	return roles
+}
+
+// Scopes returns the scopes claim, or nil when it is missing.
+func (c *StandardClaims) Scopes() []string {
+	scopes, _ := c.StringsClaim(ScopesClaim)
+	return scopes
+}
+
+// HasScope reports whether the token carries scope.
+func (c *StandardClaims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// HasRole reports whether the token carries role.
+func (c *StandardClaims) HasRole(role string) bool {
+	return slices.Contains(c.Roles(), role)
+}
+
+// toStrings converts a []string, or a []any of strings, to a []string.
+func toStrings(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, str)
+		}
+		return values, true
+	default:
+		return nil, false
+	}
+}
+
+// toFloat converts the numeric types of value to a float64.
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,44 @@
+package claims
+
+import "strings"
+
+// ScopeWildcard is the action of a scope granting every action on its resource, e.g.
+// "orders:*" grants "orders:read" and "orders:write".
+const ScopeWildcard = "*"
+
+// ScopeGrants reports whether the granted scope grants required: the same scope, or the
+// ScopeWildcard scope of its resource.
+func ScopeGrants(granted, required string) bool {
+	if granted == required {
+		return true
+	}
+	resource, action, ok := strings.Cut(granted, ":")
+	if !ok || action != ScopeWildcard {
+		return false
+	}
+	return strings.HasPrefix(required, resource+":")
+}
+
+// MissingScopes returns the scopes of required the token does not grant, wildcards included.
+func (c *StandardClaims) MissingScopes(required ...string) []string {
+	granted := c.Scopes()
+	var missing []string
+	for _, scope := range required {
+		ok := false
+		for _, g := range granted {
+			if ScopeGrants(g, scope) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// GrantsScopes reports whether the token grants every scope of required, wildcards included.
+func (c *StandardClaims) GrantsScopes(required ...string) bool {
+	return len(c.MissingScopes(required...)) == 0
+}
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Optional holds a value that may be absent, explicitly null or set, so PATCH handlers can
+// tell a field left out of the body from one cleared with null or set to its zero value.
+//
+// When decoding JSON a field is absent unless its key appears. Absent and null both encode as
+// null; tag the field with omitzero to leave absent fields out instead. As a sql.Scanner and
+// driver.Valuer NULL maps to null.
+type Optional[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// Some returns an Optional set to value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, set: true}
+}
+
+// None returns an absent Optional.
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// Null returns an Optional explicitly set to null.
+func Null[T any]() Optional[T] {
+	return Optional[T]{set: true, null: true}
+}
+
+// FromPtr returns an Optional set to *ptr, or null when ptr is nil.
+func FromPtr[T any](ptr *T) Optional[T] {
+	if ptr == nil {
+		return Null[T]()
+	}
+	return Some(*ptr)
+}
+
+// Get returns the value and whether one is present.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.HasValue()
+}
+
+// OrElse returns the value, or fallback when absent or null.
+func (o Optional[T]) OrElse(fallback T) T {
+	if o.HasValue() {
+		return o.value
+	}
+	return fallback
+}
+
+// Ptr returns a pointer to a copy of the value, or nil when absent or null.
+func (o Optional[T]) Ptr() *T {
+	if !o.HasValue() {
+		return nil
+	}
+	value := o.value
+	return &value
+}
+
+// IsSet reports whether the field was provided, either with a value or as null.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull reports whether the field was explicitly set to null.
+func (o Optional[T]) IsNull() bool {
+	return o.set && o.null
+}
+
+// HasValue reports whether the field holds a non-null value.
+func (o Optional[T]) HasValue() bool {
+	return o.set && !o.null
+}
+
+// IsZero reports whether the field is absent, so omitzero leaves it out of JSON.
+func (o Optional[T]) IsZero() bool {
+	return !o.set
+}
+
+// IsEmpty implements EmptyCheck: absent and null Optionals are empty.
+func (o Optional[T]) IsEmpty() bool {
+	return !o.HasValue()
+}
+
+// Set stores value.
+func (o *Optional[T]) Set(value T) {
+	*o = Some(value)
+}
+
+// SetNull marks the field as explicitly null.
+func (o *Optional[T]) SetNull() {
+	*o = Null[T]()
+}
+
+// Unset marks the field as absent.
+func (o *Optional[T]) Unset() {
+	*o = None[T]()
+}
+
+// String implements fmt.Stringer.
+func (o Optional[T]) String() string {
+	switch {
+	case !o.set:
+		return "<absent>"
+	case o.null:
+		return "<null>"
+	default:
+		return fmt.Sprint(o.value)
+	}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.HasValue() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for keys present in the
+// input, which is what marks the field as set.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.SetNull()
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	o.Set(value)
+	return nil
+}
+
+// Scan implements sql.Scanner.
+func (o *Optional[T]) Scan(src any) error {
+	var null sql.Null[T]
+	if err := null.Scan(src); err != nil {
+		return err
+	}
+	if !null.Valid {
+		o.SetNull()
+		return nil
+	}
+	o.Set(null.V)
+	return nil
+}
+
+// Value implements driver.Valuer. Absent and null values are written as NULL.
+func (o Optional[T]) Value() (driver.Value, error) {
+	if !o.HasValue() {
+		return nil, nil
+	}
+	return sql.Null[T]{V: o.value, Valid: true}.Value()
+}
+
+// Nullable scalar wrappers.
+type (
+	NullString  = Optional[string]
+	NullInt     = Optional[int]
+	NullInt32   = Optional[int32]
+	NullInt64   = Optional[int64]
+	NullFloat64 = Optional[float64]
+	NullBool    = Optional[bool]
+	NullTime    = Optional[time.Time]
+)